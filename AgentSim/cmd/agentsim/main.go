@@ -1,23 +1,18 @@
 package main
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"flag"
 	"fmt"
-	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
-	"sync"
 	"syscall"
 	"time"
 
-	"github.com/dennisdiepolder/monti/agentsim/internal/agent"
-	"github.com/dennisdiepolder/monti/agentsim/internal/callgen"
-	"github.com/dennisdiepolder/monti/agentsim/internal/control"
-	agentTypes "github.com/dennisdiepolder/monti/agentsim/internal/types"
+	"github.com/dennisdiepolder/monti/agentsim/internal/metrics"
+	"github.com/dennisdiepolder/monti/agentsim/internal/tracing"
+	"github.com/dennisdiepolder/monti/agentsim/pkg/sim"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -50,18 +45,14 @@ func getEnvBool(key string, fallback bool) bool {
 	return fallback
 }
 
-type App struct {
-	generator     *agent.Generator
-	simulator     *agent.Simulator
-	callGenerator *callgen.CallGenerator
-	controlAPI    *control.API
-	ctx           context.Context    // root context — only cancelled on process shutdown
-	cancel        context.CancelFunc // root cancel
-	simCtx        context.Context    // simulation context — cancelled on stop
-	simCancel     context.CancelFunc // simulation cancel
-	mu            sync.Mutex
-	logger        zerolog.Logger
-	backendURL    string
+// getEnvDuration returns the environment variable as duration or fallback
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+	}
+	return fallback
 }
 
 func main() {
@@ -73,18 +64,37 @@ func main() {
 		autoStart    = flag.Bool("auto-start", false, "Automatically start simulation")
 		activeAgents = flag.Int("active", 100, "Number of active agents (if auto-start is true)")
 		logLevel     = flag.String("log-level", "info", "Log level (debug, info, warn, error)")
+		authToken    = flag.String("internal-token", "", "Shared service token for backend /internal and /ws/agent routes")
+		taxonomyFile = flag.String("taxonomy-file", "", "JSON departments/VQs and weighted locations (same file as the backend's TAXONOMY_FILE)")
+		followCal    = flag.Bool("follow-calendar", true, "Take meetings and trainings from the backend's team calendar instead of at random")
+		followHours  = flag.Bool("follow-hours", true, "Drop call volume to near zero while the backend's business hours close a department")
+		remoteWrite  = flag.String("metrics-remote-write-url", "", "Push metrics to this Prometheus remote write endpoint")
+		statsdAddr   = flag.String("metrics-statsd-addr", "", "Push metrics to this statsd daemon (host:port, UDP)")
+		statsdFormat = flag.String("metrics-statsd-format", metrics.StatsdFormatDatadog, "Statsd line format (datadog or statsd)")
+		pushInterval = flag.Duration("metrics-push-interval", 15*time.Second, "Interval between metrics pushes")
 	)
 	flag.Parse()
 
 	// Environment variables override CLI flags
 	// AGENTSIM_CONTROL_PORT, AGENTSIM_BACKEND_URL, AGENTSIM_AGENTS,
-	// AGENTSIM_AUTO_START, AGENTSIM_ACTIVE_AGENTS, AGENTSIM_LOG_LEVEL
+	// AGENTSIM_AUTO_START, AGENTSIM_ACTIVE_AGENTS, AGENTSIM_LOG_LEVEL, AGENTSIM_INTERNAL_TOKEN,
+	// AGENTSIM_TAXONOMY_FILE, AGENTSIM_FOLLOW_CALENDAR, AGENTSIM_FOLLOW_HOURS,
+	// AGENTSIM_METRICS_REMOTE_WRITE_URL, AGENTSIM_METRICS_STATSD_ADDR,
+	// AGENTSIM_METRICS_STATSD_FORMAT, AGENTSIM_METRICS_PUSH_INTERVAL
 	*controlPort = getEnvString("AGENTSIM_CONTROL_PORT", *controlPort)
 	*backendURL = getEnvString("AGENTSIM_BACKEND_URL", *backendURL)
 	*agentCount = getEnvInt("AGENTSIM_AGENTS", *agentCount)
 	*autoStart = getEnvBool("AGENTSIM_AUTO_START", *autoStart)
 	*activeAgents = getEnvInt("AGENTSIM_ACTIVE_AGENTS", *activeAgents)
 	*logLevel = getEnvString("AGENTSIM_LOG_LEVEL", *logLevel)
+	*authToken = getEnvString("AGENTSIM_INTERNAL_TOKEN", *authToken)
+	*taxonomyFile = getEnvString("AGENTSIM_TAXONOMY_FILE", *taxonomyFile)
+	*followCal = getEnvBool("AGENTSIM_FOLLOW_CALENDAR", *followCal)
+	*followHours = getEnvBool("AGENTSIM_FOLLOW_HOURS", *followHours)
+	*remoteWrite = getEnvString("AGENTSIM_METRICS_REMOTE_WRITE_URL", *remoteWrite)
+	*statsdAddr = getEnvString("AGENTSIM_METRICS_STATSD_ADDR", *statsdAddr)
+	*statsdFormat = getEnvString("AGENTSIM_METRICS_STATSD_FORMAT", *statsdFormat)
+	*pushInterval = getEnvDuration("AGENTSIM_METRICS_PUSH_INTERVAL", *pushInterval)
 
 	// Setup logger
 	level, err := zerolog.ParseLevel(*logLevel)
@@ -99,46 +109,54 @@ func main() {
 
 	logger.Info().Msg("starting AgentSim service")
 
-	// Create application
-	app := &App{
-		logger:     logger,
-		backendURL: *backendURL,
+	// Export OpenTelemetry traces when OTEL_EXPORTER_OTLP_ENDPOINT is set
+	shutdownTracing, err := tracing.Setup(context.Background(), "monti-agentsim")
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to initialize tracing")
 	}
-	app.ctx, app.cancel = context.WithCancel(context.Background())
-
-	// Generate agents (always 2000: 500 per department)
-	logger.Info().Msg("generating agents (500 per department)")
-	app.generator = agent.NewGenerator(time.Now().UnixNano())
-	agents := app.generator.GenerateAgents(0) // count ignored, always 2000
-	logger.Info().Int("generated", len(agents)).Msg("agents generated")
 
-	// POST roster to backend so all agents are pre-registered (retry until backend is reachable)
-	go postRoster(logger, *backendURL, agents)
-
-	// Create simulator
-	app.simulator = agent.NewSimulator(agents, *backendURL, logger)
-
-	// Create call generator
-	callAPIClient := callgen.NewCallAPIClient(*backendURL)
-	app.callGenerator = callgen.NewCallGenerator(callAPIClient)
+	// Create application
+	ctx, cancel := context.WithCancel(context.Background())
+	app, err := sim.New(ctx, sim.Options{
+		BackendURL:     *backendURL,
+		InternalToken:  *authToken,
+		TaxonomyFile:   *taxonomyFile,
+		FollowCalendar: *followCal,
+		FollowHours:    *followHours,
+	}, logger)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create AgentSim")
+	}
 
-	// Create control API
-	app.controlAPI = control.NewAPI(logger)
-	app.controlAPI.SetTotalAgents(len(agents))
-	app.controlAPI.SetHandlers(
-		app.startSimulation,
-		app.stopSimulation,
-		app.scaleSimulation,
-		app.getStats,
-		app.getMetrics,
-	)
-	app.controlAPI.SetCallGenerator(app.callGenerator)
-	app.controlAPI.SetCallAPIClient(callAPIClient, *backendURL)
+	// Push metrics when a remote write endpoint or statsd daemon is configured
+	pusher := metrics.NewPusher(func() []metrics.Sample {
+		return metrics.SamplesFromMap(app.Metrics())
+	}, *pushInterval, logger)
+	if *remoteWrite != "" {
+		instance, _ := os.Hostname()
+		pusher.AddSink(metrics.NewRemoteWriteSink(*remoteWrite, map[string]string{"job": "monti-agentsim", "instance": instance}))
+	}
+	if *statsdAddr != "" {
+		if *statsdFormat != metrics.StatsdFormatDatadog && *statsdFormat != metrics.StatsdFormatPlain {
+			logger.Fatal().Str("format", *statsdFormat).Msg("metrics statsd format must be datadog or statsd")
+		}
+		pusher.AddSink(metrics.NewStatsdSink(*statsdAddr, *statsdFormat))
+	}
+	pushDone := make(chan struct{})
+	if pusher.Enabled() {
+		logger.Info().Dur("interval", *pushInterval).Msg("pushing metrics")
+		go func() {
+			pusher.Run(ctx)
+			close(pushDone)
+		}()
+	} else {
+		close(pushDone)
+	}
 
 	// Start control API
 	go func() {
 		addr := fmt.Sprintf(":%s", *controlPort)
-		if err := app.controlAPI.Start(app.ctx, addr); err != nil {
+		if err := app.ListenAndServe(addr); err != nil {
 			logger.Error().Err(err).Msg("control API stopped")
 		}
 	}()
@@ -146,7 +164,7 @@ func main() {
 	// Auto-start if requested
 	if *autoStart {
 		logger.Info().Int("active_agents", *activeAgents).Msg("auto-starting simulation")
-		if err := app.startSimulation(*activeAgents); err != nil {
+		if err := app.Start(*activeAgents); err != nil {
 			logger.Error().Err(err).Msg("failed to auto-start simulation")
 		}
 	}
@@ -165,110 +183,14 @@ func main() {
 	<-sigChan
 
 	logger.Info().Msg("shutting down AgentSim")
-	app.cancel()
+	cancel()
 	time.Sleep(1 * time.Second)
-}
-
-func (app *App) startSimulation(activeAgents int) error {
-	app.mu.Lock()
-	defer app.mu.Unlock()
-
-	app.logger.Info().Int("active_agents", activeAgents).Msg("starting simulation")
-
-	// Create a child context for this simulation run
-	app.simCtx, app.simCancel = context.WithCancel(app.ctx)
-
-	// Start simulator
-	go app.simulator.Start(app.simCtx, activeAgents)
-
-	// Start call generator (generates calls and posts to backend)
-	go app.callGenerator.Run(app.simCtx)
-
-	return nil
-}
-
-func (app *App) stopSimulation() error {
-	app.mu.Lock()
-	defer app.mu.Unlock()
+	<-pushDone
 
-	app.logger.Info().Msg("stopping simulation")
-
-	// Stop the simulator (cancels agent goroutines and closes connections)
-	app.simulator.Stop()
-
-	// Cancel the simulation context to stop call generator and any other sim goroutines
-	if app.simCancel != nil {
-		app.simCancel()
-		app.simCancel = nil
-	}
-
-	return nil
-}
-
-func (app *App) scaleSimulation(targetAgents int) error {
-	app.mu.Lock()
-	defer app.mu.Unlock()
-
-	app.logger.Info().Int("target_agents", targetAgents).Msg("scaling simulation")
-
-	if app.simCtx == nil {
-		return fmt.Errorf("simulation not running")
-	}
-
-	// Scale the simulator to the target number of agents
-	return app.simulator.Scale(app.simCtx, targetAgents)
-}
-
-func (app *App) getStats() map[string]interface{} {
-	return map[string]interface{}{
-		"active_agents": app.simulator.GetActiveCount(),
-		"events_sent":   app.simulator.GetEventsSent(),
-	}
-}
-
-func (app *App) getMetrics() map[string]interface{} {
-	return app.simulator.GetMetrics()
-}
-
-// rosterEntry is the JSON payload for each agent in the roster POST
-type rosterEntry struct {
-	AgentID    string               `json:"agentId"`
-	Department agentTypes.Department `json:"department"`
-	Location   agentTypes.Location   `json:"location"`
-	Team       string               `json:"team"`
-}
-
-// postRoster sends the full agent roster to the backend with retry
-func postRoster(logger zerolog.Logger, backendURL string, agents []agentTypes.Agent) {
-	roster := make([]rosterEntry, len(agents))
-	for i, a := range agents {
-		roster[i] = rosterEntry{
-			AgentID:    a.ID,
-			Department: a.Department,
-			Location:   a.Location,
-			Team:       a.Team,
-		}
-	}
-
-	body, err := json.Marshal(roster)
-	if err != nil {
-		logger.Fatal().Err(err).Msg("failed to marshal roster")
-	}
-
-	url := backendURL + "/internal/agents/roster"
-	for {
-		resp, err := http.Post(url, "application/json", bytes.NewReader(body))
-		if err == nil {
-			resp.Body.Close()
-			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-				logger.Info().Int("agents", len(roster)).Msg("roster posted to backend")
-				return
-			}
-			logger.Warn().Int("status", resp.StatusCode).Msg("roster POST failed, retrying...")
-		} else {
-			logger.Warn().Err(err).Msg("backend not reachable for roster, retrying...")
-		}
-		time.Sleep(2 * time.Second)
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer flushCancel()
+	if err := shutdownTracing(flushCtx); err != nil {
+		logger.Warn().Err(err).Msg("failed to flush traces")
 	}
 }
 
@@ -6,9 +6,12 @@ import (
 	"sync"
 	"time"
 
+	"github.com/dennisdiepolder/monti/agentsim/internal/backendauth"
+	"github.com/dennisdiepolder/monti/agentsim/internal/tracing"
 	"github.com/dennisdiepolder/monti/agentsim/internal/types"
 	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -21,16 +24,23 @@ const (
 	// Reconnect backoff
 	initialReconnectDelay = 1 * time.Second
 	maxReconnectDelay     = 30 * time.Second
+
+	// How long an agent waits for the backend to answer a break request
+	breakResponseTimeout = 5 * time.Second
 )
 
 // AgentConnection manages the WebSocket connection for a single agent
 type AgentConnection struct {
 	agent          *types.Agent
 	conn           *websocket.Conn
-	send           chan []byte
-	callAssignCh   chan types.CallAssignMsg // incoming call assignments
-	forceEndCallCh chan string              // incoming force_end_call (callID)
-	forceDisconnCh chan struct{}            // incoming force_disconnect
+	send           chan any // messages, numbered and marshaled when written
+	seq            sequence
+	callAssignCh   chan types.CallAssignMsg    // incoming call assignments
+	forceEndCallCh chan string                 // incoming force_end_call (callID)
+	forceDisconnCh chan struct{}               // incoming force_disconnect
+	forceStateCh   chan types.AgentState       // incoming force_state (target state)
+	breakRespCh    chan types.BreakResponseMsg // incoming break_response
+	throttleCh     chan time.Duration          // incoming throttle (heartbeat interval)
 	done           chan struct{}
 	logger         zerolog.Logger
 	backendURL     string
@@ -48,10 +58,14 @@ type AgentConnection struct {
 func NewAgentConnection(agent *types.Agent, backendURL string, logger zerolog.Logger) *AgentConnection {
 	return &AgentConnection{
 		agent:          agent,
-		send:           make(chan []byte, 64),
+		send:           make(chan any, 64),
+		seq:            make(sequence),
 		callAssignCh:   make(chan types.CallAssignMsg, 4),
 		forceEndCallCh: make(chan string, 1),
 		forceDisconnCh: make(chan struct{}, 1),
+		forceStateCh:   make(chan types.AgentState, 1),
+		breakRespCh:    make(chan types.BreakResponseMsg, 1),
+		throttleCh:     make(chan time.Duration, 1),
 		done:           make(chan struct{}),
 		logger:         logger.With().Str("agent_id", agent.ID).Logger(),
 		backendURL:     backendURL,
@@ -73,6 +87,16 @@ func (ac *AgentConnection) GetForceDisconnectChan() <-chan struct{} {
 	return ac.forceDisconnCh
 }
 
+// GetForceStateChan returns the channel where force_state messages arrive
+func (ac *AgentConnection) GetForceStateChan() <-chan types.AgentState {
+	return ac.forceStateCh
+}
+
+// GetBreakResponseChan returns the channel where break_response messages arrive
+func (ac *AgentConnection) GetBreakResponseChan() <-chan types.BreakResponseMsg {
+	return ac.breakRespCh
+}
+
 // Run starts the connection and maintains it
 func (ac *AgentConnection) Run(ctx context.Context) {
 	reconnectDelay := initialReconnectDelay
@@ -141,7 +165,7 @@ func (ac *AgentConnection) connect() error {
 		wsURL = "ws" + wsURL[4:]
 	}
 
-	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, backendauth.Header())
 	if err != nil {
 		return err
 	}
@@ -191,6 +215,8 @@ func (ac *AgentConnection) runLoop(ctx context.Context) {
 			return
 		case <-heartbeatTicker.C:
 			ac.sendHeartbeat()
+		case interval := <-ac.throttleCh:
+			heartbeatTicker.Reset(interval)
 		case msg := <-ac.send:
 			ac.writeMessage(msg)
 		}
@@ -208,12 +234,7 @@ func (ac *AgentConnection) sendRegister() {
 		State:      ac.agent.State,
 		KPIs:       ac.agent.KPIs,
 	}
-	data, err := json.Marshal(reg)
-	if err != nil {
-		ac.logger.Error().Err(err).Msg("failed to marshal register message")
-		return
-	}
-	ac.writeMessage(data)
+	ac.writeMessage(&reg)
 }
 
 // sendHeartbeat sends a heartbeat message
@@ -229,12 +250,7 @@ func (ac *AgentConnection) sendHeartbeat() {
 		Timestamp: time.Now(),
 		KPIs:      agent.KPIs,
 	}
-	data, err := json.Marshal(hb)
-	if err != nil {
-		ac.logger.Error().Err(err).Msg("failed to marshal heartbeat")
-		return
-	}
-	ac.writeMessage(data)
+	ac.writeMessage(&hb)
 	ac.heartbeatsSent++
 }
 
@@ -255,15 +271,16 @@ func (ac *AgentConnection) SendStateChange(prevState, newState types.AgentState,
 		Department:    agent.Department,
 		Location:      agent.Location,
 		Team:          agent.Team,
+		TraceParent: tracing.MessageTraceParent("agentsim.state_change", "",
+			attribute.String("agent_id", agent.ID),
+			attribute.String("state", string(newState))),
 	}
-	data, err := json.Marshal(msg)
-	if err != nil {
-		ac.logger.Error().Err(err).Msg("failed to marshal state change")
-		return
+	if newState == types.StateBreak {
+		msg.Reason = agent.BreakReason
 	}
 
 	select {
-	case ac.send <- data:
+	case ac.send <- &msg:
 		ac.stateChangesSent++
 	default:
 		ac.logger.Warn().Msg("send buffer full, dropping state change")
@@ -285,6 +302,7 @@ func (ac *AgentConnection) handleIncoming(message []byte) {
 		if err := json.Unmarshal(message, &ca); err != nil {
 			return
 		}
+		tracing.RememberCall(ca.CallID, ca.TraceParent)
 		select {
 		case ac.callAssignCh <- ca:
 		default:
@@ -302,18 +320,88 @@ func (ac *AgentConnection) handleIncoming(message []byte) {
 		case ac.forceEndCallCh <- msg.CallID:
 		default:
 		}
-	case "force_disconnect":
-		ac.logger.Info().Msg("received force_disconnect")
+	case "force_state":
+		var msg struct {
+			State types.AgentState `json:"state"`
+		}
+		if err := json.Unmarshal(message, &msg); err != nil {
+			return
+		}
+		ac.logger.Info().Str("state", string(msg.State)).Msg("received force_state")
+		select {
+		case ac.forceStateCh <- msg.State:
+		default:
+		}
+	case "force_disconnect", "superseded":
+		// superseded: a newer connection of this agent took over; stop without reconnecting
+		ac.logger.Info().Str("type", msgType.Type).Msg("received force_disconnect")
 		select {
 		case ac.forceDisconnCh <- struct{}{}:
 		default:
 		}
 		ac.Close()
+	case "break_response":
+		var br types.BreakResponseMsg
+		if err := json.Unmarshal(message, &br); err != nil {
+			return
+		}
+		select {
+		case ac.breakRespCh <- br:
+		default:
+		}
+	case "throttle":
+		interval, ok := parseThrottle(message)
+		if !ok {
+			return
+		}
+		ac.logger.Debug().Dur("heartbeat_interval", interval).Msg("received throttle")
+		offerLatest(ac.throttleCh, interval)
 	case "ack":
 		// Ignore acks
 	}
 }
 
+// parseThrottle returns the heartbeat interval a throttle message asks for; a
+// heartbeatInterval of 0 restores the default
+func parseThrottle(message []byte) (time.Duration, bool) {
+	var msg struct {
+		HeartbeatInterval int `json:"heartbeatInterval"`
+	}
+	if err := json.Unmarshal(message, &msg); err != nil || msg.HeartbeatInterval < 0 {
+		return 0, false
+	}
+	if msg.HeartbeatInterval == 0 {
+		return heartbeatInterval, true
+	}
+	return time.Duration(msg.HeartbeatInterval) * time.Second, true
+}
+
+// offerLatest puts v on a channel of capacity 1, replacing a value not yet taken
+func offerLatest[T any](ch chan T, v T) {
+	for {
+		select {
+		case ch <- v:
+			return
+		default:
+		}
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
+
+// SendBreakRequest asks the backend whether the agent may go on break; the answer
+// arrives on GetBreakResponseChan. Returns false when the request could not be queued.
+func (ac *AgentConnection) SendBreakRequest(reason types.BreakReason) bool {
+	select {
+	case ac.send <- &types.BreakRequestMsg{Type: "break_request", AgentID: ac.agent.ID, Reason: reason}:
+		return true
+	default:
+		return false
+	}
+}
+
 // SendCallComplete sends a call_complete message
 func (ac *AgentConnection) SendCallComplete(callID string, talkTime, holdTime float64) {
 	msg := types.CallCompleteMsg{
@@ -323,26 +411,43 @@ func (ac *AgentConnection) SendCallComplete(callID string, talkTime, holdTime fl
 		TalkTime:  talkTime,
 		HoldTime:  holdTime,
 		Timestamp: time.Now(),
+		TraceParent: tracing.MessageTraceParent("agentsim.call_complete", tracing.TakeCall(callID),
+			attribute.String("agent_id", ac.agent.ID),
+			attribute.String("call_id", callID)),
 	}
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return
+
+	select {
+	case ac.send <- &msg:
+	default:
 	}
+}
 
+// SendCallHold sends a call_hold_start (onHold) or call_hold_end message
+func (ac *AgentConnection) SendCallHold(callID string, onHold bool) {
+	msgType := "call_hold_end"
+	if onHold {
+		msgType = "call_hold_start"
+	}
 	select {
-	case ac.send <- data:
+	case ac.send <- &types.CallHoldMsg{Type: msgType, AgentID: ac.agent.ID, CallID: callID, Timestamp: time.Now()}:
 	default:
 	}
 }
 
-// writeMessage writes a message to the WebSocket
-func (ac *AgentConnection) writeMessage(data []byte) {
+// writeMessage numbers a message and writes it to the WebSocket
+func (ac *AgentConnection) writeMessage(msg any) {
 	ac.mu.Lock()
 	defer ac.mu.Unlock()
 
 	if ac.conn == nil || !ac.connected {
 		return
 	}
+	ac.seq.stamp(msg)
+	data, err := json.Marshal(msg)
+	if err != nil {
+		ac.logger.Error().Err(err).Msg("failed to marshal message")
+		return
+	}
 
 	ac.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
 	if err := ac.conn.WriteMessage(websocket.TextMessage, data); err != nil {
@@ -3,6 +3,7 @@ package agent
 import (
 	"fmt"
 	"math/rand"
+	"strings"
 	"time"
 
 	"github.com/dennisdiepolder/monti/agentsim/internal/types"
@@ -10,38 +11,47 @@ import (
 
 // Generator creates and manages fake agents
 type Generator struct {
-	agents []types.Agent
-	rng    *rand.Rand
+	agents   []types.Agent
+	rng      *rand.Rand
+	taxonomy types.Taxonomy
 }
 
 // NewGenerator creates a new agent generator
 func NewGenerator(seed int64) *Generator {
 	return &Generator{
-		agents: make([]types.Agent, 0, 200),
-		rng:    rand.New(rand.NewSource(seed)),
+		agents:   make([]types.Agent, 0, 200),
+		rng:      rand.New(rand.NewSource(seed)),
+		taxonomy: types.DefaultTaxonomy(),
 	}
 }
 
+// SetTaxonomy sets the departments and weighted locations agents are generated for
+func (g *Generator) SetTaxonomy(t types.Taxonomy) {
+	g.taxonomy = t
+}
+
 // GenerateAgents creates agents with equal distribution across departments (500 per dept).
-// The count parameter is ignored; always generates 2000 agents.
+// The count parameter is ignored.
 func (g *Generator) GenerateAgents(count int) []types.Agent {
-	departments := []types.Department{
-		types.DeptSales,
-		types.DeptSupport,
-		types.DeptTechnical,
-		types.DeptRetention,
+	departments := make([]types.Department, len(g.taxonomy.Departments))
+	for i, d := range g.taxonomy.Departments {
+		departments[i] = d.Name
 	}
 
-	locations := []types.Location{
-		types.LocationBerlin,
-		types.LocationMunich,
-		types.LocationHamburg,
-		types.LocationFrankfurt,
-		types.LocationRemote,
+	locations := make([]types.Location, len(g.taxonomy.Locations))
+	locWeights := make([]int, len(g.taxonomy.Locations))
+	totalWeight := 0
+	for i, l := range g.taxonomy.Locations {
+		locations[i] = l.Name
+		locWeights[i] = l.Weight
+		totalWeight += l.Weight
+	}
+	if totalWeight == 0 {
+		// No weights configured: spread agents evenly
+		for i := range locWeights {
+			locWeights[i] = 1
+		}
 	}
-
-	// Distribution: 25% Berlin, 20% Munich, 15% Hamburg, 15% Frankfurt, 25% Remote
-	locWeights := []int{25, 20, 15, 15, 25}
 
 	perDept := 500
 	total := perDept * len(departments)
@@ -127,6 +137,10 @@ func (g *Generator) generateTeamName(dept types.Department, index int) string {
 	case types.DeptRetention:
 		return fmt.Sprintf("Retention-Team-%d", teamNum)
 	default:
-		return fmt.Sprintf("Team-%d", teamNum)
+		name := string(dept)
+		if name == "" {
+			return fmt.Sprintf("Team-%d", teamNum)
+		}
+		return fmt.Sprintf("%s-Team-%d", strings.ToUpper(name[:1])+name[1:], teamNum)
 	}
 }
@@ -6,9 +6,12 @@ import (
 	"sync"
 	"time"
 
+	"github.com/dennisdiepolder/monti/agentsim/internal/backendauth"
+	"github.com/dennisdiepolder/monti/agentsim/internal/tracing"
 	"github.com/dennisdiepolder/monti/agentsim/internal/types"
 	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // MultiplexedConnection manages a single WebSocket carrying events for N agents.
@@ -18,8 +21,12 @@ type MultiplexedConnection struct {
 	callbacks       map[string]chan types.CallAssignMsg   // agentID -> call assign channel
 	forceEndCalls   map[string]chan string                // agentID -> force end call channel
 	forceDisconns   map[string]chan struct{}              // agentID -> force disconnect channel
+	forceStates     map[string]chan types.AgentState      // agentID -> force state channel
+	breakResps      map[string]chan types.BreakResponseMsg // agentID -> break response channel
+	throttle        chan time.Duration                     // incoming throttle (heartbeat interval for all agents)
 	conn            *websocket.Conn
-	send            chan []byte
+	send            chan any // messages, numbered and marshaled when written
+	seq             sequence
 	logger          zerolog.Logger
 	backendURL      string
 	mu              sync.Mutex
@@ -37,11 +44,15 @@ func NewMultiplexedConnection(agents []*types.Agent, backendURL string, logger z
 	callbacks := make(map[string]chan types.CallAssignMsg, len(agents))
 	forceEndCalls := make(map[string]chan string, len(agents))
 	forceDisconns := make(map[string]chan struct{}, len(agents))
+	forceStates := make(map[string]chan types.AgentState, len(agents))
+	breakResps := make(map[string]chan types.BreakResponseMsg, len(agents))
 	for _, a := range agents {
 		agentMap[a.ID] = a
 		callbacks[a.ID] = make(chan types.CallAssignMsg, 4)
 		forceEndCalls[a.ID] = make(chan string, 1)
 		forceDisconns[a.ID] = make(chan struct{}, 1)
+		forceStates[a.ID] = make(chan types.AgentState, 1)
+		breakResps[a.ID] = make(chan types.BreakResponseMsg, 1)
 	}
 
 	return &MultiplexedConnection{
@@ -49,7 +60,11 @@ func NewMultiplexedConnection(agents []*types.Agent, backendURL string, logger z
 		callbacks:     callbacks,
 		forceEndCalls: forceEndCalls,
 		forceDisconns: forceDisconns,
-		send:          make(chan []byte, 256),
+		forceStates:   forceStates,
+		breakResps:    breakResps,
+		throttle:      make(chan time.Duration, 1),
+		send:          make(chan any, 256),
+		seq:           make(sequence),
 		logger:        logger.With().Int("mux_agents", len(agents)).Logger(),
 		backendURL:    backendURL,
 	}
@@ -76,6 +91,20 @@ func (mc *MultiplexedConnection) GetForceDisconnectChan(agentID string) <-chan s
 	return mc.forceDisconns[agentID]
 }
 
+// GetForceStateChan returns the channel where force_state messages arrive for an agent
+func (mc *MultiplexedConnection) GetForceStateChan(agentID string) <-chan types.AgentState {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	return mc.forceStates[agentID]
+}
+
+// GetBreakResponseChan returns the channel where break_response messages arrive for an agent
+func (mc *MultiplexedConnection) GetBreakResponseChan(agentID string) <-chan types.BreakResponseMsg {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	return mc.breakResps[agentID]
+}
+
 // Run connects and maintains the multiplexed WebSocket
 func (mc *MultiplexedConnection) Run(ctx context.Context) {
 	reconnectDelay := initialReconnectDelay
@@ -138,7 +167,7 @@ func (mc *MultiplexedConnection) connect() error {
 		wsURL = "ws" + wsURL[4:]
 	}
 
-	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, backendauth.Header())
 	if err != nil {
 		return err
 	}
@@ -167,11 +196,7 @@ func (mc *MultiplexedConnection) registerAll() {
 			State:      agent.State,
 			KPIs:       agent.KPIs,
 		}
-		data, err := json.Marshal(reg)
-		if err != nil {
-			continue
-		}
-		mc.writeMessage(data)
+		mc.writeMessage(&reg)
 	}
 }
 
@@ -179,11 +204,19 @@ func (mc *MultiplexedConnection) runLoop(ctx context.Context) {
 	heartbeatTicker := time.NewTicker(heartbeatInterval)
 	defer heartbeatTicker.Stop()
 
+	// Close may clear mc.conn at any time; the read loop keeps its own reference
+	mc.mu.Lock()
+	conn := mc.conn
+	mc.mu.Unlock()
+	if conn == nil {
+		return
+	}
+
 	readDone := make(chan struct{})
 	go func() {
 		defer close(readDone)
 		for {
-			_, message, err := mc.conn.ReadMessage()
+			_, message, err := conn.ReadMessage()
 			if err != nil {
 				return
 			}
@@ -199,6 +232,8 @@ func (mc *MultiplexedConnection) runLoop(ctx context.Context) {
 			return
 		case <-heartbeatTicker.C:
 			mc.sendHeartbeats()
+		case interval := <-mc.throttle:
+			heartbeatTicker.Reset(interval)
 		case msg := <-mc.send:
 			mc.writeMessage(msg)
 		}
@@ -220,6 +255,7 @@ func (mc *MultiplexedConnection) handleIncoming(message []byte) {
 		if err := json.Unmarshal(message, &ca); err != nil {
 			return
 		}
+		tracing.RememberCall(ca.CallID, ca.TraceParent)
 		mc.mu.Lock()
 		ch, ok := mc.callbacks[ca.AgentID]
 		mc.mu.Unlock()
@@ -247,7 +283,25 @@ func (mc *MultiplexedConnection) handleIncoming(message []byte) {
 			default:
 			}
 		}
-	case "force_disconnect":
+	case "force_state":
+		var msg struct {
+			AgentID string           `json:"agentId"`
+			State   types.AgentState `json:"state"`
+		}
+		if err := json.Unmarshal(message, &msg); err != nil {
+			return
+		}
+		mc.mu.Lock()
+		ch, ok := mc.forceStates[msg.AgentID]
+		mc.mu.Unlock()
+		if ok {
+			select {
+			case ch <- msg.State:
+			default:
+			}
+		}
+	case "force_disconnect", "superseded":
+		// superseded: a newer connection of this agent took over; stop the agent here
 		mc.mu.Lock()
 		ch, ok := mc.forceDisconns[msgType.AgentID]
 		mc.mu.Unlock()
@@ -257,6 +311,27 @@ func (mc *MultiplexedConnection) handleIncoming(message []byte) {
 			default:
 			}
 		}
+	case "break_response":
+		var br types.BreakResponseMsg
+		if err := json.Unmarshal(message, &br); err != nil {
+			return
+		}
+		mc.mu.Lock()
+		ch, ok := mc.breakResps[br.AgentID]
+		mc.mu.Unlock()
+		if ok {
+			select {
+			case ch <- br:
+			default:
+			}
+		}
+	case "throttle":
+		interval, ok := parseThrottle(message)
+		if !ok {
+			return
+		}
+		mc.logger.Debug().Dur("heartbeat_interval", interval).Msg("received throttle")
+		offerLatest(mc.throttle, interval)
 	case "ack":
 		// Ignore acks
 	}
@@ -278,11 +353,7 @@ func (mc *MultiplexedConnection) sendHeartbeats() {
 			Timestamp: time.Now(),
 			KPIs:      agent.KPIs,
 		}
-		data, err := json.Marshal(hb)
-		if err != nil {
-			continue
-		}
-		mc.writeMessage(data)
+		mc.writeMessage(&hb)
 		mc.heartbeatsSent++
 	}
 }
@@ -309,20 +380,33 @@ func (mc *MultiplexedConnection) SendStateChange(agentID string, prevState, newS
 		Department:    agentCopy.Department,
 		Location:      agentCopy.Location,
 		Team:          agentCopy.Team,
+		TraceParent: tracing.MessageTraceParent("agentsim.state_change", "",
+			attribute.String("agent_id", agentCopy.ID),
+			attribute.String("state", string(newState))),
 	}
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return
+	if newState == types.StateBreak {
+		msg.Reason = agentCopy.BreakReason
 	}
 
 	select {
-	case mc.send <- data:
+	case mc.send <- &msg:
 		mc.stateChangesSent++
 	default:
 		mc.logger.Warn().Str("agent_id", agentID).Msg("mux send buffer full")
 	}
 }
 
+// SendBreakRequest asks the backend whether an agent may go on break; the answer arrives
+// on GetBreakResponseChan. Returns false when the request could not be queued.
+func (mc *MultiplexedConnection) SendBreakRequest(agentID string, reason types.BreakReason) bool {
+	select {
+	case mc.send <- &types.BreakRequestMsg{Type: "break_request", AgentID: agentID, Reason: reason}:
+		return true
+	default:
+		return false
+	}
+}
+
 // SendCallComplete sends a call_complete message for a specific agent
 func (mc *MultiplexedConnection) SendCallComplete(agentID, callID string, talkTime, holdTime float64) {
 	msg := types.CallCompleteMsg{
@@ -332,25 +416,43 @@ func (mc *MultiplexedConnection) SendCallComplete(agentID, callID string, talkTi
 		TalkTime:  talkTime,
 		HoldTime:  holdTime,
 		Timestamp: time.Now(),
+		TraceParent: tracing.MessageTraceParent("agentsim.call_complete", tracing.TakeCall(callID),
+			attribute.String("agent_id", agentID),
+			attribute.String("call_id", callID)),
 	}
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return
+
+	select {
+	case mc.send <- &msg:
+	default:
 	}
+}
 
+// SendCallHold sends a call_hold_start (onHold) or call_hold_end message for a specific agent
+func (mc *MultiplexedConnection) SendCallHold(agentID, callID string, onHold bool) {
+	msgType := "call_hold_end"
+	if onHold {
+		msgType = "call_hold_start"
+	}
 	select {
-	case mc.send <- data:
+	case mc.send <- &types.CallHoldMsg{Type: msgType, AgentID: agentID, CallID: callID, Timestamp: time.Now()}:
 	default:
 	}
 }
 
-func (mc *MultiplexedConnection) writeMessage(data []byte) {
+// writeMessage numbers a message and writes it to the WebSocket
+func (mc *MultiplexedConnection) writeMessage(msg any) {
 	mc.mu.Lock()
 	defer mc.mu.Unlock()
 
 	if mc.conn == nil || !mc.connected {
 		return
 	}
+	mc.seq.stamp(msg)
+	data, err := json.Marshal(msg)
+	if err != nil {
+		mc.logger.Error().Err(err).Msg("failed to marshal message")
+		return
+	}
 
 	mc.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
 	if err := mc.conn.WriteMessage(websocket.TextMessage, data); err != nil {
@@ -379,6 +481,8 @@ func (mc *MultiplexedConnection) RemoveAgent(agentID string) {
 	delete(mc.callbacks, agentID)
 	delete(mc.forceEndCalls, agentID)
 	delete(mc.forceDisconns, agentID)
+	delete(mc.forceStates, agentID)
+	delete(mc.breakResps, agentID)
 }
 
 // UpdateAgent updates the agent data in the connection
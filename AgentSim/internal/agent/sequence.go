@@ -0,0 +1,30 @@
+package agent
+
+import "github.com/dennisdiepolder/monti/agentsim/internal/types"
+
+// sequence numbers each agent's messages in the order they are written to the backend. The
+// numbers keep counting across reconnects, so the backend can drop duplicates and messages
+// that arrive after newer ones; a register restarts its count there. Break requests are not
+// numbered: the backend answers them rather than applying them. Guarded by the connection's mu.
+type sequence map[string]uint64
+
+// stamp gives msg the next number of its agent
+func (s sequence) stamp(msg any) {
+	switch m := msg.(type) {
+	case *types.AgentRegister:
+		m.Seq = s.next(m.AgentID)
+	case *types.AgentHeartbeat:
+		m.Seq = s.next(m.AgentID)
+	case *types.AgentStateChangeMsg:
+		m.Seq = s.next(m.AgentID)
+	case *types.CallHoldMsg:
+		m.Seq = s.next(m.AgentID)
+	case *types.CallCompleteMsg:
+		m.Seq = s.next(m.AgentID)
+	}
+}
+
+func (s sequence) next(agentID string) uint64 {
+	s[agentID]++
+	return s[agentID]
+}
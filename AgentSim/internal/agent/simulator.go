@@ -20,7 +20,7 @@ type Simulator struct {
 	muxConns     []*MultiplexedConnection
 	useMultiplex bool
 	mu           sync.RWMutex
-	rng          *rand.Rand
+	rng          *rand.Rand // shared by all agent goroutines, so backed by a lockedSource
 	logger       zerolog.Logger
 	backendURL   string
 	running      bool
@@ -31,9 +31,8 @@ type Simulator struct {
 	agentCalls   map[string]*activeCall // agentID -> current call
 	callMu       sync.RWMutex
 
-	// Break tracking per department
-	breakCounts  map[types.Department]int
-	breakMu      sync.Mutex
+	// Team meetings and trainings; nil for random ones
+	calendar CalendarSource
 
 	// Metrics
 	startTime         time.Time
@@ -42,6 +41,14 @@ type Simulator struct {
 	stateMu           sync.RWMutex
 }
 
+// CalendarSource reports the meeting or training a team has at a time (implemented by calendar.Follower)
+type CalendarSource interface {
+	Active(team string, at time.Time) (types.CalendarEvent, bool)
+}
+
+// callHoldProbability is the share of calls whose caller is put on hold once
+const callHoldProbability = 0.3
+
 // activeCall tracks the current call being handled by an agent
 type activeCall struct {
 	CallID    string
@@ -50,6 +57,30 @@ type activeCall struct {
 	HoldTime  float64
 }
 
+// lockedSource serialises a rand.Source; rand.Rand is not safe for concurrent use on its own
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source64
+}
+
+func (l *lockedSource) Int63() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.src.Int63()
+}
+
+func (l *lockedSource) Uint64() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.src.Uint64()
+}
+
+func (l *lockedSource) Seed(seed int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.src.Seed(seed)
+}
+
 // NewSimulator creates a new agent simulator
 func NewSimulator(agents []types.Agent, backendURL string, logger zerolog.Logger) *Simulator {
 	return &Simulator{
@@ -58,11 +89,10 @@ func NewSimulator(agents []types.Agent, backendURL string, logger zerolog.Logger
 		agentCancels:      make(map[string]context.CancelFunc),
 		connections:       make(map[string]*AgentConnection),
 		useMultiplex:      true, // Use multiplexed connections by default
-		rng:               rand.New(rand.NewSource(time.Now().UnixNano())),
+		rng:               rand.New(&lockedSource{src: rand.NewSource(time.Now().UnixNano()).(rand.Source64)}),
 		logger:            logger,
 		backendURL:        backendURL,
 		agentCalls:        make(map[string]*activeCall),
-		breakCounts:       make(map[types.Department]int),
 		startTime:         time.Now(),
 		stateChangeCounts: make(map[types.AgentState]int64),
 	}
@@ -316,75 +346,42 @@ func (s *Simulator) simulateAgent(ctx context.Context, agentID string) {
 				s.handleAvailable(ctx, agentID, agent)
 
 			case types.StateOnCall:
-				// Talk duration: 3-30 min
+				// Talk duration: 3-30 min, some callers put on hold once for 10-40s
 				talkDuration := time.Duration(180+s.rng.Intn(1620)) * time.Second
-
-				// Get force_end_call channel
-				forceEndCh := s.getForceEndCallChan(agentID)
-
-				select {
-				case <-ctx.Done():
+				var holdDuration time.Duration
+				if s.rng.Float64() < callHoldProbability {
+					holdDuration = time.Duration(10+s.rng.Intn(30)) * time.Second
+				}
+				if !s.talkCall(ctx, agentID, talkDuration, holdDuration) {
 					return
-				case <-time.After(talkDuration):
-					// Normal call completion
-					s.completeCall(agentID, talkDuration.Seconds())
-					s.updateAgentState(agentID, types.StateAfterCallWork)
-				case <-forceEndCh:
-					// Call was force-ended by supervisor
-					s.callMu.Lock()
-					delete(s.agentCalls, agentID)
-					s.callMu.Unlock()
-					s.updateAgentState(agentID, types.StateAvailable)
 				}
 
 			case types.StateAfterCallWork:
 				// ACW: 30s - 4min
 				acwDuration := time.Duration(30+s.rng.Intn(210)) * time.Second
-				select {
-				case <-ctx.Done():
-					return
-				case <-time.After(acwDuration):
-				}
-				s.updateAgentState(agentID, types.StateAvailable)
+				s.finishState(ctx, agentID, acwDuration)
 
 			case types.StateBreak:
 				duration := time.Duration(300+s.rng.Intn(300)) * time.Second // 5-10min
-				select {
-				case <-ctx.Done():
-					return
-				case <-time.After(duration):
-				}
-				s.breakMu.Lock()
-				s.breakCounts[agent.Department]--
-				s.breakMu.Unlock()
-				s.updateAgentState(agentID, types.StateAvailable)
+				s.finishState(ctx, agentID, duration)
 
 			case types.StateLunch:
 				duration := time.Duration(1800+s.rng.Intn(1800)) * time.Second
-				select {
-				case <-ctx.Done():
-					return
-				case <-time.After(duration):
-				}
-				s.updateAgentState(agentID, types.StateAvailable)
+				s.finishState(ctx, agentID, duration)
 
 			case types.StateMeeting:
 				duration := time.Duration(600+s.rng.Intn(1800)) * time.Second
-				select {
-				case <-ctx.Done():
-					return
-				case <-time.After(duration):
+				if d, ok := s.calendarDuration(agent.Team, agent.State); ok {
+					duration = d
 				}
-				s.updateAgentState(agentID, types.StateAvailable)
+				s.finishState(ctx, agentID, duration)
 
 			case types.StateTraining:
 				duration := time.Duration(1800+s.rng.Intn(3600)) * time.Second
-				select {
-				case <-ctx.Done():
-					return
-				case <-time.After(duration):
+				if d, ok := s.calendarDuration(agent.Team, agent.State); ok {
+					duration = d
 				}
-				s.updateAgentState(agentID, types.StateAvailable)
+				s.finishState(ctx, agentID, duration)
 
 			default:
 				// For any other state, wait a bit and go available
@@ -398,6 +395,14 @@ func (s *Simulator) simulateAgent(ctx context.Context, agentID string) {
 
 // handleAvailable waits for a call_assign or self-transitions to break
 func (s *Simulator) handleAvailable(ctx context.Context, agentID string, agent *types.Agent) {
+	// Join the team's meeting or training if one is running
+	if s.calendar != nil {
+		if ev, ok := s.calendar.Active(agent.Team, time.Now()); ok {
+			s.updateAgentState(agentID, ev.Activity)
+			return
+		}
+	}
+
 	// Get the call assign channel
 	var callAssignCh <-chan types.CallAssignMsg
 
@@ -426,8 +431,9 @@ func (s *Simulator) handleAvailable(ctx context.Context, agentID string, agent *
 	breakTimer := time.NewTimer(time.Duration(5+s.rng.Intn(10)) * time.Second)
 	defer breakTimer.Stop()
 
-	// Get force_disconnect channel
+	// Get force_disconnect and force_state channels
 	forceDisconnCh := s.getForceDisconnectChan(agentID)
+	forceStateCh := s.getForceStateChan(agentID)
 
 	select {
 	case <-ctx.Done():
@@ -449,16 +455,21 @@ func (s *Simulator) handleAvailable(ctx context.Context, agentID string, agent *
 		s.forceRemoveAgent(agentID)
 		return
 
+	case state := <-forceStateCh:
+		// Supervisor moved the agent to another state
+		s.updateAgentState(agentID, state)
+
 	case <-breakTimer.C:
-		// Decide whether to take a break (with cap at ~5% of dept agents)
+		// Decide whether to take a break (the backend enforces the department break caps)
 		roll := s.rng.Float64()
 		if roll < 0.15 { // 15% chance to take a break when timer fires
-			if s.canTakeBreak(agent.Department) {
-				s.breakMu.Lock()
-				s.breakCounts[agent.Department]++
-				s.breakMu.Unlock()
+			reason := s.pickBreakReason()
+			if s.requestBreak(ctx, agentID, reason) {
+				s.setBreakReason(agentID, reason)
 				s.updateAgentState(agentID, types.StateBreak)
 			}
+		} else if s.calendar != nil {
+			// Meetings and trainings come from the calendar
 		} else if roll < 0.20 {
 			s.updateAgentState(agentID, types.StateMeeting)
 		} else if roll < 0.22 {
@@ -468,31 +479,162 @@ func (s *Simulator) handleAvailable(ctx context.Context, agentID string, agent *
 	}
 }
 
-// canTakeBreak checks if agent's department is under the ~5% break cap
-func (s *Simulator) canTakeBreak(dept types.Department) bool {
-	s.breakMu.Lock()
-	currentOnBreak := s.breakCounts[dept]
-	s.breakMu.Unlock()
+// SetCalendar makes agents follow their team's calendar instead of taking random
+// meetings and trainings (call before Start)
+func (s *Simulator) SetCalendar(c CalendarSource) {
+	s.calendar = c
+}
+
+// calendarDuration returns how long the team's running event keeps the agent in state
+func (s *Simulator) calendarDuration(team string, state types.AgentState) (time.Duration, bool) {
+	if s.calendar == nil {
+		return 0, false
+	}
+	ev, ok := s.calendar.Active(team, time.Now())
+	if !ok || ev.Activity != state {
+		return 0, false
+	}
+	return time.Until(ev.End), true
+}
+
+// pickBreakReason picks the reason code for a self-initiated break
+func (s *Simulator) pickBreakReason() types.BreakReason {
+	roll := s.rng.Float64()
+	switch {
+	case roll < 0.60:
+		return types.BreakRest
+	case roll < 0.85:
+		return types.BreakPersonal
+	case roll < 0.95:
+		return types.BreakWellness
+	default:
+		return types.BreakTechnical
+	}
+}
+
+// requestBreak asks the backend whether the agent may go on break and waits up to
+// breakResponseTimeout for the answer. No answer counts as refused.
+func (s *Simulator) requestBreak(ctx context.Context, agentID string, reason types.BreakReason) bool {
+	var respCh <-chan types.BreakResponseMsg
+	var send func() bool
 
-	// Count total active agents in department
 	s.mu.RLock()
-	total := 0
-	for _, agent := range s.agents {
-		if s.activeAgents[agent.ID] && agent.Department == dept {
-			total++
+	if conn, ok := s.connections[agentID]; ok {
+		respCh = conn.GetBreakResponseChan()
+		send = func() bool { return conn.SendBreakRequest(reason) }
+	} else {
+		for _, mux := range s.muxConns {
+			if ch := mux.GetBreakResponseChan(agentID); ch != nil {
+				mux := mux
+				respCh = ch
+				send = func() bool { return mux.SendBreakRequest(agentID, reason) }
+				break
+			}
 		}
 	}
 	s.mu.RUnlock()
 
-	if total == 0 {
+	if respCh == nil {
+		return false
+	}
+	// Drop a late answer to an earlier request that timed out
+	select {
+	case <-respCh:
+	default:
+	}
+	if !send() {
 		return false
 	}
 
-	maxBreak := total * 5 / 100
-	if maxBreak < 1 {
-		maxBreak = 1
+	timer := time.NewTimer(breakResponseTimeout)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		s.logger.Debug().Str("agent_id", agentID).Msg("break request timed out")
+		return false
+	case resp := <-respCh:
+		if !resp.Granted {
+			s.logger.Debug().Str("agent_id", agentID).Str("reason", string(reason)).Str("message", resp.Message).Msg("break refused")
+		}
+		return resp.Granted
 	}
-	return currentOnBreak < maxBreak
+}
+
+// setBreakReason records the reason code sent with the agent's next state change to break
+func (s *Simulator) setBreakReason(agentID string, reason types.BreakReason) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.agents {
+		if s.agents[i].ID == agentID {
+			s.agents[i].BreakReason = reason
+			return
+		}
+	}
+}
+
+// talkCall runs the agent's current call for talk, putting the caller on hold halfway through
+// for hold if it is set. Returns false when ctx is done.
+func (s *Simulator) talkCall(ctx context.Context, agentID string, talk, hold time.Duration) bool {
+	forceEndCh := s.getForceEndCallChan(agentID)
+
+	phases := []time.Duration{talk}
+	if hold > 0 {
+		phases = []time.Duration{talk / 2, hold, talk - talk/2}
+	}
+	for i, d := range phases {
+		onHold := hold > 0 && i == 1
+		if onHold {
+			s.holdCall(agentID, true, 0)
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(d):
+		case <-forceEndCh:
+			// Call was force-ended by supervisor, ending a hold with it
+			s.callMu.Lock()
+			delete(s.agentCalls, agentID)
+			s.callMu.Unlock()
+			s.updateAgentState(agentID, types.StateAvailable)
+			return true
+		}
+		if onHold {
+			s.holdCall(agentID, false, d.Seconds())
+		}
+	}
+
+	// Normal call completion
+	s.completeCall(agentID, talk.Seconds())
+	s.updateAgentState(agentID, types.StateAfterCallWork)
+	return true
+}
+
+// holdCall puts the caller of the agent's current call on hold (onHold) or takes them off
+// after held seconds, which count into the call's hold time
+func (s *Simulator) holdCall(agentID string, onHold bool, held float64) {
+	s.callMu.Lock()
+	call, ok := s.agentCalls[agentID]
+	if ok && !onHold {
+		call.HoldTime += held
+	}
+	s.callMu.Unlock()
+
+	if !ok || call == nil {
+		return
+	}
+
+	s.mu.RLock()
+	if conn, ok := s.connections[agentID]; ok {
+		conn.SendCallHold(call.CallID, onHold)
+	} else {
+		for _, mux := range s.muxConns {
+			mux.SendCallHold(agentID, call.CallID, onHold)
+			break
+		}
+	}
+	s.mu.RUnlock()
 }
 
 // completeCall finishes the current call for an agent
@@ -539,6 +681,40 @@ func (s *Simulator) getForceEndCallChan(agentID string) <-chan string {
 	return nil
 }
 
+// finishState waits out a timed non-call state, then returns the agent to available.
+// A supervisor force_state ends the wait early and moves the agent to the forced state instead.
+func (s *Simulator) finishState(ctx context.Context, agentID string, duration time.Duration) {
+	forceStateCh := s.getForceStateChan(agentID)
+	next := types.StateAvailable
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(duration):
+	case state := <-forceStateCh:
+		next = state
+	}
+
+	s.updateAgentState(agentID, next)
+}
+
+// getForceStateChan returns the force_state channel for an agent
+func (s *Simulator) getForceStateChan(agentID string) <-chan types.AgentState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if conn, ok := s.connections[agentID]; ok {
+		return conn.GetForceStateChan()
+	}
+	for _, mux := range s.muxConns {
+		ch := mux.GetForceStateChan(agentID)
+		if ch != nil {
+			return ch
+		}
+	}
+	return nil
+}
+
 // getForceDisconnectChan returns the force_disconnect channel for an agent
 func (s *Simulator) getForceDisconnectChan(agentID string) <-chan struct{} {
 	s.mu.RLock()
@@ -610,6 +786,9 @@ func (s *Simulator) updateAgentState(agentID string, newState types.AgentState)
 			s.updateKPIs(&s.agents[i], previousState, stateDuration)
 
 			s.agents[i].State = newState
+			if newState != types.StateBreak {
+				s.agents[i].BreakReason = ""
+			}
 			s.agents[i].StateStart = time.Now()
 			s.agents[i].LastUpdate = time.Now()
 
@@ -882,8 +1061,6 @@ func (s *Simulator) updateKPIs(agent *types.Agent, previousState types.AgentStat
 			agent.KPIs.AvgCallDuration =
 				(agent.KPIs.AvgCallDuration*float64(agent.KPIs.TotalCalls-1) + stateDuration) / float64(agent.KPIs.TotalCalls)
 		}
-		// Update average handle time (simplified: same as call duration for now)
-		agent.KPIs.AvgHandleTime = agent.KPIs.AvgCallDuration
 
 		// Randomly adjust FCR and CSAT slightly
 		agent.KPIs.FirstCallResolution = clamp(agent.KPIs.FirstCallResolution+(s.rng.Float64()-0.5)*2, 60, 100)
@@ -907,11 +1084,22 @@ func (s *Simulator) updateKPIs(agent *types.Agent, previousState types.AgentStat
 		agent.KPIs.BreakTime += stateDuration
 	}
 
-	// Calculate occupancy: (call time + ACW time) / (login time - break time) * 100
-	productiveTime := agent.KPIs.AvgCallDuration*float64(agent.KPIs.TotalCalls) + agent.KPIs.AcwTime
-	availableTime := agent.KPIs.LoginTime - agent.KPIs.BreakTime
-	if availableTime > 0 {
-		agent.KPIs.Occupancy = clamp((productiveTime/availableTime)*100, 0, 100)
+	switch previousState {
+	case types.StateOnCall, types.StateOnHold, types.StateTransferring, types.StateConference,
+		types.StateAfterCallWork, types.StateBusy:
+		agent.HandlingTime += stateDuration
+	case types.StateAvailable:
+		agent.AvailableTime += stateDuration
+	}
+
+	// Same definitions the backend uses for its observed KPIs:
+	// AHT = (talk + hold + ACW) / calls, occupancy = handling / (handling + available) * 100
+	if agent.KPIs.TotalCalls > 0 {
+		handled := agent.KPIs.AvgCallDuration*float64(agent.KPIs.TotalCalls) + agent.KPIs.HoldTime + agent.KPIs.AcwTime
+		agent.KPIs.AvgHandleTime = handled / float64(agent.KPIs.TotalCalls)
+	}
+	if total := agent.HandlingTime + agent.AvailableTime; total > 0 {
+		agent.KPIs.Occupancy = clamp(agent.HandlingTime/total*100, 0, 100)
 	}
 
 	// Adherence fluctuates slightly
@@ -0,0 +1,38 @@
+// Package backendauth attaches the shared service token to requests sent to the backend
+package backendauth
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// HeaderName must match the header checked by the backend's service auth middleware
+const HeaderName = "X-Internal-Token"
+
+var token atomic.Value // string
+
+// SetToken sets the shared service token (call at startup; empty sends no header)
+func SetToken(t string) {
+	token.Store(t)
+}
+
+func current() string {
+	t, _ := token.Load().(string)
+	return t
+}
+
+// Apply adds the service token header to req
+func Apply(req *http.Request) {
+	if t := current(); t != "" {
+		req.Header.Set(HeaderName, t)
+	}
+}
+
+// Header returns the headers for a WebSocket dial to the backend (nil without a token)
+func Header() http.Header {
+	t := current()
+	if t == "" {
+		return nil
+	}
+	return http.Header{HeaderName: []string{t}}
+}
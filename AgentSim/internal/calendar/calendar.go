@@ -0,0 +1,115 @@
+// Package calendar follows the backend's team calendar so that simulated agents attend
+// their scheduled meetings and trainings
+package calendar
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/dennisdiepolder/monti/agentsim/internal/backendauth"
+	"github.com/dennisdiepolder/monti/agentsim/internal/types"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// How often the calendar is fetched
+	pollInterval = time.Minute
+
+	// How far ahead events are fetched
+	lookahead = 24 * time.Hour
+)
+
+// Follower keeps a copy of the next day's calendar events, refreshed from the backend's
+// /internal/calendar
+type Follower struct {
+	backendURL string
+	httpClient *http.Client
+	logger     zerolog.Logger
+
+	mu     sync.RWMutex
+	events []types.CalendarEvent
+}
+
+// NewFollower creates a follower for the backend at backendURL
+func NewFollower(backendURL string, logger zerolog.Logger) *Follower {
+	return &Follower{
+		backendURL: backendURL,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		logger:     logger.With().Str("component", "calendar").Logger(),
+	}
+}
+
+// Run refreshes the calendar every pollInterval until ctx is done. Failed refreshes keep
+// the previous events.
+func (f *Follower) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := f.Refresh(ctx); err != nil {
+			f.logger.Debug().Err(err).Msg("calendar refresh failed")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh fetches the events from now to lookahead
+func (f *Follower) Refresh(ctx context.Context) error {
+	now := time.Now().UTC()
+	q := url.Values{}
+	q.Set("from", now.Format(time.RFC3339))
+	q.Set("to", now.Add(lookahead).Format(time.RFC3339))
+	u := f.backendURL + "/internal/calendar?" + q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	backendauth.Apply(req)
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("GET %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned status %d", u, resp.StatusCode)
+	}
+
+	var events []types.CalendarEvent
+	if err := json.NewDecoder(resp.Body).Decode(&events); err != nil {
+		return fmt.Errorf("decode calendar: %w", err)
+	}
+
+	f.mu.Lock()
+	f.events = events
+	f.mu.Unlock()
+	return nil
+}
+
+// Active returns the team's event running at t; of overlapping events the latest to start wins
+func (f *Follower) Active(team string, t time.Time) (types.CalendarEvent, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var active types.CalendarEvent
+	found := false
+	for _, ev := range f.events {
+		if ev.Team != team || t.Before(ev.Start) || !t.Before(ev.End) {
+			continue
+		}
+		if !found || ev.Start.After(active.Start) {
+			active, found = ev, true
+		}
+	}
+	return active, found
+}
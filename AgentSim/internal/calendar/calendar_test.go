@@ -0,0 +1,56 @@
+package calendar
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/agentsim/internal/types"
+	"github.com/rs/zerolog"
+)
+
+func TestFollowerRefreshAndActive(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	events := []types.CalendarEvent{
+		{ID: "weekly", Team: "Team A", Activity: types.StateMeeting, Start: now.Add(-time.Hour), End: now.Add(time.Hour)},
+		{ID: "onboarding", Team: "Team A", Activity: types.StateTraining, Start: now.Add(-time.Minute), End: now.Add(time.Minute)},
+		{ID: "later", Team: "Team B", Activity: types.StateMeeting, Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/internal/calendar" || r.URL.Query().Get("from") == "" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(events)
+	}))
+	defer srv.Close()
+
+	f := NewFollower(srv.URL, zerolog.Nop())
+	if _, ok := f.Active("Team A", now); ok {
+		t.Fatal("expected no events before the first refresh")
+	}
+	if err := f.Refresh(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ev, ok := f.Active("Team A", now); !ok || ev.ID != "onboarding" {
+		t.Errorf("expected the later-starting training to win, got %+v", ev)
+	}
+	if ev, ok := f.Active("Team A", now.Add(30*time.Minute)); !ok || ev.ID != "weekly" {
+		t.Errorf("expected the meeting after the training ends, got %+v", ev)
+	}
+	if _, ok := f.Active("Team B", now); ok {
+		t.Error("expected Team B to have nothing running yet")
+	}
+
+	srv.Close()
+	if err := f.Refresh(context.Background()); err == nil {
+		t.Error("expected an unreachable backend to fail")
+	}
+	if _, ok := f.Active("Team A", now); !ok {
+		t.Error("expected a failed refresh to keep the events")
+	}
+}
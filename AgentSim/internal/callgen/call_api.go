@@ -2,12 +2,18 @@ package callgen
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/dennisdiepolder/monti/agentsim/internal/backendauth"
+	"github.com/dennisdiepolder/monti/agentsim/internal/tracing"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // CallAPIClient sends enqueue requests to the backend call API.
@@ -34,9 +40,15 @@ type enqueueRequest struct {
 }
 
 // EnqueueCall posts a new call to /internal/call/enqueue with a generated UUID.
+// The request starts the call's trace, continued by the backend's enqueue and routing spans.
 func (c *CallAPIClient) EnqueueCall(vqName string) error {
 	callID := uuid.New().String()
 
+	ctx, span := tracing.Tracer().Start(context.Background(), "agentsim.enqueue_call",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("vq", vqName), attribute.String("call_id", callID)))
+	defer span.End()
+
 	body, err := json.Marshal(enqueueRequest{
 		VQ:     vqName,
 		CallID: callID,
@@ -46,13 +58,22 @@ func (c *CallAPIClient) EnqueueCall(vqName string) error {
 	}
 
 	url := c.backendURL + "/internal/call/enqueue"
-	resp, err := c.httpClient.Post(url, "application/json", bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	backendauth.Apply(req)
+	tracing.Inject(ctx, req)
+
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("POST %s: %w", url, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		span.SetStatus(codes.Error, resp.Status)
 		return fmt.Errorf("POST %s returned status %d", url, resp.StatusCode)
 	}
 
@@ -22,12 +22,22 @@ type VQWeight struct {
 	Weight float64
 }
 
+// BusinessHours tells whether a department is within business hours
+type BusinessHours interface {
+	Open(dept types.Department) bool
+}
+
+// offHoursFactor scales a department's call rate while it is closed: a trickle of calls
+// keeps arriving, as on a real after-hours line
+const offHoursFactor = 0.02
+
 // CallGenerator generates calls at configurable rates per department and
 // enqueues them via a CallAPIClient.
 type CallGenerator struct {
 	mu             sync.RWMutex
 	departments    map[types.Department]DepartmentConfig
 	peakHourFactor float64
+	hours          BusinessHours
 	client         *CallAPIClient
 }
 
@@ -82,6 +92,51 @@ func defaultDepartments() map[types.Department]DepartmentConfig {
 	}
 }
 
+// defaultCallsPerMin is the call rate for departments without a built-in config
+const defaultCallsPerMin = 20
+
+// SetTaxonomy replaces the generated departments. Built-in departments with unchanged VQs
+// keep their rates and weights; others get defaultCallsPerMin spread evenly over their VQs.
+func (g *CallGenerator) SetTaxonomy(t types.Taxonomy) {
+	defaults := defaultDepartments()
+	departments := make(map[types.Department]DepartmentConfig, len(t.Departments))
+	for _, d := range t.Departments {
+		if cfg, ok := defaults[d.Name]; ok && sameVQs(cfg.VQs, d.VQs) {
+			departments[d.Name] = cfg
+			continue
+		}
+		cfg := DepartmentConfig{}
+		if len(d.VQs) > 0 {
+			cfg.CallsPerMin = defaultCallsPerMin
+		}
+		for _, vq := range d.VQs {
+			cfg.VQs = append(cfg.VQs, VQWeight{VQ: vq, Weight: 1})
+		}
+		departments[d.Name] = cfg
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.departments = departments
+}
+
+// sameVQs reports whether weights covers exactly the given VQs
+func sameVQs(weights []VQWeight, vqs []types.VQName) bool {
+	if len(weights) != len(vqs) {
+		return false
+	}
+	set := make(map[types.VQName]bool, len(vqs))
+	for _, vq := range vqs {
+		set[vq] = true
+	}
+	for _, w := range weights {
+		if !set[w.VQ] {
+			return false
+		}
+	}
+	return true
+}
+
 // SetDepartmentConfig thread-safely updates the config for a single department.
 func (g *CallGenerator) SetDepartmentConfig(dept types.Department, cfg DepartmentConfig) {
 	g.mu.Lock()
@@ -97,6 +152,14 @@ func (g *CallGenerator) SetPeakHourFactor(factor float64) {
 	g.peakHourFactor = factor
 }
 
+// SetBusinessHours makes closed departments generate offHoursFactor of their rate
+// (nil = always open).
+func (g *CallGenerator) SetBusinessHours(h BusinessHours) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.hours = h
+}
+
 // PeakHourFactor returns the current peak hour factor.
 func (g *CallGenerator) PeakHourFactor() float64 {
 	g.mu.RLock()
@@ -137,6 +200,9 @@ func (g *CallGenerator) runDepartment(ctx context.Context, dept types.Department
 		g.mu.RLock()
 		cfg := g.departments[dept]
 		factor := g.peakHourFactor
+		if g.hours != nil && !g.hours.Open(dept) {
+			factor *= offHoursFactor
+		}
 		g.mu.RUnlock()
 
 		effectiveRate := cfg.CallsPerMin * factor
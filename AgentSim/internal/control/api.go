@@ -3,17 +3,34 @@ package control
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"sync"
 	"time"
 
+	"github.com/dennisdiepolder/monti/agentsim/internal/backendauth"
 	"github.com/dennisdiepolder/monti/agentsim/internal/callgen"
 	"github.com/dennisdiepolder/monti/agentsim/internal/types"
 	"github.com/gorilla/mux"
 	"github.com/rs/zerolog"
 )
 
+// Errors of the programmatic control API; the HTTP handlers answer them with 409 and 400
+var (
+	ErrAlreadyRunning = errors.New("simulation already running")
+	ErrNotRunning     = errors.New("simulation not running")
+	ErrInvalidAgents  = errors.New("activeAgents must be between 0 and total agents")
+)
+
+// allVQs are the VQs InjectCalls round-robins over when no VQ is given
+var allVQs = []string{
+	"sales_inbound", "sales_outbound", "sales_callback", "sales_chat",
+	"support_general", "support_billing", "support_callback", "support_chat",
+	"tech_l1", "tech_l2", "tech_callback", "tech_chat",
+	"retention_save", "retention_cancel", "retention_callback", "retention_chat",
+}
+
 // API provides HTTP control interface for the simulation
 type API struct {
 	config        *types.SimulationConfig
@@ -84,6 +101,7 @@ func (api *API) SetupRoutes(router *mux.Router) {
 	router.HandleFunc("/config", api.configHandler).Methods("GET", "PUT")
 	router.HandleFunc("/stats", api.statsHandler).Methods("GET")
 	router.HandleFunc("/metrics", api.metricsHandler).Methods("GET")
+	router.HandleFunc("/loglevel", api.logLevelHandler).Methods("GET", "PUT")
 
 	// Call generation control
 	router.HandleFunc("/calls/config", api.callsConfigHandler).Methods("GET", "PUT")
@@ -101,14 +119,46 @@ func (api *API) healthHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// logLevelHandler reads (GET) or changes (PUT {"level": "debug"}) the global log level
+func (api *API) logLevelHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "GET" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"level": zerolog.GlobalLevel().String()})
+		return
+	}
+
+	var req struct {
+		Level string `json:"level"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	level, err := zerolog.ParseLevel(req.Level)
+	if err != nil || req.Level == "" {
+		http.Error(w, "invalid level", http.StatusBadRequest)
+		return
+	}
+
+	previous := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(level)
+	api.logger.Warn().Str("from", previous.String()).Str("to", level.String()).Msg("log level changed")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"level": level.String(), "previous": previous.String()})
+}
+
 // statusHandler returns current simulation status
 func (api *API) statusHandler(w http.ResponseWriter, r *http.Request) {
-	api.mu.RLock()
-	status := *api.status
-	api.mu.RUnlock()
-
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(status)
+	json.NewEncoder(w).Encode(api.Status())
+}
+
+// Status returns the current simulation status
+func (api *API) Status() types.SimulationStatus {
+	api.mu.RLock()
+	defer api.mu.RUnlock()
+	return *api.status
 }
 
 // startHandler starts the simulation
@@ -122,65 +172,89 @@ func (api *API) startHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.ActiveAgents <= 0 || req.ActiveAgents > api.config.TotalAgents {
-		req.ActiveAgents = 100 // default to 100 active agents
-	}
-
-	api.mu.Lock()
-	if api.status.Running {
-		api.mu.Unlock()
-		http.Error(w, "simulation already running", http.StatusConflict)
+	activeAgents, err := api.StartSimulation(req.ActiveAgents)
+	if errors.Is(err, ErrAlreadyRunning) {
+		http.Error(w, err.Error(), http.StatusConflict)
 		return
 	}
-	api.mu.Unlock()
-
-	if err := api.startFunc(req.ActiveAgents); err != nil {
+	if err != nil {
 		api.logger.Error().Err(err).Msg("failed to start simulation")
 		http.Error(w, "failed to start simulation", http.StatusInternalServerError)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":       "simulation started",
+		"active_agents": activeAgents,
+	})
+}
+
+// StartSimulation starts the simulation with activeAgents agents online, 100 when
+// activeAgents is out of range, and returns the number started
+func (api *API) StartSimulation(activeAgents int) (int, error) {
+	api.mu.RLock()
+	total, running := api.config.TotalAgents, api.status.Running
+	api.mu.RUnlock()
+	if running {
+		return 0, ErrAlreadyRunning
+	}
+	if activeAgents <= 0 || activeAgents > total {
+		activeAgents = 100 // default to 100 active agents
+	}
+
+	if err := api.startFunc(activeAgents); err != nil {
+		return 0, err
+	}
+
 	now := time.Now()
 	api.mu.Lock()
 	api.status.Running = true
-	api.status.ActiveAgents = req.ActiveAgents
+	api.status.ActiveAgents = activeAgents
 	api.status.StartedAt = &now
 	api.mu.Unlock()
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message":       "simulation started",
-		"active_agents": req.ActiveAgents,
-	})
+	return activeAgents, nil
 }
 
 // stopHandler stops the simulation
 func (api *API) stopHandler(w http.ResponseWriter, r *http.Request) {
-	api.mu.Lock()
-	if !api.status.Running {
-		api.mu.Unlock()
-		http.Error(w, "simulation not running", http.StatusConflict)
+	err := api.StopSimulation()
+	if errors.Is(err, ErrNotRunning) {
+		http.Error(w, err.Error(), http.StatusConflict)
 		return
 	}
-	api.mu.Unlock()
-
-	if err := api.stopFunc(); err != nil {
+	if err != nil {
 		api.logger.Error().Err(err).Msg("failed to stop simulation")
 		http.Error(w, "failed to stop simulation", http.StatusInternalServerError)
 		return
 	}
 
-	api.mu.Lock()
-	api.status.Running = false
-	api.status.StartedAt = nil
-	api.mu.Unlock()
-
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
 		"message": "simulation stopped",
 	})
 }
 
+// StopSimulation stops the simulation
+func (api *API) StopSimulation() error {
+	api.mu.RLock()
+	running := api.status.Running
+	api.mu.RUnlock()
+	if !running {
+		return ErrNotRunning
+	}
+
+	if err := api.stopFunc(); err != nil {
+		return err
+	}
+
+	api.mu.Lock()
+	api.status.Running = false
+	api.status.StartedAt = nil
+	api.mu.Unlock()
+	return nil
+}
+
 // configHandler gets or updates configuration
 func (api *API) configHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "GET" {
@@ -234,21 +308,17 @@ func (api *API) scaleHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.ActiveAgents < 0 || req.ActiveAgents > api.config.TotalAgents {
-		http.Error(w, "activeAgents must be between 0 and total agents", http.StatusBadRequest)
+	err := api.ScaleSimulation(req.ActiveAgents)
+	if errors.Is(err, ErrInvalidAgents) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	if err := api.scaleFunc(req.ActiveAgents); err != nil {
+	if err != nil {
 		api.logger.Error().Err(err).Msg("failed to scale simulation")
 		http.Error(w, "failed to scale simulation", http.StatusInternalServerError)
 		return
 	}
 
-	api.mu.Lock()
-	api.status.ActiveAgents = req.ActiveAgents
-	api.mu.Unlock()
-
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"message":       "simulation scaled",
@@ -256,6 +326,25 @@ func (api *API) scaleHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// ScaleSimulation changes the number of active agents of the running simulation
+func (api *API) ScaleSimulation(activeAgents int) error {
+	api.mu.RLock()
+	total := api.config.TotalAgents
+	api.mu.RUnlock()
+	if activeAgents < 0 || activeAgents > total {
+		return ErrInvalidAgents
+	}
+
+	if err := api.scaleFunc(activeAgents); err != nil {
+		return err
+	}
+
+	api.mu.Lock()
+	api.status.ActiveAgents = activeAgents
+	api.mu.Unlock()
+	return nil
+}
+
 // metricsHandler returns Prometheus-compatible metrics
 func (api *API) metricsHandler(w http.ResponseWriter, r *http.Request) {
 	metrics := api.metricsFunc()
@@ -283,14 +372,18 @@ func (api *API) metricsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// Start starts the HTTP server
-func (api *API) Start(ctx context.Context, addr string) error {
+// Handler returns a router with the control routes
+func (api *API) Handler() http.Handler {
 	router := mux.NewRouter()
 	api.SetupRoutes(router)
+	return router
+}
 
+// Start starts the HTTP server
+func (api *API) Start(ctx context.Context, addr string) error {
 	server := &http.Server{
 		Addr:    addr,
-		Handler: router,
+		Handler: api.Handler(),
 	}
 
 	go func() {
@@ -320,51 +413,52 @@ func (api *API) callsConfigHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if r.Method == "GET" {
-		configs := api.callGenerator.GetDepartmentConfigs()
-		result := map[string]interface{}{
-			"peakHourFactor": api.callGenerator.PeakHourFactor(),
-			"departments":    map[string]interface{}{},
-		}
-		depts := result["departments"].(map[string]interface{})
-		for dept, cfg := range configs {
-			depts[string(dept)] = map[string]interface{}{
-				"callsPerMin": cfg.CallsPerMin,
-			}
-		}
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(result)
+		json.NewEncoder(w).Encode(api.CallConfig())
 		return
 	}
 
 	// PUT - update call generation config
-	var req struct {
-		PeakHourFactor *float64           `json:"peakHourFactor,omitempty"`
-		Departments    map[string]struct {
-			CallsPerMin float64 `json:"callsPerMin"`
-		} `json:"departments,omitempty"`
-	}
+	var req types.CallConfigUpdate
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "invalid request body", http.StatusBadRequest)
 		return
 	}
+	api.UpdateCallConfig(req)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "call config updated"})
+}
 
-	if req.PeakHourFactor != nil {
-		api.callGenerator.SetPeakHourFactor(*req.PeakHourFactor)
+// CallConfig returns the call generator's peak hour factor and call rates
+func (api *API) CallConfig() types.CallConfig {
+	result := types.CallConfig{
+		PeakHourFactor: api.callGenerator.PeakHourFactor(),
+		Departments:    make(map[string]types.DepartmentCallRate),
 	}
+	for dept, cfg := range api.callGenerator.GetDepartmentConfigs() {
+		result.Departments[string(dept)] = types.DepartmentCallRate{CallsPerMin: cfg.CallsPerMin}
+	}
+	return result
+}
 
-	if req.Departments != nil {
+// UpdateCallConfig changes the call generator's peak hour factor and the call rates of known
+// departments; unknown departments are ignored
+func (api *API) UpdateCallConfig(update types.CallConfigUpdate) {
+	if update.PeakHourFactor != nil {
+		api.callGenerator.SetPeakHourFactor(*update.PeakHourFactor)
+	}
+
+	if update.Departments != nil {
 		current := api.callGenerator.GetDepartmentConfigs()
-		for deptName, update := range req.Departments {
+		for deptName, rate := range update.Departments {
 			dept := types.Department(deptName)
 			if existing, ok := current[dept]; ok {
-				existing.CallsPerMin = update.CallsPerMin
+				existing.CallsPerMin = rate.CallsPerMin
 				api.callGenerator.SetDepartmentConfig(dept, existing)
 			}
 		}
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"message": "call config updated"})
 }
 
 // callsInjectHandler injects N calls across VQs
@@ -382,41 +476,36 @@ func (api *API) callsInjectHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid request body", http.StatusBadRequest)
 		return
 	}
-	if req.Count <= 0 {
-		req.Count = 1
-	}
-	if req.Count > 1000 {
-		req.Count = 1000
-	}
 
-	allVQs := []string{
-		"sales_inbound", "sales_outbound", "sales_callback", "sales_chat",
-		"support_general", "support_billing", "support_callback", "support_chat",
-		"tech_l1", "tech_l2", "tech_callback", "tech_chat",
-		"retention_save", "retention_cancel", "retention_callback", "retention_chat",
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.InjectCalls(req.Count, req.VQ))
+}
+
+// InjectCalls enqueues count calls (1 to 1000) on the backend, all to vq or round-robin over
+// the VQs when vq is empty
+func (api *API) InjectCalls(count int, vq string) types.InjectResult {
+	if count <= 0 {
+		count = 1
+	}
+	if count > 1000 {
+		count = 1000
 	}
 
-	injected := 0
-	errors := 0
-	for i := 0; i < req.Count; i++ {
-		vq := req.VQ
-		if vq == "" {
-			vq = allVQs[i%len(allVQs)]
+	result := types.InjectResult{}
+	for i := 0; i < count; i++ {
+		target := vq
+		if target == "" {
+			target = allVQs[i%len(allVQs)]
 		}
-		if err := api.callAPIClient.EnqueueCall(vq); err != nil {
-			api.logger.Error().Err(err).Str("vq", vq).Msg("failed to inject call")
-			errors++
+		if err := api.callAPIClient.EnqueueCall(target); err != nil {
+			api.logger.Error().Err(err).Str("vq", target).Msg("failed to inject call")
+			result.Errors++
 		} else {
-			injected++
+			result.Injected++
 		}
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message":  fmt.Sprintf("injected %d calls", injected),
-		"injected": injected,
-		"errors":   errors,
-	})
+	result.Message = fmt.Sprintf("injected %d calls", result.Injected)
+	return result
 }
 
 // callsWipeHandler wipes all calls from the backend
@@ -432,6 +521,7 @@ func (api *API) callsWipeHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "failed to create request", http.StatusInternalServerError)
 		return
 	}
+	backendauth.Apply(req)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -3,6 +3,7 @@ package control
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -161,3 +162,64 @@ func TestScaleHandler_InvalidCount(t *testing.T) {
 		t.Fatalf("expected 400, got %d", w.Code)
 	}
 }
+
+func TestLogLevelHandler(t *testing.T) {
+	_, router := setupTestAPI(false)
+	defer zerolog.SetGlobalLevel(zerolog.GlobalLevel())
+
+	req := httptest.NewRequest(http.MethodPut, "/loglevel", bytes.NewBufferString(`{"level":"debug"}`))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if zerolog.GlobalLevel() != zerolog.DebugLevel {
+		t.Fatalf("expected debug level, got %s", zerolog.GlobalLevel())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/loglevel", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var body map[string]string
+	json.NewDecoder(w.Body).Decode(&body)
+	if body["level"] != "debug" {
+		t.Errorf("expected level debug, got %s", body["level"])
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/loglevel", bytes.NewBufferString(`{"level":"loud"}`))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown level, got %d", w.Code)
+	}
+}
+
+func TestProgrammaticLifecycle(t *testing.T) {
+	api, _ := setupTestAPI(false)
+	api.SetTotalAgents(200)
+
+	if err := api.StopSimulation(); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("expected ErrNotRunning before start, got %v", err)
+	}
+	if n, err := api.StartSimulation(0); err != nil || n != 100 {
+		t.Fatalf("expected the default 100 agents, got %d, %v", n, err)
+	}
+	if _, err := api.StartSimulation(10); !errors.Is(err, ErrAlreadyRunning) {
+		t.Fatalf("expected ErrAlreadyRunning, got %v", err)
+	}
+	if err := api.ScaleSimulation(201); !errors.Is(err, ErrInvalidAgents) {
+		t.Fatalf("expected ErrInvalidAgents, got %v", err)
+	}
+	if err := api.ScaleSimulation(150); err != nil {
+		t.Fatal(err)
+	}
+	if status := api.Status(); !status.Running || status.ActiveAgents != 150 || status.StartedAt == nil {
+		t.Errorf("unexpected status while running: %+v", status)
+	}
+	if err := api.StopSimulation(); err != nil {
+		t.Fatal(err)
+	}
+	if status := api.Status(); status.Running || status.StartedAt != nil {
+		t.Errorf("unexpected status after stop: %+v", status)
+	}
+}
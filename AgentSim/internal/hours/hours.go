@@ -0,0 +1,99 @@
+// Package hours follows the backend's business hours so that simulated call volume drops
+// while a department is closed
+package hours
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dennisdiepolder/monti/agentsim/internal/backendauth"
+	"github.com/dennisdiepolder/monti/agentsim/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// How often the opening status is fetched
+const pollInterval = time.Minute
+
+// Follower keeps the departments' opening status, refreshed from the backend's /internal/hours
+type Follower struct {
+	backendURL string
+	httpClient *http.Client
+	logger     zerolog.Logger
+
+	mu     sync.RWMutex
+	closed map[types.Department]bool
+}
+
+// NewFollower creates a follower for the backend at backendURL
+func NewFollower(backendURL string, logger zerolog.Logger) *Follower {
+	return &Follower{
+		backendURL: backendURL,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		logger:     logger.With().Str("component", "hours").Logger(),
+	}
+}
+
+// Run refreshes the status every pollInterval until ctx is done. Failed refreshes keep
+// the previous status.
+func (f *Follower) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := f.Refresh(ctx); err != nil {
+			f.logger.Debug().Err(err).Msg("business hours refresh failed")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh fetches the current opening status
+func (f *Follower) Refresh(ctx context.Context) error {
+	u := f.backendURL + "/internal/hours"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	backendauth.Apply(req)
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("GET %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned status %d", u, resp.StatusCode)
+	}
+
+	var body struct {
+		Departments []types.BusinessHoursStatus `json:"departments"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("decode business hours: %w", err)
+	}
+
+	closed := make(map[types.Department]bool, len(body.Departments))
+	for _, d := range body.Departments {
+		closed[d.Department] = !d.Open
+	}
+	f.mu.Lock()
+	f.closed = closed
+	f.mu.Unlock()
+	return nil
+}
+
+// Open reports whether dept is within business hours; departments are open until the
+// first refresh and when the backend doesn't list them
+func (f *Follower) Open(dept types.Department) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return !f.closed[dept]
+}
@@ -0,0 +1,42 @@
+package hours
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dennisdiepolder/monti/agentsim/internal/types"
+	"github.com/rs/zerolog"
+)
+
+func TestFollowerRefreshAndOpen(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/internal/hours" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"departments": [{"department": "sales", "open": false, "holiday": "Christmas"}, {"department": "support", "open": true}]}`))
+	}))
+	defer srv.Close()
+
+	f := NewFollower(srv.URL, zerolog.Nop())
+	if !f.Open(types.DeptSales) {
+		t.Fatal("expected departments open before the first refresh")
+	}
+	if err := f.Refresh(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.Open(types.DeptSales) || !f.Open(types.DeptSupport) || !f.Open(types.DeptRetention) {
+		t.Errorf("expected only sales closed, got sales=%v support=%v retention=%v",
+			f.Open(types.DeptSales), f.Open(types.DeptSupport), f.Open(types.DeptRetention))
+	}
+
+	srv.Close()
+	if err := f.Refresh(context.Background()); err == nil {
+		t.Error("expected an error once the backend is gone")
+	}
+	if f.Open(types.DeptSales) {
+		t.Error("expected a failed refresh to keep the previous status")
+	}
+}
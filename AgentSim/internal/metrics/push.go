@@ -0,0 +1,168 @@
+// Package metrics pushes the simulator's metrics to a Prometheus remote write endpoint or a
+// statsd daemon, for load tests where nothing scrapes the control API's /metrics
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// pushFlushTimeout bounds the final push on shutdown
+const pushFlushTimeout = 5 * time.Second
+
+// counterMetrics are cumulative metrics whose names do not end in _total
+var counterMetrics = map[string]bool{
+	"agentsim_state_transitions":    true,
+	"agentsim_websocket_reconnects": true,
+}
+
+// Sample is the value of one metric series at push time
+type Sample struct {
+	Name    string
+	Labels  map[string]string
+	Value   float64
+	Counter bool // cumulative; statsd sends the increase since the previous push
+}
+
+// Sink receives pushed samples (Prometheus remote write, statsd)
+type Sink interface {
+	Name() string
+	Push(ctx context.Context, samples []Sample, at time.Time) error
+}
+
+// Pusher pushes the metrics to sinks every interval
+type Pusher struct {
+	gather   func() []Sample
+	sinks    []Sink
+	interval time.Duration
+	logger   zerolog.Logger
+}
+
+// NewPusher creates a pusher of the samples returned by gather
+func NewPusher(gather func() []Sample, interval time.Duration, logger zerolog.Logger) *Pusher {
+	return &Pusher{
+		gather:   gather,
+		interval: interval,
+		logger:   logger.With().Str("component", "metrics_push").Logger(),
+	}
+}
+
+// AddSink adds a push target; must be called before Run
+func (p *Pusher) AddSink(s Sink) {
+	p.sinks = append(p.sinks, s)
+}
+
+// Enabled reports whether any sink is configured
+func (p *Pusher) Enabled() bool {
+	return len(p.sinks) > 0
+}
+
+// Run pushes every interval until ctx is done, then pushes once more so the totals of a load
+// test that just ended are not lost
+func (p *Pusher) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), pushFlushTimeout)
+			p.push(flushCtx)
+			cancel()
+			return
+		case <-ticker.C:
+			p.push(ctx)
+		}
+	}
+}
+
+// push sends the current samples to every sink; failures are logged and retried next interval
+func (p *Pusher) push(ctx context.Context) {
+	samples := p.gather()
+	now := time.Now()
+	for _, s := range p.sinks {
+		if err := s.Push(ctx, samples, now); err != nil {
+			p.logger.Warn().Err(err).Str("sink", s.Name()).Int("samples", len(samples)).Msg("metrics push failed")
+		}
+	}
+}
+
+// SamplesFromMap converts the simulator's metrics map (keys in the Prometheus text format,
+// e.g. agentsim_agents_by_state{state="available"}) into samples. Values that are not
+// numbers or booleans are skipped.
+func SamplesFromMap(m map[string]interface{}) []Sample {
+	samples := make([]Sample, 0, len(m))
+	for key, raw := range m {
+		var value float64
+		switch v := raw.(type) {
+		case int:
+			value = float64(v)
+		case int64:
+			value = float64(v)
+		case float64:
+			value = v
+		case bool:
+			if v {
+				value = 1
+			}
+		default:
+			continue
+		}
+		name, labels, err := parseSeries(key)
+		if err != nil {
+			continue
+		}
+		samples = append(samples, Sample{
+			Name:    name,
+			Labels:  labels,
+			Value:   value,
+			Counter: strings.HasSuffix(name, "_total") || counterMetrics[name],
+		})
+	}
+	return samples
+}
+
+// parseSeries splits name{label="value",...} into the name and its labels
+func parseSeries(key string) (string, map[string]string, error) {
+	open := strings.IndexByte(key, '{')
+	if open < 0 {
+		return key, nil, nil
+	}
+	if !strings.HasSuffix(key, "}") {
+		return "", nil, fmt.Errorf("unterminated labels in %q", key)
+	}
+	labels := make(map[string]string)
+	rest := key[open+1 : len(key)-1]
+	for rest != "" {
+		eq := strings.IndexByte(rest, '=')
+		if eq < 0 {
+			return "", nil, fmt.Errorf("malformed labels in %q", key)
+		}
+		name := rest[:eq]
+		value, tail, err := unquoteLabel(rest[eq+1:])
+		if err != nil {
+			return "", nil, fmt.Errorf("malformed labels in %q: %w", key, err)
+		}
+		labels[name] = value
+		rest = strings.TrimPrefix(tail, ",")
+	}
+	return key[:open], labels, nil
+}
+
+// unquoteLabel reads a quoted label value and returns it with the remaining input
+func unquoteLabel(s string) (string, string, error) {
+	prefix, err := strconv.QuotedPrefix(s)
+	if err != nil {
+		return "", "", err
+	}
+	value, err := strconv.Unquote(prefix)
+	if err != nil {
+		return "", "", err
+	}
+	return value, s[len(prefix):], nil
+}
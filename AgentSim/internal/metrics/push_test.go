@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+)
+
+func TestSamplesFromMap(t *testing.T) {
+	samples := SamplesFromMap(map[string]interface{}{
+		"agentsim_heartbeats_sent_total":                int64(40),
+		"agentsim_state_transitions":                    int64(7),
+		"agentsim_running":                              true,
+		`agentsim_agents_by_state{state="on_call"}`:     12,
+		`agentsim_agents_by_location{location="a,b=c"}`: 3,
+		"agentsim_version":                              "dev",
+	})
+
+	byName := map[string]Sample{}
+	for _, s := range samples {
+		byName[s.Name] = s
+	}
+	if len(samples) != 5 {
+		t.Fatalf("expected 5 samples (strings skipped), got %d: %+v", len(samples), samples)
+	}
+	if s := byName["agentsim_heartbeats_sent_total"]; !s.Counter || s.Value != 40 {
+		t.Errorf("expected a _total counter at 40, got %+v", s)
+	}
+	if s := byName["agentsim_state_transitions"]; !s.Counter {
+		t.Errorf("expected state transitions to be a counter, got %+v", s)
+	}
+	if s := byName["agentsim_running"]; s.Counter || s.Value != 1 {
+		t.Errorf("expected running as a gauge at 1, got %+v", s)
+	}
+	if s := byName["agentsim_agents_by_state"]; s.Labels["state"] != "on_call" || s.Value != 12 {
+		t.Errorf("expected the state label to be parsed, got %+v", s)
+	}
+	if s := byName["agentsim_agents_by_location"]; s.Labels["location"] != "a,b=c" {
+		t.Errorf("expected a quoted label value with separators, got %+v", s)
+	}
+}
+
+func TestStatsdSinkPlainFormat(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no UDP socket: %v", err)
+	}
+	defer conn.Close()
+
+	sink := NewStatsdSink(conn.LocalAddr().String(), StatsdFormatPlain)
+	samples := []Sample{{Name: "agentsim_agents_by_state", Labels: map[string]string{"state": "on call"}, Value: 4}}
+	if err := sink.Push(context.Background(), samples, time.Now()); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+
+	buf := make([]byte, statsdPacketSize)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if got := string(buf[:n]); !strings.HasPrefix(got, "agentsim_agents_by_state.on_call:4|g") {
+		t.Errorf("unexpected statsd line %q", got)
+	}
+}
+
+// writeRequestVector is a WriteRequest of two series with an extra job label. The backend's copy of
+// encodeWriteRequest is tested against the same bytes, which keeps the two in sync.
+const writeRequestVector = "0a470a140a085f5f6e616d655f5f12086d6f6e74695f75700a0c0a036a6f6212056d6f6e74690a0f0a04726f6c6512076261636b656e64121009000000000000f03f1080d095ffbc310a540a1d0a085f5f6e616d655f5f12116d6f6e74695f63616c6c735f746f74616c0a0c0a036a6f6212056d6f6e74690a130a027671120d73616c65735f696e626f756e6412100900000000004045401080d095ffbc31"
+
+func TestEncodeWriteRequestVector(t *testing.T) {
+	samples := []Sample{
+		{Name: "monti_up", Labels: map[string]string{"role": "backend"}, Value: 1},
+		{Name: "monti_calls_total", Labels: map[string]string{"vq": "sales_inbound"}, Value: 42.5, Counter: true},
+	}
+	got := encodeWriteRequest(samples, map[string]string{"job": "monti"}, 1700000000000)
+	if hex.EncodeToString(got) != writeRequestVector {
+		t.Fatalf("WriteRequest encoding changed:\n got %x\nwant %s", got, writeRequestVector)
+	}
+	if decoded, err := snappy.Decode(nil, snappy.Encode(nil, got)); err != nil || !bytes.Equal(decoded, got) {
+		t.Errorf("expected the request to round-trip through snappy, got %v", err)
+	}
+}
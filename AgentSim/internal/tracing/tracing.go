@@ -0,0 +1,110 @@
+// Package tracing wires OpenTelemetry tracing for AgentSim and propagates W3C trace context to
+// the backend: as the traceparent header on HTTP requests and the traceparent field of agent
+// WebSocket messages. Spans are exported via OTLP/HTTP only when OTEL_EXPORTER_OTLP_ENDPOINT
+// (or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT) is set; otherwise nothing is sampled or sent.
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/dennisdiepolder/monti/agentsim"
+
+// maxCallTraces bounds the call_assign traces kept until the matching call_complete
+const maxCallTraces = 10000
+
+var propagator = propagation.TraceContext{}
+
+// Setup installs the OTLP exporter and returns a shutdown func that flushes pending spans.
+// Without an OTLP endpoint configured it does nothing.
+func Setup(ctx context.Context, serviceName string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagator)
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// Tracer returns the AgentSim tracer from the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Inject adds the traceparent header for the span in ctx to req
+func Inject(ctx context.Context, req *http.Request) {
+	propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+}
+
+// MessageTraceParent records a producer span for an outgoing agent message, as a child of
+// parent (a traceparent value) when given, and returns the span's traceparent for the message.
+// Returns "" when tracing is disabled.
+func MessageTraceParent(name, parent string, attrs ...attribute.KeyValue) string {
+	ctx := context.Background()
+	if parent != "" {
+		ctx = propagator.Extract(ctx, propagation.MapCarrier{"traceparent": parent})
+	}
+	ctx, span := Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attrs...))
+	defer span.End()
+
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	return carrier["traceparent"]
+}
+
+var (
+	callTracesMu sync.Mutex
+	callTraces   = make(map[string]string) // callID -> traceparent from call_assign
+)
+
+// RememberCall keeps the traceparent of a call_assign so the call_complete continues its trace
+func RememberCall(callID, traceparent string) {
+	if traceparent == "" {
+		return
+	}
+	callTracesMu.Lock()
+	defer callTracesMu.Unlock()
+	if len(callTraces) < maxCallTraces {
+		callTraces[callID] = traceparent
+	}
+}
+
+// TakeCall returns and forgets the traceparent remembered for callID
+func TakeCall(callID string) string {
+	callTracesMu.Lock()
+	defer callTracesMu.Unlock()
+	tp := callTraces[callID]
+	delete(callTraces, callID)
+	return tp
+}
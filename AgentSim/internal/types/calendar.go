@@ -0,0 +1,13 @@
+package types
+
+import "time"
+
+// CalendarEvent is a team meeting or training from the backend's calendar
+type CalendarEvent struct {
+	ID       string     `json:"id"`
+	Team     string     `json:"team"`
+	Title    string     `json:"title,omitempty"`
+	Activity AgentState `json:"activity"` // StateMeeting or StateTraining
+	Start    time.Time  `json:"start"`
+	End      time.Time  `json:"end"`
+}
@@ -44,6 +44,7 @@ type CallAssignMsg struct {
 	CallID    string    `json:"callId"`
 	VQ        VQName    `json:"vq"`
 	Timestamp time.Time `json:"timestamp"`
+	TraceParent string  `json:"traceparent,omitempty"` // W3C trace context of the backend routing span
 }
 
 // CallCompleteMsg is sent to backend when a call is finished
@@ -54,4 +55,15 @@ type CallCompleteMsg struct {
 	TalkTime  float64   `json:"talkTime"`  // seconds
 	HoldTime  float64   `json:"holdTime"`  // seconds
 	Timestamp time.Time `json:"timestamp"`
+	TraceParent string  `json:"traceparent,omitempty"` // W3C trace context, continuing the call_assign trace
+	Seq       uint64    `json:"seq,omitempty"` // per-agent message number
+}
+
+// CallHoldMsg is sent to backend when the caller is put on hold or taken off hold
+type CallHoldMsg struct {
+	Type      string    `json:"type"` // "call_hold_start" or "call_hold_end"
+	AgentID   string    `json:"agentId"`
+	CallID    string    `json:"callId"`
+	Timestamp time.Time `json:"timestamp"`
+	Seq       uint64    `json:"seq,omitempty"` // per-agent message number
 }
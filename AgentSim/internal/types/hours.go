@@ -0,0 +1,8 @@
+package types
+
+// BusinessHoursStatus is one department's opening status from the backend's /internal/hours
+type BusinessHoursStatus struct {
+	Department Department `json:"department"`
+	Open       bool       `json:"open"`
+	Holiday    string     `json:"holiday,omitempty"`
+}
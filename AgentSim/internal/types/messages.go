@@ -9,20 +9,24 @@ type AgentHeartbeat struct {
 	State     AgentState `json:"state"`
 	Timestamp time.Time  `json:"timestamp"`
 	KPIs      AgentKPIs  `json:"kpis"`
+	Seq       uint64     `json:"seq,omitempty"` // per-agent message number
 }
 
 // AgentStateChangeMsg is sent from agent to backend on state transitions
 type AgentStateChangeMsg struct {
-	Type          string     `json:"type"` // "state_change"
-	AgentID       string     `json:"agentId"`
-	PreviousState AgentState `json:"previousState"`
-	NewState      AgentState `json:"newState"`
-	Timestamp     time.Time  `json:"timestamp"`
-	StateDuration float64    `json:"stateDuration"`
-	KPIs          AgentKPIs  `json:"kpis"`
-	Department    Department `json:"department"`
-	Location      Location   `json:"location"`
-	Team          string     `json:"team"`
+	Type          string      `json:"type"` // "state_change"
+	AgentID       string      `json:"agentId"`
+	PreviousState AgentState  `json:"previousState"`
+	NewState      AgentState  `json:"newState"`
+	Timestamp     time.Time   `json:"timestamp"`
+	StateDuration float64     `json:"stateDuration"`
+	KPIs          AgentKPIs   `json:"kpis"`
+	Department    Department  `json:"department"`
+	Location      Location    `json:"location"`
+	Team          string      `json:"team"`
+	TraceParent   string      `json:"traceparent,omitempty"` // W3C trace context of the state change span
+	Reason        BreakReason `json:"reason,omitempty"`      // set when NewState is break
+	Seq           uint64      `json:"seq,omitempty"`         // per-agent message number
 }
 
 // AgentRegister is sent when an agent first connects
@@ -34,10 +38,28 @@ type AgentRegister struct {
 	Team       string     `json:"team"`
 	State      AgentState `json:"state"`
 	KPIs       AgentKPIs  `json:"kpis"`
+	Seq        uint64     `json:"seq,omitempty"` // per-agent message number
+}
+
+// BreakRequestMsg is sent from agent to backend before going on break
+type BreakRequestMsg struct {
+	Type    string      `json:"type"` // "break_request"
+	AgentID string      `json:"agentId"`
+	Reason  BreakReason `json:"reason"`
+}
+
+// BreakResponseMsg is sent from backend to agent to answer a break_request
+type BreakResponseMsg struct {
+	Type    string      `json:"type"` // "break_response"
+	AgentID string      `json:"agentId"`
+	Reason  BreakReason `json:"reason"`
+	Granted bool        `json:"granted"`
+	Message string      `json:"message,omitempty"`
 }
 
 // ServerAck is sent from backend to agent as acknowledgment
 type ServerAck struct {
-	Type    string `json:"type"` // "ack"
-	AgentID string `json:"agentId"`
+	Type       string `json:"type"` // "ack"
+	AgentID    string `json:"agentId"`
+	Generation uint64 `json:"generation,omitempty"` // the connection's generation for register acks
 }
@@ -0,0 +1,80 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Taxonomy is the set of departments (with their VQs) and weighted locations agents are
+// generated for. It reads the backend's taxonomy file; business units are ignored here.
+type Taxonomy struct {
+	Departments []DepartmentDef `json:"departments"`
+	Locations   []LocationDef   `json:"locations"`
+}
+
+// DepartmentDef is one department and the VQs its calls are generated on
+type DepartmentDef struct {
+	Name Department `json:"name"`
+	VQs  []VQName   `json:"vqs"`
+}
+
+// LocationDef is one location and its relative share of generated agents
+type LocationDef struct {
+	Name   Location `json:"name"`
+	Weight int      `json:"weight,omitempty"`
+}
+
+// DefaultTaxonomy returns the built-in departments and locations
+func DefaultTaxonomy() Taxonomy {
+	return Taxonomy{
+		Departments: []DepartmentDef{
+			{Name: DeptSales, VQs: []VQName{VQSalesInbound, VQSalesOutbound, VQSalesCallback, VQSalesChat}},
+			{Name: DeptSupport, VQs: []VQName{VQSupportGeneral, VQSupportBilling, VQSupportCallback, VQSupportChat}},
+			{Name: DeptTechnical, VQs: []VQName{VQTechL1, VQTechL2, VQTechCallback, VQTechChat}},
+			{Name: DeptRetention, VQs: []VQName{VQRetentionSave, VQRetentionCancel, VQRetentionCallback, VQRetentionChat}},
+		},
+		// Distribution: 25% Berlin, 20% Munich, 15% Hamburg, 15% Frankfurt, 25% Remote
+		Locations: []LocationDef{
+			{Name: LocationBerlin, Weight: 25},
+			{Name: LocationMunich, Weight: 20},
+			{Name: LocationHamburg, Weight: 15},
+			{Name: LocationFrankfurt, Weight: 15},
+			{Name: LocationRemote, Weight: 25},
+		},
+	}
+}
+
+// LoadTaxonomyFile reads a JSON taxonomy; omitted sections keep their defaults
+func LoadTaxonomyFile(path string) (Taxonomy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Taxonomy{}, fmt.Errorf("failed to read taxonomy: %w", err)
+	}
+	var t Taxonomy
+	if err := json.Unmarshal(data, &t); err != nil {
+		return Taxonomy{}, fmt.Errorf("failed to parse taxonomy: %w", err)
+	}
+
+	def := DefaultTaxonomy()
+	if t.Departments == nil {
+		t.Departments = def.Departments
+	}
+	if t.Locations == nil {
+		t.Locations = def.Locations
+	}
+	if len(t.Departments) == 0 || len(t.Locations) == 0 {
+		return Taxonomy{}, fmt.Errorf("taxonomy needs at least one department and location")
+	}
+	for _, d := range t.Departments {
+		if d.Name == "" {
+			return Taxonomy{}, fmt.Errorf("department without name")
+		}
+	}
+	for _, l := range t.Locations {
+		if l.Name == "" || l.Weight < 0 {
+			return Taxonomy{}, fmt.Errorf("invalid location %q", l.Name)
+		}
+	}
+	return t, nil
+}
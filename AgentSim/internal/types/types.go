@@ -46,6 +46,16 @@ const (
 	LocationRemote    Location = "remote"
 )
 
+// BreakReason is the reason code an agent gives for a break
+type BreakReason string
+
+const (
+	BreakRest      BreakReason = "rest"
+	BreakPersonal  BreakReason = "personal"
+	BreakWellness  BreakReason = "wellness"
+	BreakTechnical BreakReason = "technical"
+)
+
 // AgentKPIs contains performance metrics for an agent
 type AgentKPIs struct {
 	TotalCalls           int     `json:"totalCalls"`
@@ -76,6 +86,13 @@ type Agent struct {
 	LastUpdate time.Time  `json:"lastUpdate"`
 	LoginTime  time.Time  `json:"loginTime"`
 	KPIs       AgentKPIs  `json:"kpis"`
+
+	BreakReason BreakReason `json:"breakReason,omitempty"` // set while on break
+
+	// Seconds spent handling calls (talk, hold, transfer, conference, ACW) and available,
+	// the basis of KPIs.Occupancy
+	HandlingTime  float64 `json:"-"`
+	AvailableTime float64 `json:"-"`
 }
 
 // AgentEvent represents an individual agent state event sent to Backend
@@ -104,3 +121,27 @@ type SimulationStatus struct {
 	EventsSent   int64      `json:"eventsSent"`
 	StartedAt    *time.Time `json:"startedAt,omitempty"`
 }
+
+// CallConfig is the call generator's config, as GET /calls/config returns it
+type CallConfig struct {
+	PeakHourFactor float64                       `json:"peakHourFactor"`
+	Departments    map[string]DepartmentCallRate `json:"departments"`
+}
+
+// DepartmentCallRate is a department's base call volume
+type DepartmentCallRate struct {
+	CallsPerMin float64 `json:"callsPerMin"`
+}
+
+// CallConfigUpdate is the body of PUT /calls/config; unset fields are left unchanged
+type CallConfigUpdate struct {
+	PeakHourFactor *float64                      `json:"peakHourFactor,omitempty"`
+	Departments    map[string]DepartmentCallRate `json:"departments,omitempty"`
+}
+
+// InjectResult reports the calls enqueued by POST /calls/inject
+type InjectResult struct {
+	Message  string `json:"message"`
+	Injected int    `json:"injected"`
+	Errors   int    `json:"errors"`
+}
@@ -0,0 +1,303 @@
+// Package sim wires AgentSim: the generated agents, the simulator that connects them to the
+// backend, the call generator and the control API. cmd/agentsim runs it as a process; the
+// cross-service end-to-end tests in test/e2e run it in-process. Embedders drive it through
+// App's methods, the control API's HTTP routes are optional.
+package sim
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dennisdiepolder/monti/agentsim/internal/agent"
+	"github.com/dennisdiepolder/monti/agentsim/internal/backendauth"
+	"github.com/dennisdiepolder/monti/agentsim/internal/calendar"
+	"github.com/dennisdiepolder/monti/agentsim/internal/callgen"
+	"github.com/dennisdiepolder/monti/agentsim/internal/control"
+	"github.com/dennisdiepolder/monti/agentsim/internal/hours"
+	agentTypes "github.com/dennisdiepolder/monti/agentsim/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// Types and errors of the programmatic control API, shared with the HTTP routes
+type (
+	Status             = agentTypes.SimulationStatus
+	CallConfig         = agentTypes.CallConfig
+	CallConfigUpdate   = agentTypes.CallConfigUpdate
+	DepartmentCallRate = agentTypes.DepartmentCallRate
+	InjectResult       = agentTypes.InjectResult
+)
+
+var (
+	ErrAlreadyRunning = control.ErrAlreadyRunning
+	ErrNotRunning     = control.ErrNotRunning
+	ErrInvalidAgents  = control.ErrInvalidAgents
+)
+
+// Options configure an App
+type Options struct {
+	BackendURL     string
+	InternalToken  string // shared service token for the backend's /internal and /ws/agent routes
+	TaxonomyFile   string // empty uses the built-in departments and locations
+	FollowCalendar bool   // take meetings and trainings from the backend's team calendar
+	FollowHours    bool   // drop call volume while the backend's business hours close a department
+	Seed           int64  // agent generation; 0 uses the current time
+}
+
+// App is one AgentSim: agents are generated and the roster is posted to the backend by New,
+// the simulation is started, scaled and stopped through App's methods or the control API
+type App struct {
+	generator     *agent.Generator
+	simulator     *agent.Simulator
+	callGenerator *callgen.CallGenerator
+	controlAPI    *control.API
+	ctx           context.Context    // root context — only cancelled on shutdown
+	simCtx        context.Context    // simulation context — cancelled on stop
+	simCancel     context.CancelFunc // simulation cancel
+	mu            sync.Mutex
+	logger        zerolog.Logger
+	backendURL    string
+}
+
+// New generates the agents and wires the simulator, call generator and control API. Roster
+// posting and the calendar and hours followers run until ctx is done.
+func New(ctx context.Context, opts Options, logger zerolog.Logger) (*App, error) {
+	backendauth.SetToken(opts.InternalToken)
+
+	app := &App{
+		ctx:        ctx,
+		logger:     logger,
+		backendURL: opts.BackendURL,
+	}
+
+	taxonomy := agentTypes.DefaultTaxonomy()
+	if opts.TaxonomyFile != "" {
+		var err error
+		taxonomy, err = agentTypes.LoadTaxonomyFile(opts.TaxonomyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load taxonomy %s: %w", opts.TaxonomyFile, err)
+		}
+	}
+
+	// Generate agents (500 per department)
+	logger.Info().Int("departments", len(taxonomy.Departments)).Msg("generating agents (500 per department)")
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	app.generator = agent.NewGenerator(seed)
+	app.generator.SetTaxonomy(taxonomy)
+	agents := app.generator.GenerateAgents(0) // count ignored
+	logger.Info().Int("generated", len(agents)).Msg("agents generated")
+
+	// POST roster to backend so all agents are pre-registered (retry until backend is reachable)
+	go postRoster(ctx, logger, opts.BackendURL, agents)
+
+	// Create simulator
+	app.simulator = agent.NewSimulator(agents, opts.BackendURL, logger)
+	if opts.FollowCalendar {
+		follower := calendar.NewFollower(opts.BackendURL, logger)
+		go follower.Run(ctx)
+		app.simulator.SetCalendar(follower)
+	}
+
+	// Create call generator
+	callAPIClient := callgen.NewCallAPIClient(opts.BackendURL)
+	app.callGenerator = callgen.NewCallGenerator(callAPIClient)
+	app.callGenerator.SetTaxonomy(taxonomy)
+	if opts.FollowHours {
+		hoursFollower := hours.NewFollower(opts.BackendURL, logger)
+		go hoursFollower.Run(ctx)
+		app.callGenerator.SetBusinessHours(hoursFollower)
+	}
+
+	// Create control API
+	app.controlAPI = control.NewAPI(logger)
+	app.controlAPI.SetTotalAgents(len(agents))
+	app.controlAPI.SetHandlers(
+		app.startSimulation,
+		app.stopSimulation,
+		app.scaleSimulation,
+		app.getStats,
+		app.Metrics,
+	)
+	app.controlAPI.SetCallGenerator(app.callGenerator)
+	app.controlAPI.SetCallAPIClient(callAPIClient, opts.BackendURL)
+
+	return app, nil
+}
+
+// Handler returns the control API's routes
+func (app *App) Handler() http.Handler {
+	return app.controlAPI.Handler()
+}
+
+// ListenAndServe serves the control API on addr until the root context is done
+func (app *App) ListenAndServe(addr string) error {
+	return app.controlAPI.Start(app.ctx, addr)
+}
+
+// Metrics returns the simulator's metrics, keyed by Prometheus series name
+func (app *App) Metrics() map[string]interface{} {
+	return app.simulator.GetMetrics()
+}
+
+// Start brings activeAgents agents online (100 when out of range) and starts the call
+// generator, like POST /start; ErrAlreadyRunning if the simulation runs
+func (app *App) Start(activeAgents int) error {
+	_, err := app.controlAPI.StartSimulation(activeAgents)
+	return err
+}
+
+// Stop takes every agent offline and stops the call generator, like POST /stop;
+// ErrNotRunning if the simulation doesn't run
+func (app *App) Stop() error {
+	return app.controlAPI.StopSimulation()
+}
+
+// Scale changes the number of online agents of the running simulation, like POST /scale;
+// ErrInvalidAgents outside 0 to the number of generated agents
+func (app *App) Scale(activeAgents int) error {
+	return app.controlAPI.ScaleSimulation(activeAgents)
+}
+
+// Status returns the simulation status, like GET /status, with the events sent so far
+func (app *App) Status() Status {
+	status := app.controlAPI.Status()
+	status.EventsSent = app.simulator.GetEventsSent()
+	return status
+}
+
+// CallConfig returns the call generator's config, like GET /calls/config
+func (app *App) CallConfig() CallConfig {
+	return app.controlAPI.CallConfig()
+}
+
+// UpdateCallConfig changes the call generator's config, like PUT /calls/config
+func (app *App) UpdateCallConfig(update CallConfigUpdate) {
+	app.controlAPI.UpdateCallConfig(update)
+}
+
+// InjectCalls enqueues count calls on the backend, to vq or round-robin over the VQs when vq is
+// empty, like POST /calls/inject
+func (app *App) InjectCalls(count int, vq string) InjectResult {
+	return app.controlAPI.InjectCalls(count, vq)
+}
+
+// startSimulation brings activeAgents agents online and starts the call generator; the
+// control API calls it once it has checked the simulation isn't running
+func (app *App) startSimulation(activeAgents int) error {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+
+	app.logger.Info().Int("active_agents", activeAgents).Msg("starting simulation")
+
+	// Create a child context for this simulation run
+	app.simCtx, app.simCancel = context.WithCancel(app.ctx)
+
+	// Start simulator
+	go app.simulator.Start(app.simCtx, activeAgents)
+
+	// Start call generator (generates calls and posts to backend)
+	go app.callGenerator.Run(app.simCtx)
+
+	return nil
+}
+
+func (app *App) stopSimulation() error {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+
+	app.logger.Info().Msg("stopping simulation")
+
+	// Stop the simulator (cancels agent goroutines and closes connections)
+	app.simulator.Stop()
+
+	// Cancel the simulation context to stop call generator and any other sim goroutines
+	if app.simCancel != nil {
+		app.simCancel()
+		app.simCancel = nil
+	}
+
+	return nil
+}
+
+func (app *App) scaleSimulation(targetAgents int) error {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+
+	app.logger.Info().Int("target_agents", targetAgents).Msg("scaling simulation")
+
+	if app.simCtx == nil {
+		return fmt.Errorf("simulation not running")
+	}
+
+	// Scale the simulator to the target number of agents
+	return app.simulator.Scale(app.simCtx, targetAgents)
+}
+
+func (app *App) getStats() map[string]interface{} {
+	return map[string]interface{}{
+		"active_agents": app.simulator.GetActiveCount(),
+		"events_sent":   app.simulator.GetEventsSent(),
+	}
+}
+
+// rosterEntry is the JSON payload for each agent in the roster POST
+type rosterEntry struct {
+	AgentID    string                `json:"agentId"`
+	Department agentTypes.Department `json:"department"`
+	Location   agentTypes.Location   `json:"location"`
+	Team       string                `json:"team"`
+}
+
+// postRoster sends the full agent roster to the backend, retrying until it succeeds or ctx is done
+func postRoster(ctx context.Context, logger zerolog.Logger, backendURL string, agents []agentTypes.Agent) {
+	roster := make([]rosterEntry, len(agents))
+	for i, a := range agents {
+		roster[i] = rosterEntry{
+			AgentID:    a.ID,
+			Department: a.Department,
+			Location:   a.Location,
+			Team:       a.Team,
+		}
+	}
+
+	body, err := json.Marshal(roster)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to marshal roster")
+		return
+	}
+
+	url := backendURL + "/internal/agents/roster"
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to create roster request")
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		backendauth.Apply(req)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				logger.Info().Int("agents", len(roster)).Msg("roster posted to backend")
+				return
+			}
+			logger.Warn().Int("status", resp.StatusCode).Msg("roster POST failed, retrying...")
+		} else {
+			logger.Warn().Err(err).Msg("backend not reachable for roster, retrying...")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
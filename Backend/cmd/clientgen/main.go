@@ -0,0 +1,28 @@
+// Command clientgen writes the generated operations of pkg/client from api.Operations.
+// Run it through go generate ./pkg/client after changing a route or a body type.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/dennisdiepolder/monti/backend/internal/api"
+	"github.com/dennisdiepolder/monti/backend/internal/openapi"
+)
+
+func main() {
+	out := flag.String("o", "operations.gen.go", "output file")
+	pkg := flag.String("pkg", "client", "package name")
+	flag.Parse()
+
+	src, err := openapi.GenerateClient(*pkg, api.Operations(), api.StreamTypes...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "clientgen:", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "clientgen:", err)
+		os.Exit(1)
+	}
+}
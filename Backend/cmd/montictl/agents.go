@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+func newAgentsCmd(opts *options) *cobra.Command {
+	cmd := &cobra.Command{Use: "agents", Short: "Inspect agents"}
+
+	var dept, location, team, state, status string
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List the agents visible to the token",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			q := url.Values{}
+			for key, value := range map[string]string{
+				"department": dept, "location": location, "team": team,
+				"state": state, "connectionStatus": status,
+			} {
+				if value != "" {
+					q.Set(key, value)
+				}
+			}
+
+			ctx, cancel := opts.context(cmd)
+			defer cancel()
+			list, err := opts.client().ListAgents(ctx, q)
+			if err != nil {
+				return err
+			}
+			return opts.print(cmd.OutOrStdout(), list, func(w *tabwriter.Writer) {
+				fmt.Fprintln(w, "AGENT\tSTATE\tSINCE\tDEPARTMENT\tLOCATION\tTEAM\tCONNECTION")
+				for _, a := range list.Agents {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", a.AgentID, a.State, since(a.StateStart),
+						a.Department, a.Location, a.Team, a.ConnectionStatus)
+				}
+			})
+		},
+	}
+	f := list.Flags()
+	f.StringVar(&dept, "department", "", "filter by department")
+	f.StringVar(&location, "location", "", "filter by location")
+	f.StringVar(&team, "team", "", "filter by team")
+	f.StringVar(&state, "state", "", "filter by state, e.g. available")
+	f.StringVar(&status, "connection-status", "", "connected, stale or disconnected")
+	cmd.AddCommand(list)
+	return cmd
+}
+
+// since renders how long ago t was, truncated to seconds
+func since(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return time.Since(t).Truncate(time.Second).String()
+}
+
+// replayCutoff is how old a snapshot may be before tail treats it as replayed history
+const replayCutoff = 5 * time.Second
+
+func newTailCmd(opts *options) *cobra.Command {
+	var agents []string
+	var count int
+	var history bool
+	cmd := &cobra.Command{
+		Use:   "tail",
+		Short: "Follow live snapshots (and agent details with --agent) until interrupted",
+		Long: "Follows the /ws stream as the token's user, reconnecting when it drops. " +
+			"Table output prints one summary line per snapshot; json prints each message.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			out := cmd.OutOrStdout()
+			errOut := cmd.ErrOrStderr()
+			seen := 0
+			sub := opts.client().NewSubscriber(client.StreamHandlers{
+				Snapshot: func(s *client.Snapshot) {
+					// the server replays its snapshot history on connect
+					if !history && time.Since(s.Timestamp) > replayCutoff {
+						return
+					}
+					if opts.output == "json" {
+						opts.print(out, s, nil)
+					} else {
+						fmt.Fprintln(out, summarize(s))
+					}
+					if seen++; count > 0 && seen >= count {
+						stop()
+					}
+				},
+				AgentDetail: func(d client.AgentDetail) {
+					if opts.output == "json" {
+						opts.print(out, d, nil)
+						return
+					}
+					fmt.Fprintf(out, "%s  %s  %s %s\n", d.Timestamp.Format("15:04:05"), d.Agent.AgentID, d.Event, d.Agent.State)
+				},
+				CommandResult: func(r client.ClientCommandResult) {
+					if !r.OK {
+						fmt.Fprintf(errOut, "%s %s refused: %s\n", r.Command, r.AgentID, r.Error)
+					}
+				},
+				Control: func(m client.ControlMessage) {
+					fmt.Fprintf(errOut, "server %s: %s\n", m.Action, m.Message)
+				},
+				Disconnected: func(err error) {
+					fmt.Fprintf(errOut, "disconnected: %v (reconnecting)\n", err)
+				},
+			})
+			for _, id := range agents {
+				sub.SubscribeAgent(id)
+			}
+			sub.Run(ctx)
+			return nil
+		},
+	}
+	cmd.Flags().StringSliceVar(&agents, "agent", nil, "also stream agent_detail for these agents (max 5)")
+	cmd.Flags().IntVar(&count, "count", 0, "exit after this many snapshots (0 = until interrupted)")
+	cmd.Flags().BoolVar(&history, "history", false, "also print the snapshot history the server replays on connect")
+	return cmd
+}
+
+// summarize renders a snapshot as one line: time, agents per state, waiting calls and longest wait
+func summarize(s *client.Snapshot) string {
+	states := make(map[client.AgentState]int)
+	agents, waiting := 0, 0
+	longest := 0.0
+	for _, data := range s.Departments {
+		for _, a := range data.Agents {
+			states[a.State]++
+			agents++
+		}
+		for _, q := range data.Queues {
+			waiting += q.WaitingCount
+			if q.LongestWaitSecs > longest {
+				longest = q.LongestWaitSecs
+			}
+		}
+	}
+
+	names := make([]string, 0, len(states))
+	for state := range states {
+		names = append(names, string(state))
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, states[client.AgentState(name)]))
+	}
+	return fmt.Sprintf("%s  agents=%d  %s  waiting=%d  longest=%.0fs  alerts=%d",
+		s.Timestamp.Format("15:04:05"), agents, strings.Join(parts, " "), waiting, longest, len(s.Alerts))
+}
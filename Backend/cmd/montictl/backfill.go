@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+func newBackfillCmd(opts *options) *cobra.Command {
+	var from, to string
+	cmd := &cobra.Command{
+		Use:   "backfill",
+		Short: "Recompute the agent and VQ daily stats of a range of days from the call records",
+		Long: "Rewrites the persisted daily stats of every day from --from to --to (inclusive, at most 92 days). " +
+			"Long ranges can take a while: raise --timeout accordingly.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if to == "" {
+				to = from
+			}
+			for _, d := range []string{from, to} {
+				if _, err := time.Parse("2006-01-02", d); err != nil {
+					return fmt.Errorf("--from and --to must be dates (YYYY-MM-DD): %w", err)
+				}
+			}
+
+			ctx, cancel := opts.context(cmd)
+			defer cancel()
+			resp, err := opts.client().BackfillDailyStats(ctx, client.BackfillRequest{From: from, To: to})
+			if err != nil {
+				return err
+			}
+			return opts.print(cmd.OutOrStdout(), resp, func(w *tabwriter.Writer) {
+				fmt.Fprintln(w, "DAYS\tCALL RECORDS\tAGENT DAYS\tVQ DAYS")
+				fmt.Fprintf(w, "%d\t%d\t%d\t%d\n", resp.Days, resp.CallRecords, resp.AgentDays, resp.VQDays)
+			})
+		},
+	}
+	cmd.Flags().StringVar(&from, "from", "", "first day to recompute (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&to, "to", "", "last day to recompute (YYYY-MM-DD, default --from)")
+	cmd.MarkFlagRequired("from")
+	return cmd
+}
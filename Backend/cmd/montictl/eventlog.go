@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+func newEventLogCmd(opts *options) *cobra.Command {
+	cmd := &cobra.Command{Use: "eventlog", Short: "Work with the Backend's event log (EVENT_LOG_FILE)"}
+
+	var until string
+	replay := &cobra.Command{
+		Use:   "replay",
+		Short: "Clear agents and calls, then rebuild them from the event log",
+		Long: "Replays the Backend's event log in order with routing paused. Stop the simulation first: " +
+			"messages arriving during the replay are applied but not recorded.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var req client.EventLogReplayRequest
+			if until != "" {
+				t, err := time.Parse(time.RFC3339, until)
+				if err != nil {
+					return fmt.Errorf("--until must be RFC 3339, e.g. 2026-01-02T15:04:05Z: %w", err)
+				}
+				req.Until = &t
+			}
+
+			ctx, cancel := opts.context(cmd)
+			defer cancel()
+			resp, err := opts.client().ReplayEventLog(ctx, req)
+			if err != nil {
+				return err
+			}
+			return opts.print(cmd.OutOrStdout(), resp, func(w *tabwriter.Writer) {
+				fmt.Fprintln(w, "EVENTS\tSKIPPED\tSEQ\tLAST EVENT\tAGENTS CLEARED\tCALLS CLEARED")
+				last := "-"
+				if resp.LastEvent != nil {
+					last = resp.LastEvent.Format(time.RFC3339)
+				}
+				fmt.Fprintf(w, "%d\t%d\t%d-%d\t%s\t%d\t%d\n", resp.Events, resp.Skipped, resp.FirstSeq, resp.LastSeq,
+					last, resp.AgentsCleared, resp.CallsCleared)
+				types := make([]string, 0, len(resp.ByType))
+				for t := range resp.ByType {
+					types = append(types, t)
+				}
+				sort.Strings(types)
+				fmt.Fprintln(w, "\nTYPE\tEVENTS")
+				for _, t := range types {
+					fmt.Fprintf(w, "%s\t%d\n", t, resp.ByType[t])
+				}
+			})
+		},
+	}
+	replay.Flags().StringVar(&until, "until", "", "replay only events up to this RFC 3339 instant, e.g. just before an incident")
+	cmd.AddCommand(replay)
+	return cmd
+}
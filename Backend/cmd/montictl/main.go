@@ -0,0 +1,106 @@
+// Command montictl drives the Backend admin API and the AgentSim control API from a shell:
+// simulation control, call injection, agent lists, live snapshots, scripted scenarios,
+// report exports and daily stats backfills.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// options are the global flags shared by every command
+type options struct {
+	backendURL  string
+	agentSimURL string
+	token       string
+	output      string
+	timeout     time.Duration
+}
+
+func newRootCmd() *cobra.Command {
+	opts := &options{}
+	root := &cobra.Command{
+		Use:          "montictl",
+		Short:        "Operate a MONTI Backend and its AgentSim",
+		SilenceUsage: true,
+	}
+
+	f := root.PersistentFlags()
+	f.StringVar(&opts.backendURL, "backend", envOr("MONTI_BACKEND_URL", "http://localhost:8080"), "Backend base URL ($MONTI_BACKEND_URL)")
+	f.StringVar(&opts.agentSimURL, "agentsim", os.Getenv("AGENTSIM_URL"), "AgentSim control API; when empty, sim and calls commands go through the Backend admin API ($AGENTSIM_URL)")
+	f.StringVar(&opts.token, "token", os.Getenv("MONTI_TOKEN"), "OIDC access token for the Backend ($MONTI_TOKEN)")
+	f.StringVarP(&opts.output, "output", "o", "table", "output format: table or json")
+	f.DurationVar(&opts.timeout, "timeout", 10*time.Second, "timeout per request")
+
+	root.AddCommand(
+		newSimCmd(opts),
+		newCallsCmd(opts),
+		newAgentsCmd(opts),
+		newTailCmd(opts),
+		newScenarioCmd(opts),
+		newReportCmd(opts),
+		newEventLogCmd(opts),
+		newBackfillCmd(opts),
+	)
+	return root
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// client returns a Backend client with the token applied
+func (o *options) client() *client.Client {
+	c := client.NewClient(o.backendURL)
+	c.SetToken(o.token)
+	return c
+}
+
+// sim returns AgentSim directly when --agentsim is set, else the Backend's admin proxy
+func (o *options) sim() simAPI {
+	if o.agentSimURL != "" {
+		return newAgentSim(o.agentSimURL, o.timeout)
+	}
+	return o.client()
+}
+
+// context returns a context bounded by --timeout
+func (o *options) context(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(cmd.Context(), o.timeout)
+}
+
+// print writes v as indented JSON, or through table when the output format is table.
+// Commands without a table layout always print JSON.
+func (o *options) print(w io.Writer, v interface{}, table func(w *tabwriter.Writer)) error {
+	switch o.output {
+	case "json":
+	case "table":
+		if table != nil {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			table(tw)
+			return tw.Flush()
+		}
+	default:
+		return fmt.Errorf("unknown output format %q (table or json)", o.output)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/pkg/client"
+)
+
+// run executes montictl with args and returns its output
+func run(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	cmd := newRootCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(args)
+	err := cmd.ExecuteContext(context.Background())
+	return out.String(), err
+}
+
+func TestSimCommandsUseAgentSimDirectly(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/status":
+			json.NewEncoder(w).Encode(client.SimStatus{Running: true, ActiveAgents: 200, TotalAgents: 2000})
+		case "/calls/inject":
+			var req client.InjectCallsRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			json.NewEncoder(w).Encode(client.InjectCallsResponse{Injected: req.Count})
+		case "/start":
+			w.Write([]byte(`{"message":"simulation started"}`))
+		}
+	}))
+	defer srv.Close()
+
+	out, err := run(t, "--agentsim", srv.URL, "sim", "status")
+	if err != nil || !strings.Contains(out, "true") || !strings.Contains(out, "200") {
+		t.Errorf("unexpected status output %q (%v)", out, err)
+	}
+	if out, err := run(t, "--agentsim", srv.URL, "-o", "json", "calls", "inject", "--count", "7"); err != nil || !strings.Contains(out, `"injected": 7`) {
+		t.Errorf("unexpected inject output %q (%v)", out, err)
+	}
+	if _, err := run(t, "--agentsim", srv.URL, "sim", "start", "0"); err == nil {
+		t.Error("expected error for zero agents")
+	}
+	if len(calls) != 2 {
+		t.Errorf("expected 2 requests, got %v", calls)
+	}
+}
+
+func TestAgentsListThroughBackend(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		query = r.URL.RawQuery
+		json.NewEncoder(w).Encode(client.AgentList{
+			Agents: []client.AgentInfo{{AgentID: "agent-7", State: "available", Department: "sales", Team: "t1"}},
+			Count:  1,
+		})
+	}))
+	defer srv.Close()
+
+	out, err := run(t, "--backend", srv.URL, "--token", "tok", "agents", "list", "--team", "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "team=t1" {
+		t.Errorf("expected team filter, got %q", query)
+	}
+	if !strings.Contains(out, "agent-7") || !strings.Contains(out, "available") {
+		t.Errorf("unexpected output %q", out)
+	}
+}
+
+func TestScenario(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "peak.yaml")
+	os.WriteFile(path, []byte(`
+name: peak
+steps:
+  - {action: start, agents: 200}
+  - {action: wait, duration: 10ms}
+  - {action: inject, count: 5, vq: sales_inbound}
+  - {action: stop}
+`), 0o644)
+
+	sc, err := LoadScenario(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := &recordingSim{}
+	var log bytes.Buffer
+	if err := sc.Run(context.Background(), sim, time.Second, &log); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(sim.calls, ","); got != "start 200,inject 5 sales_inbound,stop" {
+		t.Errorf("unexpected calls %s", got)
+	}
+
+	os.WriteFile(path, []byte(`steps: [{action: scale}]`), 0o644)
+	if _, err := LoadScenario(path); err == nil {
+		t.Error("expected error for scale without agents")
+	}
+}
+
+type recordingSim struct {
+	calls []string
+}
+
+func (s *recordingSim) GetStatus(context.Context) (*client.SimStatus, error) {
+	return &client.SimStatus{}, nil
+}
+
+func (s *recordingSim) Start(_ context.Context, n int) error {
+	s.calls = append(s.calls, "start "+strconv.Itoa(n))
+	return nil
+}
+
+func (s *recordingSim) Stop(context.Context) error {
+	s.calls = append(s.calls, "stop")
+	return nil
+}
+
+func (s *recordingSim) Scale(_ context.Context, n int) error {
+	s.calls = append(s.calls, "scale "+strconv.Itoa(n))
+	return nil
+}
+
+func (s *recordingSim) Inject(_ context.Context, count int, vq string) (*client.InjectCallsResponse, error) {
+	s.calls = append(s.calls, "inject "+strconv.Itoa(count)+" "+vq)
+	return &client.InjectCallsResponse{Injected: count}, nil
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+func newReportCmd(opts *options) *cobra.Command {
+	var outFile string
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Export reports (to stdout, or to a file with --out)",
+	}
+	cmd.PersistentFlags().StringVar(&outFile, "out", "", "write the report to this file instead of stdout")
+
+	// writer returns the report destination and a function that closes it
+	writer := func(cmd *cobra.Command) (io.Writer, func() error, error) {
+		if outFile == "" {
+			return cmd.OutOrStdout(), func() error { return nil }, nil
+		}
+		f, err := os.Create(outFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		return f, f.Close, nil
+	}
+	// export runs fetch and prints its result
+	export := func(cmd *cobra.Command, fetch func(cmd *cobra.Command) (interface{}, func(w *tabwriter.Writer), error)) error {
+		v, table, err := fetch(cmd)
+		if err != nil {
+			return err
+		}
+		w, closeFn, err := writer(cmd)
+		if err != nil {
+			return err
+		}
+		if err := opts.print(w, v, table); err != nil {
+			closeFn()
+			return err
+		}
+		return closeFn()
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "history AGENT",
+		Short: "An agent's daily stats",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return export(cmd, func(cmd *cobra.Command) (interface{}, func(*tabwriter.Writer), error) {
+				ctx, cancel := opts.context(cmd)
+				defer cancel()
+				stats, err := opts.client().GetAgentHistory(ctx, args[0])
+				return stats, func(w *tabwriter.Writer) {
+					fmt.Fprintln(w, "DATE\tCALLS\tTALK\tHOLD\tWRAP\tBREAK\tAHT\tOCCUPANCY\tLOGIN")
+					for _, s := range stats {
+						fmt.Fprintf(w, "%s\t%d\t%.0f\t%.0f\t%.0f\t%.0f\t%.0f\t%.1f%%\t%.0f\n", s.Date, s.TotalCalls,
+							s.TotalTalkTime, s.TotalHoldTime, s.TotalWrapTime, s.TotalBreakTime, s.AvgHandleTime, s.Occupancy, s.LoginDuration)
+					}
+				}, err
+			})
+		},
+	})
+
+	var date string
+	calls := &cobra.Command{
+		Use:   "calls AGENT",
+		Short: "An agent's calls on one day",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return export(cmd, func(cmd *cobra.Command) (interface{}, func(*tabwriter.Writer), error) {
+				ctx, cancel := opts.context(cmd)
+				defer cancel()
+				records, err := opts.client().GetAgentCalls(ctx, args[0], url.Values{"date": {date}})
+				return records, func(w *tabwriter.Writer) {
+					fmt.Fprintln(w, "CALL\tVQ\tCOMPLETED\tWAIT\tTALK\tHOLD\tWRAP\tIN SL")
+					for _, r := range records {
+						fmt.Fprintf(w, "%s\t%s\t%s\t%.0f\t%.0f\t%.0f\t%.0f\t%t\n", r.CallID, r.VQ, r.CompleteTime,
+							r.WaitTime, r.TalkTime, r.HoldTime, r.WrapTime, r.AnsweredInSL)
+					}
+				}, err
+			})
+		},
+	}
+	calls.Flags().StringVar(&date, "date", "", "day as YYYY-MM-DD (required)")
+	calls.MarkFlagRequired("date")
+	cmd.AddCommand(calls)
+
+	var adherenceDate string
+	adherence := &cobra.Command{
+		Use:   "adherence agent|team NAME",
+		Short: "Schedule adherence of an agent or a team",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			q := url.Values{}
+			if adherenceDate != "" {
+				q.Set("date", adherenceDate)
+			}
+			return export(cmd, func(cmd *cobra.Command) (interface{}, func(*tabwriter.Writer), error) {
+				ctx, cancel := opts.context(cmd)
+				defer cancel()
+				switch args[0] {
+				case "agent":
+					v, err := opts.client().GetAgentAdherence(ctx, args[1], q)
+					return v, nil, err
+				case "team":
+					v, err := opts.client().GetTeamAdherence(ctx, args[1], q)
+					return v, nil, err
+				}
+				return nil, nil, fmt.Errorf("expected agent or team, got %q", args[0])
+			})
+		},
+	}
+	adherence.Flags().StringVar(&adherenceDate, "date", "", "day as YYYY-MM-DD (default today)")
+	cmd.AddCommand(adherence)
+
+	var vq, interval string
+	forecast := &cobra.Command{
+		Use:   "forecast",
+		Short: "Required staffing per VQ",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			q := url.Values{}
+			if vq != "" {
+				q.Set("vq", vq)
+			}
+			if interval != "" {
+				q.Set("interval", interval)
+			}
+			return export(cmd, func(cmd *cobra.Command) (interface{}, func(*tabwriter.Writer), error) {
+				ctx, cancel := opts.context(cmd)
+				defer cancel()
+				v, err := opts.client().GetStaffingForecast(ctx, q)
+				return v, nil, err
+			})
+		},
+	}
+	forecast.Flags().StringVar(&vq, "vq", "", "single VQ (default all)")
+	forecast.Flags().StringVar(&interval, "interval", "", "forecast interval, e.g. 30m (default 15m)")
+	cmd.AddCommand(forecast)
+
+	var alertDate, alertStatus string
+	alerts := &cobra.Command{
+		Use:   "alerts",
+		Short: "Live alerts, or the alert history of a day with --date",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			q := url.Values{}
+			if alertDate != "" {
+				q.Set("date", alertDate)
+			}
+			if alertStatus != "" {
+				q.Set("status", alertStatus)
+			}
+			return export(cmd, func(cmd *cobra.Command) (interface{}, func(*tabwriter.Writer), error) {
+				ctx, cancel := opts.context(cmd)
+				defer cancel()
+				v, err := opts.client().ListAlerts(ctx, q)
+				return v, nil, err
+			})
+		},
+	}
+	alerts.Flags().StringVar(&alertDate, "date", "", "day as YYYY-MM-DD")
+	alerts.Flags().StringVar(&alertStatus, "status", "", "firing, acknowledged or resolved")
+	cmd.AddCommand(alerts)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "roster",
+		Short: "The roster as CSV (same format as roster import)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := opts.context(cmd)
+			defer cancel()
+			data, err := opts.client().ExportRosterCSV(ctx)
+			if err != nil {
+				return err
+			}
+			w, closeFn, err := writer(cmd)
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(data); err != nil {
+				closeFn()
+				return err
+			}
+			return closeFn()
+		},
+	})
+	return cmd
+}
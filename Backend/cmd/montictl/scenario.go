@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario is a scripted sequence of simulation steps, read from YAML:
+//
+//	name: morning peak
+//	steps:
+//	  - {action: start, agents: 200}
+//	  - {action: wait, duration: 2m}
+//	  - {action: inject, count: 50, vq: sales_inbound}
+//	  - {action: scale, agents: 400}
+//	  - {action: wait, duration: 5m}
+//	  - {action: stop}
+type Scenario struct {
+	Name  string `yaml:"name"`
+	Steps []Step `yaml:"steps"`
+}
+
+// Step is one scenario action
+type Step struct {
+	Action   string `yaml:"action"` // start, scale, stop, inject or wait
+	Agents   int    `yaml:"agents,omitempty"`
+	Count    int    `yaml:"count,omitempty"`
+	VQ       string `yaml:"vq,omitempty"`
+	Duration string `yaml:"duration,omitempty"`
+
+	wait time.Duration
+}
+
+// LoadScenario reads and validates a scenario file
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario: %w", err)
+	}
+	var sc Scenario
+	if err := yaml.Unmarshal(data, &sc); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario: %w", err)
+	}
+	if len(sc.Steps) == 0 {
+		return nil, fmt.Errorf("scenario has no steps")
+	}
+	for i := range sc.Steps {
+		if err := sc.Steps[i].validate(); err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	return &sc, nil
+}
+
+func (s *Step) validate() error {
+	switch s.Action {
+	case "start", "scale":
+		if s.Agents <= 0 {
+			return fmt.Errorf("%s needs agents > 0", s.Action)
+		}
+	case "stop":
+	case "inject":
+		if s.Count <= 0 || s.Count > 1000 {
+			return fmt.Errorf("inject needs a count between 1 and 1000")
+		}
+	case "wait":
+		d, err := time.ParseDuration(s.Duration)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("wait needs a positive duration, e.g. 30s")
+		}
+		s.wait = d
+	default:
+		return fmt.Errorf("unknown action %q (start, scale, stop, inject or wait)", s.Action)
+	}
+	return nil
+}
+
+func (s Step) String() string {
+	switch s.Action {
+	case "start", "scale":
+		return fmt.Sprintf("%s %d agents", s.Action, s.Agents)
+	case "inject":
+		if s.VQ != "" {
+			return fmt.Sprintf("inject %d calls into %s", s.Count, s.VQ)
+		}
+		return fmt.Sprintf("inject %d calls", s.Count)
+	case "wait":
+		return "wait " + s.wait.String()
+	}
+	return s.Action
+}
+
+// Run executes the steps in order, stopping at the first failure or when ctx is done
+func (sc *Scenario) Run(ctx context.Context, sim simAPI, timeout time.Duration, log io.Writer) error {
+	for i, step := range sc.Steps {
+		fmt.Fprintf(log, "[%d/%d] %s\n", i+1, len(sc.Steps), step)
+		if step.Action == "wait" {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(step.wait):
+			}
+			continue
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := step.apply(reqCtx, sim)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("step %d (%s): %w", i+1, step, err)
+		}
+	}
+	return nil
+}
+
+func (s Step) apply(ctx context.Context, sim simAPI) error {
+	switch s.Action {
+	case "start":
+		return sim.Start(ctx, s.Agents)
+	case "scale":
+		return sim.Scale(ctx, s.Agents)
+	case "stop":
+		return sim.Stop(ctx)
+	case "inject":
+		_, err := sim.Inject(ctx, s.Count, s.VQ)
+		return err
+	}
+	return nil
+}
+
+func newScenarioCmd(opts *options) *cobra.Command {
+	cmd := &cobra.Command{Use: "scenario", Short: "Run scripted simulation scenarios"}
+
+	var dryRun bool
+	run := &cobra.Command{
+		Use:   "run FILE",
+		Short: "Run the steps of a YAML scenario in order",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sc, err := LoadScenario(args[0])
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			if dryRun {
+				for i, step := range sc.Steps {
+					fmt.Fprintf(out, "[%d/%d] %s\n", i+1, len(sc.Steps), step)
+				}
+				return nil
+			}
+			if sc.Name != "" {
+				fmt.Fprintf(out, "running scenario %q\n", sc.Name)
+			}
+			return sc.Run(cmd.Context(), opts.sim(), opts.timeout, out)
+		},
+	}
+	run.Flags().BoolVar(&dryRun, "dry-run", false, "validate and print the steps without running them")
+	cmd.AddCommand(run)
+	return cmd
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+// simAPI is satisfied by the Backend client (admin proxy) and by agentSim (direct)
+type simAPI interface {
+	GetStatus(ctx context.Context) (*client.SimStatus, error)
+	Start(ctx context.Context, activeAgents int) error
+	Stop(ctx context.Context) error
+	Scale(ctx context.Context, activeAgents int) error
+	Inject(ctx context.Context, count int, vq string) (*client.InjectCallsResponse, error)
+}
+
+// agentSim talks to AgentSim's control API, whose bodies match the Backend's admin proxy
+type agentSim struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func newAgentSim(baseURL string, timeout time.Duration) *agentSim {
+	return &agentSim{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+func (a *agentSim) GetStatus(ctx context.Context) (*client.SimStatus, error) {
+	var status client.SimStatus
+	if err := a.do(ctx, http.MethodGet, "/status", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+func (a *agentSim) Start(ctx context.Context, activeAgents int) error {
+	return a.do(ctx, http.MethodPost, "/start", client.SimScaleRequest{ActiveAgents: activeAgents}, nil)
+}
+
+func (a *agentSim) Stop(ctx context.Context) error {
+	return a.do(ctx, http.MethodPost, "/stop", nil, nil)
+}
+
+func (a *agentSim) Scale(ctx context.Context, activeAgents int) error {
+	return a.do(ctx, http.MethodPost, "/scale", client.SimScaleRequest{ActiveAgents: activeAgents}, nil)
+}
+
+func (a *agentSim) Inject(ctx context.Context, count int, vq string) (*client.InjectCallsResponse, error) {
+	var resp client.InjectCallsResponse
+	if err := a.do(ctx, http.MethodPost, "/calls/inject", client.InjectCallsRequest{Count: count, VQ: vq}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (a *agentSim) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("agentsim: %d %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func newSimCmd(opts *options) *cobra.Command {
+	cmd := &cobra.Command{Use: "sim", Short: "Control the agent simulation"}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Show the simulation status",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := opts.context(cmd)
+			defer cancel()
+			status, err := opts.sim().GetStatus(ctx)
+			if err != nil {
+				return err
+			}
+			return opts.print(cmd.OutOrStdout(), status, func(w *tabwriter.Writer) {
+				fmt.Fprintln(w, "RUNNING\tACTIVE\tTOTAL\tEVENTS SENT\tSTARTED")
+				started := "-"
+				if status.StartedAt != nil {
+					started = status.StartedAt.Format(time.RFC3339)
+				}
+				fmt.Fprintf(w, "%t\t%d\t%d\t%d\t%s\n", status.Running, status.ActiveAgents, status.TotalAgents, status.EventsSent, started)
+			})
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "start AGENTS",
+		Short: "Start the simulation with AGENTS active agents",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n, err := agentCount(args[0])
+			if err != nil {
+				return err
+			}
+			ctx, cancel := opts.context(cmd)
+			defer cancel()
+			if err := opts.sim().Start(ctx, n); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "simulation started with %d agents\n", n)
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "stop",
+		Short: "Stop the simulation",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := opts.context(cmd)
+			defer cancel()
+			if err := opts.sim().Stop(ctx); err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "simulation stopped")
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "scale AGENTS",
+		Short: "Change the number of active agents",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n, err := agentCount(args[0])
+			if err != nil {
+				return err
+			}
+			ctx, cancel := opts.context(cmd)
+			defer cancel()
+			if err := opts.sim().Scale(ctx, n); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "simulation scaled to %d agents\n", n)
+			return nil
+		},
+	})
+	cmd.AddCommand(newSimPresetCmd(opts))
+	return cmd
+}
+
+// newSimPresetCmd lists and applies the sim presets saved on the Backend; they always go
+// through the Backend admin API
+func newSimPresetCmd(opts *options) *cobra.Command {
+	cmd := &cobra.Command{Use: "preset", Short: "List and apply the Backend's saved sim presets"}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the saved presets",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := opts.context(cmd)
+			defer cancel()
+			presets, err := opts.client().ListSimPresets(ctx)
+			if err != nil {
+				return err
+			}
+			return opts.print(cmd.OutOrStdout(), presets, func(w *tabwriter.Writer) {
+				fmt.Fprintln(w, "NAME\tAGENTS\tPEAK FACTOR\tCALL RATES\tSTEPS\tUPDATED")
+				for _, p := range presets {
+					fmt.Fprintf(w, "%s\t%d\t%.1f\t%d\t%d\t%s\n", p.Name, p.ActiveAgents, p.PeakHourFactor, len(p.CallRates),
+						len(p.Steps), p.UpdatedAt.Format(time.RFC3339))
+				}
+			})
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "apply NAME",
+		Short: "Configure AgentSim from a preset and start its scenario script",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := opts.context(cmd)
+			defer cancel()
+			resp, err := opts.client().ApplySimPreset(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			return opts.print(cmd.OutOrStdout(), resp, func(w *tabwriter.Writer) {
+				fmt.Fprintln(w, "PRESET\tCALL CONFIG\tAGENTS\tSTEPS")
+				agents := resp.Agents
+				if agents == "" {
+					agents = "-"
+				}
+				fmt.Fprintf(w, "%s\t%t\t%s\t%d\n", resp.Preset, resp.CallConfig, agents, resp.Steps)
+			})
+		},
+	})
+	return cmd
+}
+
+func newCallsCmd(opts *options) *cobra.Command {
+	cmd := &cobra.Command{Use: "calls", Short: "Manage calls"}
+
+	var count int
+	var vq string
+	inject := &cobra.Command{
+		Use:   "inject",
+		Short: "Enqueue calls (round-robin over all VQs unless --vq is set)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := opts.context(cmd)
+			defer cancel()
+			resp, err := opts.sim().Inject(ctx, count, vq)
+			if err != nil {
+				return err
+			}
+			return opts.print(cmd.OutOrStdout(), resp, func(w *tabwriter.Writer) {
+				fmt.Fprintln(w, "INJECTED\tERRORS")
+				fmt.Fprintf(w, "%d\t%d\n", resp.Injected, resp.Errors)
+			})
+		},
+	}
+	inject.Flags().IntVar(&count, "count", 10, "number of calls (1-1000)")
+	inject.Flags().StringVar(&vq, "vq", "", "target VQ, e.g. sales_inbound")
+	cmd.AddCommand(inject)
+	return cmd
+}
+
+// agentCount parses a positive agent count
+func agentCount(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("agent count must be a positive number, got %q", s)
+	}
+	return n, nil
+}
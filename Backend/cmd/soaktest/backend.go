@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/aggregator"
+	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/callqueue"
+	"github.com/dennisdiepolder/monti/backend/internal/config"
+	"github.com/dennisdiepolder/monti/backend/internal/ingestion"
+	"github.com/dennisdiepolder/monti/backend/internal/storage"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/dennisdiepolder/monti/backend/internal/websocket"
+	"github.com/rs/zerolog"
+)
+
+// backend is the part of cmd/server that agents and dashboards exercise, wired the same way:
+// tracker, call queues and routing, agent and dashboard hubs, aggregator and stale checker,
+// served on a loopback port without auth and storage
+type backend struct {
+	tracker  *cache.AgentStateTracker
+	queues   *callqueue.CallQueueManager
+	hub      *websocket.Hub
+	agentHub *websocket.AgentHub
+	server   *http.Server
+	url      string // http://127.0.0.1:<port>
+	cancel   context.CancelFunc
+}
+
+// startBackend starts the in-process backend with the settings of cfg
+func startBackend(cfg *config.Config, logger zerolog.Logger) (*backend, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	hub := websocket.NewHub(logger)
+	hub.SetSnapshotHistorySize(cfg.SnapshotHistorySize)
+	go hub.Run()
+
+	tracker := cache.NewAgentStateTracker()
+	tracker.SetStaleThreshold(cfg.StaleThreshold)
+	tracker.SetReconnectGrace(cfg.ReconnectGrace)
+	tracker.SetKPIHistorySize(cfg.KPIHistorySize)
+
+	eventCache := cache.NewEventCache(cfg.EventRetention)
+	processor := ingestion.NewDefaultProcessor(tracker, logger)
+	processor.SetEventCache(eventCache)
+
+	queues := callqueue.NewCallQueueManager(tracker, logger)
+	queues.SetStore(storage.NewNoopStore())
+	queues.SetChatMaxSessions(cfg.ChatMaxSessions)
+	processor.SetCallCompleter(queues)
+
+	agentHub := websocket.NewAgentHub(tracker, processor, logger)
+	agentHub.SetDetailPublisher(hub)
+	agentHub.SetWorkers(ingestion.NewWorkers(cfg.IngestWorkers))
+	go agentHub.Run()
+
+	routing := callqueue.NewRoutingLoop(queues, agentHub, logger)
+	routing.SetInterval(cfg.RoutingInterval)
+	routing.SetOrphanedCalls(cfg.OrphanedCallGrace, cfg.OrphanedCallAction)
+	go routing.Start(ctx)
+
+	agg := aggregator.NewAggregator(eventCache, tracker, hub, logger)
+	agg.SetCallQueue(queues)
+	agg.SetRollupDimensions(cfg.RollupDimensions)
+	agg.SetCadence(aggregator.Cadence{
+		Interval:     cfg.AggregationInterval,
+		Rollups:      cfg.RollupInterval,
+		Trends:       cfg.TrendInterval,
+		Leaderboards: cfg.LeaderboardInterval,
+	})
+	agg.SetLoadShedding(aggregator.LoadShedding{
+		AggregationLatency: cfg.LoadShedAggregationLatency,
+		BroadcastLatency:   cfg.LoadShedBroadcastLatency,
+	})
+	go agg.Start(ctx)
+
+	go func() {
+		ticker := time.NewTicker(cfg.StaleCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				tracker.CheckStaleAgents()
+				tracker.RemoveDisconnected(cfg.DisconnectedTTL)
+			}
+		}
+	}()
+
+	agentHandler := websocket.NewAgentHandler(agentHub, logger)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", websocket.NewHandler(hub, cfg, logger).ServeHTTP)
+	mux.HandleFunc("/ws/agent", agentHandler.ServeHTTP)
+	mux.HandleFunc("/ws/agent/multiplexed", agentHandler.ServeMultiplexedHTTP)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go server.Serve(listener)
+
+	return &backend{
+		tracker:  tracker,
+		queues:   queues,
+		hub:      hub,
+		agentHub: agentHub,
+		server:   server,
+		url:      "http://" + listener.Addr().String(),
+		cancel:   cancel,
+	}, nil
+}
+
+// queueSizes sums waiting and active calls over all queues
+func (b *backend) queueSizes() (waiting, active int) {
+	for _, snapshots := range b.queues.GetAllSnapshots() {
+		for _, s := range snapshots {
+			waiting += s.WaitingCount
+			active += s.ActiveCount
+		}
+	}
+	return waiting, active
+}
+
+// enqueueCall puts a call into vq, as POST /internal/call/enqueue does
+func (b *backend) enqueueCall(vq types.VQName, callID string) {
+	b.queues.EnqueueCall(vq, callID)
+}
+
+// stop drains the hubs and stops the background loops; the error names the first hub whose
+// connections did not drain before ctx was done
+func (b *backend) stop(ctx context.Context) error {
+	agentErr := b.agentHub.Shutdown(ctx)
+	hubErr := b.hub.Shutdown(ctx)
+	b.cancel()
+	b.server.Shutdown(ctx)
+	switch {
+	case agentErr != nil:
+		return fmt.Errorf("agent connections did not drain: %w", agentErr)
+	case hubErr != nil:
+		return fmt.Errorf("dashboard connections did not drain: %w", hubErr)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// orphanReads is how often the orphaned calls are counted per sample; calls that were just
+// assigned and are still on their way to the fleet only show up in some of the reads
+const orphanReads = 3
+
+// sample is the state of the process at the low point of a scaling cycle, after the agents
+// that logged off have run out their reconnect grace
+type sample struct {
+	Cycle         int       `json:"cycle"`
+	At            time.Time `json:"at"`
+	Agents        int       `json:"agents"` // fleet agents online
+	Goroutines    int       `json:"goroutines"`
+	HeapMB        float64   `json:"heapMB"`        // live heap after a GC
+	TrackedAgents int       `json:"trackedAgents"` // agents in the state tracker, any status
+	Connected     int       `json:"connected"`     // tracker agents with status connected
+	HubAgents     int       `json:"hubAgents"`     // agents registered with the AgentHub
+	Dashboards    int       `json:"dashboards"`    // /ws clients registered with the Hub
+	WaitingCalls  int       `json:"waitingCalls"`
+	ActiveCalls   int       `json:"activeCalls"`
+	Backlog       int       `json:"backlog"` // unprocessed inbound agent messages
+	BacklogCap    int       `json:"backlogCap"`
+	OrphanedCalls int       `json:"orphanedCalls"` // active calls no online agent holds
+}
+
+// takeSample measures the backend and fleet; call only while the fleet is not scaling
+func takeSample(cycle int, b *backend, f *fleet) sample {
+	runtime.GC()
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	s := sample{
+		Cycle:         cycle,
+		At:            time.Now(),
+		Goroutines:    runtime.NumGoroutine(),
+		HeapMB:        float64(mem.HeapAlloc) / (1 << 20),
+		TrackedAgents: b.tracker.Count(),
+		HubAgents:     b.agentHub.AgentCount(),
+		Dashboards:    b.hub.ClientCount(),
+		OrphanedCalls: -1,
+	}
+	s.Backlog, s.BacklogCap = b.agentHub.MessageBacklog()
+	s.Connected, _, _ = b.tracker.GetConnectionStats()
+	for i := 0; i < orphanReads; i++ {
+		if i > 0 {
+			time.Sleep(fleetTick)
+		}
+		agents, held := f.online()
+		waiting, active := b.queueSizes()
+		orphaned := max(active-held, 0)
+		if s.OrphanedCalls < 0 || orphaned < s.OrphanedCalls {
+			s.Agents, s.WaitingCalls, s.ActiveCalls, s.OrphanedCalls = agents, waiting, active, orphaned
+		}
+	}
+	return s
+}
+
+// check compares a sample with the limits and, for growth, with the first cycle's sample
+func check(s, baseline sample, opts options, poolSize int) []string {
+	var violations []string
+	fail := func(format string, args ...any) {
+		violations = append(violations, fmt.Sprintf("cycle %d: ", s.Cycle)+fmt.Sprintf(format, args...))
+	}
+
+	if growth := s.Goroutines - baseline.Goroutines; growth > opts.MaxGoroutineGrowth {
+		fail("goroutines grew by %d to %d (limit %d)", growth, s.Goroutines, opts.MaxGoroutineGrowth)
+	}
+	if growth := s.HeapMB - baseline.HeapMB; growth > opts.MaxHeapGrowthMB {
+		fail("heap grew by %.1f MB to %.1f MB (limit %.0f MB)", growth, s.HeapMB, opts.MaxHeapGrowthMB)
+	}
+	if s.TrackedAgents > poolSize {
+		fail("tracker holds %d agents, the fleet only has %d agent IDs", s.TrackedAgents, poolSize)
+	}
+	if s.Connected != s.Agents {
+		fail("tracker shows %d agents connected, %d are online", s.Connected, s.Agents)
+	}
+	if s.HubAgents != s.Agents {
+		fail("AgentHub holds %d agents, %d are online", s.HubAgents, s.Agents)
+	}
+	if s.Dashboards != opts.Dashboards {
+		fail("Hub holds %d dashboards, %d are connected", s.Dashboards, opts.Dashboards)
+	}
+	if s.Backlog > s.BacklogCap/2 {
+		fail("%d of %d inbound agent message slots in use", s.Backlog, s.BacklogCap)
+	}
+	if s.WaitingCalls > opts.MaxWaitingCalls {
+		fail("%d calls waiting (limit %d)", s.WaitingCalls, opts.MaxWaitingCalls)
+	}
+	if s.OrphanedCalls > opts.MaxOrphanedCalls {
+		fail("%d active calls held by no online agent (limit %d)", s.OrphanedCalls, opts.MaxOrphanedCalls)
+	}
+	return violations
+}
@@ -0,0 +1,408 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog"
+)
+
+const (
+	fleetTick         = 250 * time.Millisecond
+	heartbeatInterval = 2 * time.Second
+	writeTimeout      = 5 * time.Second
+	redialDelay       = 500 * time.Millisecond
+)
+
+var (
+	fleetDepartments = []types.Department{types.DeptSales, types.DeptSupport, types.DeptTechnical, types.DeptRetention}
+	fleetLocations   = []types.Location{types.LocationBerlin, types.LocationMunich, types.LocationHamburg, types.LocationFrankfurt, types.LocationRemote}
+)
+
+// fleetAgent is one simulated agent; its fields are guarded by fleet.mu
+type fleetAgent struct {
+	id            string
+	dept          types.Department
+	location      types.Location
+	team          string
+	state         types.AgentState
+	stateSince    time.Time
+	calls         map[string]time.Time // assigned calls -> when the agent hangs up
+	acwUntil      time.Time
+	nextHeartbeat time.Time
+}
+
+// link is one agent WebSocket: a single agent on /ws/agent or many on /ws/agent/multiplexed
+type link struct {
+	multiplexed bool
+	agents      []*fleetAgent
+
+	conn    *websocket.Conn // nil while down (guarded by fleet.mu)
+	writeMu sync.Mutex
+	done    chan struct{} // closed when the read loop of conn exits
+}
+
+// outMsg is a message to write once fleet.mu is released
+type outMsg struct {
+	link *link
+	conn *websocket.Conn
+	data []byte
+}
+
+// fleet drives simulated agents against the backend the way AgentSim does: register,
+// heartbeats, state changes, and call_complete after each assigned call, with ACW in between.
+// Links are activated in a fixed order, so scaling down always drops the newest links and the
+// remaining agents keep their IDs across cycles.
+type fleet struct {
+	url    string // ws://host:port
+	opts   options
+	logger zerolog.Logger
+
+	mu     sync.Mutex
+	rng    *rand.Rand
+	links  []*link
+	active int // links[:active] are in use
+
+	writeErrors atomic.Int64
+	assigns     atomic.Int64
+	completes   atomic.Int64
+}
+
+// newFleet lays out opts.MaxAgents agents: each multiplexed link of opts.MuxSize agents is
+// followed by enough single-connection agents to make up opts.SingleShare of the fleet
+func newFleet(baseURL string, opts options, logger zerolog.Logger) *fleet {
+	f := &fleet{
+		url:    "ws" + strings.TrimPrefix(baseURL, "http"),
+		opts:   opts,
+		logger: logger,
+		rng:    rand.New(rand.NewSource(opts.Seed)),
+	}
+	singlesPerMux := 0
+	if opts.SingleShare > 0 && opts.SingleShare < 1 {
+		singlesPerMux = int(float64(opts.MuxSize)*opts.SingleShare/(1-opts.SingleShare) + 0.5)
+	}
+
+	n := 0
+	newAgent := func() *fleetAgent {
+		a := &fleetAgent{
+			id:       fmt.Sprintf("soak-%05d", n),
+			dept:     fleetDepartments[n%len(fleetDepartments)],
+			location: fleetLocations[n%len(fleetLocations)],
+			team:     fmt.Sprintf("soak-team-%d", n/25),
+			calls:    make(map[string]time.Time),
+		}
+		n++
+		return a
+	}
+	for n < opts.MaxAgents {
+		if opts.MuxSize > 0 && opts.SingleShare < 1 {
+			l := &link{multiplexed: true}
+			for len(l.agents) < opts.MuxSize && n < opts.MaxAgents {
+				l.agents = append(l.agents, newAgent())
+			}
+			f.links = append(f.links, l)
+		}
+		singles := singlesPerMux
+		if opts.MuxSize == 0 || opts.SingleShare >= 1 {
+			singles = opts.MaxAgents
+		}
+		for i := 0; i < singles && n < opts.MaxAgents; i++ {
+			f.links = append(f.links, &link{agents: []*fleetAgent{newAgent()}})
+		}
+	}
+	return f
+}
+
+// poolSize is the number of distinct agent IDs the fleet can use
+func (f *fleet) poolSize() int {
+	n := 0
+	for _, l := range f.links {
+		n += len(l.agents)
+	}
+	return n
+}
+
+// scale connects or gracefully disconnects links until at least target agents are online
+func (f *fleet) scale(target int) {
+	for {
+		f.mu.Lock()
+		online := 0
+		for _, l := range f.links[:f.active] {
+			online += len(l.agents)
+		}
+		var next *link
+		grow := online < target && f.active < len(f.links)
+		shrink := f.active > 0 && online-len(f.links[f.active-1].agents) >= target
+		switch {
+		case grow:
+			next = f.links[f.active]
+			f.active++
+		case shrink:
+			f.active--
+			next = f.links[f.active]
+		}
+		f.mu.Unlock()
+
+		switch {
+		case grow:
+			if err := f.dial(next); err != nil {
+				f.logger.Warn().Err(err).Msg("failed to connect agent link")
+			}
+		case shrink:
+			f.logOff(next)
+		default:
+			return
+		}
+	}
+}
+
+// online returns the number of agents on connected links and the calls they hold
+func (f *fleet) online() (agents, calls int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, l := range f.links[:f.active] {
+		if l.conn == nil {
+			continue
+		}
+		agents += len(l.agents)
+		for _, a := range l.agents {
+			calls += len(a.calls)
+		}
+	}
+	return agents, calls
+}
+
+// dial connects a link and registers its agents in their current state
+func (f *fleet) dial(l *link) error {
+	path := "/ws/agent"
+	if l.multiplexed {
+		path = "/ws/agent/multiplexed"
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(f.url+path, nil)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var out []outMsg
+	f.mu.Lock()
+	l.conn = conn
+	l.done = make(chan struct{})
+	for _, a := range l.agents {
+		if a.state == "" {
+			a.state, a.stateSince = types.StateAvailable, now
+		}
+		a.nextHeartbeat = now.Add(heartbeatInterval)
+		out = append(out, f.message(l, types.AgentRegister{
+			Type:       "register",
+			AgentID:    a.id,
+			Department: a.dept,
+			Location:   a.location,
+			Team:       a.team,
+			State:      a.state,
+		}))
+	}
+	f.mu.Unlock()
+
+	go f.read(l, conn, l.done)
+	f.send(out)
+	return nil
+}
+
+// logOff hangs up the link's calls, closes it cleanly and resets its agents for the next cycle
+func (f *fleet) logOff(l *link) {
+	now := time.Now()
+	var out []outMsg
+	f.mu.Lock()
+	conn, done := l.conn, l.done
+	for _, a := range l.agents {
+		for callID := range a.calls {
+			out = append(out, f.complete(l, a, callID, now))
+		}
+		a.state, a.calls, a.acwUntil = "", make(map[string]time.Time), time.Time{}
+	}
+	l.conn = nil
+	f.mu.Unlock()
+	if conn == nil {
+		return
+	}
+
+	f.send(out)
+	l.writeMu.Lock()
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "scale down"), time.Now().Add(writeTimeout))
+	l.writeMu.Unlock()
+	select {
+	case <-done:
+	case <-time.After(writeTimeout):
+	}
+	conn.Close()
+}
+
+// drop cuts a random multiplexed link without a close frame and re-dials it after
+// redialDelay; its agents keep their state and calls, as after a network blip. Must not run
+// concurrently with scale.
+func (f *fleet) drop() {
+	f.mu.Lock()
+	var candidates []*link
+	for _, l := range f.links[:f.active] {
+		if l.multiplexed && l.conn != nil {
+			candidates = append(candidates, l)
+		}
+	}
+	if len(candidates) == 0 {
+		f.mu.Unlock()
+		return
+	}
+	l := candidates[f.rng.Intn(len(candidates))]
+	conn, done := l.conn, l.done
+	l.conn = nil
+	f.mu.Unlock()
+
+	conn.Close()
+	<-done
+	time.Sleep(redialDelay)
+	if err := f.dial(l); err != nil {
+		f.logger.Warn().Err(err).Msg("failed to re-dial dropped agent link")
+	}
+}
+
+// read handles call_assign messages until conn fails
+func (f *fleet) read(l *link, conn *websocket.Conn, done chan struct{}) {
+	defer close(done)
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var msg struct {
+			Type    string `json:"type"`
+			AgentID string `json:"agentId"`
+			CallID  string `json:"callId"`
+		}
+		if err := json.Unmarshal(data, &msg); err != nil || msg.Type != "call_assign" {
+			continue
+		}
+		f.assigns.Add(1)
+		out := f.assign(l, msg.AgentID, msg.CallID)
+		for i := range out {
+			// Answer on the connection the call arrived on, also while the link is logging off
+			out[i].conn = conn
+		}
+		f.send(out)
+	}
+}
+
+// assign puts an agent on an assigned call. An agent that is already talking or in ACW hangs
+// up the extra call at once, so routing races never leave calls with the fleet.
+func (f *fleet) assign(l *link, agentID, callID string) []outMsg {
+	now := time.Now()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, a := range l.agents {
+		if a.id != agentID {
+			continue
+		}
+		if a.state != types.StateAvailable {
+			return []outMsg{f.complete(l, a, callID, now)}
+		}
+		talk := time.Duration(f.rng.ExpFloat64() * float64(f.opts.TalkTime))
+		a.calls[callID] = now.Add(talk)
+		return []outMsg{f.changeState(l, a, types.StateOnCall, now)}
+	}
+	return nil
+}
+
+// tick sends due heartbeats, hangs up finished calls and ends ACW
+func (f *fleet) tick(now time.Time) {
+	var out []outMsg
+	f.mu.Lock()
+	for _, l := range f.links[:f.active] {
+		if l.conn == nil {
+			continue
+		}
+		for _, a := range l.agents {
+			hadCalls := len(a.calls) > 0
+			for callID, end := range a.calls {
+				if !now.Before(end) {
+					out = append(out, f.complete(l, a, callID, now))
+				}
+			}
+			switch {
+			case hadCalls && len(a.calls) == 0:
+				a.acwUntil = now.Add(f.opts.ACWTime)
+				out = append(out, f.changeState(l, a, types.StateAfterCallWork, now))
+			case a.state == types.StateAfterCallWork && !now.Before(a.acwUntil):
+				out = append(out, f.changeState(l, a, types.StateAvailable, now))
+			}
+			if !now.Before(a.nextHeartbeat) {
+				a.nextHeartbeat = now.Add(heartbeatInterval)
+				out = append(out, f.message(l, types.AgentHeartbeat{
+					Type: "heartbeat", AgentID: a.id, State: a.state, Timestamp: now,
+				}))
+			}
+		}
+	}
+	f.mu.Unlock()
+	f.send(out)
+}
+
+// complete hangs up one call (caller holds f.mu)
+func (f *fleet) complete(l *link, a *fleetAgent, callID string, now time.Time) outMsg {
+	talk := 0.0
+	if _, ok := a.calls[callID]; ok {
+		talk = now.Sub(a.stateSince).Seconds()
+		delete(a.calls, callID)
+	}
+	f.completes.Add(1)
+	return f.message(l, types.CallComplete{
+		Type: "call_complete", AgentID: a.id, CallID: callID, TalkTime: talk, Timestamp: now,
+	})
+}
+
+// changeState moves an agent to state (caller holds f.mu)
+func (f *fleet) changeState(l *link, a *fleetAgent, state types.AgentState, now time.Time) outMsg {
+	msg := types.AgentStateChange{
+		Type:          "state_change",
+		AgentID:       a.id,
+		PreviousState: a.state,
+		NewState:      state,
+		Timestamp:     now,
+		StateDuration: now.Sub(a.stateSince).Seconds(),
+		Department:    a.dept,
+		Location:      a.location,
+		Team:          a.team,
+	}
+	a.state, a.stateSince = state, now
+	return f.message(l, msg)
+}
+
+// message encodes v for the link's current connection (caller holds f.mu)
+func (f *fleet) message(l *link, v any) outMsg {
+	data, _ := json.Marshal(v)
+	return outMsg{link: l, conn: l.conn, data: data}
+}
+
+// send writes messages in order; writes to a connection that was replaced or closed meanwhile
+// fail and are counted
+func (f *fleet) send(out []outMsg) {
+	for _, m := range out {
+		if m.conn == nil {
+			continue
+		}
+		m.link.writeMu.Lock()
+		m.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		err := m.conn.WriteMessage(websocket.TextMessage, m.data)
+		m.link.writeMu.Unlock()
+		if err != nil {
+			f.writeErrors.Add(1)
+		}
+	}
+}
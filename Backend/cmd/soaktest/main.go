@@ -0,0 +1,304 @@
+// Command soaktest runs the backend in-process against a simulated agent fleet for hours,
+// scaling the fleet up and down in cycles while dashboards follow the snapshot stream. At the
+// low point of every cycle it checks goroutines, heap, tracker, hub and queue sizes against the
+// first cycle and fails with exit code 1 when something leaks.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/callqueue"
+	"github.com/dennisdiepolder/monti/backend/internal/config"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/dennisdiepolder/monti/backend/pkg/client"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// settleMargin is added to the reconnect grace and stale checks before a cycle is sampled
+	settleMargin = 500 * time.Millisecond
+	// drainTimeout bounds the backend shutdown after the fleet and dashboards have left
+	drainTimeout = 5 * time.Second
+)
+
+// options are the soak test's flags
+type options struct {
+	Duration       time.Duration
+	Cycle          time.Duration // one scale-up and scale-down
+	MinAgents      int
+	MaxAgents      int
+	MuxSize        int     // agents per multiplexed connection
+	SingleShare    float64 // share of agents on their own connection
+	CallsPerSecond float64
+	TalkTime       time.Duration // mean, exponentially distributed
+	ACWTime        time.Duration
+	DropEvery      time.Duration // cut a multiplexed connection this often while scaled up; 0 disables
+	Dashboards     int
+	Seed           int64
+	Report         string // JSON file for the samples and violations
+
+	MaxGoroutineGrowth int
+	MaxHeapGrowthMB    float64
+	MaxWaitingCalls    int
+	MaxOrphanedCalls   int
+}
+
+// report is the outcome of a run
+type report struct {
+	Samples    []sample `json:"samples"`
+	Violations []string `json:"violations"`
+	Snapshots  int64    `json:"snapshots"` // received by all dashboards
+	Assigns    int64    `json:"assigns"`
+	Completes  int64    `json:"completes"`
+	WriteErrs  int64    `json:"writeErrors"`
+}
+
+func main() {
+	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339})
+
+	var opts options
+	backendLevel := flag.String("backend-log-level", "warn", "log level of the in-process backend")
+	flag.DurationVar(&opts.Duration, "duration", 4*time.Hour, "total run time")
+	flag.DurationVar(&opts.Cycle, "cycle", 10*time.Minute, "length of one scale-up/scale-down cycle")
+	flag.IntVar(&opts.MinAgents, "min-agents", 200, "agents online at the low point of a cycle")
+	flag.IntVar(&opts.MaxAgents, "max-agents", 2000, "agents online at the high point of a cycle")
+	flag.IntVar(&opts.MuxSize, "mux-size", 100, "agents per multiplexed connection")
+	flag.Float64Var(&opts.SingleShare, "single-share", 0.1, "share of agents on their own connection")
+	flag.Float64Var(&opts.CallsPerSecond, "calls-per-second", 4, "calls enqueued per second")
+	flag.DurationVar(&opts.TalkTime, "talk-time", 30*time.Second, "mean talk time")
+	flag.DurationVar(&opts.ACWTime, "acw-time", 5*time.Second, "after-call work time")
+	flag.DurationVar(&opts.DropEvery, "drop-every", 30*time.Second, "cut a multiplexed connection this often while scaled up (0 disables)")
+	flag.IntVar(&opts.Dashboards, "dashboards", 5, "dashboards following /ws")
+	flag.Int64Var(&opts.Seed, "seed", 1, "random seed")
+	flag.StringVar(&opts.Report, "report", "", "write samples and violations as JSON to this file")
+	flag.IntVar(&opts.MaxGoroutineGrowth, "max-goroutine-growth", 50, "allowed goroutine growth over the first cycle")
+	flag.Float64Var(&opts.MaxHeapGrowthMB, "max-heap-growth-mb", 64, "allowed live heap growth over the first cycle in MB")
+	flag.IntVar(&opts.MaxWaitingCalls, "max-waiting-calls", 200, "allowed waiting calls at the low point of a cycle")
+	flag.IntVar(&opts.MaxOrphanedCalls, "max-orphaned-calls", 0, "allowed active calls held by no online agent")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load configuration")
+	}
+	level, err := zerolog.ParseLevel(*backendLevel)
+	if err != nil {
+		log.Fatal().Str("level", *backendLevel).Msg("invalid backend log level")
+	}
+	backendLogger := log.Logger.Level(level)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	rep, err := run(ctx, opts, cfg, log.Logger, backendLogger)
+	if err != nil {
+		log.Fatal().Err(err).Msg("soak test failed to run")
+	}
+	if opts.Report != "" {
+		data, _ := json.MarshalIndent(rep, "", "  ")
+		if err := os.WriteFile(opts.Report, data, 0o644); err != nil {
+			log.Error().Err(err).Str("file", opts.Report).Msg("failed to write report")
+		}
+	}
+	for _, v := range rep.Violations {
+		log.Error().Msg(v)
+	}
+	if len(rep.Violations) > 0 {
+		os.Exit(1)
+	}
+	log.Info().Int("cycles", len(rep.Samples)).Msg("soak test passed")
+}
+
+// settleTime is how long after the scale-down agents need to drop out of the tracker, and
+// the routing loop to release the calls they held
+func settleTime(cfg *config.Config) time.Duration {
+	gone := cfg.ReconnectGrace + 2*cfg.StaleCheckInterval
+	if cfg.OrphanedCallAction != callqueue.OrphanOff {
+		gone = max(gone, cfg.OrphanedCallGrace+cfg.StaleCheckInterval) + 2*cfg.RoutingInterval
+	}
+	return gone + 2*cfg.AggregationInterval + settleMargin
+}
+
+// validate checks that the options allow at least two cycles that can settle
+func (o options) validate(cfg *config.Config) error {
+	switch {
+	case o.MinAgents < 0 || o.MaxAgents <= 0 || o.MinAgents > o.MaxAgents:
+		return errors.New("need 0 <= min-agents <= max-agents and max-agents > 0")
+	case o.SingleShare < 0 || o.SingleShare > 1:
+		return errors.New("single-share must be between 0 and 1")
+	case o.Cycle/2 <= settleTime(cfg):
+		return fmt.Errorf("half a cycle must exceed the settle time of %s (RECONNECT_GRACE, ORPHANED_CALL_GRACE, STALE_CHECK_INTERVAL, AGGREGATION_INTERVAL)", settleTime(cfg))
+	case o.Duration < 2*o.Cycle:
+		return errors.New("duration must cover at least two cycles")
+	}
+	return nil
+}
+
+// run starts the backend, fleet, dashboards and call generator and samples every cycle until
+// the duration is up or ctx is cancelled
+func run(ctx context.Context, opts options, cfg *config.Config, logger, backendLogger zerolog.Logger) (*report, error) {
+	if err := opts.validate(cfg); err != nil {
+		return nil, err
+	}
+	b, err := startBackend(cfg, backendLogger)
+	if err != nil {
+		return nil, err
+	}
+	rep := &report{}
+	defer func() {
+		// Every connection is closed by now, so draining should not take long
+		stopCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+		defer cancel()
+		if err := b.stop(stopCtx); err != nil {
+			rep.Violations = append(rep.Violations, "shutdown: "+err.Error())
+		}
+	}()
+
+	f := newFleet(b.url, opts, logger)
+	var snapshots atomic.Int64
+
+	loopCtx, cancelLoops := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancelLoops()
+		f.scale(0)
+		wg.Wait()
+	}()
+
+	for i := 0; i < opts.Dashboards; i++ {
+		sub := client.NewClient(b.url).NewSubscriber(client.StreamHandlers{
+			Snapshot: func(*client.Snapshot) { snapshots.Add(1) },
+		})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sub.Run(loopCtx)
+		}()
+	}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		generateCalls(loopCtx, b, opts)
+	}()
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(fleetTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-loopCtx.Done():
+				return
+			case now := <-ticker.C:
+				f.tick(now)
+			}
+		}
+	}()
+
+	settle := settleTime(cfg)
+	cycles := int(opts.Duration / opts.Cycle)
+	logger.Info().
+		Int("cycles", cycles).
+		Int("minAgents", opts.MinAgents).
+		Int("maxAgents", opts.MaxAgents).
+		Int("links", len(f.links)).
+		Str("backend", b.url).
+		Msg("soak test started")
+
+	var baseline sample
+	for c := 1; c <= cycles; c++ {
+		f.scale(opts.MaxAgents)
+		if !hold(ctx, f, opts.Cycle/2, opts.DropEvery) {
+			break
+		}
+		f.scale(opts.MinAgents)
+		if !hold(ctx, f, opts.Cycle/2-settle, opts.DropEvery) || !hold(ctx, f, settle, 0) {
+			break
+		}
+
+		s := takeSample(c, b, f)
+		if c == 1 {
+			baseline = s
+		}
+		violations := check(s, baseline, opts, f.poolSize())
+		rep.Samples = append(rep.Samples, s)
+		rep.Violations = append(rep.Violations, violations...)
+		logger.Info().
+			Int("cycle", c).
+			Int("agents", s.Agents).
+			Int("goroutines", s.Goroutines).
+			Float64("heapMB", s.HeapMB).
+			Int("tracked", s.TrackedAgents).
+			Int("waiting", s.WaitingCalls).
+			Int("active", s.ActiveCalls).
+			Int("orphaned", s.OrphanedCalls).
+			Int("violations", len(violations)).
+			Msg("cycle sampled")
+	}
+
+	rep.Snapshots = snapshots.Load()
+	rep.Assigns = f.assigns.Load()
+	rep.Completes = f.completes.Load()
+	rep.WriteErrs = f.writeErrors.Load()
+	if len(rep.Samples) > 0 && rep.Snapshots == 0 && opts.Dashboards > 0 {
+		rep.Violations = append(rep.Violations, "dashboards received no snapshots")
+	}
+	return rep, nil
+}
+
+// hold waits for d, cutting a multiplexed link every dropEvery; false when ctx is cancelled
+func hold(ctx context.Context, f *fleet, d, dropEvery time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	var drops <-chan time.Time
+	if dropEvery > 0 {
+		ticker := time.NewTicker(dropEvery)
+		defer ticker.Stop()
+		drops = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-timer.C:
+			return true
+		case <-drops:
+			f.drop()
+		}
+	}
+}
+
+// generateCalls enqueues opts.CallsPerSecond calls spread randomly over all VQs
+func generateCalls(ctx context.Context, b *backend, opts options) {
+	const step = 100 * time.Millisecond
+	rng := rand.New(rand.NewSource(opts.Seed + 1))
+	ticker := time.NewTicker(step)
+	defer ticker.Stop()
+
+	due, n := 0.0, 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			due += opts.CallsPerSecond * step.Seconds()
+			for ; due >= 1; due-- {
+				vq := types.AllVQs[rng.Intn(len(types.AllVQs))]
+				b.enqueueCall(vq, fmt.Sprintf("soak-call-%d", n))
+				n++
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/config"
+	"github.com/rs/zerolog"
+)
+
+func TestRunShortSoak(t *testing.T) {
+	// Calls buffered for a link as it drops are still lost now and then, so the run is opt-in
+	if testing.Short() || os.Getenv("SOAK_TEST") == "" {
+		t.Skip("soak run takes several seconds; set SOAK_TEST=1 to run it")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.ReconnectGrace = 200 * time.Millisecond
+	cfg.OrphanedCallGrace = 200 * time.Millisecond
+	cfg.StaleCheckInterval = 100 * time.Millisecond
+	cfg.AggregationInterval = 100 * time.Millisecond
+	cfg.RoutingInterval = 50 * time.Millisecond
+
+	opts := options{
+		Duration:           6 * time.Second,
+		Cycle:              3 * time.Second,
+		MinAgents:          10,
+		MaxAgents:          30,
+		MuxSize:            10,
+		SingleShare:        0.2,
+		CallsPerSecond:     5,
+		TalkTime:           300 * time.Millisecond,
+		ACWTime:            100 * time.Millisecond,
+		DropEvery:          700 * time.Millisecond,
+		Dashboards:         2,
+		Seed:               1,
+		MaxGoroutineGrowth: 50,
+		MaxHeapGrowthMB:    64,
+		MaxWaitingCalls:    50,
+		MaxOrphanedCalls:   0,
+	}
+	rep, err := run(context.Background(), opts, cfg, zerolog.New(zerolog.NewTestWriter(t)), zerolog.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.Samples) != 2 {
+		t.Fatalf("got %d samples, want 2", len(rep.Samples))
+	}
+	for _, v := range rep.Violations {
+		t.Error(v)
+	}
+	if rep.Assigns == 0 {
+		t.Error("fleet was never assigned a call")
+	}
+	if s := rep.Samples[1]; s.Agents != opts.MinAgents {
+		t.Errorf("%d agents online at the low point, want %d", s.Agents, opts.MinAgents)
+	}
+}
+
+func TestValidateNeedsSettleTime(t *testing.T) {
+	cfg := &config.Config{ReconnectGrace: 10 * time.Second, StaleCheckInterval: time.Second, AggregationInterval: time.Second}
+	opts := options{Duration: time.Minute, Cycle: 20 * time.Second, MinAgents: 1, MaxAgents: 2}
+	if err := opts.validate(cfg); err == nil {
+		t.Fatal("expected an error when half a cycle is shorter than the settle time")
+	}
+	opts.Cycle = 30 * time.Second
+	if err := opts.validate(cfg); err != nil {
+		t.Fatal(err)
+	}
+}
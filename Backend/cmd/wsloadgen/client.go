@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/gorilla/websocket"
+)
+
+// progress is shared by all clients for the periodic log line
+type progress struct {
+	connected atomic.Int64
+	snapshots atomic.Int64
+	dropped   atomic.Int64
+}
+
+// clientStats is what one client measured. Only its own goroutine writes it; it is read once
+// the client has stopped.
+type clientStats struct {
+	ID          int    `json:"id"`
+	Profile     string `json:"profile"`
+	Scope       string `json:"scope,omitempty"`
+	Connects    int    `json:"connects"`
+	Disconnects int    `json:"disconnects"` // connections lost before the run ended
+	DialErrors  int    `json:"dialErrors"`
+	Snapshots   int64  `json:"snapshots"`
+	Dropped     int64  `json:"dropped"` // snapshots missed, from gaps in Seq
+	Bytes       int64  `json:"bytes"`   // snapshot payload bytes received
+	History     int    `json:"history"` // snapshot_history messages received on connect
+	LastError   string `json:"lastError,omitempty"`
+
+	latencies []time.Duration // receive time minus snapshot timestamp, one per snapshot
+	lastSeq   int64
+}
+
+// snapshotHeader is the part of a /ws message the load generator reads. Decoding only these
+// fields keeps the generator's own cost per snapshot small next to the backend's.
+type snapshotHeader struct {
+	Type      string    `json:"type"`
+	Seq       int64     `json:"seq"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// loadClient is one synthetic dashboard on /ws
+type loadClient struct {
+	id       identity
+	url      string // ws(s)://host/ws?token=...
+	ack      bool
+	retry    time.Duration
+	dialer   *websocket.Dialer
+	progress *progress
+	stats    clientStats
+}
+
+func newLoadClient(n int, id identity, wsURL string, opts options, p *progress) *loadClient {
+	u := wsURL + "?token=" + url.QueryEscape(id.Token)
+	return &loadClient{
+		id:       id,
+		url:      u,
+		ack:      opts.Ack,
+		retry:    opts.Reconnect,
+		dialer:   &websocket.Dialer{HandshakeTimeout: 10 * time.Second},
+		progress: p,
+		stats:    clientStats{ID: n, Profile: id.Profile, Scope: id.Scope},
+	}
+}
+
+// run keeps the client connected until ctx is done, reconnecting after opts.Reconnect
+func (c *loadClient) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		conn, _, err := c.dialer.DialContext(ctx, c.url, nil)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.stats.DialErrors++
+			c.stats.LastError = err.Error()
+		} else {
+			c.stats.Connects++
+			c.progress.connected.Add(1)
+			err = c.read(ctx, conn)
+			c.progress.connected.Add(-1)
+			if ctx.Err() != nil {
+				return
+			}
+			c.stats.Disconnects++
+			c.stats.LastError = err.Error()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.retry):
+		}
+	}
+}
+
+// read consumes messages until the connection fails or ctx is done
+func (c *loadClient) read(ctx context.Context, conn *websocket.Conn) error {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+			conn.Close()
+		case <-done:
+			conn.Close()
+		}
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		received := time.Now()
+
+		var hdr snapshotHeader
+		if err := json.Unmarshal(data, &hdr); err != nil {
+			continue
+		}
+		switch hdr.Type {
+		case "snapshot":
+			c.observe(hdr, len(data), received)
+			if c.ack {
+				ack, _ := json.Marshal(types.ClientCommand{Type: "ack", Seq: hdr.Seq})
+				if err := conn.WriteMessage(websocket.TextMessage, ack); err != nil {
+					return err
+				}
+			}
+		case "snapshot_history":
+			c.stats.History++
+		}
+	}
+}
+
+// observe records one live snapshot
+func (c *loadClient) observe(hdr snapshotHeader, size int, received time.Time) {
+	c.stats.Snapshots++
+	c.stats.Bytes += int64(size)
+	c.stats.latencies = append(c.stats.latencies, received.Sub(hdr.Timestamp))
+	c.progress.snapshots.Add(1)
+
+	// Seq counts every broadcast, so a gap is snapshots this client missed, whether its send
+	// buffer overflowed or it was reconnecting
+	if c.stats.lastSeq > 0 && hdr.Seq > c.stats.lastSeq+1 {
+		missed := hdr.Seq - c.stats.lastSeq - 1
+		c.stats.Dropped += missed
+		c.progress.dropped.Add(missed)
+	}
+	if hdr.Seq > c.stats.lastSeq {
+		c.stats.lastSeq = hdr.Seq
+	}
+}
@@ -0,0 +1,199 @@
+// Command wsloadgen opens many frontend WebSocket (/ws) connections with varying RBAC claims
+// against a running backend and reports, per profile, how long snapshots took from aggregation
+// to the client, how many each client missed and how large its filtered snapshots were. It is
+// meant for sizing the hub's per-client filter and marshal cost.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// options are the load generator's flags
+type options struct {
+	Backend       string // http(s) base URL
+	Clients       int
+	Duration      time.Duration
+	Ramp          time.Duration // connections are opened evenly over this period
+	Mix           string        // profile weights, e.g. "admin=1,bu=3"
+	BusinessUnits []string
+	Departments   []string
+	Teams         []string
+	TokenFile     string // real tokens instead of minted ones
+	Ack           bool   // acknowledge snapshots like the frontend does
+	Reconnect     time.Duration
+	LogEvery      time.Duration
+	Report        string // JSON file for the full report
+}
+
+func main() {
+	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339})
+
+	var opts options
+	var businessUnits, departments, teams string
+	flag.StringVar(&opts.Backend, "backend", envOr("MONTI_BACKEND_URL", "http://localhost:8080"), "backend base URL (MONTI_BACKEND_URL)")
+	flag.IntVar(&opts.Clients, "clients", 100, "WebSocket clients to open")
+	flag.DurationVar(&opts.Duration, "duration", time.Minute, "how long to measure, including the ramp")
+	flag.DurationVar(&opts.Ramp, "ramp", 10*time.Second, "open the connections evenly over this period")
+	flag.StringVar(&opts.Mix, "mix", "admin=1,bu=3,department=3,viewer=1", "profile weights: admin, bu, department, team, viewer")
+	flag.StringVar(&businessUnits, "business-units", strings.Join(defaultBusinessUnits(), ","), "business units the bu and viewer profiles cycle through")
+	flag.StringVar(&departments, "departments", strings.Join(defaultDepartments(), ","), "departments the department profile cycles through")
+	flag.StringVar(&teams, "teams", "", "teams the team profile cycles through (needed for team)")
+	flag.StringVar(&opts.TokenFile, "token-file", "", "one token per line, used instead of minted tokens (needed when the backend verifies signatures)")
+	flag.BoolVar(&opts.Ack, "ack", true, "acknowledge every snapshot like the frontend")
+	flag.DurationVar(&opts.Reconnect, "reconnect", time.Second, "wait before reconnecting a dropped client")
+	flag.DurationVar(&opts.LogEvery, "log-every", 10*time.Second, "progress log interval")
+	flag.StringVar(&opts.Report, "report", "", "write the per-profile and per-client report as JSON to this file")
+	flag.Parse()
+	opts.BusinessUnits = splitList(businessUnits)
+	opts.Departments = splitList(departments)
+	opts.Teams = splitList(teams)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	rep, err := run(ctx, opts, log.Logger)
+	if err != nil {
+		log.Fatal().Err(err).Msg("load generator failed to run")
+	}
+	printTable(os.Stdout, rep.Profiles)
+	if opts.Report != "" {
+		data, _ := json.MarshalIndent(rep, "", "  ")
+		if err := os.WriteFile(opts.Report, data, 0o644); err != nil {
+			log.Error().Err(err).Str("file", opts.Report).Msg("failed to write report")
+		}
+	}
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// wsURL turns the backend base URL into the dashboard WebSocket URL
+func wsURL(backend string) (string, error) {
+	base := strings.TrimRight(backend, "/")
+	switch {
+	case strings.HasPrefix(base, "http://"):
+		return "ws://" + strings.TrimPrefix(base, "http://") + "/ws", nil
+	case strings.HasPrefix(base, "https://"):
+		return "wss://" + strings.TrimPrefix(base, "https://") + "/ws", nil
+	}
+	return "", errors.New("backend URL must start with http:// or https://")
+}
+
+// run opens the clients over the ramp, keeps them connected for the duration and aggregates
+// what they measured
+func run(ctx context.Context, opts options, logger zerolog.Logger) (*report, error) {
+	switch {
+	case opts.Clients <= 0:
+		return nil, errors.New("clients must be positive")
+	case opts.Duration <= 0 || opts.Ramp < 0 || opts.Ramp >= opts.Duration:
+		return nil, errors.New("need a positive duration longer than the ramp")
+	}
+	url, err := wsURL(opts.Backend)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tokens outlive the run so that the backend's token cache never sees them expire
+	expires := time.Now().Add(opts.Duration + time.Hour)
+	var identities []identity
+	if opts.TokenFile != "" {
+		identities, err = loadTokenFile(opts.TokenFile, opts.Clients)
+	} else {
+		var mix []mixEntry
+		mix, err = parseMix(opts.Mix, builtinProfiles(opts.BusinessUnits, opts.Departments, opts.Teams))
+		if err == nil {
+			identities, err = assignIdentities(opts.Clients, mix, expires)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	runCtx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+
+	p := &progress{}
+	clients := make([]*loadClient, len(identities))
+	var wg sync.WaitGroup
+	for i, id := range identities {
+		c := newLoadClient(i, id, url, opts, p)
+		clients[i] = c
+		delay := opts.Ramp * time.Duration(i) / time.Duration(len(identities))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case <-runCtx.Done():
+				return
+			case <-time.After(delay):
+			}
+			c.run(runCtx)
+		}()
+	}
+	logger.Info().
+		Int("clients", len(clients)).
+		Str("url", url).
+		Dur("duration", opts.Duration).
+		Dur("ramp", opts.Ramp).
+		Msg("load generator started")
+
+	if opts.LogEvery > 0 {
+		go func() {
+			ticker := time.NewTicker(opts.LogEvery)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-runCtx.Done():
+					return
+				case <-ticker.C:
+					logger.Info().
+						Int64("connected", p.connected.Load()).
+						Int64("snapshots", p.snapshots.Load()).
+						Int64("dropped", p.dropped.Load()).
+						Msg("progress")
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	rep := &report{
+		Backend:  opts.Backend,
+		Started:  started,
+		Duration: time.Since(started).Round(time.Millisecond).String(),
+		Clients:  len(clients),
+		Profiles: buildReport(clients),
+	}
+	for _, c := range clients {
+		rep.PerClient = append(rep.PerClient, c.stats)
+	}
+	return rep, nil
+}
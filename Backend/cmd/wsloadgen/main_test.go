@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/pkg/server"
+	"github.com/rs/zerolog"
+)
+
+func TestRunAgainstBackend(t *testing.T) {
+	if testing.Short() {
+		t.Skip("boots the backend")
+	}
+	t.Setenv("CONFIG_FILE", os.DevNull)
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("AGGREGATION_INTERVAL", "100ms")
+	logger := zerolog.New(zerolog.NewTestWriter(t))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := server.New(logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(ln)
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	rep, err := run(context.Background(), options{
+		Backend:       "http://" + ln.Addr().String(),
+		Clients:       6,
+		Duration:      1500 * time.Millisecond,
+		Mix:           "admin=1,department=1,bu=1",
+		BusinessUnits: defaultBusinessUnits(),
+		Departments:   defaultDepartments(),
+		Ack:           true,
+		Reconnect:     100 * time.Millisecond,
+	}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range rep.PerClient {
+		if c.Connects != 1 || c.Disconnects != 0 || c.DialErrors != 0 || c.Snapshots == 0 {
+			t.Errorf("client %d (%s %s) = %+v, want one connection receiving snapshots", c.ID, c.Profile, c.Scope, c)
+		}
+	}
+	byProfile := make(map[string]profileReport)
+	for _, p := range rep.Profiles {
+		byProfile[p.Profile] = p
+	}
+	if all := byProfile["all"]; all.Clients != 6 || all.Latency.Max <= 0 {
+		t.Errorf("all = %+v, want 6 clients with latencies", all)
+	}
+	// A department-scoped supervisor gets only its own department's queues
+	if admin, dept := byProfile["admin"], byProfile["department"]; dept.AvgKB >= admin.AvgKB {
+		t.Errorf("department snapshots average %.1f KB, admin %.1f KB; want department smaller", dept.AvgKB, admin.AvgKB)
+	}
+}
+
+func TestAssignIdentitiesFollowsMix(t *testing.T) {
+	known := builtinProfiles([]string{"bu1", "bu2"}, []string{"sales"}, nil)
+	if _, err := parseMix("team=1", known); err == nil {
+		t.Error("team without -teams must be rejected")
+	}
+	mix, err := parseMix("admin=1,bu=2", known)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := assignIdentities(6, mix, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, id := range ids {
+		got = append(got, id.Profile+":"+id.Scope)
+	}
+	want := []string{"admin:", "bu:bu1", "bu:bu2", "admin:", "bu:bu1", "bu:bu2"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("identities = %v, want %v", got, want)
+		}
+	}
+}
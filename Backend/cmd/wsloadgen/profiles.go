@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// profile is an RBAC shape of dashboard user. Each client of a profile takes the next of its
+// scopes, so e.g. the "bu" clients are spread over all business units.
+type profile struct {
+	Name   string
+	Role   string
+	Prefix string   // group path prefix of the scope (empty = no scope groups)
+	Scopes []string // cycled over the profile's clients
+}
+
+// builtinProfiles are the RBAC shapes the backend's default claim mapping knows
+func builtinProfiles(businessUnits, departments, teams []string) map[string]profile {
+	return map[string]profile{
+		"admin":      {Name: "admin", Role: "admin"},
+		"bu":         {Name: "bu", Role: "supervisor", Prefix: "/business-units/", Scopes: businessUnits},
+		"department": {Name: "department", Role: "supervisor", Prefix: "/departments/", Scopes: departments},
+		"team":       {Name: "team", Role: "supervisor", Prefix: "/teams/", Scopes: teams},
+		"viewer":     {Name: "viewer", Role: "viewer", Prefix: "/business-units/", Scopes: businessUnits},
+	}
+}
+
+// defaultBusinessUnits lists the built-in taxonomy's business units in a stable order
+func defaultBusinessUnits() []string {
+	out := make([]string, 0, len(types.BULocationMapping))
+	for bu := range types.BULocationMapping {
+		out = append(out, string(bu))
+	}
+	sort.Strings(out)
+	return out
+}
+
+// defaultDepartments lists the built-in taxonomy's departments
+func defaultDepartments() []string {
+	out := make([]string, len(types.AllDepartments))
+	for i, d := range types.AllDepartments {
+		out[i] = string(d)
+	}
+	return out
+}
+
+// mixEntry is one profile with its weight in the client mix
+type mixEntry struct {
+	profile profile
+	weight  int
+}
+
+// parseMix parses "admin=1,bu=4" against the known profiles
+func parseMix(s string, known map[string]profile) ([]mixEntry, error) {
+	var mix []mixEntry
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, w, ok := strings.Cut(part, "=")
+		weight := 1
+		if ok {
+			n, err := strconv.Atoi(w)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid weight in %q", part)
+			}
+			weight = n
+		}
+		p, found := known[name]
+		if !found {
+			return nil, fmt.Errorf("unknown profile %q (admin, bu, department, team or viewer)", name)
+		}
+		if p.Prefix != "" && len(p.Scopes) == 0 && weight > 0 {
+			return nil, fmt.Errorf("profile %q needs at least one scope (see -teams)", name)
+		}
+		if weight > 0 {
+			mix = append(mix, mixEntry{profile: p, weight: weight})
+		}
+	}
+	if len(mix) == 0 {
+		return nil, fmt.Errorf("mix %q selects no profile", s)
+	}
+	return mix, nil
+}
+
+// identity is what one client connects as
+type identity struct {
+	Profile string
+	Scope   string
+	Token   string
+}
+
+// assignIdentities spreads n clients over the mix in proportion to the weights, interleaved
+// so that a ramp-up brings all profiles online together
+func assignIdentities(n int, mix []mixEntry, expires time.Time) ([]identity, error) {
+	var order []profile
+	for _, e := range mix {
+		for i := 0; i < e.weight; i++ {
+			order = append(order, e.profile)
+		}
+	}
+	next := make(map[string]int) // profile -> scope index
+	out := make([]identity, n)
+	for i := range out {
+		p := order[i%len(order)]
+		scope := ""
+		if len(p.Scopes) > 0 {
+			scope = p.Scopes[next[p.Name]%len(p.Scopes)]
+			next[p.Name]++
+		}
+		token, err := mintToken(i, p, scope, expires)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = identity{Profile: p.Name, Scope: scope, Token: token}
+	}
+	return out, nil
+}
+
+// mintToken builds an unsigned token in the Keycloak shape of the default claim mapping. The
+// backend only accepts it with signature verification off (ENV=development or unset).
+func mintToken(i int, p profile, scope string, expires time.Time) (string, error) {
+	claims := jwt.MapClaims{
+		"sub":                fmt.Sprintf("wsloadgen-%d", i),
+		"email":              fmt.Sprintf("wsloadgen-%d@monti.local", i),
+		"preferred_username": fmt.Sprintf("wsloadgen-%d", i),
+		"realm_access":       map[string]interface{}{"roles": []string{p.Role}},
+		"exp":                expires.Unix(),
+	}
+	if scope != "" {
+		claims["groups"] = []string{p.Prefix + scope}
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+}
+
+// loadTokenFile reads one token per line (blank lines and # comments skipped); clients cycle
+// through them, labelled with the profile "file"
+func loadTokenFile(path string, n int) ([]identity, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var tokens []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			tokens = append(tokens, line)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%s holds no tokens", path)
+	}
+	out := make([]identity, n)
+	for i := range out {
+		out[i] = identity{Profile: "file", Scope: strconv.Itoa(i % len(tokens)), Token: tokens[i%len(tokens)]}
+	}
+	return out, nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// report is the outcome of a run
+type report struct {
+	Backend   string          `json:"backend"`
+	Started   time.Time       `json:"started"`
+	Duration  string          `json:"duration"`
+	Clients   int             `json:"clients"`
+	Profiles  []profileReport `json:"profiles"` // one per profile, then "all"
+	PerClient []clientStats   `json:"perClient"`
+}
+
+// profileReport aggregates the clients of one profile
+type profileReport struct {
+	Profile     string         `json:"profile"`
+	Clients     int            `json:"clients"`
+	Snapshots   int64          `json:"snapshots"`
+	Dropped     int64          `json:"dropped"`
+	DropRate    float64        `json:"dropRate"` // dropped / (received + dropped)
+	Disconnects int            `json:"disconnects"`
+	DialErrors  int            `json:"dialErrors"`
+	AvgKB       float64        `json:"avgKB"` // per snapshot
+	Latency     latencySummary `json:"latency"`
+}
+
+// latencySummary holds broadcast latency percentiles in milliseconds
+type latencySummary struct {
+	P50 float64 `json:"p50Ms"`
+	P95 float64 `json:"p95Ms"`
+	P99 float64 `json:"p99Ms"`
+	Max float64 `json:"maxMs"`
+}
+
+// buildReport aggregates the clients per profile in the order profiles first appear
+func buildReport(clients []*loadClient) []profileReport {
+	var order []string
+	groups := make(map[string][]*clientStats)
+	all := make([]*clientStats, 0, len(clients))
+	for _, c := range clients {
+		if _, ok := groups[c.stats.Profile]; !ok {
+			order = append(order, c.stats.Profile)
+		}
+		groups[c.stats.Profile] = append(groups[c.stats.Profile], &c.stats)
+		all = append(all, &c.stats)
+	}
+
+	out := make([]profileReport, 0, len(order)+1)
+	for _, name := range order {
+		out = append(out, aggregate(name, groups[name]))
+	}
+	return append(out, aggregate("all", all))
+}
+
+func aggregate(name string, stats []*clientStats) profileReport {
+	r := profileReport{Profile: name, Clients: len(stats)}
+	var bytes int64
+	var latencies []time.Duration
+	for _, s := range stats {
+		r.Snapshots += s.Snapshots
+		r.Dropped += s.Dropped
+		r.Disconnects += s.Disconnects
+		r.DialErrors += s.DialErrors
+		bytes += s.Bytes
+		latencies = append(latencies, s.latencies...)
+	}
+	if r.Snapshots+r.Dropped > 0 {
+		r.DropRate = float64(r.Dropped) / float64(r.Snapshots+r.Dropped)
+	}
+	if r.Snapshots > 0 {
+		r.AvgKB = float64(bytes) / float64(r.Snapshots) / 1024
+	}
+	r.Latency = summarize(latencies)
+	return r
+}
+
+// summarize computes percentiles by nearest rank
+func summarize(latencies []time.Duration) latencySummary {
+	if len(latencies) == 0 {
+		return latencySummary{}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	at := func(q float64) float64 {
+		i := int(q*float64(len(latencies))+0.5) - 1
+		if i < 0 {
+			i = 0
+		}
+		if i >= len(latencies) {
+			i = len(latencies) - 1
+		}
+		return ms(latencies[i])
+	}
+	return latencySummary{P50: at(0.50), P95: at(0.95), P99: at(0.99), Max: ms(latencies[len(latencies)-1])}
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// printTable writes the per-profile summary
+func printTable(w io.Writer, profiles []profileReport) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROFILE\tCLIENTS\tSNAPSHOTS\tDROPPED\tDROP %\tDISCONNECTS\tDIAL ERRORS\tAVG KB\tP50 MS\tP95 MS\tP99 MS\tMAX MS")
+	for _, p := range profiles {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.2f\t%d\t%d\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\n",
+			p.Profile, p.Clients, p.Snapshots, p.Dropped, 100*p.DropRate, p.Disconnects, p.DialErrors,
+			p.AvgKB, p.Latency.P50, p.Latency.P95, p.Latency.P99, p.Latency.Max)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,121 @@
+package adherence
+
+import (
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// Compute compares an agent's state segments against their schedule for a day.
+// Only scheduled time up to now is counted; time without any segment is treated
+// as offline. Segments are expected not to overlap.
+//
+// Offline time (break, lunch, meeting, training) is split into scheduled and unscheduled
+// by whether the schedule planned that activity at the time.
+func Compute(agentID, date string, schedule []types.ScheduleInterval, segments []types.StateSegment, now time.Time) types.AgentAdherence {
+	result := types.AgentAdherence{AgentID: agentID, Date: date}
+
+	for _, iv := range schedule {
+		end := iv.End
+		if now.Before(end) {
+			end = now
+		}
+		if !end.After(iv.Start) {
+			continue
+		}
+		scheduled := end.Sub(iv.Start).Seconds()
+		result.ScheduledSecs += scheduled
+		if iv.Activity == types.ActivityWork {
+			result.ScheduledWorkSecs += scheduled
+		}
+
+		covered := 0.0
+		for _, seg := range segments {
+			overlap := overlapSecs(iv.Start, end, seg.Start, seg.End)
+			if overlap == 0 {
+				continue
+			}
+			covered += overlap
+			activity := types.ActivityForState(seg.State)
+			if activity == iv.Activity {
+				result.InAdherenceSecs += overlap
+			}
+			if activity == types.ActivityWork {
+				result.WorkedSecs += overlap
+			}
+		}
+		if iv.Activity == types.ActivityOffline && covered < scheduled {
+			result.InAdherenceSecs += scheduled - covered
+		}
+	}
+
+	for _, seg := range segments {
+		activity := types.ActivityForState(seg.State)
+		if activity == types.ActivityWork || activity == types.ActivityOffline {
+			continue
+		}
+		end := seg.End
+		if now.Before(end) {
+			end = now
+		}
+		if !end.After(seg.Start) {
+			continue
+		}
+		planned := 0.0
+		for _, iv := range schedule {
+			if iv.Activity == activity {
+				planned += overlapSecs(iv.Start, iv.End, seg.Start, end)
+			}
+		}
+		result.ScheduledOfflineSecs += planned
+		result.UnscheduledOfflineSecs += end.Sub(seg.Start).Seconds() - planned
+	}
+
+	if result.ScheduledSecs > 0 {
+		result.Adherence = result.InAdherenceSecs / result.ScheduledSecs * 100
+	}
+	if result.ScheduledWorkSecs > 0 {
+		result.Conformance = result.WorkedSecs / result.ScheduledWorkSecs * 100
+	}
+	return result
+}
+
+// Aggregate combines agent results into time-weighted team figures
+func Aggregate(team, date string, agents []types.AgentAdherence) types.TeamAdherence {
+	result := types.TeamAdherence{Team: team, Date: date, Agents: agents}
+	if result.Agents == nil {
+		result.Agents = []types.AgentAdherence{}
+	}
+
+	var scheduled, inAdherence, scheduledWork, worked float64
+	for _, a := range agents {
+		scheduled += a.ScheduledSecs
+		inAdherence += a.InAdherenceSecs
+		scheduledWork += a.ScheduledWorkSecs
+		worked += a.WorkedSecs
+		result.ScheduledOfflineSecs += a.ScheduledOfflineSecs
+		result.UnscheduledOfflineSecs += a.UnscheduledOfflineSecs
+	}
+	if scheduled > 0 {
+		result.Adherence = inAdherence / scheduled * 100
+	}
+	if scheduledWork > 0 {
+		result.Conformance = worked / scheduledWork * 100
+	}
+	return result
+}
+
+func overlapSecs(aStart, aEnd, bStart, bEnd time.Time) float64 {
+	start := aStart
+	if bStart.After(start) {
+		start = bStart
+	}
+	end := aEnd
+	if bEnd.Before(end) {
+		end = bEnd
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start).Seconds()
+}
@@ -0,0 +1,182 @@
+package adherence
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+var day = time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+
+func at(mins int) time.Time {
+	return day.Add(time.Duration(mins) * time.Minute)
+}
+
+func TestComputeAdherenceAndConformance(t *testing.T) {
+	schedule := []types.ScheduleInterval{
+		{AgentID: "a1", Start: at(0), End: at(60), Activity: types.ActivityWork},
+		{AgentID: "a1", Start: at(60), End: at(75), Activity: types.ActivityBreak},
+		{AgentID: "a1", Start: at(75), End: at(120), Activity: types.ActivityWork},
+	}
+	segments := []types.StateSegment{
+		{State: types.StateAvailable, Start: at(0), End: at(30)},
+		{State: types.StateOnCall, Start: at(30), End: at(70)}, // 10 min into the break
+		{State: types.StateBreak, Start: at(70), End: at(90)},  // 15 min late back
+		{State: types.StateAvailable, Start: at(90), End: at(120)},
+	}
+
+	got := Compute("a1", "2025-03-10", schedule, segments, at(120))
+
+	// In adherence: 60 work + 5 break + 30 work = 95 of 120 minutes
+	if math.Abs(got.Adherence-95.0/120*100) > 0.01 {
+		t.Errorf("adherence = %.2f, want %.2f", got.Adherence, 95.0/120*100)
+	}
+	// Worked 100 minutes (incl. 10 during break) vs 105 scheduled work minutes
+	if math.Abs(got.Conformance-100.0/105*100) > 0.01 {
+		t.Errorf("conformance = %.2f, want %.2f", got.Conformance, 100.0/105*100)
+	}
+}
+
+func TestComputeOnlyCountsElapsedTime(t *testing.T) {
+	schedule := []types.ScheduleInterval{
+		{AgentID: "a1", Start: at(0), End: at(60), Activity: types.ActivityWork},
+	}
+	segments := []types.StateSegment{
+		{State: types.StateAvailable, Start: at(0), End: at(30)},
+	}
+
+	got := Compute("a1", "2025-03-10", schedule, segments, at(30))
+	if got.ScheduledSecs != 1800 || got.Adherence != 100 {
+		t.Errorf("expected 30 elapsed minutes fully adherent, got %+v", got)
+	}
+}
+
+func TestAggregateIsTimeWeighted(t *testing.T) {
+	team := Aggregate("Team A", "2025-03-10", []types.AgentAdherence{
+		{AgentID: "a1", ScheduledSecs: 3600, InAdherenceSecs: 3600, ScheduledWorkSecs: 3600, WorkedSecs: 3600},
+		{AgentID: "a2", ScheduledSecs: 1200, InAdherenceSecs: 0, ScheduledWorkSecs: 1200, WorkedSecs: 600},
+	})
+	if team.Adherence != 75 {
+		t.Errorf("team adherence = %.2f, want 75", team.Adherence)
+	}
+	if math.Abs(team.Conformance-4200.0/4800*100) > 0.01 {
+		t.Errorf("team conformance = %.2f", team.Conformance)
+	}
+}
+
+func TestParseCSVAndReplace(t *testing.T) {
+	csv := `agentId,activity,start,end
+a1,work,2025-03-10T08:00:00Z,2025-03-10T12:00:00Z
+a1,Lunch,2025-03-10T12:00:00Z,2025-03-10T12:30:00Z
+a2,work,2025-03-10T09:00:00Z,2025-03-10T17:00:00Z
+`
+	intervals, err := ParseCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(intervals) != 3 || intervals[1].Activity != types.ActivityLunch {
+		t.Fatalf("unexpected intervals: %+v", intervals)
+	}
+
+	schedules := NewSchedules()
+	days, err := schedules.Replace(intervals)
+	if err != nil || days != 2 {
+		t.Fatalf("expected 2 agent-days, got %d (%v)", days, err)
+	}
+	if got := schedules.ForAgent("a1", "2025-03-10"); len(got) != 2 {
+		t.Errorf("expected 2 intervals for a1, got %d", len(got))
+	}
+
+	// Re-importing a day replaces it
+	if _, err := schedules.Replace(intervals[:1]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := schedules.ForAgent("a1", "2025-03-10"); len(got) != 1 {
+		t.Errorf("expected a1's day to be replaced, got %d intervals", len(got))
+	}
+}
+
+func TestReplaceRejectsInvalidIntervals(t *testing.T) {
+	cases := []types.ScheduleInterval{
+		{AgentID: "", Start: at(0), End: at(60), Activity: types.ActivityWork},
+		{AgentID: "a1", Start: at(60), End: at(0), Activity: types.ActivityWork},
+		{AgentID: "a1", Start: at(0), End: at(60), Activity: "nap"},
+	}
+	for _, iv := range cases {
+		if _, err := NewSchedules().Replace([]types.ScheduleInterval{iv}); err == nil {
+			t.Errorf("expected %+v to be rejected", iv)
+		}
+	}
+
+	overlapping := []types.ScheduleInterval{
+		{AgentID: "a1", Start: at(0), End: at(60), Activity: types.ActivityWork},
+		{AgentID: "a1", Start: at(30), End: at(90), Activity: types.ActivityBreak},
+	}
+	if _, err := NewSchedules().Replace(overlapping); err == nil {
+		t.Error("expected overlapping intervals to be rejected")
+	}
+}
+
+func TestCalendarOverridesScheduleAndSplitsOfflineTime(t *testing.T) {
+	schedule := []types.ScheduleInterval{
+		{AgentID: "a1", Start: at(0), End: at(60), Activity: types.ActivityWork},
+		{AgentID: "a1", Start: at(60), End: at(75), Activity: types.ActivityBreak},
+	}
+	events := []types.CalendarEvent{
+		{ID: "standup", Team: "Team A", Activity: types.ActivityMeeting, Start: at(30), End: at(45)},
+	}
+	plan := WithCalendar("a1", schedule, events)
+	if len(plan) != 4 || plan[1].Activity != types.ActivityMeeting || !plan[2].Start.Equal(at(45)) {
+		t.Fatalf("expected the meeting to split the work interval, got %+v", plan)
+	}
+
+	segments := []types.StateSegment{
+		{State: types.StateAvailable, Start: at(0), End: at(30)},
+		{State: types.StateMeeting, Start: at(30), End: at(50)}, // 5 min over
+		{State: types.StateBreak, Start: at(50), End: at(75)},   // 10 min early
+	}
+	got := Compute("a1", "2025-03-10", plan, segments, at(75))
+	if got.ScheduledOfflineSecs != 30*60 || got.UnscheduledOfflineSecs != 15*60 {
+		t.Errorf("expected 30 scheduled and 15 unscheduled offline minutes, got %+v", got)
+	}
+	// 30 work + 15 meeting + 15 break of 75 minutes
+	if math.Abs(got.Adherence-60.0/75*100) > 0.01 {
+		t.Errorf("adherence = %.2f, want %.2f", got.Adherence, 60.0/75*100)
+	}
+}
+
+func TestParseICS(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nUID:weekly-1\r\nSUMMARY:Team\\, weekly\r\nDTSTART:20250310T090000Z\r\nDTEND:20250310T093000Z\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:onboarding\r\nSUMMARY:Product\r\n  training\r\nX-MONTI-TEAM:Team B\r\nCATEGORIES:Internal,Training\r\n" +
+		"DTSTART;TZID=Europe/Berlin:20250310T140000\r\nDTEND;TZID=Europe/Berlin:20250310T160000\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	events, err := ParseICS(strings.NewReader(ics), "Team A")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %+v", events)
+	}
+	if e := events[0]; e.ID != "weekly-1" || e.Team != "Team A" || e.Title != "Team, weekly" || e.Activity != types.ActivityMeeting {
+		t.Errorf("unexpected first event %+v", e)
+	}
+	if e := events[1]; e.Team != "Team B" || e.Title != "Product training" || e.Activity != types.ActivityTraining || e.Start.Hour() != 13 {
+		t.Errorf("unexpected second event %+v", e)
+	}
+
+	cal := NewCalendar()
+	if _, err := cal.Put(events); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cal.ForTeam("Team B", "2025-03-10"); len(got) != 1 {
+		t.Errorf("expected one Team B event, got %+v", got)
+	}
+	if _, err := cal.Put([]types.CalendarEvent{{Team: "Team A", Activity: types.ActivityLunch, Start: at(0), End: at(30)}}); err == nil {
+		t.Error("expected a lunch event to be rejected")
+	}
+}
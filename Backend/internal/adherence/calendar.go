@@ -0,0 +1,313 @@
+package adherence
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/google/uuid"
+)
+
+// Calendar holds team meetings and trainings by event ID
+type Calendar struct {
+	mu     sync.RWMutex
+	events map[string]types.CalendarEvent
+}
+
+// NewCalendar creates an empty calendar
+func NewCalendar() *Calendar {
+	return &Calendar{events: make(map[string]types.CalendarEvent)}
+}
+
+// Put validates events and adds them, replacing events with the same ID. Events without
+// an ID get a new one. Returns the stored events.
+func (c *Calendar) Put(events []types.CalendarEvent) ([]types.CalendarEvent, error) {
+	out := make([]types.CalendarEvent, len(events))
+	for i, ev := range events {
+		if err := validateEvent(ev); err != nil {
+			return nil, fmt.Errorf("event %d: %w", i+1, err)
+		}
+		if ev.ID == "" {
+			ev.ID = uuid.New().String()
+		}
+		out[i] = ev
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ev := range out {
+		c.events[ev.ID] = ev
+	}
+	return out, nil
+}
+
+// Get returns an event by ID
+func (c *Calendar) Get(id string) (types.CalendarEvent, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ev, ok := c.events[id]
+	return ev, ok
+}
+
+// Delete removes an event, reporting whether it existed
+func (c *Calendar) Delete(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.events[id]
+	delete(c.events, id)
+	return ok
+}
+
+// Between returns the events overlapping [from, to), ordered by start. An empty team
+// matches every team.
+func (c *Calendar) Between(team string, from, to time.Time) []types.CalendarEvent {
+	c.mu.RLock()
+	out := []types.CalendarEvent{}
+	for _, ev := range c.events {
+		if (team == "" || ev.Team == team) && ev.Start.Before(to) && ev.End.After(from) {
+			out = append(out, ev)
+		}
+	}
+	c.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Start.Equal(out[j].Start) {
+			return out[i].Start.Before(out[j].Start)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// ForTeam returns a team's events on a reporting day, ordered by start
+func (c *Calendar) ForTeam(team, date string) []types.CalendarEvent {
+	from, to, err := types.DayBounds(date)
+	if err != nil {
+		return nil
+	}
+	return c.Between(team, from, to)
+}
+
+func validateEvent(ev types.CalendarEvent) error {
+	if ev.Team == "" {
+		return errors.New("team is required")
+	}
+	if ev.Activity != types.ActivityMeeting && ev.Activity != types.ActivityTraining {
+		return fmt.Errorf("activity must be meeting or training, got %q", ev.Activity)
+	}
+	if !ev.End.After(ev.Start) {
+		return errors.New("end must be after start")
+	}
+	if dateOf(ev.Start) != dateOf(ev.End.Add(-time.Nanosecond)) {
+		return errors.New("event must not cross midnight in the reporting timezone")
+	}
+	return nil
+}
+
+// WithCalendar lays team events over an agent's schedule: where an event runs it replaces
+// the scheduled activity, later events replacing earlier ones.
+func WithCalendar(agentID string, schedule []types.ScheduleInterval, events []types.CalendarEvent) []types.ScheduleInterval {
+	if len(events) == 0 {
+		return schedule
+	}
+	out := make([]types.ScheduleInterval, len(schedule))
+	copy(out, schedule)
+	for _, ev := range events {
+		var cut []types.ScheduleInterval
+		for _, iv := range out {
+			if !iv.Start.Before(ev.End) || !iv.End.After(ev.Start) {
+				cut = append(cut, iv)
+				continue
+			}
+			if iv.Start.Before(ev.Start) {
+				before := iv
+				before.End = ev.Start
+				cut = append(cut, before)
+			}
+			if iv.End.After(ev.End) {
+				after := iv
+				after.Start = ev.End
+				cut = append(cut, after)
+			}
+		}
+		out = append(cut, types.ScheduleInterval{AgentID: agentID, Start: ev.Start, End: ev.End, Activity: ev.Activity})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+// ParseCalendarCSV reads calendar events from CSV with a header row naming the columns
+// team, start, end and activity, and optionally id and title (any order; times in RFC3339).
+func ParseCalendarCSV(r io.Reader) ([]types.CalendarEvent, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"team", "start", "end", "activity"} {
+		if _, ok := cols[name]; !ok {
+			return nil, fmt.Errorf("missing CSV column %q", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		i, ok := cols[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var events []types.CalendarEvent
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		start, err := time.Parse(time.RFC3339, field(record, "start"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid start: %w", line, err)
+		}
+		end, err := time.Parse(time.RFC3339, field(record, "end"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid end: %w", line, err)
+		}
+		events = append(events, types.CalendarEvent{
+			ID:       field(record, "id"),
+			Team:     field(record, "team"),
+			Title:    field(record, "title"),
+			Activity: types.PlannedActivity(strings.ToLower(field(record, "activity"))),
+			Start:    start,
+			End:      end,
+		})
+	}
+	return events, nil
+}
+
+// ParseICS reads the VEVENTs of an iCalendar file. The team comes from an X-MONTI-TEAM
+// property, else from team. CATEGORIES containing TRAINING make a training, anything else
+// is a meeting. UID becomes the event ID so re-importing a file updates its events.
+// Recurrence rules are not expanded.
+func ParseICS(r io.Reader, team string) ([]types.CalendarEvent, error) {
+	lines, err := unfoldICS(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []types.CalendarEvent
+	var ev *types.CalendarEvent
+	for n, line := range lines {
+		name, params, value := splitICSLine(line)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			ev = &types.CalendarEvent{Team: team, Activity: types.ActivityMeeting}
+		case name == "END" && value == "VEVENT":
+			if ev == nil {
+				return nil, fmt.Errorf("line %d: END:VEVENT without BEGIN", n+1)
+			}
+			events = append(events, *ev)
+			ev = nil
+		case ev == nil:
+			// calendar properties and other components
+		case name == "UID":
+			ev.ID = value
+		case name == "SUMMARY":
+			ev.Title = unescapeICS(value)
+		case name == "X-MONTI-TEAM":
+			ev.Team = unescapeICS(value)
+		case name == "CATEGORIES":
+			if strings.Contains(strings.ToUpper(value), "TRAINING") {
+				ev.Activity = types.ActivityTraining
+			}
+		case name == "DTSTART" || name == "DTEND":
+			t, err := parseICSTime(value, params["TZID"])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid %s: %w", n+1, name, err)
+			}
+			if name == "DTSTART" {
+				ev.Start = t
+			} else {
+				ev.End = t
+			}
+		}
+	}
+	if ev != nil {
+		return nil, errors.New("unterminated VEVENT")
+	}
+	return events, nil
+}
+
+// unfoldICS splits an iCalendar stream into logical lines, joining folded continuations
+func unfoldICS(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %w", err)
+	}
+	return lines, nil
+}
+
+// splitICSLine splits NAME;PARAM=VALUE:value into its upper-cased name, parameters and value
+func splitICSLine(line string) (string, map[string]string, string) {
+	head, value, _ := strings.Cut(line, ":")
+	parts := strings.Split(head, ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(p, "=")
+		params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return strings.ToUpper(parts[0]), params, value
+}
+
+// parseICSTime parses a DATE-TIME in UTC (…Z), in tzid, or floating (read in the reporting
+// timezone)
+func parseICSTime(value, tzid string) (time.Time, error) {
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse("20060102T150405Z", value)
+	}
+	loc := types.ReportingLocation
+	if tzid != "" {
+		var err error
+		if loc, err = time.LoadLocation(tzid); err != nil {
+			return time.Time{}, fmt.Errorf("unknown TZID %q", tzid)
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC(), nil
+}
+
+var icsUnescaper = strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+
+func unescapeICS(s string) string {
+	return icsUnescaper.Replace(s)
+}
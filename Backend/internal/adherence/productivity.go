@@ -0,0 +1,86 @@
+package adherence
+
+import (
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/kpi"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// Productivity splits an agent's day into intervals of width (starting at midnight in the
+// reporting timezone) and
+// reports for each the calls completed in it with their AHT, the occupancy of the state
+// timeline and the adherence to the schedule. Intervals are counted up to now; those without
+// scheduled time, logged-in time or calls are left out.
+func Productivity(agentID, date string, width time.Duration, schedule []types.ScheduleInterval, segments []types.StateSegment, calls []types.CallRecord, now time.Time) []types.IntervalStats {
+	dayStart, dayEnd, err := types.DayBounds(date)
+	if err != nil || width <= 0 {
+		return nil
+	}
+
+	var out []types.IntervalStats
+	for start := dayStart; start.Before(dayEnd) && start.Before(now); start = start.Add(width) {
+		end := start.Add(width)
+		if dayEnd.Before(end) {
+			end = dayEnd
+		}
+		if now.Before(end) {
+			end = now
+		}
+		stats := types.IntervalStats{Start: start}
+
+		loggedIn := 0.0
+		for _, seg := range segments {
+			overlap := overlapSecs(start, end, seg.Start, seg.End)
+			if overlap == 0 || seg.State == types.StateOffline {
+				continue
+			}
+			loggedIn += overlap
+			switch {
+			case kpi.Handling(seg.State):
+				stats.HandlingSecs += overlap
+			case seg.State == types.StateAvailable:
+				stats.AvailableSecs += overlap
+			}
+		}
+		if stats.HandlingSecs+stats.AvailableSecs > 0 {
+			stats.Occupancy = stats.HandlingSecs / (stats.HandlingSecs + stats.AvailableSecs) * 100
+		}
+
+		handleSecs := 0.0
+		for _, call := range calls {
+			completed, err := time.Parse(time.RFC3339, call.CompleteTime)
+			if err != nil || call.Abandoned || completed.Before(start) || !completed.Before(end) {
+				continue
+			}
+			stats.CallsHandled++
+			handleSecs += call.HandleTime
+		}
+		if stats.CallsHandled > 0 {
+			stats.AvgHandleTime = handleSecs / float64(stats.CallsHandled)
+		}
+
+		var planned []types.ScheduleInterval
+		for _, iv := range schedule {
+			if iv.End.After(start) && iv.Start.Before(end) {
+				if iv.Start.Before(start) {
+					iv.Start = start
+				}
+				if iv.End.After(end) {
+					iv.End = end
+				}
+				planned = append(planned, iv)
+			}
+		}
+		if len(planned) > 0 {
+			result := Compute(agentID, date, planned, segments, end)
+			stats.ScheduledSecs = result.ScheduledSecs
+			stats.Adherence = result.Adherence
+		}
+
+		if stats.ScheduledSecs > 0 || loggedIn > 0 || stats.CallsHandled > 0 {
+			out = append(out, stats)
+		}
+	}
+	return out
+}
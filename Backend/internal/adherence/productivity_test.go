@@ -0,0 +1,52 @@
+package adherence
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+func TestProductivityIntervals(t *testing.T) {
+	// day starts at 08:00; with 30 minute intervals, at(0)-at(30) is the 08:00 interval
+	schedule := []types.ScheduleInterval{
+		{AgentID: "a1", Start: at(0), End: at(45), Activity: types.ActivityWork},
+		{AgentID: "a1", Start: at(45), End: at(60), Activity: types.ActivityBreak},
+	}
+	segments := []types.StateSegment{
+		{State: types.StateAvailable, Start: at(0), End: at(10)},
+		{State: types.StateOnCall, Start: at(10), End: at(25)},
+		{State: types.StateAfterCallWork, Start: at(25), End: at(30)},
+		{State: types.StateAvailable, Start: at(30), End: at(50)}, // 5 min into the break
+		{State: types.StateBreak, Start: at(50), End: at(60)},
+	}
+	calls := []types.CallRecord{
+		{AgentID: "a1", CompleteTime: at(29).Format(time.RFC3339), HandleTime: 1200},
+		{AgentID: "a1", CompleteTime: at(20).Format(time.RFC3339), HandleTime: 600},
+		{AgentID: "a1", CompleteTime: at(20).Format(time.RFC3339), Abandoned: true},
+	}
+
+	got := Productivity("a1", "2025-03-10", 30*time.Minute, schedule, segments, calls, at(55))
+	if len(got) != 2 {
+		t.Fatalf("expected the 08:00 and 08:30 intervals, got %+v", got)
+	}
+
+	first := got[0]
+	if !first.Start.Equal(at(0)) || first.CallsHandled != 2 || first.AvgHandleTime != 900 {
+		t.Errorf("unexpected calls in the first interval: %+v", first)
+	}
+	// 20 of 30 minutes handling (call + ACW)
+	if first.HandlingSecs != 1200 || first.AvailableSecs != 600 || math.Abs(first.Occupancy-1200.0/1800*100) > 0.01 {
+		t.Errorf("unexpected occupancy in the first interval: %+v", first)
+	}
+	if first.ScheduledSecs != 1800 || first.Adherence != 100 {
+		t.Errorf("expected the first interval fully adherent, got %+v", first)
+	}
+
+	// Up to now (08:55): 15 min work adherent, 5 of 10 break min adherent
+	second := got[1]
+	if second.CallsHandled != 0 || second.Occupancy != 0 || second.ScheduledSecs != 1500 || math.Abs(second.Adherence-20.0/25*100) > 0.01 {
+		t.Errorf("unexpected second interval: %+v", second)
+	}
+}
@@ -0,0 +1,147 @@
+package adherence
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// Schedules holds planned activity intervals per agent, keyed by reporting day
+type Schedules struct {
+	mu      sync.RWMutex
+	byAgent map[string]map[string][]types.ScheduleInterval // agentID -> date -> intervals
+}
+
+// NewSchedules creates an empty schedule store
+func NewSchedules() *Schedules {
+	return &Schedules{byAgent: make(map[string]map[string][]types.ScheduleInterval)}
+}
+
+// dateOf returns the reporting day an interval belongs to
+func dateOf(t time.Time) string {
+	return types.DateOf(t)
+}
+
+// Replace validates intervals and replaces every agent-day they cover.
+// Returns the number of agent-days replaced.
+func (s *Schedules) Replace(intervals []types.ScheduleInterval) (int, error) {
+	days := make(map[string]map[string][]types.ScheduleInterval)
+	for i, iv := range intervals {
+		if err := validateInterval(iv); err != nil {
+			return 0, fmt.Errorf("interval %d: %w", i+1, err)
+		}
+		date := dateOf(iv.Start)
+		if days[iv.AgentID] == nil {
+			days[iv.AgentID] = make(map[string][]types.ScheduleInterval)
+		}
+		days[iv.AgentID][date] = append(days[iv.AgentID][date], iv)
+	}
+
+	count := 0
+	for agentID, byDate := range days {
+		for date, ivs := range byDate {
+			sort.Slice(ivs, func(i, j int) bool { return ivs[i].Start.Before(ivs[j].Start) })
+			for i := 1; i < len(ivs); i++ {
+				if ivs[i].Start.Before(ivs[i-1].End) {
+					return 0, fmt.Errorf("overlapping intervals for agent %s on %s", agentID, date)
+				}
+			}
+			count++
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for agentID, byDate := range days {
+		if s.byAgent[agentID] == nil {
+			s.byAgent[agentID] = make(map[string][]types.ScheduleInterval)
+		}
+		for date, ivs := range byDate {
+			s.byAgent[agentID][date] = ivs
+		}
+	}
+	return count, nil
+}
+
+// ForAgent returns an agent's intervals for a date, ordered by start
+func (s *Schedules) ForAgent(agentID, date string) []types.ScheduleInterval {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ivs := s.byAgent[agentID][date]
+	out := make([]types.ScheduleInterval, len(ivs))
+	copy(out, ivs)
+	return out
+}
+
+func validateInterval(iv types.ScheduleInterval) error {
+	if iv.AgentID == "" {
+		return errors.New("agentId is required")
+	}
+	if !iv.End.After(iv.Start) {
+		return errors.New("end must be after start")
+	}
+	if dateOf(iv.Start) != dateOf(iv.End.Add(-time.Nanosecond)) {
+		return errors.New("interval must not cross midnight in the reporting timezone")
+	}
+	for _, a := range types.AllActivities {
+		if iv.Activity == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown activity %q", iv.Activity)
+}
+
+// ParseCSV reads schedule intervals from CSV with a header row naming the
+// columns agentId, start, end and activity (any order; times in RFC3339).
+func ParseCSV(r io.Reader) ([]types.ScheduleInterval, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"agentid", "start", "end", "activity"} {
+		if _, ok := cols[name]; !ok {
+			return nil, fmt.Errorf("missing CSV column %q", name)
+		}
+	}
+
+	var intervals []types.ScheduleInterval
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		start, err := time.Parse(time.RFC3339, strings.TrimSpace(record[cols["start"]]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid start: %w", line, err)
+		}
+		end, err := time.Parse(time.RFC3339, strings.TrimSpace(record[cols["end"]]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid end: %w", line, err)
+		}
+		intervals = append(intervals, types.ScheduleInterval{
+			AgentID:  strings.TrimSpace(record[cols["agentid"]]),
+			Start:    start,
+			End:      end,
+			Activity: types.PlannedActivity(strings.ToLower(strings.TrimSpace(record[cols["activity"]]))),
+		})
+	}
+	return intervals, nil
+}
@@ -0,0 +1,208 @@
+package adherence
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// SegmentStore persists and loads agent state segments (implemented by storage.Store)
+type SegmentStore interface {
+	SaveStateSegment(seg types.StateSegment) error
+	GetStateSegments(agentID string, from, to time.Time) ([]types.StateSegment, error)
+}
+
+// AgentLister provides the live agent roster (implemented by cache.AgentStateTracker)
+type AgentLister interface {
+	GetAll() []types.AgentInfo
+}
+
+// Service records the state timeline and computes adherence against schedules and the
+// team calendar
+type Service struct {
+	schedules *Schedules
+	calendar  *Calendar
+	store     SegmentStore
+	agents    AgentLister
+	logger    zerolog.Logger
+}
+
+// NewService creates a new adherence service
+func NewService(store SegmentStore, agents AgentLister, logger zerolog.Logger) *Service {
+	return &Service{
+		schedules: NewSchedules(),
+		calendar:  NewCalendar(),
+		store:     store,
+		agents:    agents,
+		logger:    logger.With().Str("component", "adherence").Logger(),
+	}
+}
+
+// Schedules returns the schedule store
+func (s *Service) Schedules() *Schedules {
+	return s.schedules
+}
+
+// Calendar returns the team calendar
+func (s *Service) Calendar() *Calendar {
+	return s.calendar
+}
+
+// Plan returns an agent's schedule for date with the team's calendar events laid over it
+func (s *Service) Plan(agentID, team, date string) []types.ScheduleInterval {
+	schedule := s.schedules.ForAgent(agentID, date)
+	if team == "" {
+		return schedule
+	}
+	return WithCalendar(agentID, schedule, s.calendar.ForTeam(team, date))
+}
+
+// RecordStateSegment persists a finished state segment (the store writes in the background)
+func (s *Service) RecordStateSegment(seg types.StateSegment) {
+	if err := s.store.SaveStateSegment(seg); err != nil {
+		s.logger.Error().Err(err).Str("agent_id", seg.AgentID).Msg("failed to save state segment")
+	}
+}
+
+// Agent returns the live info for an agent
+func (s *Service) Agent(agentID string) (types.AgentInfo, bool) {
+	for _, a := range s.agents.GetAll() {
+		if a.AgentID == agentID {
+			return a, true
+		}
+	}
+	return types.AgentInfo{}, false
+}
+
+// AgentAdherence computes adherence for one agent on date, including the
+// agent's current (not yet persisted) state.
+func (s *Service) AgentAdherence(agentID, date string, now time.Time) (types.AgentAdherence, error) {
+	agent, _ := s.Agent(agentID)
+	return s.compute(agent, agentID, date, now)
+}
+
+// TeamAdherence computes adherence for every scheduled agent of a team on date. With
+// calendar events for the team that day, every member counts as scheduled.
+// include filters agents (e.g. by the caller's allowed locations); nil includes all.
+func (s *Service) TeamAdherence(team, date string, now time.Time, include func(types.AgentInfo) bool) (types.TeamAdherence, error) {
+	var results []types.AgentAdherence
+	for _, agent := range s.agents.GetAll() {
+		if agent.Team != team || (include != nil && !include(agent)) {
+			continue
+		}
+		if len(s.Plan(agent.AgentID, agent.Team, date)) == 0 {
+			continue
+		}
+		res, err := s.compute(agent, agent.AgentID, date, now)
+		if err != nil {
+			return types.TeamAdherence{}, err
+		}
+		results = append(results, res)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].AgentID < results[j].AgentID })
+	return Aggregate(team, date, results), nil
+}
+
+// ScheduledAgents counts a tenant's agents of the department scheduled to work at the given time
+func (s *Service) ScheduledAgents(tenant string, dept types.Department, at time.Time) int {
+	date := dateOf(at)
+	count := 0
+	for _, agent := range s.agents.GetAll() {
+		if agent.Department != dept || types.TenantOf(agent.Tenant) != types.TenantOf(tenant) {
+			continue
+		}
+		for _, iv := range s.Plan(agent.AgentID, agent.Team, date) {
+			if iv.Activity == types.ActivityWork && !at.Before(iv.Start) && at.Before(iv.End) {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
+
+// Productivity reports the intervals of width of every agent on date, from their state
+// timelines, schedules and calls, the day's call records of all agents.
+// include filters agents (e.g. by the caller's scope); nil includes all.
+func (s *Service) Productivity(date string, width time.Duration, calls []types.CallRecord, now time.Time, include func(types.AgentInfo) bool) (types.ProductivityReport, error) {
+	byAgent := make(map[string][]types.CallRecord)
+	for _, call := range calls {
+		if call.AgentID != "" {
+			byAgent[call.AgentID] = append(byAgent[call.AgentID], call)
+		}
+	}
+
+	report := types.ProductivityReport{
+		Date:            date,
+		IntervalMinutes: int(width / time.Minute),
+		Agents:          []types.AgentProductivity{},
+	}
+	for _, agent := range s.agents.GetAll() {
+		if include != nil && !include(agent) {
+			continue
+		}
+		segments, err := s.timeline(agent, agent.AgentID, date, now)
+		if err != nil {
+			return types.ProductivityReport{}, err
+		}
+		intervals := Productivity(agent.AgentID, date, width, s.Plan(agent.AgentID, agent.Team, date), segments, byAgent[agent.AgentID], now)
+		if len(intervals) == 0 {
+			continue
+		}
+		report.Agents = append(report.Agents, types.AgentProductivity{
+			AgentID:    agent.AgentID,
+			Team:       agent.Team,
+			Department: agent.Department,
+			Intervals:  intervals,
+		})
+	}
+	sort.Slice(report.Agents, func(i, j int) bool { return report.Agents[i].AgentID < report.Agents[j].AgentID })
+	return report, nil
+}
+
+func (s *Service) compute(agent types.AgentInfo, agentID, date string, now time.Time) (types.AgentAdherence, error) {
+	segments, err := s.timeline(agent, agentID, date, now)
+	if err != nil {
+		return types.AgentAdherence{}, err
+	}
+
+	result := Compute(agentID, date, s.Plan(agentID, agent.Team, date), segments, now)
+	result.Team = agent.Team
+	return result, nil
+}
+
+// timeline loads the agent's persisted state segments of date and adds the current,
+// not yet persisted state up to now. Segments crossing into the day are cut at its start.
+func (s *Service) timeline(agent types.AgentInfo, agentID, date string, now time.Time) ([]types.StateSegment, error) {
+	from, to, err := types.DayBounds(date)
+	if err != nil {
+		return nil, err
+	}
+	segments, err := s.store.GetStateSegments(agentID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load state timeline: %w", err)
+	}
+	if agent.AgentID != "" && agent.State != types.StateOffline && now.After(agent.StateStart) {
+		current := types.StateSegment{
+			AgentID: agentID,
+			State:   agent.State,
+			Start:   agent.StateStart,
+			End:     now,
+		}
+		if current.Overlaps(from, to) {
+			segments = append(segments, current)
+		}
+	}
+	for i := range segments {
+		if segments[i].Start.Before(from) {
+			segments[i].Start = from
+		}
+		if segments[i].End.After(to) {
+			segments[i].End = to
+		}
+	}
+	return segments, nil
+}
@@ -0,0 +1,135 @@
+// Package agentsim talks to AgentSim's control API, for the admin simulation routes and for
+// sim presets
+package agentsim
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/callqueue"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// requestTimeout bounds one request to AgentSim
+const requestTimeout = 10 * time.Second
+
+// Client calls the AgentSim control API at a base URL
+type Client struct {
+	url    string
+	client *http.Client
+}
+
+// New creates a Client for the AgentSim control API at url
+func New(url string) *Client {
+	return &Client{url: url, client: &http.Client{Timeout: requestTimeout}}
+}
+
+// Do sends a request with a JSON body (nil = none) to path and returns AgentSim's response
+// whatever its status; the caller closes the body
+func (c *Client) Do(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.url+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("AgentSim unavailable: %w", err)
+	}
+	return resp, nil
+}
+
+// Status returns AgentSim's status
+func (c *Client) Status(ctx context.Context) (types.SimStatus, error) {
+	var status types.SimStatus
+	err := c.call(ctx, http.MethodGet, "/status", nil, &status)
+	return status, err
+}
+
+// Start starts the simulation with agents online
+func (c *Client) Start(ctx context.Context, agents int) error {
+	return c.call(ctx, http.MethodPost, "/start", types.SimScaleRequest{ActiveAgents: agents}, nil)
+}
+
+// Scale changes the number of online agents
+func (c *Client) Scale(ctx context.Context, agents int) error {
+	return c.call(ctx, http.MethodPost, "/scale", types.SimScaleRequest{ActiveAgents: agents}, nil)
+}
+
+// Stop stops the simulation
+func (c *Client) Stop(ctx context.Context) error {
+	return c.call(ctx, http.MethodPost, "/stop", struct{}{}, nil)
+}
+
+// SetCallConfig changes the call generator's peak factor (0 keeps it) and department rates
+func (c *Client) SetCallConfig(ctx context.Context, peakHourFactor float64, rates map[types.Department]float64) error {
+	body := map[string]interface{}{}
+	if peakHourFactor > 0 {
+		body["peakHourFactor"] = peakHourFactor
+	}
+	if len(rates) > 0 {
+		departments := make(map[string]interface{}, len(rates))
+		for dept, rate := range rates {
+			departments[string(dept)] = map[string]float64{"callsPerMin": rate}
+		}
+		body["departments"] = departments
+	}
+	return c.call(ctx, http.MethodPut, "/calls/config", body, nil)
+}
+
+// call sends in as JSON (nil = no body) and decodes the response into out, if set. Error
+// statuses are returned as errors.
+func (c *Client) call(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	resp, err := c.Do(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("AgentSim %s %s: %d %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+// Control drives AgentSim for sim preset scripts. Injected calls are enqueued locally, like
+// POST /api/admin/calls/inject.
+type Control struct {
+	*Client
+	callQueue *callqueue.CallQueueManager
+}
+
+// NewControl creates a Control over client that enqueues injected calls into callQueue
+func NewControl(client *Client, callQueue *callqueue.CallQueueManager) *Control {
+	return &Control{Client: client, callQueue: callQueue}
+}
+
+// Inject enqueues count calls into vq, or round-robin over all VQs when vq is empty
+func (c *Control) Inject(_ context.Context, count int, vq string) error {
+	vqs := types.AllVQs
+	if vq != "" {
+		vqs = []types.VQName{types.VQName(vq)}
+	}
+	for i := 0; i < count; i++ {
+		c.callQueue.EnqueueCall(vqs[i%len(vqs)], "")
+	}
+	return nil
+}
@@ -3,19 +3,26 @@ package aggregator
 import (
 	"context"
 	"encoding/json"
+	"sync"
 	"time"
 
 	"github.com/dennisdiepolder/monti/backend/internal/alerts"
 	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/leaderboard"
 	"github.com/dennisdiepolder/monti/backend/internal/metrics"
+	"github.com/dennisdiepolder/monti/backend/internal/rollup"
+	"github.com/dennisdiepolder/monti/backend/internal/tracing"
 	"github.com/dennisdiepolder/monti/backend/internal/types"
 	"github.com/dennisdiepolder/monti/backend/internal/websocket"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// VQSnapshotProvider provides VQ snapshots grouped by department
+// VQSnapshotProvider provides VQ snapshots and per-channel occupancy grouped by department
 type VQSnapshotProvider interface {
 	GetAllSnapshots() map[types.Department][]types.VQSnapshot
+	GetChannelOccupancy() map[types.Department][]types.ChannelOccupancy
 }
 
 // Aggregator collects events and creates widgets
@@ -24,7 +31,26 @@ type Aggregator struct {
 	stateTracker *cache.AgentStateTracker
 	hub          *websocket.Hub
 	callQueue    VQSnapshotProvider
+	alertEngine  *alerts.Engine
+	alertTracker *alerts.Tracker
+	rollupDims   []types.RollupDimension
+	teams        TeamDirectory
+	notices      AnnouncementSource
+	trends       *trendTracker
+	cadence      Cadence
+	shed         loadShedder
 	logger       zerolog.Logger
+
+	// Per-section state, only touched from the aggregation loop
+	ticks            uint64
+	cycle            uint64
+	lastRollups      []types.Rollup
+	lastTrends       *types.SnapshotTrends
+	lastLeaderboards map[types.Department][]types.Leaderboard
+
+	// Leaderboard settings can be changed at runtime via the admin API
+	leaderboards types.LeaderboardSettings
+	mu           sync.RWMutex
 }
 
 // NewAggregator creates a new aggregator
@@ -33,6 +59,9 @@ func NewAggregator(cache *cache.EventCache, stateTracker *cache.AgentStateTracke
 		cache:        cache,
 		stateTracker: stateTracker,
 		hub:          hub,
+		alertEngine:  alerts.NewEngine(nil),
+		trends:       newTrendTracker(trendWindow),
+		cadence:      DefaultCadence(),
 		logger:       logger,
 	}
 }
@@ -42,13 +71,84 @@ func (a *Aggregator) SetCallQueue(cq VQSnapshotProvider) {
 	a.callQueue = cq
 }
 
+// SetAlertEngine replaces the alert rules engine evaluated every cycle
+func (a *Aggregator) SetAlertEngine(engine *alerts.Engine) {
+	a.alertEngine = engine
+}
+
+// SetAlertTracker sets the tracker that turns evaluated alerts into stateful records
+func (a *Aggregator) SetAlertTracker(tracker *alerts.Tracker) {
+	a.alertTracker = tracker
+}
+
+// SetRollupDimensions sets the grouping dimensions for snapshot rollups (nil disables rollups)
+func (a *Aggregator) SetRollupDimensions(dims []types.RollupDimension) {
+	a.rollupDims = dims
+}
+
+// TeamDirectory resolves team supervisors for team rollups (implemented by teams.Directory)
+type TeamDirectory interface {
+	Supervisor(team string) string
+}
+
+// SetTeamDirectory sets the directory used to label team rollups with their supervisor
+func (a *Aggregator) SetTeamDirectory(teams TeamDirectory) {
+	a.teams = teams
+}
+
+// AnnouncementSource provides the announcements to embed in snapshots (implemented by
+// announcements.Board)
+type AnnouncementSource interface {
+	Active(now time.Time) []types.Announcement
+}
+
+// SetAnnouncements sets the source of the announcements embedded in every snapshot
+func (a *Aggregator) SetAnnouncements(source AnnouncementSource) {
+	a.notices = source
+}
+
+// SetCadence sets the broadcast interval and per-section cadence (call before Start)
+func (a *Aggregator) SetCadence(cadence Cadence) {
+	if cadence.Interval <= 0 {
+		cadence.Interval = DefaultCadence().Interval
+	}
+	a.cadence = cadence
+}
+
+// SetLoadShedding sets the latency thresholds for degrading snapshots under overload
+// (call before Start)
+func (a *Aggregator) SetLoadShedding(limits LoadShedding) {
+	a.shed.limits = limits
+}
+
+// LeaderboardSettings returns the current leaderboard settings
+func (a *Aggregator) LeaderboardSettings() types.LeaderboardSettings {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.leaderboards
+}
+
+// SetLeaderboardSettings enables/disables leaderboards and sets their size
+func (a *Aggregator) SetLeaderboardSettings(settings types.LeaderboardSettings) {
+	if settings.Size > leaderboard.MaxSize {
+		settings.Size = leaderboard.MaxSize
+	}
+	a.mu.Lock()
+	a.leaderboards = settings
+	a.mu.Unlock()
+}
+
 // Start begins aggregating events and broadcasting a single snapshot every tick
 func (a *Aggregator) Start(ctx context.Context) {
-	ticker := time.NewTicker(1 * time.Second)
+	ticker := time.NewTicker(a.cadence.Interval)
 	defer ticker.Stop()
 
 	m := metrics.Get()
-	a.logger.Info().Msg("aggregator started")
+	a.logger.Info().
+		Dur("interval", a.cadence.Interval).
+		Dur("trends_every", a.cadence.Trends).
+		Dur("leaderboards_every", a.cadence.Leaderboards).
+		Msg("aggregator started")
 
 	for {
 		select {
@@ -57,36 +157,62 @@ func (a *Aggregator) Start(ctx context.Context) {
 			return
 
 		case <-ticker.C:
-			cycleStart := time.Now()
+			// While shedding load only every stride-th interval produces a snapshot
+			a.ticks++
+			if a.ticks%a.shed.stride() != 0 {
+				m.RecordSnapshotShed()
+				continue
+			}
 
-			// Clear recent events
-			a.cache.GetAndClear()
+			cycleStart := time.Now()
+			_, span := tracing.Tracer().Start(ctx, "snapshot.broadcast",
+				trace.WithNewRoot(),
+				trace.WithLinks(tracing.DrainPendingLinks()...))
 
-			// Get VQ snapshots
-			var vqSnapshots map[types.Department][]types.VQSnapshot
-			if a.callQueue != nil {
-				vqSnapshots = a.callQueue.GetAllSnapshots()
-			}
+			// Drop events that left the retention window
+			a.cache.Expire(cycleStart)
 
-			// Single-pass: build snapshot and collect connected agents under one lock
-			snapshot, connectedAgents := a.stateTracker.BuildSnapshot(vqSnapshots)
+			snapshot, connectedAgents := a.buildSnapshot()
 
 			if len(connectedAgents) > 0 {
 				m.UpdateAgentStats(connectedAgents)
-				alerts.CheckAgentAlerts(connectedAgents)
+			}
+			if a.callQueue != nil {
+				var queues []types.VQSnapshot
+				for _, dept := range snapshot.Departments {
+					queues = append(queues, dept.Queues...)
+				}
+				m.UpdateQueueStats(queues)
+			}
+
+			snapshot.Degradation = a.shed.degradation(a.cadence.Interval)
+			if snapshot.Degradation != nil {
+				// Event timings only feed the broadcast latency summary
+				snapshot.EventTimes = nil
 			}
 
 			data, err := json.Marshal(snapshot)
 			if err != nil {
 				a.logger.Error().Err(err).Msg("failed to marshal snapshot")
 				m.RecordAggregationError()
+				span.RecordError(err)
+				span.End()
 				continue
 			}
 
+			built := time.Since(cycleStart)
 			a.hub.Broadcast(data)
 
 			// Record aggregation cycle metrics
-			m.RecordAggregationCycle(time.Since(cycleStart), 1)
+			m.RecordAggregationCycle(built, 1)
+			if a.shed.observe(built, a.hub.LastBroadcastDuration(), cycleStart) {
+				a.logShedChange(m)
+			}
+			span.SetAttributes(
+				attribute.Int("payload_bytes", len(data)),
+				attribute.Int("clients", a.hub.ClientCount()),
+			)
+			span.End()
 
 			a.logger.Debug().
 				Int("connected_agents", len(connectedAgents)).
@@ -97,3 +223,84 @@ func (a *Aggregator) Start(ctx context.Context) {
 	}
 }
 
+// logShedChange reports a new degradation level in the logs and metrics
+func (a *Aggregator) logShedChange(m *metrics.Metrics) {
+	level := shedLevels[a.shed.level]
+	name := string(level)
+	if level == types.DegradationNormal {
+		name = "normal"
+	}
+	m.SetLoadShedLevel(a.shed.level, name)
+
+	event := a.logger.Warn()
+	if level == types.DegradationNormal {
+		event = a.logger.Info()
+	}
+	event.Str("level", name).
+		Str("reason", a.shed.reason).
+		Dur("snapshot_interval", time.Duration(a.shed.stride())*a.cadence.Interval).
+		Msg("snapshot fidelity changed")
+}
+
+// buildSnapshot assembles the broadcast snapshot: agent states from the tracker,
+// VQ snapshots from the call queue, active alerts evaluated on the agents
+// that are actually sent to clients, optional leaderboards, the configured rollups,
+// short-window trends and active announcements. Sections run on their own cadence.
+func (a *Aggregator) buildSnapshot() (types.Snapshot, []types.AgentInfo) {
+	cycle := a.cycle
+	a.cycle++
+
+	// Get VQ snapshots and channel occupancy
+	var vqSnapshots map[types.Department][]types.VQSnapshot
+	var channels map[types.Department][]types.ChannelOccupancy
+	if a.callQueue != nil {
+		vqSnapshots = a.callQueue.GetAllSnapshots()
+		channels = a.callQueue.GetChannelOccupancy()
+	}
+
+	// Single-pass: build snapshot and collect connected agents from the frozen agent view
+	snapshot, connectedAgents := a.stateTracker.BuildSnapshot(vqSnapshots)
+	for dept, data := range snapshot.Departments {
+		data.Channels = channels[dept]
+	}
+
+	// Alerts must be evaluated on the snapshot's agent slices — the connected
+	// slice holds separate copies that never reach the frontend.
+	a.alertEngine.Evaluate(&snapshot, snapshot.Timestamp)
+	if a.alertTracker != nil {
+		a.alertTracker.Update(&snapshot, snapshot.Timestamp)
+	}
+
+	if lb := a.LeaderboardSettings(); lb.Enabled {
+		if a.lastLeaderboards == nil || cycle%a.cadence.every(a.cadence.Leaderboards) == 0 {
+			a.lastLeaderboards = make(map[types.Department][]types.Leaderboard, len(snapshot.Departments))
+			for dept, data := range snapshot.Departments {
+				a.lastLeaderboards[dept] = leaderboard.Build(data.Agents, lb.Size)
+			}
+		}
+		for dept, data := range snapshot.Departments {
+			data.Leaderboards = a.lastLeaderboards[dept]
+		}
+	} else {
+		a.lastLeaderboards = nil
+	}
+
+	if cycle%a.cadence.every(a.cadence.Rollups) == 0 {
+		a.lastRollups = rollup.Build(snapshot.Departments, a.rollupDims)
+		if a.teams != nil {
+			rollup.SetSupervisors(a.lastRollups, a.teams.Supervisor)
+		}
+	}
+	snapshot.Rollups = a.lastRollups
+
+	if cycle%a.cadence.every(a.cadence.Trends) == 0 {
+		a.lastTrends = a.trends.update(&snapshot, snapshot.Timestamp)
+	}
+	snapshot.Trends = a.lastTrends
+
+	if a.notices != nil {
+		snapshot.Announcements = a.notices.Active(snapshot.Timestamp)
+	}
+
+	return snapshot, connectedAgents
+}
@@ -0,0 +1,101 @@
+package aggregator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/dennisdiepolder/monti/backend/internal/websocket"
+	"github.com/rs/zerolog"
+)
+
+type fakeVQProvider struct {
+	snapshots map[types.Department][]types.VQSnapshot
+}
+
+func (f *fakeVQProvider) GetAllSnapshots() map[types.Department][]types.VQSnapshot {
+	return f.snapshots
+}
+
+func (f *fakeVQProvider) GetChannelOccupancy() map[types.Department][]types.ChannelOccupancy {
+	return nil
+}
+
+func TestBuildSnapshotIncludesQueues(t *testing.T) {
+	logger := zerolog.Nop()
+	tracker := cache.NewAgentStateTracker()
+	tracker.RegisterAgent(&types.AgentRegister{
+		AgentID:    "agent-1",
+		Department: types.DeptSales,
+		Location:   types.LocationBerlin,
+		State:      types.StateAvailable,
+	})
+
+	agg := NewAggregator(cache.NewEventCache(0), tracker, websocket.NewHub(logger), logger)
+	agg.SetCallQueue(&fakeVQProvider{snapshots: map[types.Department][]types.VQSnapshot{
+		types.DeptSales: {{VQ: types.VQSalesInbound, Department: types.DeptSales, WaitingCount: 3}},
+	}})
+
+	snapshot, connected := agg.buildSnapshot()
+
+	if len(connected) != 1 {
+		t.Fatalf("expected 1 connected agent, got %d", len(connected))
+	}
+
+	sales := snapshot.Departments[types.DeptSales]
+	if len(sales.Queues) != 1 || sales.Queues[0].WaitingCount != 3 {
+		t.Errorf("expected sales queue snapshot with 3 waiting, got %+v", sales.Queues)
+	}
+	if len(sales.Agents) != 1 {
+		t.Errorf("expected 1 sales agent, got %d", len(sales.Agents))
+	}
+
+	support := snapshot.Departments[types.DeptSupport]
+	if support.Queues == nil {
+		t.Error("expected empty (non-nil) queue slice for department without snapshots")
+	}
+}
+
+func TestBuildSnapshotWithoutCallQueue(t *testing.T) {
+	logger := zerolog.Nop()
+	agg := NewAggregator(cache.NewEventCache(0), cache.NewAgentStateTracker(), websocket.NewHub(logger), logger)
+
+	snapshot, _ := agg.buildSnapshot()
+
+	if len(snapshot.Departments) != 4 {
+		t.Errorf("expected 4 departments, got %d", len(snapshot.Departments))
+	}
+}
+
+func TestCadenceEvery(t *testing.T) {
+	c := Cadence{Interval: time.Second, Trends: 5 * time.Second, Leaderboards: 2500 * time.Millisecond, Rollups: 0}
+
+	if got := c.every(c.Trends); got != 5 {
+		t.Errorf("expected trends every 5 cycles, got %d", got)
+	}
+	if got := c.every(c.Leaderboards); got != 3 {
+		t.Errorf("expected leaderboards every 3 cycles (rounded up), got %d", got)
+	}
+	if got := c.every(c.Rollups); got != 1 {
+		t.Errorf("expected unset cadence to refresh every cycle, got %d", got)
+	}
+}
+
+func TestBuildSnapshotReusesSectionsBetweenRefreshes(t *testing.T) {
+	logger := zerolog.Nop()
+	agg := NewAggregator(cache.NewEventCache(0), cache.NewAgentStateTracker(), websocket.NewHub(logger), logger)
+	agg.SetCadence(Cadence{Interval: time.Second, Trends: 3 * time.Second})
+
+	first, _ := agg.buildSnapshot()
+	second, _ := agg.buildSnapshot()
+	if first.Trends == nil || first.Trends != second.Trends {
+		t.Error("expected trends to be reused until the next refresh")
+	}
+
+	agg.buildSnapshot()
+	fourth, _ := agg.buildSnapshot()
+	if fourth.Trends == first.Trends {
+		t.Error("expected trends to be recomputed after 3 cycles")
+	}
+}
@@ -0,0 +1,33 @@
+package aggregator
+
+import "time"
+
+// Cadence controls how often the aggregator broadcasts and how often each
+// snapshot section is recomputed. Sections that are not due reuse their last
+// computed value, so every broadcast still carries the full payload.
+type Cadence struct {
+	Interval     time.Duration // base broadcast interval (agents + queues)
+	Rollups      time.Duration
+	Trends       time.Duration
+	Leaderboards time.Duration
+}
+
+// DefaultCadence returns the default cadence: agents every second, trends every 5s,
+// leaderboards every 30s
+func DefaultCadence() Cadence {
+	return Cadence{
+		Interval:     1 * time.Second,
+		Rollups:      1 * time.Second,
+		Trends:       5 * time.Second,
+		Leaderboards: 30 * time.Second,
+	}
+}
+
+// every converts a section cadence into a number of base cycles (at least 1)
+func (c Cadence) every(section time.Duration) uint64 {
+	if c.Interval <= 0 || section <= c.Interval {
+		return 1
+	}
+	n := (section + c.Interval - 1) / c.Interval // round up
+	return uint64(n)
+}
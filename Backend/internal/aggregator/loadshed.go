@@ -0,0 +1,100 @@
+package aggregator
+
+import (
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+const (
+	// shedAfter is how many snapshots in a row must be slow before fidelity drops a level
+	shedAfter = 3
+	// recoverAfter is how many snapshots in a row must be fast (under half of every
+	// threshold) before fidelity rises a level again
+	recoverAfter = 20
+)
+
+// shedLevels lists the degradation levels from full fidelity down
+var shedLevels = []types.DegradationLevel{types.DegradationNormal, types.DegradationReduced, types.DegradationSummary}
+
+// LoadShedding sets the latencies above which the aggregator degrades snapshots; a threshold
+// of 0 disables its trigger, both 0 disable load shedding
+type LoadShedding struct {
+	AggregationLatency time.Duration // building and marshalling one snapshot
+	BroadcastLatency   time.Duration // fanning a snapshot out to all dashboards
+}
+
+// loadShedder steps the degradation level down after consecutive slow snapshots and back up
+// after consecutive fast ones; only touched from the aggregation loop
+type loadShedder struct {
+	limits LoadShedding
+	level  int // index into shedLevels
+	since  time.Time
+	reason string
+	slow   int
+	fast   int
+}
+
+// observe records the latencies of one snapshot and reports whether the level changed
+func (s *loadShedder) observe(aggregation, broadcast time.Duration, now time.Time) bool {
+	reason := ""
+	switch {
+	case over(aggregation, s.limits.AggregationLatency):
+		reason = "aggregation"
+	case over(broadcast, s.limits.BroadcastLatency):
+		reason = "broadcast"
+	}
+
+	if reason != "" {
+		s.fast = 0
+		s.slow++
+		if s.slow < shedAfter || s.level == len(shedLevels)-1 {
+			return false
+		}
+		s.slow = 0
+		s.level++
+		s.since, s.reason = now, reason
+		return true
+	}
+
+	s.slow = 0
+	if over(2*aggregation, s.limits.AggregationLatency) || over(2*broadcast, s.limits.BroadcastLatency) {
+		// Between half the threshold and the threshold: hold the current level
+		s.fast = 0
+		return false
+	}
+	s.fast++
+	if s.fast < recoverAfter || s.level == 0 {
+		return false
+	}
+	s.fast = 0
+	s.level--
+	s.since = now
+	if s.level == 0 {
+		s.reason = ""
+	}
+	return true
+}
+
+// over reports whether d exceeds an enabled threshold
+func over(d, threshold time.Duration) bool {
+	return threshold > 0 && d > threshold
+}
+
+// stride is how many aggregation intervals pass between snapshots at the current level
+func (s *loadShedder) stride() uint64 {
+	return 1 << s.level
+}
+
+// degradation describes the current level for the snapshot, nil at full fidelity
+func (s *loadShedder) degradation(interval time.Duration) *types.Degradation {
+	if s.level == 0 {
+		return nil
+	}
+	return &types.Degradation{
+		Level:           shedLevels[s.level],
+		Since:           s.since,
+		Reason:          s.reason,
+		IntervalSeconds: (time.Duration(s.stride()) * interval).Seconds(),
+	}
+}
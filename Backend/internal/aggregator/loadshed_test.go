@@ -0,0 +1,57 @@
+package aggregator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+func TestLoadShedderStepsDownAndRecovers(t *testing.T) {
+	s := loadShedder{limits: LoadShedding{AggregationLatency: 100 * time.Millisecond, BroadcastLatency: 100 * time.Millisecond}}
+	now := time.Now()
+	slow, fast := 200*time.Millisecond, 10*time.Millisecond
+
+	for i := 0; i < shedAfter-1; i++ {
+		if s.observe(slow, fast, now) {
+			t.Fatalf("degraded after %d slow snapshots", i+1)
+		}
+	}
+	if !s.observe(fast, slow, now) {
+		t.Fatal("expected to degrade after consecutive slow snapshots")
+	}
+	d := s.degradation(time.Second)
+	if d == nil || d.Level != types.DegradationReduced || d.Reason != "broadcast" || d.IntervalSeconds != 2 {
+		t.Fatalf("expected reduced fidelity every 2s, got %+v", d)
+	}
+
+	for i := 0; i < 2*shedAfter; i++ {
+		s.observe(slow, fast, now)
+	}
+	if d := s.degradation(time.Second); d.Level != types.DegradationSummary || s.stride() != 4 {
+		t.Fatalf("expected summary-only every 4th interval, got %+v", d)
+	}
+
+	// Latencies between half the threshold and the threshold hold the level
+	for i := 0; i < recoverAfter; i++ {
+		s.observe(60*time.Millisecond, fast, now)
+	}
+	if s.level != 2 {
+		t.Fatalf("expected the level to hold, got %d", s.level)
+	}
+	for i := 0; i < 2*recoverAfter; i++ {
+		s.observe(fast, fast, now)
+	}
+	if s.degradation(time.Second) != nil || s.stride() != 1 {
+		t.Errorf("expected full fidelity after recovering, got level %d", s.level)
+	}
+}
+
+func TestLoadShedderDisabled(t *testing.T) {
+	var s loadShedder
+	for i := 0; i < 10*shedAfter; i++ {
+		if s.observe(time.Hour, time.Hour, time.Now()) {
+			t.Fatal("expected no degradation without thresholds")
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package aggregator
+
+import (
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/rollup"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// trendWindow is the moving-average window for snapshot trends
+const trendWindow = 5 * time.Minute
+
+// trendValues holds one sample of the trended metrics
+type trendValues struct {
+	available float64
+	waiting   float64
+	sl        float64
+	occupancy float64
+}
+
+type trendSample struct {
+	at     time.Time
+	values trendValues
+}
+
+// trendSeries keeps the samples of one scope within the trend window
+type trendSeries struct {
+	samples []trendSample
+}
+
+// add appends a sample and drops samples that fell out of the window
+func (s *trendSeries) add(sample trendSample, window time.Duration) {
+	cutoff := sample.at.Add(-window)
+	keep := 0
+	for keep < len(s.samples) && s.samples[keep].at.Before(cutoff) {
+		keep++
+	}
+	if keep > 0 {
+		// Shift left instead of re-slicing so the backing array doesn't grow unbounded
+		n := copy(s.samples, s.samples[keep:])
+		s.samples = s.samples[:n]
+	}
+	s.samples = append(s.samples, sample)
+}
+
+// trendSet computes moving averages and deltas for the latest sample
+func (s *trendSeries) trendSet() types.TrendSet {
+	if len(s.samples) == 0 {
+		return types.TrendSet{}
+	}
+
+	var sum trendValues
+	for _, sample := range s.samples {
+		sum.available += sample.values.available
+		sum.waiting += sample.values.waiting
+		sum.sl += sample.values.sl
+		sum.occupancy += sample.values.occupancy
+	}
+	n := float64(len(s.samples))
+	first := s.samples[0].values
+	last := s.samples[len(s.samples)-1].values
+
+	return types.TrendSet{
+		AvailableAgents: &types.Trend{Current: last.available, Average: sum.available / n, Delta: last.available - first.available},
+		WaitingCalls:    &types.Trend{Current: last.waiting, Average: sum.waiting / n, Delta: last.waiting - first.waiting},
+		ServiceLevel:    &types.Trend{Current: last.sl, Average: sum.sl / n, Delta: last.sl - first.sl},
+		Occupancy:       &types.Trend{Current: last.occupancy, Average: sum.occupancy / n, Delta: last.occupancy - first.occupancy},
+	}
+}
+
+// trendTracker maintains trend series globally and per department across aggregation cycles
+type trendTracker struct {
+	window      time.Duration
+	global      *trendSeries
+	departments map[types.Department]*trendSeries
+}
+
+func newTrendTracker(window time.Duration) *trendTracker {
+	return &trendTracker{
+		window:      window,
+		global:      &trendSeries{},
+		departments: make(map[types.Department]*trendSeries),
+	}
+}
+
+// update samples the snapshot and returns the current trends
+func (t *trendTracker) update(snapshot *types.Snapshot, now time.Time) *types.SnapshotTrends {
+	result := &types.SnapshotTrends{
+		WindowSecs:  int(t.window.Seconds()),
+		Departments: make(map[types.Department]types.TrendSet, len(snapshot.Departments)),
+	}
+
+	allDepts := make([]types.Department, 0, len(snapshot.Departments))
+	for dept := range snapshot.Departments {
+		allDepts = append(allDepts, dept)
+
+		series, ok := t.departments[dept]
+		if !ok {
+			series = &trendSeries{}
+			t.departments[dept] = series
+		}
+		series.add(trendSample{at: now, values: sampleValues(snapshot.Departments, []types.Department{dept})}, t.window)
+		result.Departments[dept] = series.trendSet()
+	}
+
+	t.global.add(trendSample{at: now, values: sampleValues(snapshot.Departments, allDepts)}, t.window)
+	result.Global = t.global.trendSet()
+
+	return result
+}
+
+// sampleValues computes the trended metrics across the given departments
+func sampleValues(departments map[types.Department]*types.DepartmentData, depts []types.Department) trendValues {
+	var v trendValues
+	loggedIn := 0
+	occupancySum := 0.0
+	for _, dept := range depts {
+		data, ok := departments[dept]
+		if !ok {
+			continue
+		}
+		for _, agent := range data.Agents {
+			if agent.ConnectionStatus != types.StatusConnected || agent.State == types.StateOffline {
+				continue
+			}
+			loggedIn++
+			occupancySum += agent.KPIs.Occupancy
+			if agent.State == types.StateAvailable {
+				v.available++
+			}
+		}
+	}
+	if loggedIn > 0 {
+		v.occupancy = occupancySum / float64(loggedIn)
+	}
+
+	waiting, sl := rollup.QueueTotals(departments, depts)
+	v.waiting = float64(waiting)
+	v.sl = sl
+	return v
+}
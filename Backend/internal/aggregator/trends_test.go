@@ -0,0 +1,92 @@
+package aggregator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+func trendSnapshot(available int, waiting int) *types.Snapshot {
+	agents := make([]types.AgentInfo, 0, available)
+	for i := 0; i < available; i++ {
+		agents = append(agents, types.AgentInfo{
+			State:            types.StateAvailable,
+			ConnectionStatus: types.StatusConnected,
+			KPIs:             types.AgentKPIs{Occupancy: 50},
+		})
+	}
+	return &types.Snapshot{
+		Departments: map[types.Department]*types.DepartmentData{
+			types.DeptSales: {
+				Agents: agents,
+				Queues: []types.VQSnapshot{{VQ: types.VQSalesInbound, WaitingCount: waiting}},
+			},
+		},
+	}
+}
+
+func TestTrendTrackerAveragesAndDeltas(t *testing.T) {
+	tracker := newTrendTracker(5 * time.Minute)
+	start := time.Now()
+
+	tracker.update(trendSnapshot(10, 0), start)
+	tracker.update(trendSnapshot(20, 4), start.Add(1*time.Minute))
+	trends := tracker.update(trendSnapshot(30, 8), start.Add(2*time.Minute))
+
+	sales := trends.Departments[types.DeptSales]
+	if sales.AvailableAgents.Current != 30 {
+		t.Errorf("expected current 30 available, got %.1f", sales.AvailableAgents.Current)
+	}
+	if sales.AvailableAgents.Average != 20 {
+		t.Errorf("expected average 20 available, got %.1f", sales.AvailableAgents.Average)
+	}
+	if sales.AvailableAgents.Delta != 20 {
+		t.Errorf("expected delta +20 available, got %.1f", sales.AvailableAgents.Delta)
+	}
+	if trends.Global.WaitingCalls.Average != 4 {
+		t.Errorf("expected global waiting average 4, got %.1f", trends.Global.WaitingCalls.Average)
+	}
+	if trends.WindowSecs != 300 {
+		t.Errorf("expected 300s window, got %d", trends.WindowSecs)
+	}
+}
+
+func TestTrendTrackerDropsSamplesOutsideWindow(t *testing.T) {
+	tracker := newTrendTracker(5 * time.Minute)
+	start := time.Now()
+
+	tracker.update(trendSnapshot(100, 0), start)
+	trends := tracker.update(trendSnapshot(10, 0), start.Add(6*time.Minute))
+
+	sales := trends.Departments[types.DeptSales]
+	if sales.AvailableAgents.Average != 10 {
+		t.Errorf("expected old sample to be dropped (average 10), got %.1f", sales.AvailableAgents.Average)
+	}
+	if sales.AvailableAgents.Delta != 0 {
+		t.Errorf("expected delta 0 with a single sample, got %.1f", sales.AvailableAgents.Delta)
+	}
+}
+
+func TestSnapshotTrendsQueueOnly(t *testing.T) {
+	tracker := newTrendTracker(5 * time.Minute)
+	full := tracker.update(trendSnapshot(5, 2), time.Now())
+	trends := full.QueueOnly(map[types.Department]bool{types.DeptSales: true})
+
+	sales := trends.Departments[types.DeptSales]
+	if sales.AvailableAgents != nil || sales.Occupancy != nil {
+		t.Error("expected agent-derived trends to be removed")
+	}
+	if sales.WaitingCalls == nil || sales.ServiceLevel == nil {
+		t.Error("expected queue-derived trends to be kept")
+	}
+
+	if trends.Global.WaitingCalls == nil {
+		t.Error("expected global trends to be kept when all departments are visible")
+	}
+
+	hidden := full.QueueOnly(map[types.Department]bool{})
+	if len(hidden.Departments) != 0 || hidden.Global.WaitingCalls != nil {
+		t.Error("expected queue trends of invisible departments to be removed")
+	}
+}
@@ -0,0 +1,288 @@
+package alerts
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/rollup"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// Engine evaluates a configurable rule set against each snapshot
+type Engine struct {
+	mu    sync.RWMutex
+	rules map[string]Rule
+
+	queueMu     sync.Mutex
+	queueStates map[string]*queueState // by rule ID + VQ
+}
+
+// NewEngine creates an engine with the given rules (nil uses DefaultRules)
+func NewEngine(rules []Rule) *Engine {
+	if rules == nil {
+		rules = DefaultRules()
+	}
+	e := &Engine{
+		rules:       make(map[string]Rule, len(rules)),
+		queueStates: make(map[string]*queueState),
+	}
+	for _, r := range rules {
+		e.rules[r.ID] = r
+	}
+	return e
+}
+
+// Rules returns all rules sorted by ID
+func (e *Engine) Rules() []Rule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	rules := make([]Rule, 0, len(e.rules))
+	for _, r := range e.rules {
+		rules = append(rules, r)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules
+}
+
+// GetRule returns a rule by ID
+func (e *Engine) GetRule(id string) (Rule, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	r, ok := e.rules[id]
+	return r, ok
+}
+
+// PutRule creates or replaces a rule
+func (e *Engine) PutRule(r Rule) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	e.mu.Lock()
+	e.rules[r.ID] = r
+	e.mu.Unlock()
+	return nil
+}
+
+// DeleteRule removes a rule, reporting whether it existed
+func (e *Engine) DeleteRule(id string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.rules[id]; !ok {
+		return false
+	}
+	delete(e.rules, id)
+	return true
+}
+
+// Evaluate runs all enabled rules against the snapshot, replacing each agent's
+// Alerts and the snapshot's team/department/queue alerts in place. Department, team and
+// queue alerts are raised per tenant and carry it.
+func (e *Engine) Evaluate(snapshot *types.Snapshot, now time.Time) {
+	rules := e.Rules()
+
+	e.queueMu.Lock()
+	defer e.queueMu.Unlock()
+	seenQueues := make(map[string]bool, len(e.queueStates))
+
+	snapshot.Alerts = nil
+	for _, dept := range sortedDepartments(snapshot.Departments) {
+		data := snapshot.Departments[dept]
+		for i := range data.Agents {
+			data.Agents[i].Alerts = nil
+			for _, r := range rules {
+				if !r.Enabled {
+					continue
+				}
+				if alert, ok := evaluateAgent(r, &data.Agents[i], now); ok {
+					data.Agents[i].Alerts = append(data.Agents[i].Alerts, alert)
+				}
+			}
+		}
+
+		for _, r := range rules {
+			if !r.Enabled {
+				continue
+			}
+			switch r.Type {
+			case RuleSLBreach:
+				for _, tenant := range types.AllTenants() {
+					if alert, ok := evaluateSL(r, data, dept, tenant); ok {
+						snapshot.Alerts = append(snapshot.Alerts, alert)
+					}
+				}
+			case RuleTeamBreak:
+				snapshot.Alerts = append(snapshot.Alerts, evaluateTeamBreak(r, dept, data.Agents)...)
+			case RuleQueueSL, RuleQueueWait, RuleQueueAbandon:
+				for _, q := range data.Queues {
+					if alert, ok := e.evaluateQueue(r, q, now, seenQueues); ok {
+						snapshot.Alerts = append(snapshot.Alerts, alert)
+					}
+				}
+			}
+		}
+	}
+
+	// Drop state for queues or rules that are gone
+	for key := range e.queueStates {
+		if !seenQueues[key] {
+			delete(e.queueStates, key)
+		}
+	}
+}
+
+// evaluateAgent checks a single agent-scoped rule
+func evaluateAgent(r Rule, agent *types.AgentInfo, now time.Time) (types.AgentAlert, bool) {
+	alert := types.AgentAlert{Rule: r.ID, Severity: r.Severity}
+
+	switch r.Type {
+	case RuleStateDuration:
+		if agent.State != r.State {
+			return alert, false
+		}
+		start := agent.StateStart
+		switch {
+		case r.State == types.StateAfterCallWork && agent.ACWStartTime != nil:
+			start = *agent.ACWStartTime
+		case r.State == types.StateBreak && agent.BreakStartTime != nil:
+			start = *agent.BreakStartTime
+		}
+		dur := now.Sub(start)
+		if dur.Seconds() <= r.Threshold {
+			return alert, false
+		}
+		alert.Message = fmt.Sprintf("%s for %s", stateLabel(r.State), formatDuration(dur))
+		return alert, true
+
+	case RuleOccupancyLow:
+		if agent.State == types.StateOffline || agent.KPIs.LoginTime < r.MinLoginSecs {
+			return alert, false
+		}
+		if agent.KPIs.Occupancy >= r.Threshold {
+			return alert, false
+		}
+		alert.Message = fmt.Sprintf("Occupancy %.0f%%", agent.KPIs.Occupancy)
+		return alert, true
+
+	case RuleHoldTime:
+		if agent.KPIs.TotalCalls == 0 {
+			return alert, false
+		}
+		avg := agent.KPIs.HoldTime / float64(agent.KPIs.TotalCalls)
+		if avg <= r.Threshold {
+			return alert, false
+		}
+		alert.Message = fmt.Sprintf("Avg hold %s per call", formatDuration(time.Duration(avg*float64(time.Second))))
+		return alert, true
+	}
+	return alert, false
+}
+
+// evaluateSL checks the combined service level of a tenant's queues in a department
+func evaluateSL(r Rule, data *types.DepartmentData, dept types.Department, tenant string) (types.Alert, bool) {
+	own := &types.DepartmentData{}
+	for _, q := range data.Queues {
+		if types.TenantOf(q.Tenant) == tenant {
+			own.Queues = append(own.Queues, q)
+		}
+	}
+	_, sl := rollup.QueueTotals(map[types.Department]*types.DepartmentData{dept: own}, []types.Department{dept})
+	if sl >= r.Threshold {
+		return types.Alert{}, false
+	}
+	return types.Alert{
+		Rule:       r.ID,
+		Severity:   r.Severity,
+		Message:    fmt.Sprintf("SL %.1f%% below %.0f%%", sl, r.Threshold),
+		Scope:      types.AlertScopeDepartment,
+		Target:     string(dept),
+		Department: dept,
+		Tenant:     tenantField(tenant),
+	}, true
+}
+
+// teamKey identifies a team within its tenant
+type teamKey struct {
+	tenant string
+	team   string
+}
+
+// evaluateTeamBreak counts agents on break or lunch per tenant and team
+func evaluateTeamBreak(r Rule, dept types.Department, agents []types.AgentInfo) []types.Alert {
+	counts := make(map[teamKey]int)
+	for _, agent := range agents {
+		if agent.Team != "" && (agent.State == types.StateBreak || agent.State == types.StateLunch) {
+			counts[teamKey{types.TenantOf(agent.Tenant), agent.Team}]++
+		}
+	}
+
+	teams := make([]teamKey, 0, len(counts))
+	for key, n := range counts {
+		if float64(n) > r.Threshold {
+			teams = append(teams, key)
+		}
+	}
+	sort.Slice(teams, func(i, j int) bool {
+		if teams[i].tenant != teams[j].tenant {
+			return teams[i].tenant < teams[j].tenant
+		}
+		return teams[i].team < teams[j].team
+	})
+
+	out := make([]types.Alert, 0, len(teams))
+	for _, key := range teams {
+		out = append(out, types.Alert{
+			Rule:       r.ID,
+			Severity:   r.Severity,
+			Message:    fmt.Sprintf("%d agents on break", counts[key]),
+			Scope:      types.AlertScopeTeam,
+			Target:     key.team,
+			Department: dept,
+			Tenant:     tenantField(key.tenant),
+		})
+	}
+	return out
+}
+
+// tenantField returns tenant as alerts carry it, empty for DefaultTenant
+func tenantField(tenant string) string {
+	if tenant == types.DefaultTenant {
+		return ""
+	}
+	return tenant
+}
+
+func stateLabel(state types.AgentState) string {
+	switch state {
+	case types.StateAfterCallWork:
+		return "ACW"
+	case types.StateBreak:
+		return "Break"
+	case types.StateLunch:
+		return "Lunch"
+	case types.StateOnHold:
+		return "Hold"
+	}
+	return string(state)
+}
+
+func sortedDepartments(departments map[types.Department]*types.DepartmentData) []types.Department {
+	depts := make([]types.Department, 0, len(departments))
+	for dept := range departments {
+		depts = append(depts, dept)
+	}
+	sort.Slice(depts, func(i, j int) bool { return depts[i] < depts[j] })
+	return depts
+}
+
+func formatDuration(d time.Duration) string {
+	mins := int(d.Minutes())
+	secs := int(d.Seconds()) % 60
+	if mins >= 60 {
+		hours := mins / 60
+		mins = mins % 60
+		return fmt.Sprintf("%dh%dm", hours, mins)
+	}
+	return fmt.Sprintf("%dm%ds", mins, secs)
+}
@@ -0,0 +1,256 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+func snapshotWith(agents []types.AgentInfo, queues []types.VQSnapshot) *types.Snapshot {
+	return &types.Snapshot{
+		Departments: map[types.Department]*types.DepartmentData{
+			types.DeptSales: {Agents: agents, Queues: queues},
+		},
+	}
+}
+
+func TestDefaultRulesMatchLegacyChecks(t *testing.T) {
+	now := time.Now()
+	acwStart := now.Add(-6 * time.Minute)
+	snap := snapshotWith([]types.AgentInfo{
+		{AgentID: "a1", State: types.StateAfterCallWork, StateStart: now, ACWStartTime: &acwStart},
+		{AgentID: "a2", State: types.StateBreak, StateStart: now.Add(-11 * time.Minute)},
+		{AgentID: "a3", State: types.StateBreak, StateStart: now.Add(-9 * time.Minute)},
+	}, nil)
+
+	NewEngine(nil).Evaluate(snap, now)
+
+	agents := snap.Departments[types.DeptSales].Agents
+	if len(agents[0].Alerts) != 1 || agents[0].Alerts[0].Rule != "acw_long" || agents[0].Alerts[0].Severity != types.SeverityWarning {
+		t.Errorf("expected acw_long warning, got %+v", agents[0].Alerts)
+	}
+	if len(agents[1].Alerts) != 1 || agents[1].Alerts[0].Rule != "break_long" || agents[1].Alerts[0].Severity != types.SeverityCritical {
+		t.Errorf("expected break_long critical, got %+v", agents[1].Alerts)
+	}
+	if len(agents[2].Alerts) != 0 {
+		t.Errorf("expected no alerts under threshold, got %+v", agents[2].Alerts)
+	}
+}
+
+func TestAgentRuleTypes(t *testing.T) {
+	now := time.Now()
+	engine := NewEngine([]Rule{
+		{ID: "occ", Type: RuleOccupancyLow, Severity: types.SeverityWarning, Threshold: 50, MinLoginSecs: 600, Enabled: true},
+		{ID: "hold", Type: RuleHoldTime, Severity: types.SeverityWarning, Threshold: 60, Enabled: true},
+	})
+	snap := snapshotWith([]types.AgentInfo{
+		{AgentID: "low", State: types.StateAvailable, KPIs: types.AgentKPIs{Occupancy: 30, LoginTime: 900}},
+		{AgentID: "fresh", State: types.StateAvailable, KPIs: types.AgentKPIs{Occupancy: 0, LoginTime: 60}},
+		{AgentID: "holder", State: types.StateOnCall, KPIs: types.AgentKPIs{Occupancy: 90, LoginTime: 900, TotalCalls: 2, HoldTime: 200}},
+	}, nil)
+
+	engine.Evaluate(snap, now)
+
+	agents := snap.Departments[types.DeptSales].Agents
+	if len(agents[0].Alerts) != 1 || agents[0].Alerts[0].Rule != "occ" {
+		t.Errorf("expected occupancy alert, got %+v", agents[0].Alerts)
+	}
+	if len(agents[1].Alerts) != 0 {
+		t.Errorf("expected freshly logged-in agent to be skipped, got %+v", agents[1].Alerts)
+	}
+	if len(agents[2].Alerts) != 1 || agents[2].Alerts[0].Rule != "hold" {
+		t.Errorf("expected hold alert, got %+v", agents[2].Alerts)
+	}
+}
+
+func TestSnapshotRuleTypes(t *testing.T) {
+	engine := NewEngine([]Rule{
+		{ID: "sl", Type: RuleSLBreach, Severity: types.SeverityCritical, Threshold: 80, Enabled: true},
+		{ID: "breaks", Type: RuleTeamBreak, Severity: types.SeverityWarning, Threshold: 1, Enabled: true},
+	})
+	snap := snapshotWith([]types.AgentInfo{
+		{AgentID: "a1", Team: "Team A", State: types.StateBreak},
+		{AgentID: "a2", Team: "Team A", State: types.StateLunch},
+		{AgentID: "a3", Team: "Team B", State: types.StateBreak},
+	}, []types.VQSnapshot{
+		{VQ: "sales_inbound", ServiceLevel: types.ServiceLevel{AnsweredInSL: 6, TotalAnswered: 10}},
+	})
+
+	engine.Evaluate(snap, time.Now())
+
+	if len(snap.Alerts) != 2 {
+		t.Fatalf("expected 2 alerts, got %+v", snap.Alerts)
+	}
+	// Rules run in ID order: "breaks" before "sl"
+	if snap.Alerts[0].Scope != types.AlertScopeTeam || snap.Alerts[0].Target != "Team A" {
+		t.Errorf("expected Team A break alert, got %+v", snap.Alerts[0])
+	}
+	if snap.Alerts[1].Scope != types.AlertScopeDepartment || snap.Alerts[1].Target != string(types.DeptSales) {
+		t.Errorf("expected department SL alert, got %+v", snap.Alerts[1])
+	}
+}
+
+func TestSnapshotRulesPerTenant(t *testing.T) {
+	if err := types.SetTenants([]string{"acme"}); err != nil {
+		t.Fatal(err)
+	}
+	defer types.SetTenants(nil)
+
+	engine := NewEngine([]Rule{
+		{ID: "breaks", Type: RuleTeamBreak, Severity: types.SeverityWarning, Threshold: 1, Enabled: true},
+		{ID: "sl", Type: RuleSLBreach, Severity: types.SeverityCritical, Threshold: 80, Enabled: true},
+		{ID: "wait", Type: RuleQueueWait, Severity: types.SeverityWarning, Threshold: 120, Enabled: true},
+	})
+	snap := snapshotWith([]types.AgentInfo{
+		{AgentID: "a1", Team: "Team A", State: types.StateBreak},
+		{AgentID: "a2", Tenant: "acme", Team: "Team A", State: types.StateBreak},
+		{AgentID: "a3", Tenant: "acme", Team: "Team A", State: types.StateLunch},
+	}, []types.VQSnapshot{
+		{VQ: types.VQSalesInbound, Department: types.DeptSales, ServiceLevel: types.ServiceLevel{AnsweredInSL: 9, TotalAnswered: 10}},
+		{VQ: types.VQSalesInbound, Department: types.DeptSales, Tenant: "acme", LongestWaitSecs: 200,
+			ServiceLevel: types.ServiceLevel{AnsweredInSL: 5, TotalAnswered: 10}},
+	})
+
+	engine.Evaluate(snap, time.Now())
+
+	if len(snap.Alerts) != 3 {
+		t.Fatalf("expected 3 alerts, got %+v", snap.Alerts)
+	}
+	for _, alert := range snap.Alerts {
+		if alert.Tenant != "acme" {
+			t.Errorf("expected only acme to breach, got %+v", alert)
+		}
+	}
+}
+
+func TestDisabledRulesAndCRUD(t *testing.T) {
+	engine := NewEngine(nil)
+	if err := engine.PutRule(Rule{ID: "bad", Type: "nope", Severity: types.SeverityWarning}); err == nil {
+		t.Error("expected unknown rule type to be rejected")
+	}
+
+	rule, ok := engine.GetRule("break_long")
+	if !ok {
+		t.Fatal("expected default break_long rule")
+	}
+	rule.Enabled = false
+	if err := engine.PutRule(rule); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now := time.Now()
+	snap := snapshotWith([]types.AgentInfo{
+		{AgentID: "a1", State: types.StateBreak, StateStart: now.Add(-time.Hour)},
+	}, nil)
+	engine.Evaluate(snap, now)
+	if len(snap.Departments[types.DeptSales].Agents[0].Alerts) != 0 {
+		t.Error("expected disabled rule not to fire")
+	}
+
+	if !engine.DeleteRule("break_long") || engine.DeleteRule("break_long") {
+		t.Error("expected delete to succeed once")
+	}
+}
+
+func TestQueueRuleTypes(t *testing.T) {
+	engine := NewEngine([]Rule{
+		{ID: "sl", Type: RuleQueueSL, Severity: types.SeverityCritical, ForSecs: 60, Enabled: true},
+		{ID: "wait", Type: RuleQueueWait, Severity: types.SeverityWarning, Threshold: 120, Enabled: true},
+		{ID: "abandon", Type: RuleQueueAbandon, Severity: types.SeverityWarning, Threshold: 20, ForSecs: 300, MinCalls: 5, Enabled: true},
+	})
+	queue := func(sl float64, wait float64, completed, abandoned int) []types.VQSnapshot {
+		return []types.VQSnapshot{{
+			VQ:              types.VQSalesInbound,
+			Department:      types.DeptSales,
+			CompletedCount:  completed,
+			AbandonedCount:  abandoned,
+			LongestWaitSecs: wait,
+			ServiceLevel:    types.ServiceLevel{Target: 80, TotalAnswered: 10, CurrentSL: sl},
+		}}
+	}
+	rulesFired := func(snap *types.Snapshot) map[string]bool {
+		fired := make(map[string]bool)
+		for _, a := range snap.Alerts {
+			if a.Scope != types.AlertScopeQueue || a.Target != string(types.VQSalesInbound) {
+				t.Errorf("unexpected alert target: %+v", a)
+			}
+			fired[a.Rule] = true
+		}
+		return fired
+	}
+
+	now := time.Now()
+	snap := snapshotWith(nil, queue(70, 30, 100, 0))
+	engine.Evaluate(snap, now)
+	if fired := rulesFired(snap); len(fired) != 0 {
+		t.Errorf("expected SL breach to need sustaining, got %v", fired)
+	}
+
+	snap = snapshotWith(nil, queue(70, 200, 105, 5))
+	engine.Evaluate(snap, now.Add(2*time.Minute))
+	fired := rulesFired(snap)
+	if !fired["sl"] || !fired["wait"] || !fired["abandon"] {
+		t.Errorf("expected sl, wait and abandon alerts, got %v", fired)
+	}
+
+	// SL recovers: breach timer resets
+	snap = snapshotWith(nil, queue(85, 30, 200, 5))
+	engine.Evaluate(snap, now.Add(3*time.Minute))
+	snap = snapshotWith(nil, queue(70, 30, 200, 5))
+	engine.Evaluate(snap, now.Add(3*time.Minute+time.Second))
+	if fired := rulesFired(snap); fired["sl"] {
+		t.Error("expected SL breach timer to restart after recovery")
+	}
+}
+
+func TestStateDurationFromTrackedStartTimes(t *testing.T) {
+	tracker := cache.NewAgentStateTracker()
+	for _, id := range []string{"acw", "break", "skewed"} {
+		tracker.RegisterAgent(&types.AgentRegister{AgentID: id, Department: types.DeptSales, State: types.StateOnCall})
+	}
+	time.Sleep(10 * time.Millisecond)
+	sent := time.Now().Add(-5 * time.Millisecond)
+
+	// The sender entered ACW/break before the backend applied it; a timestamp ahead of the
+	// backend's clock falls back to the receive time
+	tracker.UpdateFromStateChange(&types.AgentStateChange{AgentID: "acw", NewState: types.StateAfterCallWork, Timestamp: sent})
+	tracker.UpdateFromStateChange(&types.AgentStateChange{AgentID: "break", NewState: types.StateBreak, Timestamp: sent})
+	tracker.UpdateFromStateChange(&types.AgentStateChange{AgentID: "skewed", NewState: types.StateBreak, Timestamp: sent.Add(time.Hour)})
+
+	agents := make(map[string]types.AgentInfo)
+	for _, a := range tracker.GetAll() {
+		agents[a.AgentID] = a
+	}
+	if a := agents["acw"]; a.ACWStartTime == nil || !a.ACWStartTime.Equal(sent) || a.BreakStartTime != nil {
+		t.Fatalf("expected ACW start at the sender timestamp, got %v / %v", a.ACWStartTime, a.BreakStartTime)
+	}
+	if a := agents["break"]; a.BreakStartTime == nil || a.ACWStartTime != nil {
+		t.Fatalf("expected only a break start, got %v / %v", a.ACWStartTime, a.BreakStartTime)
+	}
+	if a := agents["skewed"]; a.BreakStartTime == nil || a.BreakStartTime.After(time.Now()) {
+		t.Fatalf("expected a future sender timestamp to be ignored, got %v", a.BreakStartTime)
+	}
+
+	// Thresholds count from the tracked start: 6 minutes later ACW is long, the break is not
+	later := sent.Add(6 * time.Minute)
+	snap := snapshotWith([]types.AgentInfo{agents["acw"], agents["break"]}, nil)
+	NewEngine(nil).Evaluate(snap, later)
+	evaluated := snap.Departments[types.DeptSales].Agents
+	if len(evaluated[0].Alerts) != 1 || evaluated[0].Alerts[0].Rule != "acw_long" {
+		t.Errorf("expected acw_long, got %+v", evaluated[0].Alerts)
+	}
+	if len(evaluated[1].Alerts) != 0 {
+		t.Errorf("expected no break alert after 6 minutes, got %+v", evaluated[1].Alerts)
+	}
+
+	// Leaving the state clears its start time
+	tracker.UpdateFromStateChange(&types.AgentStateChange{AgentID: "acw", NewState: types.StateAvailable})
+	tracker.SetDisconnected("break")
+	for _, a := range tracker.GetAll() {
+		if (a.AgentID == "acw" || a.AgentID == "break") && (a.ACWStartTime != nil || a.BreakStartTime != nil) {
+			t.Errorf("%s: expected start times cleared, got %v / %v", a.AgentID, a.ACWStartTime, a.BreakStartTime)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package alerts
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// maxResolvedHistory bounds the in-memory list of recently resolved alerts
+const maxResolvedHistory = 500
+
+// ErrAlertNotFound is returned when acknowledging an alert that is not active
+var ErrAlertNotFound = errors.New("alert not found")
+
+// RecordSaver persists alert records (implemented by storage.Store)
+type RecordSaver interface {
+	SaveAlert(record types.AlertRecord) error
+}
+
+// Tracker turns the alerts evaluated each cycle into stateful records:
+// new conditions fire, acknowledged alerts stay acknowledged while the
+// condition holds, and cleared conditions resolve.
+type Tracker struct {
+	mu       sync.RWMutex
+	active   map[string]*types.AlertRecord // by condition key
+	byID     map[string]string             // alert ID -> condition key
+	resolved []types.AlertRecord           // most recent last
+	store    RecordSaver
+	logger   zerolog.Logger
+}
+
+// NewTracker creates a tracker; store may be nil to disable persistence
+func NewTracker(store RecordSaver, logger zerolog.Logger) *Tracker {
+	return &Tracker{
+		active: make(map[string]*types.AlertRecord),
+		byID:   make(map[string]string),
+		store:  store,
+		logger: logger.With().Str("component", "alert_tracker").Logger(),
+	}
+}
+
+// Update reconciles the snapshot's evaluated alerts with the active set and
+// stamps each alert in the snapshot with its lifecycle ID and status.
+func (t *Tracker) Update(snapshot *types.Snapshot, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	seen := make(map[string]bool, len(t.active))
+
+	for dept, data := range snapshot.Departments {
+		for i := range data.Agents {
+			agent := &data.Agents[i]
+			for j := range agent.Alerts {
+				alert := &agent.Alerts[j]
+				rec := t.observe(seen, types.AlertRecord{
+					Rule:       alert.Rule,
+					Severity:   alert.Severity,
+					Message:    alert.Message,
+					Scope:      types.AlertScopeAgent,
+					Target:     agent.AgentID,
+					Department: dept,
+					Tenant:     agent.Tenant,
+					Location:   agent.Location,
+					Team:       agent.Team,
+				}, now)
+				alert.ID, alert.Status = rec.AlertID, rec.Status
+			}
+		}
+	}
+
+	for i := range snapshot.Alerts {
+		alert := &snapshot.Alerts[i]
+		rec := t.observe(seen, types.AlertRecord{
+			Rule:       alert.Rule,
+			Severity:   alert.Severity,
+			Message:    alert.Message,
+			Scope:      alert.Scope,
+			Target:     alert.Target,
+			Department: alert.Department,
+			Tenant:     alert.Tenant,
+		}, now)
+		alert.ID, alert.Status = rec.AlertID, rec.Status
+	}
+
+	for key, rec := range t.active {
+		if seen[key] {
+			continue
+		}
+		resolvedAt := now
+		rec.Status = types.AlertResolved
+		rec.ResolvedAt = &resolvedAt
+		rec.Duration = now.Sub(rec.FiredAt).Seconds()
+		delete(t.active, key)
+		delete(t.byID, rec.AlertID)
+
+		t.resolved = append(t.resolved, *rec)
+		if len(t.resolved) > maxResolvedHistory {
+			t.resolved = t.resolved[len(t.resolved)-maxResolvedHistory:]
+		}
+		t.persist(*rec)
+	}
+}
+
+// observe returns the active record for a condition, firing a new one if needed (caller holds mu)
+func (t *Tracker) observe(seen map[string]bool, cond types.AlertRecord, now time.Time) *types.AlertRecord {
+	key := conditionKey(cond)
+	seen[key] = true
+
+	if rec, ok := t.active[key]; ok {
+		rec.Severity = cond.Severity
+		rec.Message = cond.Message
+		return rec
+	}
+
+	rec := cond
+	rec.AlertID = uuid.New().String()
+	rec.DateKey = types.DateOf(now)
+	rec.Status = types.AlertFiring
+	rec.FiredAt = now
+	t.active[key] = &rec
+	t.byID[rec.AlertID] = key
+	t.persist(rec)
+	return &rec
+}
+
+// Acknowledge marks an active alert as acknowledged by user
+func (t *Tracker) Acknowledge(id, user string, now time.Time) (types.AlertRecord, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key, ok := t.byID[id]
+	if !ok {
+		return types.AlertRecord{}, ErrAlertNotFound
+	}
+	rec := t.active[key]
+	if rec.Status != types.AlertAcknowledged {
+		ackAt := now
+		rec.Status = types.AlertAcknowledged
+		rec.AcknowledgedAt = &ackAt
+		rec.AcknowledgedBy = user
+		t.persist(*rec)
+	}
+	return *rec, nil
+}
+
+// Get returns an active alert by ID
+func (t *Tracker) Get(id string) (types.AlertRecord, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	key, ok := t.byID[id]
+	if !ok {
+		return types.AlertRecord{}, false
+	}
+	return *t.active[key], true
+}
+
+// Active returns all firing and acknowledged alerts, oldest first
+func (t *Tracker) Active() []types.AlertRecord {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	out := make([]types.AlertRecord, 0, len(t.active))
+	for _, rec := range t.active {
+		out = append(out, *rec)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].FiredAt.Equal(out[j].FiredAt) {
+			return out[i].FiredAt.Before(out[j].FiredAt)
+		}
+		return out[i].AlertID < out[j].AlertID
+	})
+	return out
+}
+
+// Resolved returns recently resolved alerts, most recent first
+func (t *Tracker) Resolved() []types.AlertRecord {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	out := make([]types.AlertRecord, len(t.resolved))
+	for i, rec := range t.resolved {
+		out[len(out)-1-i] = rec
+	}
+	return out
+}
+
+// AgentHistory assembles an agent's alerts of date from the persisted records, keeping those
+// include accepts (nil = all). The tracker's live and recently resolved alerts take precedence,
+// so the current day is complete even while the store is still writing.
+func (t *Tracker) AgentHistory(agentID, date string, persisted []types.AlertRecord, include func(types.AlertRecord) bool, now time.Time) types.AgentAlertHistory {
+	byID := make(map[string]types.AlertRecord)
+	add := func(records []types.AlertRecord) {
+		for _, rec := range records {
+			if rec.Scope != types.AlertScopeAgent || rec.Target != agentID || rec.DateKey != date {
+				continue
+			}
+			if include == nil || include(rec) {
+				byID[rec.AlertID] = rec
+			}
+		}
+	}
+	add(persisted)
+	add(t.Resolved())
+	add(t.Active())
+
+	history := types.AgentAlertHistory{AgentID: agentID, Date: date, Alerts: make([]types.AlertRecord, 0, len(byID))}
+	for _, rec := range byID {
+		history.Alerts = append(history.Alerts, rec)
+	}
+	sort.Slice(history.Alerts, func(i, j int) bool {
+		a, b := history.Alerts[i], history.Alerts[j]
+		if !a.FiredAt.Equal(b.FiredAt) {
+			return a.FiredAt.Before(b.FiredAt)
+		}
+		return a.AlertID < b.AlertID
+	})
+
+	rules := make(map[string]*types.AlertRuleSummary)
+	for _, rec := range history.Alerts {
+		sum, ok := rules[rec.Rule]
+		if !ok {
+			sum = &types.AlertRuleSummary{Rule: rec.Rule}
+			rules[rec.Rule] = sum
+		}
+		end := now
+		if rec.ResolvedAt != nil {
+			end = *rec.ResolvedAt
+		}
+		secs := end.Sub(rec.FiredAt).Seconds()
+		sum.Count++
+		if rec.Severity == types.SeverityCritical {
+			sum.Critical++
+		}
+		sum.TotalSeconds += secs
+		if secs > sum.LongestSeconds {
+			sum.LongestSeconds = secs
+		}
+	}
+	history.Rules = make([]types.AlertRuleSummary, 0, len(rules))
+	for _, sum := range rules {
+		history.Rules = append(history.Rules, *sum)
+	}
+	sort.Slice(history.Rules, func(i, j int) bool { return history.Rules[i].Rule < history.Rules[j].Rule })
+	return history
+}
+
+// persist saves a record copy; the store writes in the background (caller holds mu)
+func (t *Tracker) persist(rec types.AlertRecord) {
+	if t.store == nil {
+		return
+	}
+	if err := t.store.SaveAlert(rec); err != nil {
+		t.logger.Error().Err(err).Str("alert_id", rec.AlertID).Msg("failed to save alert")
+	}
+}
+
+func conditionKey(rec types.AlertRecord) string {
+	return rec.Rule + "|" + types.TenantOf(rec.Tenant) + "|" + string(rec.Scope) + "|" + string(rec.Department) + "|" + rec.Target
+}
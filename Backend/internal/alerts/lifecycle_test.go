@@ -0,0 +1,160 @@
+package alerts
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+type recordingSaver struct {
+	mu      sync.Mutex
+	records []types.AlertRecord
+}
+
+func (s *recordingSaver) SaveAlert(record types.AlertRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+	return nil
+}
+
+func breakSnapshot(now time.Time, onBreak bool) *types.Snapshot {
+	agent := types.AgentInfo{AgentID: "a1", Location: types.LocationBerlin, State: types.StateAvailable, StateStart: now}
+	if onBreak {
+		agent.State = types.StateBreak
+		agent.StateStart = now.Add(-time.Hour)
+	}
+	return snapshotWith([]types.AgentInfo{agent}, nil)
+}
+
+func TestTrackerLifecycle(t *testing.T) {
+	engine := NewEngine(nil)
+	tracker := NewTracker(nil, zerolog.Nop())
+	now := time.Now()
+
+	snap := breakSnapshot(now, true)
+	engine.Evaluate(snap, now)
+	tracker.Update(snap, now)
+
+	alert := snap.Departments[types.DeptSales].Agents[0].Alerts[0]
+	if alert.ID == "" || alert.Status != types.AlertFiring {
+		t.Fatalf("expected firing alert with ID, got %+v", alert)
+	}
+
+	rec, err := tracker.Acknowledge(alert.ID, "sup@example.com", now)
+	if err != nil || rec.Status != types.AlertAcknowledged || rec.AcknowledgedBy != "sup@example.com" {
+		t.Fatalf("unexpected ack result: %+v, %v", rec, err)
+	}
+
+	// Condition still holds: same ID, stays acknowledged
+	snap = breakSnapshot(now, true)
+	engine.Evaluate(snap, now.Add(time.Second))
+	tracker.Update(snap, now.Add(time.Second))
+	again := snap.Departments[types.DeptSales].Agents[0].Alerts[0]
+	if again.ID != alert.ID || again.Status != types.AlertAcknowledged {
+		t.Errorf("expected acknowledged alert to persist, got %+v", again)
+	}
+
+	// Condition clears: alert resolves
+	snap = breakSnapshot(now, false)
+	engine.Evaluate(snap, now.Add(2*time.Second))
+	tracker.Update(snap, now.Add(2*time.Second))
+	if len(tracker.Active()) != 0 {
+		t.Errorf("expected no active alerts, got %+v", tracker.Active())
+	}
+	resolved := tracker.Resolved()
+	if len(resolved) != 1 || resolved[0].AlertID != alert.ID || resolved[0].ResolvedAt == nil {
+		t.Errorf("expected resolved alert in history, got %+v", resolved)
+	}
+
+	if _, err := tracker.Acknowledge(alert.ID, "sup@example.com", now); err != ErrAlertNotFound {
+		t.Errorf("expected ErrAlertNotFound for resolved alert, got %v", err)
+	}
+}
+
+func TestTrackerPersistsTransitions(t *testing.T) {
+	saver := &recordingSaver{}
+	engine := NewEngine(nil)
+	tracker := NewTracker(saver, zerolog.Nop())
+	now := time.Now()
+
+	snap := breakSnapshot(now, true)
+	engine.Evaluate(snap, now)
+	tracker.Update(snap, now)
+	id := snap.Departments[types.DeptSales].Agents[0].Alerts[0].ID
+	tracker.Acknowledge(id, "sup", now)
+
+	snap = breakSnapshot(now, false)
+	engine.Evaluate(snap, now)
+	tracker.Update(snap, now)
+
+	// Saves happen asynchronously
+	deadline := time.Now().Add(time.Second)
+	for {
+		saver.mu.Lock()
+		n := len(saver.records)
+		saver.mu.Unlock()
+		if n == 3 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	saver.mu.Lock()
+	defer saver.mu.Unlock()
+	if len(saver.records) != 3 {
+		t.Fatalf("expected fire, ack and resolve to be saved, got %d records", len(saver.records))
+	}
+	for _, rec := range saver.records {
+		if rec.AlertID != id || rec.DateKey == "" {
+			t.Errorf("unexpected saved record: %+v", rec)
+		}
+	}
+}
+
+func TestAgentHistory(t *testing.T) {
+	engine := NewEngine(nil)
+	tracker := NewTracker(nil, zerolog.Nop())
+	now := time.Now()
+	date := now.UTC().Format("2006-01-02")
+
+	// A critical break alert (an hour on break) fires and resolves ten minutes later
+	snap := breakSnapshot(now, true)
+	engine.Evaluate(snap, now)
+	tracker.Update(snap, now)
+	snap = breakSnapshot(now, false)
+	engine.Evaluate(snap, now.Add(10*time.Minute))
+	tracker.Update(snap, now.Add(10*time.Minute))
+
+	resolved := tracker.Resolved()
+	if len(resolved) != 1 || resolved[0].Duration != 600 {
+		t.Fatalf("expected one resolved alert lasting 600s, got %+v", resolved)
+	}
+
+	// An older occurrence of the same rule only the store still knows, and one of another agent
+	persisted := []types.AlertRecord{
+		{DateKey: date, AlertID: "old", Rule: resolved[0].Rule, Scope: types.AlertScopeAgent, Target: "a1",
+			Severity: types.SeverityCritical, Status: types.AlertResolved, FiredAt: now.Add(-time.Hour),
+			ResolvedAt: timePtr(now.Add(-50 * time.Minute))},
+		{DateKey: date, AlertID: "other", Rule: resolved[0].Rule, Scope: types.AlertScopeAgent, Target: "a2",
+			Status: types.AlertResolved, FiredAt: now},
+	}
+	history := tracker.AgentHistory("a1", date, persisted, nil, now.Add(time.Hour))
+	if len(history.Alerts) != 2 || history.Alerts[0].AlertID != "old" {
+		t.Fatalf("expected a1's two alerts oldest first, got %+v", history.Alerts)
+	}
+	want := types.AlertRuleSummary{Rule: resolved[0].Rule, Count: 2, Critical: 2, TotalSeconds: 1200, LongestSeconds: 600}
+	if len(history.Rules) != 1 || history.Rules[0] != want {
+		t.Errorf("got rules %+v, want %+v", history.Rules, want)
+	}
+
+	hidden := tracker.AgentHistory("a1", date, persisted, func(rec types.AlertRecord) bool { return rec.AlertID != "old" }, now)
+	if len(hidden.Alerts) != 1 || hidden.Rules[0].Count != 1 {
+		t.Errorf("expected include to drop the old alert, got %+v", hidden.Alerts)
+	}
+}
+
+func timePtr(t time.Time) *time.Time { return &t }
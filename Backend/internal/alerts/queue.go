@@ -0,0 +1,99 @@
+package alerts
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// queueSample is a point-in-time reading of a VQ's cumulative call counters
+type queueSample struct {
+	at        time.Time
+	completed int
+	abandoned int
+}
+
+// queueState holds per-rule, per-VQ state for rules that look back in time
+type queueState struct {
+	breachSince time.Time     // queue_sl: start of the current breach (zero if none)
+	samples     []queueSample // queue_abandon: counters within the window
+}
+
+// evaluateQueue checks a single queue-scoped rule (caller holds queueMu)
+func (e *Engine) evaluateQueue(r Rule, q types.VQSnapshot, now time.Time, seen map[string]bool) (types.Alert, bool) {
+	key := r.ID + "|" + types.TenantOf(q.Tenant) + "|" + string(q.VQ)
+	seen[key] = true
+	st, ok := e.queueStates[key]
+	if !ok {
+		st = &queueState{}
+		e.queueStates[key] = st
+	}
+
+	alert := types.Alert{
+		Rule:       r.ID,
+		Severity:   r.Severity,
+		Scope:      types.AlertScopeQueue,
+		Target:     string(q.VQ),
+		Department: q.Department,
+		Tenant:     q.Tenant,
+	}
+
+	switch r.Type {
+	case RuleQueueSL:
+		target := r.Threshold
+		if target == 0 {
+			target = float64(q.ServiceLevel.Target)
+		}
+		// SL is not tracked outside business hours
+		if q.Closed || q.ServiceLevel.TotalAnswered == 0 || q.ServiceLevel.CurrentSL >= target {
+			st.breachSince = time.Time{}
+			return alert, false
+		}
+		if st.breachSince.IsZero() {
+			st.breachSince = now
+		}
+		dur := now.Sub(st.breachSince)
+		if dur.Seconds() < r.ForSecs {
+			return alert, false
+		}
+		alert.Message = fmt.Sprintf("SL %.1f%% below %.0f%% for %s", q.ServiceLevel.CurrentSL, target, formatDuration(dur))
+		return alert, true
+
+	case RuleQueueWait:
+		if q.LongestWaitSecs <= r.Threshold {
+			return alert, false
+		}
+		alert.Message = fmt.Sprintf("Longest wait %s", formatDuration(time.Duration(q.LongestWaitSecs*float64(time.Second))))
+		return alert, true
+
+	case RuleQueueAbandon:
+		// Counters go backwards after a reset; start the window over
+		if n := len(st.samples); n > 0 && (q.CompletedCount < st.samples[n-1].completed || q.AbandonedCount < st.samples[n-1].abandoned) {
+			st.samples = st.samples[:0]
+		}
+		st.samples = append(st.samples, queueSample{at: now, completed: q.CompletedCount, abandoned: q.AbandonedCount})
+		cutoff := now.Add(-time.Duration(r.ForSecs * float64(time.Second)))
+		drop := 0
+		for drop < len(st.samples)-1 && !st.samples[drop+1].at.After(cutoff) {
+			drop++
+		}
+		if drop > 0 {
+			st.samples = append(st.samples[:0], st.samples[drop:]...)
+		}
+
+		oldest := st.samples[0]
+		abandoned := q.AbandonedCount - oldest.abandoned
+		total := abandoned + q.CompletedCount - oldest.completed
+		if total == 0 || total < r.MinCalls {
+			return alert, false
+		}
+		rate := float64(abandoned) / float64(total) * 100
+		if rate <= r.Threshold {
+			return alert, false
+		}
+		alert.Message = fmt.Sprintf("Abandon rate %.0f%% (%d of %d calls)", rate, abandoned, total)
+		return alert, true
+	}
+	return alert, false
+}
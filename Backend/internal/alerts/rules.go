@@ -0,0 +1,111 @@
+package alerts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// RuleType identifies how a rule is evaluated
+type RuleType string
+
+const (
+	RuleStateDuration RuleType = "state_duration" // agent in State for more than Threshold seconds
+	RuleOccupancyLow  RuleType = "occupancy_low"  // agent occupancy below Threshold percent
+	RuleHoldTime      RuleType = "hold_time"      // agent average hold per call above Threshold seconds
+	RuleSLBreach      RuleType = "sl_breach"      // department SL below Threshold percent
+	RuleTeamBreak     RuleType = "team_break"     // more than Threshold agents of a team on break/lunch
+	RuleQueueSL       RuleType = "queue_sl"       // VQ SL below Threshold percent (0 = the VQ's own target) for ForSecs
+	RuleQueueWait     RuleType = "queue_wait"     // VQ longest wait above Threshold seconds
+	RuleQueueAbandon  RuleType = "queue_abandon"  // VQ abandon rate over the last ForSecs above Threshold percent
+)
+
+// Rule is a configurable alert rule. Threshold units depend on the rule type.
+type Rule struct {
+	ID           string              `json:"id"` // also reported as the alert's rule name
+	Type         RuleType            `json:"type"`
+	Severity     types.AlertSeverity `json:"severity"`
+	Threshold    float64             `json:"threshold"`
+	State        types.AgentState    `json:"state,omitempty"`        // state_duration only
+	MinLoginSecs float64             `json:"minLoginSecs,omitempty"` // occupancy_low: skip freshly logged-in agents
+	ForSecs      float64             `json:"forSecs,omitempty"`      // queue_sl: sustain time, queue_abandon: window
+	MinCalls     int                 `json:"minCalls,omitempty"`     // queue_abandon: minimum calls in the window
+	Enabled      bool                `json:"enabled"`
+}
+
+// DefaultRules returns the built-in rules: the ACW, break and queue checks are enabled,
+// the remaining types ship disabled with sensible thresholds.
+func DefaultRules() []Rule {
+	return []Rule{
+		{ID: "acw_long", Type: RuleStateDuration, Severity: types.SeverityWarning, State: types.StateAfterCallWork, Threshold: 300, Enabled: true},
+		{ID: "break_long", Type: RuleStateDuration, Severity: types.SeverityCritical, State: types.StateBreak, Threshold: 600, Enabled: true},
+		{ID: "occupancy_low", Type: RuleOccupancyLow, Severity: types.SeverityWarning, Threshold: 40, MinLoginSecs: 1800},
+		{ID: "hold_long", Type: RuleHoldTime, Severity: types.SeverityWarning, Threshold: 90},
+		{ID: "sl_breach", Type: RuleSLBreach, Severity: types.SeverityCritical, Threshold: 80},
+		{ID: "team_break", Type: RuleTeamBreak, Severity: types.SeverityWarning, Threshold: 3},
+		{ID: "queue_sl", Type: RuleQueueSL, Severity: types.SeverityCritical, ForSecs: 300, Enabled: true},
+		{ID: "queue_wait", Type: RuleQueueWait, Severity: types.SeverityWarning, Threshold: 120, Enabled: true},
+		{ID: "queue_abandon", Type: RuleQueueAbandon, Severity: types.SeverityWarning, Threshold: 10, ForSecs: 300, MinCalls: 10, Enabled: true},
+	}
+}
+
+// Validate checks that a rule is well-formed
+func (r Rule) Validate() error {
+	if r.ID == "" {
+		return fmt.Errorf("rule id is required")
+	}
+	switch r.Severity {
+	case types.SeverityWarning, types.SeverityCritical:
+	default:
+		return fmt.Errorf("invalid severity %q", r.Severity)
+	}
+	if r.Threshold < 0 || r.ForSecs < 0 || r.MinCalls < 0 {
+		return fmt.Errorf("threshold, forSecs and minCalls must not be negative")
+	}
+	switch r.Type {
+	case RuleStateDuration:
+		if r.State == "" {
+			return fmt.Errorf("state is required for %s rules", r.Type)
+		}
+	case RuleOccupancyLow, RuleSLBreach, RuleQueueSL:
+		if r.Threshold > 100 {
+			return fmt.Errorf("threshold is a percentage for %s rules", r.Type)
+		}
+	case RuleQueueAbandon:
+		if r.Threshold > 100 {
+			return fmt.Errorf("threshold is a percentage for %s rules", r.Type)
+		}
+		if r.ForSecs <= 0 {
+			return fmt.Errorf("forSecs is required for %s rules", r.Type)
+		}
+	case RuleHoldTime, RuleTeamBreak, RuleQueueWait:
+	default:
+		return fmt.Errorf("unknown rule type %q", r.Type)
+	}
+	return nil
+}
+
+// LoadRulesFile reads a JSON array of rules from path
+func LoadRulesFile(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alert rules: %w", err)
+	}
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse alert rules: %w", err)
+	}
+	seen := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		if err := r.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid alert rule %q: %w", r.ID, err)
+		}
+		if seen[r.ID] {
+			return nil, fmt.Errorf("duplicate alert rule %q", r.ID)
+		}
+		seen[r.ID] = true
+	}
+	return rules, nil
+}
@@ -0,0 +1,82 @@
+// Package announcements holds shift-wide notices such as system maintenance or weather
+// warnings. The aggregator embeds the active ones in every snapshot; each dashboard only
+// gets those addressed to its departments and locations.
+package announcements
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/google/uuid"
+)
+
+// ErrNotFound is returned when withdrawing an unknown or expired announcement
+var ErrNotFound = errors.New("announcement not found")
+
+// severityRank orders announcements, most severe first
+var severityRank = map[types.AnnouncementSeverity]int{
+	types.AnnouncementCritical: 0,
+	types.AnnouncementWarning:  1,
+	types.AnnouncementInfo:     2,
+}
+
+// Board holds the published announcements in memory
+type Board struct {
+	mu    sync.Mutex
+	items map[string]types.Announcement // by ID
+}
+
+// NewBoard creates an empty board
+func NewBoard() *Board {
+	return &Board{items: make(map[string]types.Announcement)}
+}
+
+// Publish adds an announcement, assigning its ID and creation time (empty severity = info)
+func (b *Board) Publish(a types.Announcement) types.Announcement {
+	a.ID = uuid.New().String()
+	a.CreatedAt = time.Now().UTC()
+	if a.Severity == "" {
+		a.Severity = types.AnnouncementInfo
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.items[a.ID] = a
+	return a
+}
+
+// Withdraw removes an announcement before it expires
+func (b *Board) Withdraw(id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.items[id]; !ok {
+		return ErrNotFound
+	}
+	delete(b.items, id)
+	return nil
+}
+
+// Active returns the announcements not expired at now, most severe and then newest first.
+// Expired announcements are dropped.
+func (b *Board) Active(now time.Time) []types.Announcement {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	out := make([]types.Announcement, 0, len(b.items))
+	for id, a := range b.items {
+		if a.ExpiresAt != nil && !a.ExpiresAt.After(now) {
+			delete(b.items, id)
+			continue
+		}
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if ri, rj := severityRank[out[i].Severity], severityRank[out[j].Severity]; ri != rj {
+			return ri < rj
+		}
+		return out[i].CreatedAt.After(out[j].CreatedAt)
+	})
+	return out
+}
@@ -0,0 +1,42 @@
+package announcements
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+func TestBoardActiveOrderAndExpiry(t *testing.T) {
+	b := NewBoard()
+	now := time.Now()
+	soon := now.Add(time.Minute)
+
+	info := b.Publish(types.Announcement{Text: "info"})
+	critical := b.Publish(types.Announcement{Text: "critical", Severity: types.AnnouncementCritical})
+	expiring := b.Publish(types.Announcement{Text: "expiring", Severity: types.AnnouncementWarning, ExpiresAt: &soon})
+
+	if info.Severity != types.AnnouncementInfo {
+		t.Errorf("expected empty severity to default to info, got %q", info.Severity)
+	}
+
+	active := b.Active(now)
+	if len(active) != 3 || active[0].ID != critical.ID || active[1].ID != expiring.ID || active[2].ID != info.ID {
+		t.Fatalf("expected critical, warning, info order, got %+v", active)
+	}
+
+	active = b.Active(soon)
+	if len(active) != 2 {
+		t.Fatalf("expected the expired announcement to be dropped, got %d", len(active))
+	}
+	if err := b.Withdraw(expiring.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an expired announcement, got %v", err)
+	}
+	if err := b.Withdraw(info.ID); err != nil {
+		t.Errorf("withdraw: %v", err)
+	}
+	if active = b.Active(now); len(active) != 1 || active[0].ID != critical.ID {
+		t.Errorf("expected only the critical announcement left, got %+v", active)
+	}
+}
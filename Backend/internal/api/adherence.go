@@ -0,0 +1,147 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/adherence"
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+)
+
+// AdherenceHandler provides schedule import and adherence endpoints
+type AdherenceHandler struct {
+	service *adherence.Service
+	logger  zerolog.Logger
+}
+
+// NewAdherenceHandler creates a new AdherenceHandler
+func NewAdherenceHandler(service *adherence.Service, logger zerolog.Logger) *AdherenceHandler {
+	return &AdherenceHandler{
+		service: service,
+		logger:  logger.With().Str("component", "adherence_handler").Logger(),
+	}
+}
+
+// PutSchedules handles PUT /api/schedules
+// Body: JSON array of intervals; replaces every agent-day contained in the body
+func (h *AdherenceHandler) PutSchedules(w http.ResponseWriter, r *http.Request) {
+	var intervals []types.ScheduleInterval
+	if err := json.NewDecoder(r.Body).Decode(&intervals); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	h.replace(w, r, intervals)
+}
+
+// ImportSchedulesCSV handles POST /api/schedules/import
+// Body: CSV with header agentId,start,end,activity (RFC3339 times)
+func (h *AdherenceHandler) ImportSchedulesCSV(w http.ResponseWriter, r *http.Request) {
+	intervals, err := adherence.ParseCSV(r.Body)
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.replace(w, r, intervals)
+}
+
+func (h *AdherenceHandler) replace(w http.ResponseWriter, r *http.Request, intervals []types.ScheduleInterval) {
+	days, err := h.service.Schedules().Replace(intervals)
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.logger.Info().
+		Int("intervals", len(intervals)).
+		Int("agent_days", days).
+		Msg("schedules imported")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(types.ScheduleImportResponse{
+		Intervals: len(intervals),
+		AgentDays: days,
+	})
+}
+
+// GetSchedule handles GET /api/schedules/{agentId}?date=YYYY-MM-DD
+func (h *AdherenceHandler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	agentID := chi.URLParam(r, "agentId")
+	date, ok := dateParam(w, r)
+	if !ok || !h.agentVisible(w, r, agentID) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.service.Schedules().ForAgent(agentID, date))
+}
+
+// GetAgentAdherence handles GET /api/adherence/agents/{agentId}?date=YYYY-MM-DD
+func (h *AdherenceHandler) GetAgentAdherence(w http.ResponseWriter, r *http.Request) {
+	agentID := chi.URLParam(r, "agentId")
+	date, ok := dateParam(w, r)
+	if !ok || !h.agentVisible(w, r, agentID) {
+		return
+	}
+
+	result, err := h.service.AgentAdherence(agentID, date, time.Now())
+	if err != nil {
+		h.logger.Error().Err(err).Str("agent_id", agentID).Msg("failed to compute adherence")
+		apierror.Write(w, r, http.StatusInternalServerError, "failed to compute adherence")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetTeamAdherence handles GET /api/adherence/teams/{team}?date=YYYY-MM-DD
+func (h *AdherenceHandler) GetTeamAdherence(w http.ResponseWriter, r *http.Request) {
+	team := chi.URLParam(r, "team")
+	date, ok := dateParam(w, r)
+	if !ok {
+		return
+	}
+
+	claims, _ := auth.GetUserFromContext(r.Context())
+	include := func(agent types.AgentInfo) bool {
+		return claims == nil || claims.IsAgentAllowed(agent.Tenant, agent.Location, agent.Department, agent.Team)
+	}
+
+	result, err := h.service.TeamAdherence(team, date, time.Now(), include)
+	if err != nil {
+		h.logger.Error().Err(err).Str("team", team).Msg("failed to compute team adherence")
+		apierror.Write(w, r, http.StatusInternalServerError, "failed to compute adherence")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// agentVisible rejects agents outside the caller's locations, departments or teams
+func (h *AdherenceHandler) agentVisible(w http.ResponseWriter, r *http.Request, agentID string) bool {
+	claims, _ := auth.GetUserFromContext(r.Context())
+	if agent, ok := h.service.Agent(agentID); ok && claims != nil && !claims.IsAgentAllowed(agent.Tenant, agent.Location, agent.Department, agent.Team) {
+		apierror.Write(w, r, http.StatusNotFound, "agent not found")
+		return false
+	}
+	return true
+}
+
+// dateParam reads ?date=YYYY-MM-DD, defaulting to today in the reporting timezone
+func dateParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		return types.DateOf(time.Now()), true
+	}
+	if _, err := time.Parse(types.DateLayout, date); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "date must be YYYY-MM-DD")
+		return "", false
+	}
+	return date, true
+}
@@ -6,35 +6,35 @@ import (
 	"io"
 	"net/http"
 	"strings"
-	"time"
 
+	"github.com/dennisdiepolder/monti/backend/internal/agentsim"
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
 	"github.com/dennisdiepolder/monti/backend/internal/auth"
 	"github.com/dennisdiepolder/monti/backend/internal/cache"
 	"github.com/dennisdiepolder/monti/backend/internal/callqueue"
 	"github.com/dennisdiepolder/monti/backend/internal/storage"
 	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog"
 )
 
 // AdminHandler proxies admin requests to AgentSim and handles local resets
 type AdminHandler struct {
-	simURL       string
+	sim          *agentsim.Client // nil when there is no AgentSim
 	stateTracker *cache.AgentStateTracker
 	callQueue    *callqueue.CallQueueManager
 	store        storage.Store
 	logger       zerolog.Logger
-	client       *http.Client
 }
 
-// NewAdminHandler creates a new AdminHandler
-func NewAdminHandler(simURL string, stateTracker *cache.AgentStateTracker, callQueue *callqueue.CallQueueManager, store storage.Store, logger zerolog.Logger) *AdminHandler {
+// NewAdminHandler creates a new AdminHandler; sim is nil when there is no AgentSim
+func NewAdminHandler(sim *agentsim.Client, stateTracker *cache.AgentStateTracker, callQueue *callqueue.CallQueueManager, store storage.Store, logger zerolog.Logger) *AdminHandler {
 	return &AdminHandler{
-		simURL:       simURL,
+		sim:          sim,
 		stateTracker: stateTracker,
 		callQueue:    callQueue,
 		store:        store,
 		logger:       logger,
-		client:       &http.Client{Timeout: 10 * time.Second},
 	}
 }
 
@@ -43,8 +43,7 @@ func RequireAdmin(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		claims, ok := auth.GetUserFromContext(r.Context())
 		if !ok || !auth.HasRole(claims, "admin") {
-			w.Header().Set("Content-Type", "application/json")
-			http.Error(w, `{"error":"admin role required"}`, http.StatusForbidden)
+			apierror.Write(w, r, http.StatusForbidden, "admin role required")
 			return
 		}
 		next.ServeHTTP(w, r)
@@ -56,35 +55,62 @@ func RequireManagerOrAdmin(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		claims, ok := auth.GetUserFromContext(r.Context())
 		if !ok || (claims.Role != "admin" && claims.Role != "manager" && claims.Role != "supervisor") {
-			w.Header().Set("Content-Type", "application/json")
-			http.Error(w, `{"error":"manager or admin role required"}`, http.StatusForbidden)
+			apierror.Write(w, r, http.StatusForbidden, "manager or admin role required")
 			return
 		}
 		next.ServeHTTP(w, r)
 	})
 }
 
+// RequireAgentScope middleware — rejects {agentId} routes for agents outside the caller's
+// location/department/team scope, so team leads can only act on their own agents
+func RequireAgentScope(tracker *cache.AgentStateTracker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := auth.GetUserFromContext(r.Context())
+			if !ok || claims.IsUnrestricted() {
+				next.ServeHTTP(w, r)
+				return
+			}
+			agent, found := tracker.Get(chi.URLParam(r, "agentId"))
+			if !found || !claims.IsAgentAllowed(agent.Tenant, agent.Location, agent.Department, agent.Team) {
+				apierror.Write(w, r, http.StatusNotFound, "agent not found")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// UserRateLimitKey keys rate limits by the authenticated user (token subject, else email)
+func UserRateLimitKey(r *http.Request) string {
+	claims, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		return ""
+	}
+	if claims.Subject != "" {
+		return claims.Subject
+	}
+	return claims.Email
+}
+
 // proxyToSim forwards a request to AgentSim and copies the response back
 func (h *AdminHandler) proxyToSim(w http.ResponseWriter, r *http.Request, method, path string) {
-	url := h.simURL + path
+	if h.sim == nil {
+		apierror.WriteCode(w, r, http.StatusServiceUnavailable, types.ErrCodeSimNotConfigured,
+			"simulation backend not configured (set AGENTSIM_URL)")
+		return
+	}
 
 	var body io.Reader
 	if r.Body != nil && (method == http.MethodPost || method == http.MethodPut || method == http.MethodDelete) {
 		body = r.Body
 	}
 
-	req, err := http.NewRequestWithContext(r.Context(), method, url, body)
-	if err != nil {
-		h.logger.Error().Err(err).Str("path", path).Msg("failed to create proxy request")
-		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
-		return
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := h.client.Do(req)
+	resp, err := h.sim.Do(r.Context(), method, path, body)
 	if err != nil {
-		h.logger.Error().Err(err).Str("url", url).Msg("failed to reach AgentSim")
-		http.Error(w, `{"error":"AgentSim unavailable"}`, http.StatusBadGateway)
+		h.logger.Error().Err(err).Str("path", path).Msg("failed to reach AgentSim")
+		apierror.Write(w, r, http.StatusBadGateway, "AgentSim unavailable")
 		return
 	}
 	defer resp.Body.Close()
@@ -126,12 +152,9 @@ func (h *AdminHandler) UpdateCallConfig(w http.ResponseWriter, r *http.Request)
 
 // InjectCalls enqueues calls directly into the local call queue
 func (h *AdminHandler) InjectCalls(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Count int    `json:"count"`
-		VQ    string `json:"vq,omitempty"`
-	}
+	var req types.InjectCallsRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		apierror.Write(w, r, http.StatusBadRequest, "invalid request body")
 		return
 	}
 	if req.Count <= 0 {
@@ -164,10 +187,10 @@ func (h *AdminHandler) InjectCalls(w http.ResponseWriter, r *http.Request) {
 	h.logger.Info().Int("injected", injected).Int("requested", req.Count).Msg("calls injected via admin")
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message":  fmt.Sprintf("injected %d calls", injected),
-		"injected": injected,
-		"errors":   req.Count - injected,
+	json.NewEncoder(w).Encode(types.InjectCallsResponse{
+		Message:  fmt.Sprintf("injected %d calls", injected),
+		Injected: injected,
+		Errors:   req.Count - injected,
 	})
 }
 
@@ -178,9 +201,9 @@ func (h *AdminHandler) WipeAllCalls(w http.ResponseWriter, r *http.Request) {
 	h.logger.Info().Int("cleared", cleared).Msg("all calls wiped via admin")
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "all calls wiped",
-		"cleared": cleared,
+	json.NewEncoder(w).Encode(types.WipeCallsResponse{
+		Message: "all calls wiped",
+		Cleared: cleared,
 	})
 }
 
@@ -195,10 +218,10 @@ func (h *AdminHandler) ResetMemory(w http.ResponseWriter, r *http.Request) {
 		Msg("backend memory reset")
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message":       "backend memory reset",
-		"agentsCleared": agentsCleared,
-		"callsCleared":  callsCleared,
+	json.NewEncoder(w).Encode(types.ResetResponse{
+		Message:       "backend memory reset",
+		AgentsCleared: agentsCleared,
+		CallsCleared:  callsCleared,
 	})
 }
 
@@ -206,50 +229,51 @@ func (h *AdminHandler) ResetMemory(w http.ResponseWriter, r *http.Request) {
 func (h *AdminHandler) WipeDynamo(w http.ResponseWriter, r *http.Request) {
 	if err := h.store.TruncateAll(); err != nil {
 		h.logger.Error().Err(err).Msg("failed to truncate DynamoDB tables")
-		http.Error(w, fmt.Sprintf(`{"error":"failed to truncate: %s"}`, err), http.StatusInternalServerError)
+		apierror.Write(w, r, http.StatusInternalServerError, fmt.Sprintf("failed to truncate: %s", err))
 		return
 	}
 
 	h.logger.Info().Msg("DynamoDB tables truncated")
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "DynamoDB tables truncated",
+	json.NewEncoder(w).Encode(types.MessageResponse{
+		Message: "DynamoDB tables truncated",
 	})
 }
 
 // LogoffAll scales agents to 0 (keeps simulation running) and clears backend state.
+// Without AgentSim it only clears backend state.
 func (h *AdminHandler) LogoffAll(w http.ResponseWriter, r *http.Request) {
-	url := h.simURL + "/scale"
-	body := strings.NewReader(`{"activeAgents":0}`)
-	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, url, body)
-	if err != nil {
-		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+	if h.sim != nil && !h.scaleSimToZero(w, r) {
 		return
 	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := h.client.Do(req)
-	if err != nil {
-		h.logger.Error().Err(err).Msg("failed to reach AgentSim for logoff-all")
-		http.Error(w, `{"error":"AgentSim unavailable"}`, http.StatusBadGateway)
-		return
-	}
-	defer resp.Body.Close()
 
 	// Clear local backend state
 	agentsCleared := h.stateTracker.Clear()
 	callsCleared := h.callQueue.WipeAllCalls()
 
 	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(types.ResetResponse{
+		Message:       "all agents logged off",
+		AgentsCleared: agentsCleared,
+		CallsCleared:  callsCleared,
+	})
+}
+
+// scaleSimToZero asks AgentSim to scale to 0 agents; it writes an error and returns false
+// only when AgentSim can't be reached
+func (h *AdminHandler) scaleSimToZero(w http.ResponseWriter, r *http.Request) bool {
+	resp, err := h.sim.Do(r.Context(), http.MethodPost, "/scale", strings.NewReader(`{"activeAgents":0}`))
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to reach AgentSim for logoff-all")
+		apierror.Write(w, r, http.StatusBadGateway, "AgentSim unavailable")
+		return false
+	}
+	defer resp.Body.Close()
+
 	if resp.StatusCode >= 400 {
 		// Scale might fail if sim is not running — still clear local state
 		h.logger.Warn().Int("status", resp.StatusCode).Msg("AgentSim scale to 0 returned error, local state still cleared")
 	}
-
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message":       "all agents logged off",
-		"agentsCleared": agentsCleared,
-		"callsCleared":  callsCleared,
-	})
+	return true
 }
@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/cache"
 	"github.com/dennisdiepolder/monti/backend/internal/callqueue"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
 	"github.com/dennisdiepolder/monti/backend/internal/websocket"
 	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog"
@@ -14,14 +17,34 @@ import (
 type AgentActionsHandler struct {
 	agentHub     *websocket.AgentHub
 	callQueueMgr *callqueue.CallQueueManager
+	tracker      *cache.AgentStateTracker
 	logger       zerolog.Logger
 }
 
+// forceableStates are the states a supervisor may move an agent into
+var forceableStates = map[types.AgentState]bool{
+	types.StateAvailable: true,
+	types.StateBreak:     true,
+	types.StateLunch:     true,
+	types.StateMeeting:   true,
+	types.StateTraining:  true,
+}
+
+// callStates are states during which an agent is handling a call and cannot be forced
+var callStates = map[types.AgentState]bool{
+	types.StateOnCall:       true,
+	types.StateOnHold:       true,
+	types.StateTransferring: true,
+	types.StateConference:   true,
+	types.StateMultiSession: true,
+}
+
 // NewAgentActionsHandler creates a new AgentActionsHandler
-func NewAgentActionsHandler(agentHub *websocket.AgentHub, callQueueMgr *callqueue.CallQueueManager, logger zerolog.Logger) *AgentActionsHandler {
+func NewAgentActionsHandler(agentHub *websocket.AgentHub, callQueueMgr *callqueue.CallQueueManager, tracker *cache.AgentStateTracker, logger zerolog.Logger) *AgentActionsHandler {
 	return &AgentActionsHandler{
 		agentHub:     agentHub,
 		callQueueMgr: callQueueMgr,
+		tracker:      tracker,
 		logger:       logger.With().Str("component", "agent_actions").Logger(),
 	}
 }
@@ -32,14 +55,14 @@ func (h *AgentActionsHandler) ForceEndCall(w http.ResponseWriter, r *http.Reques
 	callID := chi.URLParam(r, "callId")
 
 	if agentID == "" || callID == "" {
-		http.Error(w, "agentId and callId are required", http.StatusBadRequest)
+		apierror.Write(w, r, http.StatusBadRequest, "agentId and callId are required")
 		return
 	}
 
 	// Force-end the call in the queue manager
 	foundAgentID, found := h.callQueueMgr.ForceEndCall(callID)
 	if !found {
-		http.Error(w, "call not found in active queues", http.StatusNotFound)
+		apierror.Write(w, r, http.StatusNotFound, "call not found in active queues")
 		return
 	}
 
@@ -52,10 +75,10 @@ func (h *AgentActionsHandler) ForceEndCall(w http.ResponseWriter, r *http.Reques
 		Msg("force-ended call via API")
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "call ended",
-		"agentId": foundAgentID,
-		"callId":  callID,
+	json.NewEncoder(w).Encode(types.AgentActionResponse{
+		Message: "call ended",
+		AgentID: foundAgentID,
+		CallID:  callID,
 	})
 }
 
@@ -63,14 +86,14 @@ func (h *AgentActionsHandler) ForceEndCall(w http.ResponseWriter, r *http.Reques
 func (h *AgentActionsHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	agentID := chi.URLParam(r, "agentId")
 	if agentID == "" {
-		http.Error(w, "agentId is required", http.StatusBadRequest)
+		apierror.Write(w, r, http.StatusBadRequest, "agentId is required")
 		return
 	}
 
 	// Force-disconnect the agent (hub handles cleanup)
 	ok := h.agentHub.ForceDisconnect(agentID)
 	if !ok {
-		http.Error(w, "agent not connected", http.StatusNotFound)
+		apierror.WriteCode(w, r, http.StatusNotFound, types.ErrCodeAgentNotConnected, "agent not connected")
 		return
 	}
 
@@ -79,8 +102,56 @@ func (h *AgentActionsHandler) Logout(w http.ResponseWriter, r *http.Request) {
 		Msg("force-disconnected agent via API")
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "agent logged out",
-		"agentId": agentID,
+	json.NewEncoder(w).Encode(types.AgentActionResponse{
+		Message: "agent logged out",
+		AgentID: agentID,
+	})
+}
+
+// ForceState handles POST /api/agents/{agentId}/state
+// Body: {"state": "available|break|lunch|meeting|training"}. Agents on a call must have the call ended first.
+func (h *AgentActionsHandler) ForceState(w http.ResponseWriter, r *http.Request) {
+	agentID := chi.URLParam(r, "agentId")
+	if agentID == "" {
+		apierror.Write(w, r, http.StatusBadRequest, "agentId is required")
+		return
+	}
+
+	var req types.ForceStateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if !forceableStates[req.State] {
+		apierror.Write(w, r, http.StatusBadRequest, "state must be one of available, break, lunch, meeting, training")
+		return
+	}
+
+	agent, ok := h.tracker.Get(agentID)
+	if !ok {
+		apierror.Write(w, r, http.StatusNotFound, "agent not found")
+		return
+	}
+	if callStates[agent.State] {
+		apierror.WriteCode(w, r, http.StatusConflict, types.ErrCodeAgentOnCall, "agent is on a call, end the call first")
+		return
+	}
+
+	if !h.agentHub.ForceState(agentID, req.State) {
+		apierror.WriteCode(w, r, http.StatusNotFound, types.ErrCodeAgentNotConnected, "agent not connected")
+		return
+	}
+
+	h.logger.Info().
+		Str("agent_id", agentID).
+		Str("from_state", string(agent.State)).
+		Str("to_state", string(req.State)).
+		Msg("forced agent state via API")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(types.AgentActionResponse{
+		Message: "state change requested",
+		AgentID: agentID,
+		State:   req.State,
 	})
 }
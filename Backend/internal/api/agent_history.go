@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
 	"github.com/dennisdiepolder/monti/backend/internal/storage"
 	"github.com/dennisdiepolder/monti/backend/internal/types"
 	"github.com/go-chi/chi/v5"
@@ -29,14 +31,14 @@ func NewAgentHistoryHandler(store storage.Store, logger zerolog.Logger) *AgentHi
 func (h *AgentHistoryHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
 	agentID := chi.URLParam(r, "agentId")
 	if agentID == "" {
-		http.Error(w, "agentId is required", http.StatusBadRequest)
+		apierror.Write(w, r, http.StatusBadRequest, "agentId is required")
 		return
 	}
 
 	stats, err := h.store.GetAgentDailyStats(agentID)
 	if err != nil {
 		h.logger.Error().Err(err).Str("agent_id", agentID).Msg("failed to get agent daily stats")
-		http.Error(w, "failed to retrieve history", http.StatusInternalServerError)
+		apierror.Write(w, r, http.StatusInternalServerError, "failed to retrieve history")
 		return
 	}
 
@@ -53,23 +55,28 @@ func (h *AgentHistoryHandler) GetHistory(w http.ResponseWriter, r *http.Request)
 func (h *AgentHistoryHandler) GetCalls(w http.ResponseWriter, r *http.Request) {
 	agentID := chi.URLParam(r, "agentId")
 	if agentID == "" {
-		http.Error(w, "agentId is required", http.StatusBadRequest)
+		apierror.Write(w, r, http.StatusBadRequest, "agentId is required")
 		return
 	}
 
 	date := r.URL.Query().Get("date")
 	if date == "" {
-		http.Error(w, "date query parameter is required (YYYY-MM-DD)", http.StatusBadRequest)
+		apierror.Write(w, r, http.StatusBadRequest, "date query parameter is required (YYYY-MM-DD)")
 		return
 	}
 
-	records, err := h.store.GetAgentCallsByDate(agentID, date)
+	// Call records of other tenants are partitioned under tenant-prefixed date keys
+	tenant := ""
+	if claims, ok := auth.GetUserFromContext(r.Context()); ok {
+		tenant = claims.Tenant
+	}
+	records, err := h.store.GetAgentCallsByDate(agentID, types.TenantKey(tenant, date))
 	if err != nil {
 		h.logger.Error().Err(err).
 			Str("agent_id", agentID).
 			Str("date", date).
 			Msg("failed to get agent calls")
-		http.Error(w, "failed to retrieve calls", http.StatusInternalServerError)
+		apierror.Write(w, r, http.StatusInternalServerError, "failed to retrieve calls")
 		return
 	}
 
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dennisdiepolder/monti/backend/internal/alerts"
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+)
+
+// AlertRuleHandler provides admin CRUD for alert rules
+type AlertRuleHandler struct {
+	engine *alerts.Engine
+	logger zerolog.Logger
+}
+
+// NewAlertRuleHandler creates a new AlertRuleHandler
+func NewAlertRuleHandler(engine *alerts.Engine, logger zerolog.Logger) *AlertRuleHandler {
+	return &AlertRuleHandler{
+		engine: engine,
+		logger: logger.With().Str("component", "alert_rules").Logger(),
+	}
+}
+
+// ListRules handles GET /api/admin/alert-rules
+func (h *AlertRuleHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.engine.Rules())
+}
+
+// GetRule handles GET /api/admin/alert-rules/{ruleId}
+func (h *AlertRuleHandler) GetRule(w http.ResponseWriter, r *http.Request) {
+	rule, ok := h.engine.GetRule(chi.URLParam(r, "ruleId"))
+	if !ok {
+		apierror.Write(w, r, http.StatusNotFound, "rule not found")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+// PutRule handles PUT /api/admin/alert-rules/{ruleId} (create or replace)
+func (h *AlertRuleHandler) PutRule(w http.ResponseWriter, r *http.Request) {
+	var rule alerts.Rule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	rule.ID = chi.URLParam(r, "ruleId")
+
+	if err := h.engine.PutRule(rule); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.logger.Info().
+		Str("rule", rule.ID).
+		Str("type", string(rule.Type)).
+		Float64("threshold", rule.Threshold).
+		Bool("enabled", rule.Enabled).
+		Msg("alert rule saved via admin")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+// DeleteRule handles DELETE /api/admin/alert-rules/{ruleId}
+func (h *AlertRuleHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "ruleId")
+	if !h.engine.DeleteRule(id) {
+		apierror.Write(w, r, http.StatusNotFound, "rule not found")
+		return
+	}
+
+	h.logger.Info().Str("rule", id).Msg("alert rule deleted via admin")
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/alerts"
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
+	"github.com/dennisdiepolder/monti/backend/internal/storage"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+)
+
+// AlertHandler provides REST endpoints for alert state and history
+type AlertHandler struct {
+	tracker *alerts.Tracker
+	store   storage.Store
+	logger  zerolog.Logger
+}
+
+// NewAlertHandler creates a new AlertHandler
+func NewAlertHandler(tracker *alerts.Tracker, store storage.Store, logger zerolog.Logger) *AlertHandler {
+	return &AlertHandler{
+		tracker: tracker,
+		store:   store,
+		logger:  logger.With().Str("component", "alert_handler").Logger(),
+	}
+}
+
+// ListAlerts handles GET /api/alerts?status=&date=YYYY-MM-DD
+// Without date, returns active alerts plus recently resolved ones from memory;
+// with date, returns that day's persisted history.
+func (h *AlertHandler) ListAlerts(w http.ResponseWriter, r *http.Request) {
+	status := types.AlertStatus(r.URL.Query().Get("status"))
+	switch status {
+	case "", types.AlertFiring, types.AlertAcknowledged, types.AlertResolved:
+	default:
+		apierror.Write(w, r, http.StatusBadRequest, "status must be firing, acknowledged or resolved")
+		return
+	}
+
+	var records []types.AlertRecord
+	if date := r.URL.Query().Get("date"); date != "" {
+		var err error
+		records, err = h.store.GetAlerts(date)
+		if err != nil {
+			h.logger.Error().Err(err).Str("date", date).Msg("failed to get alert history")
+			apierror.Write(w, r, http.StatusInternalServerError, "failed to retrieve alerts")
+			return
+		}
+	} else {
+		records = append(h.tracker.Active(), h.tracker.Resolved()...)
+	}
+
+	claims, _ := auth.GetUserFromContext(r.Context())
+	out := make([]types.AlertRecord, 0, len(records))
+	for _, rec := range records {
+		if status != "" && rec.Status != status {
+			continue
+		}
+		if !alertVisible(claims, rec) {
+			continue
+		}
+		out = append(out, rec)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// GetAgentAlerts handles GET /api/agents/{agentId}/alerts?date=YYYY-MM-DD (default today),
+// the agent's alert occurrences of the day with a count and duration per rule
+func (h *AlertHandler) GetAgentAlerts(w http.ResponseWriter, r *http.Request) {
+	agentID := chi.URLParam(r, "agentId")
+	date, ok := dateParam(w, r)
+	if !ok {
+		return
+	}
+
+	persisted, err := h.store.GetAlerts(date)
+	if err != nil {
+		h.logger.Error().Err(err).Str("agent_id", agentID).Str("date", date).Msg("failed to get agent alert history")
+		apierror.Write(w, r, http.StatusInternalServerError, "failed to retrieve alerts")
+		return
+	}
+
+	// Each alert carries the team and location the agent had when it fired
+	claims, _ := auth.GetUserFromContext(r.Context())
+	include := func(rec types.AlertRecord) bool { return alertVisible(claims, rec) }
+	history := h.tracker.AgentHistory(agentID, date, persisted, include, time.Now())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+// Acknowledge handles POST /api/alerts/{alertId}/ack
+func (h *AlertHandler) Acknowledge(w http.ResponseWriter, r *http.Request) {
+	alertID := chi.URLParam(r, "alertId")
+	claims, _ := auth.GetUserFromContext(r.Context())
+
+	if rec, ok := h.tracker.Get(alertID); !ok || !alertVisible(claims, rec) {
+		apierror.Write(w, r, http.StatusNotFound, "alert not found")
+		return
+	}
+
+	user := ""
+	if claims != nil {
+		user = claims.Email
+		if user == "" {
+			user = claims.Name
+		}
+	}
+
+	rec, err := h.tracker.Acknowledge(alertID, user, time.Now())
+	if errors.Is(err, alerts.ErrAlertNotFound) {
+		apierror.Write(w, r, http.StatusNotFound, "alert not found")
+		return
+	}
+
+	h.logger.Info().
+		Str("alert_id", alertID).
+		Str("rule", rec.Rule).
+		Str("user", user).
+		Msg("alert acknowledged")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rec)
+}
+
+// alertVisible applies RBAC: alerts are limited to the caller's departments,
+// agent alerts additionally to their locations and teams, team alerts to their teams.
+// Each tenant only sees its own alerts.
+func alertVisible(claims *auth.Claims, rec types.AlertRecord) bool {
+	if claims == nil {
+		return true
+	}
+	if !claims.IsTenantAllowed(rec.Tenant) {
+		return false
+	}
+	if rec.Department != "" && !claims.IsDepartmentAllowed(rec.Department) {
+		return false
+	}
+	switch rec.Scope {
+	case types.AlertScopeAgent:
+		return claims.IsLocationAllowed(rec.Location) && claims.IsTeamAllowed(rec.Team)
+	case types.AlertScopeTeam:
+		return claims.IsTeamAllowed(rec.Target)
+	}
+	return true
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/announcements"
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+)
+
+// AnnouncementHandler publishes and withdraws the announcements shown on dashboards
+type AnnouncementHandler struct {
+	board  *announcements.Board
+	logger zerolog.Logger
+}
+
+// NewAnnouncementHandler creates a new AnnouncementHandler
+func NewAnnouncementHandler(board *announcements.Board, logger zerolog.Logger) *AnnouncementHandler {
+	return &AnnouncementHandler{
+		board:  board,
+		logger: logger.With().Str("component", "announcements").Logger(),
+	}
+}
+
+// ListAnnouncements handles GET /api/admin/announcements: the active announcements of the
+// caller's tenant, most severe first
+func (h *AnnouncementHandler) ListAnnouncements(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.visible(r))
+}
+
+// PublishAnnouncement handles POST /api/admin/announcements
+// The announcement is part of the next snapshot; it belongs to the caller's tenant.
+func (h *AnnouncementHandler) PublishAnnouncement(w http.ResponseWriter, r *http.Request) {
+	var req types.AnnouncementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.WriteValidation(w, r, "invalid JSON body", nil)
+		return
+	}
+	if fields := req.Validate(time.Now()); len(fields) > 0 {
+		apierror.WriteValidation(w, r, "invalid announcement", fields)
+		return
+	}
+
+	a := types.Announcement{
+		Text:        req.Text,
+		Severity:    req.Severity,
+		Departments: req.Departments,
+		Locations:   req.Locations,
+		ExpiresAt:   req.ExpiresAt,
+	}
+	if claims, ok := auth.GetUserFromContext(r.Context()); ok {
+		a.CreatedBy = claims.Email
+		if a.CreatedBy == "" {
+			a.CreatedBy = claims.Name
+		}
+		if claims.Tenant != types.DefaultTenant {
+			a.Tenant = claims.Tenant
+		}
+	}
+	a = h.board.Publish(a)
+
+	h.logger.Info().
+		Str("announcement_id", a.ID).
+		Str("severity", string(a.Severity)).
+		Str("created_by", a.CreatedBy).
+		Msg("announcement published")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(a)
+}
+
+// WithdrawAnnouncement handles DELETE /api/admin/announcements/{announcementId}
+func (h *AnnouncementHandler) WithdrawAnnouncement(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "announcementId")
+	found := false
+	for _, a := range h.visible(r) {
+		if a.ID == id {
+			found = true
+			break
+		}
+	}
+	if found {
+		if err := h.board.Withdraw(id); errors.Is(err, announcements.ErrNotFound) {
+			found = false
+		}
+	}
+	if !found {
+		apierror.Write(w, r, http.StatusNotFound, announcements.ErrNotFound.Error())
+		return
+	}
+
+	h.logger.Info().Str("announcement_id", id).Msg("announcement withdrawn")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// visible returns the active announcements of the caller's tenant
+func (h *AnnouncementHandler) visible(r *http.Request) []types.Announcement {
+	active := h.board.Active(time.Now())
+	claims, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		return active
+	}
+	out := make([]types.Announcement, 0, len(active))
+	for _, a := range active {
+		if claims.IsTenantAllowed(a.Tenant) {
+			out = append(out, a)
+		}
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/backfill"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// maxBackfillDays is the longest range one backfill request recomputes
+const maxBackfillDays = 92
+
+// BackfillHandler recomputes persisted daily stats from the call records
+type BackfillHandler struct {
+	service *backfill.Service
+	logger  zerolog.Logger
+
+	mu sync.Mutex // one backfill at a time
+}
+
+// NewBackfillHandler creates a new BackfillHandler
+func NewBackfillHandler(service *backfill.Service, logger zerolog.Logger) *BackfillHandler {
+	return &BackfillHandler{
+		service: service,
+		logger:  logger.With().Str("component", "backfill_handler").Logger(),
+	}
+}
+
+// BackfillDailyStats handles POST /api/admin/backfill/daily-stats {"from", "to"}
+// Rewrites the agent and VQ daily stats of the days in the range from the call records.
+func (h *BackfillHandler) BackfillDailyStats(w http.ResponseWriter, r *http.Request) {
+	var req types.BackfillRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.WriteValidation(w, r, "invalid JSON body", nil)
+		return
+	}
+	from, fromErr := time.Parse(types.DateLayout, req.From)
+	to, toErr := time.Parse(types.DateLayout, req.To)
+	var fields []types.FieldError
+	if fromErr != nil {
+		fields = append(fields, types.FieldError{Field: "from", Message: "must be a date (YYYY-MM-DD)"})
+	}
+	if toErr != nil {
+		fields = append(fields, types.FieldError{Field: "to", Message: "must be a date (YYYY-MM-DD)"})
+	}
+	if fields == nil && (to.Before(from) || to.Sub(from) >= maxBackfillDays*24*time.Hour) {
+		fields = append(fields, types.FieldError{Field: "to", Message: "must be on or after from, at most 92 days"})
+	}
+	if fields != nil {
+		apierror.WriteValidation(w, r, "invalid backfill range", fields)
+		return
+	}
+
+	if !h.mu.TryLock() {
+		apierror.Write(w, r, http.StatusConflict, "a backfill is already running")
+		return
+	}
+	defer h.mu.Unlock()
+
+	resp, err := h.service.Run(req.From, req.To, time.Now())
+	if err != nil {
+		h.logger.Error().Err(err).Str("from", req.From).Str("to", req.To).Msg("backfill failed")
+		apierror.Write(w, r, http.StatusInternalServerError, "backfill failed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
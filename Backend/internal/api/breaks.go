@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
+	"github.com/dennisdiepolder/monti/backend/internal/breaks"
+	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// BreaksHandler exposes live breaks against the break policy
+type BreaksHandler struct {
+	guard   *breaks.Guard
+	tracker *cache.AgentStateTracker
+	logger  zerolog.Logger
+}
+
+// NewBreaksHandler creates a new BreaksHandler
+func NewBreaksHandler(guard *breaks.Guard, tracker *cache.AgentStateTracker, logger zerolog.Logger) *BreaksHandler {
+	return &BreaksHandler{
+		guard:   guard,
+		tracker: tracker,
+		logger:  logger.With().Str("component", "breaks").Logger(),
+	}
+}
+
+// GetStatus handles GET /api/breaks
+// Query params: department (optional).
+// Restricted callers only get departments where they can see agents (same rule as /api/queues);
+// counts and caps always cover the whole department.
+func (h *BreaksHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	dept := types.Department(r.URL.Query().Get("department"))
+	claims, _ := auth.GetUserFromContext(r.Context())
+
+	var visible map[types.Department]bool
+	if claims != nil && !claims.IsUnrestricted() {
+		visible = make(map[types.Department]bool)
+		for _, agent := range h.tracker.GetAll() {
+			if claims.IsAgentAllowed(agent.Tenant, agent.Location, agent.Department, agent.Team) {
+				visible[agent.Department] = true
+			}
+		}
+	}
+
+	status := types.BreakStatus{Departments: make([]types.BreakDepartmentStatus, 0)}
+	for _, d := range h.guard.Status() {
+		if (dept != "" && d.Department != dept) || (visible != nil && !visible[d.Department]) {
+			continue
+		}
+		status.Departments = append(status.Departments, d)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// Bulk action names
+const (
+	bulkActionLogout     = "logout"
+	bulkActionForceState = "force_state"
+)
+
+// BulkAction handles POST /api/agents/bulk
+// Applies logout or force_state to every agent matching the filter within the caller's scope.
+// With dryRun the matched agents and their eligibility are returned without executing anything.
+func (h *AgentActionsHandler) BulkAction(w http.ResponseWriter, r *http.Request) {
+	var req types.BulkActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	switch req.Action {
+	case bulkActionLogout:
+	case bulkActionForceState:
+		if !forceableStates[req.State] {
+			apierror.Write(w, r, http.StatusBadRequest, "state must be one of available, break, lunch, meeting, training")
+			return
+		}
+	default:
+		apierror.Write(w, r, http.StatusBadRequest, "action must be logout or force_state")
+		return
+	}
+	if req.Filter.IsEmpty() {
+		apierror.Write(w, r, http.StatusBadRequest, "filter must select at least one field")
+		return
+	}
+
+	claims, _ := auth.GetUserFromContext(r.Context())
+
+	var matched []types.AgentInfo
+	for _, agent := range h.tracker.GetAll() {
+		if claims != nil && !claims.IsAgentAllowed(agent.Tenant, agent.Location, agent.Department, agent.Team) {
+			continue
+		}
+		if req.Filter.Matches(agent) {
+			matched = append(matched, agent)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].AgentID < matched[j].AgentID })
+
+	results := make([]types.BulkActionResult, 0, len(matched))
+	applied := 0
+	for _, agent := range matched {
+		result := types.BulkActionResult{
+			AgentID:  agent.AgentID,
+			State:    agent.State,
+			Location: agent.Location,
+			Team:     agent.Team,
+		}
+
+		switch {
+		case agent.ConnectionStatus == types.StatusDisconnected:
+			result.Error = "agent not connected"
+		case req.Action == bulkActionForceState && callStates[agent.State]:
+			result.Error = "agent is on a call"
+		case req.DryRun:
+			result.OK = true
+		case req.Action == bulkActionLogout:
+			result.OK = h.agentHub.ForceDisconnect(agent.AgentID)
+		default:
+			result.OK = h.agentHub.ForceState(agent.AgentID, req.State)
+		}
+		if !result.OK && result.Error == "" {
+			result.Error = "agent not connected"
+		}
+		if result.OK {
+			applied++
+		}
+		results = append(results, result)
+	}
+
+	if !req.DryRun {
+		h.logger.Info().
+			Str("action", req.Action).
+			Str("state", string(req.State)).
+			Int("matched", len(matched)).
+			Int("applied", applied).
+			Msg("bulk agent action via API")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(types.BulkActionResponse{
+		Action:  req.Action,
+		DryRun:  req.DryRun,
+		Matched: len(matched),
+		Applied: applied,
+		Results: results,
+	})
+}
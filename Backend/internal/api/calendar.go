@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/adherence"
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/go-chi/chi/v5"
+)
+
+// ListCalendar handles GET /api/calendar?team=&date=YYYY-MM-DD
+// Returns the day's events of the teams visible to the caller.
+func (h *AdherenceHandler) ListCalendar(w http.ResponseWriter, r *http.Request) {
+	date, ok := dateParam(w, r)
+	if !ok {
+		return
+	}
+	from, to, _ := types.DayBounds(date)
+	events := h.service.Calendar().Between(r.URL.Query().Get("team"), from, to)
+
+	claims, _ := auth.GetUserFromContext(r.Context())
+	out := []types.CalendarEvent{}
+	for _, ev := range events {
+		if claims == nil || (claims.IsTenantAllowed(ev.Tenant) && claims.IsTeamAllowed(ev.Team)) {
+			out = append(out, ev)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// PutCalendarEvents handles POST /api/calendar
+// Body: JSON array of events; events with a known ID are replaced
+func (h *AdherenceHandler) PutCalendarEvents(w http.ResponseWriter, r *http.Request) {
+	var events []types.CalendarEvent
+	if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	h.putEvents(w, r, events)
+}
+
+// ImportCalendarCSV handles POST /api/calendar/import
+// Body: CSV with header team,start,end,activity and optional id,title (RFC3339 times)
+func (h *AdherenceHandler) ImportCalendarCSV(w http.ResponseWriter, r *http.Request) {
+	events, err := adherence.ParseCalendarCSV(r.Body)
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.putEvents(w, r, events)
+}
+
+// ImportCalendarICS handles POST /api/calendar/import/ics?team=
+// Body: iCalendar; events without an X-MONTI-TEAM property go to ?team=
+func (h *AdherenceHandler) ImportCalendarICS(w http.ResponseWriter, r *http.Request) {
+	events, err := adherence.ParseICS(r.Body, r.URL.Query().Get("team"))
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.putEvents(w, r, events)
+}
+
+func (h *AdherenceHandler) putEvents(w http.ResponseWriter, r *http.Request, events []types.CalendarEvent) {
+	claims, _ := auth.GetUserFromContext(r.Context())
+	for i, ev := range events {
+		if claims == nil {
+			continue
+		}
+		if !claims.IsTeamAllowed(ev.Team) {
+			apierror.Write(w, r, http.StatusForbidden, fmt.Sprintf("event %d: team %q is outside your scope", i+1, ev.Team))
+			return
+		}
+		// Replacing an event moves it, so its current team must be in scope as well
+		if existing, ok := h.service.Calendar().Get(ev.ID); ok {
+			if !claims.IsTenantAllowed(existing.Tenant) {
+				apierror.Write(w, r, http.StatusForbidden, fmt.Sprintf("event %d: id %q belongs to another tenant", i+1, ev.ID))
+				return
+			}
+			if !claims.IsTeamAllowed(existing.Team) {
+				apierror.Write(w, r, http.StatusForbidden, fmt.Sprintf("event %d: id %q belongs to team %q outside your scope", i+1, ev.ID, existing.Team))
+				return
+			}
+		}
+		events[i].Tenant = ""
+		if claims.Tenant != types.DefaultTenant {
+			events[i].Tenant = claims.Tenant
+		}
+	}
+
+	stored, err := h.service.Calendar().Put(events)
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.logger.Info().
+		Int("events", len(stored)).
+		Msg("calendar events imported")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(types.CalendarImportResponse{Events: stored})
+}
+
+// DeleteCalendarEvent handles DELETE /api/calendar/{eventId}
+func (h *AdherenceHandler) DeleteCalendarEvent(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "eventId")
+	claims, _ := auth.GetUserFromContext(r.Context())
+	ev, ok := h.service.Calendar().Get(id)
+	if !ok || (claims != nil && !(claims.IsTenantAllowed(ev.Tenant) && claims.IsTeamAllowed(ev.Team))) {
+		apierror.Write(w, r, http.StatusNotFound, "event not found")
+		return
+	}
+	h.service.Calendar().Delete(id)
+	h.logger.Info().Str("event_id", id).Str("team", ev.Team).Msg("calendar event deleted")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// InternalCalendar handles GET /internal/calendar?from=&to= for AgentSim
+// Returns every team's events overlapping the range (RFC3339; default the next 24 hours).
+func (h *AdherenceHandler) InternalCalendar(w http.ResponseWriter, r *http.Request) {
+	from, to := time.Now(), time.Time{}
+	for name, t := range map[string]*time.Time{"from": &from, "to": &to} {
+		v := r.URL.Query().Get(name)
+		if v == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			apierror.Write(w, r, http.StatusBadRequest, name+" must be RFC3339")
+			return
+		}
+		*t = parsed
+	}
+	if to.IsZero() {
+		to = from.Add(24 * time.Hour)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.service.Calendar().Between("", from, to))
+}
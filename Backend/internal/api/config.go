@@ -0,0 +1,44 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dennisdiepolder/monti/backend/internal/config"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// ConfigHandler exposes the effective backend configuration to admins
+type ConfigHandler struct {
+	cfg    *config.Config
+	logger zerolog.Logger
+}
+
+// NewConfigHandler creates a new ConfigHandler
+func NewConfigHandler(cfg *config.Config, logger zerolog.Logger) *ConfigHandler {
+	return &ConfigHandler{
+		cfg:    cfg,
+		logger: logger.With().Str("component", "config").Logger(),
+	}
+}
+
+// GetConfig handles GET /api/admin/config
+// Returns every setting read by config.Load with its value and source (env, file or default);
+// secrets are redacted
+func (h *ConfigHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(types.ConfigResponse{
+		Settings: h.cfg.Settings,
+	})
+}
+
+// GetCapabilities handles GET /api/admin/capabilities
+// Returns which optional backends are configured
+func (h *ConfigHandler) GetCapabilities(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(types.Capabilities{
+		Simulation: h.cfg.AgentSimURL != "",
+		EventLog:   h.cfg.EventLogFile != "",
+	})
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/callqueue"
+	"github.com/dennisdiepolder/monti/backend/internal/eventlog"
+	"github.com/dennisdiepolder/monti/backend/internal/ingestion"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// EventLogHandler rebuilds in-memory state from the event log
+type EventLogHandler struct {
+	log          *eventlog.Log // nil when EVENT_LOG_FILE is unset
+	stateTracker *cache.AgentStateTracker
+	callQueue    *callqueue.CallQueueManager
+	processor    *ingestion.DefaultProcessor // the unrecorded processor
+	logger       zerolog.Logger
+
+	mu sync.Mutex // one replay at a time
+}
+
+// NewEventLogHandler creates a new EventLogHandler; log may be nil
+func NewEventLogHandler(log *eventlog.Log, stateTracker *cache.AgentStateTracker, callQueue *callqueue.CallQueueManager, processor *ingestion.DefaultProcessor, logger zerolog.Logger) *EventLogHandler {
+	return &EventLogHandler{
+		log:          log,
+		stateTracker: stateTracker,
+		callQueue:    callQueue,
+		processor:    processor,
+		logger:       logger.With().Str("component", "eventlog").Logger(),
+	}
+}
+
+// Replay clears agents and calls, then re-applies the event log (up to an optional instant)
+// with routing paused. Messages arriving while the replay runs are applied but not recorded.
+func (h *EventLogHandler) Replay(w http.ResponseWriter, r *http.Request) {
+	if h.log == nil {
+		apierror.Write(w, r, http.StatusNotFound, "event log is not enabled (set EVENT_LOG_FILE)")
+		return
+	}
+
+	var req types.EventLogReplayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		apierror.WriteValidation(w, r, "invalid JSON body", nil)
+		return
+	}
+	var until time.Time
+	if req.Until != nil {
+		until = *req.Until
+	}
+
+	if !h.mu.TryLock() {
+		apierror.Write(w, r, http.StatusConflict, "a replay is already running")
+		return
+	}
+	defer h.mu.Unlock()
+
+	start := time.Now()
+	h.callQueue.PauseRouting(true)
+	defer h.callQueue.PauseRouting(false)
+
+	agentsCleared := h.stateTracker.Clear()
+	callsCleared := h.callQueue.WipeAllCalls()
+	h.processor.ResetSequences()
+	stats, err := h.log.Replay(until, h.processor, h.callQueue)
+	if err != nil {
+		h.logger.Error().Err(err).Int("events", stats.Events).Msg("event log replay failed")
+		apierror.Write(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.logger.Info().
+		Int("events", stats.Events).
+		Int("skipped", stats.Skipped).
+		Int("agents_cleared", agentsCleared).
+		Int("calls_cleared", callsCleared).
+		Dur("duration", time.Since(start)).
+		Msg("event log replayed")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(types.EventLogReplayResponse{
+		ResetResponse: types.ResetResponse{
+			Message:       "event log replayed",
+			AgentsCleared: agentsCleared,
+			CallsCleared:  callsCleared,
+		},
+		EventLogReplayStats: stats,
+	})
+}
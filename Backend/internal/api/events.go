@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
+	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// RecentEventsHandler serves the raw agent events kept in the event cache
+type RecentEventsHandler struct {
+	events  *cache.EventCache
+	tracker *cache.AgentStateTracker
+}
+
+// NewRecentEventsHandler creates a new RecentEventsHandler
+func NewRecentEventsHandler(events *cache.EventCache, tracker *cache.AgentStateTracker) *RecentEventsHandler {
+	return &RecentEventsHandler{events: events, tracker: tracker}
+}
+
+// ListEvents handles GET /api/events
+// Query params: agentId, since (RFC3339). Only events within EVENT_RETENTION are kept.
+// Events of agents outside the caller's tenant, locations, departments or teams are never
+// returned.
+func (h *RecentEventsHandler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var since time.Time
+	if v := q.Get("since"); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			apierror.Write(w, r, http.StatusBadRequest, "since must be RFC3339")
+			return
+		}
+		since = parsed
+	}
+
+	claims, _ := auth.GetUserFromContext(r.Context())
+
+	events := h.events.Query(q.Get("agentId"), since)
+	if claims != nil && !claims.IsUnrestricted() {
+		visible := events[:0]
+		for _, e := range events {
+			// The tracked agent carries its current scope; events of removed agents are
+			// judged by the tenant and scope recorded with them
+			allowed := claims.IsAgentAllowed(e.Tenant, e.Location, e.Department, e.Team)
+			if agent, ok := h.tracker.Get(e.AgentID); ok {
+				allowed = claims.IsAgentAllowed(agent.Tenant, agent.Location, agent.Department, agent.Team)
+			}
+			if allowed {
+				visible = append(visible, e)
+			}
+		}
+		events = visible
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(types.AgentEventList{
+		Events: events,
+		Count:  len(events),
+	})
+}
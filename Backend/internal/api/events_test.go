@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
+	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/ingestion"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+func TestListEventsOfRetiredAgentScopedByTenant(t *testing.T) {
+	if err := types.SetTenants([]string{"acme"}); err != nil {
+		t.Fatal(err)
+	}
+	defer types.SetTenants(nil)
+
+	tracker := cache.NewAgentStateTracker()
+	events := cache.NewEventCache(time.Hour)
+	processor := ingestion.NewDefaultProcessor(tracker, zerolog.Nop())
+	processor.SetEventCache(events)
+	processor.ProcessRegister(&types.AgentRegister{AgentID: "a1", Tenant: "acme", Department: types.DeptSales,
+		Location: types.LocationBerlin, Team: "Team A", State: types.StateAvailable})
+	processor.ProcessStateChange(&types.AgentStateChange{AgentID: "a1", NewState: types.StateBreak})
+	tracker.SetDisconnected("a1")
+	if err := tracker.RetireAgent("a1"); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewRecentEventsHandler(events, tracker)
+	count := func(tenant string) int {
+		claims := &auth.Claims{Role: "manager", Tenant: tenant, AllowedLocations: types.AllLocations}
+		req := httptest.NewRequest(http.MethodGet, "/api/events", nil)
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, claims))
+		rec := httptest.NewRecorder()
+		h.ListEvents(rec, req)
+		var list types.AgentEventList
+		if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+			t.Fatalf("expected an event list, got %d: %v", rec.Code, err)
+		}
+		return list.Count
+	}
+
+	if n := count("acme"); n != 2 {
+		t.Errorf("expected acme's manager to see the retired agent's 2 events, got %d", n)
+	}
+	if n := count(types.DefaultTenant); n != 0 {
+		t.Errorf("expected the default tenant's manager to see none of acme's events, got %d", n)
+	}
+}
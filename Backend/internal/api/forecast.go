@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
+	"github.com/dennisdiepolder/monti/backend/internal/forecast"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// maxForecastInterval bounds the interval so the lookback fits in the retained traffic history
+const maxForecastInterval = 6 * time.Hour
+
+// ForecastHandler provides Erlang C staffing forecasts
+type ForecastHandler struct {
+	stats     forecast.StatsSource
+	schedules forecast.ScheduleSource
+	logger    zerolog.Logger
+}
+
+// NewForecastHandler creates a new ForecastHandler
+func NewForecastHandler(stats forecast.StatsSource, schedules forecast.ScheduleSource, logger zerolog.Logger) *ForecastHandler {
+	return &ForecastHandler{
+		stats:     stats,
+		schedules: schedules,
+		logger:    logger.With().Str("component", "forecast_handler").Logger(),
+	}
+}
+
+// GetStaffing handles GET /api/forecast/staffing?vq=&interval=15m
+// Without vq, returns a forecast for every VQ within the caller's departments. Forecasts use
+// the caller's tenant's traffic.
+func (h *ForecastHandler) GetStaffing(w http.ResponseWriter, r *http.Request) {
+	interval := 15 * time.Minute
+	if s := r.URL.Query().Get("interval"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < time.Minute || d > maxForecastInterval || d%time.Minute != 0 {
+			apierror.Write(w, r, http.StatusBadRequest, "interval must be a whole number of minutes between 1m and 6h")
+			return
+		}
+		interval = d
+	}
+
+	claims, _ := auth.GetUserFromContext(r.Context())
+	tenant := ""
+	if claims != nil {
+		tenant = claims.Tenant
+	}
+	vqs := scopedVQs(claims)
+	if vq := r.URL.Query().Get("vq"); vq != "" {
+		vqs = []types.VQName{types.VQName(vq)}
+	}
+
+	now := time.Now()
+	forecasts := make([]types.StaffingForecast, 0, len(vqs))
+	for _, vq := range vqs {
+		f, ok := forecast.Staffing(h.stats, h.schedules, tenant, vq, interval, now)
+		if !ok {
+			apierror.Write(w, r, http.StatusNotFound, "unknown vq")
+			return
+		}
+		if claims != nil && !claims.IsDepartmentAllowed(f.Department) {
+			apierror.Write(w, r, http.StatusForbidden, "queue is outside your scope")
+			return
+		}
+		forecasts = append(forecasts, f)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(forecasts)
+}
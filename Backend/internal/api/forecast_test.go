@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
+	"github.com/dennisdiepolder/monti/backend/internal/callqueue"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// tenantStats offers calls per tenant in every interval of every VQ
+type tenantStats map[string]int
+
+func (s tenantStats) IntervalHistory(tenant string, vq types.VQName, interval time.Duration, count int) []types.IntervalStat {
+	n := s[types.TenantOf(tenant)]
+	return []types.IntervalStat{{VQ: vq, Offered: n, Handled: n, AHTSecs: 120}}
+}
+
+func (s tenantStats) VQConfig(vq types.VQName) (callqueue.VQConfig, bool) {
+	dept, ok := types.VQDepartmentMapping[vq]
+	return callqueue.VQConfig{Name: vq, Department: dept, SLTarget: 80, SLSeconds: 20}, ok
+}
+
+func TestGetStaffingScopedToCaller(t *testing.T) {
+	h := NewForecastHandler(tenantStats{types.DefaultTenant: 100, "acme": 7}, nil, zerolog.Nop())
+	claims := &auth.Claims{Role: "manager", Tenant: "acme", Departments: []types.Department{types.DeptSupport}}
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/forecast/staffing"+query, nil)
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, claims))
+		rec := httptest.NewRecorder()
+		h.GetStaffing(rec, req)
+		return rec
+	}
+
+	rec := get("")
+	var forecasts []types.StaffingForecast
+	if err := json.NewDecoder(rec.Body).Decode(&forecasts); err != nil {
+		t.Fatalf("expected forecasts, got %d: %v", rec.Code, err)
+	}
+	if len(forecasts) != len(types.DepartmentVQs[types.DeptSupport]) {
+		t.Fatalf("expected the support VQs only, got %d forecasts", len(forecasts))
+	}
+	for _, f := range forecasts {
+		if f.Department != types.DeptSupport || f.ForecastCalls != 7 {
+			t.Errorf("expected acme's support traffic only, got %s %s with %.0f calls", f.Department, f.VQ, f.ForecastCalls)
+		}
+	}
+
+	if rec := get("?vq=" + string(types.VQSalesInbound)); rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a VQ outside the caller's departments, got %d", rec.Code)
+	}
+}
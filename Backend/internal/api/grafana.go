@@ -0,0 +1,332 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
+	"github.com/dennisdiepolder/monti/backend/internal/callqueue"
+	"github.com/dennisdiepolder/monti/backend/internal/storage"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// maxGrafanaDays bounds how many days of daily stats and alerts one query reads
+const maxGrafanaDays = 92
+
+// alertsTarget is the table target listing the alerts fired in the query range
+const alertsTarget = "alerts"
+
+// grafanaMetrics are the metrics of every VQ, as "<vq>.<metric>". Interval metrics come from
+// the live traffic history (last 24h), daily metrics from the persisted VQ daily stats. Daily
+// ones come first so "x.daily.abandoned" isn't taken for "abandoned" of VQ "x.daily".
+var grafanaMetrics = []string{
+	"daily.completed", "daily.abandoned", "daily.service_level",
+	"offered", "handled", "abandoned", "aht",
+}
+
+// GrafanaHandler serves interval stats, VQ daily stats and the alert history to the Grafana
+// JSON datasource
+type GrafanaHandler struct {
+	callQueue *callqueue.CallQueueManager
+	store     storage.Store
+	logger    zerolog.Logger
+}
+
+// NewGrafanaHandler creates a new GrafanaHandler
+func NewGrafanaHandler(callQueue *callqueue.CallQueueManager, store storage.Store, logger zerolog.Logger) *GrafanaHandler {
+	return &GrafanaHandler{
+		callQueue: callQueue,
+		store:     store,
+		logger:    logger.With().Str("component", "grafana_handler").Logger(),
+	}
+}
+
+// TestConnection handles GET /api/grafana/, the datasource's connection test
+func (h *GrafanaHandler) TestConnection(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// Search handles POST /api/grafana/search {"target"}
+// Returns the metrics of the VQs within the caller's scope, and the alerts table.
+func (h *GrafanaHandler) Search(w http.ResponseWriter, r *http.Request) {
+	var req types.GrafanaSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		req = types.GrafanaSearchRequest{}
+	}
+
+	claims, _ := auth.GetUserFromContext(r.Context())
+	metrics := []string{}
+	for _, vq := range scopedVQs(claims) {
+		for _, m := range grafanaMetrics {
+			metrics = append(metrics, string(vq)+"."+m)
+		}
+	}
+	metrics = append(metrics, alertsTarget)
+
+	out := make([]string, 0, len(metrics))
+	for _, m := range metrics {
+		if strings.Contains(m, req.Target) {
+			out = append(out, m)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// Query handles POST /api/grafana/query
+// Returns a time series per VQ metric target and a table for the alerts target.
+func (h *GrafanaHandler) Query(w http.ResponseWriter, r *http.Request) {
+	var req types.GrafanaQueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.WriteValidation(w, r, "invalid JSON body", nil)
+		return
+	}
+	from, to, ok := grafanaRange(w, r, req.Range)
+	if !ok {
+		return
+	}
+
+	// Whole minutes, at least one, coarse enough to stay within maxDataPoints
+	interval := time.Duration(req.IntervalMs) * time.Millisecond
+	if req.MaxDataPoints > 0 {
+		if finest := to.Sub(from) / time.Duration(req.MaxDataPoints); interval < finest {
+			interval = finest
+		}
+	}
+	interval = ((interval + time.Minute - 1) / time.Minute) * time.Minute
+	if interval < time.Minute {
+		interval = time.Minute
+	}
+
+	claims, _ := auth.GetUserFromContext(r.Context())
+	tenant := ""
+	if claims != nil {
+		tenant = claims.Tenant
+	}
+	visible := make(map[types.VQName]bool)
+	for _, vq := range scopedVQs(claims) {
+		visible[vq] = true
+	}
+
+	results := make([]types.GrafanaQueryResult, 0, len(req.Targets))
+	daily := make(map[string][]types.VQDailyStats) // date -> stats, read once per request
+	for _, target := range req.Targets {
+		if target.Hide || target.Target == "" {
+			continue
+		}
+		if target.Target == alertsTarget {
+			records, err := h.alerts(claims, from, to)
+			if err != nil {
+				h.logger.Error().Err(err).Msg("failed to get alert history")
+				apierror.Write(w, r, http.StatusInternalServerError, "failed to retrieve alerts")
+				return
+			}
+			results = append(results, alertsTable(records))
+			continue
+		}
+
+		vq, metric, ok := parseGrafanaTarget(target.Target)
+		if !ok || !visible[vq] {
+			apierror.Write(w, r, http.StatusBadRequest, fmt.Sprintf("unknown target %q", target.Target))
+			return
+		}
+		result := types.GrafanaQueryResult{Target: target.Target, Datapoints: [][2]float64{}}
+		if !strings.HasPrefix(metric, "daily.") {
+			for _, iv := range h.callQueue.IntervalRange(tenant, vq, interval, from, to) {
+				result.Datapoints = append(result.Datapoints, [2]float64{intervalValue(iv, metric), float64(iv.Start.UnixMilli())})
+			}
+			results = append(results, result)
+			continue
+		}
+		for _, date := range grafanaDays(from, to) {
+			stats, ok := daily[date]
+			if !ok {
+				var err error
+				if stats, err = h.store.GetVQDailyStats(types.TenantKey(tenant, date)); err != nil {
+					h.logger.Error().Err(err).Str("date", date).Msg("failed to get VQ daily stats")
+					apierror.Write(w, r, http.StatusInternalServerError, "failed to retrieve daily stats")
+					return
+				}
+				daily[date] = stats
+			}
+			start, _, _ := types.DayBounds(date)
+			for _, s := range stats {
+				if s.VQ == vq {
+					result.Datapoints = append(result.Datapoints, [2]float64{dailyValue(s, metric), float64(start.UnixMilli())})
+				}
+			}
+		}
+		results = append(results, result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// Annotations handles POST /api/grafana/annotations
+// Returns the alerts fired in the range, optionally only those of the rule or severity in
+// the annotation query.
+func (h *GrafanaHandler) Annotations(w http.ResponseWriter, r *http.Request) {
+	var req types.GrafanaAnnotationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.WriteValidation(w, r, "invalid JSON body", nil)
+		return
+	}
+	from, to, ok := grafanaRange(w, r, req.Range)
+	if !ok {
+		return
+	}
+
+	claims, _ := auth.GetUserFromContext(r.Context())
+	records, err := h.alerts(claims, from, to)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to get alert history")
+		apierror.Write(w, r, http.StatusInternalServerError, "failed to retrieve alerts")
+		return
+	}
+
+	query := strings.TrimSpace(req.Annotation.Query)
+	events := make([]types.GrafanaAnnotationEvent, 0, len(records))
+	for _, rec := range records {
+		if query != "" && rec.Rule != query && string(rec.Severity) != query {
+			continue
+		}
+		event := types.GrafanaAnnotationEvent{
+			Annotation: req.Annotation,
+			Time:       rec.FiredAt.UnixMilli(),
+			Title:      rec.Rule,
+			Text:       rec.Message,
+			Tags:       []string{string(rec.Severity), string(rec.Scope), rec.Target},
+		}
+		if rec.ResolvedAt != nil {
+			event.TimeEnd = rec.ResolvedAt.UnixMilli()
+		}
+		events = append(events, event)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
+
+// alerts returns the persisted alerts visible to the caller that fired between from and to,
+// oldest first
+func (h *GrafanaHandler) alerts(claims *auth.Claims, from, to time.Time) ([]types.AlertRecord, error) {
+	var out []types.AlertRecord
+	for _, date := range grafanaDays(from, to) {
+		records, err := h.store.GetAlerts(date)
+		if err != nil {
+			return nil, err
+		}
+		for _, rec := range records {
+			if !rec.FiredAt.Before(from) && !rec.FiredAt.After(to) && alertVisible(claims, rec) {
+				out = append(out, rec)
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].FiredAt.Before(out[j].FiredAt) })
+	return out, nil
+}
+
+// alertsTable lays out alerts as a Grafana table
+func alertsTable(records []types.AlertRecord) types.GrafanaQueryResult {
+	table := types.GrafanaQueryResult{
+		Type: "table",
+		Columns: []types.GrafanaColumn{
+			{Text: "Fired", Type: "time"},
+			{Text: "Severity", Type: "string"},
+			{Text: "Rule", Type: "string"},
+			{Text: "Scope", Type: "string"},
+			{Text: "Target", Type: "string"},
+			{Text: "Department", Type: "string"},
+			{Text: "Status", Type: "string"},
+			{Text: "Message", Type: "string"},
+			{Text: "Duration", Type: "number"},
+		},
+		Rows: [][]interface{}{},
+	}
+	for _, rec := range records {
+		table.Rows = append(table.Rows, []interface{}{rec.FiredAt.UnixMilli(), rec.Severity, rec.Rule, rec.Scope,
+			rec.Target, rec.Department, rec.Status, rec.Message, rec.Duration})
+	}
+	return table
+}
+
+// grafanaRange parses a query range, keeping at most maxGrafanaDays before its end; on
+// failure it writes a 400 and returns false
+func grafanaRange(w http.ResponseWriter, r *http.Request, rng types.GrafanaRange) (from, to time.Time, ok bool) {
+	from, fromErr := time.Parse(time.RFC3339, rng.From)
+	to, toErr := time.Parse(time.RFC3339, rng.To)
+	if fromErr != nil || toErr != nil || to.Before(from) {
+		apierror.Write(w, r, http.StatusBadRequest, "range must have RFC3339 from and to, from not after to")
+		return time.Time{}, time.Time{}, false
+	}
+	if oldest := to.AddDate(0, 0, -maxGrafanaDays); from.Before(oldest) {
+		from = oldest
+	}
+	return from, to, true
+}
+
+// grafanaDays returns the reporting days from covers up to to
+func grafanaDays(from, to time.Time) []string {
+	var days []string
+	for day, _, _ := types.DayBounds(types.DateOf(from)); !day.After(to); day = day.AddDate(0, 0, 1) {
+		days = append(days, day.Format(types.DateLayout))
+	}
+	return days
+}
+
+// scopedVQs returns the VQs of the departments within the caller's scope
+func scopedVQs(claims *auth.Claims) []types.VQName {
+	var vqs []types.VQName
+	for _, vq := range types.AllVQs {
+		if claims == nil || claims.IsDepartmentAllowed(types.VQDepartmentMapping[vq]) {
+			vqs = append(vqs, vq)
+		}
+	}
+	return vqs
+}
+
+// parseGrafanaTarget splits "<vq>.<metric>" into a known VQ and metric
+func parseGrafanaTarget(target string) (types.VQName, string, bool) {
+	for _, m := range grafanaMetrics {
+		if vq, ok := strings.CutSuffix(target, "."+m); ok {
+			_, known := types.VQDepartmentMapping[types.VQName(vq)]
+			return types.VQName(vq), m, known
+		}
+	}
+	return "", "", false
+}
+
+// intervalValue returns an interval metric of iv
+func intervalValue(iv types.IntervalStat, metric string) float64 {
+	switch metric {
+	case "offered":
+		return float64(iv.Offered)
+	case "handled":
+		return float64(iv.Handled)
+	case "abandoned":
+		return float64(iv.Abandoned)
+	default:
+		return iv.AHTSecs
+	}
+}
+
+// dailyValue returns a daily metric of s
+func dailyValue(s types.VQDailyStats, metric string) float64 {
+	switch metric {
+	case "daily.completed":
+		return float64(s.Completed)
+	case "daily.abandoned":
+		return float64(s.Abandoned)
+	default:
+		return s.ServiceLevel
+	}
+}
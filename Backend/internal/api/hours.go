@@ -0,0 +1,32 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/hours"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// HoursHandler exposes which departments are within business hours
+type HoursHandler struct {
+	calendar *hours.Calendar
+	logger   zerolog.Logger
+}
+
+// NewHoursHandler creates a new HoursHandler
+func NewHoursHandler(calendar *hours.Calendar, logger zerolog.Logger) *HoursHandler {
+	return &HoursHandler{
+		calendar: calendar,
+		logger:   logger.With().Str("component", "hours").Logger(),
+	}
+}
+
+// GetStatus handles GET /api/hours and GET /internal/hours (polled by AgentSim's call
+// generator): every department's opening status right now
+func (h *HoursHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(types.BusinessHoursResponse{Departments: h.calendar.Status(time.Now())})
+}
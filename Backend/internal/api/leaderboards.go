@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// LeaderboardConfigurer reads and updates leaderboard settings (implemented by the aggregator)
+type LeaderboardConfigurer interface {
+	LeaderboardSettings() types.LeaderboardSettings
+	SetLeaderboardSettings(settings types.LeaderboardSettings)
+}
+
+// LeaderboardHandler provides the admin toggle for snapshot leaderboards
+type LeaderboardHandler struct {
+	configurer LeaderboardConfigurer
+	logger     zerolog.Logger
+}
+
+// NewLeaderboardHandler creates a new LeaderboardHandler
+func NewLeaderboardHandler(configurer LeaderboardConfigurer, logger zerolog.Logger) *LeaderboardHandler {
+	return &LeaderboardHandler{
+		configurer: configurer,
+		logger:     logger.With().Str("component", "leaderboards").Logger(),
+	}
+}
+
+// GetSettings handles GET /api/admin/leaderboards
+func (h *LeaderboardHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.configurer.LeaderboardSettings())
+}
+
+// UpdateSettings handles PUT /api/admin/leaderboards
+// Body: {"enabled": true, "size": 10} — omitted fields keep their current value
+func (h *LeaderboardHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	var req types.LeaderboardUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	settings := h.configurer.LeaderboardSettings()
+	if req.Enabled != nil {
+		settings.Enabled = *req.Enabled
+	}
+	if req.Size != nil {
+		if *req.Size <= 0 {
+			apierror.Write(w, r, http.StatusBadRequest, "size must be positive")
+			return
+		}
+		settings.Size = *req.Size
+	}
+	h.configurer.SetLeaderboardSettings(settings)
+
+	h.logger.Info().
+		Bool("enabled", settings.Enabled).
+		Int("size", settings.Size).
+		Msg("leaderboard settings updated via admin")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.configurer.LeaderboardSettings())
+}
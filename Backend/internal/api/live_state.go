@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
+	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/callqueue"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// LiveStateHandler exposes the current tracker and queue state over REST
+type LiveStateHandler struct {
+	tracker      *cache.AgentStateTracker
+	callQueueMgr *callqueue.CallQueueManager
+	logger       zerolog.Logger
+}
+
+// NewLiveStateHandler creates a new LiveStateHandler
+func NewLiveStateHandler(tracker *cache.AgentStateTracker, callQueueMgr *callqueue.CallQueueManager, logger zerolog.Logger) *LiveStateHandler {
+	return &LiveStateHandler{
+		tracker:      tracker,
+		callQueueMgr: callQueueMgr,
+		logger:       logger.With().Str("component", "live_state").Logger(),
+	}
+}
+
+// ListAgents handles GET /api/agents
+// Query params: department, location, team, state, connectionStatus.
+// Agents outside the caller's locations, departments or teams are never returned.
+func (h *LiveStateHandler) ListAgents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := types.AgentFilter{
+		Department:       types.Department(q.Get("department")),
+		Location:         types.Location(q.Get("location")),
+		Team:             q.Get("team"),
+		State:            types.AgentState(q.Get("state")),
+		ConnectionStatus: types.AgentConnectionStatus(q.Get("connectionStatus")),
+	}
+	switch filter.ConnectionStatus {
+	case "", types.StatusConnected, types.StatusStale, types.StatusDisconnected:
+	default:
+		apierror.Write(w, r, http.StatusBadRequest, "connectionStatus must be connected, stale or disconnected")
+		return
+	}
+
+	claims, _ := auth.GetUserFromContext(r.Context())
+
+	agents := make([]types.AgentInfo, 0)
+	for _, agent := range h.tracker.GetAll() {
+		if claims != nil && !claims.IsAgentAllowed(agent.Tenant, agent.Location, agent.Department, agent.Team) {
+			continue
+		}
+		if filter.Matches(agent) {
+			agents = append(agents, agent)
+		}
+	}
+	sort.Slice(agents, func(i, j int) bool { return agents[i].AgentID < agents[j].AgentID })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(types.AgentList{
+		Agents: agents,
+		Count:  len(agents),
+	})
+}
+
+// ListQueues handles GET /api/queues
+// Query params: department (optional).
+// Restricted callers only get queues of departments where they can see agents (same rule as snapshots).
+func (h *LiveStateHandler) ListQueues(w http.ResponseWriter, r *http.Request) {
+	dept := types.Department(r.URL.Query().Get("department"))
+	claims, _ := auth.GetUserFromContext(r.Context())
+
+	var visible map[types.Department]bool
+	if claims != nil && !claims.IsUnrestricted() {
+		visible = make(map[types.Department]bool)
+		for _, agent := range h.tracker.GetAll() {
+			if claims.IsAgentAllowed(agent.Tenant, agent.Location, agent.Department, agent.Team) {
+				visible[agent.Department] = true
+			}
+		}
+	}
+
+	queues := make([]types.VQSnapshot, 0)
+	for d, snapshots := range h.callQueueMgr.GetAllSnapshots() {
+		if (dept != "" && d != dept) || (visible != nil && !visible[d]) {
+			continue
+		}
+		for _, q := range snapshots {
+			if claims == nil || claims.IsTenantAllowed(q.Tenant) {
+				queues = append(queues, q)
+			}
+		}
+	}
+	sort.Slice(queues, func(i, j int) bool { return queues[i].VQ < queues[j].VQ })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(types.QueueList{
+		Queues: queues,
+		Count:  len(queues),
+	})
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// LogLevelHandler reads and changes the global log level at runtime
+type LogLevelHandler struct {
+	logger zerolog.Logger
+}
+
+// NewLogLevelHandler creates a new LogLevelHandler
+func NewLogLevelHandler(logger zerolog.Logger) *LogLevelHandler {
+	return &LogLevelHandler{
+		logger: logger.With().Str("component", "loglevel").Logger(),
+	}
+}
+
+// GetLevel handles GET /api/admin/loglevel
+func (h *LogLevelHandler) GetLevel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(types.LogLevel{Level: zerolog.GlobalLevel().String()})
+}
+
+// SetLevel handles PUT /api/admin/loglevel
+// Body: {"level": "debug"} — one of trace, debug, info, warn, error, fatal, panic, disabled
+func (h *LogLevelHandler) SetLevel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req types.LogLevel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	level, err := zerolog.ParseLevel(req.Level)
+	if err != nil || req.Level == "" {
+		apierror.Write(w, r, http.StatusBadRequest, "invalid level")
+		return
+	}
+
+	previous := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(level)
+
+	// Logged at warn so the change is visible at every level but the most restrictive
+	event := h.logger.Warn().Str("from", previous.String()).Str("to", level.String())
+	if claims, ok := auth.GetUserFromContext(r.Context()); ok {
+		event = event.Str("user", claims.Email)
+	}
+	event.Msg("log level changed")
+
+	json.NewEncoder(w).Encode(types.LogLevel{Level: level.String(), Previous: previous.String()})
+}
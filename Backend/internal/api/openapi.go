@@ -0,0 +1,386 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dennisdiepolder/monti/backend/internal/alerts"
+	"github.com/dennisdiepolder/monti/backend/internal/openapi"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// APIVersion is the info.version of the OpenAPI document
+const APIVersion = "1.0.0"
+
+// simBody is the untyped JSON that AgentSim proxy routes pass through
+type simBody = map[string]interface{}
+
+var (
+	dateQuery = openapi.Param{Name: "date", Description: "Day as YYYY-MM-DD"}
+	deptQuery = openapi.Param{Name: "department", Description: "Filter by department"}
+	managers  = []string{"manager", "admin"}
+	admins    = []string{"admin"}
+)
+
+// StreamTypes are the messages of the /ws frontend WebSocket. They are published as schemas
+// in the OpenAPI document and aliased in the generated client.
+var StreamTypes = []interface{}{
+	types.Snapshot{},
+	types.SnapshotHistory{},
+	types.AgentDetail{},
+	types.ClientCommand{},
+	types.ClientCommandResult{},
+	types.ControlMessage{},
+}
+
+// WSMessages are the messages of the /ws frontend and /ws/agent WebSockets, published as
+// JSON Schema by GET /api/schema/ws
+var WSMessages = []openapi.Message{
+	{Channel: "/ws", Direction: "server", Types: []string{"snapshot"}, Value: types.Snapshot{},
+		Description: "RBAC-filtered live state, once per aggregation tick"},
+	{Channel: "/ws", Direction: "server", Types: []string{"snapshot_history"}, Value: types.SnapshotHistory{},
+		Description: "The latest snapshots, sent once on connect"},
+	{Channel: "/ws", Direction: "server", Types: []string{"agent_detail"}, Value: types.AgentDetail{},
+		Description: "Changes of an agent the client subscribed to"},
+	{Channel: "/ws", Direction: "server", Types: []string{"command_result"}, Value: types.ClientCommandResult{}},
+	{Channel: "/ws", Direction: "server", Types: []string{"control"}, Value: types.ControlMessage{},
+		Description: "Reload or notice pushed by an admin"},
+	{Channel: "/ws", Direction: "client", Types: []string{"subscribe_agent", "unsubscribe_agent", "ack", "hello",
+		"get_snapshot", "get_agent", "get_queue_history"}, Value: types.ClientCommand{},
+		Description: "Commands and on-demand requests; each is answered by a command_result with the same requestId, except ack"},
+
+	{Channel: "/ws/agent", Direction: "client", Types: []string{"register"}, Value: types.AgentRegister{}},
+	{Channel: "/ws/agent", Direction: "client", Types: []string{"heartbeat"}, Value: types.AgentHeartbeat{}},
+	{Channel: "/ws/agent", Direction: "client", Types: []string{"state_change"}, Value: types.AgentStateChange{}},
+	{Channel: "/ws/agent", Direction: "client", Types: []string{"call_complete"}, Value: types.CallComplete{}},
+	{Channel: "/ws/agent", Direction: "client", Types: []string{"call_hold_start", "call_hold_end"}, Value: types.CallHold{},
+		Description: "The caller of an active call put on hold or picked up again; hold time accumulates on the call"},
+	{Channel: "/ws/agent", Direction: "client", Types: []string{"break_request"}, Value: types.BreakRequest{}},
+	{Channel: "/ws/agent", Direction: "server", Types: []string{"ack"}, Value: types.ServerAck{}},
+	{Channel: "/ws/agent", Direction: "server", Types: []string{"call_assign"}, Value: types.CallAssign{}},
+	{Channel: "/ws/agent", Direction: "server", Types: []string{"break_response"}, Value: types.BreakResponse{}},
+	{Channel: "/ws/agent", Direction: "server", Types: []string{"force_end_call"}, Value: types.ForceEndCall{}},
+	{Channel: "/ws/agent", Direction: "server", Types: []string{"force_state"}, Value: types.ForceState{}},
+	{Channel: "/ws/agent", Direction: "server", Types: []string{"force_disconnect"}, Value: types.ForceDisconnect{}},
+	{Channel: "/ws/agent", Direction: "server", Types: []string{"superseded"}, Value: types.ConnectionSuperseded{},
+		Description: "A newer connection of the agent took over"},
+	{Channel: "/ws/agent", Direction: "server", Types: []string{"throttle"}, Value: types.Throttle{},
+		Description: "Send heartbeats every heartbeatInterval seconds while the backend sheds them; 0 lifts it"},
+}
+
+// Operations lists every /api route with its request and response types. It is the single
+// source for the OpenAPI document and for the generated client (go generate ./pkg/client);
+// main warns at startup about /api routes missing here.
+func Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{ID: "GetOpenAPIDocument", Method: http.MethodGet, Path: "/api/openapi.json", Tag: "meta",
+			Summary: "Get this OpenAPI document", Response: map[string]interface{}{}},
+		{ID: "GetWSSchema", Method: http.MethodGet, Path: "/api/schema/ws", Tag: "meta",
+			Summary:     "Get the JSON Schema of the WebSocket messages",
+			Description: "Draft 2020-12 definitions of every /ws and /ws/agent message under $defs; x-messages maps channel, direction and type to its definition.",
+			Response:    "", ResponseType: "application/schema+json"},
+
+		// Live state
+		{ID: "ListAgents", Method: http.MethodGet, Path: "/api/agents", Tag: "agents", Auth: openapi.AuthBearer,
+			Summary: "List agents visible to the caller",
+			Query: []openapi.Param{deptQuery, {Name: "location"}, {Name: "team"}, {Name: "state"},
+				{Name: "connectionStatus", Description: "connected, stale or disconnected"}},
+			Response: types.AgentList{}},
+		{ID: "ListRecentEvents", Method: http.MethodGet, Path: "/api/events", Tag: "agents", Auth: openapi.AuthBearer,
+			Summary:     "List recent raw agent events",
+			Description: "Events of the last EVENT_RETENTION (default 15m), oldest first, limited to agents visible to the caller.",
+			Query: []openapi.Param{{Name: "agentId", Description: "Only this agent's events"},
+				{Name: "since", Description: "Only events at or after this RFC3339 instant"}},
+			Response: types.AgentEventList{}},
+		{ID: "ListQueues", Method: http.MethodGet, Path: "/api/queues", Tag: "agents", Auth: openapi.AuthBearer,
+			Summary: "List virtual queues", Query: []openapi.Param{deptQuery}, Response: types.QueueList{}},
+		{ID: "ListQueueEvents", Method: http.MethodGet, Path: "/api/queues/{vq}/events", Tag: "agents", Auth: openapi.AuthBearer,
+			Summary:     "List a queue's pauses, SL target changes and overflows on a day",
+			Description: "Events of the caller's tenant in time order, to explain SL anomalies in reports. 404 for an unknown VQ.",
+			Query:       []openapi.Param{dateQuery}, Response: []types.QueueEvent{}},
+		{ID: "ListTeams", Method: http.MethodGet, Path: "/api/teams", Tag: "agents", Auth: openapi.AuthBearer,
+			Summary: "List teams visible to the caller", Response: []types.Team{}},
+		{ID: "GetTeamSummary", Method: http.MethodGet, Path: "/api/teams/{team}/summary", Tag: "agents", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "Get a team's live states, today's KPIs, queues and top alerts in one call",
+			Response: types.TeamSummary{}},
+		{ID: "GetBusinessHours", Method: http.MethodGet, Path: "/api/hours", Tag: "agents", Auth: openapi.AuthBearer,
+			Summary: "Get which departments are within business hours", Response: types.BusinessHoursResponse{}},
+		{ID: "GetWallboard", Method: http.MethodGet, Path: "/api/wallboard", Tag: "reports", Auth: openapi.AuthBearer,
+			Summary:     "Get the wallboard summary",
+			Description: "Supports If-None-Match; answers 304 when the board is unchanged and 503 before the first snapshot.",
+			Response:    types.Wallboard{}},
+
+		// History
+		{ID: "GetAgentHistory", Method: http.MethodGet, Path: "/api/agents/{agentId}/history", Tag: "history", Auth: openapi.AuthBearer,
+			Summary: "Get an agent's daily stats", Response: []types.AgentDailyStats{}},
+		{ID: "GetAgentCalls", Method: http.MethodGet, Path: "/api/agents/{agentId}/calls", Tag: "history", Auth: openapi.AuthBearer,
+			Summary:  "Get an agent's calls for a day",
+			Query:    []openapi.Param{{Name: "date", Description: "Day as YYYY-MM-DD", Required: true}},
+			Response: []types.CallRecord{}},
+
+		// Supervisor actions
+		{ID: "ForceEndCall", Method: http.MethodPost, Path: "/api/agents/{agentId}/calls/{callId}/end", Tag: "agents", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "Force-end an agent's active call", Response: types.AgentActionResponse{}},
+		{ID: "LogoutAgent", Method: http.MethodPost, Path: "/api/agents/{agentId}/logout", Tag: "agents", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "Log an agent out", Response: types.AgentActionResponse{}},
+		{ID: "ForceAgentState", Method: http.MethodPost, Path: "/api/agents/{agentId}/state", Tag: "agents", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "Force an agent into a state",
+			Request: types.ForceStateRequest{}, Response: types.AgentActionResponse{}},
+		{ID: "BulkAgentAction", Method: http.MethodPost, Path: "/api/agents/bulk", Tag: "agents", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "Log out or force the state of every agent matching a filter",
+			Request: types.BulkActionRequest{}, Response: types.BulkActionResponse{}},
+
+		// Alerts
+		{ID: "ListAlerts", Method: http.MethodGet, Path: "/api/alerts", Tag: "alerts", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "List live alerts, or the alert history of a day",
+			Query:    []openapi.Param{{Name: "status", Description: "firing, acknowledged or resolved"}, dateQuery},
+			Response: []types.AlertRecord{}},
+		{ID: "GetAgentAlerts", Method: http.MethodGet, Path: "/api/agents/{agentId}/alerts", Tag: "alerts", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "Get an agent's alerts of a day, with a count and duration per rule",
+			Query: []openapi.Param{{Name: "date", Description: "Day as YYYY-MM-DD (default today in the reporting timezone)"}}, Response: types.AgentAlertHistory{}},
+		{ID: "AcknowledgeAlert", Method: http.MethodPost, Path: "/api/alerts/{alertId}/ack", Tag: "alerts", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "Acknowledge a firing alert", Response: types.AlertRecord{}},
+
+		// Schedules, adherence and forecasts
+		{ID: "PutSchedules", Method: http.MethodPut, Path: "/api/schedules", Tag: "reports", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "Replace the schedule intervals of the agent-days in the body",
+			Request: []types.ScheduleInterval{}, Response: types.ScheduleImportResponse{}},
+		{ID: "ImportSchedulesCSV", Method: http.MethodPost, Path: "/api/schedules/import", Tag: "reports", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "Import schedule intervals from CSV",
+			Request: "", RequestType: openapi.ContentCSV, Response: types.ScheduleImportResponse{}},
+		{ID: "GetSchedule", Method: http.MethodGet, Path: "/api/schedules/{agentId}", Tag: "reports", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "Get an agent's schedule for a day", Query: []openapi.Param{dateQuery},
+			Response: []types.ScheduleInterval{}},
+		{ID: "GetAgentAdherence", Method: http.MethodGet, Path: "/api/adherence/agents/{agentId}", Tag: "reports", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "Get an agent's schedule adherence", Query: []openapi.Param{dateQuery},
+			Response: types.AgentAdherence{}},
+		{ID: "GetTeamAdherence", Method: http.MethodGet, Path: "/api/adherence/teams/{team}", Tag: "reports", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "Get a team's schedule adherence", Query: []openapi.Param{dateQuery},
+			Response: types.TeamAdherence{}},
+		{ID: "GetAgentProductivity", Method: http.MethodGet, Path: "/api/reports/agents", Tag: "reports", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "Get per-agent, per-interval calls, AHT, occupancy and adherence of a day",
+			Query: []openapi.Param{dateQuery,
+				{Name: "interval", Description: "Interval width, whole minutes of at least 5m that divide a day (default 30m)"},
+				{Name: "team", Description: "Single team; all visible agents when omitted"}},
+			Response: types.ProductivityReport{}},
+		{ID: "TestGrafanaConnection", Method: http.MethodGet, Path: "/api/grafana/", Tag: "grafana", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "Connection test of the Grafana JSON datasource", Response: map[string]string{}},
+		{ID: "SearchGrafanaMetrics", Method: http.MethodPost, Path: "/api/grafana/search", Tag: "grafana", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "List the metrics a Grafana panel can query",
+			Description: "\"<vq>.<metric>\" for the VQs of the caller's departments, and the alerts table.",
+			Request:     types.GrafanaSearchRequest{}, Response: []string{}},
+		{ID: "QueryGrafana", Method: http.MethodPost, Path: "/api/grafana/query", Tag: "grafana", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "Get time series of interval and daily VQ stats, or the alerts table, for a time range",
+			Description: "Interval metrics (offered, handled, abandoned, aht) cover the last 24h; daily metrics (daily.completed, daily.abandoned, daily.service_level) and alerts at most 92 days.",
+			Request:     types.GrafanaQueryRequest{}, Response: []types.GrafanaQueryResult{}},
+		{ID: "GetGrafanaAnnotations", Method: http.MethodPost, Path: "/api/grafana/annotations", Tag: "grafana", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "Get the alerts fired in a time range as annotations",
+			Description: "The annotation query optionally keeps only alerts of one rule or severity.",
+			Request:     types.GrafanaAnnotationRequest{}, Response: []types.GrafanaAnnotationEvent{}},
+		{ID: "ListCalendar", Method: http.MethodGet, Path: "/api/calendar", Tag: "reports", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "List a day's team meetings and trainings",
+			Query:    []openapi.Param{{Name: "team", Description: "Single team; all visible teams when omitted"}, dateQuery},
+			Response: []types.CalendarEvent{}},
+		{ID: "PutCalendarEvents", Method: http.MethodPost, Path: "/api/calendar", Tag: "reports", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "Add calendar events, replacing events with the same ID",
+			Request: []types.CalendarEvent{}, Response: types.CalendarImportResponse{}},
+		{ID: "ImportCalendarCSV", Method: http.MethodPost, Path: "/api/calendar/import", Tag: "reports", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "Import calendar events from CSV",
+			Request: "", RequestType: openapi.ContentCSV, Response: types.CalendarImportResponse{}},
+		{ID: "ImportCalendarICS", Method: http.MethodPost, Path: "/api/calendar/import/ics", Tag: "reports", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "Import calendar events from iCalendar",
+			Description: "Each VEVENT's team is its X-MONTI-TEAM property, else ?team=. CATEGORIES containing TRAINING make a training.",
+			Query:       []openapi.Param{{Name: "team", Description: "Team of events without X-MONTI-TEAM"}},
+			Request:     "", RequestType: openapi.ContentICS, Response: types.CalendarImportResponse{}},
+		{ID: "DeleteCalendarEvent", Method: http.MethodDelete, Path: "/api/calendar/{eventId}", Tag: "reports", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "Delete a calendar event", Status: http.StatusNoContent},
+		{ID: "GetStaffingForecast", Method: http.MethodGet, Path: "/api/forecast/staffing", Tag: "reports", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "Forecast required staffing per VQ",
+			Query:    []openapi.Param{{Name: "vq", Description: "Single VQ; all VQs when omitted"}, {Name: "interval", Description: "Go duration, 1m to 6h (default 15m)"}},
+			Response: []types.StaffingForecast{}},
+		{ID: "GetBreakStatus", Method: http.MethodGet, Path: "/api/breaks", Tag: "agents", Auth: openapi.AuthBearer,
+			Roles: managers, Summary: "Get each department's breaks against the break caps",
+			Description: "Counts and caps cover whole departments; restricted callers only see departments where they can see agents.",
+			Query:       []openapi.Param{deptQuery}, Response: types.BreakStatus{}},
+
+		// Admin
+		{ID: "GetConfig", Method: http.MethodGet, Path: "/api/admin/config", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Get the effective configuration with secrets redacted", Response: types.ConfigResponse{}},
+		{ID: "GetCapabilities", Method: http.MethodGet, Path: "/api/admin/capabilities", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Get the optional features this deployment has",
+			Description: "Without simulation (AGENTSIM_URL unset) the sim and call config endpoints answer 503 sim_not_configured.",
+			Response:    types.Capabilities{}},
+		{ID: "GetLogLevel", Method: http.MethodGet, Path: "/api/admin/loglevel", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Get the global log level", Response: types.LogLevel{}},
+		{ID: "SetLogLevel", Method: http.MethodPut, Path: "/api/admin/loglevel", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Change the global log level at runtime", Request: types.LogLevel{}, Response: types.LogLevel{}},
+		{ID: "GetSimStatus", Method: http.MethodGet, Path: "/api/admin/sim/status", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Get the AgentSim status", Response: types.SimStatus{}},
+		{ID: "StartSim", Method: http.MethodPost, Path: "/api/admin/sim/start", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Start the simulation", Request: types.SimScaleRequest{}, Response: simBody{}},
+		{ID: "StopSim", Method: http.MethodPost, Path: "/api/admin/sim/stop", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Stop the simulation", Response: simBody{}},
+		{ID: "ScaleSim", Method: http.MethodPost, Path: "/api/admin/sim/scale", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Change the number of simulated agents", Request: types.SimScaleRequest{}, Response: simBody{}},
+		{ID: "ListSimPresets", Method: http.MethodGet, Path: "/api/admin/sim/presets", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "List the saved sim presets", Response: []types.SimPreset{}},
+		{ID: "SaveSimPreset", Method: http.MethodPost, Path: "/api/admin/sim/presets", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Create or replace a sim preset by name",
+			Description: "A preset combines an agent count, call rates per department, a peak hour factor and a scenario script (start, scale, stop, inject or wait steps). Zero and unlisted values are left unchanged when applied.",
+			Request:     types.SimPreset{}, Response: types.SimPreset{}},
+		{ID: "DeleteSimPreset", Method: http.MethodDelete, Path: "/api/admin/sim/presets/{name}", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Delete a sim preset", Status: http.StatusNoContent},
+		{ID: "ApplySimPreset", Method: http.MethodPost, Path: "/api/admin/sim/presets/{name}/apply", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Configure AgentSim from a sim preset",
+			Description: "Sends the call config, starts or scales the simulation to the preset's agents, then runs its scenario script in the background, cancelling the script of the previously applied preset. 503 sim_not_configured without AgentSim, 502 when AgentSim rejects a change.",
+			Response:    types.SimPresetApplyResponse{}},
+		{ID: "GetSimSchedule", Method: http.MethodGet, Path: "/api/admin/sim/schedule", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Get the sim schedule with its next and latest run",
+			Description: "404 without SIM_SCHEDULE_FILE.", Response: types.SimScheduleStatus{}},
+		{ID: "GetCallConfig", Method: http.MethodGet, Path: "/api/admin/calls/config", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Get the AgentSim call generator config", Response: simBody{}},
+		{ID: "UpdateCallConfig", Method: http.MethodPut, Path: "/api/admin/calls/config", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Update the AgentSim call generator config", Request: simBody{}, Response: simBody{}},
+		{ID: "InjectCalls", Method: http.MethodPost, Path: "/api/admin/calls/inject", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Enqueue calls directly", Request: types.InjectCallsRequest{}, Response: types.InjectCallsResponse{}},
+		{ID: "WipeAllCalls", Method: http.MethodDelete, Path: "/api/admin/calls/all", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Clear every queued and active call", Response: types.WipeCallsResponse{}},
+		{ID: "ResetMemory", Method: http.MethodPost, Path: "/api/admin/reset/memory", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Clear in-memory agents and calls", Response: types.ResetResponse{}},
+		{ID: "ReplayEventLog", Method: http.MethodPost, Path: "/api/admin/eventlog/replay", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Rebuild agents and calls from the event log",
+			Description: "Clears in-memory agents and calls, then re-applies the EVENT_LOG_FILE log in order (up to until, if set) with routing paused. 404 when the event log is disabled, 409 while another replay runs.",
+			Request:     types.EventLogReplayRequest{}, Response: types.EventLogReplayResponse{}},
+		{ID: "BackfillDailyStats", Method: http.MethodPost, Path: "/api/admin/backfill/daily-stats", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Recompute the agent and VQ daily stats of a range of days from the call records",
+			Description: "Days are inclusive, at most 92. VQ business days that haven't ended are skipped, and abandoned counts are kept since abandoned calls have no call records. 409 while another backfill runs.",
+			Request:     types.BackfillRequest{}, Response: types.BackfillResponse{}},
+		{ID: "GetShadowRouting", Method: http.MethodGet, Path: "/api/admin/routing/shadow", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Compare the shadow routing strategy's choices with the active strategy's",
+			Description: "404 when no shadow strategy is evaluated.",
+			Response:    types.ShadowRoutingReport{}},
+		{ID: "SetShadowRouting", Method: http.MethodPut, Path: "/api/admin/routing/shadow", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Start evaluating a shadow routing strategy",
+			Description: "Restarts the evaluation with the given strategy; an empty strategy stops it and answers 204.",
+			Request:     types.ShadowRoutingRequest{}, Response: types.ShadowRoutingReport{}},
+		{ID: "DryRunRouting", Method: http.MethodPost, Path: "/api/admin/routing/dry-run", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Show which agents would take hypothetical calls",
+			Description: "Routes the given waiting calls once to the given agents with the active strategy, chat limit, reserves and overflow rules. Live calls, agents and metrics are untouched.",
+			Request:     types.RoutingDryRunRequest{}, Response: types.RoutingDryRunResponse{}},
+		{ID: "PauseQueue", Method: http.MethodPost, Path: "/api/admin/queues/{vq}/pause", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Stop routing a queue's calls",
+			Description: "Calls keep queueing and are not overflowed until the queue is resumed. Recorded as a queue event. 404 when the tenant has no such queue yet.",
+			Response:    types.QueuePauseResponse{}},
+		{ID: "ResumeQueue", Method: http.MethodPost, Path: "/api/admin/queues/{vq}/resume", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Resume routing a paused queue", Response: types.QueuePauseResponse{}},
+		{ID: "SetQueueSLTarget", Method: http.MethodPut, Path: "/api/admin/queues/{vq}/sl", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Change a queue's SL target and threshold",
+			Description: "Answers counted so far keep the threshold they were counted against. Recorded as a queue event.",
+			Request:     types.SLTargetRequest{}, Response: types.ServiceLevel{}},
+		{ID: "ListWSClients", Method: http.MethodGet, Path: "/api/admin/ws/clients", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "List connected dashboard clients",
+			Description: "User, role, allowed locations, connect time, dropped messages and the last acknowledged snapshot of every WebSocket client. lag counts snapshots broadcast since that acknowledgement.",
+			Response:    types.WSClientsResponse{}},
+		{ID: "SendWSControl", Method: http.MethodPost, Path: "/api/admin/ws/control", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Push a control message to dashboard clients",
+			Description: "Sends a reload or notice to the clients listed in clientIds or holding one of roles, or to every client when both are empty.",
+			Request:     types.ControlMessageRequest{}, Response: types.ControlMessageResponse{}},
+		{ID: "ListAnnouncements", Method: http.MethodGet, Path: "/api/admin/announcements", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "List active announcements", Response: []types.Announcement{}},
+		{ID: "PublishAnnouncement", Method: http.MethodPost, Path: "/api/admin/announcements", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Publish an announcement to dashboards", Status: http.StatusCreated,
+			Description: "The announcement is embedded in every snapshot until expiresAt or until withdrawn. Dashboards only get it when they see one of its departments and locations; empty lists address all.",
+			Request:     types.AnnouncementRequest{}, Response: types.Announcement{}},
+		{ID: "WithdrawAnnouncement", Method: http.MethodDelete, Path: "/api/admin/announcements/{announcementId}", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Withdraw an announcement", Status: http.StatusNoContent},
+		{ID: "WipeStorage", Method: http.MethodDelete, Path: "/api/admin/reset/dynamo", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Delete all persisted history", Response: types.MessageResponse{}},
+		{ID: "LogoffAll", Method: http.MethodPost, Path: "/api/admin/agents/logoff-all", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Log off every agent", Response: types.ResetResponse{}},
+		{ID: "ListRoster", Method: http.MethodGet, Path: "/api/admin/roster", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "List the roster", Response: []types.RosterRecord{}},
+		{ID: "AddRosterAgent", Method: http.MethodPost, Path: "/api/admin/roster", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Add an agent to the roster", Status: http.StatusCreated,
+			Request: types.RosterRecord{}, Response: types.RosterRecord{}},
+		{ID: "ImportRosterCSV", Method: http.MethodPost, Path: "/api/admin/roster/import", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Import the roster from CSV",
+			Request: "", RequestType: openapi.ContentCSV, Response: types.RosterImportResponse{}},
+		{ID: "ExportRosterCSV", Method: http.MethodGet, Path: "/api/admin/roster/export", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Export the roster as CSV", Response: "", ResponseType: openapi.ContentCSV},
+		{ID: "UpdateRosterAgent", Method: http.MethodPut, Path: "/api/admin/roster/{agentId}", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Create or update a roster agent",
+			Request: types.RosterRecord{}, Response: types.RosterRecord{}},
+		{ID: "RetireRosterAgent", Method: http.MethodDelete, Path: "/api/admin/roster/{agentId}", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Retire a roster agent", Status: http.StatusNoContent},
+		{ID: "PutTeam", Method: http.MethodPut, Path: "/api/admin/teams/{team}", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Create or replace a team", Request: types.Team{}, Response: types.Team{}},
+		{ID: "DeleteTeam", Method: http.MethodDelete, Path: "/api/admin/teams/{team}", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Delete a team", Status: http.StatusNoContent},
+		{ID: "GetLeaderboardSettings", Method: http.MethodGet, Path: "/api/admin/leaderboards", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Get the leaderboard settings", Response: types.LeaderboardSettings{}},
+		{ID: "UpdateLeaderboardSettings", Method: http.MethodPut, Path: "/api/admin/leaderboards", Tag: "admin", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Update the leaderboard settings",
+			Request: types.LeaderboardUpdate{}, Response: types.LeaderboardSettings{}},
+		{ID: "ListAlertRules", Method: http.MethodGet, Path: "/api/admin/alert-rules", Tag: "alerts", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "List alert rules", Response: []alerts.Rule{}},
+		{ID: "GetAlertRule", Method: http.MethodGet, Path: "/api/admin/alert-rules/{ruleId}", Tag: "alerts", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Get an alert rule", Response: alerts.Rule{}},
+		{ID: "PutAlertRule", Method: http.MethodPut, Path: "/api/admin/alert-rules/{ruleId}", Tag: "alerts", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Create or replace an alert rule", Request: alerts.Rule{}, Response: alerts.Rule{}},
+		{ID: "DeleteAlertRule", Method: http.MethodDelete, Path: "/api/admin/alert-rules/{ruleId}", Tag: "alerts", Auth: openapi.AuthBearer,
+			Roles: admins, Summary: "Delete an alert rule", Status: http.StatusNoContent},
+	}
+}
+
+// OpenAPIHandler serves the OpenAPI document built from Operations
+type OpenAPIHandler struct {
+	document []byte
+}
+
+// NewOpenAPIHandler builds and marshals the document once
+func NewOpenAPIHandler(logger zerolog.Logger) (*OpenAPIHandler, error) {
+	doc, err := openapi.Document("MONTI Backend API", APIVersion, Operations(), StreamTypes...)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	logger = logger.With().Str("component", "openapi").Logger()
+	logger.Debug().Int("operations", len(Operations())).Int("bytes", len(data)).Msg("OpenAPI document built")
+	return &OpenAPIHandler{document: data}, nil
+}
+
+// ServeHTTP handles GET /api/openapi.json
+func (h *OpenAPIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Write(h.document)
+}
+
+// WSSchemaHandler serves the JSON Schema of the WebSocket messages built from WSMessages
+type WSSchemaHandler struct {
+	document []byte
+}
+
+// NewWSSchemaHandler builds and marshals the schema once
+func NewWSSchemaHandler() (*WSSchemaHandler, error) {
+	doc, err := openapi.MessageSchema("MONTI WebSocket messages", APIVersion, WSMessages)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &WSSchemaHandler{document: data}, nil
+}
+
+// ServeHTTP handles GET /api/schema/ws
+func (h *WSSchemaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Write(h.document)
+}
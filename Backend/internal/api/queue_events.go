@@ -0,0 +1,151 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
+	"github.com/dennisdiepolder/monti/backend/internal/callqueue"
+	"github.com/dennisdiepolder/monti/backend/internal/storage"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+)
+
+// QueueEventHandler lets admins pause queues and change their SL targets, and lists the
+// operational events of a queue
+type QueueEventHandler struct {
+	callQueue *callqueue.CallQueueManager
+	store     storage.Store
+	logger    zerolog.Logger
+}
+
+// NewQueueEventHandler creates a new QueueEventHandler
+func NewQueueEventHandler(callQueue *callqueue.CallQueueManager, store storage.Store, logger zerolog.Logger) *QueueEventHandler {
+	return &QueueEventHandler{
+		callQueue: callQueue,
+		store:     store,
+		logger:    logger.With().Str("component", "queue_events").Logger(),
+	}
+}
+
+// queueParam returns the VQ of the path with its configuration, writing a 404 for an unknown VQ
+func (h *QueueEventHandler) queueParam(w http.ResponseWriter, r *http.Request) (callqueue.VQConfig, bool) {
+	vq := types.VQName(chi.URLParam(r, "vq"))
+	cfg, ok := h.callQueue.VQConfig(vq)
+	if !ok {
+		apierror.Write(w, r, http.StatusNotFound, "unknown queue")
+		return callqueue.VQConfig{}, false
+	}
+	return cfg, true
+}
+
+// ListEvents handles GET /api/queues/{vq}/events?date=YYYY-MM-DD
+// Returns the pauses, SL target changes and overflows of the VQ on a business day in time order,
+// for the caller's tenant.
+func (h *QueueEventHandler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	cfg, ok := h.queueParam(w, r)
+	if !ok {
+		return
+	}
+	date, ok := dateParam(w, r)
+	if !ok {
+		return
+	}
+
+	claims, _ := auth.GetUserFromContext(r.Context())
+	tenant := ""
+	if claims != nil {
+		if !claims.IsDepartmentAllowed(cfg.Department) {
+			apierror.Write(w, r, http.StatusForbidden, "queue is outside your scope")
+			return
+		}
+		tenant = claims.Tenant
+	}
+	events, err := h.store.GetQueueEvents(types.TenantKey(tenant, date), cfg.Name)
+	if err != nil {
+		h.logger.Error().Err(err).Str("vq", string(cfg.Name)).Str("date", date).Msg("failed to get queue events")
+		apierror.Write(w, r, http.StatusInternalServerError, "failed to retrieve queue events")
+		return
+	}
+	if events == nil {
+		events = []types.QueueEvent{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
+
+// Pause handles POST /api/admin/queues/{vq}/pause: waiting calls of the VQ stay queued but are
+// no longer routed until it is resumed
+func (h *QueueEventHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	h.setPaused(w, r, true)
+}
+
+// Resume handles POST /api/admin/queues/{vq}/resume
+func (h *QueueEventHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	h.setPaused(w, r, false)
+}
+
+func (h *QueueEventHandler) setPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	cfg, ok := h.queueParam(w, r)
+	if !ok {
+		return
+	}
+	claims, _ := auth.GetUserFromContext(r.Context())
+	tenant, actor := "", ""
+	if claims != nil {
+		tenant, actor = claims.Tenant, claims.Email
+	}
+	if err := h.callQueue.PauseQueue(tenant, cfg.Name, paused, actor); err != nil {
+		h.writeQueueError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(types.QueuePauseResponse{VQ: cfg.Name, Paused: paused})
+}
+
+// SetSLTarget handles PUT /api/admin/queues/{vq}/sl: changes the SL target and threshold of
+// the VQ and returns its service level
+func (h *QueueEventHandler) SetSLTarget(w http.ResponseWriter, r *http.Request) {
+	cfg, ok := h.queueParam(w, r)
+	if !ok {
+		return
+	}
+	var req types.SLTargetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.WriteValidation(w, r, "invalid JSON body", nil)
+		return
+	}
+	if fields := req.Validate(); len(fields) > 0 {
+		apierror.WriteValidation(w, r, "invalid SL target", fields)
+		return
+	}
+
+	claims, _ := auth.GetUserFromContext(r.Context())
+	tenant, actor := "", ""
+	if claims != nil {
+		tenant, actor = claims.Tenant, claims.Email
+	}
+	sl, err := h.callQueue.SetSLTarget(tenant, cfg.Name, req.Target, req.ThresholdSecs, actor)
+	if err != nil {
+		h.writeQueueError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sl)
+}
+
+// writeQueueError writes a 404 for a VQ the tenant has no queue for yet, a 500 otherwise
+func (h *QueueEventHandler) writeQueueError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, callqueue.ErrUnknownQueue) {
+		apierror.Write(w, r, http.StatusNotFound, "queue has no calls in your tenant yet")
+		return
+	}
+	h.logger.Error().Err(err).Msg("failed to change queue")
+	apierror.Write(w, r, http.StatusInternalServerError, "failed to change queue")
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/adherence"
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
+	"github.com/dennisdiepolder/monti/backend/internal/storage"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// minReportInterval is the finest interval of the productivity report
+const minReportInterval = 5 * time.Minute
+
+// ReportHandler provides workforce management reports built from persisted timelines and
+// call records
+type ReportHandler struct {
+	service *adherence.Service
+	store   storage.Store
+	logger  zerolog.Logger
+}
+
+// NewReportHandler creates a new ReportHandler
+func NewReportHandler(service *adherence.Service, store storage.Store, logger zerolog.Logger) *ReportHandler {
+	return &ReportHandler{
+		service: service,
+		store:   store,
+		logger:  logger.With().Str("component", "report_handler").Logger(),
+	}
+}
+
+// GetAgentProductivity handles GET /api/reports/agents?date=YYYY-MM-DD&interval=30m&team=
+// Returns per-agent, per-interval calls handled, AHT, occupancy and adherence for the agents
+// within the caller's scope, optionally of one team.
+func (h *ReportHandler) GetAgentProductivity(w http.ResponseWriter, r *http.Request) {
+	date, ok := dateParam(w, r)
+	if !ok {
+		return
+	}
+	interval := 30 * time.Minute
+	if s := r.URL.Query().Get("interval"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < minReportInterval || d%time.Minute != 0 || (24*time.Hour)%d != 0 {
+			apierror.Write(w, r, http.StatusBadRequest, "interval must be a whole number of minutes of at least 5m that divides a day")
+			return
+		}
+		interval = d
+	}
+	team := r.URL.Query().Get("team")
+
+	claims, _ := auth.GetUserFromContext(r.Context())
+	include := func(agent types.AgentInfo) bool {
+		return (team == "" || agent.Team == team) &&
+			(claims == nil || claims.IsAgentAllowed(agent.Tenant, agent.Location, agent.Department, agent.Team))
+	}
+
+	// Call records of other tenants are partitioned under tenant-prefixed date keys
+	tenant := ""
+	if claims != nil {
+		tenant = claims.Tenant
+	}
+	calls, err := h.store.GetCallRecords(types.TenantKey(tenant, date))
+	if err != nil {
+		h.logger.Error().Err(err).Str("date", date).Msg("failed to get call records")
+		apierror.Write(w, r, http.StatusInternalServerError, "failed to retrieve calls")
+		return
+	}
+
+	report, err := h.service.Productivity(date, interval, calls, time.Now(), include)
+	if err != nil {
+		h.logger.Error().Err(err).Str("date", date).Msg("failed to build productivity report")
+		apierror.Write(w, r, http.StatusInternalServerError, "failed to build report")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -2,10 +2,20 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"time"
 
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
 	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/ingestion"
+	"github.com/dennisdiepolder/monti/backend/internal/storage"
+	"github.com/dennisdiepolder/monti/backend/internal/teams"
 	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog"
 )
 
@@ -17,25 +27,61 @@ type RosterEntry struct {
 	Team       string          `json:"team"`
 }
 
-// RosterHandler handles the roster registration endpoint
+// maxRosterFieldErrors caps how many field errors a rejected roster reports
+const maxRosterFieldErrors = 50
+
+// RosterHandler handles the bulk roster registration endpoint and the managed roster API
 type RosterHandler struct {
-	tracker *cache.AgentStateTracker
-	logger  zerolog.Logger
+	tracker   *cache.AgentStateTracker
+	processor *ingestion.DefaultProcessor
+	store     storage.Store
+	teams     *teams.Directory
+	logger    zerolog.Logger
 }
 
 // NewRosterHandler creates a new RosterHandler
-func NewRosterHandler(tracker *cache.AgentStateTracker, logger zerolog.Logger) *RosterHandler {
+func NewRosterHandler(tracker *cache.AgentStateTracker, processor *ingestion.DefaultProcessor, store storage.Store, directory *teams.Directory, logger zerolog.Logger) *RosterHandler {
 	return &RosterHandler{
-		tracker: tracker,
-		logger:  logger.With().Str("component", "roster").Logger(),
+		tracker:   tracker,
+		processor: processor,
+		store:     store,
+		teams:     directory,
+		logger:    logger.With().Str("component", "roster").Logger(),
 	}
 }
 
+// Restore loads the persisted managed roster into the tracker (called at startup)
+func (h *RosterHandler) Restore() (int, error) {
+	records, err := h.store.GetRoster()
+	if err != nil {
+		return 0, err
+	}
+	for _, rec := range records {
+		h.tracker.UpsertRosterAgent(rec)
+	}
+	return len(records), nil
+}
+
 // HandleRoster handles POST /internal/agents/roster
 func (h *RosterHandler) HandleRoster(w http.ResponseWriter, r *http.Request) {
 	var roster []RosterEntry
 	if err := json.NewDecoder(r.Body).Decode(&roster); err != nil {
-		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		apierror.WriteValidation(w, r, "invalid JSON", nil)
+		return
+	}
+
+	// Reject the whole roster if any entry is invalid so junk never reaches the tracker
+	var errs []types.FieldError
+	for i, entry := range roster {
+		errs = append(errs, types.ValidateRosterEntry(fmt.Sprintf("[%d].", i), entry.AgentID, entry.Department, entry.Location)...)
+		if len(errs) >= maxRosterFieldErrors {
+			errs = errs[:maxRosterFieldErrors]
+			break
+		}
+	}
+	if len(errs) > 0 {
+		h.logger.Warn().Int("entries", len(roster)).Int("errors", len(errs)).Msg("rejected invalid roster")
+		apierror.WriteValidation(w, r, "invalid roster", errs)
 		return
 	}
 
@@ -51,3 +97,121 @@ func (h *RosterHandler) HandleRoster(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]int{"registered": registered})
 }
+
+// ListRoster handles GET /api/admin/roster
+// Returns the managed roster entries that have been persisted.
+func (h *RosterHandler) ListRoster(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	records, err := h.store.GetRoster()
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to get roster")
+		apierror.Write(w, r, http.StatusInternalServerError, "failed to retrieve roster")
+		return
+	}
+	if records == nil {
+		records = []types.RosterRecord{}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].AgentID < records[j].AgentID })
+	json.NewEncoder(w).Encode(records)
+}
+
+// AddAgent handles POST /api/admin/roster
+// Body: {"agentId", "department", "location", "team", "skills"}. Returns 409 if the agent exists.
+func (h *RosterHandler) AddAgent(w http.ResponseWriter, r *http.Request) {
+	rec, ok := h.decodeRecord(w, r, "")
+	if !ok {
+		return
+	}
+	if _, exists := h.tracker.Get(rec.AgentID); exists {
+		apierror.Write(w, r, http.StatusConflict, "agent already exists")
+		return
+	}
+	h.apply(w, r, rec, http.StatusCreated)
+}
+
+// UpdateAgent handles PUT /api/admin/roster/{agentId}
+// Creates the agent if unknown; otherwise updates department, location, team and skills
+// while keeping its live state.
+func (h *RosterHandler) UpdateAgent(w http.ResponseWriter, r *http.Request) {
+	rec, ok := h.decodeRecord(w, r, chi.URLParam(r, "agentId"))
+	if !ok {
+		return
+	}
+	h.apply(w, r, rec, http.StatusOK)
+}
+
+// RetireAgent handles DELETE /api/admin/roster/{agentId}
+// Connected agents are refused with 409 and must be logged off first.
+func (h *RosterHandler) RetireAgent(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	agentID := chi.URLParam(r, "agentId")
+
+	switch err := h.tracker.RetireAgent(agentID); {
+	case errors.Is(err, cache.ErrAgentNotFound):
+		apierror.Write(w, r, http.StatusNotFound, "agent not found")
+		return
+	case errors.Is(err, cache.ErrAgentConnected):
+		apierror.Write(w, r, http.StatusConflict, "agent is connected, log off first")
+		return
+	}
+	h.processor.ForgetAgent(agentID)
+
+	if err := h.store.DeleteRosterRecord(agentID); err != nil {
+		h.logger.Error().Err(err).Str("agent_id", agentID).Msg("failed to delete roster record")
+		apierror.Write(w, r, http.StatusInternalServerError, "failed to persist roster change")
+		return
+	}
+
+	h.logChange(r, "agent retired", agentID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeRecord parses and validates a managed roster entry. pathID, when set, overrides
+// the body's agentId.
+func (h *RosterHandler) decodeRecord(w http.ResponseWriter, r *http.Request, pathID string) (types.RosterRecord, bool) {
+	var rec types.RosterRecord
+	if err := json.NewDecoder(r.Body).Decode(&rec); err != nil {
+		apierror.WriteValidation(w, r, "invalid JSON", nil)
+		return rec, false
+	}
+	if pathID != "" {
+		rec.AgentID = pathID
+	}
+	errs := append(rec.Validate(), teamFieldErrors(h.teams, "", rec)...)
+	if len(errs) > 0 {
+		apierror.WriteValidation(w, r, "invalid roster entry", errs)
+		return rec, false
+	}
+	return rec, true
+}
+
+// apply persists a roster entry, reflects it in the tracker and writes it back with status
+func (h *RosterHandler) apply(w http.ResponseWriter, r *http.Request, rec types.RosterRecord, status int) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rec.UpdatedAt = time.Now().UTC()
+	if err := h.store.SaveRosterRecord(rec); err != nil {
+		h.logger.Error().Err(err).Str("agent_id", rec.AgentID).Msg("failed to save roster record")
+		apierror.Write(w, r, http.StatusInternalServerError, "failed to persist roster change")
+		return
+	}
+
+	if h.tracker.UpsertRosterAgent(rec) {
+		h.logChange(r, "agent added", rec.AgentID)
+	} else {
+		h.logChange(r, "agent updated", rec.AgentID)
+	}
+
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(rec)
+}
+
+func (h *RosterHandler) logChange(r *http.Request, msg, agentID string) {
+	event := h.logger.Info().Str("agent_id", agentID)
+	if claims, ok := auth.GetUserFromContext(r.Context()); ok {
+		event = event.Str("user", claims.Email)
+	}
+	event.Msg(msg)
+}
+
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// rosterCSVHeader is the column order written by export; import accepts any order
+var rosterCSVHeader = []string{"agentId", "department", "location", "team", "skills"}
+
+// rosterSkillSeparator joins skills within the skills column; a skill may carry its
+// proficiency after rosterLevelSeparator ("tech_l2:5;sales")
+const (
+	rosterSkillSeparator = ";"
+	rosterLevelSeparator = ":"
+)
+
+// ImportRosterCSV handles POST /api/admin/roster/import
+// Body: CSV with header agentId,department,location,team[,skills] (skills separated by ";").
+// The import is all-or-nothing: any invalid row rejects the file with per-row field errors.
+func (h *RosterHandler) ImportRosterCSV(w http.ResponseWriter, r *http.Request) {
+	records, errs, err := parseRosterCSV(r.Body, func(prefix string, rec types.RosterRecord) []types.FieldError {
+		return teamFieldErrors(h.teams, prefix, rec)
+	})
+	if err != nil {
+		apierror.WriteValidation(w, r, err.Error(), nil)
+		return
+	}
+	if len(errs) > 0 {
+		h.logger.Warn().Int("rows", len(records)).Int("errors", len(errs)).Msg("rejected invalid roster CSV")
+		apierror.WriteValidation(w, r, "invalid roster CSV", errs)
+		return
+	}
+
+	now := time.Now().UTC()
+	added, updated := 0, 0
+	for _, rec := range records {
+		rec.UpdatedAt = now
+		if err := h.store.SaveRosterRecord(rec); err != nil {
+			h.logger.Error().Err(err).Str("agent_id", rec.AgentID).Msg("failed to save roster record")
+			apierror.Write(w, r, http.StatusInternalServerError, fmt.Sprintf("failed to persist roster change after %d agents", added+updated))
+			return
+		}
+		if h.tracker.UpsertRosterAgent(rec) {
+			added++
+		} else {
+			updated++
+		}
+	}
+
+	event := h.logger.Info().Int("added", added).Int("updated", updated)
+	if claims, ok := auth.GetUserFromContext(r.Context()); ok {
+		event = event.Str("user", claims.Email)
+	}
+	event.Msg("roster imported")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(types.RosterImportResponse{
+		Imported: len(records),
+		Added:    added,
+		Updated:  updated,
+	})
+}
+
+// ExportRosterCSV handles GET /api/admin/roster/export
+// Writes every agent known to the tracker in the import format.
+func (h *RosterHandler) ExportRosterCSV(w http.ResponseWriter, r *http.Request) {
+	agents := h.tracker.GetAllAgents()
+	sort.Slice(agents, func(i, j int) bool { return agents[i].AgentID < agents[j].AgentID })
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="roster.csv"`)
+
+	cw := csv.NewWriter(w)
+	cw.Write(rosterCSVHeader)
+	for _, a := range agents {
+		cw.Write([]string{
+			a.AgentID,
+			string(a.Department),
+			string(a.Location),
+			a.Team,
+			formatSkills(a.Skills, a.Proficiency),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		h.logger.Error().Err(err).Msg("failed to write roster CSV")
+	}
+}
+
+// parseRosterCSV reads roster rows and validates each one, plus any extra checks. Field errors
+// are prefixed with the CSV line ("line 3.department"); the returned error is only set for
+// unreadable input.
+func parseRosterCSV(r io.Reader, extra func(prefix string, rec types.RosterRecord) []types.FieldError) ([]types.RosterRecord, []types.FieldError, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"agentid", "department", "location", "team"} {
+		if _, ok := cols[name]; !ok {
+			return nil, nil, fmt.Errorf("missing CSV column %q", name)
+		}
+	}
+	skillsCol, hasSkills := cols["skills"]
+
+	var (
+		records []types.RosterRecord
+		errs    []types.FieldError
+	)
+	seen := make(map[string]int)
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		rec := types.RosterRecord{
+			AgentID:    strings.TrimSpace(row[cols["agentid"]]),
+			Department: types.Department(strings.ToLower(strings.TrimSpace(row[cols["department"]]))),
+			Location:   types.Location(strings.ToLower(strings.TrimSpace(row[cols["location"]]))),
+			Team:       strings.TrimSpace(row[cols["team"]]),
+		}
+		prefix := fmt.Sprintf("line %d.", line)
+		if hasSkills {
+			for _, entry := range strings.Split(row[skillsCol], rosterSkillSeparator) {
+				skill, level, rated := strings.Cut(strings.TrimSpace(entry), rosterLevelSeparator)
+				if skill = strings.TrimSpace(skill); skill == "" {
+					continue
+				}
+				rec.Skills = append(rec.Skills, skill)
+				if !rated {
+					continue
+				}
+				n, err := strconv.Atoi(strings.TrimSpace(level))
+				if err != nil {
+					errs = append(errs, types.FieldError{Field: prefix + "proficiency." + skill, Message: "must be a whole number"})
+					continue
+				}
+				if rec.Proficiency == nil {
+					rec.Proficiency = make(map[string]int)
+				}
+				rec.Proficiency[skill] = n
+			}
+		}
+
+		for _, fe := range rec.Validate() {
+			fe.Field = prefix + fe.Field
+			errs = append(errs, fe)
+		}
+		errs = append(errs, extra(prefix, rec)...)
+		if first, dup := seen[rec.AgentID]; dup && rec.AgentID != "" {
+			errs = append(errs, types.FieldError{Field: prefix + "agentId", Message: fmt.Sprintf("duplicate of line %d", first)})
+		} else {
+			seen[rec.AgentID] = line
+		}
+		if len(errs) >= maxRosterFieldErrors {
+			return records, errs[:maxRosterFieldErrors], nil
+		}
+		records = append(records, rec)
+	}
+	return records, errs, nil
+}
+
+// formatSkills writes the skills column, with the proficiency of rated skills
+func formatSkills(skills []string, proficiency map[string]int) string {
+	out := make([]string, len(skills))
+	for i, skill := range skills {
+		out[i] = skill
+		if level, ok := proficiency[skill]; ok {
+			out[i] += rosterLevelSeparator + strconv.Itoa(level)
+		}
+	}
+	return strings.Join(out, rosterSkillSeparator)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/callqueue"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// RoutingHandler lets admins evaluate routing strategies and debug routing decisions
+type RoutingHandler struct {
+	callQueue *callqueue.CallQueueManager
+	logger    zerolog.Logger
+}
+
+// NewRoutingHandler creates a new RoutingHandler
+func NewRoutingHandler(callQueue *callqueue.CallQueueManager, logger zerolog.Logger) *RoutingHandler {
+	return &RoutingHandler{
+		callQueue: callQueue,
+		logger:    logger.With().Str("component", "routing").Logger(),
+	}
+}
+
+// GetShadow handles GET /api/admin/routing/shadow: how the shadow strategy's choices differ
+// from the active strategy's (404 without a shadow strategy)
+func (h *RoutingHandler) GetShadow(w http.ResponseWriter, r *http.Request) {
+	report, ok := h.callQueue.ShadowReport()
+	if !ok {
+		apierror.Write(w, r, http.StatusNotFound, "no shadow strategy is evaluated (set ROUTING_SHADOW_STRATEGY or PUT one)")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// SetShadow handles PUT /api/admin/routing/shadow: starts a fresh evaluation of a strategy,
+// or stops evaluating with an empty strategy
+func (h *RoutingHandler) SetShadow(w http.ResponseWriter, r *http.Request) {
+	var req types.ShadowRoutingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.WriteValidation(w, r, "invalid JSON body", nil)
+		return
+	}
+	if err := h.callQueue.SetShadowStrategy(req.Strategy); err != nil {
+		apierror.WriteValidation(w, r, "invalid shadow strategy", []types.FieldError{{Field: "strategy", Message: err.Error()}})
+		return
+	}
+	h.logger.Info().Str("shadow", req.Strategy).Msg("shadow routing strategy set")
+
+	report, ok := h.callQueue.ShadowReport()
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// DryRun handles POST /api/admin/routing/dry-run: routes a hypothetical queue state to
+// hypothetical agents with the live routing configuration, without touching live calls
+func (h *RoutingHandler) DryRun(w http.ResponseWriter, r *http.Request) {
+	var req types.RoutingDryRunRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.WriteValidation(w, r, "invalid JSON body", nil)
+		return
+	}
+	if fields := req.Validate(); len(fields) > 0 {
+		apierror.WriteValidation(w, r, "invalid dry run", fields)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.callQueue.DryRun(req))
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/simpresets"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+)
+
+// SimPresetHandler saves sim presets, applies them to AgentSim and reports the sim schedule
+type SimPresetHandler struct {
+	library   *simpresets.Library
+	runner    *simpresets.Runner    // nil without AgentSim
+	scheduler *simpresets.Scheduler // nil without SIM_SCHEDULE_FILE
+	logger    zerolog.Logger
+}
+
+// NewSimPresetHandler creates a new SimPresetHandler; runner is nil when there is no AgentSim,
+// scheduler when there is no sim schedule
+func NewSimPresetHandler(library *simpresets.Library, runner *simpresets.Runner, scheduler *simpresets.Scheduler, logger zerolog.Logger) *SimPresetHandler {
+	return &SimPresetHandler{
+		library:   library,
+		runner:    runner,
+		scheduler: scheduler,
+		logger:    logger.With().Str("component", "sim_preset_handler").Logger(),
+	}
+}
+
+// ListPresets handles GET /api/admin/sim/presets
+func (h *SimPresetHandler) ListPresets(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.library.List())
+}
+
+// SavePreset handles POST /api/admin/sim/presets (create or replace by name)
+func (h *SimPresetHandler) SavePreset(w http.ResponseWriter, r *http.Request) {
+	var preset types.SimPreset
+	if err := json.NewDecoder(r.Body).Decode(&preset); err != nil {
+		apierror.WriteValidation(w, r, "invalid JSON body", nil)
+		return
+	}
+	preset.Name = strings.TrimSpace(preset.Name)
+	if errs := preset.Validate(); len(errs) > 0 {
+		apierror.WriteValidation(w, r, "invalid preset", errs)
+		return
+	}
+
+	saved, err := h.library.Put(preset)
+	if err != nil {
+		h.logger.Error().Err(err).Str("preset", preset.Name).Msg("failed to save sim preset")
+		apierror.Write(w, r, http.StatusInternalServerError, "failed to save preset")
+		return
+	}
+	h.logger.Info().Str("preset", saved.Name).Int("steps", len(saved.Steps)).Msg("sim preset saved via admin")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(saved)
+}
+
+// DeletePreset handles DELETE /api/admin/sim/presets/{name}
+func (h *SimPresetHandler) DeletePreset(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if err := h.library.Delete(name); err != nil {
+		if errors.Is(err, simpresets.ErrPresetNotFound) {
+			apierror.Write(w, r, http.StatusNotFound, "preset not found")
+			return
+		}
+		h.logger.Error().Err(err).Str("preset", name).Msg("failed to delete sim preset")
+		apierror.Write(w, r, http.StatusInternalServerError, "failed to delete preset")
+		return
+	}
+	h.logger.Info().Str("preset", name).Msg("sim preset deleted via admin")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ApplyPreset handles POST /api/admin/sim/presets/{name}/apply
+// Sends the preset's call config to AgentSim, starts or scales it to the preset's agents and
+// starts its scenario script, replacing the script of a previously applied preset.
+func (h *SimPresetHandler) ApplyPreset(w http.ResponseWriter, r *http.Request) {
+	if h.runner == nil {
+		apierror.WriteCode(w, r, http.StatusServiceUnavailable, types.ErrCodeSimNotConfigured,
+			"simulation backend not configured (set AGENTSIM_URL)")
+		return
+	}
+	name := chi.URLParam(r, "name")
+	preset, ok := h.library.Get(name)
+	if !ok {
+		apierror.Write(w, r, http.StatusNotFound, "preset not found")
+		return
+	}
+
+	resp, err := h.runner.Apply(r.Context(), preset)
+	if err != nil {
+		h.logger.Error().Err(err).Str("preset", name).Msg("failed to apply sim preset")
+		apierror.Write(w, r, http.StatusBadGateway, fmt.Sprintf("failed to apply preset: %s", err))
+		return
+	}
+	h.logger.Info().Str("preset", name).Str("agents", resp.Agents).Int("steps", resp.Steps).Msg("sim preset applied via admin")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetSchedule handles GET /api/admin/sim/schedule
+// Returns the schedule's entries, its next run time and the outcome of its latest run.
+func (h *SimPresetHandler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	if h.scheduler == nil {
+		apierror.Write(w, r, http.StatusNotFound, "no sim schedule configured (set SIM_SCHEDULE_FILE)")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.scheduler.Status())
+}
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dennisdiepolder/monti/backend/internal/alerts"
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
+	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/rollup"
+	"github.com/dennisdiepolder/monti/backend/internal/teams"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/dennisdiepolder/monti/backend/internal/websocket"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+)
+
+// maxTeamAlerts is how many active alerts a team summary lists
+const maxTeamAlerts = 5
+
+// TeamHandler provides REST endpoints for the team directory and team summaries
+type TeamHandler struct {
+	teams   *teams.Directory
+	tracker *cache.AgentStateTracker
+	source  SnapshotSource
+	alerts  *alerts.Tracker
+	logger  zerolog.Logger
+}
+
+// NewTeamHandler creates a new TeamHandler
+func NewTeamHandler(directory *teams.Directory, tracker *cache.AgentStateTracker, source SnapshotSource, alertTracker *alerts.Tracker, logger zerolog.Logger) *TeamHandler {
+	return &TeamHandler{
+		teams:   directory,
+		tracker: tracker,
+		source:  source,
+		alerts:  alertTracker,
+		logger:  logger.With().Str("component", "team_handler").Logger(),
+	}
+}
+
+// ListTeams handles GET /api/teams
+// Returns the teams within the caller's team and department scope.
+func (h *TeamHandler) ListTeams(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.GetUserFromContext(r.Context())
+	out := []types.Team{}
+	for _, t := range h.teams.List() {
+		if claims == nil || (claims.IsTenantAllowed(t.Tenant) && claims.IsTeamAllowed(t.Name) && claims.IsDepartmentAllowed(t.Department)) {
+			out = append(out, t)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// GetTeamSummary handles GET /api/teams/{team}/summary
+// Returns the team's live state breakdown, its observed calls, AHT and occupancy so far today,
+// the queues of its departments with their combined SL, and its top active alerts. Teams not
+// in the directory are summarized from their agents.
+func (h *TeamHandler) GetTeamSummary(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "team")
+	claims, _ := auth.GetUserFromContext(r.Context())
+	team, managed := h.teams.Get(name)
+	if !managed {
+		team = types.Team{Name: name}
+	}
+	if claims != nil && (!claims.IsTenantAllowed(team.Tenant) || !claims.IsTeamAllowed(name) ||
+		(managed && !claims.IsDepartmentAllowed(team.Department))) {
+		apierror.Write(w, r, http.StatusNotFound, "team not found")
+		return
+	}
+
+	snapshot := h.source.LatestSnapshot()
+	if snapshot == nil {
+		apierror.Write(w, r, http.StatusServiceUnavailable, "no snapshot available yet")
+		return
+	}
+
+	var active []types.AlertRecord
+	for _, rec := range h.alerts.Active() {
+		if alertVisible(claims, rec) {
+			active = append(active, rec)
+		}
+	}
+	summary := rollup.TeamSummary(websocket.FilterSnapshot(snapshot, claims).Departments, team, active, maxTeamAlerts)
+	if !managed && summary.TotalAgents == 0 {
+		apierror.Write(w, r, http.StatusNotFound, "team not found")
+		return
+	}
+	summary.Timestamp = snapshot.Timestamp
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+// PutTeam handles PUT /api/admin/teams/{team}
+// Body: {"department", "supervisor", "supervisorName"}; creates or replaces the team.
+func (h *TeamHandler) PutTeam(w http.ResponseWriter, r *http.Request) {
+	var team types.Team
+	if err := json.NewDecoder(r.Body).Decode(&team); err != nil {
+		apierror.WriteValidation(w, r, "invalid JSON", nil)
+		return
+	}
+	team.Name = chi.URLParam(r, "team")
+	if errs := team.Validate(); len(errs) > 0 {
+		apierror.WriteValidation(w, r, "invalid team", errs)
+		return
+	}
+	// Team names are unique across tenants; a team belongs to the tenant that created it
+	team.Tenant = ""
+	if claims, ok := auth.GetUserFromContext(r.Context()); ok && claims.Tenant != types.DefaultTenant {
+		team.Tenant = claims.Tenant
+	}
+	if existing, ok := h.teams.Get(team.Name); ok && types.TenantOf(existing.Tenant) != types.TenantOf(team.Tenant) {
+		apierror.Write(w, r, http.StatusConflict, "team name is taken")
+		return
+	}
+
+	team, err := h.teams.Put(team)
+	if err != nil {
+		h.logger.Error().Err(err).Str("team", team.Name).Msg("failed to save team")
+		apierror.Write(w, r, http.StatusInternalServerError, "failed to persist team")
+		return
+	}
+	// Supervisor scope is resolved when tokens are validated
+	auth.FlushTokens()
+
+	h.logChange(r, "team saved", team)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(team)
+}
+
+// DeleteTeam handles DELETE /api/admin/teams/{team}
+// Teams that still have agents are refused with 409.
+func (h *TeamHandler) DeleteTeam(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "team")
+	team, ok := h.teams.Get(name)
+	if claims, found := auth.GetUserFromContext(r.Context()); ok && found && !claims.IsTenantAllowed(team.Tenant) {
+		ok = false
+	}
+	if !ok {
+		apierror.Write(w, r, http.StatusNotFound, "team not found")
+		return
+	}
+	for _, agent := range h.tracker.GetAllAgents() {
+		if agent.Team == name {
+			apierror.Write(w, r, http.StatusConflict, "team still has agents")
+			return
+		}
+	}
+
+	if err := h.teams.Delete(name); err != nil {
+		if errors.Is(err, teams.ErrTeamNotFound) {
+			apierror.Write(w, r, http.StatusNotFound, "team not found")
+			return
+		}
+		h.logger.Error().Err(err).Str("team", name).Msg("failed to delete team")
+		apierror.Write(w, r, http.StatusInternalServerError, "failed to persist team")
+		return
+	}
+	auth.FlushTokens()
+
+	h.logChange(r, "team deleted", team)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TeamHandler) logChange(r *http.Request, msg string, team types.Team) {
+	event := h.logger.Info().
+		Str("team", team.Name).
+		Str("department", string(team.Department)).
+		Str("supervisor", team.Supervisor)
+	if claims, ok := auth.GetUserFromContext(r.Context()); ok {
+		event = event.Str("user", claims.Email)
+	}
+	event.Msg(msg)
+}
+
+// teamFieldErrors checks a roster entry against the team directory: once teams are
+// defined, agents must join a known team of their own department
+func teamFieldErrors(directory *teams.Directory, prefix string, rec types.RosterRecord) []types.FieldError {
+	if directory == nil || directory.Empty() {
+		return nil
+	}
+	team, ok := directory.Get(rec.Team)
+	if !ok {
+		return []types.FieldError{{Field: prefix + "team", Message: fmt.Sprintf("unknown team %q", rec.Team)}}
+	}
+	if team.Department != rec.Department {
+		return []types.FieldError{{Field: prefix + "team", Message: fmt.Sprintf("team %q belongs to %s", rec.Team, team.Department)}}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
+	"github.com/dennisdiepolder/monti/backend/internal/rollup"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/dennisdiepolder/monti/backend/internal/websocket"
+	"github.com/rs/zerolog"
+)
+
+// SnapshotSource provides the most recent broadcast snapshot (implemented by websocket.Hub)
+type SnapshotSource interface {
+	LatestSnapshot() *types.Snapshot
+}
+
+// WallboardHandler serves the latest snapshot as plain JSON for polling clients
+type WallboardHandler struct {
+	source SnapshotSource
+	logger zerolog.Logger
+}
+
+// NewWallboardHandler creates a new WallboardHandler
+func NewWallboardHandler(source SnapshotSource, logger zerolog.Logger) *WallboardHandler {
+	return &WallboardHandler{
+		source: source,
+		logger: logger.With().Str("component", "wallboard").Logger(),
+	}
+}
+
+// GetWallboard handles GET /api/wallboard
+// The ETag covers the content only (not the timestamp), so polling clients
+// sending If-None-Match get 304 until something actually changes.
+func (h *WallboardHandler) GetWallboard(w http.ResponseWriter, r *http.Request) {
+	snapshot := h.source.LatestSnapshot()
+	if snapshot == nil {
+		apierror.Write(w, r, http.StatusServiceUnavailable, "no snapshot available yet")
+		return
+	}
+
+	claims, _ := auth.GetUserFromContext(r.Context())
+	board := buildWallboard(websocket.FilterSnapshot(snapshot, claims))
+
+	timestamp := board.Timestamp
+	board.Timestamp = time.Time{}
+	content, err := json.Marshal(board)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to marshal wallboard")
+		apierror.Write(w, r, http.StatusInternalServerError, "failed to build wallboard")
+		return
+	}
+	sum := sha256.Sum256(content)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	board.Timestamp = timestamp
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(board)
+}
+
+// buildWallboard condenses a (filtered) snapshot into department summaries, queues and alerts
+func buildWallboard(snapshot *types.Snapshot) types.Wallboard {
+	board := types.Wallboard{
+		Timestamp:     snapshot.Timestamp,
+		Summary:       rollup.Total(snapshot.Departments),
+		Departments:   make([]types.WallboardDepartment, 0, len(snapshot.Departments)),
+		Alerts:        snapshot.Alerts,
+		AgentAlerts:   []types.WallboardAgentAlert{},
+		Announcements: snapshot.Announcements,
+	}
+	if board.Alerts == nil {
+		board.Alerts = []types.Alert{}
+	}
+	if board.Announcements == nil {
+		board.Announcements = []types.Announcement{}
+	}
+
+	summaries := make(map[string]types.Rollup)
+	for _, r := range rollup.Build(snapshot.Departments, []types.RollupDimension{types.RollupDepartment}) {
+		summaries[r.Key] = r
+	}
+
+	depts := make([]types.Department, 0, len(snapshot.Departments))
+	for dept := range snapshot.Departments {
+		depts = append(depts, dept)
+	}
+	sort.Slice(depts, func(i, j int) bool { return depts[i] < depts[j] })
+
+	for _, dept := range depts {
+		data := snapshot.Departments[dept]
+		summary, ok := summaries[string(dept)]
+		if !ok {
+			summary = rollup.Total(map[types.Department]*types.DepartmentData{dept: data})
+		}
+		summary.Dimension = types.RollupDepartment
+		summary.Key = string(dept)
+
+		board.Departments = append(board.Departments, types.WallboardDepartment{
+			Department: dept,
+			Summary:    summary,
+			Queues:     data.Queues,
+		})
+
+		for _, agent := range data.Agents {
+			for _, alert := range agent.Alerts {
+				board.AgentAlerts = append(board.AgentAlerts, types.WallboardAgentAlert{
+					AgentID:    agent.AgentID,
+					Department: dept,
+					Team:       agent.Team,
+					AgentAlert: alert,
+				})
+			}
+		}
+	}
+
+	sort.Slice(board.AgentAlerts, func(i, j int) bool {
+		return board.AgentAlerts[i].AgentID < board.AgentAlerts[j].AgentID
+	})
+	return board
+}
+
+// etagMatches reports whether an If-None-Match header matches etag
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
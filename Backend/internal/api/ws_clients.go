@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// ClientInventory lists and messages the connected dashboard clients (implemented by websocket.Hub)
+type ClientInventory interface {
+	Clients() types.WSClientsResponse
+	SendControl(msg types.ControlMessage, clientIDs, roles []string) (delivered, dropped int)
+}
+
+// WSClientsHandler shows operators who is consuming the snapshot stream and lets them push
+// control messages to it
+type WSClientsHandler struct {
+	inventory ClientInventory
+	logger    zerolog.Logger
+}
+
+// NewWSClientsHandler creates a new WSClientsHandler
+func NewWSClientsHandler(inventory ClientInventory, logger zerolog.Logger) *WSClientsHandler {
+	return &WSClientsHandler{
+		inventory: inventory,
+		logger:    logger.With().Str("component", "ws_clients").Logger(),
+	}
+}
+
+// ListClients handles GET /api/admin/ws/clients: connected dashboard clients with their
+// dropped messages and snapshot acknowledgements
+func (h *WSClientsHandler) ListClients(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.inventory.Clients())
+}
+
+// SendControl handles POST /api/admin/ws/control: pushes a reload or notice to the clients
+// selected by ID or role, or to all clients
+func (h *WSClientsHandler) SendControl(w http.ResponseWriter, r *http.Request) {
+	var req types.ControlMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.WriteValidation(w, r, "invalid JSON body", nil)
+		return
+	}
+	if fields := req.Validate(); len(fields) > 0 {
+		apierror.WriteValidation(w, r, "invalid control message", fields)
+		return
+	}
+
+	msg := types.ControlMessage{Action: req.Action, Message: req.Message, Timestamp: time.Now()}
+	delivered, dropped := h.inventory.SendControl(msg, req.ClientIDs, req.Roles)
+	h.logger.Info().
+		Str("action", req.Action).
+		Strs("client_ids", req.ClientIDs).
+		Strs("roles", req.Roles).
+		Int("delivered", delivered).
+		Int("dropped", dropped).
+		Msg("control message sent")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(types.ControlMessageResponse{Delivered: delivered, Dropped: dropped})
+}
@@ -0,0 +1,68 @@
+// Package apierror writes the REST API's error envelope (types.ErrorResponse), so that every
+// handler and middleware fails with the same machine-readable body: a message, a stable code and
+// the request ID under which the backend logged the request.
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// Write sends an error whose code follows from the status
+func Write(w http.ResponseWriter, r *http.Request, status int, msg string) {
+	WriteCode(w, r, status, CodeFor(status), msg)
+}
+
+// WriteCode sends an error with an explicit code
+func WriteCode(w http.ResponseWriter, r *http.Request, status int, code, msg string) {
+	send(w, r, status, types.ErrorResponse{Error: msg, Code: code})
+}
+
+// WriteValidation sends a 400 listing why each rejected field failed (fields may be empty,
+// e.g. for a body that is not JSON at all)
+func WriteValidation(w http.ResponseWriter, r *http.Request, msg string, fields []types.FieldError) {
+	send(w, r, http.StatusBadRequest, types.ErrorResponse{Error: msg, Code: types.ErrCodeValidation, Fields: fields})
+}
+
+func send(w http.ResponseWriter, r *http.Request, status int, resp types.ErrorResponse) {
+	if r != nil {
+		resp.RequestID = chimiddleware.GetReqID(r.Context())
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// CodeFor maps a status to its default error code
+func CodeFor(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return types.ErrCodeBadRequest
+	case http.StatusUnauthorized:
+		return types.ErrCodeUnauthorized
+	case http.StatusForbidden:
+		return types.ErrCodeForbidden
+	case http.StatusNotFound:
+		return types.ErrCodeNotFound
+	case http.StatusMethodNotAllowed:
+		return types.ErrCodeMethodNotAllowed
+	case http.StatusConflict:
+		return types.ErrCodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return types.ErrCodePayloadTooLarge
+	case http.StatusTooManyRequests:
+		return types.ErrCodeRateLimited
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return types.ErrCodeUpstream
+	case http.StatusServiceUnavailable:
+		return types.ErrCodeUnavailable
+	}
+	if status >= 500 {
+		return types.ErrCodeInternal
+	}
+	return types.ErrCodeBadRequest
+}
@@ -0,0 +1,63 @@
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+func TestWriteCarriesCodeAndRequestID(t *testing.T) {
+	h := chimiddleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Write(w, r, http.StatusNotFound, "agent not found")
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/api/agents/a-1", nil)
+	req.Header.Set(chimiddleware.RequestIDHeader, "req-42")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("got %d %q, want 404 application/json", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var resp types.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	want := types.ErrorResponse{Error: "agent not found", Code: types.ErrCodeNotFound, RequestID: "req-42"}
+	if resp.Error != want.Error || resp.Code != want.Code || resp.RequestID != want.RequestID {
+		t.Errorf("got %+v, want %+v", resp, want)
+	}
+}
+
+func TestWriteValidationListsFields(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteValidation(rec, httptest.NewRequest(http.MethodPost, "/", nil), "invalid event",
+		[]types.FieldError{{Field: "state", Message: `unknown state "x"`}})
+
+	var resp types.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusBadRequest || resp.Code != types.ErrCodeValidation || len(resp.Fields) != 1 || resp.RequestID != "" {
+		t.Errorf("got %d %+v, want 400 validation_failed with one field and no request ID", rec.Code, resp)
+	}
+}
+
+func TestCodeFor(t *testing.T) {
+	for status, want := range map[int]string{
+		http.StatusUnauthorized:        types.ErrCodeUnauthorized,
+		http.StatusConflict:            types.ErrCodeConflict,
+		http.StatusTooManyRequests:     types.ErrCodeRateLimited,
+		http.StatusBadGateway:          types.ErrCodeUpstream,
+		http.StatusInternalServerError: types.ErrCodeInternal,
+		http.StatusNotImplemented:      types.ErrCodeInternal,
+		http.StatusTeapot:              types.ErrCodeBadRequest,
+	} {
+		if got := CodeFor(status); got != want {
+			t.Errorf("CodeFor(%d) = %q, want %q", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MicahParks/jwkset"
+	"github.com/MicahParks/keyfunc/v3"
+	"github.com/dennisdiepolder/monti/backend/internal/metrics"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// jwksFetchTimeout bounds a single JWKS HTTP request
+	jwksFetchTimeout = 10 * time.Second
+
+	// unknownKIDRefreshInterval rate-limits refreshes triggered by tokens signed with an unknown key ID
+	unknownKIDRefreshInterval = 30 * time.Second
+)
+
+// JWKSManager handles JWKS fetching, caching and refresh on key rotation
+type JWKSManager struct {
+	jwks        keyfunc.Keyfunc
+	issuerURL   string
+	mu          sync.RWMutex
+	lastUpdate  time.Time
+	lastAttempt time.Time
+
+	refreshMu sync.Mutex // serializes fetches
+}
+
+var (
+	jwksManager *JWKSManager
+
+	// errJWKSUnavailable means tokens cannot be verified because no keys are loaded
+	errJWKSUnavailable = errors.New("JWKS not available")
+)
+
+// InitJWKS initializes the JWKS manager for token verification.
+// Retries up to maxAttempts times with a delay between attempts to handle
+// cases where Keycloak is still starting up.
+func InitJWKS(issuerURL string, maxAttempts int) error {
+	jwksManager = &JWKSManager{issuerURL: issuerURL}
+
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if err := jwksManager.refresh(); err != nil {
+			lastErr = err
+			logger.Warn().Err(err).Int("attempt", attempt).Int("max_attempts", maxAttempts).Msg("JWKS fetch failed")
+			if attempt < maxAttempts {
+				time.Sleep(3 * time.Second)
+			}
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("failed to initialize JWKS after %d attempts: %w", maxAttempts, lastErr)
+}
+
+// StartJWKSRefresh re-fetches the JWKS every interval until ctx is done, so rotated
+// signing keys are picked up without a restart. A failed refresh keeps the current keys.
+func StartJWKSRefresh(ctx context.Context, interval time.Duration) {
+	m := jwksManager
+	if m == nil || interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := m.refresh(); err != nil {
+					logger.Error().Err(err).Time("keys_from", m.LastUpdate()).Msg("periodic JWKS refresh failed, keeping current keys")
+				}
+			}
+		}
+	}()
+}
+
+// JWKSLastUpdate returns when the JWKS was last fetched successfully, or the zero time
+// when JWKS verification is not initialized
+func JWKSLastUpdate() time.Time {
+	if jwksManager == nil {
+		return time.Time{}
+	}
+	return jwksManager.LastUpdate()
+}
+
+// LastUpdate returns when the keys were last fetched successfully
+func (m *JWKSManager) LastUpdate() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.lastUpdate
+}
+
+// refresh fetches the JWKS from the OIDC provider
+func (m *JWKSManager) refresh() error {
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
+	return m.fetch()
+}
+
+// refreshForUnknownKID fetches the JWKS unless a fetch was attempted within
+// unknownKIDRefreshInterval. Returns true if new keys were loaded.
+func (m *JWKSManager) refreshForUnknownKID() bool {
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
+
+	m.mu.RLock()
+	recent := time.Since(m.lastAttempt) < unknownKIDRefreshInterval
+	m.mu.RUnlock()
+	if recent {
+		return false
+	}
+
+	if err := m.fetch(); err != nil {
+		logger.Error().Err(err).Msg("JWKS refresh for unknown key ID failed")
+		return false
+	}
+	return true
+}
+
+// fetch loads the JWKS and swaps it in (caller must hold refreshMu)
+func (m *JWKSManager) fetch() error {
+	m.mu.Lock()
+	m.lastAttempt = time.Now()
+	m.mu.Unlock()
+
+	// Construct JWKS URL (Keycloak format)
+	jwksURL := strings.TrimSuffix(m.issuerURL, "/") + "/protocol/openid-connect/certs"
+	logger.Debug().Str("url", jwksURL).Msg("fetching JWKS")
+
+	k, err := loadKeyfunc(jwksURL)
+	if err != nil {
+		metrics.Get().RecordJWKSRefreshError()
+		return err
+	}
+
+	m.mu.Lock()
+	m.jwks = k
+	m.lastUpdate = time.Now()
+	m.mu.Unlock()
+
+	// Revalidate tokens against the new key set
+	tokens.flush()
+
+	metrics.Get().RecordJWKSRefresh()
+	logger.Info().Str("url", jwksURL).Msg("JWKS loaded")
+	return nil
+}
+
+// loadKeyfunc fetches a JWK Set once and wraps it in a keyfunc.
+// An empty set is rejected so a misbehaving IdP cannot wipe out the current keys.
+func loadKeyfunc(jwksURL string) (keyfunc.Keyfunc, error) {
+	u, err := url.ParseRequestURI(jwksURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWKS URL: %w", err)
+	}
+
+	store, err := jwkset.NewStorageFromHTTP(u, jwkset.HTTPClientStorageOptions{HTTPTimeout: jwksFetchTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	keys, err := store.KeyReadAll(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS contains no keys")
+	}
+
+	k, err := keyfunc.New(keyfunc.Options{Storage: store})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create keyfunc: %w", err)
+	}
+	return k, nil
+}
+
+// getKeyfunc returns the JWT keyfunc for token verification
+func (m *JWKSManager) getKeyfunc() jwt.Keyfunc {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.jwks == nil {
+		return nil
+	}
+	return m.jwks.Keyfunc
+}
+
+// parseAndVerifyToken verifies the JWT signature using JWKS
+func parseAndVerifyToken(tokenString string) (*jwt.Token, error) {
+	if jwksManager == nil {
+		return nil, fmt.Errorf("%w: call InitJWKS on startup", errJWKSUnavailable)
+	}
+
+	keyfunc := jwksManager.getKeyfunc()
+	if keyfunc == nil {
+		// Try one refresh in case it recovered
+		if err := jwksManager.refresh(); err != nil {
+			return nil, fmt.Errorf("%w: refresh failed: %w", errJWKSUnavailable, err)
+		}
+		keyfunc = jwksManager.getKeyfunc()
+		if keyfunc == nil {
+			return nil, errJWKSUnavailable
+		}
+	}
+
+	// Parse and verify the token
+	token, err := parseWithKeyfunc(tokenString, keyfunc)
+	if errors.Is(err, jwkset.ErrKeyNotFound) && jwksManager.refreshForUnknownKID() {
+		// Signed with a key we have not seen yet - the IdP probably rotated keys
+		logger.Info().Msg("token signed with unknown key ID, retrying with refreshed JWKS")
+		token, err = parseWithKeyfunc(tokenString, jwksManager.getKeyfunc())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("token verification failed: %w", err)
+	}
+
+	if !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	return token, nil
+}
+
+func parseWithKeyfunc(tokenString string, keyfunc jwt.Keyfunc) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, keyfunc, jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}))
+}
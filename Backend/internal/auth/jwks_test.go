@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MicahParks/jwkset"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testIdP serves a JWK Set on the Keycloak certs path and signs tokens with its current key
+type testIdP struct {
+	mu  sync.Mutex
+	kid string
+	key *rsa.PrivateKey
+}
+
+func (p *testIdP) rotate(t *testing.T, kid string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	p.mu.Lock()
+	p.kid, p.key = kid, key
+	p.mu.Unlock()
+}
+
+func (p *testIdP) sign(t *testing.T) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"email": "sup@example.com"})
+	token.Header["kid"] = p.kid
+	signed, err := token.SignedString(p.key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func (p *testIdP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	jwk, err := jwkset.NewJWKFromKey(&p.key.PublicKey, jwkset.JWKOptions{
+		Metadata: jwkset.JWKMetadataOptions{KID: p.kid, ALG: jwkset.AlgRS256},
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(jwkset.JWKSMarshal{Keys: []jwkset.JWKMarshal{jwk.Marshal()}})
+}
+
+func TestJWKSRefreshOnUnknownKID(t *testing.T) {
+	idp := &testIdP{}
+	idp.rotate(t, "key-1")
+	srv := httptest.NewServer(idp)
+	defer srv.Close()
+
+	if err := InitJWKS(srv.URL, 1); err != nil {
+		t.Fatalf("InitJWKS failed: %v", err)
+	}
+	defer func() { jwksManager = nil }()
+
+	if _, err := parseAndVerifyToken(idp.sign(t)); err != nil {
+		t.Fatalf("expected token with initial key to verify: %v", err)
+	}
+
+	// Rotation right after a fetch is rate-limited
+	idp.rotate(t, "key-2")
+	if _, err := parseAndVerifyToken(idp.sign(t)); err == nil {
+		t.Fatal("expected unknown key to fail while refresh is rate-limited")
+	}
+
+	// Once the rate limit has passed, an unknown kid triggers a refresh and the token verifies
+	jwksManager.mu.Lock()
+	jwksManager.lastAttempt = time.Now().Add(-unknownKIDRefreshInterval)
+	jwksManager.mu.Unlock()
+	if _, err := parseAndVerifyToken(idp.sign(t)); err != nil {
+		t.Fatalf("expected token with rotated key to verify after refresh: %v", err)
+	}
+}
+
+func TestJWKSRefreshFailureKeepsKeys(t *testing.T) {
+	idp := &testIdP{}
+	idp.rotate(t, "key-1")
+	srv := httptest.NewServer(idp)
+
+	if err := InitJWKS(srv.URL, 1); err != nil {
+		t.Fatalf("InitJWKS failed: %v", err)
+	}
+	defer func() { jwksManager = nil }()
+
+	srv.Close()
+	if err := jwksManager.refresh(); err == nil {
+		t.Fatal("expected refresh against a stopped IdP to fail")
+	}
+	if _, err := parseAndVerifyToken(idp.sign(t)); err != nil {
+		t.Fatalf("expected previous keys to stay active after a failed refresh: %v", err)
+	}
+}
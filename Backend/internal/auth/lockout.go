@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// maxTrackedClients bounds the failure table; expired records are swept when it fills up
+const maxTrackedClients = 10000
+
+// LockoutSettings configures temporary lockout after repeated invalid-token attempts
+type LockoutSettings struct {
+	IPThreshold   int           // failures per IP within Window before lockout; 0 disables
+	UserThreshold int           // failures per token subject within Window; 0 disables
+	Window        time.Duration // failures older than this are forgotten
+	Duration      time.Duration // how long a lockout lasts
+}
+
+// DefaultLockoutSettings locks an IP out for 15 minutes after 10 bad tokens in 5 minutes.
+// User lockout is off by default: the subject comes from an unverified token, so anyone
+// could use it to lock out another user.
+func DefaultLockoutSettings() LockoutSettings {
+	return LockoutSettings{
+		IPThreshold: 10,
+		Window:      5 * time.Minute,
+		Duration:    15 * time.Minute,
+	}
+}
+
+type failureRecord struct {
+	count       int
+	first       time.Time
+	lockedUntil time.Time
+}
+
+// lockoutTracker counts auth failures per key ("ip:<addr>" / "user:<sub>")
+type lockoutTracker struct {
+	mu       sync.Mutex
+	settings LockoutSettings
+	failures map[string]*failureRecord
+}
+
+var lockouts = &lockoutTracker{
+	settings: DefaultLockoutSettings(),
+	failures: make(map[string]*failureRecord),
+}
+
+// SetLockoutSettings replaces the lockout settings and clears tracked failures
+func SetLockoutSettings(s LockoutSettings) {
+	lockouts.mu.Lock()
+	defer lockouts.mu.Unlock()
+	lockouts.settings = s
+	lockouts.failures = make(map[string]*failureRecord)
+}
+
+// lockedUntil returns the end of an active lockout for key
+func (l *lockoutTracker) lockedUntil(key string, now time.Time) (time.Time, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rec, ok := l.failures[key]
+	if !ok || !now.Before(rec.lockedUntil) {
+		return time.Time{}, false
+	}
+	return rec.lockedUntil, true
+}
+
+// fail records a failure for key and reports whether it just triggered a lockout
+func (l *lockoutTracker) fail(key string, threshold int, now time.Time) bool {
+	if threshold <= 0 {
+		return false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rec, ok := l.failures[key]
+	if ok && now.Before(rec.lockedUntil) {
+		return false // already locked out
+	}
+	if !ok || now.Sub(rec.first) > l.settings.Window {
+		if !ok && len(l.failures) >= maxTrackedClients {
+			l.sweep(now)
+		}
+		rec = &failureRecord{first: now}
+		l.failures[key] = rec
+	}
+	rec.count++
+	if rec.count >= threshold {
+		rec.lockedUntil = now.Add(l.settings.Duration)
+		rec.count = 0
+		rec.first = now
+		return true
+	}
+	return false
+}
+
+// sweep drops records whose window and lockout have both passed (caller must hold l.mu)
+func (l *lockoutTracker) sweep(now time.Time) {
+	for key, rec := range l.failures {
+		if now.Sub(rec.first) > l.settings.Window && !now.Before(rec.lockedUntil) {
+			delete(l.failures, key)
+		}
+	}
+}
+
+func (l *lockoutTracker) thresholds() (ip, user int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.settings.IPThreshold, l.settings.UserThreshold
+}
+
+// clientIP returns the request's client address (chi's RealIP middleware has already
+// applied X-Forwarded-For / X-Real-IP)
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// unverifiedSubject reads the sub claim without checking the signature (lockout key only)
+func unverifiedSubject(tokenString string) string {
+	mapClaims := jwt.MapClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(tokenString, mapClaims); err != nil {
+		return ""
+	}
+	sub, _ := mapClaims["sub"].(string)
+	return sub
+}
@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestLockoutTracker(t *testing.T) {
+	l := &lockoutTracker{
+		settings: LockoutSettings{IPThreshold: 3, Window: time.Minute, Duration: 10 * time.Minute},
+		failures: make(map[string]*failureRecord),
+	}
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if l.fail("ip:10.0.0.1", 3, now) {
+			t.Fatalf("unexpected lockout after %d failures", i+1)
+		}
+	}
+	if _, locked := l.lockedUntil("ip:10.0.0.1", now); locked {
+		t.Fatal("expected no lockout below threshold")
+	}
+	if !l.fail("ip:10.0.0.1", 3, now) {
+		t.Fatal("expected lockout at threshold")
+	}
+	if until, locked := l.lockedUntil("ip:10.0.0.1", now); !locked || !until.Equal(now.Add(10*time.Minute)) {
+		t.Fatalf("expected lockout until %v, got %v %v", now.Add(10*time.Minute), until, locked)
+	}
+	if _, locked := l.lockedUntil("ip:10.0.0.1", now.Add(11*time.Minute)); locked {
+		t.Error("expected lockout to expire")
+	}
+
+	// Failures spread beyond the window do not add up
+	later := now.Add(time.Hour)
+	l.fail("ip:10.0.0.2", 3, later)
+	l.fail("ip:10.0.0.2", 3, later.Add(2*time.Minute))
+	if l.fail("ip:10.0.0.2", 3, later.Add(2*time.Minute+time.Second)) {
+		t.Error("expected failures outside the window to reset the count")
+	}
+}
+
+func TestMiddlewareLocksOutAfterInvalidTokens(t *testing.T) {
+	t.Setenv("ENV", "")
+	t.Setenv("SKIP_AUTH", "")
+	SetLockoutSettings(LockoutSettings{IPThreshold: 2, Window: time.Minute, Duration: time.Minute})
+	defer SetLockoutSettings(DefaultLockoutSettings())
+
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	request := func(token, ip string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/agents", nil)
+		req.RemoteAddr = ip + ":40000"
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Expired tokens never count toward lockout
+	expired, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"exp": float64(time.Now().Add(-time.Hour).Unix()),
+	}).SignedString([]byte("test"))
+	for i := 0; i < 3; i++ {
+		if code := request(expired, "10.0.0.1"); code != http.StatusUnauthorized {
+			t.Fatalf("expected 401 for expired token, got %d", code)
+		}
+	}
+
+	valid, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"email": "sup@example.com",
+		"exp":   float64(time.Now().Add(time.Hour).Unix()),
+	}).SignedString([]byte("test"))
+	if code := request(valid, "10.0.0.1"); code != http.StatusOK {
+		t.Fatalf("expected 200 before lockout, got %d", code)
+	}
+
+	for i := 0; i < 2; i++ {
+		if code := request("not-a-jwt", "10.0.0.1"); code != http.StatusUnauthorized {
+			t.Fatalf("expected 401 for malformed token, got %d", code)
+		}
+	}
+	if code := request(valid, "10.0.0.1"); code != http.StatusTooManyRequests {
+		t.Errorf("expected 429 for locked-out IP, got %d", code)
+	}
+	if code := request(valid, "10.0.0.2"); code != http.StatusOK {
+		t.Errorf("expected other IPs to be unaffected, got %d", code)
+	}
+}
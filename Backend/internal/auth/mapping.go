@@ -0,0 +1,272 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Role claim match modes
+const (
+	MatchExact    = "exact"    // claim value equals the role name
+	MatchContains = "contains" // claim value contains the role name (e.g. Cognito group "monti-admins")
+)
+
+// RoleClaim is a token claim holding a list of role or group names
+type RoleClaim struct {
+	Claim string `json:"claim"` // dotted path, e.g. "realm_access.roles" or "cognito:groups"
+	Match string `json:"match"` // "exact" or "contains"
+}
+
+// Mapping describes how token claims map to MONTI roles and RBAC scopes.
+// The defaults match Keycloak (realm roles, /business-units/ groups) and Cognito.
+type Mapping struct {
+	RoleClaims         []RoleClaim                             `json:"roleClaims"`         // checked in order, first source yielding a role wins
+	Roles              []string                                `json:"roles"`              // MONTI roles, highest priority first
+	RoleAliases        map[string]string                       `json:"roleAliases"`        // IdP role/group name -> MONTI role
+	DefaultRole        string                                  `json:"defaultRole"`        // when no claim yields a role
+	GroupClaims        []string                                `json:"groupClaims"`        // claims holding group paths
+	BusinessUnitPrefix string                                  `json:"businessUnitPrefix"` // group prefix for business units
+	TeamPrefix         string                                  `json:"teamPrefix"`         // group prefix for team scope
+	DepartmentPrefix   string                                  `json:"departmentPrefix"`   // group prefix for department scope
+	BusinessUnits      map[types.BusinessUnit][]types.Location `json:"businessUnits"`      // BU -> allowed locations
+	AgentIDClaims      []string                                `json:"agentIdClaims"`      // claims holding the agent ID for desktop clients, first non-empty wins
+	TenantClaims       []string                                `json:"tenantClaims"`       // claims holding the user's tenant, first non-empty wins
+}
+
+// DefaultMapping returns the built-in claim mapping
+func DefaultMapping() *Mapping {
+	return &Mapping{
+		RoleClaims: []RoleClaim{
+			{Claim: "realm_access.roles", Match: MatchExact},
+			{Claim: "cognito:groups", Match: MatchContains},
+			{Claim: "custom:groups", Match: MatchContains},
+		},
+		Roles:              []string{"admin", "supervisor", "agent", "viewer"},
+		DefaultRole:        "viewer",
+		GroupClaims:        []string{"groups", "cognito:groups"},
+		BusinessUnitPrefix: "/business-units/",
+		TeamPrefix:         "/teams/",
+		DepartmentPrefix:   "/departments/",
+		BusinessUnits:      types.BULocationMapping,
+		AgentIDClaims:      []string{"agent_id", "custom:agent_id"},
+		TenantClaims:       []string{"tenant", "custom:tenant"},
+	}
+}
+
+var mapping atomic.Pointer[Mapping]
+
+// SetMapping replaces the active claim mapping (call at startup, before serving requests)
+func SetMapping(m *Mapping) {
+	mapping.Store(m)
+	tokens.flush()
+}
+
+// TeamResolver returns the names of the teams a user supervises, by login email
+type TeamResolver func(email string) []string
+
+var teamResolver atomic.Pointer[TeamResolver]
+
+// SetTeamResolver scopes supervisors to the teams they supervise in the team directory,
+// in addition to any team groups. Call FlushTokens when the directory changes.
+func SetTeamResolver(r TeamResolver) {
+	teamResolver.Store(&r)
+	tokens.flush()
+}
+
+// FlushTokens drops cached token validations so scope changes apply to the next request
+func FlushTokens() {
+	tokens.flush()
+}
+
+// supervisedTeams returns the teams supervised by email via the configured resolver
+func supervisedTeams(email string) []string {
+	if r := teamResolver.Load(); r != nil && *r != nil {
+		return (*r)(email)
+	}
+	return nil
+}
+
+// activeMapping returns the configured mapping, or the defaults
+func activeMapping() *Mapping {
+	if m := mapping.Load(); m != nil {
+		return m
+	}
+	return DefaultMapping()
+}
+
+// LoadMappingFile reads a JSON claim mapping; omitted fields keep their defaults
+func LoadMappingFile(path string) (*Mapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read auth mapping: %w", err)
+	}
+	var m Mapping
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse auth mapping: %w", err)
+	}
+
+	def := DefaultMapping()
+	if m.RoleClaims == nil {
+		m.RoleClaims = def.RoleClaims
+	}
+	if m.Roles == nil {
+		m.Roles = def.Roles
+	}
+	if m.DefaultRole == "" {
+		m.DefaultRole = def.DefaultRole
+	}
+	if m.GroupClaims == nil {
+		m.GroupClaims = def.GroupClaims
+	}
+	if m.BusinessUnitPrefix == "" {
+		m.BusinessUnitPrefix = def.BusinessUnitPrefix
+	}
+	if m.TeamPrefix == "" {
+		m.TeamPrefix = def.TeamPrefix
+	}
+	if m.DepartmentPrefix == "" {
+		m.DepartmentPrefix = def.DepartmentPrefix
+	}
+	if m.BusinessUnits == nil {
+		m.BusinessUnits = def.BusinessUnits
+	}
+	if m.AgentIDClaims == nil {
+		m.AgentIDClaims = def.AgentIDClaims
+	}
+	if m.TenantClaims == nil {
+		m.TenantClaims = def.TenantClaims
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid auth mapping: %w", err)
+	}
+	return &m, nil
+}
+
+// Validate checks match modes, role references and BU locations
+func (m *Mapping) Validate() error {
+	roles := make(map[string]bool, len(m.Roles))
+	for _, role := range m.Roles {
+		roles[role] = true
+	}
+	if len(roles) == 0 {
+		return fmt.Errorf("roles must not be empty")
+	}
+	if !roles[m.DefaultRole] {
+		return fmt.Errorf("defaultRole %q is not in roles", m.DefaultRole)
+	}
+	for _, rc := range m.RoleClaims {
+		if rc.Claim == "" {
+			return fmt.Errorf("roleClaims entry without claim")
+		}
+		if rc.Match != MatchExact && rc.Match != MatchContains {
+			return fmt.Errorf("roleClaims %q: match must be %q or %q", rc.Claim, MatchExact, MatchContains)
+		}
+	}
+	for from, to := range m.RoleAliases {
+		if !roles[to] {
+			return fmt.Errorf("roleAliases %q maps to unknown role %q", from, to)
+		}
+	}
+
+	known := make(map[types.Location]bool, len(types.AllLocations))
+	for _, loc := range types.AllLocations {
+		known[loc] = true
+	}
+	for bu, locations := range m.BusinessUnits {
+		for _, loc := range locations {
+			if !known[loc] {
+				return fmt.Errorf("business unit %q: unknown location %q", bu, loc)
+			}
+		}
+	}
+	return nil
+}
+
+// role resolves the MONTI role from token claims
+func (m *Mapping) role(mapClaims jwt.MapClaims) string {
+	for _, rc := range m.RoleClaims {
+		values := claimStrings(mapClaims, rc.Claim)
+		if len(values) == 0 {
+			continue
+		}
+		for i, v := range values {
+			if alias, ok := m.RoleAliases[v]; ok {
+				values[i] = alias
+			}
+		}
+		for _, role := range m.Roles {
+			for _, v := range values {
+				if v == role || (rc.Match == MatchContains && strings.Contains(v, role)) {
+					return role
+				}
+			}
+		}
+	}
+	return m.DefaultRole
+}
+
+// groups collects group paths from all configured group claims
+func (m *Mapping) groups(mapClaims jwt.MapClaims) []string {
+	var groups []string
+	for _, claim := range m.GroupClaims {
+		groups = append(groups, claimStrings(mapClaims, claim)...)
+	}
+	return groups
+}
+
+// claimStrings returns the string list at a dotted claim path (nil if absent or not a list)
+func claimStrings(mapClaims jwt.MapClaims, path string) []string {
+	list, ok := claimValue(mapClaims, path).([]interface{})
+	if !ok {
+		return nil
+	}
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// agentID returns the first non-empty agent ID claim
+func (m *Mapping) agentID(mapClaims jwt.MapClaims) string {
+	return firstString(mapClaims, m.AgentIDClaims)
+}
+
+// tenant returns the first non-empty tenant claim, or types.DefaultTenant
+func (m *Mapping) tenant(mapClaims jwt.MapClaims) string {
+	return types.TenantOf(firstString(mapClaims, m.TenantClaims))
+}
+
+// firstString returns the first non-empty string among the claims
+func firstString(mapClaims jwt.MapClaims, claims []string) string {
+	for _, claim := range claims {
+		if s, ok := claimValue(mapClaims, claim).(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// claimValue resolves a dotted claim path, returning nil if any part is missing
+func claimValue(mapClaims jwt.MapClaims, path string) interface{} {
+	var current interface{} = map[string]interface{}(mapClaims)
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if current, ok = obj[key]; !ok {
+			return nil
+		}
+	}
+	return current
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestDefaultMappingRoles(t *testing.T) {
+	m := DefaultMapping()
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		want   string
+	}{
+		{"keycloak realm roles", jwt.MapClaims{"realm_access": map[string]interface{}{"roles": []interface{}{"agent", "supervisor"}}}, "supervisor"},
+		{"cognito groups", jwt.MapClaims{"cognito:groups": []interface{}{"monti-admins"}}, "admin"},
+		{"no roles", jwt.MapClaims{}, "viewer"},
+	}
+	for _, tt := range tests {
+		if got := m.role(tt.claims); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestLoadMappingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.json")
+	data := `{
+		"roleClaims": [{"claim": "resource_access.monti.roles", "match": "exact"}],
+		"roles": ["admin", "manager", "supervisor", "viewer"],
+		"roleAliases": {"team-lead": "supervisor"},
+		"businessUnitPrefix": "/org/",
+		"businessUnits": {"EAST": ["berlin"]}
+	}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := LoadMappingFile(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if m.DefaultRole != "viewer" || m.TeamPrefix != "/teams/" {
+		t.Error("expected omitted fields to keep their defaults")
+	}
+
+	claims := jwt.MapClaims{
+		"resource_access": map[string]interface{}{"monti": map[string]interface{}{"roles": []interface{}{"team-lead"}}},
+		"groups":          []interface{}{"/org/EAST"},
+	}
+	if role := m.role(claims); role != "supervisor" {
+		t.Errorf("expected alias to resolve to supervisor, got %q", role)
+	}
+	bus := extractGroupNames(m.groups(claims), m.BusinessUnitPrefix)
+	locations := computeAllowedLocations("supervisor", bus, m.BusinessUnits)
+	if len(locations) != 1 || locations[0] != types.LocationBerlin {
+		t.Errorf("expected [berlin], got %v", locations)
+	}
+}
+
+func TestLoadMappingFileRejectsUnknownAliasTarget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.json")
+	if err := os.WriteFile(path, []byte(`{"roleAliases": {"boss": "owner"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadMappingFile(path); err == nil {
+		t.Error("expected error for alias to unknown role")
+	}
+}
+
+func TestSupervisorScopedToDirectoryTeams(t *testing.T) {
+	t.Setenv("ENV", "")
+	SetTeamResolver(func(email string) []string {
+		if email == "lead@monti.local" {
+			return []string{"sales-berlin-1", "sales-berlin-2"}
+		}
+		return nil
+	})
+	defer SetTeamResolver(nil)
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{
+		"email":        "lead@monti.local",
+		"realm_access": map[string]interface{}{"roles": []interface{}{"supervisor"}},
+		"groups":       []interface{}{"/teams/sales-berlin-1"},
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := validateToken(token)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(claims.Teams) != 2 || !claims.IsTeamAllowed("sales-berlin-2") || claims.IsTeamAllowed("support-munich-1") {
+		t.Errorf("expected group and directory teams without duplicates, got %v", claims.Teams)
+	}
+}
+
+func TestMappingAgentID(t *testing.T) {
+	m := DefaultMapping()
+	if id := m.agentID(jwt.MapClaims{"custom:agent_id": "agent-0042"}); id != "agent-0042" {
+		t.Errorf("expected agent-0042 from cognito attribute, got %q", id)
+	}
+	if id := m.agentID(jwt.MapClaims{"sub": "user"}); id != "" {
+		t.Errorf("expected no agent ID, got %q", id)
+	}
+
+	m.AgentIDClaims = []string{"monti.agent"}
+	claims := jwt.MapClaims{"monti": map[string]interface{}{"agent": "agent-0007"}}
+	if id := m.agentID(claims); id != "agent-0007" {
+		t.Errorf("expected nested claim agent-0007, got %q", id)
+	}
+}
+
+func TestMappingTenant(t *testing.T) {
+	m := DefaultMapping()
+	if tenant := m.tenant(jwt.MapClaims{"custom:tenant": "acme"}); tenant != "acme" {
+		t.Errorf("expected acme from cognito attribute, got %q", tenant)
+	}
+	if tenant := m.tenant(jwt.MapClaims{"sub": "user"}); tenant != types.DefaultTenant {
+		t.Errorf("expected the default tenant, got %q", tenant)
+	}
+
+	claims := &Claims{Tenant: "acme", AllowedLocations: types.AllLocations}
+	if claims.IsAgentAllowed("", types.LocationBerlin, types.DeptSales, "Team A") {
+		t.Error("expected default tenant agents hidden from an acme user")
+	}
+	if !claims.IsAgentAllowed("acme", types.LocationBerlin, types.DeptSales, "Team A") {
+		t.Error("expected acme agents visible to an acme user")
+	}
+}
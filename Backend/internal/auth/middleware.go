@@ -2,26 +2,33 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
-	"sync"
 	"time"
 
-	"github.com/MicahParks/keyfunc/v3"
+	"github.com/MicahParks/jwkset"
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/metrics"
 	"github.com/dennisdiepolder/monti/backend/internal/types"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog"
 )
 
 type Claims struct {
-	Email            string           `json:"email"`
-	Name             string           `json:"name"`
-	Role             string           `json:"role"`
-	Groups           []string         `json:"groups"`
-	BusinessUnits    []string         `json:"businessUnits"`    // Extracted from groups (e.g., SGB, NGB, RGB)
-	AllowedLocations []types.Location `json:"allowedLocations"` // Computed from BUs or admin override
+	Email            string             `json:"email"`
+	Name             string             `json:"name"`
+	Role             string             `json:"role"`
+	Groups           []string           `json:"groups"`
+	BusinessUnits    []string           `json:"businessUnits"`         // Extracted from groups (e.g., SGB, NGB, RGB)
+	AllowedLocations []types.Location   `json:"allowedLocations"`      // Computed from BUs or admin override
+	Teams            []string           `json:"teams,omitempty"`       // From /teams/<name> groups; empty = all teams
+	Departments      []types.Department `json:"departments,omitempty"` // From /departments/<name> groups; empty = all departments
+	AgentID          string             `json:"agentId,omitempty"`     // From the mapping's agent ID claims; set for agent desktop users
+	Tenant           string             `json:"tenant"`                // From the mapping's tenant claims; types.DefaultTenant when absent
 	jwt.RegisteredClaims
 }
 
@@ -29,69 +36,11 @@ type contextKey string
 
 const UserContextKey contextKey = "user"
 
-// JWKSManager handles JWKS fetching and caching
-type JWKSManager struct {
-	jwks       keyfunc.Keyfunc
-	issuerURL  string
-	mu         sync.RWMutex
-	lastUpdate time.Time
-}
-
-var (
-	jwksManager *JWKSManager
-)
-
-// InitJWKS initializes the JWKS manager for token verification.
-// Retries up to maxAttempts times with a delay between attempts to handle
-// cases where Keycloak is still starting up.
-func InitJWKS(issuerURL string, maxAttempts int) error {
-	jwksManager = &JWKSManager{issuerURL: issuerURL}
-
-	var lastErr error
-	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		if err := jwksManager.refresh(); err != nil {
-			lastErr = err
-			log.Printf("[Auth] JWKS fetch attempt %d/%d failed: %v", attempt, maxAttempts, err)
-			if attempt < maxAttempts {
-				time.Sleep(3 * time.Second)
-			}
-			continue
-		}
-		return nil
-	}
-	return fmt.Errorf("failed to initialize JWKS after %d attempts: %w", maxAttempts, lastErr)
-}
-
-// refresh fetches the JWKS from the OIDC provider
-func (m *JWKSManager) refresh() error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	// Construct JWKS URL (Keycloak format)
-	jwksURL := strings.TrimSuffix(m.issuerURL, "/") + "/protocol/openid-connect/certs"
-	log.Printf("[Auth] Fetching JWKS from: %s", jwksURL)
-
-	// Create keyfunc with options
-	k, err := keyfunc.NewDefault([]string{jwksURL})
-	if err != nil {
-		return fmt.Errorf("failed to create keyfunc: %w", err)
-	}
-
-	m.jwks = k
-	m.lastUpdate = time.Now()
-	log.Printf("[Auth] JWKS loaded successfully")
-	return nil
-}
+var logger = zerolog.Nop()
 
-// getKeyfunc returns the JWT keyfunc for token verification
-func (m *JWKSManager) getKeyfunc() jwt.Keyfunc {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	if m.jwks == nil {
-		return nil
-	}
-	return m.jwks.Keyfunc
+// SetLogger sets the logger used by the auth package (call at startup)
+func SetLogger(l zerolog.Logger) {
+	logger = l.With().Str("component", "auth").Logger()
 }
 
 // Middleware validates JWT tokens from OIDC provider
@@ -106,7 +55,7 @@ func Middleware(next http.Handler) http.Handler {
 		// In development mode, you can bypass auth
 		skipAuth := os.Getenv("SKIP_AUTH")
 		if skipAuth == "true" {
-			log.Println("[Auth] SKIP_AUTH enabled - bypassing authentication")
+			logger.Debug().Str("path", r.URL.Path).Msg("SKIP_AUTH enabled - bypassing authentication")
 			// Create a default dev user with admin role (sees all locations)
 			ctx := context.WithValue(r.Context(), UserContextKey, &Claims{
 				Email:            "dev@monti.local",
@@ -115,28 +64,73 @@ func Middleware(next http.Handler) http.Handler {
 				Groups:           []string{"developers", "monti-admins"},
 				BusinessUnits:    []string{}, // Admin doesn't need specific BUs
 				AllowedLocations: types.AllLocations,
+				AgentID:          r.URL.Query().Get("agentId"), // lets /ws/agent/desktop be tried locally
 			})
 			next.ServeHTTP(w, r.WithContext(ctx))
 			return
 		}
 
+		// Reject clients locked out after repeated invalid tokens
+		now := time.Now()
+		ip := clientIP(r)
+		if until, locked := lockouts.lockedUntil("ip:"+ip, now); locked {
+			metrics.Get().RecordAuthFailure("locked_out")
+			rejectLockedOut(w, r, until, now)
+			return
+		}
+
 		// Extract token from Authorization header or query parameter
 		tokenString := extractToken(r)
 		if tokenString == "" {
-			log.Println("[Auth] Missing authorization token")
-			http.Error(w, "Unauthorized: Missing token", http.StatusUnauthorized)
+			metrics.Get().RecordAuthFailure("missing_token")
+			logger.Debug().Str("ip", ip).Str("path", r.URL.Path).Msg("missing authorization token")
+			apierror.Write(w, r, http.StatusUnauthorized, "missing token")
 			return
 		}
 
-		// Validate token
-		claims, err := validateToken(tokenString)
-		if err != nil {
-			log.Printf("[Auth] Token validation failed: %v", err)
-			http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
-			return
+		// Validate token (reusing a recent validation of the same token if cached)
+		claims, ok := tokens.get(tokenString)
+		if !ok {
+			ipThreshold, userThreshold := lockouts.thresholds()
+			var sub string
+			if userThreshold > 0 {
+				sub = unverifiedSubject(tokenString)
+				if until, locked := lockouts.lockedUntil("user:"+sub, now); sub != "" && locked {
+					metrics.Get().RecordAuthFailure("locked_out")
+					rejectLockedOut(w, r, until, now)
+					return
+				}
+			}
+
+			var err error
+			claims, err = validateToken(tokenString)
+			if err != nil {
+				reason := failureReason(err)
+				metrics.Get().RecordAuthFailure(reason)
+				logger.Warn().Err(err).
+					Str("reason", reason).
+					Str("ip", ip).
+					Str("path", r.URL.Path).
+					Msg("token validation failed")
+
+				if countsTowardLockout(reason) {
+					if lockouts.fail("ip:"+ip, ipThreshold, now) {
+						metrics.Get().RecordAuthLockout("ip")
+						logger.Warn().Str("ip", ip).Msg("client IP locked out after repeated invalid tokens")
+					}
+					if sub != "" && lockouts.fail("user:"+sub, userThreshold, now) {
+						metrics.Get().RecordAuthLockout("user")
+						logger.Warn().Str("sub", sub).Msg("user locked out after repeated invalid tokens")
+					}
+				}
+
+				apierror.Write(w, r, http.StatusUnauthorized, fmt.Sprintf("invalid token: %v", err))
+				return
+			}
+			tokens.put(tokenString, claims)
 		}
 
-		log.Printf("[Auth] User authenticated: %s (%s)", claims.Email, claims.Role)
+		logger.Debug().Str("email", claims.Email).Str("role", claims.Role).Msg("user authenticated")
 
 		// Add user to context
 		ctx := context.WithValue(r.Context(), UserContextKey, claims)
@@ -144,6 +138,36 @@ func Middleware(next http.Handler) http.Handler {
 	})
 }
 
+// rejectLockedOut answers a locked-out client with 429 and Retry-After
+func rejectLockedOut(w http.ResponseWriter, r *http.Request, until, now time.Time) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(until.Sub(now).Seconds())+1))
+	apierror.WriteCode(w, r, http.StatusTooManyRequests, types.ErrCodeLockedOut, "too many failed authentication attempts")
+}
+
+// failureReason classifies a validation error for metrics and logs
+func failureReason(err error) string {
+	switch {
+	case errors.Is(err, errJWKSUnavailable):
+		return "jwks_unavailable"
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return "expired"
+	case errors.Is(err, jwkset.ErrKeyNotFound):
+		return "unknown_key"
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		return "invalid_signature"
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return "malformed"
+	default:
+		return "invalid"
+	}
+}
+
+// countsTowardLockout reports whether a failure looks like a forged or guessed token.
+// Expired tokens (e.g. a wallboard left running) and IdP outages never lock clients out.
+func countsTowardLockout(reason string) bool {
+	return reason != "expired" && reason != "jwks_unavailable"
+}
+
 // extractToken gets the token from Authorization header or query parameter
 func extractToken(r *http.Request) string {
 	// Try Authorization header first
@@ -185,7 +209,7 @@ func validateToken(tokenString string) (*Claims, error) {
 		}
 	} else {
 		// Development: Parse without verification (for local testing)
-		log.Println("[Auth] WARNING: JWT signature verification disabled (development mode)")
+		logger.Debug().Msg("JWT signature verification disabled (development mode)")
 		token, _, err = new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -212,147 +236,57 @@ func validateToken(tokenString string) (*Claims, error) {
 		claims.Name = preferredUsername
 	}
 
-	// Extract role from various possible locations
-	claims.Role = extractRoleFromMapClaims(mapClaims)
-
-	// Extract groups
-	claims.Groups = extractGroupsFromMapClaims(mapClaims)
+	// Map role and groups using the configured claim mapping
+	m := activeMapping()
+	claims.Role = m.role(mapClaims)
+	claims.Groups = m.groups(mapClaims)
+	claims.AgentID = m.agentID(mapClaims)
+	claims.Tenant = m.tenant(mapClaims)
 
 	// Extract business units from groups and compute allowed locations
-	claims.BusinessUnits = extractBusinessUnits(claims.Groups)
-	claims.AllowedLocations = computeAllowedLocations(claims.Role, claims.BusinessUnits)
+	claims.BusinessUnits = extractGroupNames(claims.Groups, m.BusinessUnitPrefix)
+	claims.AllowedLocations = computeAllowedLocations(claims.Role, claims.BusinessUnits, m.BusinessUnits)
+
+	// Team leads are additionally scoped to their teams/departments (admins never are)
+	if claims.Role != "admin" {
+		claims.Teams = extractGroupNames(claims.Groups, m.TeamPrefix)
+		if claims.Role == "supervisor" {
+			claims.Teams = appendUnique(claims.Teams, supervisedTeams(claims.Email)...)
+		}
+		for _, dept := range extractGroupNames(claims.Groups, m.DepartmentPrefix) {
+			claims.Departments = append(claims.Departments, types.Department(dept))
+		}
+	}
 
 	// Extract standard claims
 	if sub, ok := mapClaims["sub"].(string); ok {
 		claims.Subject = sub
 	}
 
-	// Check expiration (for unverified tokens - verified tokens check this automatically)
-	if !verifySignature {
-		if exp, ok := mapClaims["exp"].(float64); ok {
-			expTime := time.Unix(int64(exp), 0)
-			claims.ExpiresAt = jwt.NewNumericDate(expTime)
-			if expTime.Before(time.Now()) {
-				return nil, fmt.Errorf("token expired")
-			}
+	// Check expiration (for unverified tokens - verified tokens check this automatically).
+	// ExpiresAt is always set so cached validations never outlive the token.
+	if exp, ok := mapClaims["exp"].(float64); ok {
+		expTime := time.Unix(int64(exp), 0)
+		claims.ExpiresAt = jwt.NewNumericDate(expTime)
+		if !verifySignature && expTime.Before(time.Now()) {
+			return nil, jwt.ErrTokenExpired
 		}
 	}
 
-	log.Printf("[Auth] Token parsed: email=%s, role=%s, groups=%v, businessUnits=%v, allowedLocations=%v",
-		claims.Email, claims.Role, claims.Groups, claims.BusinessUnits, claims.AllowedLocations)
+	logger.Debug().
+		Str("email", claims.Email).
+		Str("role", claims.Role).
+		Str("tenant", claims.Tenant).
+		Strs("groups", claims.Groups).
+		Strs("business_units", claims.BusinessUnits).
+		Interface("allowed_locations", claims.AllowedLocations).
+		Strs("teams", claims.Teams).
+		Interface("departments", claims.Departments).
+		Msg("token parsed")
 
 	return claims, nil
 }
 
-// parseAndVerifyToken verifies the JWT signature using JWKS
-func parseAndVerifyToken(tokenString string) (*jwt.Token, error) {
-	if jwksManager == nil {
-		return nil, fmt.Errorf("JWKS not initialized - call InitJWKS on startup")
-	}
-
-	keyfunc := jwksManager.getKeyfunc()
-	if keyfunc == nil {
-		// Try one refresh in case it recovered
-		if err := jwksManager.refresh(); err != nil {
-			return nil, fmt.Errorf("JWKS not available and refresh failed: %w", err)
-		}
-		keyfunc = jwksManager.getKeyfunc()
-		if keyfunc == nil {
-			return nil, fmt.Errorf("JWKS not available after refresh")
-		}
-	}
-
-	// Parse and verify the token
-	token, err := jwt.Parse(tokenString, keyfunc, jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}))
-	if err != nil {
-		return nil, fmt.Errorf("token verification failed: %w", err)
-	}
-
-	if !token.Valid {
-		return nil, fmt.Errorf("invalid token")
-	}
-
-	return token, nil
-}
-
-// extractRoleFromMapClaims extracts role from various possible token claim locations
-func extractRoleFromMapClaims(mapClaims jwt.MapClaims) string {
-	// Check realm_access.roles (Keycloak)
-	if realmAccess, ok := mapClaims["realm_access"].(map[string]interface{}); ok {
-		if roles, ok := realmAccess["roles"].([]interface{}); ok {
-			// Priority order: admin > supervisor > agent > viewer
-			for _, priority := range []string{"admin", "supervisor", "agent", "viewer"} {
-				for _, role := range roles {
-					if roleStr, ok := role.(string); ok && roleStr == priority {
-						return roleStr
-					}
-				}
-			}
-		}
-	}
-
-	// Check cognito:groups (AWS Cognito)
-	if cognitoGroups, ok := mapClaims["cognito:groups"].([]interface{}); ok {
-		for _, group := range cognitoGroups {
-			if groupStr, ok := group.(string); ok {
-				if strings.Contains(groupStr, "admin") {
-					return "admin"
-				}
-				if strings.Contains(groupStr, "supervisor") {
-					return "supervisor"
-				}
-				if strings.Contains(groupStr, "agent") {
-					return "agent"
-				}
-			}
-		}
-	}
-
-	// Check custom:groups
-	if customGroups, ok := mapClaims["custom:groups"].([]interface{}); ok {
-		for _, group := range customGroups {
-			if groupStr, ok := group.(string); ok {
-				if strings.Contains(groupStr, "admin") {
-					return "admin"
-				}
-				if strings.Contains(groupStr, "supervisor") {
-					return "supervisor"
-				}
-				if strings.Contains(groupStr, "agent") {
-					return "agent"
-				}
-			}
-		}
-	}
-
-	return "viewer" // default role
-}
-
-// extractGroupsFromMapClaims extracts groups from token claims
-func extractGroupsFromMapClaims(mapClaims jwt.MapClaims) []string {
-	var groups []string
-
-	// Check groups claim
-	if groupsClaim, ok := mapClaims["groups"].([]interface{}); ok {
-		for _, group := range groupsClaim {
-			if groupStr, ok := group.(string); ok {
-				groups = append(groups, groupStr)
-			}
-		}
-	}
-
-	// Check cognito:groups
-	if cognitoGroups, ok := mapClaims["cognito:groups"].([]interface{}); ok {
-		for _, group := range cognitoGroups {
-			if groupStr, ok := group.(string); ok {
-				groups = append(groups, groupStr)
-			}
-		}
-	}
-
-	return groups
-}
-
 // GetUserFromContext retrieves user claims from request context
 func GetUserFromContext(ctx context.Context) (*Claims, bool) {
 	claims, ok := ctx.Value(UserContextKey).(*Claims)
@@ -374,32 +308,46 @@ func InGroup(claims *Claims, group string) bool {
 	return false
 }
 
-// extractBusinessUnits parses business unit names from group paths
-// Groups are expected in format: /business-units/SGB, /business-units/NGB, etc.
-func extractBusinessUnits(groups []string) []string {
-	var businessUnits []string
-	buPrefix := "/business-units/"
-
-	for _, group := range groups {
-		if strings.HasPrefix(group, buPrefix) {
-			bu := strings.TrimPrefix(group, buPrefix)
-			// Remove any trailing path components
-			if idx := strings.Index(bu, "/"); idx > 0 {
-				bu = bu[:idx]
-			}
-			if bu != "" {
-				businessUnits = append(businessUnits, bu)
+// appendUnique appends the names not already in list
+func appendUnique(list []string, names ...string) []string {
+	for _, name := range names {
+		found := false
+		for _, existing := range list {
+			if existing == name {
+				found = true
+				break
 			}
 		}
+		if !found {
+			list = append(list, name)
+		}
 	}
+	return list
+}
 
-	return businessUnits
+// extractGroupNames returns the first path component after prefix for each matching group
+// e.g. prefix "/teams/" turns "/teams/sales-berlin-1" into "sales-berlin-1"
+func extractGroupNames(groups []string, prefix string) []string {
+	var names []string
+	for _, group := range groups {
+		if !strings.HasPrefix(group, prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(group, prefix)
+		if idx := strings.Index(name, "/"); idx > 0 {
+			name = name[:idx]
+		}
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
 }
 
 // computeAllowedLocations maps business units to their allowed locations
 // Admin role overrides and gets access to all locations
 // If no BUs are assigned, returns empty slice (fail secure)
-func computeAllowedLocations(role string, businessUnits []string) []types.Location {
+func computeAllowedLocations(role string, businessUnits []string, buLocations map[types.BusinessUnit][]types.Location) []types.Location {
 	// Admin role sees everything
 	if role == "admin" {
 		return types.AllLocations
@@ -409,7 +357,7 @@ func computeAllowedLocations(role string, businessUnits []string) []types.Locati
 	locationSet := make(map[types.Location]bool)
 	for _, buName := range businessUnits {
 		bu := types.BusinessUnit(buName)
-		if locations, ok := types.BULocationMapping[bu]; ok {
+		if locations, ok := buLocations[bu]; ok {
 			for _, loc := range locations {
 				locationSet[loc] = true
 			}
@@ -434,3 +382,46 @@ func (c *Claims) IsLocationAllowed(location types.Location) bool {
 	}
 	return false
 }
+
+// IsTeamAllowed checks the team scope (no team groups = all teams)
+func (c *Claims) IsTeamAllowed(team string) bool {
+	if len(c.Teams) == 0 {
+		return true
+	}
+	for _, t := range c.Teams {
+		if t == team {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDepartmentAllowed checks the department scope (no department groups = all departments)
+func (c *Claims) IsDepartmentAllowed(dept types.Department) bool {
+	if len(c.Departments) == 0 {
+		return true
+	}
+	for _, d := range c.Departments {
+		if d == dept {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTenantAllowed checks that tenant (empty = default) is the user's own; users never see
+// other tenants, admins included
+func (c *Claims) IsTenantAllowed(tenant string) bool {
+	return types.TenantOf(tenant) == types.TenantOf(c.Tenant)
+}
+
+// IsAgentAllowed checks tenant, location, department and team scope for an agent
+func (c *Claims) IsAgentAllowed(tenant string, location types.Location, dept types.Department, team string) bool {
+	return c.IsTenantAllowed(tenant) && c.IsLocationAllowed(location) && c.IsDepartmentAllowed(dept) && c.IsTeamAllowed(team)
+}
+
+// IsUnrestricted reports whether the claims see every agent (all locations, no team/department
+// scope). Nobody is unrestricted while several tenants are hosted.
+func (c *Claims) IsUnrestricted() bool {
+	return !types.MultiTenant() && len(c.AllowedLocations) == len(types.AllLocations) && len(c.Teams) == 0 && len(c.Departments) == 0
+}
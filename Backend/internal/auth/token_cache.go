@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// maxCachedTokens bounds the validated-token cache; when full, expired entries are
+// swept and, if that frees nothing, the cache is cleared
+const maxCachedTokens = 10000
+
+// tokenCache holds validated claims keyed by token hash, so polling clients do not pay
+// for a full JWT parse and signature check on every request
+type tokenCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[[sha256.Size]byte]cachedToken
+}
+
+type cachedToken struct {
+	claims  *Claims
+	expires time.Time
+}
+
+var tokens = &tokenCache{
+	ttl:     30 * time.Second,
+	entries: make(map[[sha256.Size]byte]cachedToken),
+}
+
+// SetTokenCacheTTL sets how long validated tokens are reused; 0 disables the cache.
+// A short TTL bounds how long a token revoked at the IdP keeps working.
+func SetTokenCacheTTL(ttl time.Duration) {
+	tokens.mu.Lock()
+	defer tokens.mu.Unlock()
+	tokens.ttl = ttl
+	tokens.entries = make(map[[sha256.Size]byte]cachedToken)
+}
+
+// get returns a copy of the cached claims for tokenString, if still valid
+func (c *tokenCache) get(tokenString string) (*Claims, bool) {
+	key := sha256.Sum256([]byte(tokenString))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ttl <= 0 {
+		return nil, false
+	}
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !time.Now().Before(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	claims := *entry.claims
+	return &claims, true
+}
+
+// put caches claims until the TTL passes or the token expires, whichever is first
+func (c *tokenCache) put(tokenString string, claims *Claims) {
+	now := time.Now()
+	expires := now.Add(c.currentTTL())
+	if claims.ExpiresAt != nil && claims.ExpiresAt.Time.Before(expires) {
+		expires = claims.ExpiresAt.Time
+	}
+	if !now.Before(expires) {
+		return
+	}
+	key := sha256.Sum256([]byte(tokenString))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxCachedTokens {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxCachedTokens {
+			c.entries = make(map[[sha256.Size]byte]cachedToken)
+		}
+	}
+	stored := *claims
+	c.entries[key] = cachedToken{claims: &stored, expires: expires}
+}
+
+func (c *tokenCache) currentTTL() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ttl
+}
+
+// flush drops all cached tokens (after a key refresh or claim mapping change)
+func (c *tokenCache) flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[[sha256.Size]byte]cachedToken)
+}
@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestTokenCache(t *testing.T) {
+	c := &tokenCache{ttl: time.Minute, entries: make(map[[32]byte]cachedToken)}
+
+	c.put("token-a", &Claims{Email: "a@example.com"})
+	got, ok := c.get("token-a")
+	if !ok || got.Email != "a@example.com" {
+		t.Fatalf("expected cached claims for token-a, got %v %v", got, ok)
+	}
+	got.Email = "mutated"
+	if again, _ := c.get("token-a"); again.Email != "a@example.com" {
+		t.Error("expected get to return a copy of the cached claims")
+	}
+	if _, ok := c.get("token-b"); ok {
+		t.Error("expected miss for unknown token")
+	}
+
+	c.flush()
+	if _, ok := c.get("token-a"); ok {
+		t.Error("expected miss after flush")
+	}
+}
+
+func TestTokenCacheRespectsTokenExpiry(t *testing.T) {
+	c := &tokenCache{ttl: time.Hour, entries: make(map[[32]byte]cachedToken)}
+
+	// Already expired tokens are never cached
+	c.put("expired", &Claims{RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Second))}})
+	if _, ok := c.get("expired"); ok {
+		t.Error("expected expired token not to be cached")
+	}
+
+	// Entries expire with the token even when the TTL is longer
+	c.put("short", &Claims{RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}})
+	key := [32]byte{}
+	for k := range c.entries {
+		key = k
+	}
+	entry := c.entries[key]
+	if entry.expires.After(time.Now().Add(time.Hour)) {
+		t.Errorf("expected entry to expire with the token, got %v", entry.expires)
+	}
+	entry.expires = time.Now().Add(-time.Millisecond)
+	c.entries[key] = entry
+	if _, ok := c.get("short"); ok {
+		t.Error("expected miss after entry expiry")
+	}
+}
+
+func TestTokenCacheDisabled(t *testing.T) {
+	c := &tokenCache{ttl: 0, entries: make(map[[32]byte]cachedToken)}
+	c.put("token-a", &Claims{Email: "a@example.com"})
+	if _, ok := c.get("token-a"); ok {
+		t.Error("expected no caching with a zero TTL")
+	}
+}
@@ -0,0 +1,247 @@
+// Package backfill recomputes the persisted daily stats of agents and VQs from the raw call
+// records, e.g. after an outage of the jobs that write them or a change to how they are
+// aggregated.
+package backfill
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/kpi"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// Store reads call records and state segments and writes daily stats (implemented by
+// storage.Store)
+type Store interface {
+	GetCallRecords(dateKey string) ([]types.CallRecord, error)
+	GetStateSegments(agentID string, from, to time.Time) ([]types.StateSegment, error)
+	GetVQDailyStats(dateKey string) ([]types.VQDailyStats, error)
+	SaveAgentDailyStats(stats types.AgentDailyStats) error
+	SaveVQDailyStats(stats types.VQDailyStats) error
+}
+
+// Service backfills the daily stats of a range of days
+type Service struct {
+	store    Store
+	dayStart time.Duration // business day start of the VQ daily stats
+	logger   zerolog.Logger
+}
+
+// NewService creates a new backfill service; dayStart is BUSINESS_DAY_START
+func NewService(store Store, dayStart time.Duration, logger zerolog.Logger) *Service {
+	return &Service{
+		store:    store,
+		dayStart: dayStart,
+		logger:   logger.With().Str("component", "backfill").Logger(),
+	}
+}
+
+// Run recomputes the daily stats of every tenant for the days from to to (inclusive,
+// YYYY-MM-DD). VQ business days that haven't ended by now are skipped; the rollover writes
+// them.
+func (s *Service) Run(from, to string, now time.Time) (types.BackfillResponse, error) {
+	var resp types.BackfillResponse
+	first, err := time.ParseInLocation(types.DateLayout, from, types.ReportingLocation)
+	if err != nil {
+		return resp, fmt.Errorf("invalid from date %q: %w", from, err)
+	}
+	last, err := time.ParseInLocation(types.DateLayout, to, types.ReportingLocation)
+	if err != nil {
+		return resp, fmt.Errorf("invalid to date %q: %w", to, err)
+	}
+
+	// A VQ business day also takes the calls of the next date after BUSINESS_DAY_START, so
+	// each date's records are read once for both days
+	cached := make(map[string][]types.CallRecord)
+	calls := func(key string) ([]types.CallRecord, error) {
+		if records, ok := cached[key]; ok {
+			return records, nil
+		}
+		records, err := s.store.GetCallRecords(key)
+		if err != nil {
+			return nil, fmt.Errorf("get call records %s: %w", key, err)
+		}
+		cached[key] = records
+		resp.CallRecords += len(records)
+		return records, nil
+	}
+
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		date := day.Format(types.DateLayout)
+		next := day.AddDate(0, 0, 1).Format(types.DateLayout)
+		resp.Days++
+
+		for _, tenant := range types.AllTenants() {
+			records, err := calls(types.TenantKey(tenant, date))
+			if err != nil {
+				return resp, err
+			}
+			agents, err := s.agentDays(date, records)
+			if err != nil {
+				return resp, err
+			}
+			resp.AgentDays += agents
+
+			start, end, _ := types.BusinessDayBounds(date, s.dayStart)
+			if end.After(now) {
+				delete(cached, types.TenantKey(tenant, date))
+				continue
+			}
+			nextRecords, err := calls(types.TenantKey(tenant, next))
+			if err != nil {
+				return resp, err
+			}
+			existing, err := s.store.GetVQDailyStats(types.TenantKey(tenant, date))
+			if err != nil {
+				return resp, fmt.Errorf("get VQ daily stats %s: %w", date, err)
+			}
+			for _, stats := range VQDaily(start, end, append(append([]types.CallRecord(nil), records...), nextRecords...), existing) {
+				stats.DateKey = types.TenantKey(tenant, date)
+				if tenant != types.DefaultTenant {
+					stats.Tenant = tenant
+				}
+				if err := s.store.SaveVQDailyStats(stats); err != nil {
+					return resp, fmt.Errorf("save VQ daily stats %s %s: %w", date, stats.VQ, err)
+				}
+				resp.VQDays++
+			}
+			delete(cached, types.TenantKey(tenant, date))
+		}
+	}
+
+	s.logger.Info().
+		Str("from", from).
+		Str("to", to).
+		Int("call_records", resp.CallRecords).
+		Int("agent_days", resp.AgentDays).
+		Int("vq_days", resp.VQDays).
+		Msg("daily stats backfilled")
+	return resp, nil
+}
+
+// agentDays writes the daily stats of every agent with calls in records, returning how many
+func (s *Service) agentDays(date string, records []types.CallRecord) (int, error) {
+	byAgent := make(map[string][]types.CallRecord)
+	for _, rec := range records {
+		if rec.AgentID != "" && !rec.Abandoned {
+			byAgent[rec.AgentID] = append(byAgent[rec.AgentID], rec)
+		}
+	}
+	start, end, err := types.DayBounds(date)
+	if err != nil {
+		return 0, err
+	}
+	for agentID, calls := range byAgent {
+		segments, err := s.store.GetStateSegments(agentID, start, end)
+		if err != nil {
+			return 0, fmt.Errorf("get state segments of %s: %w", agentID, err)
+		}
+		if err := s.store.SaveAgentDailyStats(AgentDaily(agentID, date, calls, segments)); err != nil {
+			return 0, fmt.Errorf("save daily stats of %s: %w", agentID, err)
+		}
+	}
+	return len(byAgent), nil
+}
+
+// AgentDaily computes an agent's stats for a reporting day from its completed calls and the
+// state segments of its timeline, which give the break, login and occupancy figures
+func AgentDaily(agentID, date string, calls []types.CallRecord, segments []types.StateSegment) types.AgentDailyStats {
+	stats := types.AgentDailyStats{AgentID: agentID, Date: date}
+	for _, call := range calls {
+		if stats.Department == "" {
+			stats.Department = call.Department
+		}
+		stats.TotalCalls++
+		stats.TotalTalkTime += call.TalkTime
+		stats.TotalHoldTime += call.HoldTime
+		stats.TotalWrapTime += call.WrapTime
+	}
+	if stats.TotalCalls > 0 {
+		stats.AvgHandleTime = (stats.TotalTalkTime + stats.TotalHoldTime + stats.TotalWrapTime) / float64(stats.TotalCalls)
+	}
+
+	start, end, err := types.DayBounds(date)
+	if err != nil {
+		return stats
+	}
+	var handling, available float64
+	for _, seg := range segments {
+		secs := overlapSecs(start, end, seg.Start, seg.End)
+		if secs == 0 || seg.State == types.StateOffline {
+			continue
+		}
+		stats.LoginDuration += secs
+		switch {
+		case kpi.Handling(seg.State):
+			handling += secs
+		case seg.State == types.StateAvailable:
+			available += secs
+		case seg.State == types.StateBreak || seg.State == types.StateLunch:
+			stats.TotalBreakTime += secs
+		}
+	}
+	if handling+available > 0 {
+		stats.Occupancy = handling / (handling + available) * 100
+	}
+	return stats
+}
+
+// VQDaily computes the totals of every VQ over a business day from the calls completed in it.
+// Abandoned calls have no call records, so the abandoned counts of the existing stats are
+// kept.
+func VQDaily(start, end time.Time, calls []types.CallRecord, existing []types.VQDailyStats) []types.VQDailyStats {
+	byVQ := make(map[types.VQName]*types.VQDailyStats)
+	get := func(vq types.VQName, dept types.Department) *types.VQDailyStats {
+		stats, ok := byVQ[vq]
+		if !ok {
+			stats = &types.VQDailyStats{VQ: vq, Department: dept, From: start, To: end}
+			byVQ[vq] = stats
+		}
+		return stats
+	}
+	for _, old := range existing {
+		get(old.VQ, old.Department).Abandoned = old.Abandoned
+	}
+	for _, call := range calls {
+		completed, err := time.Parse(time.RFC3339, call.CompleteTime)
+		if err != nil || call.Abandoned || completed.Before(start) || !completed.Before(end) {
+			continue
+		}
+		stats := get(call.VQ, types.Department(call.Department))
+		stats.Completed++
+		if call.AssignTime != "" {
+			stats.TotalAnswered++
+			if call.AnsweredInSL {
+				stats.AnsweredInSL++
+			}
+		}
+	}
+
+	out := make([]types.VQDailyStats, 0, len(byVQ))
+	for _, stats := range byVQ {
+		stats.ServiceLevel = 100
+		if stats.TotalAnswered > 0 {
+			stats.ServiceLevel = float64(stats.AnsweredInSL) / float64(stats.TotalAnswered) * 100
+		}
+		out = append(out, *stats)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].VQ < out[j].VQ })
+	return out
+}
+
+// overlapSecs returns how many seconds two intervals share
+func overlapSecs(aStart, aEnd, bStart, bEnd time.Time) float64 {
+	if bStart.After(aStart) {
+		aStart = bStart
+	}
+	if bEnd.Before(aEnd) {
+		aEnd = bEnd
+	}
+	if !aEnd.After(aStart) {
+		return 0
+	}
+	return aEnd.Sub(aStart).Seconds()
+}
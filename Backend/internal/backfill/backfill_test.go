@@ -0,0 +1,71 @@
+package backfill
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/storage"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+func TestRunRecomputesDailyStats(t *testing.T) {
+	store := storage.NewMemoryStore()
+	at := func(day, hour, min int) string {
+		return time.Date(2026, 3, day, hour, min, 0, 0, time.UTC).Format(time.RFC3339)
+	}
+	call := func(id, dateKey, agent string, completed string, inSL bool) types.CallRecord {
+		return types.CallRecord{DateKey: dateKey, CallID: id, VQ: types.VQSalesInbound, Department: "sales", AgentID: agent,
+			AssignTime: completed, CompleteTime: completed, TalkTime: 100, HoldTime: 20, WrapTime: 30, AnsweredInSL: inSL}
+	}
+	for _, rec := range []types.CallRecord{
+		call("c1", "2026-03-02", "a1", at(2, 9, 0), true),
+		call("c2", "2026-03-02", "a1", at(2, 10, 0), false),
+		call("c3", "2026-03-02", "a2", at(2, 5, 0), true),  // before the 06:00 business day start
+		call("c4", "2026-03-03", "a2", at(3, 5, 30), true), // still the business day of 03-02
+	} {
+		store.SaveCallRecord(rec)
+	}
+	store.SaveStateSegment(types.StateSegment{AgentID: "a1", StartKey: types.SegmentStartKey(time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)),
+		State: types.StateOnCall, Start: time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC), End: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)})
+	store.SaveStateSegment(types.StateSegment{AgentID: "a1", StartKey: types.SegmentStartKey(time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)),
+		State: types.StateAvailable, Start: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC), End: time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)})
+	store.SaveStateSegment(types.StateSegment{AgentID: "a1", StartKey: types.SegmentStartKey(time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)),
+		State: types.StateBreak, Start: time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC), End: time.Date(2026, 3, 2, 10, 15, 0, 0, time.UTC)})
+	// The rollover saved the abandoned calls, which have no call records
+	store.SaveVQDailyStats(types.VQDailyStats{DateKey: "2026-03-02", VQ: types.VQSalesInbound, Department: types.DeptSales, Abandoned: 4, Completed: 1})
+
+	svc := NewService(store, 6*time.Hour, zerolog.Nop())
+	resp, err := svc.Run("2026-03-02", "2026-03-03", time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 03-03's business day runs until 06:00 on 03-04 and is left to the rollover
+	if resp.Days != 2 || resp.CallRecords != 4 || resp.AgentDays != 3 || resp.VQDays != 1 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	daily, _ := store.GetAgentDailyStats("a1")
+	if len(daily) != 1 {
+		t.Fatalf("expected one day for a1, got %+v", daily)
+	}
+	a1 := daily[0]
+	if a1.Date != "2026-03-02" || a1.TotalCalls != 2 || a1.AvgHandleTime != 150 || a1.Department != "sales" {
+		t.Errorf("unexpected call figures: %+v", a1)
+	}
+	if a1.LoginDuration != 8100 || a1.TotalBreakTime != 900 || a1.Occupancy != 50 {
+		t.Errorf("unexpected timeline figures: %+v", a1)
+	}
+
+	vq, _ := store.GetVQDailyStats("2026-03-02")
+	if len(vq) != 1 {
+		t.Fatalf("expected one VQ day, got %+v", vq)
+	}
+	if s := vq[0]; s.Completed != 3 || s.Abandoned != 4 || s.AnsweredInSL != 2 || s.TotalAnswered != 3 {
+		t.Errorf("expected c1, c2 and c4 with the kept abandoned count, got %+v", s)
+	}
+
+	if _, err := svc.Run("2026-03-xx", "2026-03-03", time.Now()); err == nil {
+		t.Error("expected an invalid date to be rejected")
+	}
+}
@@ -0,0 +1,154 @@
+package breaks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+func TestCapLimit(t *testing.T) {
+	cases := []struct {
+		cap    Cap
+		agents int
+		want   int
+	}{
+		{Cap{}, 100, 0},
+		{Cap{Percent: 5}, 100, 5},
+		{Cap{Percent: 5}, 10, 1}, // at least one
+		{Cap{Percent: 5}, 0, 1},
+		{Cap{Max: 3}, 100, 3},
+		{Cap{Percent: 10, Max: 3}, 100, 3},
+		{Cap{Percent: 10, Max: 30}, 100, 10},
+	}
+	for _, c := range cases {
+		if got := c.cap.Limit(c.agents); got != c.want {
+			t.Errorf("%+v with %d agents: expected %d, got %d", c.cap, c.agents, c.want, got)
+		}
+	}
+}
+
+func TestLoadFileValidates(t *testing.T) {
+	dir := t.TempDir()
+	write := func(body string) string {
+		path := filepath.Join(dir, "policy.json")
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	p, err := LoadFile(write(`{"default":{"total":{"percent":5}},"departments":{"support":{"total":{"max":2},"reasons":{"personal":{"max":1}}}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.For(types.DeptSupport).Total.Max; got != 2 {
+		t.Errorf("expected support max 2, got %d", got)
+	}
+	if got := p.For(types.DeptSales).Total.Percent; got != 5 {
+		t.Errorf("expected sales to fall back to the default, got %v", got)
+	}
+
+	for _, bad := range []string{
+		`{"departments":{"nowhere":{}}}`,
+		`{"default":{"total":{"percent":150}}}`,
+		`{"default":{"reasons":{"Bad Reason":{"max":1}}}}`,
+		`{"default":`,
+	} {
+		if _, err := LoadFile(write(bad)); err == nil {
+			t.Errorf("expected %s to be rejected", bad)
+		}
+	}
+}
+
+// register adds n connected sales agents named sales-a, sales-b, ...
+func register(tracker *cache.AgentStateTracker, n int) {
+	for i := 0; i < n; i++ {
+		tracker.RegisterAgent(&types.AgentRegister{
+			AgentID: "sales-" + string(rune('a'+i)), Department: types.DeptSales,
+			Location: types.LocationBerlin, Team: "Team A", State: types.StateAvailable,
+		})
+	}
+}
+
+func TestGuardEnforcesTotalAndReasonCaps(t *testing.T) {
+	tracker := cache.NewAgentStateTracker()
+	register(tracker, 10)
+	policy := Policy{Default: DepartmentPolicy{
+		Total:   Cap{Max: 2},
+		Reasons: map[types.BreakReason]Cap{types.BreakPersonal: {Max: 1}},
+	}}
+	g := NewGuard(policy, tracker, zerolog.Nop())
+
+	ask := func(agentID string, reason types.BreakReason) types.BreakResponse {
+		return g.Request(&types.BreakRequest{AgentID: agentID, Reason: reason})
+	}
+
+	if r := ask("sales-a", types.BreakPersonal); !r.Granted {
+		t.Fatalf("expected first personal break granted, got %+v", r)
+	}
+	// the grant holds its slot before the agent's state_change arrives
+	if r := ask("sales-b", types.BreakPersonal); r.Granted {
+		t.Errorf("expected second personal break refused, got %+v", r)
+	}
+	tracker.UpdateFromStateChange(&types.AgentStateChange{AgentID: "sales-a", PreviousState: types.StateAvailable,
+		NewState: types.StateBreak, Reason: types.BreakPersonal})
+	if r := ask("sales-b", ""); !r.Granted || r.Reason != types.DefaultBreakReason {
+		t.Errorf("expected a default-reason break granted, got %+v", r)
+	}
+	if r := ask("sales-c", types.BreakWellness); r.Granted {
+		t.Errorf("expected the total cap to refuse a third break, got %+v", r)
+	}
+	// an agent already on break may ask again
+	if r := ask("sales-a", types.BreakPersonal); !r.Granted {
+		t.Errorf("expected an agent on break to be granted, got %+v", r)
+	}
+	if r := ask("nobody", types.BreakRest); r.Granted {
+		t.Errorf("expected unknown agent refused, got %+v", r)
+	}
+	if r := ask("sales-d", "Not Valid"); r.Granted {
+		t.Errorf("expected invalid reason refused, got %+v", r)
+	}
+
+	// unused grants expire
+	g.now = func() time.Time { return time.Now().Add(grantTTL + time.Second) }
+	if r := ask("sales-c", types.BreakWellness); !r.Granted {
+		t.Errorf("expected the expired grant to free its slot, got %+v", r)
+	}
+}
+
+func TestTrackerAccountsBreakTimePerReason(t *testing.T) {
+	tracker := cache.NewAgentStateTracker()
+	register(tracker, 1)
+	tracker.UpdateFromStateChange(&types.AgentStateChange{AgentID: "sales-a", PreviousState: types.StateAvailable,
+		NewState: types.StateBreak, Reason: types.BreakWellness})
+	agent, _ := tracker.Get("sales-a")
+	if agent.BreakReason != types.BreakWellness {
+		t.Fatalf("expected wellness break, got %q", agent.BreakReason)
+	}
+	time.Sleep(10 * time.Millisecond)
+	tracker.UpdateFromStateChange(&types.AgentStateChange{AgentID: "sales-a", PreviousState: types.StateBreak,
+		NewState: types.StateAvailable})
+
+	after, _ := tracker.Get("sales-a")
+	if after.BreakReason != "" || after.BreakTimeByReason[types.BreakWellness] <= 0 {
+		t.Errorf("expected finished wellness break time, got %+v", after)
+	}
+	if agent.BreakTimeByReason != nil {
+		t.Error("expected earlier copies to keep their own break time")
+	}
+
+	g := NewGuard(DefaultPolicy(), tracker, zerolog.Nop())
+	for _, d := range g.Status() {
+		if d.Department != types.DeptSales {
+			continue
+		}
+		if d.Agents != 1 || d.Limit != 1 || len(d.Reasons) != 1 || d.Reasons[0].BreakSeconds <= 0 {
+			t.Errorf("unexpected sales status %+v", d)
+		}
+	}
+}
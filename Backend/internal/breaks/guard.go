@@ -0,0 +1,182 @@
+package breaks
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/metrics"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// grantTTL is how long a granted break holds its slot before the agent's state_change to
+// break arrives; unused grants then expire
+const grantTTL = 10 * time.Second
+
+// grant is a granted break the tracker does not show yet
+type grant struct {
+	dept    types.Department
+	reason  types.BreakReason
+	expires time.Time
+}
+
+// Guard answers break requests against a policy and the tracker's live states
+type Guard struct {
+	policy  Policy
+	tracker *cache.AgentStateTracker
+	logger  zerolog.Logger
+
+	mu      sync.Mutex // serializes decisions so two requests cannot take the last slot
+	pending map[string]grant
+
+	now func() time.Time // replaced in tests
+}
+
+// NewGuard creates a guard enforcing policy
+func NewGuard(policy Policy, tracker *cache.AgentStateTracker, logger zerolog.Logger) *Guard {
+	return &Guard{
+		policy:  policy,
+		tracker: tracker,
+		logger:  logger.With().Str("component", "breaks").Logger(),
+		pending: make(map[string]grant),
+		now:     time.Now,
+	}
+}
+
+// Policy returns the policy the guard enforces
+func (g *Guard) Policy() Policy {
+	return g.policy
+}
+
+// occupancy counts a department's connected agents and breaks, by reason
+type occupancy struct {
+	agents   int
+	onBreak  int
+	byReason map[types.BreakReason]int
+	seconds  map[types.BreakReason]float64 // finished breaks
+}
+
+// count returns the occupancy of dept, including pending grants (caller holds mu)
+func (g *Guard) count(dept types.Department, now time.Time) occupancy {
+	occ := occupancy{byReason: make(map[types.BreakReason]int), seconds: make(map[types.BreakReason]float64)}
+	onBreak := make(map[string]bool)
+	for _, a := range g.tracker.GetByDepartment(dept) {
+		for reason, secs := range a.BreakTimeByReason {
+			occ.seconds[reason] += secs
+		}
+		if a.ConnectionStatus == types.StatusDisconnected {
+			continue
+		}
+		occ.agents++
+		if a.State == types.StateBreak {
+			onBreak[a.AgentID] = true
+			occ.onBreak++
+			occ.byReason[a.BreakReason.OrDefault()]++
+		}
+	}
+	for agentID, gr := range g.pending {
+		if onBreak[agentID] || now.After(gr.expires) {
+			delete(g.pending, agentID)
+			continue
+		}
+		if gr.dept == dept {
+			occ.onBreak++
+			occ.byReason[gr.reason]++
+		}
+	}
+	return occ
+}
+
+// Request grants or refuses a break. A granted break holds its slot for grantTTL so that
+// concurrent requests see it before the agent's state_change arrives.
+func (g *Guard) Request(req *types.BreakRequest) types.BreakResponse {
+	reason := req.Reason.OrDefault()
+	resp := types.BreakResponse{Type: "break_response", AgentID: req.AgentID, Reason: reason}
+	if !reason.Valid() {
+		resp.Message = "invalid reason code"
+		return resp
+	}
+	agent, ok := g.tracker.Get(req.AgentID)
+	if !ok {
+		resp.Message = "unknown agent"
+		return resp
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	if agent.State != types.StateBreak {
+		occ := g.count(agent.Department, now)
+		dp := g.policy.For(agent.Department)
+		if limit := dp.Total.Limit(occ.agents); limit > 0 && occ.onBreak >= limit {
+			resp.Message = fmt.Sprintf("break cap for %s reached (%d of %d)", agent.Department, occ.onBreak, limit)
+		} else if c, ok := dp.Reasons[reason]; ok {
+			if limit := c.Limit(occ.agents); limit > 0 && occ.byReason[reason] >= limit {
+				resp.Message = fmt.Sprintf("%s break cap for %s reached (%d of %d)", reason, agent.Department, occ.byReason[reason], limit)
+			}
+		}
+	}
+
+	result := "denied"
+	if resp.Message == "" {
+		resp.Granted = true
+		result = "granted"
+		if agent.State != types.StateBreak {
+			g.pending[req.AgentID] = grant{dept: agent.Department, reason: reason, expires: now.Add(grantTTL)}
+		}
+	}
+	metrics.Get().RecordBreakRequest(agent.Department, reason, result)
+	g.logger.Debug().
+		Str("agent_id", req.AgentID).
+		Str("reason", string(reason)).
+		Bool("granted", resp.Granted).
+		Str("message", resp.Message).
+		Msg("break request")
+	return resp
+}
+
+// Status reports every department's breaks against its caps. Reasons are those with a cap,
+// on break now, or with finished break time.
+func (g *Guard) Status() []types.BreakDepartmentStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	out := make([]types.BreakDepartmentStatus, 0, len(types.AllDepartments))
+	for _, dept := range types.AllDepartments {
+		occ := g.count(dept, now)
+		dp := g.policy.For(dept)
+
+		reasons := make(map[types.BreakReason]bool)
+		for r := range dp.Reasons {
+			reasons[r] = true
+		}
+		for r := range occ.byReason {
+			reasons[r] = true
+		}
+		for r := range occ.seconds {
+			reasons[r] = true
+		}
+		status := types.BreakDepartmentStatus{
+			Department: dept,
+			Agents:     occ.agents,
+			OnBreak:    occ.onBreak,
+			Limit:      dp.Total.Limit(occ.agents),
+			Reasons:    make([]types.BreakReasonStatus, 0, len(reasons)),
+		}
+		for r := range reasons {
+			rs := types.BreakReasonStatus{Reason: r, OnBreak: occ.byReason[r], BreakSeconds: occ.seconds[r]}
+			if c, ok := dp.Reasons[r]; ok {
+				rs.Limit = c.Limit(occ.agents)
+			}
+			status.Reasons = append(status.Reasons, rs)
+		}
+		sort.Slice(status.Reasons, func(i, j int) bool { return status.Reasons[i].Reason < status.Reasons[j].Reason })
+		out = append(out, status)
+	}
+	return out
+}
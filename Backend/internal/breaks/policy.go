@@ -0,0 +1,126 @@
+// Package breaks enforces per-department break caps: agents ask before going on break and
+// the backend grants or refuses the request against the policy.
+package breaks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// Cap limits how many agents may be on break at once. Percent is of the department's
+// connected agents, rounded down but at least 1; Max is an absolute limit. With both set
+// the lower one applies, with neither the cap is unlimited.
+type Cap struct {
+	Percent float64 `json:"percent,omitempty"`
+	Max     int     `json:"max,omitempty"`
+}
+
+// Limit returns the cap for a department of agents connected agents, or 0 when unlimited
+func (c Cap) Limit(agents int) int {
+	limit := 0
+	if c.Percent > 0 {
+		limit = int(float64(agents) * c.Percent / 100)
+		if limit < 1 {
+			limit = 1
+		}
+	}
+	if c.Max > 0 && (limit == 0 || c.Max < limit) {
+		limit = c.Max
+	}
+	return limit
+}
+
+func (c Cap) validate() error {
+	if c.Percent < 0 || c.Percent > 100 {
+		return fmt.Errorf("percent must be between 0 and 100")
+	}
+	if c.Max < 0 {
+		return fmt.Errorf("max must not be negative")
+	}
+	return nil
+}
+
+// DepartmentPolicy caps a department's breaks in total and per reason code.
+// Reasons without an entry only count toward the total.
+type DepartmentPolicy struct {
+	Total   Cap                       `json:"total"`
+	Reasons map[types.BreakReason]Cap `json:"reasons,omitempty"`
+}
+
+// Policy is the break policy file (BREAK_POLICY_FILE):
+//
+//	{
+//	  "default": {"total": {"percent": 5}},
+//	  "departments": {
+//	    "support": {"total": {"percent": 8}, "reasons": {"personal": {"max": 3}}}
+//	  }
+//	}
+//
+// A department entry replaces the default for that department.
+type Policy struct {
+	Default     DepartmentPolicy                      `json:"default"`
+	Departments map[types.Department]DepartmentPolicy `json:"departments,omitempty"`
+}
+
+// DefaultPolicy caps every department at 5% of its connected agents (at least one),
+// the limit AgentSim used to apply on its own
+func DefaultPolicy() Policy {
+	return Policy{Default: DepartmentPolicy{Total: Cap{Percent: 5}}}
+}
+
+// For returns the policy that applies to dept
+func (p Policy) For(dept types.Department) DepartmentPolicy {
+	if dp, ok := p.Departments[dept]; ok {
+		return dp
+	}
+	return p.Default
+}
+
+// LoadFile reads and validates a policy file
+func LoadFile(path string) (Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Policy{}, fmt.Errorf("failed to read break policy: %w", err)
+	}
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return Policy{}, fmt.Errorf("failed to parse break policy: %w", err)
+	}
+	if err := p.validate(); err != nil {
+		return Policy{}, err
+	}
+	return p, nil
+}
+
+func (p Policy) validate() error {
+	if err := p.Default.validate(); err != nil {
+		return fmt.Errorf("default: %w", err)
+	}
+	for dept, dp := range p.Departments {
+		if !dept.Valid() {
+			return fmt.Errorf("unknown department %q", dept)
+		}
+		if err := dp.validate(); err != nil {
+			return fmt.Errorf("%s: %w", dept, err)
+		}
+	}
+	return nil
+}
+
+func (dp DepartmentPolicy) validate() error {
+	if err := dp.Total.validate(); err != nil {
+		return fmt.Errorf("total: %w", err)
+	}
+	for reason, c := range dp.Reasons {
+		if !reason.Valid() {
+			return fmt.Errorf("invalid reason code %q", reason)
+		}
+		if err := c.validate(); err != nil {
+			return fmt.Errorf("reason %s: %w", reason, err)
+		}
+	}
+	return nil
+}
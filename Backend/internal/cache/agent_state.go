@@ -1,50 +1,278 @@
 package cache
 
 import (
+	"errors"
 	"sync"
 	"time"
 
+	"github.com/dennisdiepolder/monti/backend/internal/kpi"
+	"github.com/dennisdiepolder/monti/backend/internal/metrics"
 	"github.com/dennisdiepolder/monti/backend/internal/types"
 )
 
-// allDepartments is the fixed list of departments for snapshot building
-var allDepartments = []types.Department{
-	types.DeptSales,
-	types.DeptSupport,
-	types.DeptTechnical,
-	types.DeptRetention,
-}
-
 const (
-	// StaleThreshold is the duration after which an agent is considered stale (3 missed heartbeats)
+	// StaleThreshold is the default duration after which an agent is considered stale (3 missed heartbeats)
 	StaleThreshold = 6 * time.Second
+
+	// maxPendingTimings caps the event timings carried into one snapshot
+	maxPendingTimings = 4096
+)
+
+var (
+	// ErrAgentNotFound is returned when a roster change targets an unknown agent
+	ErrAgentNotFound = errors.New("agent not found")
+
+	// ErrAgentConnected is returned when retiring an agent that is still connected
+	ErrAgentConnected = errors.New("agent is connected")
+
+	// ErrTenantConflict is returned when an agent registers for another tenant than its own
+	ErrTenantConflict = errors.New("agent belongs to another tenant")
 )
 
+// StateRecorder receives completed state segments (must not block, called under the tracker lock)
+type StateRecorder interface {
+	RecordStateSegment(seg types.StateSegment)
+}
+
+// PresenceObserver is notified when agents log on or off (must not block, called under the tracker lock)
+type PresenceObserver interface {
+	AgentLoggedOn(agent types.AgentInfo)
+	AgentLoggedOff(agent types.AgentInfo)
+}
+
 // AgentStateTracker maintains the current state of all agents
 type AgentStateTracker struct {
-	agents map[string]*types.AgentInfo // agentID -> current state
-	mu     sync.RWMutex
+	agents   map[string]*types.AgentInfo // agentID -> current state
+	kpis     map[string]*kpi.Counter     // agentID -> observed time and calls
+	recorder StateRecorder
+	presence PresenceObserver
+	mu       sync.RWMutex
+
+	// Heartbeat age after which a connected agent is marked stale
+	staleThreshold time.Duration
+
+	// How many recent calls per agent the handle time percentiles cover
+	kpiHistory int
+
+	// How long an agent whose connection dropped stays stale before it is logged off, and
+	// when each such agent lost its connection
+	reconnectGrace time.Duration
+	lostAt         map[string]time.Time
+
+	// Tenant each agent first registered for; agent IDs are unique across tenants, so an
+	// agent only moves to another tenant once it was retired
+	tenants map[string]string
+
+	// Which state changes are valid, and agents quarantined for sending invalid ones
+	transitions transitionGuard
+
+	// Timings of state changes applied since the last snapshot, for end-to-end latency
+	timingsMu sync.Mutex
+	timings   []types.EventTiming
+
+	// Frozen copies of the agents that snapshots are built from
+	frozen *snapshotBuffers
 }
 
 // NewAgentStateTracker creates a new agent state tracker
 func NewAgentStateTracker() *AgentStateTracker {
 	return &AgentStateTracker{
-		agents: make(map[string]*types.AgentInfo),
+		agents:         make(map[string]*types.AgentInfo),
+		kpis:           make(map[string]*kpi.Counter),
+		staleThreshold: StaleThreshold,
+		lostAt:         make(map[string]time.Time),
+		tenants:        make(map[string]string),
+		transitions:    transitionGuard{policy: TransitionFlag},
+		frozen:         newSnapshotBuffers(),
+	}
+}
+
+// SetStaleThreshold sets the heartbeat age after which an agent is marked stale
+func (t *AgentStateTracker) SetStaleThreshold(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.staleThreshold = d
+}
+
+// SetKPIHistorySize sets how many recent calls per agent the handle time percentiles cover
+func (t *AgentStateTracker) SetKPIHistorySize(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.kpiHistory = n
+}
+
+// SetStateRecorder sets the recorder notified whenever an agent leaves a state
+func (t *AgentStateTracker) SetStateRecorder(r StateRecorder) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.recorder = r
+}
+
+// SetPresenceObserver sets the observer notified on agent logon and logoff
+func (t *AgentStateTracker) SetPresenceObserver(o PresenceObserver) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.presence = o
+}
+
+// endSegment reports the agent's current state as finished at end (caller holds mu).
+// Offline time is not recorded.
+func (t *AgentStateTracker) endSegment(agent *types.AgentInfo, end time.Time) {
+	if agent.State == types.StateBreak && end.After(agent.StateStart) {
+		addBreakTime(agent, end.Sub(agent.StateStart).Seconds())
+	}
+	t.counter(agent).AddState(agent.State, agent.StateStart, end)
+	if t.recorder == nil || agent.State == types.StateOffline || !end.After(agent.StateStart) {
+		return
+	}
+	t.recorder.RecordStateSegment(types.StateSegment{
+		AgentID:  agent.AgentID,
+		StartKey: types.SegmentStartKey(agent.StateStart),
+		State:    agent.State,
+		Start:    agent.StateStart,
+		End:      end,
+	})
+}
+
+// counter returns the agent's observed KPI counter, creating it if needed, with the daily
+// window in the timezone of the agent's location and the configured handle time window
+// (caller holds mu)
+func (t *AgentStateTracker) counter(agent *types.AgentInfo) *kpi.Counter {
+	c, ok := t.kpis[agent.AgentID]
+	if !ok {
+		c = &kpi.Counter{}
+		t.kpis[agent.AgentID] = c
+	}
+	c.Location = types.LocationTimezone(agent.Location)
+	c.Window = t.kpiHistory
+	return c
+}
+
+// observe recomputes the agent's observed KPIs against its reported ones (caller holds mu)
+func (t *AgentStateTracker) observe(agent *types.AgentInfo, now time.Time) {
+	prev := agent.Observed
+	agent.Observed = t.counter(agent).Observed(agent.State, agent.StateStart, now, agent.KPIs)
+	for _, name := range agent.Observed.Divergent {
+		if prev == nil || !contains(prev.Divergent, name) {
+			metrics.Get().RecordKPIDivergence(name)
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// addBreakTime credits a finished break to the agent's current break reason. The map is
+// copied because AgentInfo copies handed out by the tracker share it.
+func addBreakTime(agent *types.AgentInfo, secs float64) {
+	reason := agent.BreakReason.OrDefault()
+	byReason := make(map[types.BreakReason]float64, len(agent.BreakTimeByReason)+1)
+	for r, v := range agent.BreakTimeByReason {
+		byReason[r] = v
+	}
+	byReason[reason] += secs
+	agent.BreakTimeByReason = byReason
+	metrics.Get().RecordBreakTime(agent.Department, reason, secs)
+}
+
+// setState moves the agent into state at now, ending the previous state's segment. Messages
+// repeating the current state keep StateStart and IdleSince, so KPI-only updates and
+// re-registrations do not make an agent look freshly idle. Returns whether the state changed.
+// Caller holds mu.
+func (t *AgentStateTracker) setState(agent *types.AgentInfo, state types.AgentState, now time.Time) bool {
+	if agent.State == state {
+		return false
+	}
+	t.endSegment(agent, now)
+	agent.State = state
+	agent.StateStart = now
+	agent.IdleSince = idleSince(state, now)
+	agent.ACWStartTime = stateSince(types.StateAfterCallWork, state, now)
+	agent.BreakStartTime = stateSince(types.StateBreak, state, now)
+	return true
+}
+
+// stateSince is the start time to keep for tracked (ACW, break) while the agent is in state
+func stateSince(tracked, state types.AgentState, start time.Time) *time.Time {
+	if state != tracked {
+		return nil
 	}
+	return &start
+}
+
+// senderStart is when a state_change's sender entered the new state. The timestamp is only
+// trusted between the end of the previous state and now, so clock skew cannot stretch ACW
+// or break durations beyond what the backend observed.
+func senderStart(sent, prevStart, now time.Time) time.Time {
+	if sent.IsZero() || sent.Before(prevStart) || sent.After(now) {
+		return now
+	}
+	return sent
+}
+
+// idleSince is the IdleSince of an agent entering state at now
+func idleSince(state types.AgentState, now time.Time) *time.Time {
+	if state != types.StateAvailable {
+		return nil
+	}
+	return &now
+}
+
+// breakReason is the reason to record for an agent entering state
+func breakReason(state types.AgentState, reason types.BreakReason) types.BreakReason {
+	if state != types.StateBreak {
+		return ""
+	}
+	return reason.OrDefault()
+}
+
+// noteTiming remembers when a state change originated and was applied
+func (t *AgentStateTracker) noteTiming(origin time.Time) {
+	now := time.Now()
+	if origin.IsZero() {
+		origin = now
+	}
+	t.timingsMu.Lock()
+	defer t.timingsMu.Unlock()
+	if len(t.timings) < maxPendingTimings {
+		t.timings = append(t.timings, types.EventTiming{Origin: origin, Ingest: now})
+	}
+}
+
+// drainTimings returns and clears the timings collected since the last snapshot
+func (t *AgentStateTracker) drainTimings() []types.EventTiming {
+	t.timingsMu.Lock()
+	defer t.timingsMu.Unlock()
+	timings := t.timings
+	t.timings = nil
+	return timings
 }
 
 // Update updates or adds an agent's state (from HTTP POST event - legacy)
 func (t *AgentStateTracker) Update(event types.AgentEvent) {
+	defer t.noteTiming(event.Timestamp)
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	t.touch(event.AgentID)
 
 	existing, exists := t.agents[event.AgentID]
+	if exists && !t.allowTransition(existing, event.State, SourceEvent, time.Now()) {
+		return
+	}
 
 	// If agent exists and state changed, update state start time
 	// Otherwise, keep the existing state start time
 	stateStart := event.Timestamp
 	if exists && existing.State == event.State {
 		stateStart = existing.StateStart
+	} else if exists {
+		t.endSegment(existing, event.Timestamp)
 	}
 
 	// Preserve connection status if exists
@@ -53,7 +281,7 @@ func (t *AgentStateTracker) Update(event types.AgentEvent) {
 		connectionStatus = existing.ConnectionStatus
 	}
 
-	t.agents[event.AgentID] = &types.AgentInfo{
+	info := &types.AgentInfo{
 		AgentID:          event.AgentID,
 		State:            event.State,
 		Department:       event.Department,
@@ -64,13 +292,29 @@ func (t *AgentStateTracker) Update(event types.AgentEvent) {
 		LastHeartbeat:    time.Now(),
 		ConnectionStatus: connectionStatus,
 		KPIs:             event.KPIs,
+		BreakReason:      breakReason(event.State, ""),
+		IdleSince:        idleSince(event.State, stateStart),
+		ACWStartTime:     stateSince(types.StateAfterCallWork, event.State, stateStart),
+		BreakStartTime:   stateSince(types.StateBreak, event.State, stateStart),
 	}
+	if exists {
+		info.BreakTimeByReason = existing.BreakTimeByReason
+		info.Quarantined = existing.Quarantined
+		if existing.State == event.State {
+			info.BreakReason = existing.BreakReason
+			info.IdleSince = existing.IdleSince
+			info.ACWStartTime = existing.ACWStartTime
+			info.BreakStartTime = existing.BreakStartTime
+		}
+	}
+	t.agents[event.AgentID] = info
 }
 
 // UpdateFromHeartbeat updates an agent's state from a WebSocket heartbeat
 func (t *AgentStateTracker) UpdateFromHeartbeat(hb *types.AgentHeartbeat) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	t.touch(hb.AgentID)
 
 	existing, exists := t.agents[hb.AgentID]
 	if !exists {
@@ -78,71 +322,126 @@ func (t *AgentStateTracker) UpdateFromHeartbeat(hb *types.AgentHeartbeat) {
 		return
 	}
 
-	// Update state if changed
-	stateStart := existing.StateStart
-	if existing.State != hb.State {
-		stateStart = time.Now()
+	// A heartbeat may report a new state before its state_change arrives
+	now := time.Now()
+	if t.allowTransition(existing, hb.State, SourceHeartbeat, now) && t.setState(existing, hb.State, now) {
+		existing.BreakReason = breakReason(hb.State, "")
 	}
-
-	existing.State = hb.State
 	existing.KPIs = hb.KPIs
-	existing.LastHeartbeat = time.Now()
-	existing.LastUpdate = time.Now()
-	existing.ConnectionStatus = types.StatusConnected
-	existing.StateStart = stateStart
+	existing.LastHeartbeat = now
+	existing.LastUpdate = now
+	t.setConnection(existing, types.StatusConnected)
+	t.observe(existing, now)
 }
 
 // UpdateFromStateChange updates an agent's state from a WebSocket state change message
 func (t *AgentStateTracker) UpdateFromStateChange(sc *types.AgentStateChange) {
+	defer t.noteTiming(sc.Timestamp)
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	t.touch(sc.AgentID)
 
 	existing, exists := t.agents[sc.AgentID]
 	if !exists {
 		// Agent not registered yet, create new entry
+		now := time.Now()
 		t.agents[sc.AgentID] = &types.AgentInfo{
 			AgentID:          sc.AgentID,
 			State:            sc.NewState,
 			Department:       sc.Department,
 			Location:         sc.Location,
 			Team:             sc.Team,
-			StateStart:       time.Now(),
-			LastUpdate:       time.Now(),
-			LastHeartbeat:    time.Now(),
+			StateStart:       now,
+			LastUpdate:       now,
+			LastHeartbeat:    now,
 			ConnectionStatus: types.StatusConnected,
 			KPIs:             sc.KPIs,
+			BreakReason:      breakReason(sc.NewState, sc.Reason),
+			IdleSince:        idleSince(sc.NewState, now),
+			ACWStartTime:     stateSince(types.StateAfterCallWork, sc.NewState, now),
+			BreakStartTime:   stateSince(types.StateBreak, sc.NewState, now),
 		}
+		t.observe(t.agents[sc.AgentID], now)
 		return
 	}
 
-	existing.State = sc.NewState
+	// The state may already be current when a heartbeat reported it first; only the
+	// reason is taken from such a state_change. A rejected change still counts as a sign
+	// of life.
+	now := time.Now()
+	prevStart := existing.StateStart
+	allowed := t.allowTransition(existing, sc.NewState, SourceStateChange, now)
+	changed := allowed && t.setState(existing, sc.NewState, now)
+	if changed || (allowed && sc.Reason != "") {
+		existing.BreakReason = breakReason(sc.NewState, sc.Reason)
+	}
+	// ACW and break alerts measure from when the agent entered the state at the source
+	if changed {
+		start := senderStart(sc.Timestamp, prevStart, now)
+		existing.ACWStartTime = stateSince(types.StateAfterCallWork, sc.NewState, start)
+		existing.BreakStartTime = stateSince(types.StateBreak, sc.NewState, start)
+	}
 	existing.KPIs = sc.KPIs
-	existing.LastHeartbeat = time.Now()
-	existing.LastUpdate = time.Now()
-	existing.ConnectionStatus = types.StatusConnected
-	existing.StateStart = time.Now()
+	existing.LastHeartbeat = now
+	existing.LastUpdate = now
+	t.setConnection(existing, types.StatusConnected)
+	t.observe(existing, now)
+}
+
+// CheckTenant returns ErrTenantConflict when agentID registered for a tenant other than
+// tenant (empty = DefaultTenant) and has not been retired since
+func (t *AgentStateTracker) CheckTenant(agentID, tenant string) error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.checkTenant(agentID, tenant)
+}
+
+func (t *AgentStateTracker) checkTenant(agentID, tenant string) error {
+	if owner, ok := t.tenants[agentID]; ok && owner != types.TenantOf(tenant) {
+		return ErrTenantConflict
+	}
+	return nil
 }
 
-// RegisterAgent registers a new agent connection, updating the existing roster entry if present
-func (t *AgentStateTracker) RegisterAgent(reg *types.AgentRegister) {
+// RegisterAgent registers a new agent connection, updating the existing roster entry if
+// present. A registration for another tenant than the agent's is rejected.
+func (t *AgentStateTracker) RegisterAgent(reg *types.AgentRegister) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	if err := t.checkTenant(reg.AgentID, reg.Tenant); err != nil {
+		return err
+	}
+	t.tenants[reg.AgentID] = types.TenantOf(reg.Tenant)
+	t.touch(reg.AgentID)
 
 	now := time.Now()
 	if existing, exists := t.agents[reg.AgentID]; exists {
-		// Update existing roster entry in-place
-		existing.State = reg.State
+		loggedOff := existing.ConnectionStatus == types.StatusDisconnected || existing.State == types.StateOffline
+		t.liftQuarantine(existing)
+		// Update existing roster entry in-place. A reconnect in the same state (e.g. a whole
+		// multiplexed batch re-registering) keeps the agent's place in longest-idle routing.
+		if t.setState(existing, reg.State, now) {
+			existing.BreakReason = breakReason(reg.State, "")
+		}
+		existing.Tenant = reg.Tenant
 		existing.Department = reg.Department
 		existing.Location = reg.Location
 		existing.Team = reg.Team
-		existing.StateStart = now
 		existing.LastUpdate = now
 		existing.LastHeartbeat = now
-		existing.ConnectionStatus = types.StatusConnected
+		t.setConnection(existing, types.StatusConnected)
 		existing.KPIs = reg.KPIs
+		if loggedOff {
+			t.counter(existing).ResetSession()
+		}
+		t.observe(existing, now)
+		if loggedOff && t.presence != nil {
+			t.presence.AgentLoggedOn(*existing)
+		}
 	} else {
 		t.agents[reg.AgentID] = &types.AgentInfo{
 			AgentID:          reg.AgentID,
+			Tenant:           reg.Tenant,
 			State:            reg.State,
 			Department:       reg.Department,
 			Location:         reg.Location,
@@ -152,21 +451,95 @@ func (t *AgentStateTracker) RegisterAgent(reg *types.AgentRegister) {
 			LastHeartbeat:    now,
 			ConnectionStatus: types.StatusConnected,
 			KPIs:             reg.KPIs,
+			BreakReason:      breakReason(reg.State, ""),
+			IdleSince:        idleSince(reg.State, now),
+			ACWStartTime:     stateSince(types.StateAfterCallWork, reg.State, now),
+			BreakStartTime:   stateSince(types.StateBreak, reg.State, now),
+		}
+		t.counter(t.agents[reg.AgentID]).ResetSession()
+		t.observe(t.agents[reg.AgentID], now)
+		if t.presence != nil {
+			t.presence.AgentLoggedOn(*t.agents[reg.AgentID])
 		}
 	}
+	return nil
+}
+
+// RecordCallComplete counts a completed call towards the agent's observed KPIs. Calls the
+// backend force-ends never produce a call_complete and are not counted.
+func (t *AgentStateTracker) RecordCallComplete(cc *types.CallComplete) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.touch(cc.AgentID)
+
+	agent, exists := t.agents[cc.AgentID]
+	if !exists {
+		return
+	}
+	now := time.Now()
+	t.counter(agent).AddCall(cc.TalkTime, cc.HoldTime, now)
+	t.observe(agent, now)
+}
+
+// SetCurrentCall records the call routing assigned to an agent and when it started
+func (t *AgentStateTracker) SetCurrentCall(agentID, callID string, vq types.VQName, start time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.touch(agentID)
+
+	if agent, exists := t.agents[agentID]; exists {
+		agent.CurrentCallID = callID
+		agent.CurrentVQ = vq
+		agent.CallStartTime = &start
+		agent.CallHoldTime = 0
+		agent.HoldStartTime = nil
+	}
+}
+
+// SetCallHold records the holds of an agent's current call: the seconds of the holds ended so
+// far, and when the current hold started (nil unless on hold). Ignored unless callID is the
+// agent's current call.
+func (t *AgentStateTracker) SetCallHold(agentID, callID string, holdTime float64, holdStart *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.touch(agentID)
+
+	if agent, exists := t.agents[agentID]; exists && agent.CurrentCallID == callID {
+		agent.CallHoldTime = holdTime
+		agent.HoldStartTime = holdStart
+	}
+}
+
+// ClearCurrentCall forgets an agent's current call if it is callID, and reports whether it was
+func (t *AgentStateTracker) ClearCurrentCall(agentID, callID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.touch(agentID)
+
+	agent, exists := t.agents[agentID]
+	if !exists || agent.CurrentCallID != callID {
+		return false
+	}
+	agent.CurrentCallID = ""
+	agent.CurrentVQ = ""
+	agent.CallStartTime = nil
+	agent.CallHoldTime = 0
+	agent.HoldStartTime = nil
+	return true
 }
 
 // SetConnected updates the connection status of an agent
 func (t *AgentStateTracker) SetConnected(agentID string, connected bool) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	t.touch(agentID)
 
 	if agent, exists := t.agents[agentID]; exists {
 		if connected {
-			agent.ConnectionStatus = types.StatusConnected
+			t.setConnection(agent, types.StatusConnected)
 			agent.LastHeartbeat = time.Now()
 		} else {
-			agent.ConnectionStatus = types.StatusDisconnected
+			t.setConnection(agent, types.StatusDisconnected)
 			agent.LastHeartbeat = time.Now() // Track when disconnection happened for cleanup
 		}
 	}
@@ -182,11 +555,27 @@ func (t *AgentStateTracker) DisconnectAndRemove(agentID string) {
 func (t *AgentStateTracker) SetDisconnected(agentID string) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	t.touch(agentID)
 	if agent, exists := t.agents[agentID]; exists {
-		agent.ConnectionStatus = types.StatusDisconnected
-		agent.State = types.StateOffline
-		agent.StateStart = time.Now()
-		agent.LastHeartbeat = time.Now()
+		t.disconnect(agent, time.Now())
+	}
+}
+
+// disconnect logs an agent off at now (caller holds mu)
+func (t *AgentStateTracker) disconnect(agent *types.AgentInfo, now time.Time) {
+	delete(t.lostAt, agent.AgentID)
+	loggedOn := agent.ConnectionStatus != types.StatusDisconnected
+	t.endSegment(agent, now)
+	t.setConnection(agent, types.StatusDisconnected)
+	agent.State = types.StateOffline
+	agent.BreakReason = ""
+	agent.IdleSince = nil
+	agent.ACWStartTime = nil
+	agent.BreakStartTime = nil
+	agent.StateStart = now
+	agent.LastHeartbeat = now
+	if loggedOn && t.presence != nil {
+		t.presence.AgentLoggedOff(*agent)
 	}
 }
 
@@ -194,6 +583,7 @@ func (t *AgentStateTracker) SetDisconnected(agentID string) {
 func (t *AgentStateTracker) RegisterOfflineAgent(agentID string, dept types.Department, loc types.Location, team string) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	t.touch(agentID)
 
 	// Don't overwrite an existing connected agent
 	if existing, exists := t.agents[agentID]; exists && existing.ConnectionStatus == types.StatusConnected {
@@ -201,29 +591,132 @@ func (t *AgentStateTracker) RegisterOfflineAgent(agentID string, dept types.Depa
 	}
 
 	now := time.Now()
+	var skills []string
+	var proficiency map[string]int
+	if existing, exists := t.agents[agentID]; exists {
+		t.endSegment(existing, now)
+		// managed via the roster API, not the bulk roster
+		skills, proficiency = existing.Skills, existing.Proficiency
+	}
 	t.agents[agentID] = &types.AgentInfo{
 		AgentID:          agentID,
 		State:            types.StateOffline,
 		Department:       dept,
 		Location:         loc,
 		Team:             team,
+		Skills:           skills,
+		Proficiency:      proficiency,
+		StateStart:       now,
+		LastUpdate:       now,
+		LastHeartbeat:    now,
+		ConnectionStatus: types.StatusDisconnected,
+	}
+}
+
+// Put stores a copy of agent as is, replacing its tracked state. It bypasses state segments
+// and presence, so it is meant for building hypothetical trackers such as routing dry runs.
+func (t *AgentStateTracker) Put(agent types.AgentInfo) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.touch(agent.AgentID)
+	t.agents[agent.AgentID] = &agent
+}
+
+// UpsertRosterAgent applies a managed roster entry. Known agents keep their live state and
+// only take the new department, location, team, skills and proficiency; unknown agents are added offline.
+// It reports whether the agent was newly added.
+func (t *AgentStateTracker) UpsertRosterAgent(rec types.RosterRecord) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.touch(rec.AgentID)
+
+	now := time.Now()
+	if existing, exists := t.agents[rec.AgentID]; exists {
+		existing.Department = rec.Department
+		existing.Location = rec.Location
+		existing.Team = rec.Team
+		existing.Skills = rec.Skills
+		existing.Proficiency = rec.Proficiency
+		existing.LastUpdate = now
+		return false
+	}
+
+	t.agents[rec.AgentID] = &types.AgentInfo{
+		AgentID:          rec.AgentID,
+		State:            types.StateOffline,
+		Department:       rec.Department,
+		Location:         rec.Location,
+		Team:             rec.Team,
+		Skills:           rec.Skills,
+		Proficiency:      rec.Proficiency,
 		StateStart:       now,
 		LastUpdate:       now,
 		LastHeartbeat:    now,
 		ConnectionStatus: types.StatusDisconnected,
 	}
+	return true
+}
+
+// ScopeEvent sets an event's tenant to its tracked agent's and fills in the department,
+// location and team it left out, so the event can be scoped after the agent is gone
+func (t *AgentStateTracker) ScopeEvent(e *types.AgentEvent) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	e.Tenant = ""
+	agent, ok := t.agents[e.AgentID]
+	if !ok {
+		return
+	}
+	e.Tenant = agent.Tenant
+	if e.Department == "" {
+		e.Department = agent.Department
+	}
+	if e.Location == "" {
+		e.Location = agent.Location
+	}
+	if e.Team == "" {
+		e.Team = agent.Team
+	}
 }
 
-// CheckStaleAgents marks agents as stale if no heartbeat received within threshold
+// RetireAgent removes an agent from the roster. Connected agents must log off first.
+func (t *AgentStateTracker) RetireAgent(agentID string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.touch(agentID)
+
+	agent, exists := t.agents[agentID]
+	if !exists {
+		return ErrAgentNotFound
+	}
+	if agent.ConnectionStatus == types.StatusConnected {
+		return ErrAgentConnected
+	}
+	t.endSegment(agent, time.Now())
+	delete(t.agents, agentID)
+	delete(t.kpis, agentID)
+	delete(t.lostAt, agentID)
+	delete(t.tenants, agentID)
+	delete(t.transitions.strikes, agentID)
+	delete(t.transitions.quarantined, agentID)
+	return nil
+}
+
+// CheckStaleAgents marks agents as stale if no heartbeat received within threshold, and logs
+// off agents whose connection dropped longer than the reconnect grace period ago
 func (t *AgentStateTracker) CheckStaleAgents() {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	threshold := time.Now().Add(-StaleThreshold)
+	now := time.Now()
+	t.expireLost(now)
+
+	threshold := now.Add(-t.staleThreshold)
 	for _, agent := range t.agents {
 		if agent.ConnectionStatus == types.StatusConnected &&
 			agent.LastHeartbeat.Before(threshold) {
-			agent.ConnectionStatus = types.StatusStale
+			t.setConnection(agent, types.StatusStale)
+			t.touch(agent.AgentID)
 		}
 	}
 }
@@ -240,6 +733,18 @@ func (t *AgentStateTracker) GetAll() []types.AgentInfo {
 	return states
 }
 
+// Get returns a copy of one agent's current state
+func (t *AgentStateTracker) Get(agentID string) (types.AgentInfo, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	agent, ok := t.agents[agentID]
+	if !ok {
+		return types.AgentInfo{}, false
+	}
+	return *agent, true
+}
+
 // GetAllAgents returns all agents including offline/disconnected ones
 func (t *AgentStateTracker) GetAllAgents() []types.AgentInfo {
 	return t.GetAll()
@@ -280,28 +785,37 @@ func (t *AgentStateTracker) GetByDepartment(dept types.Department) []types.Agent
 
 // GetAvailableByDepartment returns connected agents in "available" state for a department
 func (t *AgentStateTracker) GetAvailableByDepartment(dept types.Department) []types.AgentInfo {
+	return t.GetConnectedInStates(dept, types.StateAvailable)
+}
+
+// GetConnectedInStates returns connected agents of a department in any of the given states
+func (t *AgentStateTracker) GetConnectedInStates(dept types.Department, states ...types.AgentState) []types.AgentInfo {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
-	states := make([]types.AgentInfo, 0)
-	for _, state := range t.agents {
-		if state.Department == dept &&
-			state.State == types.StateAvailable &&
-			state.ConnectionStatus == types.StatusConnected {
-			states = append(states, *state)
+	result := make([]types.AgentInfo, 0)
+	for _, agent := range t.agents {
+		if agent.Department != dept || agent.ConnectionStatus != types.StatusConnected {
+			continue
+		}
+		for _, state := range states {
+			if agent.State == state {
+				result = append(result, *agent)
+				break
+			}
 		}
 	}
-	return states
+	return result
 }
 
-// BuildSnapshot builds a snapshot and returns connected agents in a single pass under one read lock.
-// This avoids multiple lock acquisitions and redundant slice copies per aggregation cycle.
+// BuildSnapshot builds a snapshot and returns connected agents in a single pass over a frozen
+// view of the agents. Only the agents changed since the view was last refreshed are copied
+// under the read lock; the pass itself runs without it. Not safe for concurrent use.
 func (t *AgentStateTracker) BuildSnapshot(vqSnapshots map[types.Department][]types.VQSnapshot) (types.Snapshot, []types.AgentInfo) {
-	t.mu.RLock()
-	defer t.mu.RUnlock()
+	agents := t.freeze()
 
 	departments := make(map[types.Department]*types.DepartmentData, 4)
-	for _, dept := range allDepartments {
+	for _, dept := range types.AllDepartments {
 		departments[dept] = &types.DepartmentData{
 			Agents: []types.AgentInfo{},
 			Queues: vqSnapshots[dept],
@@ -311,20 +825,24 @@ func (t *AgentStateTracker) BuildSnapshot(vqSnapshots map[types.Department][]typ
 		}
 	}
 
-	connected := make([]types.AgentInfo, 0, len(t.agents))
+	connected := make([]types.AgentInfo, 0, len(agents))
 
-	for _, agent := range t.agents {
-		a := *agent
-		departments[a.Department].Agents = append(departments[a.Department].Agents, a)
+	for _, a := range agents {
+		// An agent in a department outside the taxonomy has no department to list it under
+		if dept, ok := departments[a.Department]; ok {
+			dept.Agents = append(dept.Agents, a)
+		}
 		if a.ConnectionStatus == types.StatusConnected {
 			connected = append(connected, a)
 		}
 	}
 
 	snapshot := types.Snapshot{
-		Type:        "snapshot",
-		Timestamp:   time.Now(),
-		Departments: departments,
+		Type:          "snapshot",
+		SchemaVersion: types.SnapshotSchemaVersion,
+		Timestamp:     time.Now(),
+		Departments:   departments,
+		EventTimes:    t.drainTimings(),
 	}
 
 	return snapshot, connected
@@ -336,6 +854,10 @@ func (t *AgentStateTracker) Clear() int {
 	defer t.mu.Unlock()
 	count := len(t.agents)
 	t.agents = make(map[string]*types.AgentInfo)
+	t.kpis = make(map[string]*kpi.Counter)
+	t.lostAt = make(map[string]time.Time)
+	t.tenants = make(map[string]string)
+	t.touchAll()
 	return count
 }
 
@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/metrics"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// SetReconnectGrace sets how long an agent whose connection dropped is kept stale, in its
+// current state, before it is logged off (0 = log off right away). Agents reconnecting
+// within the grace period, e.g. a multiplexed connection re-dialing, simply continue.
+func (t *AgentStateTracker) SetReconnectGrace(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reconnectGrace = d
+}
+
+// ConnectionLost handles an agent's dropped connection. Within the reconnect grace period
+// the agent is stale, so it is not routed to but keeps its state, KPIs session and presence;
+// CheckStaleAgents logs it off once the period is over.
+func (t *AgentStateTracker) ConnectionLost(agentID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.touch(agentID)
+
+	agent, exists := t.agents[agentID]
+	if !exists {
+		return
+	}
+	now := time.Now()
+	if t.reconnectGrace <= 0 || agent.ConnectionStatus == types.StatusDisconnected {
+		t.disconnect(agent, now)
+		return
+	}
+	t.setConnection(agent, types.StatusStale)
+	t.lostAt[agentID] = now
+}
+
+// expireLost logs off the agents whose grace period ended before now (caller holds mu)
+func (t *AgentStateTracker) expireLost(now time.Time) {
+	for agentID, lost := range t.lostAt {
+		if now.Sub(lost) < t.reconnectGrace {
+			continue
+		}
+		if agent, exists := t.agents[agentID]; exists {
+			t.disconnect(agent, now)
+			t.touch(agentID)
+		}
+		delete(t.lostAt, agentID)
+	}
+}
+
+// setConnection moves an agent to a connection status, counting the transition. Becoming
+// connected ends a pending grace period (caller holds mu).
+func (t *AgentStateTracker) setConnection(agent *types.AgentInfo, status types.AgentConnectionStatus) {
+	if status == types.StatusConnected {
+		delete(t.lostAt, agent.AgentID)
+	}
+	if agent.ConnectionStatus == status {
+		return
+	}
+	metrics.Get().RecordAgentConnectionTransition(string(agent.ConnectionStatus), string(status))
+	agent.ConnectionStatus = status
+}
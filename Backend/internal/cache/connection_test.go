@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+func TestReconnectWithinGraceKeepsState(t *testing.T) {
+	tracker := NewAgentStateTracker()
+	tracker.SetReconnectGrace(time.Hour)
+	tracker.RegisterAgent(&types.AgentRegister{AgentID: "a1", Department: types.DeptSales, State: types.StateBreak})
+	before, _ := tracker.Get("a1")
+
+	tracker.ConnectionLost("a1")
+	agent, _ := tracker.Get("a1")
+	if agent.ConnectionStatus != types.StatusStale || agent.State != types.StateBreak {
+		t.Fatalf("expected a stale agent still on break, got %s/%s", agent.ConnectionStatus, agent.State)
+	}
+	if len(tracker.GetConnectedInStates(types.DeptSales, types.StateBreak)) != 0 {
+		t.Error("expected a stale agent not to count as connected")
+	}
+
+	tracker.CheckStaleAgents()
+	tracker.RegisterAgent(&types.AgentRegister{AgentID: "a1", Department: types.DeptSales, State: types.StateBreak})
+	agent, _ = tracker.Get("a1")
+	if agent.ConnectionStatus != types.StatusConnected || !agent.StateStart.Equal(before.StateStart) {
+		t.Errorf("expected the reconnect to continue the break, got %s since %v", agent.ConnectionStatus, agent.StateStart)
+	}
+}
+
+func TestGraceExpiryLogsOff(t *testing.T) {
+	tracker := NewAgentStateTracker()
+	tracker.SetReconnectGrace(time.Millisecond)
+	tracker.RegisterAgent(&types.AgentRegister{AgentID: "a1", Department: types.DeptSales, State: types.StateAvailable})
+
+	tracker.ConnectionLost("a1")
+	time.Sleep(5 * time.Millisecond)
+	tracker.CheckStaleAgents()
+
+	agent, _ := tracker.Get("a1")
+	if agent.ConnectionStatus != types.StatusDisconnected || agent.State != types.StateOffline {
+		t.Errorf("expected the agent logged off after the grace period, got %s/%s", agent.ConnectionStatus, agent.State)
+	}
+}
+
+func TestNoGraceLogsOffAtOnce(t *testing.T) {
+	tracker := NewAgentStateTracker()
+	tracker.RegisterAgent(&types.AgentRegister{AgentID: "a1", Department: types.DeptSales, State: types.StateAvailable})
+
+	tracker.ConnectionLost("a1")
+	if agent, _ := tracker.Get("a1"); agent.ConnectionStatus != types.StatusDisconnected {
+		t.Errorf("expected a disconnected agent without grace, got %s", agent.ConnectionStatus)
+	}
+}
+
+func TestRegisterForAnotherTenantRejected(t *testing.T) {
+	tracker := NewAgentStateTracker()
+	if err := tracker.RegisterAgent(&types.AgentRegister{AgentID: "a1", Tenant: "acme", Department: types.DeptSales, State: types.StateAvailable}); err != nil {
+		t.Fatal(err)
+	}
+	err := tracker.RegisterAgent(&types.AgentRegister{AgentID: "a1", Department: types.DeptTechnical, State: types.StateBreak})
+	if err != ErrTenantConflict {
+		t.Fatalf("expected ErrTenantConflict, got %v", err)
+	}
+	agent, _ := tracker.Get("a1")
+	if agent.Tenant != "acme" || agent.Department != types.DeptSales || agent.State != types.StateAvailable {
+		t.Errorf("expected the agent untouched, got %s/%s/%s", agent.Tenant, agent.Department, agent.State)
+	}
+
+	tracker.SetDisconnected("a1")
+	if err := tracker.RetireAgent("a1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.RegisterAgent(&types.AgentRegister{AgentID: "a1", Department: types.DeptTechnical, State: types.StateBreak}); err != nil {
+		t.Errorf("expected a retired agent to register for another tenant, got %v", err)
+	}
+}
@@ -2,40 +2,100 @@ package cache
 
 import (
 	"sync"
+	"time"
 
 	"github.com/dennisdiepolder/monti/backend/internal/types"
 )
 
-// EventCache stores agent events in memory
+// DefaultEventRetention is how long the event cache keeps raw events
+const DefaultEventRetention = 15 * time.Minute
+
+// maxCachedEvents bounds the cache so an event flood cannot grow it without limit; the oldest
+// events are dropped first
+const maxCachedEvents = 100000
+
+// cachedEvent is an event with the time it was added
+type cachedEvent struct {
+	added time.Time
+	event types.AgentEvent
+}
+
+// EventCache keeps the agent events of the last retention window in memory, oldest first,
+// so recent history can be inspected without a storage round-trip
 type EventCache struct {
-	events []types.AgentEvent
-	mu     sync.RWMutex
+	retention time.Duration
+	events    []cachedEvent
+	mu        sync.RWMutex
 }
 
-// NewEventCache creates a new event cache
-func NewEventCache() *EventCache {
+// NewEventCache creates a new event cache keeping events for retention
+// (<= 0 = DefaultEventRetention)
+func NewEventCache(retention time.Duration) *EventCache {
+	if retention <= 0 {
+		retention = DefaultEventRetention
+	}
 	return &EventCache{
-		events: make([]types.AgentEvent, 0, 2000),
+		retention: retention,
+		events:    make([]cachedEvent, 0, 2000),
 	}
 }
 
-// Add appends an event to the cache
+// Add appends an event to the cache, dropping events that left the retention window
 func (c *EventCache) Add(event types.AgentEvent) {
+	now := time.Now()
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.events = append(c.events, event)
+	c.expire(now)
+	if len(c.events) >= maxCachedEvents {
+		c.events = c.events[len(c.events)-maxCachedEvents+1:]
+	}
+	c.events = append(c.events, cachedEvent{added: now, event: event})
 }
 
-// GetAndClear returns all events and clears the cache
-func (c *EventCache) GetAndClear() []types.AgentEvent {
+// Expire drops the events added before the retention window ending at now
+func (c *EventCache) Expire(now time.Time) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.expire(now)
+}
+
+// expire drops expired events; called with mu held
+func (c *EventCache) expire(now time.Time) {
+	cutoff := now.Add(-c.retention)
+	i := 0
+	for i < len(c.events) && c.events[i].added.Before(cutoff) {
+		i++
+	}
+	if i > 0 {
+		c.events = c.events[i:]
+	}
+}
 
-	events := c.events
-	c.events = make([]types.AgentEvent, 0, 2000) // pre-allocate for next second
+// Query returns the cached events of agentID (empty = all agents) with a timestamp at or
+// after since (zero = no lower bound), oldest first
+func (c *EventCache) Query(agentID string, since time.Time) []types.AgentEvent {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	events := make([]types.AgentEvent, 0)
+	for _, e := range c.events {
+		if agentID != "" && e.event.AgentID != agentID {
+			continue
+		}
+		if e.event.Timestamp.Before(since) {
+			continue
+		}
+		events = append(events, e.event)
+	}
 	return events
 }
 
+// Retention returns how long events are kept
+func (c *EventCache) Retention() time.Duration {
+	return c.retention
+}
+
 // Size returns the current number of cached events
 func (c *EventCache) Size() int {
 	c.mu.RLock()
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+func TestEventCacheQuery(t *testing.T) {
+	c := NewEventCache(time.Minute)
+	base := time.Now().Add(-30 * time.Second)
+	c.Add(types.AgentEvent{AgentID: "agent-1", State: types.StateAvailable, Timestamp: base})
+	c.Add(types.AgentEvent{AgentID: "agent-2", State: types.StateOnCall, Timestamp: base.Add(time.Second)})
+	c.Add(types.AgentEvent{AgentID: "agent-1", State: types.StateOnCall, Timestamp: base.Add(2 * time.Second)})
+
+	if got := c.Query("", time.Time{}); len(got) != 3 {
+		t.Fatalf("expected all 3 events, got %d", len(got))
+	}
+	got := c.Query("agent-1", time.Time{})
+	if len(got) != 2 || got[0].State != types.StateAvailable || got[1].State != types.StateOnCall {
+		t.Errorf("expected agent-1's events oldest first, got %+v", got)
+	}
+	if got := c.Query("", base.Add(time.Second)); len(got) != 2 {
+		t.Errorf("expected 2 events since the second one, got %d", len(got))
+	}
+	if got := c.Query("agent-3", time.Time{}); got == nil || len(got) != 0 {
+		t.Errorf("expected an empty list for an unknown agent, got %v", got)
+	}
+}
+
+func TestEventCacheExpiresOutsideRetention(t *testing.T) {
+	c := NewEventCache(time.Minute)
+	c.Add(types.AgentEvent{AgentID: "agent-1", Timestamp: time.Now()})
+
+	c.Expire(time.Now().Add(30 * time.Second))
+	if c.Size() != 1 {
+		t.Fatalf("expected the event to be kept within the retention window, got %d", c.Size())
+	}
+	c.Expire(time.Now().Add(2 * time.Minute))
+	if c.Size() != 0 {
+		t.Errorf("expected the event to expire, got %d", c.Size())
+	}
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// frozenAgents is a copy of all tracked agents as of one freeze. Aggregation reads it
+// without the tracker lock.
+type frozenAgents struct {
+	agents map[string]types.AgentInfo
+	dirty  map[string]struct{} // changed since this buffer was last frozen
+	reset  bool                // refill from scratch on its next freeze
+}
+
+// snapshotBuffers double-buffers the frozen agents. Each freeze brings the back buffer up to
+// date with the agents changed since it was last frozen, then swaps it to the front; the
+// previous front stays untouched until the freeze after next.
+type snapshotBuffers struct {
+	mu      sync.Mutex // serializes freezes
+	buffers [2]frozenAgents
+	front   atomic.Pointer[frozenAgents]
+}
+
+func newSnapshotBuffers() *snapshotBuffers {
+	b := &snapshotBuffers{}
+	for i := range b.buffers {
+		b.buffers[i] = frozenAgents{
+			agents: make(map[string]types.AgentInfo),
+			dirty:  make(map[string]struct{}),
+		}
+	}
+	return b
+}
+
+// touch marks an agent as changed for both buffers (caller holds mu)
+func (t *AgentStateTracker) touch(agentID string) {
+	for i := range t.frozen.buffers {
+		t.frozen.buffers[i].dirty[agentID] = struct{}{}
+	}
+}
+
+// touchAll marks every agent as changed, e.g. after the agent map was replaced (caller holds mu)
+func (t *AgentStateTracker) touchAll() {
+	for i := range t.frozen.buffers {
+		b := &t.frozen.buffers[i]
+		b.reset = true
+		clear(b.dirty)
+	}
+}
+
+// freeze returns a consistent copy of all agents. It holds the read lock only to copy the
+// agents changed since the back buffer was last frozen, so ingest is blocked for a fraction
+// of a full copy. The view stays valid until the freeze after next; the aggregator freezes
+// once per cycle and is done with the previous view by then.
+func (t *AgentStateTracker) freeze() map[string]types.AgentInfo {
+	f := t.frozen
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	back := &f.buffers[0]
+	if f.front.Load() == back {
+		back = &f.buffers[1]
+	}
+
+	// The dirty sets only change under the write lock, so the read lock excludes writers
+	// while freezes are serialized by f.mu
+	t.mu.RLock()
+	if back.reset {
+		clear(back.agents)
+		for id, agent := range t.agents {
+			back.agents[id] = *agent
+		}
+		back.reset = false
+	} else {
+		for id := range back.dirty {
+			if agent, ok := t.agents[id]; ok {
+				back.agents[id] = *agent
+			} else {
+				delete(back.agents, id)
+			}
+		}
+	}
+	clear(back.dirty)
+	t.mu.RUnlock()
+
+	f.front.Store(back)
+	return back.agents
+}
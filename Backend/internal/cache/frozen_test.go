@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// snapshotAgents indexes the agents of a snapshot by ID
+func snapshotAgents(snapshot types.Snapshot) map[string]types.AgentInfo {
+	out := make(map[string]types.AgentInfo)
+	for _, data := range snapshot.Departments {
+		for _, a := range data.Agents {
+			out[a.AgentID] = a
+		}
+	}
+	return out
+}
+
+func TestSnapshotFollowsChangesAcrossSwaps(t *testing.T) {
+	tracker := NewAgentStateTracker()
+	for i := 0; i < 3; i++ {
+		tracker.RegisterAgent(&types.AgentRegister{AgentID: fmt.Sprintf("a%d", i), Department: types.DeptSales, State: types.StateAvailable})
+	}
+	tracker.BuildSnapshot(nil)
+
+	// Changed after the first freeze: the other buffer has to catch up on them too
+	tracker.UpdateFromStateChange(&types.AgentStateChange{AgentID: "a0", NewState: types.StateBreak, Department: types.DeptSales})
+	tracker.SetCurrentCall("a1", "c1", "sales_inbound", tracker.agents["a1"].StateStart)
+	tracker.SetDisconnected("a2")
+	if err := tracker.RetireAgent("a2"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		snapshot, connected := tracker.BuildSnapshot(nil)
+		agents := snapshotAgents(snapshot)
+		if len(agents) != tracker.Count() || len(connected) != 2 {
+			t.Fatalf("freeze %d: expected %d agents, 2 connected, got %d, %d", i, tracker.Count(), len(agents), len(connected))
+		}
+		if agents["a0"].State != types.StateBreak || agents["a1"].CurrentCallID != "c1" {
+			t.Errorf("freeze %d: expected the changes in the snapshot, got %+v", i, agents)
+		}
+	}
+
+	tracker.Clear()
+	if snapshot, connected := tracker.BuildSnapshot(nil); len(snapshotAgents(snapshot)) != 0 || len(connected) != 0 {
+		t.Error("expected no agents after Clear")
+	}
+}
+
+func TestSnapshotConsistentUnderIngest(t *testing.T) {
+	tracker := NewAgentStateTracker()
+	const agents = 200
+	for i := 0; i < agents; i++ {
+		tracker.RegisterAgent(&types.AgentRegister{AgentID: fmt.Sprintf("a%d", i), Department: types.DeptSales, State: types.StateAvailable})
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			states := []types.AgentState{types.StateOnCall, types.StateAfterCallWork, types.StateAvailable}
+			for i := 0; i < 500; i++ {
+				id := fmt.Sprintf("a%d", (w*agents/4+i)%agents)
+				tracker.UpdateFromHeartbeat(&types.AgentHeartbeat{AgentID: id, State: states[i%len(states)]})
+				if i%50 == 0 {
+					tracker.SetDisconnected(id)
+					tracker.RegisterAgent(&types.AgentRegister{AgentID: id, Department: types.DeptSales, State: types.StateAvailable})
+				}
+			}
+		}(w)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		snapshot, _ := tracker.BuildSnapshot(nil)
+		if n := len(snapshotAgents(snapshot)); n != agents {
+			t.Fatalf("expected %d agents in every snapshot, got %d", agents, n)
+		}
+	}
+
+	// Once ingest stops, two freezes bring both buffers up to date
+	for i := 0; i < 2; i++ {
+		snapshot, _ := tracker.BuildSnapshot(nil)
+		frozen := snapshotAgents(snapshot)
+		for _, live := range tracker.GetAll() {
+			if got := frozen[live.AgentID]; got.State != live.State || got.ConnectionStatus != live.ConnectionStatus || !got.LastHeartbeat.Equal(live.LastHeartbeat) {
+				t.Fatalf("freeze %d: expected %s as %s/%s, got %s/%s", i, live.AgentID, live.State, live.ConnectionStatus, got.State, got.ConnectionStatus)
+			}
+		}
+	}
+}
+
+func BenchmarkBuildSnapshot(b *testing.B) {
+	tracker := NewAgentStateTracker()
+	const agents = 10000
+	for i := 0; i < agents; i++ {
+		tracker.RegisterAgent(&types.AgentRegister{AgentID: fmt.Sprintf("a%d", i), Department: types.AllDepartments[i%len(types.AllDepartments)], State: types.StateAvailable})
+	}
+	tracker.BuildSnapshot(nil)
+
+	// About 1% of the agents change between snapshots, as with heartbeats every few seconds
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < agents/100; j++ {
+			tracker.UpdateFromHeartbeat(&types.AgentHeartbeat{AgentID: fmt.Sprintf("a%d", (i*agents/100+j)%agents), State: types.StateAvailable})
+		}
+		tracker.BuildSnapshot(nil)
+	}
+}
@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/metrics"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// TransitionPolicy is what the tracker does with a state change its transition table does
+// not allow
+type TransitionPolicy string
+
+const (
+	TransitionOff    TransitionPolicy = "off"    // apply every change unchecked
+	TransitionFlag   TransitionPolicy = "flag"   // apply it, but count it
+	TransitionReject TransitionPolicy = "reject" // count it and keep the current state
+)
+
+// Sources of state changes, as counted in the invalid transition metrics
+const (
+	SourceEvent       = "event"
+	SourceHeartbeat   = "heartbeat"
+	SourceStateChange = "state_change"
+)
+
+var (
+	callStates   = []types.AgentState{types.StateOnCall, types.StateOnHold, types.StateTransferring, types.StateConference}
+	absentStates = []types.AgentState{types.StateBreak, types.StateLunch, types.StateMeeting, types.StateTraining}
+)
+
+// stateTransitions lists, per state, the states an agent may move to from it. Absences
+// (break, lunch, meeting, training) can follow one another because supervisors force them;
+// calls are only taken while available and only end in after-call work, or in available
+// when a supervisor ends them. Staying in a state, going offline and coming back from offline
+// are always allowed, as is anything involving a state not in the table (busy, and values
+// accepted by permissive ingestion).
+var stateTransitions = map[types.AgentState]map[types.AgentState]bool{
+	types.StateAvailable:     transitionSet([]types.AgentState{types.StateOnCall, types.StateMultiSession}, absentStates),
+	types.StateAfterCallWork: transitionSet([]types.AgentState{types.StateAvailable}, absentStates),
+	types.StateBreak:         transitionSet([]types.AgentState{types.StateAvailable}, absentStates),
+	types.StateLunch:         transitionSet([]types.AgentState{types.StateAvailable}, absentStates),
+	types.StateMeeting:       transitionSet([]types.AgentState{types.StateAvailable}, absentStates),
+	types.StateTraining:      transitionSet([]types.AgentState{types.StateAvailable}, absentStates),
+	types.StateOnCall:        transitionSet([]types.AgentState{types.StateAvailable, types.StateAfterCallWork}, callStates),
+	types.StateOnHold:        transitionSet([]types.AgentState{types.StateAvailable, types.StateAfterCallWork}, callStates),
+	types.StateTransferring:  transitionSet([]types.AgentState{types.StateAvailable, types.StateAfterCallWork}, callStates),
+	types.StateConference:    transitionSet([]types.AgentState{types.StateAvailable, types.StateAfterCallWork}, callStates),
+	types.StateMultiSession:  transitionSet([]types.AgentState{types.StateAvailable, types.StateAfterCallWork, types.StateOnCall}),
+}
+
+func transitionSet(groups ...[]types.AgentState) map[types.AgentState]bool {
+	set := make(map[types.AgentState]bool)
+	for _, group := range groups {
+		for _, s := range group {
+			set[s] = true
+		}
+	}
+	return set
+}
+
+// ValidTransition reports whether an agent may move from one state to another
+func ValidTransition(from, to types.AgentState) bool {
+	if from == to || from == types.StateOffline || to == types.StateOffline {
+		return true
+	}
+	allowed, known := stateTransitions[from]
+	if !known {
+		return true
+	}
+	if _, checked := stateTransitions[to]; !checked {
+		return true
+	}
+	return allowed[to]
+}
+
+// transitionGuard is the tracker's transition policy and the quarantine of agents that
+// keep sending invalid transitions (guarded by the tracker's mu)
+type transitionGuard struct {
+	policy TransitionPolicy
+
+	// An agent sending quarantineAfter invalid transitions within quarantineFor has its state
+	// changes ignored for quarantineFor (0 = never quarantine)
+	quarantineAfter int
+	quarantineFor   time.Duration
+
+	strikes     map[string]*transitionStrikes
+	quarantined map[string]time.Time // agentID -> end of quarantine
+}
+
+type transitionStrikes struct {
+	count int
+	since time.Time
+}
+
+// SetTransitionPolicy sets what happens to state changes the transition table does not allow
+func (t *AgentStateTracker) SetTransitionPolicy(p TransitionPolicy) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.transitions.policy = p
+}
+
+// SetTransitionQuarantine quarantines agents that send `after` invalid transitions within
+// period: their state changes are ignored for period, while heartbeats keep them connected.
+// A registration is a full resync and lifts the quarantine. after = 0 disables it.
+func (t *AgentStateTracker) SetTransitionQuarantine(after int, period time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.transitions.quarantineAfter = after
+	t.transitions.quarantineFor = period
+}
+
+// allowTransition checks the agent's move to state reported by source, and returns whether
+// it may be applied. Caller holds mu.
+func (t *AgentStateTracker) allowTransition(agent *types.AgentInfo, to types.AgentState, source string, now time.Time) bool {
+	g := &t.transitions
+	if until, ok := g.quarantined[agent.AgentID]; ok {
+		if now.Before(until) {
+			if agent.State != to {
+				metrics.Get().RecordInvalidTransition(source, agent.State, to, "quarantined")
+			}
+			return false
+		}
+		t.liftQuarantine(agent)
+	}
+	if g.policy == TransitionOff || ValidTransition(agent.State, to) {
+		return true
+	}
+
+	action := string(g.policy)
+	if g.strike(agent.AgentID, now) {
+		action = "quarantined"
+		g.quarantined[agent.AgentID] = now.Add(g.quarantineFor)
+		agent.Quarantined = true
+		metrics.Get().RecordAgentQuarantine()
+	}
+	metrics.Get().RecordInvalidTransition(source, agent.State, to, action)
+	return action == string(TransitionFlag)
+}
+
+// strike counts an invalid transition and returns whether it puts the agent into quarantine
+func (g *transitionGuard) strike(agentID string, now time.Time) bool {
+	if g.quarantineAfter <= 0 {
+		return false
+	}
+	if g.strikes == nil {
+		g.strikes = make(map[string]*transitionStrikes)
+		g.quarantined = make(map[string]time.Time)
+	}
+	s, ok := g.strikes[agentID]
+	if !ok || now.Sub(s.since) > g.quarantineFor {
+		s = &transitionStrikes{since: now}
+		g.strikes[agentID] = s
+	}
+	s.count++
+	if s.count < g.quarantineAfter {
+		return false
+	}
+	delete(g.strikes, agentID)
+	return true
+}
+
+// liftQuarantine ends the agent's quarantine and forgets its strikes (caller holds mu)
+func (t *AgentStateTracker) liftQuarantine(agent *types.AgentInfo) {
+	delete(t.transitions.quarantined, agent.AgentID)
+	delete(t.transitions.strikes, agent.AgentID)
+	agent.Quarantined = false
+}
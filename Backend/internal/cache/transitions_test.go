@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+func TestValidTransition(t *testing.T) {
+	for _, tc := range []struct {
+		from, to types.AgentState
+		want     bool
+	}{
+		{types.StateAvailable, types.StateOnCall, true},
+		{types.StateOnCall, types.StateAfterCallWork, true},
+		{types.StateOnCall, types.StateAvailable, true}, // supervisor ended the call
+		{types.StateBreak, types.StateLunch, true},      // supervisor forced lunch
+		{types.StateOnCall, types.StateLunch, false},
+		{types.StateAvailable, types.StateAfterCallWork, false},
+		{types.StateBreak, types.StateOnCall, false},
+		{types.StateOnCall, types.StateOffline, true},
+		{types.StateOffline, types.StateOnHold, true},
+		{types.StateBusy, types.StateLunch, true},              // not in the table
+		{types.StateOnCall, types.AgentState("napping"), true}, // permissive ingestion
+	} {
+		if got := ValidTransition(tc.from, tc.to); got != tc.want {
+			t.Errorf("ValidTransition(%s, %s) = %v, want %v", tc.from, tc.to, got, tc.want)
+		}
+	}
+}
+
+func TestTransitionPolicy(t *testing.T) {
+	tracker := NewAgentStateTracker()
+	tracker.RegisterAgent(&types.AgentRegister{AgentID: "a1", Department: types.DeptSales, State: types.StateOnCall})
+
+	tracker.UpdateFromStateChange(&types.AgentStateChange{AgentID: "a1", NewState: types.StateLunch})
+	if agent, _ := tracker.Get("a1"); agent.State != types.StateLunch {
+		t.Fatalf("expected the flag policy to apply the change, got %s", agent.State)
+	}
+
+	tracker.SetTransitionPolicy(TransitionReject)
+	tracker.UpdateFromStateChange(&types.AgentStateChange{AgentID: "a1", NewState: types.StateOnHold})
+	tracker.UpdateFromHeartbeat(&types.AgentHeartbeat{AgentID: "a1", State: types.StateOnHold})
+	if agent, _ := tracker.Get("a1"); agent.State != types.StateLunch {
+		t.Errorf("expected the reject policy to keep lunch, got %s", agent.State)
+	}
+}
+
+func TestTransitionQuarantine(t *testing.T) {
+	tracker := NewAgentStateTracker()
+	tracker.SetTransitionPolicy(TransitionReject)
+	tracker.SetTransitionQuarantine(2, time.Hour)
+	tracker.RegisterAgent(&types.AgentRegister{AgentID: "a1", Department: types.DeptSales, State: types.StateAvailable})
+
+	for i := 0; i < 2; i++ {
+		tracker.UpdateFromStateChange(&types.AgentStateChange{AgentID: "a1", NewState: types.StateConference})
+	}
+	agent, _ := tracker.Get("a1")
+	if !agent.Quarantined {
+		t.Fatal("expected the agent to be quarantined after two invalid transitions")
+	}
+
+	// Valid changes are ignored too while quarantined
+	tracker.UpdateFromStateChange(&types.AgentStateChange{AgentID: "a1", NewState: types.StateOnCall})
+	if agent, _ := tracker.Get("a1"); agent.State != types.StateAvailable {
+		t.Errorf("expected a quarantined agent to stay available, got %s", agent.State)
+	}
+
+	// A registration resyncs the agent
+	tracker.RegisterAgent(&types.AgentRegister{AgentID: "a1", Department: types.DeptSales, State: types.StateAvailable})
+	tracker.UpdateFromStateChange(&types.AgentStateChange{AgentID: "a1", NewState: types.StateOnCall})
+	if agent, _ := tracker.Get("a1"); agent.Quarantined || agent.State != types.StateOnCall {
+		t.Errorf("expected registration to lift the quarantine, got %s quarantined=%v", agent.State, agent.Quarantined)
+	}
+}
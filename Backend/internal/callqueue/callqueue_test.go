@@ -1,6 +1,11 @@
 package callqueue
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -55,7 +60,7 @@ func TestLongestIdleFirstSelection(t *testing.T) {
 		{AgentID: "agent-3", StateStart: now.Add(-2 * time.Minute)},
 	}
 
-	selected := strategy.SelectAgent(agents)
+	selected := strategy.SelectAgent("", agents)
 	if selected == nil {
 		t.Fatal("expected agent to be selected")
 	}
@@ -66,11 +71,131 @@ func TestLongestIdleFirstSelection(t *testing.T) {
 
 func TestLongestIdleFirstEmpty(t *testing.T) {
 	strategy := &LongestIdleFirst{}
-	if strategy.SelectAgent(nil) != nil {
+	if strategy.SelectAgent("", nil) != nil {
 		t.Error("expected nil for empty list")
 	}
 }
 
+func TestLongestIdleFirstTiebreaker(t *testing.T) {
+	strategy := &LongestIdleFirst{}
+
+	since := time.Now().Add(-time.Minute)
+	agents := []types.AgentInfo{
+		{AgentID: "agent-3", IdleSince: &since, KPIs: types.AgentKPIs{TotalCalls: 4}},
+		{AgentID: "agent-2", IdleSince: &since, KPIs: types.AgentKPIs{TotalCalls: 2}},
+		{AgentID: "agent-1", IdleSince: &since, KPIs: types.AgentKPIs{TotalCalls: 2}},
+		{AgentID: "agent-0", StateStart: since.Add(time.Second)}, // idle for less time
+	}
+	if selected := strategy.SelectAgent("", agents); selected.AgentID != "agent-1" {
+		t.Errorf("expected agent-1 (fewer calls, then lower ID), got %s", selected.AgentID)
+	}
+}
+
+func TestProficiencyWeightedSelection(t *testing.T) {
+	now := time.Now()
+	idle := func(d time.Duration) *time.Time { t := now.Add(-d); return &t }
+	agents := []types.AgentInfo{
+		{AgentID: "expert", IdleSince: idle(10 * time.Second), Proficiency: map[string]int{"tech_l2": 5}, KPIs: types.AgentKPIs{TotalCalls: 9}},
+		{AgentID: "unrated", IdleSince: idle(time.Minute), KPIs: types.AgentKPIs{TotalCalls: 1}},
+		{AgentID: "novice", IdleSince: idle(2 * time.Minute), Proficiency: map[string]int{"technical": 2}, KPIs: types.AgentKPIs{TotalCalls: 5}},
+	}
+	opts := StrategyOptions{ProficiencyWeight: 0.7, ComplexVQs: []types.VQName{types.VQTechL2}}
+
+	// Complex VQ: the expert outscores agents idle far longer
+	if got := NewProficiencyWeighted(opts).SelectAgent(types.VQTechL2, agents); got.AgentID != "expert" {
+		t.Errorf("tech_l2: expected expert, got %s", got.AgentID)
+	}
+	// The department's rating applies to its other VQs, but simple VQs are spread evenly
+	if got := NewProficiencyWeighted(opts).SelectAgent(types.VQTechL1, agents); got.AgentID != "unrated" {
+		t.Errorf("tech_l1: expected unrated (fewest calls), got %s", got.AgentID)
+	}
+	// Without weight on proficiency the longest idle agent wins
+	opts.ProficiencyWeight = 0
+	if got := NewProficiencyWeighted(opts).SelectAgent(types.VQTechL2, agents); got.AgentID != "novice" {
+		t.Errorf("weight 0: expected novice (longest idle), got %s", got.AgentID)
+	}
+	if NewProficiencyWeighted(opts).SelectAgent(types.VQTechL2, nil) != nil {
+		t.Error("expected nil for empty list")
+	}
+}
+
+func TestSetStrategyOptionsRebuildsActiveStrategy(t *testing.T) {
+	mgr := NewCallQueueManager(cache.NewAgentStateTracker(), zerolog.Nop())
+	if err := mgr.SetRoutingStrategy(StrategyProficiency); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.SetStrategyOptions(StrategyOptions{ProficiencyWeight: 2}); err == nil {
+		t.Error("expected a weight above 1 to be rejected")
+	}
+	if err := mgr.SetStrategyOptions(StrategyOptions{ComplexVQs: []types.VQName{"bogus"}}); err == nil {
+		t.Error("expected an unknown complex VQ to be rejected")
+	}
+	if err := mgr.SetStrategyOptions(StrategyOptions{ProficiencyWeight: 1, ComplexVQs: []types.VQName{types.VQSalesInbound}}); err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := mgr.routing.(*ProficiencyWeighted); !ok || p.weight != 1 || !p.complex[types.VQSalesInbound] {
+		t.Errorf("expected the active strategy rebuilt with the new options, got %+v", mgr.routing)
+	}
+}
+
+func TestIdleTimeSurvivesHeartbeatAndStateInterleavings(t *testing.T) {
+	tracker := cache.NewAgentStateTracker()
+	mgr := NewCallQueueManager(tracker, zerolog.Nop())
+	register := func(id string) {
+		tracker.RegisterAgent(&types.AgentRegister{AgentID: id, Department: types.DeptSales,
+			Location: types.LocationBerlin, Team: "Team A", State: types.StateAvailable})
+	}
+	idleSince := func(id string) time.Time {
+		a, _ := tracker.Get(id)
+		if a.IdleSince == nil {
+			t.Fatalf("expected %s to be idle, got %+v", id, a)
+		}
+		return *a.IdleSince
+	}
+
+	register("agent-1")
+	time.Sleep(5 * time.Millisecond)
+	register("agent-2")
+	first := idleSince("agent-1")
+
+	// KPI-only updates repeating the current state must not make agent-1 look freshly idle
+	time.Sleep(5 * time.Millisecond)
+	tracker.UpdateFromStateChange(&types.AgentStateChange{AgentID: "agent-1", PreviousState: types.StateAvailable,
+		NewState: types.StateAvailable, KPIs: types.AgentKPIs{TotalCalls: 3}})
+	if a, _ := tracker.Get("agent-1"); a.KPIs.TotalCalls != 3 || !a.StateStart.Equal(first) {
+		t.Errorf("expected KPIs updated and StateStart kept, got %+v", a)
+	}
+	tracker.UpdateFromHeartbeat(&types.AgentHeartbeat{AgentID: "agent-1", State: types.StateAvailable})
+	// ... nor must its multiplexed batch reconnecting and registering again
+	register("agent-1")
+	if got := idleSince("agent-1"); !got.Equal(first) {
+		t.Errorf("expected idle time %v to be kept, got %v", first, got)
+	}
+
+	mgr.EnqueueCall(types.VQSalesInbound, "call-1")
+	if matches := mgr.TickRouting(); len(matches) != 1 || matches[0].AgentID != "agent-1" {
+		t.Fatalf("expected the call routed to agent-1, got %+v", matches)
+	}
+
+	// A heartbeat reporting the new state before its state_change starts the state once
+	tracker.UpdateFromHeartbeat(&types.AgentHeartbeat{AgentID: "agent-2", State: types.StateBreak})
+	hbStart, _ := tracker.Get("agent-2")
+	time.Sleep(5 * time.Millisecond)
+	tracker.UpdateFromStateChange(&types.AgentStateChange{AgentID: "agent-2", PreviousState: types.StateAvailable,
+		NewState: types.StateBreak, Reason: types.BreakPersonal})
+	a2, _ := tracker.Get("agent-2")
+	if !a2.StateStart.Equal(hbStart.StateStart) || a2.IdleSince != nil || a2.BreakReason != types.BreakPersonal {
+		t.Errorf("expected the heartbeat's break start with the state_change's reason, got %+v", a2)
+	}
+
+	// Becoming available again starts a new idle period
+	tracker.UpdateFromStateChange(&types.AgentStateChange{AgentID: "agent-2", PreviousState: types.StateBreak,
+		NewState: types.StateAvailable})
+	if got := idleSince("agent-2"); !got.After(first) {
+		t.Errorf("expected a fresh idle time, got %v", got)
+	}
+}
+
 func TestServiceLevelCalculation(t *testing.T) {
 	sl := NewSLTracker(80, 20)
 
@@ -136,7 +261,7 @@ func TestCallQueueManagerEnqueueRouteComplete(t *testing.T) {
 	}
 
 	// Complete
-	completed := mgr.CompleteCall("call-1", 120.0, 5.0)
+	completed, _ := mgr.CompleteCall("agent-1", "call-1", 120.0, 5.0)
 	if completed == nil {
 		t.Fatal("expected call to be completed")
 	}
@@ -154,6 +279,221 @@ func TestCallQueueManagerEnqueueRouteComplete(t *testing.T) {
 	}
 }
 
+func TestAssignedCallTrackedOnAgent(t *testing.T) {
+	tracker := cache.NewAgentStateTracker()
+	mgr := NewCallQueueManager(tracker, zerolog.Nop())
+	mgr.SetChatMaxSessions(2)
+
+	tracker.RegisterAgent(&types.AgentRegister{
+		AgentID:    "agent-1",
+		Department: types.DeptSales,
+		Location:   types.LocationBerlin,
+		State:      types.StateAvailable,
+	})
+	mgr.EnqueueCall(types.VQSalesChat, "chat-1")
+	mgr.TickRouting()
+
+	agent, _ := tracker.Get("agent-1")
+	if agent.CurrentCallID != "chat-1" || agent.CurrentVQ != types.VQSalesChat || agent.CallStartTime == nil {
+		t.Fatalf("expected chat-1 on the agent, got %q %q %v", agent.CurrentCallID, agent.CurrentVQ, agent.CallStartTime)
+	}
+
+	tracker.UpdateFromStateChange(&types.AgentStateChange{AgentID: "agent-1", NewState: types.StateMultiSession})
+	mgr.EnqueueCall(types.VQSalesChat, "chat-2")
+	mgr.TickRouting()
+	if agent, _ = tracker.Get("agent-1"); agent.CurrentCallID != "chat-2" {
+		t.Fatalf("expected the latest chat on the agent, got %q", agent.CurrentCallID)
+	}
+
+	// Ending the shown chat falls back to the one still open, ending that clears it
+	mgr.CompleteCall("agent-1", "chat-2", 60, 0)
+	if agent, _ = tracker.Get("agent-1"); agent.CurrentCallID != "chat-1" {
+		t.Errorf("expected chat-1 after chat-2 ended, got %q", agent.CurrentCallID)
+	}
+	mgr.CompleteCall("agent-1", "chat-1", 60, 0)
+	if agent, _ = tracker.Get("agent-1"); agent.CurrentCallID != "" || agent.CurrentVQ != "" || agent.CallStartTime != nil {
+		t.Errorf("expected no current call, got %q", agent.CurrentCallID)
+	}
+}
+
+func TestCompleteCallIdempotent(t *testing.T) {
+	tracker := cache.NewAgentStateTracker()
+	mgr := NewCallQueueManager(tracker, zerolog.Nop())
+	store := &dailyStore{}
+	mgr.SetStore(store)
+	for _, id := range []string{"agent-1", "agent-2"} {
+		tracker.RegisterAgent(&types.AgentRegister{AgentID: id, Department: types.DeptSales,
+			Location: types.LocationBerlin, State: types.StateAvailable})
+	}
+	for _, id := range []string{"call-1", "call-2"} {
+		mgr.EnqueueCall(types.VQSalesInbound, id)
+	}
+	agentOf := make(map[string]string)
+	for _, match := range mgr.TickRouting() {
+		agentOf[match.Call.CallID] = match.AgentID
+	}
+
+	// A repeated call_complete is a duplicate; one with other times conflicts and is ignored
+	if call, duplicate := mgr.CompleteCall(agentOf["call-1"], "call-1", 120, 5); call == nil || duplicate {
+		t.Fatalf("expected call-1 completed, got %+v, %t", call, duplicate)
+	}
+	mgr.mu.Lock()
+	_, dup := mgr.completeCall(agentOf["call-1"], "call-1", 120.4, 5)
+	call, conflict := mgr.completeCall(agentOf["call-1"], "call-1", 200, 5)
+	mgr.mu.Unlock()
+	if dup != completionDuplicate || conflict != completionConflict || call.TalkTime != 120 {
+		t.Errorf("expected duplicate, then conflict keeping 120s, got %s, %s, %v", dup, conflict, call.TalkTime)
+	}
+	if _, duplicate := mgr.CompleteCall(agentOf["call-1"], "call-1", 200, 5); !duplicate {
+		t.Error("expected a conflicting report flagged as duplicate for the agent's KPIs")
+	}
+	if snapshot := mgr.GetSnapshot(types.VQSalesInbound); snapshot.CompletedCount != 1 || len(store.records) != 1 {
+		t.Fatalf("expected call-1 counted and saved once, got %d completed, %d records", snapshot.CompletedCount, len(store.records))
+	}
+
+	// The agent's times replace the estimate of a force-end, in the record and the interval stats
+	if _, found := mgr.ForceEndCall("call-2"); !found {
+		t.Fatal("expected call-2 force-ended")
+	}
+	if call, duplicate := mgr.CompleteCall(agentOf["call-1"], "call-2", 90, 0); call != nil || duplicate {
+		t.Errorf("expected call-2 of another agent ignored, got %+v", call)
+	}
+	if call, duplicate := mgr.CompleteCall(agentOf["call-2"], "call-2", 90, 10); call == nil || duplicate || call.TalkTime != 90 {
+		t.Fatalf("expected call-2 reconciled with the agent's times, got %+v, %t", call, duplicate)
+	}
+	if record := store.records[len(store.records)-1]; record.CallID != "call-2" || record.TalkTime != 90 || record.HoldTime != 10 {
+		t.Errorf("expected the reconciled record saved again, got %+v", record)
+	}
+	handle := 0.0
+	mgr.mu.RLock()
+	for _, b := range mgr.stats.buckets[types.VQSalesInbound] {
+		handle += b.handleSecs
+	}
+	mgr.mu.RUnlock()
+	if handle != 225 {
+		t.Errorf("expected 125s + 100s handle time in the interval stats, got %v", handle)
+	}
+
+	// A call reaped from its agent ignores the agent's late report; old calls are forgotten
+	mgr.EnqueueCall(types.VQSalesInbound, "call-3")
+	match := mgr.TickRouting()[0]
+	mgr.ReapCall("call-3", OrphanAbandon)
+	if call, _ := mgr.CompleteCall(match.AgentID, "call-3", 60, 0); call != nil {
+		t.Errorf("expected the late report of a reaped call ignored, got %+v", call)
+	}
+	mgr.mu.Lock()
+	mgr.finished.expire(time.Now().Add(finishedCallRetention + time.Second))
+	_, result := mgr.completeCall(agentOf["call-1"], "call-1", 120, 5)
+	mgr.mu.Unlock()
+	if result != completionUnknown || len(mgr.finished.order) != 0 {
+		t.Errorf("expected finished calls forgotten after the retention, got %s", result)
+	}
+}
+
+func TestHoldTimeAccumulatesOnActiveCall(t *testing.T) {
+	tracker := cache.NewAgentStateTracker()
+	mgr := NewCallQueueManager(tracker, zerolog.Nop())
+	for _, id := range []string{"agent-1", "agent-2"} {
+		tracker.RegisterAgent(&types.AgentRegister{AgentID: id, Department: types.DeptSales,
+			Location: types.LocationBerlin, State: types.StateAvailable})
+	}
+	mgr.EnqueueCall(types.VQSalesInbound, "call-1")
+	agentID := mgr.TickRouting()[0].AgentID
+	other := "agent-1"
+	if agentID == other {
+		other = "agent-2"
+	}
+
+	// A repeated start keeps the first; another agent's hold is ignored
+	start := time.Now().Add(-time.Minute)
+	mgr.HoldCall(agentID, "call-1", true, start)
+	mgr.HoldCall(agentID, "call-1", true, start.Add(10*time.Second))
+	if call := mgr.HoldCall(other, "call-1", false, start.Add(20*time.Second)); call != nil {
+		t.Errorf("expected the hold of another agent ignored, got %+v", call)
+	}
+	if snapshot := mgr.GetSnapshot(types.VQSalesInbound); snapshot.OnHoldCount != 1 {
+		t.Errorf("expected 1 call on hold, got %d", snapshot.OnHoldCount)
+	}
+	if agent, _ := tracker.Get(agentID); agent.HoldStartTime == nil || !agent.HoldStartTime.Equal(start) {
+		t.Errorf("expected the agent on hold since the first start, got %v", agent.HoldStartTime)
+	}
+
+	call := mgr.HoldCall(agentID, "call-1", false, start.Add(30*time.Second))
+	if call == nil || call.HoldTime != 30 || call.Holds != 1 || call.HoldStart != nil {
+		t.Fatalf("expected one 30s hold, got %+v", call)
+	}
+	if agent, _ := tracker.Get(agentID); agent.CallHoldTime != 30 || agent.HoldStartTime != nil {
+		t.Errorf("expected the agent off hold after 30s, got %v, %v", agent.CallHoldTime, agent.HoldStartTime)
+	}
+	if snapshot := mgr.GetSnapshot(types.VQSalesInbound); snapshot.OnHoldCount != 0 {
+		t.Errorf("expected no call on hold, got %d", snapshot.OnHoldCount)
+	}
+
+	// A call_complete without hold time keeps the holds tracked live, also when repeated
+	mgr.HoldCall(agentID, "call-1", true, time.Now().Add(-5*time.Second))
+	call, duplicate := mgr.CompleteCall(agentID, "call-1", 100, 0)
+	if call == nil || duplicate || call.HoldTime < 35 || call.HoldStart != nil {
+		t.Fatalf("expected the open hold ended with the call, got %+v", call)
+	}
+	if _, duplicate := mgr.CompleteCall(agentID, "call-1", 100, 0); !duplicate {
+		t.Error("expected the repeated call_complete to be a duplicate")
+	}
+	if call := mgr.HoldCall(agentID, "call-1", true, time.Now()); call != nil {
+		t.Errorf("expected a hold of a completed call ignored, got %+v", call)
+	}
+}
+
+func TestReapOrphanedCalls(t *testing.T) {
+	tracker := cache.NewAgentStateTracker()
+	mgr := NewCallQueueManager(tracker, zerolog.Nop())
+	for _, id := range []string{"agent-1", "agent-2"} {
+		tracker.RegisterAgent(&types.AgentRegister{AgentID: id, Department: types.DeptSales,
+			Location: types.LocationBerlin, State: types.StateAvailable})
+	}
+	mgr.EnqueueCall(types.VQSalesInbound, "call-1")
+	mgr.EnqueueCall(types.VQSalesInbound, "call-2")
+	mgr.TickRouting()
+	mgr.EnqueueCall(types.VQSalesInbound, "call-3")
+
+	agent1Call := "call-1"
+	if agent, _ := tracker.Get("agent-1"); agent.CurrentCallID != "call-1" {
+		agent1Call = "call-2"
+	}
+	tracker.SetDisconnected("agent-1")
+
+	now := time.Now()
+	if reaped := mgr.ReapOrphanedCalls(30*time.Second, OrphanRequeue, now); len(reaped) != 0 {
+		t.Fatalf("expected no call released within the grace period, got %+v", reaped)
+	}
+	reaped := mgr.ReapOrphanedCalls(30*time.Second, OrphanRequeue, now.Add(31*time.Second))
+	if len(reaped) != 1 || reaped[0].CallID != agent1Call || reaped[0].Status != types.CallStatusWaiting || reaped[0].Requeues != 1 {
+		t.Fatalf("expected %s requeued, got %+v", agent1Call, reaped)
+	}
+	if agent, _ := tracker.Get("agent-1"); agent.CurrentCallID != "" {
+		t.Errorf("expected the call cleared from the agent, got %q", agent.CurrentCallID)
+	}
+	snapshot := mgr.GetSnapshot(types.VQSalesInbound)
+	if snapshot.WaitingCount != 2 || snapshot.ActiveCount != 1 {
+		t.Errorf("expected 2 waiting and 1 active, got %d and %d", snapshot.WaitingCount, snapshot.ActiveCount)
+	}
+	mgr.mu.RLock()
+	head := mgr.queues[queueKey{types.DefaultTenant, types.VQSalesInbound}].Waiting[0].CallID
+	mgr.mu.RUnlock()
+	if head != agent1Call {
+		t.Errorf("expected the requeued call at the head of the queue, got %s", head)
+	}
+
+	// Calls of agents no longer tracked are orphaned too; abandoning counts them
+	tracker.Clear()
+	reaped = mgr.ReapOrphanedCalls(30*time.Second, OrphanAbandon, now)
+	if len(reaped) != 1 || reaped[0].Status != types.CallStatusAbandoned {
+		t.Fatalf("expected the other call abandoned, got %+v", reaped)
+	}
+	if snapshot := mgr.GetSnapshot(types.VQSalesInbound); snapshot.ActiveCount != 0 || snapshot.AbandonedCount != 1 {
+		t.Errorf("expected no active and 1 abandoned call, got %+v", snapshot)
+	}
+}
+
 func TestCallQueueManagerNoAvailableAgent(t *testing.T) {
 	tracker := cache.NewAgentStateTracker()
 	logger := zerolog.Nop()
@@ -208,3 +548,552 @@ func TestGetAllSnapshots(t *testing.T) {
 		}
 	}
 }
+
+func TestTenantsRoutedSeparately(t *testing.T) {
+	if err := types.SetTenants([]string{"acme"}); err != nil {
+		t.Fatal(err)
+	}
+	defer types.SetTenants(nil)
+
+	tracker := cache.NewAgentStateTracker()
+	mgr := NewCallQueueManager(tracker, zerolog.Nop())
+	tracker.RegisterAgent(&types.AgentRegister{AgentID: "agent-1", Tenant: "acme", Department: types.DeptSales,
+		Location: types.LocationBerlin, Team: "Team A", State: types.StateAvailable})
+
+	// The default tenant's call must not reach acme's agent
+	mgr.EnqueueCall(types.VQSalesInbound, "call-1")
+	if matches := mgr.TickRouting(); len(matches) != 0 {
+		t.Fatalf("expected no cross-tenant routing, got %+v", matches)
+	}
+
+	mgr.EnqueueCallContext(context.Background(), "acme", types.VQSalesInbound, "call-2")
+	matches := mgr.TickRouting()
+	if len(matches) != 1 || matches[0].Call.CallID != "call-2" || matches[0].AgentID != "agent-1" {
+		t.Fatalf("expected call-2 routed to agent-1, got %+v", matches)
+	}
+
+	waiting := make(map[string]int)
+	for _, q := range mgr.GetAllSnapshots()[types.DeptSales] {
+		if q.VQ == types.VQSalesInbound {
+			waiting[types.TenantOf(q.Tenant)] = q.WaitingCount
+		}
+	}
+	if waiting[types.DefaultTenant] != 1 || waiting["acme"] != 0 {
+		t.Errorf("expected call-1 waiting in the default tenant only, got %v", waiting)
+	}
+	if rec := callToRecord(matches[0].Call); rec.DateKey != "acme#"+matches[0].Call.EnqueueTime.Format("2006-01-02") {
+		t.Errorf("expected tenant-prefixed date key, got %q", rec.DateKey)
+	}
+}
+
+// closedHours keeps every department closed
+type closedHours struct{}
+
+func (closedHours) IsOpen(types.Department, time.Time) bool { return false }
+
+func TestSLNotTrackedOutsideBusinessHours(t *testing.T) {
+	tracker := cache.NewAgentStateTracker()
+	mgr := NewCallQueueManager(tracker, zerolog.Nop())
+	mgr.SetBusinessHours(closedHours{})
+	tracker.RegisterAgent(&types.AgentRegister{AgentID: "agent-1", Department: types.DeptSales,
+		Location: types.LocationBerlin, Team: "Team A", State: types.StateAvailable})
+
+	mgr.EnqueueCall(types.VQSalesInbound, "call-1")
+	if matches := mgr.TickRouting(); len(matches) != 1 {
+		t.Fatalf("expected calls still routed while closed, got %+v", matches)
+	}
+	snapshot := mgr.GetSnapshot(types.VQSalesInbound)
+	if !snapshot.Closed || snapshot.ServiceLevel.TotalAnswered != 0 {
+		t.Errorf("expected a closed queue without SL answers, got %+v", snapshot)
+	}
+}
+
+// dailyStore records the saved call records, VQ daily stats and queue events
+type dailyStore struct {
+	records []types.CallRecord
+	saved   []types.VQDailyStats
+	events  []types.QueueEvent
+}
+
+func (s *dailyStore) SaveCallRecord(record types.CallRecord) error {
+	s.records = append(s.records, record)
+	return nil
+}
+func (s *dailyStore) SaveQueueEvent(event types.QueueEvent) error {
+	s.events = append(s.events, event)
+	return nil
+}
+func (s *dailyStore) SaveVQDailyStats(stats types.VQDailyStats) error {
+	s.saved = append(s.saved, stats)
+	return nil
+}
+
+func TestCountersRollOverAtBusinessDayStart(t *testing.T) {
+	tracker := cache.NewAgentStateTracker()
+	mgr := NewCallQueueManager(tracker, zerolog.Nop())
+	store := &dailyStore{}
+	mgr.SetStore(store)
+	mgr.dayStart = 6 * time.Hour
+
+	// 05:00 still belongs to the business day that started the day before at 06:00
+	mgr.rollover(time.Date(2025, 3, 10, 5, 0, 0, 0, time.UTC))
+	if mgr.day != "2025-03-09" {
+		t.Fatalf("expected business day 2025-03-09, got %s", mgr.day)
+	}
+	mgr.EnqueueCall(types.VQSalesInbound, "call-1")
+	mgr.AbandonCall("call-1")
+
+	mgr.rollover(time.Date(2025, 3, 10, 5, 59, 0, 0, time.UTC))
+	if len(store.saved) != 0 {
+		t.Fatalf("expected no rollover before 06:00, got %+v", store.saved)
+	}
+
+	rolled := time.Date(2025, 3, 10, 6, 0, 1, 0, time.UTC)
+	mgr.rollover(rolled)
+	if mgr.day != "2025-03-10" || len(store.saved) != len(mgr.queues) {
+		t.Fatalf("expected every queue saved and day 2025-03-10, got day %s and %d saved", mgr.day, len(store.saved))
+	}
+	for _, stats := range store.saved {
+		if stats.VQ == types.VQSalesInbound && (stats.DateKey != "2025-03-09" || stats.Abandoned != 1 || !stats.To.Equal(rolled)) {
+			t.Errorf("unexpected sales inbound totals: %+v", stats)
+		}
+	}
+
+	snapshot := mgr.GetSnapshot(types.VQSalesInbound)
+	if snapshot.AbandonedCount != 0 || !snapshot.ResetAt.Equal(rolled) {
+		t.Errorf("expected counters reset at the rollover, got %+v", snapshot)
+	}
+}
+
+func TestIntervalStatsHistory(t *testing.T) {
+	stats := NewIntervalStats()
+	now := time.Date(2025, 3, 10, 10, 7, 0, 0, time.UTC)
+
+	// Two calls in the 09:45 interval, one in 09:30, one in the current (incomplete) interval
+	stats.RecordOffered(types.VQSalesInbound, now.Add(-20*time.Minute))
+	stats.RecordHandled(types.VQSalesInbound, 100, now.Add(-18*time.Minute))
+	stats.RecordOffered(types.VQSalesInbound, now.Add(-15*time.Minute))
+	stats.RecordHandled(types.VQSalesInbound, 200, now.Add(-14*time.Minute))
+	stats.RecordOffered(types.VQSalesInbound, now.Add(-30*time.Minute))
+	stats.RecordAbandoned(types.VQSalesInbound, now.Add(-29*time.Minute))
+	stats.RecordOffered(types.VQSalesInbound, now.Add(-time.Minute))
+
+	history := stats.History(types.VQSalesInbound, 15*time.Minute, 2, now)
+	if len(history) != 2 {
+		t.Fatalf("expected 2 intervals, got %d", len(history))
+	}
+	if !history[1].Start.Equal(time.Date(2025, 3, 10, 9, 45, 0, 0, time.UTC)) {
+		t.Errorf("expected last interval to start at 09:45, got %s", history[1].Start)
+	}
+	if history[1].Offered != 2 || history[1].Handled != 2 || history[1].AHTSecs != 150 {
+		t.Errorf("unexpected 09:45 interval: %+v", history[1])
+	}
+	if history[0].Offered != 1 || history[0].Abandoned != 1 {
+		t.Errorf("unexpected 09:30 interval: %+v", history[0])
+	}
+}
+
+func TestHandleEnqueueRejectsUnknownVQ(t *testing.T) {
+	tracker := cache.NewAgentStateTracker()
+	mgr := NewCallQueueManager(tracker, zerolog.Nop())
+	h := NewCallHandler(mgr, zerolog.Nop())
+
+	rec := httptest.NewRecorder()
+	h.HandleEnqueue(rec, httptest.NewRequest(http.MethodPost, "/internal/call/enqueue", strings.NewReader(`{"vq":"bogus"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+	var resp types.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("expected JSON error body: %v", err)
+	}
+	if resp.Code != types.ErrCodeValidation {
+		t.Errorf("expected code %s, got %q", types.ErrCodeValidation, resp.Code)
+	}
+	if len(resp.Fields) != 1 || resp.Fields[0].Field != "vq" || resp.Fields[0].Code != types.FieldUnknownValue {
+		t.Errorf("expected a vq field error, got %+v", resp.Fields)
+	}
+
+	// Every rejected field is reported, not just the first
+	rec = httptest.NewRecorder()
+	h.HandleEnqueue(rec, httptest.NewRequest(http.MethodPost, "/internal/call/enqueue", strings.NewReader(`{"tenant":"nowhere"}`)))
+	resp = types.ErrorResponse{}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if len(resp.Fields) != 2 || resp.Fields[0].Code != types.FieldRequired || resp.Fields[1].Value != "nowhere" {
+		t.Errorf("expected vq required and tenant unknown, got %+v", resp.Fields)
+	}
+
+	rec = httptest.NewRecorder()
+	h.HandleEnqueue(rec, httptest.NewRequest(http.MethodPost, "/internal/call/enqueue", strings.NewReader(`{"vq":"sales_inbound"}`)))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for a known VQ, got %d", rec.Code)
+	}
+}
+
+// recordingObserver collects call notifications
+type recordingObserver struct {
+	completed, abandoned []string
+}
+
+func (o *recordingObserver) CallCompleted(call types.Call) {
+	o.completed = append(o.completed, call.CallID)
+}
+func (o *recordingObserver) CallAbandoned(call types.Call) {
+	o.abandoned = append(o.abandoned, call.CallID)
+}
+
+func TestCallObserverNotified(t *testing.T) {
+	tracker := cache.NewAgentStateTracker()
+	mgr := NewCallQueueManager(tracker, zerolog.Nop())
+	observer := &recordingObserver{}
+	mgr.SetCallObserver(observer)
+
+	tracker.RegisterAgent(&types.AgentRegister{
+		AgentID:    "agent-1",
+		Department: types.DeptSales,
+		Location:   types.LocationBerlin,
+		State:      types.StateAvailable,
+	})
+	mgr.EnqueueCall(types.VQSalesInbound, "call-1")
+	mgr.EnqueueCall(types.VQSalesInbound, "call-2")
+	mgr.TickRouting()
+	mgr.CompleteCall("agent-1", "call-1", 60, 0)
+	mgr.AbandonCall("call-2")
+	mgr.CompleteCall("agent-1", "unknown", 60, 0)
+
+	if len(observer.completed) != 1 || observer.completed[0] != "call-1" {
+		t.Errorf("expected completion of call-1, got %v", observer.completed)
+	}
+	if len(observer.abandoned) != 1 || observer.abandoned[0] != "call-2" {
+		t.Errorf("expected abandon of call-2, got %v", observer.abandoned)
+	}
+}
+
+func TestChatConcurrency(t *testing.T) {
+	tracker := cache.NewAgentStateTracker()
+	mgr := NewCallQueueManager(tracker, zerolog.Nop())
+	mgr.SetChatMaxSessions(2)
+
+	tracker.RegisterAgent(&types.AgentRegister{
+		AgentID:    "agent-1",
+		Department: types.DeptSales,
+		Location:   types.LocationBerlin,
+		State:      types.StateAvailable,
+	})
+	mgr.EnqueueCall(types.VQSalesChat, "chat-1")
+	mgr.EnqueueCall(types.VQSalesChat, "chat-2")
+	mgr.EnqueueCall(types.VQSalesChat, "chat-3")
+
+	// An available agent gets one chat until it reports multi-session
+	if matches := mgr.TickRouting(); len(matches) != 1 {
+		t.Fatalf("expected 1 chat routed to an available agent, got %d", len(matches))
+	}
+	if matches := mgr.TickRouting(); len(matches) != 0 {
+		t.Fatalf("expected no second chat before multi-session, got %+v", matches)
+	}
+	tracker.UpdateFromStateChange(&types.AgentStateChange{AgentID: "agent-1", NewState: types.StateMultiSession})
+	if matches := mgr.TickRouting(); len(matches) != 1 {
+		t.Fatalf("expected a concurrent chat in multi-session, got %d", len(matches))
+	}
+
+	// At the limit the agent takes no more chats, and no voice calls while chatting
+	mgr.EnqueueCall(types.VQSalesInbound, "call-1")
+	if matches := mgr.TickRouting(); len(matches) != 0 {
+		t.Fatalf("expected nothing routed at the chat limit, got %+v", matches)
+	}
+
+	var chat types.ChannelOccupancy
+	for _, c := range mgr.GetChannelOccupancy()[types.DeptSales] {
+		if c.Channel == types.ChannelChat {
+			chat = c
+		}
+	}
+	if chat.Active != 2 || chat.Capacity != 2 || chat.Occupancy != 100 {
+		t.Errorf("expected full chat occupancy, got %+v", chat)
+	}
+
+	mgr.CompleteCall("agent-1", "chat-1", 120, 0)
+	matches := mgr.TickRouting()
+	if len(matches) != 1 || matches[0].Call.CallID != "chat-3" {
+		t.Fatalf("expected chat-3 routed after a chat ended, got %+v", matches)
+	}
+	if snapshot := mgr.GetSnapshot(types.VQSalesChat); snapshot.Channel != types.ChannelChat || snapshot.ActiveCount != 2 {
+		t.Errorf("unexpected chat snapshot: %+v", snapshot)
+	}
+}
+
+func TestChatsSpreadAcrossAgents(t *testing.T) {
+	tracker := cache.NewAgentStateTracker()
+	mgr := NewCallQueueManager(tracker, zerolog.Nop())
+
+	for _, id := range []string{"agent-1", "agent-2"} {
+		tracker.RegisterAgent(&types.AgentRegister{
+			AgentID:    id,
+			Department: types.DeptSupport,
+			Location:   types.LocationBerlin,
+			State:      types.StateAvailable,
+		})
+	}
+	mgr.EnqueueCall(types.VQSupportChat, "chat-1")
+	mgr.EnqueueCall(types.VQSupportChat, "chat-2")
+
+	matches := mgr.TickRouting()
+	if len(matches) != 2 || matches[0].AgentID == matches[1].AgentID {
+		t.Errorf("expected one chat per agent, got %+v", matches)
+	}
+}
+
+func TestReserveHoldsAgentsForCriticalVQ(t *testing.T) {
+	tracker := cache.NewAgentStateTracker()
+	mgr := NewCallQueueManager(tracker, zerolog.Nop())
+	mgr.SetReserves(Reserves{types.VQRetentionCancel: {Agents: 2}})
+
+	for _, id := range []string{"agent-1", "agent-2", "agent-3"} {
+		tracker.RegisterAgent(&types.AgentRegister{
+			AgentID:    id,
+			Department: types.DeptRetention,
+			Location:   types.LocationBerlin,
+			State:      types.StateAvailable,
+		})
+	}
+	onCall := func(matches []RoutingMatch) {
+		for _, m := range matches {
+			tracker.UpdateFromStateChange(&types.AgentStateChange{AgentID: m.AgentID, NewState: types.StateOnCall})
+		}
+	}
+
+	mgr.EnqueueCall(types.VQRetentionSave, "save-1")
+	mgr.EnqueueCall(types.VQRetentionSave, "save-2")
+	matches := mgr.TickRouting()
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 save call routed above the reserve, got %d", len(matches))
+	}
+	onCall(matches)
+	reserve := mgr.GetSnapshot(types.VQRetentionCancel).Reserve
+	if reserve == nil || reserve.Reserved != 2 || reserve.Idle != 2 || reserve.Utilization != 0 {
+		t.Errorf("expected a full reserve standing by, got %+v", reserve)
+	}
+
+	mgr.EnqueueCall(types.VQRetentionCancel, "cancel-1")
+	matches = mgr.TickRouting()
+	if len(matches) != 1 || matches[0].Call.CallID != "cancel-1" {
+		t.Fatalf("expected only the reserved VQ's call routed, got %+v", matches)
+	}
+	onCall(matches)
+	reserve = mgr.GetSnapshot(types.VQRetentionCancel).Reserve
+	if reserve.Idle != 1 || reserve.Utilization != 50 {
+		t.Errorf("expected half the reserve in use, got %+v", reserve)
+	}
+	if mgr.GetSnapshot(types.VQRetentionSave).Reserve != nil {
+		t.Error("expected no reserve on an unreserved VQ")
+	}
+
+	if err := (Reserves{"bogus": {Agents: 1}}).validate(); err == nil {
+		t.Error("expected an unknown VQ to be rejected")
+	}
+	if got := (Reserve{Percent: 10, Agents: 2}).Count(50); got != 5 {
+		t.Errorf("expected the higher of percent and agents, got %d", got)
+	}
+}
+
+func TestOverflowToSkilledFallbackAgents(t *testing.T) {
+	tracker := cache.NewAgentStateTracker()
+	mgr := NewCallQueueManager(tracker, zerolog.Nop())
+	mgr.SetOverflow(OverflowRules{types.DeptSales: {Department: types.DeptSupport, AfterSecs: 30}})
+
+	tracker.UpsertRosterAgent(types.RosterRecord{AgentID: "support-1", Department: types.DeptSupport, Location: types.LocationBerlin, Skills: []string{"sales"}})
+	tracker.UpsertRosterAgent(types.RosterRecord{AgentID: "support-2", Department: types.DeptSupport, Location: types.LocationBerlin})
+	for _, id := range []string{"support-1", "support-2"} {
+		tracker.RegisterAgent(&types.AgentRegister{AgentID: id, Department: types.DeptSupport, Location: types.LocationBerlin, State: types.StateAvailable})
+	}
+
+	call := mgr.EnqueueCall(types.VQSalesInbound, "call-1")
+	if matches := mgr.TickRouting(); len(matches) != 0 {
+		t.Fatalf("expected no overflow before the wait threshold, got %+v", matches)
+	}
+
+	call.EnqueueTime = call.EnqueueTime.Add(-time.Minute)
+	matches := mgr.TickRouting()
+	if len(matches) != 1 || matches[0].AgentID != "support-1" {
+		t.Fatalf("expected overflow to the skilled support agent, got %+v", matches)
+	}
+	if got := callToRecord(matches[0].Call).OverflowDepartment; got != string(types.DeptSupport) {
+		t.Errorf("expected the overflow recorded on the call record, got %q", got)
+	}
+
+	if err := (OverflowRules{types.DeptSales: {Department: types.DeptSales}}).validate(); err == nil {
+		t.Error("expected overflow to the same department to be rejected")
+	}
+}
+
+func TestOverflowInDepartmentOrder(t *testing.T) {
+	tracker := cache.NewAgentStateTracker()
+	mgr := NewCallQueueManager(tracker, zerolog.Nop())
+	mgr.SetOverflow(OverflowRules{
+		types.DeptSales:     {Department: types.DeptSupport, Skill: "overflow", AfterSecs: 30},
+		types.DeptRetention: {Department: types.DeptSupport, Skill: "overflow", AfterSecs: 30},
+	})
+	tracker.UpsertRosterAgent(types.RosterRecord{AgentID: "support-1", Department: types.DeptSupport, Location: types.LocationBerlin, Skills: []string{"overflow"}})
+	tracker.RegisterAgent(&types.AgentRegister{AgentID: "support-1", Department: types.DeptSupport, Location: types.LocationBerlin, State: types.StateAvailable})
+
+	// Both departments wait for the one fallback agent; the first in taxonomy order gets it
+	retention := mgr.EnqueueCall(types.DepartmentVQs[types.DeptRetention][0], "call-retention")
+	retention.EnqueueTime = retention.EnqueueTime.Add(-time.Minute)
+	sales := mgr.EnqueueCall(types.VQSalesInbound, "call-sales")
+	sales.EnqueueTime = sales.EnqueueTime.Add(-time.Minute)
+
+	matches := mgr.TickRouting()
+	if len(matches) != 1 || matches[0].Call.CallID != "call-sales" {
+		t.Fatalf("expected the sales call overflowed first, got %+v", matches)
+	}
+}
+
+func TestQueueEventsRecorded(t *testing.T) {
+	tracker := cache.NewAgentStateTracker()
+	mgr := NewCallQueueManager(tracker, zerolog.Nop())
+	store := &dailyStore{}
+	mgr.SetStore(store)
+	mgr.SetOverflow(OverflowRules{types.DeptSales: {Department: types.DeptSupport, AfterSecs: 30}})
+	tracker.RegisterAgent(&types.AgentRegister{AgentID: "sales-1", Department: types.DeptSales, Location: types.LocationBerlin, State: types.StateAvailable})
+
+	// A paused queue keeps its calls, an unchanged pause records nothing
+	if err := mgr.PauseQueue("", types.VQSalesInbound, true, "admin@monti.local"); err != nil {
+		t.Fatal(err)
+	}
+	mgr.PauseQueue("", types.VQSalesInbound, true, "admin@monti.local")
+	call := mgr.EnqueueCall(types.VQSalesInbound, "call-1")
+	call.EnqueueTime = call.EnqueueTime.Add(-time.Minute)
+	if matches := mgr.TickRouting(); len(matches) != 0 || !mgr.GetSnapshot(types.VQSalesInbound).Paused {
+		t.Fatalf("expected a paused queue neither routed nor overflowed, got %+v", matches)
+	}
+	mgr.PauseQueue("", types.VQSalesInbound, false, "admin@monti.local")
+	if matches := mgr.TickRouting(); len(matches) != 1 {
+		t.Fatalf("expected the call routed once resumed, got %+v", matches)
+	}
+	tracker.UpdateFromStateChange(&types.AgentStateChange{AgentID: "sales-1", NewState: types.StateOnCall, Department: types.DeptSales})
+
+	if _, err := mgr.SetSLTarget("", types.VQSalesInbound, 90, 15, "admin@monti.local"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mgr.SetSLTarget("acme", types.VQSalesInbound, 90, 15, ""); err != ErrUnknownQueue {
+		t.Errorf("expected ErrUnknownQueue for a tenant without queues, got %v", err)
+	}
+
+	// Two overflowed calls are one overflow, ended after a quiet minute
+	for _, id := range []string{"support-1", "support-2"} {
+		tracker.UpsertRosterAgent(types.RosterRecord{AgentID: id, Department: types.DeptSupport, Location: types.LocationBerlin, Skills: []string{"sales"}})
+		tracker.RegisterAgent(&types.AgentRegister{AgentID: id, Department: types.DeptSupport, Location: types.LocationBerlin, State: types.StateAvailable})
+	}
+	for _, id := range []string{"call-2", "call-3"} {
+		call := mgr.EnqueueCall(types.VQSalesInbound, id)
+		call.EnqueueTime = call.EnqueueTime.Add(-time.Minute)
+	}
+	if matches := mgr.TickRouting(); len(matches) != 2 {
+		t.Fatalf("expected both calls overflowed, got %+v", matches)
+	}
+	mgr.mu.Lock()
+	mgr.endOverflows(time.Now().Add(2 * overflowQuietPeriod))
+	mgr.mu.Unlock()
+
+	want := []types.QueueEventType{types.QueueEventPaused, types.QueueEventResumed, types.QueueEventSLTargetChanged,
+		types.QueueEventOverflowStarted, types.QueueEventOverflowEnded}
+	if len(store.events) != len(want) {
+		t.Fatalf("expected %d events, got %+v", len(want), store.events)
+	}
+	for i, event := range store.events {
+		if event.Type != want[i] || event.VQ != types.VQSalesInbound || event.DateKey == "" {
+			t.Errorf("event %d: expected %s, got %+v", i, want[i], event)
+		}
+	}
+	if e := store.events[2]; e.Actor != "admin@monti.local" || e.SLTarget != 90 || e.PrevSLTarget != 80 || e.PrevSLThresholdSecs != 20 {
+		t.Errorf("unexpected SL target change: %+v", e)
+	}
+	if e := store.events[4]; e.Overflowed != 2 || e.FallbackDepartment != types.DeptSupport {
+		t.Errorf("unexpected overflow end: %+v", e)
+	}
+}
+
+func TestShadowStrategyEvaluatedNotApplied(t *testing.T) {
+	mgr := NewCallQueueManager(cache.NewAgentStateTracker(), zerolog.Nop())
+	if err := mgr.SetShadowStrategy("coin_flip"); err == nil {
+		t.Fatal("expected an unknown strategy to be rejected")
+	}
+	if err := mgr.SetShadowStrategy(StrategyFewestCalls); err != nil {
+		t.Fatal(err)
+	}
+
+	// agent-1 idled longest; agent-2 handled fewer calls but takes longer per call
+	now := time.Now()
+	idle1, idle2 := now.Add(-time.Minute), now
+	candidates := []types.AgentInfo{
+		{AgentID: "agent-1", IdleSince: &idle1, KPIs: types.AgentKPIs{TotalCalls: 10, AvgHandleTime: 120}},
+		{AgentID: "agent-2", IdleSince: &idle2, KPIs: types.AgentKPIs{TotalCalls: 2, AvgHandleTime: 300}},
+	}
+
+	mgr.mu.Lock()
+	chosen := mgr.selectAgent(types.VQSalesInbound, candidates)
+	mgr.selectAgent(types.VQSalesInbound, candidates[:1]) // a single candidate is no choice
+	mgr.mu.Unlock()
+	if chosen.AgentID != "agent-1" {
+		t.Fatalf("expected the active strategy's pick applied, got %s", chosen.AgentID)
+	}
+
+	report, ok := mgr.ShadowReport()
+	if !ok || report.Strategy != StrategyLongestIdle || report.Shadow != StrategyFewestCalls {
+		t.Fatalf("unexpected shadow report: %+v", report)
+	}
+	if report.Decisions != 1 || report.Divergent != 1 || report.DivergenceRate != 100 {
+		t.Errorf("expected one divergent decision, got %+v", report)
+	}
+	if report.ProjectedWaitImpactSecs != 90 {
+		t.Errorf("expected (300-120)/2 seconds longer waits, got %v", report.ProjectedWaitImpactSecs)
+	}
+
+	if err := mgr.SetShadowStrategy(""); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mgr.ShadowReport(); ok {
+		t.Error("expected no report once the evaluation stopped")
+	}
+}
+
+func TestDryRunLeavesLiveStateAlone(t *testing.T) {
+	mgr := NewCallQueueManager(cache.NewAgentStateTracker(), zerolog.Nop())
+	live := mgr.EnqueueCall(types.VQSalesInbound, "live-1")
+
+	resp := mgr.DryRun(types.RoutingDryRunRequest{
+		Calls: []types.DryRunCall{
+			{CallID: "call-1", VQ: types.VQSalesInbound, WaitSecs: 5},
+			{CallID: "call-2", VQ: types.VQSalesInbound, WaitSecs: 20},
+			{CallID: "call-3", VQ: types.VQSalesInbound},
+		},
+		Agents: []types.DryRunAgent{
+			{AgentID: "agent-1", Department: types.DeptSales, IdleSecs: 10},
+			{AgentID: "agent-2", Department: types.DeptSales, IdleSecs: 60},
+			{AgentID: "agent-3", Department: types.DeptSales, State: types.StateBreak, IdleSecs: 600},
+		},
+	})
+
+	if resp.Strategy != StrategyLongestIdle {
+		t.Errorf("expected the active strategy reported, got %q", resp.Strategy)
+	}
+	// The longest-waiting call goes to the longest-idle agent
+	if len(resp.Assignments) != 2 ||
+		resp.Assignments[0].CallID != "call-2" || resp.Assignments[0].AgentID != "agent-2" ||
+		resp.Assignments[1].CallID != "call-1" || resp.Assignments[1].AgentID != "agent-1" {
+		t.Fatalf("unexpected assignments: %+v", resp.Assignments)
+	}
+	if got := resp.Assignments[0].Candidates; len(got) != 2 || got[0] != "agent-1" || got[1] != "agent-2" {
+		t.Errorf("expected both available agents as candidates, got %v", got)
+	}
+	if len(resp.Unassigned) != 1 || resp.Unassigned[0] != "call-3" {
+		t.Errorf("expected call-3 left waiting, got %v", resp.Unassigned)
+	}
+
+	if live.Status != types.CallStatusWaiting {
+		t.Errorf("expected the live call untouched, got status %s", live.Status)
+	}
+	if snap := mgr.GetSnapshot(types.VQSalesInbound); snap.WaitingCount != 1 {
+		t.Errorf("expected only the live call queued, got %d", snap.WaitingCount)
+	}
+}
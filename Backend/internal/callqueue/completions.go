@@ -0,0 +1,137 @@
+package callqueue
+
+import (
+	"math"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/metrics"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// finishedCallRetention is how long a call is remembered after it left the active calls, so a
+// call_complete that arrives again or late for it is recognized instead of missed
+const finishedCallRetention = 10 * time.Minute
+
+// timeTolerance is how far two reports of a call's talk or hold seconds may differ and still
+// count as the same report
+const timeTolerance = 1.0
+
+// How a call_complete was applied. All but completionApplied and completionUnknown count in
+// monti_call_complete_discrepancies_total.
+const (
+	completionApplied    = "applied"    // the agent's active call was completed
+	completionDuplicate  = "duplicate"  // the agent had completed the call with the same times
+	completionConflict   = "conflict"   // the agent had completed the call with other times; the first are kept
+	completionReconciled = "reconciled" // a force-ended call took the agent's times over the estimate
+	completionLate       = "late"       // the call was taken from the agent: reaped, or active with another agent
+	completionUnknown    = "unknown"    // not a routed call, or finished too long ago
+)
+
+// How a remembered call left the active calls
+const (
+	finishedByAgent    = "agent"
+	finishedByForceEnd = "force_end"
+	finishedByReap     = "reap"
+)
+
+// finishedCall is a call that recently left the active calls
+type finishedCall struct {
+	call    *types.Call
+	agentID string // the agent the call was taken from
+	by      string
+	at      time.Time
+}
+
+// finishedCalls indexes the recently finished calls by call ID. Not safe for concurrent use;
+// guarded by the manager's lock.
+type finishedCalls struct {
+	byID  map[string]*finishedCall
+	order []*finishedCall // oldest first
+}
+
+func newFinishedCalls() *finishedCalls {
+	return &finishedCalls{byID: make(map[string]*finishedCall)}
+}
+
+// add remembers a call that left the active calls at now. A call reaped and routed again is
+// remembered by its latest finish.
+func (f *finishedCalls) add(call *types.Call, by string, now time.Time) {
+	entry := &finishedCall{call: call, agentID: call.AgentID, by: by, at: now}
+	f.byID[call.CallID] = entry
+	f.order = append(f.order, entry)
+}
+
+// expire forgets the calls finished more than finishedCallRetention before now
+func (f *finishedCalls) expire(now time.Time) {
+	drop := 0
+	for drop < len(f.order) && now.Sub(f.order[drop].at) > finishedCallRetention {
+		entry := f.order[drop]
+		if f.byID[entry.call.CallID] == entry {
+			delete(f.byID, entry.call.CallID)
+		}
+		f.order[drop] = nil
+		drop++
+	}
+	f.order = f.order[drop:]
+}
+
+// reset forgets all finished calls
+func (f *finishedCalls) reset() {
+	clear(f.byID)
+	f.order = nil
+}
+
+// reconcile applies a call_complete of agentID for a call that is no longer active with the
+// agent (caller must hold m.mu). Times reported by the agent replace the estimate of a
+// force-end; of two reports by the agent, the first is kept.
+func (m *CallQueueManager) reconcile(agentID, callID string, talkTime, holdTime float64) (*types.Call, string) {
+	f, ok := m.finished.byID[callID]
+	if !ok {
+		m.logger.Debug().Str("call_id", callID).Msg("call not found in active calls")
+		return nil, completionUnknown
+	}
+	call := f.call
+	log := m.logger.With().Str("call_id", callID).Str("agent_id", agentID).Str("vq", string(call.VQ)).Logger()
+
+	if f.by == finishedByReap || (agentID != "" && f.agentID != agentID) {
+		metrics.Get().RecordCallCompleteDiscrepancy(call.Tenant, call.VQ, completionLate, 0)
+		log.Warn().Str("finished_by", f.by).Msg("call_complete for a call taken from the agent, ignored")
+		return nil, completionLate
+	}
+
+	talkDiff := math.Abs(talkTime - call.TalkTime)
+	if f.by == finishedByAgent {
+		// A report without hold time took the holds tracked live
+		sameHold := math.Abs(holdTime-call.HoldTime) <= timeTolerance || (holdTime == 0 && call.Holds > 0)
+		if talkDiff <= timeTolerance && sameHold {
+			metrics.Get().RecordCallCompleteDiscrepancy(call.Tenant, call.VQ, completionDuplicate, 0)
+			log.Debug().Msg("duplicate call_complete ignored")
+			return call, completionDuplicate
+		}
+		metrics.Get().RecordCallCompleteDiscrepancy(call.Tenant, call.VQ, completionConflict, talkDiff)
+		log.Warn().
+			Float64("talk_time", call.TalkTime).Float64("reported_talk_time", talkTime).
+			Float64("hold_time", call.HoldTime).Float64("reported_hold_time", holdTime).
+			Msg("conflicting call_complete ignored, keeping the first")
+		return call, completionConflict
+	}
+
+	// Force-ended: the backend only estimated the talk time from the assignment
+	if holdTime == 0 {
+		holdTime = call.HoldTime
+	}
+	delta := talkTime + holdTime - call.TalkTime - call.HoldTime
+	m.stats.AdjustHandled(statsKey(call.Tenant, call.VQ), delta, *call.CompleteTime)
+	metrics.Get().RecordCallCompleteDiscrepancy(call.Tenant, call.VQ, completionReconciled, talkDiff)
+	log.Info().Float64("estimated_talk_time", call.TalkTime).Float64("talk_time", talkTime).
+		Msg("force-ended call reconciled with the agent's times")
+	call.TalkTime = talkTime
+	call.HoldTime = holdTime
+	f.by = finishedByAgent
+	if m.store != nil {
+		if err := m.store.SaveCallRecord(callToRecord(call)); err != nil {
+			log.Error().Err(err).Msg("failed to save reconciled call record")
+		}
+	}
+	return call, completionReconciled
+}
@@ -0,0 +1,143 @@
+package callqueue
+
+import (
+	"sort"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// dryRunRecorder collects the choices of a dry-run manager
+type dryRunRecorder struct {
+	candidates  []string // of the latest selectAgent
+	assignments []types.DryRunAssignment
+}
+
+// DryRun routes a hypothetical set of waiting calls to hypothetical agents once, with the live
+// strategy, chat limit, reserves and overflow rules, and reports who took which call among
+// which candidates. The live queues, agents, metrics and call log are not touched; the
+// shadow strategy does not take part.
+func (m *CallQueueManager) DryRun(req types.RoutingDryRunRequest) types.RoutingDryRunResponse {
+	now := time.Now()
+	tracker := cache.NewAgentStateTracker()
+	for _, a := range req.Agents {
+		tracker.Put(dryRunAgent(a, now))
+	}
+
+	m.mu.RLock()
+	sim := &CallQueueManager{
+		queues:       make(map[queueKey]*VQQueue, len(m.queues)),
+		configs:      m.configs,
+		tracker:      tracker,
+		routing:      m.routing,
+		strategy:     m.strategy,
+		strategyOpts: m.strategyOpts,
+		hours:        m.hours,
+		maxChats:     m.maxChats,
+		reserves:     m.reserves,
+		overflow:     m.overflow,
+		stats:        NewIntervalStats(),
+		traces:       make(map[string]trace.SpanContext),
+		finished:     newFinishedCalls(),
+		logger:       zerolog.Nop(),
+		dryRun:       &dryRunRecorder{},
+	}
+	for key, queue := range m.queues {
+		sim.queues[key] = NewVQQueue(m.configs[key.vq])
+		sim.queues[key].Paused = queue.Paused
+	}
+	m.mu.RUnlock()
+
+	// Queues are FIFO: enqueue the longest-waiting calls first
+	calls := append([]types.DryRunCall(nil), req.Calls...)
+	sort.SliceStable(calls, func(i, j int) bool { return calls[i].WaitSecs > calls[j].WaitSecs })
+	for _, c := range calls {
+		tenant := types.TenantOf(c.Tenant)
+		queue, ok := sim.queues[queueKey{tenant, c.VQ}]
+		if !ok {
+			continue
+		}
+		queue.Enqueue(&types.Call{
+			CallID:      c.CallID,
+			Tenant:      tenant,
+			VQ:          c.VQ,
+			Department:  queue.Department,
+			EnqueueTime: now.Add(-time.Duration(c.WaitSecs * float64(time.Second))),
+		})
+	}
+
+	sim.TickRouting()
+
+	resp := types.RoutingDryRunResponse{
+		Strategy:    sim.strategy,
+		Assignments: sim.dryRun.assignments,
+		Unassigned:  []string{},
+	}
+	if resp.Assignments == nil {
+		resp.Assignments = []types.DryRunAssignment{}
+	}
+	routed := make(map[string]bool, len(resp.Assignments))
+	for _, a := range resp.Assignments {
+		routed[a.CallID] = true
+	}
+	for _, c := range req.Calls {
+		if !routed[c.CallID] {
+			resp.Unassigned = append(resp.Unassigned, c.CallID)
+		}
+	}
+	return resp
+}
+
+// dryRunAgent builds the connected agent a dry run routes to
+func dryRunAgent(a types.DryRunAgent, now time.Time) types.AgentInfo {
+	state := a.State
+	if state == "" {
+		state = types.StateAvailable
+	}
+	start := now.Add(-time.Duration(a.IdleSecs * float64(time.Second)))
+	info := types.AgentInfo{
+		AgentID:          a.AgentID,
+		Tenant:           a.Tenant,
+		State:            state,
+		Department:       a.Department,
+		Skills:           a.Skills,
+		Proficiency:      a.Proficiency,
+		StateStart:       start,
+		LastUpdate:       now,
+		LastHeartbeat:    now,
+		ConnectionStatus: types.StatusConnected,
+		KPIs: types.AgentKPIs{
+			TotalCalls:    a.TotalCalls,
+			AvgHandleTime: a.AvgHandleTime,
+		},
+	}
+	if state == types.StateAvailable {
+		info.IdleSince = &start
+	}
+	return info
+}
+
+// record notes a dry-run assignment with the candidates of the choice that led to it
+func (r *dryRunRecorder) record(call *types.Call, agentID string) {
+	r.assignments = append(r.assignments, types.DryRunAssignment{
+		CallID:             call.CallID,
+		VQ:                 call.VQ,
+		AgentID:            agentID,
+		Candidates:         r.candidates,
+		OverflowDepartment: call.OverflowDepartment,
+	})
+	r.candidates = nil
+}
+
+// candidateIDs returns the sorted IDs of agents
+func candidateIDs(agents []types.AgentInfo) []string {
+	ids := make([]string, len(agents))
+	for i, a := range agents {
+		ids[i] = a.AgentID
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
 	"github.com/dennisdiepolder/monti/backend/internal/types"
 	"github.com/rs/zerolog"
 )
@@ -26,6 +27,7 @@ func NewCallHandler(mgr *CallQueueManager, logger zerolog.Logger) *CallHandler {
 type enqueueRequest struct {
 	VQ     string `json:"vq"`
 	CallID string `json:"callId,omitempty"`
+	Tenant string `json:"tenant,omitempty"` // empty = default tenant
 }
 
 // enqueueResponse is the JSON response for a successful enqueue
@@ -38,32 +40,26 @@ type enqueueResponse struct {
 // HandleEnqueue handles POST /internal/call/enqueue
 func (h *CallHandler) HandleEnqueue(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		apierror.Write(w, r, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
 	var req enqueueRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid JSON body", http.StatusBadRequest)
-		return
-	}
-
-	if req.VQ == "" {
-		http.Error(w, "missing vq field", http.StatusBadRequest)
+		apierror.WriteValidation(w, r, "invalid JSON body", nil)
 		return
 	}
 
 	vqName := types.VQName(req.VQ)
-
-	// Validate that the VQ name exists
-	if _, ok := types.VQDepartmentMapping[vqName]; !ok {
-		http.Error(w, "invalid vq name", http.StatusBadRequest)
+	if errs := types.ValidateEnqueue(vqName, req.Tenant); len(errs) > 0 {
+		h.logger.Warn().Str("vq", req.VQ).Str("tenant", req.Tenant).Interface("fields", errs).Msg("rejected invalid enqueue")
+		apierror.WriteValidation(w, r, "invalid enqueue request", errs)
 		return
 	}
 
-	call := h.mgr.EnqueueCall(vqName, req.CallID)
+	call := h.mgr.EnqueueCallContext(r.Context(), req.Tenant, vqName, req.CallID)
 	if call == nil {
-		http.Error(w, "failed to enqueue call", http.StatusInternalServerError)
+		apierror.Write(w, r, http.StatusInternalServerError, "failed to enqueue call")
 		return
 	}
 
@@ -81,7 +77,7 @@ func (h *CallHandler) HandleEnqueue(w http.ResponseWriter, r *http.Request) {
 // HandleWipeAll handles DELETE /internal/calls/all
 func (h *CallHandler) HandleWipeAll(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		apierror.Write(w, r, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
@@ -0,0 +1,53 @@
+package callqueue
+
+import (
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/metrics"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// HoldCall puts the caller of an agent's active call on hold at at (onHold), or picks the
+// call up again, accumulating the hold time on the call as it goes. A repeated start or end
+// is ignored. Returns nil unless the call is active with the agent; an empty agentID matches
+// any agent.
+func (m *CallQueueManager) HoldCall(agentID, callID string, onHold bool, at time.Time) *types.Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, queue := range m.queues {
+		call, ok := queue.Active[callID]
+		if !ok {
+			continue
+		}
+		if agentID != "" && call.AgentID != agentID {
+			m.logger.Debug().Str("call_id", callID).Str("agent_id", agentID).Msg("hold for a call of another agent ignored")
+			return nil
+		}
+		switch {
+		case onHold && call.HoldStart == nil:
+			call.HoldStart = &at
+			call.Holds++
+			metrics.Get().RecordCallHold(call.Tenant, call.VQ, queue.Department)
+		case !onHold && call.HoldStart != nil:
+			endHold(call, at)
+		default:
+			return call
+		}
+		m.tracker.SetCallHold(call.AgentID, callID, call.HoldTime, call.HoldStart)
+		return call
+	}
+	m.logger.Debug().Str("call_id", callID).Bool("on_hold", onHold).Msg("hold for a call not active, ignored")
+	return nil
+}
+
+// endHold adds the call's current hold up to at to its hold time
+func endHold(call *types.Call, at time.Time) {
+	if call.HoldStart == nil {
+		return
+	}
+	if secs := at.Sub(*call.HoldStart).Seconds(); secs > 0 {
+		call.HoldTime += secs
+	}
+	call.HoldStart = nil
+}
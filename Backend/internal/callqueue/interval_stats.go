@@ -0,0 +1,115 @@
+package callqueue
+
+import (
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// statsRetention is how much per-minute traffic history is kept per VQ
+const statsRetention = 24 * time.Hour
+
+// minuteBucket holds one minute of traffic for a VQ
+type minuteBucket struct {
+	minute     int64 // unix minute
+	offered    int
+	handled    int
+	abandoned  int
+	handleSecs float64
+}
+
+// IntervalStats keeps per-minute traffic per VQ so any interval length can be
+// summarized. Not safe for concurrent use; guarded by the manager's lock.
+type IntervalStats struct {
+	buckets map[types.VQName][]minuteBucket
+}
+
+// NewIntervalStats creates empty interval stats
+func NewIntervalStats() *IntervalStats {
+	return &IntervalStats{buckets: make(map[types.VQName][]minuteBucket)}
+}
+
+// bucket returns the bucket for now's minute, pruning expired history
+func (s *IntervalStats) bucket(vq types.VQName, now time.Time) *minuteBucket {
+	minute := now.Unix() / 60
+	buckets := s.buckets[vq]
+	if n := len(buckets); n > 0 && buckets[n-1].minute == minute {
+		return &buckets[n-1]
+	}
+
+	cutoff := minute - int64(statsRetention/time.Minute)
+	drop := 0
+	for drop < len(buckets) && buckets[drop].minute <= cutoff {
+		drop++
+	}
+	if drop > 0 {
+		buckets = append(buckets[:0], buckets[drop:]...)
+	}
+
+	buckets = append(buckets, minuteBucket{minute: minute})
+	s.buckets[vq] = buckets
+	return &buckets[len(buckets)-1]
+}
+
+// RecordOffered counts a call entering the VQ
+func (s *IntervalStats) RecordOffered(vq types.VQName, now time.Time) {
+	s.bucket(vq, now).offered++
+}
+
+// RecordHandled counts a completed call and its handle time
+func (s *IntervalStats) RecordHandled(vq types.VQName, handleSecs float64, now time.Time) {
+	b := s.bucket(vq, now)
+	b.handled++
+	b.handleSecs += handleSecs
+}
+
+// AdjustHandled corrects the handle time of a call counted at; calls older than the
+// retained history are left alone
+func (s *IntervalStats) AdjustHandled(vq types.VQName, deltaSecs float64, at time.Time) {
+	minute := at.Unix() / 60
+	buckets := s.buckets[vq]
+	for i := len(buckets) - 1; i >= 0 && buckets[i].minute >= minute; i-- {
+		if buckets[i].minute == minute {
+			buckets[i].handleSecs += deltaSecs
+			return
+		}
+	}
+}
+
+// RecordAbandoned counts a call abandoned while waiting
+func (s *IntervalStats) RecordAbandoned(vq types.VQName, now time.Time) {
+	s.bucket(vq, now).abandoned++
+}
+
+// History returns the last count completed intervals of the given length
+// (aligned to interval boundaries), oldest first.
+func (s *IntervalStats) History(vq types.VQName, interval time.Duration, count int, now time.Time) []types.IntervalStat {
+	current := now.Truncate(interval)
+	stats := make([]types.IntervalStat, count)
+	for i := range stats {
+		start := current.Add(-time.Duration(count-i) * interval)
+		stats[i] = types.IntervalStat{VQ: vq, Start: start, End: start.Add(interval)}
+	}
+	if count == 0 {
+		return stats
+	}
+
+	first := stats[0].Start.Unix() / 60
+	handleSecs := make([]float64, count)
+	for _, b := range s.buckets[vq] {
+		if b.minute < first || b.minute >= current.Unix()/60 {
+			continue
+		}
+		i := int((b.minute - first) * 60 / int64(interval.Seconds()))
+		stats[i].Offered += b.offered
+		stats[i].Handled += b.handled
+		stats[i].Abandoned += b.abandoned
+		handleSecs[i] += b.handleSecs
+	}
+	for i := range stats {
+		if stats[i].Handled > 0 {
+			stats[i].AHTSecs = handleSecs[i] / float64(stats[i].Handled)
+		}
+	}
+	return stats
+}
@@ -1,45 +1,109 @@
 package callqueue
 
 import (
+	"context"
 	"sync"
 	"time"
 
 	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/metrics"
+	"github.com/dennisdiepolder/monti/backend/internal/tracing"
 	"github.com/dennisdiepolder/monti/backend/internal/types"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // CallStore is the subset of storage.Store needed by CallQueueManager
 type CallStore interface {
 	SaveCallRecord(record types.CallRecord) error
+	SaveVQDailyStats(stats types.VQDailyStats) error
+	SaveQueueEvent(event types.QueueEvent) error
 }
 
-// CallQueueManager manages all virtual queues and call routing
+// CallObserver is notified when calls complete or are abandoned (must not block, called under the manager lock)
+type CallObserver interface {
+	CallCompleted(call types.Call)
+	CallAbandoned(call types.Call)
+}
+
+// CallLog is notified of every queue mutation needed to rebuild queue state
+// (must not block, called under the manager lock)
+type CallLog interface {
+	CallEnqueued(tenant string, vq types.VQName, callID string)
+	CallAssigned(callID, agentID string)
+	CallAbandoned(callID string)
+	CallReaped(callID, action string)
+}
+
+// BusinessHours tells whether a department is open; SL is only tracked while it is
+type BusinessHours interface {
+	IsOpen(dept types.Department, t time.Time) bool
+}
+
+// DefaultChatMaxSessions is how many chats are routed to one agent at once unless configured
+const DefaultChatMaxSessions = 3
+
+// queueKey identifies one tenant's queue for a VQ
+type queueKey struct {
+	tenant string
+	vq     types.VQName
+}
+
+// CallQueueManager manages all virtual queues and call routing. Every tenant has its own
+// queues; calls are only routed to agents of the call's tenant. Voice and email contacts go
+// to available agents one at a time; chats also go to agents in multi-session state until
+// they handle maxChats at once.
 type CallQueueManager struct {
-	queues   map[types.VQName]*VQQueue
-	configs  map[types.VQName]VQConfig
-	tracker  *cache.AgentStateTracker
-	routing  RoutingStrategy
-	store    CallStore
-	mu       sync.RWMutex
-	logger   zerolog.Logger
+	queues       map[queueKey]*VQQueue
+	configs      map[types.VQName]VQConfig
+	tracker      *cache.AgentStateTracker
+	routing      RoutingStrategy
+	strategy     string // name of routing
+	strategyOpts StrategyOptions
+	shadow       *shadowEvaluation // nil unless a shadow strategy is evaluated
+	store        CallStore
+	observer     CallObserver
+	callLog      CallLog
+	hours        BusinessHours
+	maxChats     int
+	reserves     Reserves
+	overflow     OverflowRules
+	paused       bool // routing is suspended, e.g. while an event log is replayed
+	stats        *IntervalStats
+	dayStart     time.Duration                // business day start, offset from midnight in the reporting timezone
+	day          string                       // business day the queue counters belong to; empty until the first tick
+	dayEnd       time.Time                    // when the counters roll over
+	traces       map[string]trace.SpanContext // callID -> enqueue span, parent of the routing span
+	finished     *finishedCalls               // calls that recently left the active calls
+	dryRun       *dryRunRecorder              // set on the throwaway managers of DryRun only
+	mu           sync.RWMutex
+	logger       zerolog.Logger
 }
 
 // NewCallQueueManager creates a new call queue manager
 func NewCallQueueManager(tracker *cache.AgentStateTracker, logger zerolog.Logger) *CallQueueManager {
 	configs := DefaultVQConfigs()
-	queues := make(map[types.VQName]*VQQueue, len(configs))
-	for name, cfg := range configs {
-		queues[name] = NewVQQueue(cfg)
+	queues := make(map[queueKey]*VQQueue, len(configs))
+	for _, tenant := range types.AllTenants() {
+		for name, cfg := range configs {
+			queues[queueKey{tenant, name}] = NewVQQueue(cfg)
+		}
 	}
 
 	return &CallQueueManager{
-		queues:  queues,
-		configs: configs,
-		tracker: tracker,
-		routing: &LongestIdleFirst{},
-		logger:  logger,
+		queues:       queues,
+		configs:      configs,
+		tracker:      tracker,
+		routing:      &LongestIdleFirst{},
+		strategy:     StrategyLongestIdle,
+		strategyOpts: DefaultStrategyOptions(),
+		maxChats:     DefaultChatMaxSessions,
+		stats:        NewIntervalStats(),
+		traces:       make(map[string]trace.SpanContext),
+		finished:     newFinishedCalls(),
+		logger:       logger,
 	}
 }
 
@@ -48,14 +112,69 @@ func (m *CallQueueManager) SetStore(store CallStore) {
 	m.store = store
 }
 
-// EnqueueCall adds a new call to the appropriate VQ
+// SetCallObserver sets the observer notified of completed and abandoned calls
+func (m *CallQueueManager) SetCallObserver(o CallObserver) {
+	m.observer = o
+}
+
+// SetCallLog sets the log notified of enqueues, assignments and abandons
+func (m *CallQueueManager) SetCallLog(l CallLog) {
+	m.callLog = l
+}
+
+// SetBusinessHours sets the business hours outside of which answers don't count toward SL
+// (nil = always open)
+func (m *CallQueueManager) SetBusinessHours(h BusinessHours) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hours = h
+}
+
+// SetChatMaxSessions sets how many chats are routed to one agent at once (values below 1 keep
+// the current limit)
+func (m *CallQueueManager) SetChatMaxSessions(n int) {
+	if n < 1 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxChats = n
+}
+
+// SetReserves sets the idle agents held for critical VQs against their departments' other VQs
+func (m *CallQueueManager) SetReserves(r Reserves) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reserves = r
+}
+
+// PauseRouting suspends (true) or resumes (false) TickRouting
+func (m *CallQueueManager) PauseRouting(paused bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.paused = paused
+}
+
+// EnqueueCall adds a new call to the default tenant's queue for the VQ
 func (m *CallQueueManager) EnqueueCall(vq types.VQName, callID string) *types.Call {
+	return m.EnqueueCallContext(context.Background(), types.DefaultTenant, vq, callID)
+}
+
+// EnqueueCallContext adds a call to a tenant's queue (empty = default tenant), with a trace:
+// the enqueue span is a child of the span in ctx and becomes the parent of the call's routing span
+func (m *CallQueueManager) EnqueueCallContext(ctx context.Context, tenant string, vq types.VQName, callID string) *types.Call {
+	tenant = types.TenantOf(tenant)
+	_, span := tracing.Tracer().Start(ctx, "callqueue.enqueue", trace.WithAttributes(
+		attribute.String("vq", string(vq)),
+		attribute.String("tenant", tenant)))
+	defer span.End()
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	queue, ok := m.queues[vq]
+	queue, ok := m.queues[queueKey{tenant, vq}]
 	if !ok {
-		m.logger.Warn().Str("vq", string(vq)).Msg("unknown VQ, ignoring call")
+		m.logger.Warn().Str("vq", string(vq)).Str("tenant", tenant).Msg("unknown VQ or tenant, ignoring call")
 		return nil
 	}
 
@@ -66,6 +185,7 @@ func (m *CallQueueManager) EnqueueCall(vq types.VQName, callID string) *types.Ca
 	dept := types.VQDepartmentMapping[vq]
 	call := &types.Call{
 		CallID:      callID,
+		Tenant:      tenant,
 		VQ:          vq,
 		Department:  dept,
 		Status:      types.CallStatusWaiting,
@@ -73,9 +193,19 @@ func (m *CallQueueManager) EnqueueCall(vq types.VQName, callID string) *types.Ca
 	}
 
 	queue.Enqueue(call)
+	m.stats.RecordOffered(statsKey(tenant, vq), call.EnqueueTime)
+	span.SetAttributes(attribute.String("call_id", callID))
+	if sc := span.SpanContext(); sc.IsSampled() {
+		m.traces[callID] = sc
+	}
+	metrics.Get().RecordCallOffered(tenant, vq, dept)
+	if m.callLog != nil {
+		m.callLog.CallEnqueued(tenant, vq, callID)
+	}
 
 	m.logger.Debug().
 		Str("call_id", callID).
+		Str("tenant", tenant).
 		Str("vq", string(vq)).
 		Str("department", string(dept)).
 		Int("queue_depth", len(queue.Waiting)).
@@ -84,35 +214,54 @@ func (m *CallQueueManager) EnqueueCall(vq types.VQName, callID string) *types.Ca
 	return call
 }
 
-// CompleteCall marks a call as completed
-func (m *CallQueueManager) CompleteCall(callID string, talkTime, holdTime float64) *types.Call {
+// CompleteCall completes an agent's active call with the times the agent reported. It is
+// idempotent by call ID: a call_complete for a call finished within finishedCallRetention is
+// reconciled with the recorded call instead (see reconcile). duplicate reports that the agent
+// had completed the call before, so its KPIs must not count again. An empty agentID matches
+// any agent.
+func (m *CallQueueManager) CompleteCall(agentID, callID string, talkTime, holdTime float64) (call *types.Call, duplicate bool) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	call, result := m.completeCall(agentID, callID, talkTime, holdTime)
+	return call, result == completionDuplicate || result == completionConflict
+}
+
+// completeCall completes the agent's active call, or reconciles a call_complete for a call
+// that already left the active calls (caller must hold m.mu)
+func (m *CallQueueManager) completeCall(agentID, callID string, talkTime, holdTime float64) (*types.Call, string) {
 	// Search all queues for the active call
 	for _, queue := range m.queues {
+		if active, ok := queue.Active[callID]; ok && agentID != "" && active.AgentID != agentID {
+			metrics.Get().RecordCallCompleteDiscrepancy(active.Tenant, active.VQ, completionLate, 0)
+			m.logger.Warn().Str("call_id", callID).Str("agent_id", agentID).Str("active_agent_id", active.AgentID).
+				Msg("call_complete for a call active with another agent, ignored")
+			return nil, completionLate
+		}
 		if call := queue.CompleteCall(callID, talkTime, holdTime); call != nil {
+			m.finished.add(call, finishedByAgent, *call.CompleteTime)
+			m.stats.RecordHandled(statsKey(call.Tenant, call.VQ), call.TalkTime+call.HoldTime+call.WrapTime, *call.CompleteTime)
+			metrics.Get().RecordCallCompleted(call.Tenant, call.VQ, queue.Department)
+			m.releaseAgentCall(call)
 			m.logger.Debug().
 				Str("call_id", callID).
 				Str("agent_id", call.AgentID).
 				Float64("talk_time", talkTime).
 				Msg("call completed")
 
-			// Persist call record asynchronously
+			// Persist call record (the store writes in the background)
 			if m.store != nil {
-				record := callToRecord(call)
-				go func() {
-					if err := m.store.SaveCallRecord(record); err != nil {
-						m.logger.Error().Err(err).Str("call_id", callID).Msg("failed to save call record")
-					}
-				}()
+				if err := m.store.SaveCallRecord(callToRecord(call)); err != nil {
+					m.logger.Error().Err(err).Str("call_id", callID).Msg("failed to save call record")
+				}
 			}
-			return call
+			if m.observer != nil {
+				m.observer.CallCompleted(*call)
+			}
+			return call, completionApplied
 		}
 	}
-
-	m.logger.Debug().Str("call_id", callID).Msg("call not found in active calls")
-	return nil
+	return m.reconcile(agentID, callID, talkTime, holdTime)
 }
 
 // AbandonCall marks a waiting call as abandoned
@@ -122,108 +271,241 @@ func (m *CallQueueManager) AbandonCall(callID string) *types.Call {
 
 	for _, queue := range m.queues {
 		if call := queue.AbandonCall(callID); call != nil {
+			m.stats.RecordAbandoned(statsKey(call.Tenant, call.VQ), *call.CompleteTime)
+			delete(m.traces, callID)
+			metrics.Get().RecordCallAbandoned(call.Tenant, call.VQ, queue.Department)
+			if m.callLog != nil {
+				m.callLog.CallAbandoned(callID)
+			}
 			m.logger.Debug().
 				Str("call_id", callID).
 				Str("vq", string(queue.Name)).
 				Msg("call abandoned")
+			if m.observer != nil {
+				m.observer.CallAbandoned(*call)
+			}
 			return call
 		}
 	}
 	return nil
 }
 
-// TickRouting tries to match waiting calls to available agents.
-// Returns a list of (call, agentID) pairs that were matched.
+// TickRouting tries to match waiting calls to available (and, for chats, multi-session)
+// agents of the call's tenant. Returns a list of (call, agentID) pairs that were matched.
 func (m *CallQueueManager) TickRouting() []RoutingMatch {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if m.paused {
+		return nil
+	}
+	now := time.Now()
+	m.rollover(now)
+	m.updateOpen(now)
+	m.finished.expire(now)
+
 	var matches []RoutingMatch
+	sessions := m.chatSessions()
+	// Agents assigned a voice or email contact in this tick
+	assigned := make(map[string]bool)
 
 	// Process each department's VQs
 	for dept, vqNames := range types.DepartmentVQs {
-		// Get available agents for this department
-		available := m.tracker.GetAvailableByDepartment(dept)
-		if len(available) == 0 {
-			continue
+		// Get routable agents for this department, split by tenant
+		agents := m.tracker.GetConnectedInStates(dept, types.StateAvailable, types.StateMultiSession)
+		connected := m.connectedByTenant(dept)
+		for tenant, routable := range byTenant(agents) {
+			reserved, total := m.reserves.reserveCounts(vqNames, connected[tenant])
+			matches = append(matches, m.routeDepartment(tenant, vqNames, routable, assigned, sessions, reserved, total)...)
 		}
+	}
 
-		// Track which agents have been assigned in this tick
-		assigned := make(map[string]bool)
-
-		// Round-robin through VQs in the department
-		for _, vqName := range vqNames {
-			queue := m.queues[vqName]
-			for len(queue.Waiting) > 0 {
-				// Filter out already-assigned agents
-				free := filterUnassigned(available, assigned)
-				if len(free) == 0 {
-					break
-				}
+	// Calls still waiting in departments without agents may overflow to fallback departments
+	matches = append(matches, m.routeOverflow(assigned, sessions)...)
+	m.endOverflows(now)
 
-				agent := m.routing.SelectAgent(free)
-				if agent == nil {
-					break
-				}
+	return matches
+}
+
+// routeDepartment matches one tenant's waiting calls of a department to its routable agents;
+// assigned and sessions hold the agents' contacts and are updated as calls are assigned. A
+// VQ's calls leave the idle agents reserved for the department's other VQs alone (caller
+// must hold m.mu).
+func (m *CallQueueManager) routeDepartment(tenant string, vqNames []types.VQName, routable []types.AgentInfo, assigned map[string]bool, sessions map[string]int, reserved map[types.VQName]int, totalReserved int) []RoutingMatch {
+	var matches []RoutingMatch
+
+	// Round-robin through VQs in the department
+	for _, vqName := range vqNames {
+		queue, ok := m.queues[queueKey{tenant, vqName}]
+		if !ok || queue.Paused {
+			continue
+		}
+		held := totalReserved - reserved[vqName]
+		for len(queue.Waiting) > 0 {
+			free := m.eligible(routable, queue.Channel, assigned, sessions)
+			if held > 0 && len(m.eligible(routable, types.ChannelVoice, assigned, sessions)) <= held {
+				free = withoutIdle(free, sessions)
+			}
+			if len(free) == 0 {
+				break
+			}
 
-				call := queue.DequeueNext()
-				queue.AssignToAgent(call, agent.AgentID)
-				assigned[agent.AgentID] = true
-
-				matches = append(matches, RoutingMatch{
-					Call:    call,
-					AgentID: agent.AgentID,
-				})
-
-				m.logger.Debug().
-					Str("call_id", call.CallID).
-					Str("agent_id", agent.AgentID).
-					Str("vq", string(vqName)).
-					Float64("wait_time", call.WaitTime).
-					Msg("call routed to agent")
+			agent := m.selectAgent(vqName, free)
+			if agent == nil {
+				break
 			}
+
+			matches = append(matches, m.assignNext(queue, agent.AgentID, "", assigned, sessions))
 		}
 	}
 
 	return matches
 }
 
+// assignNext assigns the queue's next waiting call to agentID, noting it in assigned (voice, email) or sessions (chat). overflow is the fallback
+// department the agent belongs to, empty for the call's own department. Caller must hold m.mu.
+func (m *CallQueueManager) assignNext(queue *VQQueue, agentID string, overflow types.Department, assigned map[string]bool, sessions map[string]int) RoutingMatch {
+	call := queue.DequeueNext()
+	call.OverflowDepartment = overflow
+	queue.AssignToAgent(call, agentID)
+	if queue.Channel.Concurrent() {
+		sessions[agentID]++
+	} else {
+		assigned[agentID] = true
+	}
+	if m.dryRun != nil {
+		m.dryRun.record(call, agentID)
+	} else {
+		metrics.Get().RecordCallRouted(call.Tenant, call.VQ, queue.Department, call.AssignTime.Sub(call.EnqueueTime))
+	}
+	if m.callLog != nil {
+		m.callLog.CallAssigned(call.CallID, agentID)
+	}
+	m.tracker.SetCurrentCall(agentID, call.CallID, call.VQ, *call.AssignTime)
+
+	m.logger.Debug().
+		Str("call_id", call.CallID).
+		Str("agent_id", agentID).
+		Str("tenant", call.Tenant).
+		Str("vq", string(call.VQ)).
+		Str("overflow", string(overflow)).
+		Float64("wait_time", call.WaitTime).
+		Msg("call routed to agent")
+
+	return RoutingMatch{
+		Call:        call,
+		AgentID:     agentID,
+		TraceParent: m.traceRouting(call, agentID),
+	}
+}
+
+// AssignCall routes a specific waiting call to a specific agent, bypassing the routing strategy.
+// Used to replay recorded assignments; returns nil when the call is not waiting.
+func (m *CallQueueManager) AssignCall(callID, agentID string) *types.Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.updateOpen(time.Now())
+
+	for _, queue := range m.queues {
+		for i, call := range queue.Waiting {
+			if call.CallID != callID {
+				continue
+			}
+			queue.Waiting = append(queue.Waiting[:i], queue.Waiting[i+1:]...)
+			queue.AssignToAgent(call, agentID)
+			metrics.Get().RecordCallRouted(call.Tenant, call.VQ, queue.Department, call.AssignTime.Sub(call.EnqueueTime))
+			if m.callLog != nil {
+				m.callLog.CallAssigned(callID, agentID)
+			}
+			m.tracker.SetCurrentCall(agentID, call.CallID, call.VQ, *call.AssignTime)
+			return call
+		}
+	}
+	return nil
+}
+
 // RoutingMatch represents a call matched to an agent
 type RoutingMatch struct {
-	Call    *types.Call
-	AgentID string
+	Call        *types.Call
+	AgentID     string
+	TraceParent string // routing span, forwarded to the agent in call_assign
 }
 
-// GetSnapshot returns the snapshot for a specific VQ
+// traceRouting records the routing span under the call's enqueue span (caller must hold m.mu)
+func (m *CallQueueManager) traceRouting(call *types.Call, agentID string) string {
+	parent, ok := m.traces[call.CallID]
+	if !ok {
+		return ""
+	}
+	delete(m.traces, call.CallID)
+
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), parent)
+	ctx, span := tracing.Tracer().Start(ctx, "callqueue.route", trace.WithAttributes(
+		attribute.String("call_id", call.CallID),
+		attribute.String("vq", string(call.VQ)),
+		attribute.String("agent_id", agentID),
+		attribute.Float64("wait_seconds", call.WaitTime),
+	))
+	defer span.End()
+	return tracing.TraceParent(ctx)
+}
+
+// GetSnapshot returns the snapshot for a specific VQ of the default tenant
 func (m *CallQueueManager) GetSnapshot(vq types.VQName) *types.VQSnapshot {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	queue, ok := m.queues[vq]
+	queue, ok := m.queues[queueKey{types.DefaultTenant, vq}]
 	if !ok {
 		return nil
 	}
 
-	available := m.tracker.GetAvailableByDepartment(queue.Department)
+	available := byTenant(m.tracker.GetAvailableByDepartment(queue.Department))[types.DefaultTenant]
 	snapshot := queue.Snapshot(len(available))
+	snapshot.Closed = !m.isOpen(queue.Department, time.Now())
+	snapshot.Paused = queue.Paused
+	if len(m.reserves) > 0 {
+		connected := m.connectedByTenant(queue.Department)[types.DefaultTenant]
+		idle := len(m.eligible(available, types.ChannelVoice, nil, m.chatSessions()))
+		snapshot.Reserve = m.reserves.status(types.DepartmentVQs[queue.Department], connected, idle)[vq]
+	}
 	return &snapshot
 }
 
-// GetAllSnapshots returns snapshots for all VQs grouped by department
+// GetAllSnapshots returns snapshots for all VQs grouped by department. Hosted tenants' queues
+// follow the default tenant's, with Tenant set.
 func (m *CallQueueManager) GetAllSnapshots() map[types.Department][]types.VQSnapshot {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	result := make(map[types.Department][]types.VQSnapshot)
+	tenants := types.AllTenants()
+	now := time.Now()
+	var sessions map[string]int
+	if len(m.reserves) > 0 {
+		sessions = m.chatSessions()
+	}
 
 	for dept, vqNames := range types.DepartmentVQs {
-		available := m.tracker.GetAvailableByDepartment(dept)
-		availCount := len(available)
-
-		snapshots := make([]types.VQSnapshot, 0, len(vqNames))
-		for _, vqName := range vqNames {
-			queue := m.queues[vqName]
-			snapshots = append(snapshots, queue.Snapshot(availCount))
+		available := byTenant(m.tracker.GetAvailableByDepartment(dept))
+		connected := m.connectedByTenant(dept)
+		closed := !m.isOpen(dept, now)
+
+		snapshots := make([]types.VQSnapshot, 0, len(vqNames)*len(tenants))
+		for _, tenant := range tenants {
+			idle := len(m.eligible(available[tenant], types.ChannelVoice, nil, sessions))
+			reserves := m.reserves.status(vqNames, connected[tenant], idle)
+			for _, vqName := range vqNames {
+				queue := m.queues[queueKey{tenant, vqName}]
+				snapshot := queue.Snapshot(len(available[tenant]))
+				snapshot.Closed = closed
+				snapshot.Paused = queue.Paused
+				snapshot.Reserve = reserves[vqName]
+				if tenant != types.DefaultTenant {
+					snapshot.Tenant = tenant
+				}
+				snapshots = append(snapshots, snapshot)
+			}
 		}
 		result[dept] = snapshots
 	}
@@ -231,6 +513,99 @@ func (m *CallQueueManager) GetAllSnapshots() map[types.Department][]types.VQSnap
 	return result
 }
 
+// GetChannelOccupancy returns every department's occupancy per tenant and channel, for the
+// channels the department has VQs for. Hosted tenants' entries have Tenant set.
+func (m *CallQueueManager) GetChannelOccupancy() map[types.Department][]types.ChannelOccupancy {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make(map[types.Department][]types.ChannelOccupancy)
+	sessions := m.chatSessions()
+	tenants := types.AllTenants()
+
+	for dept, vqNames := range types.DepartmentVQs {
+		routable := byTenant(m.tracker.GetConnectedInStates(dept, types.StateAvailable, types.StateMultiSession))
+
+		var out []types.ChannelOccupancy
+		for _, tenant := range tenants {
+			var channels []types.Channel
+			active := make(map[types.Channel]int)
+			for _, vqName := range vqNames {
+				queue, ok := m.queues[queueKey{tenant, vqName}]
+				if !ok {
+					continue
+				}
+				if _, seen := active[queue.Channel]; !seen {
+					channels = append(channels, queue.Channel)
+				}
+				active[queue.Channel] += len(queue.Active)
+			}
+
+			for _, channel := range channels {
+				occ := types.ChannelOccupancy{
+					Channel:  channel,
+					Active:   active[channel],
+					Capacity: active[channel] + m.freeSlots(routable[tenant], channel, sessions),
+				}
+				if tenant != types.DefaultTenant {
+					occ.Tenant = tenant
+				}
+				if occ.Capacity > 0 {
+					occ.Occupancy = float64(occ.Active) / float64(occ.Capacity) * 100
+				}
+				out = append(out, occ)
+			}
+		}
+		result[dept] = out
+	}
+
+	return result
+}
+
+// IntervalHistory returns the last count completed intervals of a tenant's traffic for a VQ,
+// oldest first
+func (m *CallQueueManager) IntervalHistory(tenant string, vq types.VQName, interval time.Duration, count int) []types.IntervalStat {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	stats := m.stats.History(statsKey(tenant, vq), interval, count, time.Now())
+	for i := range stats {
+		stats[i].VQ = vq
+	}
+	return stats
+}
+
+// IntervalRange returns a tenant's traffic for a VQ in the completed intervals between from
+// and to, oldest first. The range is clipped to the retained history.
+func (m *CallQueueManager) IntervalRange(tenant string, vq types.VQName, interval time.Duration, from, to time.Time) []types.IntervalStat {
+	now := time.Now()
+	if oldest := now.Add(-statsRetention); from.Before(oldest) {
+		from = oldest
+	}
+	if to.After(now) {
+		to = now
+	}
+	count := int(to.Truncate(interval).Sub(from.Truncate(interval)) / interval)
+	if count <= 0 {
+		return []types.IntervalStat{}
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	stats := m.stats.History(statsKey(tenant, vq), interval, count, to)
+	for i := range stats {
+		stats[i].VQ = vq
+	}
+	return stats
+}
+
+// VQConfig returns the configuration of a VQ
+func (m *CallQueueManager) VQConfig(vq types.VQName) (VQConfig, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	cfg, ok := m.configs[vq]
+	return cfg, ok
+}
+
 // WipeAllCalls clears all waiting and active calls from every queue
 func (m *CallQueueManager) WipeAllCalls() int {
 	m.mu.Lock()
@@ -238,8 +613,13 @@ func (m *CallQueueManager) WipeAllCalls() int {
 
 	total := 0
 	for _, queue := range m.queues {
+		for _, call := range queue.Active {
+			m.tracker.ClearCurrentCall(call.AgentID, call.CallID)
+		}
 		total += queue.Wipe()
 	}
+	m.traces = make(map[string]trace.SpanContext)
+	m.finished.reset()
 
 	m.logger.Info().Int("cleared", total).Msg("wiped all calls from all queues")
 	return total
@@ -256,15 +636,20 @@ func (m *CallQueueManager) ForceEndCall(callID string) (agentID string, found bo
 			continue
 		}
 
-		talkTime := 0.0
-		if call.AssignTime != nil {
-			talkTime = time.Since(*call.AssignTime).Seconds()
-		}
-
-		completed := queue.CompleteCall(callID, talkTime, 0)
+		completed := queue.CompleteCall(callID, 0, 0)
 		if completed == nil {
 			continue
 		}
+		// Estimated from the assignment; the holds tracked live are not talk time
+		talkTime := 0.0
+		if call.AssignTime != nil {
+			talkTime = max(0, completed.CompleteTime.Sub(*call.AssignTime).Seconds()-completed.HoldTime)
+		}
+		completed.TalkTime = talkTime
+		m.finished.add(completed, finishedByForceEnd, *completed.CompleteTime)
+		m.stats.RecordHandled(statsKey(completed.Tenant, completed.VQ), completed.TalkTime+completed.HoldTime, *completed.CompleteTime)
+		metrics.Get().RecordCallCompleted(completed.Tenant, completed.VQ, queue.Department)
+		m.releaseAgentCall(completed)
 
 		m.logger.Info().
 			Str("call_id", callID).
@@ -273,12 +658,12 @@ func (m *CallQueueManager) ForceEndCall(callID string) (agentID string, found bo
 			Msg("call force-ended")
 
 		if m.store != nil {
-			record := callToRecord(completed)
-			go func() {
-				if err := m.store.SaveCallRecord(record); err != nil {
-					m.logger.Error().Err(err).Str("call_id", callID).Msg("failed to save force-ended call record")
-				}
-			}()
+			if err := m.store.SaveCallRecord(callToRecord(completed)); err != nil {
+				m.logger.Error().Err(err).Str("call_id", callID).Msg("failed to save force-ended call record")
+			}
+		}
+		if m.observer != nil {
+			m.observer.CallCompleted(*completed)
 		}
 
 		return completed.AgentID, true
@@ -287,20 +672,146 @@ func (m *CallQueueManager) ForceEndCall(callID string) (agentID string, found bo
 	return "", false
 }
 
-// filterUnassigned returns agents not in the assigned map
-func filterUnassigned(agents []types.AgentInfo, assigned map[string]bool) []types.AgentInfo {
+// releaseAgentCall clears a finished call from its agent's tracker entry. A chat agent with
+// other chats still open shows the most recently assigned of them instead (caller must hold m.mu).
+func (m *CallQueueManager) releaseAgentCall(call *types.Call) {
+	if !m.tracker.ClearCurrentCall(call.AgentID, call.CallID) {
+		return
+	}
+	var latest *types.Call
+	for _, queue := range m.queues {
+		for _, c := range queue.Active {
+			if c.AgentID == call.AgentID && (latest == nil || c.AssignTime.After(*latest.AssignTime)) {
+				latest = c
+			}
+		}
+	}
+	if latest != nil {
+		m.tracker.SetCurrentCall(latest.AgentID, latest.CallID, latest.VQ, *latest.AssignTime)
+		m.tracker.SetCallHold(latest.AgentID, latest.CallID, latest.HoldTime, latest.HoldStart)
+	}
+}
+
+// isOpen reports whether dept is within business hours at t (caller must hold m.mu)
+func (m *CallQueueManager) isOpen(dept types.Department, t time.Time) bool {
+	return m.hours == nil || m.hours.IsOpen(dept, t)
+}
+
+// updateOpen marks the queues of closed departments so their answers skip SL (caller must
+// hold m.mu for writing)
+func (m *CallQueueManager) updateOpen(now time.Time) {
+	open := make(map[types.Department]bool)
+	for _, queue := range m.queues {
+		isOpen, ok := open[queue.Department]
+		if !ok {
+			isOpen = m.isOpen(queue.Department, now)
+			open[queue.Department] = isOpen
+		}
+		queue.Closed = !isOpen
+	}
+}
+
+// connectedByTenant counts a department's connected, logged-on agents per tenant; nil
+// without reserves, which are the only users (caller must hold m.mu)
+func (m *CallQueueManager) connectedByTenant(dept types.Department) map[string]int {
+	if len(m.reserves) == 0 {
+		return nil
+	}
+	counts := make(map[string]int)
+	for _, a := range m.tracker.GetByDepartment(dept) {
+		if a.ConnectionStatus == types.StatusConnected && a.State != types.StateOffline {
+			counts[types.TenantOf(a.Tenant)]++
+		}
+	}
+	return counts
+}
+
+// byTenant groups agents by tenant
+func byTenant(agents []types.AgentInfo) map[string][]types.AgentInfo {
+	out := make(map[string][]types.AgentInfo)
+	for _, a := range agents {
+		tenant := types.TenantOf(a.Tenant)
+		out[tenant] = append(out[tenant], a)
+	}
+	return out
+}
+
+// statsKey keys interval statistics: the VQ for the default tenant, "tenant/vq" otherwise, so
+// hosted tenants' traffic stays out of the default tenant's forecast
+func statsKey(tenant string, vq types.VQName) types.VQName {
+	if types.TenantOf(tenant) == types.DefaultTenant {
+		return vq
+	}
+	return types.VQName(tenant + "/" + string(vq))
+}
+
+// chatSessions counts the active contacts of concurrent channels per agent (caller must
+// hold m.mu)
+func (m *CallQueueManager) chatSessions() map[string]int {
+	sessions := make(map[string]int)
+	for _, queue := range m.queues {
+		if !queue.Channel.Concurrent() {
+			continue
+		}
+		for _, call := range queue.Active {
+			sessions[call.AgentID]++
+		}
+	}
+	return sessions
+}
+
+// eligible returns the agents that can take a contact of channel. Voice and email need an
+// available agent without chats that got no contact this tick. Chats go to multi-session
+// agents below maxChats and to available agents without chats (one chat until the client
+// reports multi-session), and only to those with the fewest sessions so chats spread across
+// agents before stacking up.
+func (m *CallQueueManager) eligible(agents []types.AgentInfo, channel types.Channel, assigned map[string]bool, sessions map[string]int) []types.AgentInfo {
 	result := make([]types.AgentInfo, 0, len(agents))
+	if !channel.Concurrent() {
+		for _, a := range agents {
+			if a.State == types.StateAvailable && !assigned[a.AgentID] && sessions[a.AgentID] == 0 {
+				result = append(result, a)
+			}
+		}
+		return result
+	}
+
+	fewest := m.maxChats
 	for _, a := range agents {
-		if !assigned[a.AgentID] {
+		n := sessions[a.AgentID]
+		if assigned[a.AgentID] || n >= m.maxChats || (a.State == types.StateAvailable && n > 0) {
+			continue
+		}
+		if n < fewest {
+			fewest = n
+			result = result[:0]
+		}
+		if n == fewest {
 			result = append(result, a)
 		}
 	}
 	return result
 }
 
+// freeSlots counts the contacts of channel that agents could still take: one per eligible
+// agent for voice and email, the sessions left below maxChats for chat
+func (m *CallQueueManager) freeSlots(agents []types.AgentInfo, channel types.Channel, sessions map[string]int) int {
+	if !channel.Concurrent() {
+		return len(m.eligible(agents, channel, nil, sessions))
+	}
+	free := 0
+	for _, a := range agents {
+		if n := sessions[a.AgentID]; n < m.maxChats {
+			free += m.maxChats - n
+		}
+	}
+	return free
+}
+
 // callToRecord converts a completed Call to a CallRecord for persistence
 func callToRecord(call *types.Call) types.CallRecord {
 	record := types.CallRecord{
+		Tenant:     call.Tenant,
 		CallID:     call.CallID,
 		VQ:         call.VQ,
 		Department: string(call.Department),
@@ -313,12 +824,13 @@ func callToRecord(call *types.Call) types.CallRecord {
 		Abandoned:  call.Status == types.CallStatusAbandoned,
 	}
 
-	record.DateKey = call.EnqueueTime.Format("2006-01-02")
+	record.DateKey = types.TenantKey(call.Tenant, types.DateOf(call.EnqueueTime))
 	record.EnqueueTime = call.EnqueueTime.Format(time.RFC3339)
 	if call.AssignTime != nil {
 		record.AssignTime = call.AssignTime.Format(time.RFC3339)
 		record.AnsweredInSL = call.WaitTime <= 20.0
 	}
+	record.OverflowDepartment = string(call.OverflowDepartment)
 	if call.CompleteTime != nil {
 		record.CompleteTime = call.CompleteTime.Format(time.RFC3339)
 	}
@@ -0,0 +1,146 @@
+package callqueue
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// DefaultOverflowAfterSecs is how long a call waits before it may overflow unless configured
+const DefaultOverflowAfterSecs = 60
+
+// OverflowRule lets a department's calls go to the agents of a fallback department that
+// hold Skill, once they waited AfterSecs and their own department has no agent free for them
+type OverflowRule struct {
+	Department types.Department `json:"department"`          // fallback department
+	Skill      string           `json:"skill,omitempty"`     // secondary skill; empty = the overflowing department's name
+	AfterSecs  int              `json:"afterSecs,omitempty"` // wait threshold; 0 = DefaultOverflowAfterSecs
+}
+
+// OverflowRules is the overflow file (OVERFLOW_FILE), keyed by the overflowing department:
+//
+//	{"sales": {"department": "support", "skill": "sales", "afterSecs": 45}}
+type OverflowRules map[types.Department]OverflowRule
+
+// skill returns the skill fallback agents need to take dept's calls
+func (r OverflowRule) skill(dept types.Department) string {
+	if r.Skill != "" {
+		return r.Skill
+	}
+	return string(dept)
+}
+
+// after returns the wait threshold
+func (r OverflowRule) after() time.Duration {
+	if r.AfterSecs > 0 {
+		return time.Duration(r.AfterSecs) * time.Second
+	}
+	return DefaultOverflowAfterSecs * time.Second
+}
+
+// LoadOverflowFile reads and validates an overflow file
+func LoadOverflowFile(path string) (OverflowRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read overflow rules: %w", err)
+	}
+	var r OverflowRules
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse overflow rules: %w", err)
+	}
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r OverflowRules) validate() error {
+	for dept, rule := range r {
+		if !dept.Valid() {
+			return fmt.Errorf("unknown department %q", dept)
+		}
+		if !rule.Department.Valid() {
+			return fmt.Errorf("%s: unknown fallback department %q", dept, rule.Department)
+		}
+		if rule.Department == dept {
+			return fmt.Errorf("%s: cannot overflow to itself", dept)
+		}
+		if rule.AfterSecs < 0 {
+			return fmt.Errorf("%s: afterSecs must not be negative", dept)
+		}
+	}
+	return nil
+}
+
+// SetOverflow sets the departments whose waiting calls may go to fallback departments
+func (m *CallQueueManager) SetOverflow(r OverflowRules) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.overflow = r
+}
+
+// routeOverflow routes calls that waited past their rule's threshold to skilled agents of the
+// fallback department, for VQs whose own department has no agent free for them. The fallback
+// department's reserves stay untouched. Departments overflow in taxonomy order, so when two
+// share a fallback the first one's calls get its agents first. Runs after the departments'
+// own routing in a tick; assigned and sessions carry its assignments (caller must hold m.mu).
+func (m *CallQueueManager) routeOverflow(assigned map[string]bool, sessions map[string]int) []RoutingMatch {
+	var matches []RoutingMatch
+	now := time.Now()
+
+	for _, dept := range types.AllDepartments {
+		rule, ok := m.overflow[dept]
+		if !ok {
+			continue
+		}
+		fallback := byTenant(m.tracker.GetConnectedInStates(rule.Department, types.StateAvailable, types.StateMultiSession))
+		home := byTenant(m.tracker.GetConnectedInStates(dept, types.StateAvailable, types.StateMultiSession))
+		connected := m.connectedByTenant(rule.Department)
+
+		for _, tenant := range types.AllTenants() {
+			agents := fallback[tenant]
+			skilled := withSkill(agents, rule.skill(dept))
+			if len(skilled) == 0 {
+				continue
+			}
+			_, held := m.reserves.reserveCounts(types.DepartmentVQs[rule.Department], connected[tenant])
+			for _, vqName := range types.DepartmentVQs[dept] {
+				queue, ok := m.queues[queueKey{tenant, vqName}]
+				if !ok || queue.Paused || len(m.eligible(home[tenant], queue.Channel, assigned, sessions)) > 0 {
+					continue
+				}
+				for len(queue.Waiting) > 0 && now.Sub(queue.Waiting[0].EnqueueTime) >= rule.after() {
+					free := m.eligible(skilled, queue.Channel, assigned, sessions)
+					if held > 0 && len(m.eligible(agents, types.ChannelVoice, assigned, sessions)) <= held {
+						free = withoutIdle(free, sessions)
+					}
+					agent := m.selectAgent(vqName, free)
+					if agent == nil {
+						break
+					}
+					matches = append(matches, m.assignNext(queue, agent.AgentID, rule.Department, assigned, sessions))
+					m.noteOverflow(queueKey{tenant, vqName}, queue, rule.Department, now)
+				}
+			}
+		}
+	}
+
+	return matches
+}
+
+// withSkill returns the agents holding skill
+func withSkill(agents []types.AgentInfo, skill string) []types.AgentInfo {
+	result := make([]types.AgentInfo, 0, len(agents))
+	for _, a := range agents {
+		for _, s := range a.Skills {
+			if s == skill {
+				result = append(result, a)
+				break
+			}
+		}
+	}
+	return result
+}
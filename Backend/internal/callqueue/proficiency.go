@@ -0,0 +1,76 @@
+package callqueue
+
+import (
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// ProficiencyWeighted sends the calls of complex VQs to the agents most proficient in them,
+// weighed against how long the agents have been idle, and spreads the calls of all other VQs
+// evenly like FewestCallsFirst
+type ProficiencyWeighted struct {
+	weight  float64
+	complex map[types.VQName]bool
+}
+
+// NewProficiencyWeighted creates the strategy with opts' weight and complex VQs
+func NewProficiencyWeighted(opts StrategyOptions) *ProficiencyWeighted {
+	complex := make(map[types.VQName]bool, len(opts.ComplexVQs))
+	for _, vq := range opts.ComplexVQs {
+		complex[vq] = true
+	}
+	return &ProficiencyWeighted{weight: opts.ProficiencyWeight, complex: complex}
+}
+
+// SelectAgent picks, for a complex VQ, the agent with the highest score of proficiency (1-5
+// mapped to 0-1) and idle time (relative to the longest idle candidate), mixed by the weight.
+// Equal scores go to the agent idle longest.
+func (p *ProficiencyWeighted) SelectAgent(vq types.VQName, available []types.AgentInfo) *types.AgentInfo {
+	if !p.complex[vq] {
+		return (&FewestCallsFirst{}).SelectAgent(vq, available)
+	}
+	if len(available) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	idle := make([]float64, len(available))
+	var maxIdle float64
+	for i := range available {
+		idle[i] = now.Sub(idleStart(&available[i])).Seconds()
+		if idle[i] > maxIdle {
+			maxIdle = idle[i]
+		}
+	}
+
+	best, bestScore := 0, p.score(vq, &available[0], idle[0], maxIdle)
+	for i := 1; i < len(available); i++ {
+		score := p.score(vq, &available[i], idle[i], maxIdle)
+		if score > bestScore || (score == bestScore && idleLonger(&available[i], &available[best])) {
+			best, bestScore = i, score
+		}
+	}
+	return &available[best]
+}
+
+func (p *ProficiencyWeighted) score(vq types.VQName, a *types.AgentInfo, idle, maxIdle float64) float64 {
+	level := float64(proficiency(a, vq)-types.MinProficiency) / float64(types.MaxProficiency-types.MinProficiency)
+	var idleShare float64
+	if maxIdle > 0 && idle > 0 {
+		idleShare = idle / maxIdle
+	}
+	return p.weight*level + (1-p.weight)*idleShare
+}
+
+// proficiency is the agent's rating for a VQ: the skill named after the VQ if rated, else the
+// one named after its department (the skill overflow routing uses), else DefaultProficiency
+func proficiency(a *types.AgentInfo, vq types.VQName) int {
+	if level, ok := a.Proficiency[string(vq)]; ok {
+		return level
+	}
+	if level, ok := a.Proficiency[string(types.VQDepartmentMapping[vq])]; ok {
+		return level
+	}
+	return types.DefaultProficiency
+}
@@ -10,11 +10,20 @@ import (
 type VQQueue struct {
 	Name       types.VQName
 	Department types.Department
+	Channel    types.Channel
 	Waiting    []*types.Call            // FIFO queue of waiting calls
 	Active     map[string]*types.Call   // callID -> active call
 	Completed  int
 	Abandoned  int
 	SL         *SLTracker
+	Closed     bool      // outside business hours; answers are not recorded in SL
+	Paused     bool      // routing suspended by an admin
+	ResetAt    time.Time // when Completed, Abandoned and SL were last reset
+
+	// Calls overflowed since the overflow started, the latest one's time and its fallback
+	overflowed   int
+	lastOverflow time.Time
+	overflowTo   types.Department
 }
 
 // NewVQQueue creates a new per-VQ queue
@@ -22,9 +31,11 @@ func NewVQQueue(config VQConfig) *VQQueue {
 	return &VQQueue{
 		Name:       config.Name,
 		Department: config.Department,
+		Channel:    config.Channel,
 		Waiting:    make([]*types.Call, 0),
 		Active:     make(map[string]*types.Call),
 		SL:         NewSLTracker(config.SLTarget, config.SLSeconds),
+		ResetAt:    time.Now(),
 	}
 }
 
@@ -53,8 +64,21 @@ func (q *VQQueue) AssignToAgent(call *types.Call, agentID string) {
 	call.WaitTime = now.Sub(call.EnqueueTime).Seconds()
 	q.Active[call.CallID] = call
 
-	// Record SL
-	q.SL.RecordAnswer(call.WaitTime)
+	// Record SL during business hours only, once per call
+	if !q.Closed && call.Requeues == 0 {
+		q.SL.RecordAnswer(call.WaitTime)
+	}
+}
+
+// Requeue puts a call taken from its agent back at the head of the waiting queue. It keeps
+// its enqueue time, so its wait continues.
+func (q *VQQueue) Requeue(call *types.Call) {
+	call.Status = types.CallStatusWaiting
+	call.AgentID = ""
+	call.AssignTime = nil
+	endHold(call, time.Now())
+	call.Requeues++
+	q.Waiting = append([]*types.Call{call}, q.Waiting...)
 }
 
 // CompleteCall marks a call as completed and removes from active
@@ -67,7 +91,11 @@ func (q *VQQueue) CompleteCall(callID string, talkTime, holdTime float64) *types
 	call.Status = types.CallStatusCompleted
 	call.CompleteTime = &now
 	call.TalkTime = talkTime
-	call.HoldTime = holdTime
+	// The agent's hold time wins; calls it reports none for keep the holds tracked live
+	endHold(call, now)
+	if holdTime > 0 || call.Holds == 0 {
+		call.HoldTime = holdTime
+	}
 	delete(q.Active, callID)
 	q.Completed++
 	return call
@@ -89,6 +117,17 @@ func (q *VQQueue) AbandonCall(callID string) *types.Call {
 	return nil
 }
 
+// onHold counts the active calls whose caller is on hold
+func (q *VQQueue) onHold() int {
+	n := 0
+	for _, call := range q.Active {
+		if call.HoldStart != nil {
+			n++
+		}
+	}
+	return n
+}
+
 // LongestWaitSecs returns the wait time of the oldest waiting call
 func (q *VQQueue) LongestWaitSecs() float64 {
 	if len(q.Waiting) == 0 {
@@ -105,17 +144,44 @@ func (q *VQQueue) Wipe() int {
 	return count
 }
 
+// DailyStats returns the counts since the last reset as the totals of a business day ending at now
+func (q *VQQueue) DailyStats(date string, now time.Time) types.VQDailyStats {
+	return types.VQDailyStats{
+		DateKey:       date,
+		VQ:            q.Name,
+		Department:    q.Department,
+		Completed:     q.Completed,
+		Abandoned:     q.Abandoned,
+		AnsweredInSL:  q.SL.AnsweredInSL,
+		TotalAnswered: q.SL.TotalAnswered,
+		ServiceLevel:  q.SL.CurrentSL(),
+		From:          q.ResetAt,
+		To:            now,
+	}
+}
+
+// ResetCounters zeroes the completed, abandoned and SL counts; waiting and active calls stay
+func (q *VQQueue) ResetCounters(now time.Time) {
+	q.Completed = 0
+	q.Abandoned = 0
+	q.SL.Reset()
+	q.ResetAt = now
+}
+
 // Snapshot returns a VQSnapshot of the current queue state
 func (q *VQQueue) Snapshot(availableAgents int) types.VQSnapshot {
 	return types.VQSnapshot{
 		VQ:              q.Name,
 		Department:      q.Department,
+		Channel:         q.Channel,
 		WaitingCount:    len(q.Waiting),
 		ActiveCount:     len(q.Active),
+		OnHoldCount:     q.onHold(),
 		CompletedCount:  q.Completed,
 		AbandonedCount:  q.Abandoned,
 		LongestWaitSecs: q.LongestWaitSecs(),
 		AvailableAgents: availableAgents,
 		ServiceLevel:    q.SL.Snapshot(),
+		ResetAt:         q.ResetAt,
 	}
 }
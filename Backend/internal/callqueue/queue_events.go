@@ -0,0 +1,124 @@
+package callqueue
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/metrics"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// overflowQuietPeriod is how long a VQ goes without overflowing a call before its overflow
+// counts as ended
+const overflowQuietPeriod = time.Minute
+
+// ErrUnknownQueue is returned for a VQ the tenant has no queue for
+var ErrUnknownQueue = errors.New("unknown queue")
+
+// PauseQueue suspends (true) or resumes (false) routing of a tenant's VQ. Calls keep queueing
+// while it is paused. A change is recorded as a queue event by actor.
+func (m *CallQueueManager) PauseQueue(tenant string, vq types.VQName, paused bool, actor string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := queueKey{types.TenantOf(tenant), vq}
+	queue, ok := m.queues[key]
+	if !ok {
+		return ErrUnknownQueue
+	}
+	if queue.Paused == paused {
+		return nil
+	}
+	queue.Paused = paused
+
+	eventType := types.QueueEventResumed
+	if paused {
+		eventType = types.QueueEventPaused
+	}
+	m.recordQueueEvent(key, types.QueueEvent{Type: eventType, Actor: actor}, time.Now())
+	m.logger.Info().Str("tenant", key.tenant).Str("vq", string(vq)).Bool("paused", paused).Str("actor", actor).Msg("queue routing paused or resumed")
+	return nil
+}
+
+// SetSLTarget changes the SL target and threshold of a tenant's VQ and returns its new service
+// level. Answers already counted keep the threshold they were counted against. A change is
+// recorded as a queue event by actor.
+func (m *CallQueueManager) SetSLTarget(tenant string, vq types.VQName, target, thresholdSecs int, actor string) (types.ServiceLevel, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := queueKey{types.TenantOf(tenant), vq}
+	queue, ok := m.queues[key]
+	if !ok {
+		return types.ServiceLevel{}, ErrUnknownQueue
+	}
+	prev := *queue.SL
+	if prev.Target == target && prev.ThresholdSecs == thresholdSecs {
+		return queue.SL.Snapshot(), nil
+	}
+	queue.SL.Target = target
+	queue.SL.ThresholdSecs = thresholdSecs
+
+	m.recordQueueEvent(key, types.QueueEvent{
+		Type:                types.QueueEventSLTargetChanged,
+		Actor:               actor,
+		SLTarget:            target,
+		SLThresholdSecs:     thresholdSecs,
+		PrevSLTarget:        prev.Target,
+		PrevSLThresholdSecs: prev.ThresholdSecs,
+	}, time.Now())
+	m.logger.Info().Str("tenant", key.tenant).Str("vq", string(vq)).Int("target", target).
+		Int("threshold_secs", thresholdSecs).Str("actor", actor).Msg("queue SL target changed")
+	return queue.SL.Snapshot(), nil
+}
+
+// noteOverflow counts a call of the queue that overflowed to fallback at now, recording the
+// start of an overflow (caller must hold m.mu)
+func (m *CallQueueManager) noteOverflow(key queueKey, queue *VQQueue, fallback types.Department, now time.Time) {
+	if queue.overflowed == 0 {
+		m.recordQueueEvent(key, types.QueueEvent{Type: types.QueueEventOverflowStarted, FallbackDepartment: fallback}, now)
+	}
+	queue.overflowed++
+	queue.lastOverflow = now
+	queue.overflowTo = fallback
+}
+
+// endOverflows records the end of the overflows that have been quiet for overflowQuietPeriod
+// (caller must hold m.mu)
+func (m *CallQueueManager) endOverflows(now time.Time) {
+	for key, queue := range m.queues {
+		if queue.overflowed == 0 || now.Sub(queue.lastOverflow) < overflowQuietPeriod {
+			continue
+		}
+		m.recordQueueEvent(key, types.QueueEvent{
+			Type:               types.QueueEventOverflowEnded,
+			FallbackDepartment: queue.overflowTo,
+			Overflowed:         queue.overflowed,
+		}, now)
+		queue.overflowed = 0
+	}
+}
+
+// recordQueueEvent saves an event of a tenant's VQ that happened at, under the business day
+// it happened in like the VQ daily stats (caller must hold m.mu)
+func (m *CallQueueManager) recordQueueEvent(key queueKey, event types.QueueEvent, at time.Time) {
+	if m.dryRun != nil {
+		return
+	}
+	metrics.Get().RecordQueueEvent(key.tenant, key.vq, event.Type)
+	if m.store == nil {
+		return
+	}
+	day, _ := types.BusinessDay(at, m.dayStart)
+	event.DateKey = types.TenantKey(key.tenant, day)
+	event.EventKey = types.QueueEventKey(key.vq, at)
+	event.VQ = key.vq
+	event.At = at
+	if key.tenant != types.DefaultTenant {
+		event.Tenant = key.tenant
+	}
+	if err := m.store.SaveQueueEvent(event); err != nil {
+		m.logger.Error().Err(err).Str("tenant", key.tenant).Str("vq", string(key.vq)).
+			Str("type", string(event.Type)).Msg("failed to save queue event")
+	}
+}
@@ -0,0 +1,85 @@
+package callqueue
+
+import (
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/metrics"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// What happens to an active call whose agent has been disconnected longer than the grace
+// period (ORPHANED_CALL_ACTION)
+const (
+	OrphanRequeue = "requeue" // back to the head of its queue
+	OrphanAbandon = "abandon" // counted as abandoned
+	OrphanOff     = "off"     // left active
+)
+
+// ReapOrphanedCalls releases the active calls whose agent has been disconnected for at least
+// grace at now, or is no longer tracked, as action says. It returns the released calls.
+func (m *CallQueueManager) ReapOrphanedCalls(grace time.Duration, action string, now time.Time) []types.Call {
+	if action == OrphanOff {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var reaped []types.Call
+	for _, queue := range m.queues {
+		for _, call := range queue.Active {
+			agent, ok := m.tracker.Get(call.AgentID)
+			if ok && (agent.ConnectionStatus != types.StatusDisconnected || now.Sub(agent.LastHeartbeat) < grace) {
+				continue
+			}
+			m.logger.Warn().
+				Str("call_id", call.CallID).
+				Str("agent_id", call.AgentID).
+				Str("vq", string(call.VQ)).
+				Str("action", action).
+				Msg("releasing active call of disconnected agent")
+			reaped = append(reaped, *m.reap(queue, call, action, now))
+		}
+	}
+	return reaped
+}
+
+// ReapCall releases one active call as ReapOrphanedCalls does, regardless of its agent's
+// connection. Used to replay recorded releases; returns nil when the call is not active.
+func (m *CallQueueManager) ReapCall(callID, action string) *types.Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, queue := range m.queues {
+		if call, ok := queue.Active[callID]; ok {
+			return m.reap(queue, call, action, time.Now())
+		}
+	}
+	return nil
+}
+
+// reap takes an active call from its agent and requeues or abandons it (caller must hold m.mu)
+func (m *CallQueueManager) reap(queue *VQQueue, call *types.Call, action string, now time.Time) *types.Call {
+	delete(queue.Active, call.CallID)
+	m.finished.add(call, finishedByReap, now)
+	m.releaseAgentCall(call)
+	metrics.Get().RecordOrphanedCall(call.Tenant, call.VQ, action)
+	if m.callLog != nil {
+		m.callLog.CallReaped(call.CallID, action)
+	}
+
+	if action == OrphanRequeue {
+		queue.Requeue(call)
+		return call
+	}
+
+	call.Status = types.CallStatusAbandoned
+	call.CompleteTime = &now
+	queue.Abandoned++
+	m.stats.RecordAbandoned(statsKey(call.Tenant, call.VQ), now)
+	delete(m.traces, call.CallID)
+	metrics.Get().RecordCallAbandoned(call.Tenant, call.VQ, queue.Department)
+	if m.observer != nil {
+		m.observer.CallAbandoned(*call)
+	}
+	return call
+}
@@ -0,0 +1,119 @@
+package callqueue
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// Reserve keeps idle agents of a VQ's department for that VQ: calls of the department's
+// other VQs are not routed when that would leave fewer idle agents than reserved. Percent
+// is of the department's connected agents, rounded down but at least 1; Agents is an
+// absolute count. With both set the higher one applies.
+type Reserve struct {
+	Percent float64 `json:"percent,omitempty"`
+	Agents  int     `json:"agents,omitempty"`
+}
+
+// Reserves is the routing reserves file (ROUTING_RESERVES_FILE), keyed by the critical VQ:
+//
+//	{"retention_cancel": {"agents": 2}, "support_billing": {"percent": 10}}
+type Reserves map[types.VQName]Reserve
+
+// Count returns how many agents the reserve holds in a department of connected agents
+func (r Reserve) Count(connected int) int {
+	count := 0
+	if r.Percent > 0 {
+		count = int(float64(connected) * r.Percent / 100)
+		if count < 1 {
+			count = 1
+		}
+	}
+	if r.Agents > count {
+		count = r.Agents
+	}
+	return count
+}
+
+// LoadReservesFile reads and validates a routing reserves file
+func LoadReservesFile(path string) (Reserves, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read routing reserves: %w", err)
+	}
+	var r Reserves
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse routing reserves: %w", err)
+	}
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r Reserves) validate() error {
+	for vq, res := range r {
+		if !vq.Valid() {
+			return fmt.Errorf("unknown VQ %q", vq)
+		}
+		if res.Percent < 0 || res.Percent > 100 {
+			return fmt.Errorf("%s: percent must be between 0 and 100", vq)
+		}
+		if res.Agents < 0 {
+			return fmt.Errorf("%s: agents must not be negative", vq)
+		}
+	}
+	return nil
+}
+
+// reserveCounts returns the agents held for each reserved VQ of a department of connected
+// agents, and their total
+func (r Reserves) reserveCounts(vqNames []types.VQName, connected int) (map[types.VQName]int, int) {
+	counts := make(map[types.VQName]int)
+	total := 0
+	for _, vq := range vqNames {
+		if res, ok := r[vq]; ok {
+			n := res.Count(connected)
+			counts[vq] = n
+			total += n
+		}
+	}
+	return counts, total
+}
+
+// status reports each reserved VQ's reserve: idle agents cover the department's reserves
+// in VQ order
+func (r Reserves) status(vqNames []types.VQName, connected, idle int) map[types.VQName]*types.ReserveStatus {
+	if len(r) == 0 {
+		return nil
+	}
+	counts, _ := r.reserveCounts(vqNames, connected)
+	out := make(map[types.VQName]*types.ReserveStatus, len(counts))
+	for _, vq := range vqNames {
+		n, ok := counts[vq]
+		if !ok {
+			continue
+		}
+		covered := min(idle, n)
+		idle -= covered
+		st := &types.ReserveStatus{Reserved: n, Idle: covered}
+		if n > 0 {
+			st.Utilization = float64(n-covered) / float64(n) * 100
+		}
+		out[vq] = st
+	}
+	return out
+}
+
+// withoutIdle drops idle agents (available without chats), keeping those already chatting
+func withoutIdle(agents []types.AgentInfo, sessions map[string]int) []types.AgentInfo {
+	result := make([]types.AgentInfo, 0, len(agents))
+	for _, a := range agents {
+		if a.State != types.StateAvailable || sessions[a.AgentID] > 0 {
+			result = append(result, a)
+		}
+	}
+	return result
+}
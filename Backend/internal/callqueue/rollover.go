@@ -0,0 +1,45 @@
+package callqueue
+
+import (
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// SetBusinessDayStart sets the time of day (offset from midnight in the reporting timezone)
+// at which the completed, abandoned and SL counts roll over to a new business day
+func (m *CallQueueManager) SetBusinessDayStart(offset time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dayStart = offset
+	m.day, m.dayEnd = types.BusinessDay(time.Now(), offset)
+}
+
+// rollover saves every queue's counts for the business day that ended and resets them, once
+// now has passed the end of the day (caller must hold m.mu for writing)
+func (m *CallQueueManager) rollover(now time.Time) {
+	if m.day == "" {
+		m.day, m.dayEnd = types.BusinessDay(now, m.dayStart)
+		return
+	}
+	if now.Before(m.dayEnd) {
+		return
+	}
+
+	for key, queue := range m.queues {
+		if m.store != nil {
+			stats := queue.DailyStats(m.day, now)
+			stats.DateKey = types.TenantKey(key.tenant, m.day)
+			if key.tenant != types.DefaultTenant {
+				stats.Tenant = key.tenant
+			}
+			if err := m.store.SaveVQDailyStats(stats); err != nil {
+				m.logger.Error().Err(err).Str("tenant", key.tenant).Str("vq", string(key.vq)).Msg("failed to save VQ daily stats")
+			}
+		}
+		queue.ResetCounters(now)
+	}
+
+	m.logger.Info().Str("day", m.day).Msg("rolled queue counters over to a new business day")
+	m.day, m.dayEnd = types.BusinessDay(now, m.dayStart)
+}
@@ -1,28 +1,139 @@
 package callqueue
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/dennisdiepolder/monti/backend/internal/types"
 )
 
-// RoutingStrategy selects the best agent to handle a call
+// RoutingStrategy selects the best agent to handle the next call of a VQ
 type RoutingStrategy interface {
-	SelectAgent(available []types.AgentInfo) *types.AgentInfo
+	SelectAgent(vq types.VQName, available []types.AgentInfo) *types.AgentInfo
+}
+
+// Routing strategy names, as used by ROUTING_STRATEGY and ROUTING_SHADOW_STRATEGY
+const (
+	StrategyLongestIdle = "longest_idle"
+	StrategyFewestCalls = "fewest_calls"
+	StrategyProficiency = "proficiency"
+)
+
+// StrategyOptions tune the strategies that take settings
+type StrategyOptions struct {
+	// Share of proficiency against idle time in the proficiency strategy's score on complex
+	// VQs, from 0 (idle time only) to 1 (proficiency only)
+	ProficiencyWeight float64
+	ComplexVQs        []types.VQName
+}
+
+// DefaultStrategyOptions returns the settings used unless configured otherwise. Complex VQs
+// missing from the taxonomy are left out.
+func DefaultStrategyOptions() StrategyOptions {
+	opts := StrategyOptions{ProficiencyWeight: 0.7}
+	for _, vq := range []types.VQName{types.VQTechL2, types.VQRetentionCancel} {
+		if vq.Valid() {
+			opts.ComplexVQs = append(opts.ComplexVQs, vq)
+		}
+	}
+	return opts
+}
+
+// strategies constructs the routing strategies by name
+var strategies = map[string]func(StrategyOptions) RoutingStrategy{
+	StrategyLongestIdle: func(StrategyOptions) RoutingStrategy { return &LongestIdleFirst{} },
+	StrategyFewestCalls: func(StrategyOptions) RoutingStrategy { return &FewestCallsFirst{} },
+	StrategyProficiency: func(opts StrategyOptions) RoutingStrategy { return NewProficiencyWeighted(opts) },
+}
+
+// NewRoutingStrategy returns the routing strategy called name
+func NewRoutingStrategy(name string, opts StrategyOptions) (RoutingStrategy, error) {
+	newStrategy, ok := strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown routing strategy %q (use %s)", name, strings.Join(StrategyNames(), ", "))
+	}
+	return newStrategy(opts), nil
+}
+
+// StrategyNames lists the routing strategies, sorted
+func StrategyNames() []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 // LongestIdleFirst selects the agent who has been available the longest
 type LongestIdleFirst struct{}
 
-// SelectAgent picks the available agent with the oldest StateStart time
-func (l *LongestIdleFirst) SelectAgent(available []types.AgentInfo) *types.AgentInfo {
+// SelectAgent picks the available agent with the oldest idle time. Ties, common when a
+// multiplexed batch registers at once, go to the agent with fewer calls, then the lower ID,
+// so the choice does not depend on the tracker's map order.
+func (l *LongestIdleFirst) SelectAgent(_ types.VQName, available []types.AgentInfo) *types.AgentInfo {
 	if len(available) == 0 {
 		return nil
 	}
 
-	oldest := &available[0]
+	best := &available[0]
 	for i := 1; i < len(available); i++ {
-		if available[i].StateStart.Before(oldest.StateStart) {
-			oldest = &available[i]
+		if idleLonger(&available[i], best) {
+			best = &available[i]
 		}
 	}
-	return oldest
+	return best
+}
+
+// idleStart is when the agent became idle: IdleSince, or StateStart for agents the
+// tracker did not see becoming available
+func idleStart(a *types.AgentInfo) time.Time {
+	if a.IdleSince != nil {
+		return *a.IdleSince
+	}
+	return a.StateStart
+}
+
+// idleLonger reports whether a should be routed to before b
+func idleLonger(a, b *types.AgentInfo) bool {
+	as, bs := idleStart(a), idleStart(b)
+	if !as.Equal(bs) {
+		return as.Before(bs)
+	}
+	if a.KPIs.TotalCalls != b.KPIs.TotalCalls {
+		return a.KPIs.TotalCalls < b.KPIs.TotalCalls
+	}
+	return a.AgentID < b.AgentID
+}
+
+// FewestCallsFirst selects the agent who has handled the fewest calls, spreading work evenly
+// across a shift; ties go to the agent idle longest
+type FewestCallsFirst struct{}
+
+// SelectAgent picks the available agent with the fewest handled calls
+func (f *FewestCallsFirst) SelectAgent(_ types.VQName, available []types.AgentInfo) *types.AgentInfo {
+	if len(available) == 0 {
+		return nil
+	}
+
+	best := &available[0]
+	for i := 1; i < len(available); i++ {
+		a := &available[i]
+		if a.KPIs.TotalCalls < best.KPIs.TotalCalls ||
+			(a.KPIs.TotalCalls == best.KPIs.TotalCalls && idleLonger(a, best)) {
+			best = a
+		}
+	}
+	return best
+}
+
+// handleTime is an agent's average handle time in seconds: observed by the backend when it
+// saw calls complete, else as reported
+func handleTime(a *types.AgentInfo) float64 {
+	if a.Observed != nil && a.Observed.Session.CallsHandled > 0 {
+		return a.Observed.Session.AvgHandleTime
+	}
+	return a.KPIs.AvgHandleTime
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"time"
 
+	"github.com/dennisdiepolder/monti/backend/internal/metrics"
 	"github.com/dennisdiepolder/monti/backend/internal/types"
 	"github.com/rs/zerolog"
 )
@@ -16,23 +17,43 @@ type AgentSender interface {
 
 // RoutingLoop periodically matches waiting calls to available agents
 type RoutingLoop struct {
-	mgr    *CallQueueManager
-	sender AgentSender
-	logger zerolog.Logger
+	mgr      *CallQueueManager
+	sender   AgentSender
+	logger   zerolog.Logger
+	interval time.Duration
+
+	// Active calls of agents disconnected this long are released as orphanAction says
+	orphanGrace  time.Duration
+	orphanAction string
 }
 
 // NewRoutingLoop creates a new RoutingLoop
 func NewRoutingLoop(mgr *CallQueueManager, sender AgentSender, logger zerolog.Logger) *RoutingLoop {
 	return &RoutingLoop{
-		mgr:    mgr,
-		sender: sender,
-		logger: logger,
+		mgr:          mgr,
+		sender:       sender,
+		logger:       logger,
+		interval:     time.Second,
+		orphanGrace:  30 * time.Second,
+		orphanAction: OrphanRequeue,
 	}
 }
 
-// Start begins the routing loop, ticking every 1 second until the context is cancelled
+// SetInterval sets the time between routing passes; call before Start
+func (rl *RoutingLoop) SetInterval(d time.Duration) {
+	rl.interval = d
+}
+
+// SetOrphanedCalls sets what happens to active calls whose agent has been disconnected for
+// grace: OrphanRequeue, OrphanAbandon or OrphanOff; call before Start
+func (rl *RoutingLoop) SetOrphanedCalls(grace time.Duration, action string) {
+	rl.orphanGrace = grace
+	rl.orphanAction = action
+}
+
+// Start begins the routing loop, ticking every interval (default 1 second) until the context is cancelled
 func (rl *RoutingLoop) Start(ctx context.Context) {
-	ticker := time.NewTicker(1 * time.Second)
+	ticker := time.NewTicker(rl.interval)
 	defer ticker.Stop()
 
 	rl.logger.Info().Msg("routing loop started")
@@ -50,15 +71,22 @@ func (rl *RoutingLoop) Start(ctx context.Context) {
 
 // tick performs a single routing pass
 func (rl *RoutingLoop) tick() {
+	start := time.Now()
+	m := metrics.Get()
+	defer func() { m.RecordRoutingTick(time.Since(start)) }()
+
+	// Requeued calls of disconnected agents are routed in the same pass
+	rl.mgr.ReapOrphanedCalls(rl.orphanGrace, rl.orphanAction, start)
 	matches := rl.mgr.TickRouting()
 
 	for _, match := range matches {
 		msg := types.CallAssign{
-			Type:      "call_assign",
-			AgentID:   match.AgentID,
-			CallID:    match.Call.CallID,
-			VQ:        match.Call.VQ,
-			Timestamp: time.Now(),
+			Type:        "call_assign",
+			AgentID:     match.AgentID,
+			CallID:      match.Call.CallID,
+			VQ:          match.Call.VQ,
+			Timestamp:   time.Now(),
+			TraceParent: match.TraceParent,
 		}
 
 		data, err := json.Marshal(msg)
@@ -26,6 +26,12 @@ func (s *SLTracker) RecordAnswer(waitTimeSecs float64) {
 	}
 }
 
+// Reset clears the answered counts
+func (s *SLTracker) Reset() {
+	s.AnsweredInSL = 0
+	s.TotalAnswered = 0
+}
+
 // CurrentSL returns the current service level percentage
 func (s *SLTracker) CurrentSL() float64 {
 	if s.TotalAnswered == 0 {
@@ -0,0 +1,133 @@
+package callqueue
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/metrics"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// shadowEvaluation tallies how a secondary strategy would have routed, without applying its
+// choices. It sees the same candidates as the active strategy at every choice; its picks are
+// never fed back, so later choices always start from the live state.
+type shadowEvaluation struct {
+	name       string
+	strategy   RoutingStrategy
+	since      time.Time
+	decisions  int
+	divergent  int
+	impactSecs float64 // summed projected change of the next call's wait
+}
+
+// observe compares the shadow's pick among candidates with the active strategy's choice
+func (s *shadowEvaluation) observe(vq types.VQName, candidates []types.AgentInfo, chosen *types.AgentInfo) {
+	if len(candidates) < 2 || chosen == nil {
+		return
+	}
+	pick := s.strategy.SelectAgent(vq, candidates)
+	divergent := pick != nil && pick.AgentID != chosen.AgentID
+	s.decisions++
+	metrics.Get().RecordShadowDecision(divergent)
+	if !divergent {
+		return
+	}
+	s.divergent++
+	// The shadow's agent would have been tied up for its handle time instead of the chosen
+	// agent's; spread over the candidate pool that shifts when the next agent frees up
+	s.impactSecs += (handleTime(pick) - handleTime(chosen)) / float64(len(candidates))
+}
+
+// report summarizes the evaluation against the active strategy
+func (s *shadowEvaluation) report(active string) types.ShadowRoutingReport {
+	r := types.ShadowRoutingReport{
+		Strategy:  active,
+		Shadow:    s.name,
+		Since:     s.since,
+		Decisions: s.decisions,
+		Divergent: s.divergent,
+	}
+	if s.decisions > 0 {
+		r.DivergenceRate = float64(s.divergent) / float64(s.decisions) * 100
+	}
+	if s.divergent > 0 {
+		r.ProjectedWaitImpactSecs = s.impactSecs / float64(s.divergent)
+	}
+	return r
+}
+
+// SetRoutingStrategy switches the strategy that picks agents
+func (m *CallQueueManager) SetRoutingStrategy(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	strategy, err := NewRoutingStrategy(name, m.strategyOpts)
+	if err != nil {
+		return err
+	}
+	m.routing = strategy
+	m.strategy = name
+	return nil
+}
+
+// SetStrategyOptions changes the settings of the strategies that take any. The active and
+// shadow strategies are rebuilt with them; the shadow evaluation keeps its tallies.
+func (m *CallQueueManager) SetStrategyOptions(opts StrategyOptions) error {
+	if opts.ProficiencyWeight < 0 || opts.ProficiencyWeight > 1 {
+		return fmt.Errorf("proficiency weight must be between 0 and 1")
+	}
+	for _, vq := range opts.ComplexVQs {
+		if !vq.Valid() {
+			return fmt.Errorf("unknown VQ %q", vq)
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.strategyOpts = opts
+	m.routing, _ = NewRoutingStrategy(m.strategy, opts)
+	if m.shadow != nil {
+		m.shadow.strategy, _ = NewRoutingStrategy(m.shadow.name, opts)
+	}
+	return nil
+}
+
+// SetShadowStrategy starts a fresh shadow evaluation of the strategy called name next to the
+// active one (empty = stop evaluating)
+func (m *CallQueueManager) SetShadowStrategy(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var shadow *shadowEvaluation
+	if name != "" {
+		strategy, err := NewRoutingStrategy(name, m.strategyOpts)
+		if err != nil {
+			return err
+		}
+		shadow = &shadowEvaluation{name: name, strategy: strategy, since: time.Now()}
+	}
+	m.shadow = shadow
+	return nil
+}
+
+// ShadowReport compares the shadow strategy's choices with the active strategy's since the
+// evaluation started; false when no shadow strategy is set
+func (m *CallQueueManager) ShadowReport() (types.ShadowRoutingReport, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.shadow == nil {
+		return types.ShadowRoutingReport{}, false
+	}
+	return m.shadow.report(m.strategy), true
+}
+
+// selectAgent picks an agent for the next call of vq with the routing strategy; the shadow
+// strategy, if any, weighs in on the same candidates, and a dry run notes them (caller must
+// hold m.mu)
+func (m *CallQueueManager) selectAgent(vq types.VQName, free []types.AgentInfo) *types.AgentInfo {
+	agent := m.routing.SelectAgent(vq, free)
+	if m.dryRun != nil {
+		m.dryRun.candidates = candidateIDs(free)
+	}
+	if m.shadow != nil {
+		m.shadow.observe(vq, free, agent)
+	}
+	return agent
+}
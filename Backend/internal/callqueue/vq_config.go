@@ -6,13 +6,14 @@ import "github.com/dennisdiepolder/monti/backend/internal/types"
 type VQConfig struct {
 	Name       types.VQName
 	Department types.Department
+	Channel    types.Channel
 	SLTarget   int // target percentage (e.g., 80)
 	SLSeconds  int // threshold in seconds (e.g., 20)
 }
 
-// DefaultVQConfigs returns the default configuration for all 16 VQs
+// DefaultVQConfigs returns the default configuration for every VQ of the taxonomy
 func DefaultVQConfigs() map[types.VQName]VQConfig {
-	configs := make(map[types.VQName]VQConfig, 16)
+	configs := make(map[types.VQName]VQConfig, len(types.AllVQs))
 
 	// All VQs default to 80/20 SL target
 	for _, vq := range types.AllVQs {
@@ -20,6 +21,7 @@ func DefaultVQConfigs() map[types.VQName]VQConfig {
 		configs[vq] = VQConfig{
 			Name:       vq,
 			Department: dept,
+			Channel:    types.ChannelOf(vq),
 			SLTarget:   80,
 			SLSeconds:  20,
 		}
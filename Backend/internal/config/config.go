@@ -2,11 +2,12 @@ package config
 
 import (
 	"fmt"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/dennisdiepolder/monti/backend/internal/rollup"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
 	"github.com/joho/godotenv"
 )
 
@@ -21,6 +22,162 @@ type Config struct {
 	PongWait          time.Duration
 	WriteWait         time.Duration
 	MaxMessageSize    int64
+	RollupDimensions  []types.RollupDimension
+	Leaderboards      types.LeaderboardSettings
+	AlertRulesFile    string // JSON rule set; empty uses the built-in defaults
+	AuthMappingFile   string // JSON role/group claim mapping; empty uses the built-in defaults
+	TaxonomyFile      string // JSON departments/VQs, locations and business units; empty uses the built-in defaults
+	JWKSRefreshInterval time.Duration // periodic JWKS re-fetch to pick up key rotation
+	InternalAuthToken   string        // shared secret for /internal and /ws/agent; empty disables the check
+	TokenCacheTTL       time.Duration // reuse of validated JWTs; 0 disables the cache
+
+	// Frontend WebSocket connection caps (0 = unlimited)
+	WSMaxConnectionsPerUser int
+	WSMaxConnections        int
+
+	// How long a dashboard that acknowledged snapshots may go without an ack before it counts as stalled
+	WSAckStallAfter time.Duration
+
+	// REST rate limits in requests per minute (0 = unlimited)
+	RateLimitIPPerMinute    int
+	RateLimitUserPerMinute  int
+	RateLimitAdminPerMinute int
+
+	// Request body limits for /internal POST endpoints, in bytes (0 = unlimited)
+	MaxEventBodyBytes  int
+	MaxRosterBodyBytes int
+
+	// Accept /internal/event payloads with unknown states, departments or locations (logged
+	// and counted) instead of rejecting them with 400
+	PermissiveIngestion bool
+
+	// Amazon Connect agent event ingestion via Firehose (empty access key disables the endpoint)
+	ConnectAccessKey string
+	ConnectHierarchy []string // field each Connect hierarchy level maps to
+
+	// JSON list of webhook subscribers (url, secret, events); empty disables webhooks
+	WebhooksFile string
+
+	// JSON break policy (per-department and per-reason break caps); empty caps breaks at 5% per department
+	BreakPolicyFile string
+
+	// JSON business hours and holidays; empty keeps every department always open
+	BusinessHoursFile string
+
+	// JSON weekly sim schedule applying presets or stopping AgentSim; empty disables it
+	SimScheduleFile string
+
+	// Append-only JSON-lines log of every agent message and queue mutation; empty disables it
+	EventLogFile string
+
+	// Contact centers hosted besides the default tenant; empty runs single-tenant
+	Tenants []string
+
+	// IANA timezone days are cut in: date keys of call records and alerts, schedule days and
+	// the dates of reports
+	ReportingTimezone string
+
+	// Time of day (offset from midnight in ReportingTimezone) the queue counters roll over at
+	BusinessDayStart time.Duration
+
+	// Concurrent chats routed to one agent
+	ChatMaxSessions int
+
+	// Routing strategy, and a strategy evaluated next to it without being applied (empty = none)
+	RoutingStrategy       string
+	RoutingShadowStrategy string
+
+	// Proficiency strategy: share of proficiency against idle time (0-1), and the VQs it
+	// applies to (empty = the built-in tech_l2 and retention_cancel)
+	RoutingProficiencyWeight float64
+	RoutingComplexVQs        []string
+
+	// JSON idle agents reserved per critical VQ; empty reserves none
+	RoutingReservesFile string
+
+	// JSON fallback department per department for calls waiting without agents; empty disables overflow
+	OverflowFile string
+
+	// JSON list of vendor ingestion adapters (type, name, settings); empty runs none
+	IngestionAdaptersFile string
+
+	// Goroutines processing agent messages, sharded by agent ID (0 = one per CPU)
+	IngestWorkers int
+
+	// Deep health checks: per-check timeout and goroutine count above which the instance is degraded
+	HealthCheckTimeout  time.Duration
+	HealthMaxGoroutines int
+
+	// Upper bound for draining connections and flushing writes on SIGTERM
+	ShutdownTimeout time.Duration
+
+	// Metrics pushed for deployments without a scraping Prometheus: remote write endpoint,
+	// statsd address and line format (statsd or datadog), and the push interval
+	MetricsRemoteWriteURL string
+	MetricsStatsdAddr     string
+	MetricsStatsdFormat   string
+	MetricsPushInterval   time.Duration
+
+	// Lockout after repeated invalid tokens (thresholds of 0 disable)
+	AuthLockoutIPThreshold   int
+	AuthLockoutUserThreshold int
+	AuthLockoutWindow        time.Duration
+	AuthLockoutDuration      time.Duration
+
+	// Aggregation cadence: base broadcast interval and per-section refresh intervals
+	AggregationInterval time.Duration
+	RollupInterval      time.Duration
+	TrendInterval       time.Duration
+	LeaderboardInterval time.Duration
+
+	// Latencies above which snapshots are degraded under overload (0 disables the trigger)
+	LoadShedAggregationLatency time.Duration
+	LoadShedBroadcastLatency   time.Duration
+
+	// Call routing pass interval
+	RoutingInterval time.Duration
+
+	// Agent liveness: heartbeat age that marks an agent stale, how often that is checked,
+	// and how long disconnected agents are kept before removal
+	StaleThreshold     time.Duration
+	StaleCheckInterval time.Duration
+	DisconnectedTTL    time.Duration
+
+	// How long an agent whose connection dropped stays stale before it is logged off (0 = at once)
+	ReconnectGrace time.Duration
+
+	// What happens to an active call whose agent has been disconnected for OrphanedCallGrace:
+	// requeue, abandon or off
+	OrphanedCallAction string
+	OrphanedCallGrace  time.Duration
+
+	// Heartbeat interval agent connections are asked to slow down to while the AgentHub sheds
+	// heartbeats (whole seconds below StaleThreshold; 0 = never throttle)
+	AgentThrottleHeartbeatInterval time.Duration
+
+	// What happens to state changes the transition table does not allow (off, flag or reject),
+	// and the invalid transitions within the quarantine period after which an agent's state
+	// changes are ignored for that period (0 = never quarantine)
+	StateTransitionPolicy     string
+	StateTransitionQuarantine int
+	StateQuarantinePeriod     time.Duration
+
+	// Recent calls per agent that the p50/p90 handle time percentiles cover
+	KPIHistorySize int
+
+	// How long raw agent events stay queryable through GET /api/events
+	EventRetention time.Duration
+
+	// Snapshots replayed to newly connected dashboards
+	SnapshotHistorySize int
+
+	// Auth and AgentSim endpoints
+	SkipAuth    bool   // development only: bypass token validation
+	OIDCIssuer  string // empty disables JWKS verification
+	AgentSimURL string // AgentSim control API used by the admin endpoints; empty = no AgentSim
+
+	// Effective settings with their source, for GET /api/admin/config (secrets redacted)
+	Settings []Setting
 }
 
 // Load loads configuration from environment variables
@@ -28,20 +185,106 @@ func Load() (*Config, error) {
 	// Try to load .env file (ignore error if it doesn't exist)
 	_ = godotenv.Load()
 
+	// Fill unset variables from the YAML config file (CONFIG_FILE, default config.yaml)
+	fromFile, err := applyFile()
+	if err != nil {
+		return nil, err
+	}
+	l := &loader{fromFile: fromFile, settings: make(map[string]Setting)}
+
 	config := &Config{
-		Port:           getEnv("PORT", "8080"),
-		AllowedOrigins: strings.Split(getEnv("ALLOWED_ORIGINS", "http://localhost:5173"), ","),
-		LogLevel:       getEnv("LOG_LEVEL", "info"),
+		Port:           l.get("PORT", "8080"),
+		AllowedOrigins: strings.Split(l.get("ALLOWED_ORIGINS", "http://localhost:5173"), ","),
+		LogLevel:       l.get("LOG_LEVEL", "info"),
+		AlertRulesFile: l.get("ALERT_RULES_FILE", ""),
+		AuthMappingFile: l.get("AUTH_MAPPING_FILE", ""),
+		TaxonomyFile: l.get("TAXONOMY_FILE", ""),
+		ReportingTimezone: l.get("REPORTING_TIMEZONE", "UTC"),
+		InternalAuthToken: l.get("INTERNAL_AUTH_TOKEN", ""),
+		SkipAuth:          l.get("SKIP_AUTH", "false") == "true",
+		PermissiveIngestion: l.get("PERMISSIVE_INGESTION", "false") == "true",
+		OIDCIssuer:        l.get("OIDC_ISSUER", ""),
+		AgentSimURL:       l.get("AGENTSIM_URL", ""),
+		ConnectAccessKey:  l.get("CONNECT_ACCESS_KEY", ""),
+		IngestionAdaptersFile: l.get("INGESTION_ADAPTERS_FILE", ""),
+		WebhooksFile:          l.get("WEBHOOKS_FILE", ""),
+		EventLogFile:          l.get("EVENT_LOG_FILE", ""),
+		BreakPolicyFile:       l.get("BREAK_POLICY_FILE", ""),
+		BusinessHoursFile:     l.get("BUSINESS_HOURS_FILE", ""),
+		SimScheduleFile:       l.get("SIM_SCHEDULE_FILE", ""),
+		RoutingReservesFile:   l.get("ROUTING_RESERVES_FILE", ""),
+		StateTransitionPolicy: l.get("STATE_TRANSITION_POLICY", "flag"),
+		OrphanedCallAction:    l.get("ORPHANED_CALL_ACTION", "requeue"),
+		RoutingStrategy:       l.get("ROUTING_STRATEGY", "longest_idle"),
+		RoutingShadowStrategy: l.get("ROUTING_SHADOW_STRATEGY", ""),
+		OverflowFile:          l.get("OVERFLOW_FILE", ""),
+		MetricsRemoteWriteURL: l.get("METRICS_REMOTE_WRITE_URL", ""),
+		MetricsStatsdAddr:     l.get("METRICS_STATSD_ADDR", ""),
+		MetricsStatsdFormat:   l.get("METRICS_STATSD_FORMAT", "datadog"),
+	}
+	switch config.MetricsStatsdFormat {
+	case "statsd", "datadog":
+	default:
+		return nil, fmt.Errorf("invalid METRICS_STATSD_FORMAT: must be statsd or datadog")
+	}
+
+	switch config.StateTransitionPolicy {
+	case "off", "flag", "reject":
+	default:
+		return nil, fmt.Errorf("invalid STATE_TRANSITION_POLICY: must be off, flag or reject")
+	}
+
+	switch config.OrphanedCallAction {
+	case "requeue", "abandon", "off":
+	default:
+		return nil, fmt.Errorf("invalid ORPHANED_CALL_ACTION: must be requeue, abandon or off")
+	}
+
+	if _, err := time.LoadLocation(config.ReportingTimezone); err != nil {
+		return nil, fmt.Errorf("invalid REPORTING_TIMEZONE: %w", err)
+	}
+
+	dayStart, err := time.Parse("15:04", l.get("BUSINESS_DAY_START", "00:00"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid BUSINESS_DAY_START: must be HH:MM")
+	}
+	config.BusinessDayStart = time.Duration(dayStart.Hour())*time.Hour + time.Duration(dayStart.Minute())*time.Minute
+
+	for _, field := range strings.Split(l.get("CONNECT_HIERARCHY", "location,department,team"), ",") {
+		field = strings.TrimSpace(field)
+		switch field {
+		case "", "location", "department", "team":
+			config.ConnectHierarchy = append(config.ConnectHierarchy, field)
+		default:
+			return nil, fmt.Errorf("invalid CONNECT_HIERARCHY field %q", field)
+		}
+	}
+
+	weight, err := strconv.ParseFloat(l.get("ROUTING_PROFICIENCY_WEIGHT", "0.7"), 64)
+	if err != nil || weight < 0 || weight > 1 {
+		return nil, fmt.Errorf("invalid ROUTING_PROFICIENCY_WEIGHT: must be between 0 and 1")
+	}
+	config.RoutingProficiencyWeight = weight
+	for _, vq := range strings.Split(l.get("ROUTING_COMPLEX_VQS", ""), ",") {
+		if vq = strings.TrimSpace(vq); vq != "" {
+			config.RoutingComplexVQs = append(config.RoutingComplexVQs, vq)
+		}
+	}
+
+	for _, tenant := range strings.Split(l.get("TENANTS", ""), ",") {
+		if tenant = strings.TrimSpace(tenant); tenant != "" {
+			config.Tenants = append(config.Tenants, tenant)
+		}
 	}
 
 	// Parse WebSocket timeouts
-	wsReadTimeout, err := strconv.Atoi(getEnv("WS_READ_TIMEOUT", "60"))
+	wsReadTimeout, err := strconv.Atoi(l.get("WS_READ_TIMEOUT", "60"))
 	if err != nil {
 		return nil, fmt.Errorf("invalid WS_READ_TIMEOUT: %w", err)
 	}
 	config.WSReadTimeout = time.Duration(wsReadTimeout) * time.Second
 
-	wsWriteTimeout, err := strconv.Atoi(getEnv("WS_WRITE_TIMEOUT", "10"))
+	wsWriteTimeout, err := strconv.Atoi(l.get("WS_WRITE_TIMEOUT", "10"))
 	if err != nil {
 		return nil, fmt.Errorf("invalid WS_WRITE_TIMEOUT: %w", err)
 	}
@@ -53,18 +296,173 @@ func Load() (*Config, error) {
 	config.WriteWait = config.WSWriteTimeout
 	config.MaxMessageSize = 512
 
+	// Parse snapshot rollup dimensions
+	rollupDims, err := rollup.ParseDimensions(l.get("ROLLUP_DIMENSIONS", "team,location"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ROLLUP_DIMENSIONS: %w", err)
+	}
+	config.RollupDimensions = rollupDims
+
+	// Parse leaderboard settings
+	config.Leaderboards.Enabled = l.get("LEADERBOARD_ENABLED", "false") == "true"
+	leaderboardSize, err := strconv.Atoi(l.get("LEADERBOARD_SIZE", "5"))
+	if err != nil || leaderboardSize <= 0 {
+		return nil, fmt.Errorf("invalid LEADERBOARD_SIZE: must be a positive integer")
+	}
+	config.Leaderboards.Size = leaderboardSize
+
+	chatMaxSessions, err := strconv.Atoi(l.get("CHAT_MAX_SESSIONS", "3"))
+	if err != nil || chatMaxSessions <= 0 {
+		return nil, fmt.Errorf("invalid CHAT_MAX_SESSIONS: must be a positive integer")
+	}
+	config.ChatMaxSessions = chatMaxSessions
+
+	kpiHistorySize, err := strconv.Atoi(l.get("KPI_HISTORY_SIZE", "50"))
+	if err != nil || kpiHistorySize <= 0 || kpiHistorySize > 1000 {
+		return nil, fmt.Errorf("invalid KPI_HISTORY_SIZE: must be between 1 and 1000")
+	}
+	config.KPIHistorySize = kpiHistorySize
+
+	// Parse aggregation cadence (Go duration strings, e.g. "500ms", "5s")
+	intervals := []struct {
+		key    string
+		def    string
+		target *time.Duration
+	}{
+		{"AGGREGATION_INTERVAL", "1s", &config.AggregationInterval},
+		{"ROLLUP_INTERVAL", "1s", &config.RollupInterval},
+		{"TREND_INTERVAL", "5s", &config.TrendInterval},
+		{"LEADERBOARD_INTERVAL", "30s", &config.LeaderboardInterval},
+		{"ROUTING_INTERVAL", "1s", &config.RoutingInterval},
+		{"STALE_THRESHOLD", "6s", &config.StaleThreshold},
+		{"STALE_CHECK_INTERVAL", "2s", &config.StaleCheckInterval},
+		{"DISCONNECTED_TTL", "30s", &config.DisconnectedTTL},
+		{"EVENT_RETENTION", "15m", &config.EventRetention},
+		{"WS_ACK_STALL_AFTER", "30s", &config.WSAckStallAfter},
+		{"ORPHANED_CALL_GRACE", "30s", &config.OrphanedCallGrace},
+		{"METRICS_PUSH_INTERVAL", "15s", &config.MetricsPushInterval},
+	}
+	for _, iv := range intervals {
+		d, err := time.ParseDuration(l.get(iv.key, iv.def))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid %s: must be a positive duration", iv.key)
+		}
+		*iv.target = d
+	}
+
+	// Parse reconnect grace period ("0" logs dropped agents off at once)
+	reconnectGrace, err := time.ParseDuration(l.get("RECONNECT_GRACE", "10s"))
+	if err != nil || reconnectGrace < 0 {
+		return nil, fmt.Errorf("invalid RECONNECT_GRACE: must be a non-negative duration")
+	}
+	config.ReconnectGrace = reconnectGrace
+
+	// Parse the heartbeat throttle ("0" disables it). Throttled agents must still beat before
+	// they count as stale, so the default stays below a short STALE_THRESHOLD.
+	defaultThrottle := 5 * time.Second
+	if defaultThrottle >= config.StaleThreshold {
+		defaultThrottle = (config.StaleThreshold - 1).Truncate(time.Second)
+	}
+	throttle, err := time.ParseDuration(l.get("AGENT_THROTTLE_HEARTBEAT_INTERVAL", defaultThrottle.String()))
+	if err != nil || throttle < 0 || throttle%time.Second != 0 || throttle >= config.StaleThreshold {
+		return nil, fmt.Errorf("invalid AGENT_THROTTLE_HEARTBEAT_INTERVAL: must be 0 or whole seconds below STALE_THRESHOLD")
+	}
+	config.AgentThrottleHeartbeatInterval = throttle
+
+	// Parse load shedding thresholds ("0" disables the trigger)
+	shedThresholds := []struct {
+		key    string
+		def    string
+		target *time.Duration
+	}{
+		{"LOAD_SHED_AGGREGATION_LATENCY", "500ms", &config.LoadShedAggregationLatency},
+		{"LOAD_SHED_BROADCAST_LATENCY", "500ms", &config.LoadShedBroadcastLatency},
+	}
+	for _, st := range shedThresholds {
+		d, err := time.ParseDuration(l.get(st.key, st.def))
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid %s: must be a non-negative duration", st.key)
+		}
+		*st.target = d
+	}
+
+	// Parse JWKS refresh interval
+	jwksRefresh, err := time.ParseDuration(l.get("JWKS_REFRESH_INTERVAL", "15m"))
+	if err != nil || jwksRefresh <= 0 {
+		return nil, fmt.Errorf("invalid JWKS_REFRESH_INTERVAL: must be a positive duration")
+	}
+	config.JWKSRefreshInterval = jwksRefresh
+
+	// Parse validated-token cache TTL ("0" disables)
+	tokenCacheTTL, err := time.ParseDuration(l.get("TOKEN_CACHE_TTL", "30s"))
+	if err != nil || tokenCacheTTL < 0 {
+		return nil, fmt.Errorf("invalid TOKEN_CACHE_TTL: must be a non-negative duration")
+	}
+	config.TokenCacheTTL = tokenCacheTTL
+
+	// Parse deep health check timeout
+	healthTimeout, err := time.ParseDuration(l.get("HEALTH_CHECK_TIMEOUT", "2s"))
+	if err != nil || healthTimeout <= 0 {
+		return nil, fmt.Errorf("invalid HEALTH_CHECK_TIMEOUT: must be a positive duration")
+	}
+	config.HealthCheckTimeout = healthTimeout
+
+	// Parse graceful shutdown timeout
+	shutdownTimeout, err := time.ParseDuration(l.get("SHUTDOWN_TIMEOUT", "30s"))
+	if err != nil || shutdownTimeout <= 0 {
+		return nil, fmt.Errorf("invalid SHUTDOWN_TIMEOUT: must be a positive duration")
+	}
+	config.ShutdownTimeout = shutdownTimeout
+
+	// Parse auth lockout thresholds, WebSocket connection caps, rate limits and body limits
+	thresholds := []struct {
+		key    string
+		def    string
+		target *int
+	}{
+		{"AUTH_LOCKOUT_IP_THRESHOLD", "10", &config.AuthLockoutIPThreshold},
+		{"AUTH_LOCKOUT_USER_THRESHOLD", "0", &config.AuthLockoutUserThreshold},
+		{"WS_MAX_CONNECTIONS_PER_USER", "10", &config.WSMaxConnectionsPerUser},
+		{"WS_MAX_CONNECTIONS", "2000", &config.WSMaxConnections},
+		{"RATE_LIMIT_IP_PER_MINUTE", "1200", &config.RateLimitIPPerMinute},
+		{"RATE_LIMIT_USER_PER_MINUTE", "300", &config.RateLimitUserPerMinute},
+		{"RATE_LIMIT_ADMIN_PER_MINUTE", "60", &config.RateLimitAdminPerMinute},
+		{"MAX_EVENT_BODY_BYTES", "65536", &config.MaxEventBodyBytes},
+		{"MAX_ROSTER_BODY_BYTES", "4194304", &config.MaxRosterBodyBytes},
+		{"HEALTH_MAX_GOROUTINES", "10000", &config.HealthMaxGoroutines},
+		{"SNAPSHOT_HISTORY_SIZE", "300", &config.SnapshotHistorySize},
+		{"INGEST_WORKERS", "0", &config.IngestWorkers},
+		{"STATE_TRANSITION_QUARANTINE", "0", &config.StateTransitionQuarantine},
+	}
+	for _, th := range thresholds {
+		n, err := strconv.Atoi(l.get(th.key, th.def))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid %s: must be a non-negative integer", th.key)
+		}
+		*th.target = n
+	}
+	lockoutDurations := []struct {
+		key    string
+		def    string
+		target *time.Duration
+	}{
+		{"AUTH_LOCKOUT_WINDOW", "5m", &config.AuthLockoutWindow},
+		{"AUTH_LOCKOUT_DURATION", "15m", &config.AuthLockoutDuration},
+		{"STATE_QUARANTINE_PERIOD", "5m", &config.StateQuarantinePeriod},
+	}
+	for _, ld := range lockoutDurations {
+		d, err := time.ParseDuration(l.get(ld.key, ld.def))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid %s: must be a positive duration", ld.key)
+		}
+		*ld.target = d
+	}
+
 	// Trim spaces from allowed origins
 	for i, origin := range config.AllowedOrigins {
 		config.AllowedOrigins[i] = strings.TrimSpace(origin)
 	}
 
+	config.Settings = l.effective()
 	return config, nil
 }
-
-// getEnv gets an environment variable with a fallback default value
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
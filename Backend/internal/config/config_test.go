@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -26,6 +27,9 @@ func TestLoad(t *testing.T) {
 				if cfg.WSReadTimeout != 60*time.Second {
 					t.Errorf("expected WSReadTimeout 60s, got %v", cfg.WSReadTimeout)
 				}
+				if cfg.AgentSimURL != "" {
+					t.Errorf("expected no AgentSim by default, got %q", cfg.AgentSimURL)
+				}
 			},
 		},
 		{
@@ -69,6 +73,129 @@ func TestLoad(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "custom rollup dimensions",
+			env: map[string]string{
+				"ROLLUP_DIMENSIONS": "department, team",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				if len(cfg.RollupDimensions) != 2 {
+					t.Fatalf("expected 2 rollup dimensions, got %d", len(cfg.RollupDimensions))
+				}
+				if cfg.RollupDimensions[0] != "department" || cfg.RollupDimensions[1] != "team" {
+					t.Errorf("unexpected rollup dimensions: %v", cfg.RollupDimensions)
+				}
+			},
+		},
+		{
+			name: "aggregation cadence",
+			env: map[string]string{
+				"AGGREGATION_INTERVAL": "500ms",
+				"LEADERBOARD_INTERVAL": "1m",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.AggregationInterval != 500*time.Millisecond {
+					t.Errorf("expected 500ms aggregation interval, got %v", cfg.AggregationInterval)
+				}
+				if cfg.TrendInterval != 5*time.Second {
+					t.Errorf("expected default 5s trend interval, got %v", cfg.TrendInterval)
+				}
+				if cfg.LeaderboardInterval != time.Minute {
+					t.Errorf("expected 1m leaderboard interval, got %v", cfg.LeaderboardInterval)
+				}
+			},
+		},
+		{
+			name: "invalid AGGREGATION_INTERVAL",
+			env: map[string]string{
+				"AGGREGATION_INTERVAL": "0s",
+			},
+			wantErr: true,
+		},
+		{
+			name: "reconnect grace disabled",
+			env: map[string]string{
+				"RECONNECT_GRACE": "0",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.ReconnectGrace != 0 {
+					t.Errorf("expected no reconnect grace, got %v", cfg.ReconnectGrace)
+				}
+			},
+		},
+		{
+			name: "invalid RECONNECT_GRACE",
+			env: map[string]string{
+				"RECONNECT_GRACE": "-1s",
+			},
+			wantErr: true,
+		},
+		{
+			name: "heartbeat throttle at or above STALE_THRESHOLD",
+			env: map[string]string{
+				"STALE_THRESHOLD":                   "6s",
+				"AGENT_THROTTLE_HEARTBEAT_INTERVAL": "6s",
+			},
+			wantErr: true,
+		},
+		{
+			name: "heartbeat throttle default below a short STALE_THRESHOLD",
+			env: map[string]string{
+				"STALE_THRESHOLD": "3s",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.AgentThrottleHeartbeatInterval != 2*time.Second {
+					t.Errorf("expected a 2s throttle, got %v", cfg.AgentThrottleHeartbeatInterval)
+				}
+			},
+		},
+		{
+			name: "heartbeat throttle in fractions of a second",
+			env: map[string]string{
+				"AGENT_THROTTLE_HEARTBEAT_INTERVAL": "2500ms",
+			},
+			wantErr: true,
+		},
+		{
+			name: "KPI history size out of range",
+			env: map[string]string{
+				"KPI_HISTORY_SIZE": "0",
+			},
+			wantErr: true,
+		},
+		{
+			name: "broadcast load shedding disabled",
+			env: map[string]string{
+				"LOAD_SHED_BROADCAST_LATENCY": "0",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.LoadShedBroadcastLatency != 0 || cfg.LoadShedAggregationLatency != 500*time.Millisecond {
+					t.Errorf("expected only the broadcast trigger disabled, got %v / %v",
+						cfg.LoadShedAggregationLatency, cfg.LoadShedBroadcastLatency)
+				}
+			},
+		},
+		{
+			name: "invalid METRICS_STATSD_FORMAT",
+			env: map[string]string{
+				"METRICS_STATSD_FORMAT": "graphite",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid ROLLUP_DIMENSIONS",
+			env: map[string]string{
+				"ROLLUP_DIMENSIONS": "team,floor",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid BUSINESS_DAY_START",
+			env: map[string]string{
+				"BUSINESS_DAY_START": "25:00",
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -133,3 +260,58 @@ func TestWebSocketConstants(t *testing.T) {
 		t.Errorf("MaxMessageSize should be positive, got %d", cfg.MaxMessageSize)
 	}
 }
+
+func TestLoadConfigFile(t *testing.T) {
+	os.Clearenv()
+	path := filepath.Join(t.TempDir(), "monti.yaml")
+	data := `
+port: 9100
+log_level: debug
+allowed_origins:
+  - http://a.example
+  - http://b.example
+routing_interval: 500ms
+internal_auth_token: s3cret
+dynamo:
+  mode: local
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("LOG_LEVEL", "warn") // env overrides the file
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Port != "9100" || cfg.LogLevel != "warn" || cfg.RoutingInterval != 500*time.Millisecond {
+		t.Errorf("unexpected config: port=%s level=%s routing=%v", cfg.Port, cfg.LogLevel, cfg.RoutingInterval)
+	}
+	if len(cfg.AllowedOrigins) != 2 {
+		t.Errorf("expected 2 origins from list, got %v", cfg.AllowedOrigins)
+	}
+	// Nested keys reach packages that read their own variables
+	if os.Getenv("DYNAMO_MODE") != "local" {
+		t.Errorf("expected DYNAMO_MODE from file, got %q", os.Getenv("DYNAMO_MODE"))
+	}
+
+	sources := map[string]Setting{}
+	for _, s := range cfg.Settings {
+		sources[s.Key] = s
+	}
+	if sources["PORT"].Source != "file" || sources["LOG_LEVEL"].Source != "env" || sources["TREND_INTERVAL"].Source != "default" {
+		t.Errorf("unexpected sources: %+v %+v %+v", sources["PORT"], sources["LOG_LEVEL"], sources["TREND_INTERVAL"])
+	}
+	if sources["INTERNAL_AUTH_TOKEN"].Value != "***" {
+		t.Errorf("expected token to be redacted, got %q", sources["INTERNAL_AUTH_TOKEN"].Value)
+	}
+}
+
+func TestLoadMissingConfigFile(t *testing.T) {
+	os.Clearenv()
+	t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.yaml"))
+	if _, err := Load(); err == nil {
+		t.Error("expected error for missing explicit CONFIG_FILE")
+	}
+}
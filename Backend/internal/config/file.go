@@ -0,0 +1,129 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFile is read when CONFIG_FILE is unset and the file exists
+const defaultConfigFile = "config.yaml"
+
+// Setting is one resolved configuration value and where it came from
+type Setting = types.ConfigSetting
+
+// secretMarkers flag keys whose values are redacted in Settings
+var secretMarkers = []string{"TOKEN", "SECRET", "PASSWORD", "KEY"}
+
+// loadFile reads a YAML config file and flattens it into env-style keys:
+// nested maps join with "_" and are upper-cased (dynamo: {mode: local} -> DYNAMO_MODE),
+// lists become comma-separated values.
+func loadFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	values := make(map[string]string)
+	flatten("", raw, values)
+	return values, nil
+}
+
+func flatten(prefix string, node map[string]any, out map[string]string) {
+	for k, v := range node {
+		key := strings.ToUpper(strings.ReplaceAll(k, "-", "_"))
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+		switch val := v.(type) {
+		case map[string]any:
+			flatten(key, val, out)
+		case []any:
+			parts := make([]string, len(val))
+			for i, item := range val {
+				parts[i] = fmt.Sprint(item)
+			}
+			out[key] = strings.Join(parts, ",")
+		case nil:
+			out[key] = ""
+		default:
+			out[key] = fmt.Sprint(val)
+		}
+	}
+}
+
+// applyFile exports file values as environment variables unless already set, so env
+// always overrides the file and packages reading their own variables see file values too.
+// It returns the keys taken from the file.
+func applyFile() (map[string]bool, error) {
+	path, explicit := os.LookupEnv("CONFIG_FILE")
+	if !explicit {
+		path = defaultConfigFile
+	}
+	values, err := loadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load CONFIG_FILE: %w", err)
+	}
+
+	fromFile := make(map[string]bool)
+	for k, v := range values {
+		if _, set := os.LookupEnv(k); set {
+			continue
+		}
+		os.Setenv(k, v)
+		fromFile[k] = true
+	}
+	return fromFile, nil
+}
+
+// loader resolves settings and records their effective values
+type loader struct {
+	fromFile map[string]bool
+	settings map[string]Setting
+}
+
+// get returns the environment value for key or defaultValue, recording the result
+func (l *loader) get(key, defaultValue string) string {
+	value, source := os.Getenv(key), "env"
+	switch {
+	case value == "":
+		value, source = defaultValue, "default"
+	case l.fromFile[key]:
+		source = "file"
+	}
+	l.settings[key] = Setting{Key: key, Value: value, Source: source}
+	return value
+}
+
+// effective returns the recorded settings sorted by key with secrets redacted
+func (l *loader) effective() []Setting {
+	out := make([]Setting, 0, len(l.settings))
+	for _, s := range l.settings {
+		if s.Value != "" && isSecret(s.Key) {
+			s.Value = "***"
+		}
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+func isSecret(key string) bool {
+	for _, m := range secretMarkers {
+		if strings.Contains(key, m) {
+			return true
+		}
+	}
+	return false
+}
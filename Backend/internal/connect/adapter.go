@@ -0,0 +1,281 @@
+// Package connect ingests Amazon Connect agent event streams, translating them into the
+// register/heartbeat/state_change/call_complete flow used for simulated agents.
+package connect
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/ingestion"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// HeartbeatInterval is how often tracked Connect agents are kept alive. Connect only sends
+// its own HEARTBEAT events every few minutes, well past the stale threshold.
+const HeartbeatInterval = 2 * time.Second
+
+// DefaultHierarchy maps Connect hierarchy levels 1-3 to location, department and team
+var DefaultHierarchy = []string{"location", "department", "team"}
+
+// agentState is the last state the adapter reported for a Connect agent
+type agentState struct {
+	state types.AgentState
+	since time.Time
+}
+
+// Adapter is an ingestion.EventSource fed by Amazon Connect agent events
+type Adapter struct {
+	tracker   *cache.AgentStateTracker
+	hierarchy []string
+	logger    zerolog.Logger
+
+	mu        sync.Mutex
+	processor ingestion.EventProcessor
+	agents    map[string]*agentState // agentID -> last reported state
+}
+
+// NewAdapter creates an Amazon Connect adapter. hierarchy names the field ("location",
+// "department" or "team") each Connect hierarchy level maps to; empty entries are ignored.
+func NewAdapter(tracker *cache.AgentStateTracker, hierarchy []string, logger zerolog.Logger) *Adapter {
+	if len(hierarchy) == 0 {
+		hierarchy = DefaultHierarchy
+	}
+	return &Adapter{
+		tracker:   tracker,
+		hierarchy: hierarchy,
+		logger:    logger.With().Str("component", "connect").Logger(),
+		agents:    make(map[string]*agentState),
+	}
+}
+
+// Start keeps tracked agents alive until ctx is done. Events received before Start are rejected.
+func (a *Adapter) Start(ctx context.Context, processor ingestion.EventProcessor) error {
+	a.mu.Lock()
+	a.processor = processor
+	a.mu.Unlock()
+
+	ticker := time.NewTicker(HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			a.heartbeat()
+		}
+	}
+}
+
+// SendToAgent always fails: Connect agents receive their contacts from Connect, not from MONTI
+func (a *Adapter) SendToAgent(agentID string, message []byte) bool {
+	return false
+}
+
+// AgentCount returns the number of logged-in Connect agents
+func (a *Adapter) AgentCount() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.agents)
+}
+
+// started reports whether Start has been called
+func (a *Adapter) started() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.processor != nil
+}
+
+// heartbeat reports the current state of every tracked agent
+func (a *Adapter) heartbeat() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	for id, agent := range a.agents {
+		a.processor.ProcessHeartbeat(&types.AgentHeartbeat{
+			Type:      "heartbeat",
+			AgentID:   id,
+			State:     agent.state,
+			Timestamp: now,
+		})
+	}
+}
+
+// Process translates one Connect agent event
+func (a *Adapter) Process(e AgentEvent) {
+	id := e.agentID()
+	if id == "" {
+		a.logger.Warn().Str("event_id", e.EventID).Msg("dropped Connect event without agent")
+		return
+	}
+	ts := e.EventTimestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if e.EventType == EventLogout || e.CurrentAgentSnapshot == nil {
+		if _, ok := a.agents[id]; ok {
+			delete(a.agents, id)
+			a.tracker.SetDisconnected(id)
+			a.logger.Debug().Str("agent_id", id).Msg("Connect agent logged out")
+		}
+		return
+	}
+
+	current := e.CurrentAgentSnapshot
+	state := mapState(current)
+	agent, known := a.agents[id]
+	if !known {
+		reg := a.registration(id, current)
+		reg.State = state
+		a.processor.ProcessRegister(reg)
+		a.agents[id] = &agentState{state: state, since: ts}
+		a.logger.Debug().Str("agent_id", id).Str("state", string(state)).Msg("Connect agent registered")
+	} else if agent.state != state {
+		info, _ := a.tracker.Get(id)
+		a.processor.ProcessStateChange(&types.AgentStateChange{
+			Type:          "state_change",
+			AgentID:       id,
+			PreviousState: agent.state,
+			NewState:      state,
+			Timestamp:     ts,
+			StateDuration: ts.Sub(agent.since).Seconds(),
+			Department:    info.Department,
+			Location:      info.Location,
+			Team:          info.Team,
+		})
+		agent.state = state
+		agent.since = ts
+	} else {
+		a.processor.ProcessHeartbeat(&types.AgentHeartbeat{
+			Type:      "heartbeat",
+			AgentID:   id,
+			State:     state,
+			Timestamp: ts,
+		})
+	}
+
+	for _, cc := range completedContacts(id, e.PreviousAgentSnapshot, current, ts) {
+		a.processor.ProcessCallComplete(cc)
+	}
+}
+
+// registration builds the register message from the agent hierarchy, completing missing
+// fields from the roster
+func (a *Adapter) registration(id string, snap *AgentSnapshot) *types.AgentRegister {
+	reg := &types.AgentRegister{Type: "register", AgentID: id}
+	for i, field := range a.hierarchy {
+		group := snap.Configuration.AgentHierarchyGroups[fmt.Sprintf("Level%d", i+1)]
+		if group == nil || group.Name == "" {
+			continue
+		}
+		name := strings.TrimSpace(group.Name)
+		switch field {
+		case "location":
+			if loc := types.Location(strings.ToLower(name)); loc.Valid() {
+				reg.Location = loc
+			}
+		case "department":
+			if dept := types.Department(strings.ToLower(name)); dept.Valid() {
+				reg.Department = dept
+			}
+		case "team":
+			reg.Team = name
+		}
+	}
+
+	if known, ok := a.tracker.Get(id); ok {
+		if reg.Department == "" {
+			reg.Department = known.Department
+		}
+		if reg.Location == "" {
+			reg.Location = known.Location
+		}
+		if reg.Team == "" {
+			reg.Team = known.Team
+		}
+	}
+	return reg
+}
+
+// mapState derives the MONTI state from a Connect snapshot. Contacts take precedence over the
+// selected status, since Connect keeps the status "Available" while the agent is on a contact.
+func mapState(snap *AgentSnapshot) types.AgentState {
+	if strings.EqualFold(snap.AgentStatus.Type, "OFFLINE") {
+		return types.StateOffline
+	}
+
+	best := ""
+	rank := map[string]int{contactIncoming: 1, contactPending: 1, contactConnecting: 1, contactEnded: 2, contactConnected: 3, contactOnHold: 4}
+	for _, c := range snap.Contacts {
+		if rank[c.State] > rank[best] {
+			best = c.State
+		}
+	}
+	switch best {
+	case contactOnHold:
+		return types.StateOnHold
+	case contactConnected:
+		return types.StateOnCall
+	case contactEnded:
+		return types.StateAfterCallWork
+	case contactIncoming, contactPending, contactConnecting:
+		return types.StateBusy
+	}
+
+	if strings.EqualFold(snap.AgentStatus.Type, "ROUTABLE") {
+		return types.StateAvailable
+	}
+	name := strings.ToLower(snap.AgentStatus.Name)
+	for _, s := range []types.AgentState{types.StateLunch, types.StateBreak, types.StateTraining, types.StateMeeting} {
+		if strings.Contains(name, string(s)) {
+			return s
+		}
+	}
+	return types.StateBusy
+}
+
+// completedContacts returns a call_complete for every contact that was connected to the agent
+// and has now ended or left the snapshot
+func completedContacts(agentID string, prev, current *AgentSnapshot, ts time.Time) []*types.CallComplete {
+	if prev == nil {
+		return nil
+	}
+	now := make(map[string]Contact, len(current.Contacts))
+	for _, c := range current.Contacts {
+		now[c.ContactID] = c
+	}
+
+	var done []*types.CallComplete
+	for _, before := range prev.Contacts {
+		if before.ConnectedToAgentTimestamp == nil || before.State == contactEnded {
+			continue
+		}
+		end := ts
+		after, still := now[before.ContactID]
+		if still {
+			if after.State != contactEnded {
+				continue
+			}
+			if after.StateStartTimestamp != nil {
+				end = *after.StateStartTimestamp
+			}
+		}
+		done = append(done, &types.CallComplete{
+			Type:      "call_complete",
+			AgentID:   agentID,
+			CallID:    before.ContactID,
+			TalkTime:  end.Sub(*before.ConnectedToAgentTimestamp).Seconds(),
+			Timestamp: ts,
+		})
+	}
+	return done
+}
@@ -0,0 +1,167 @@
+package connect
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/ingestion"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// recordingProcessor forwards to the default processor and keeps the messages it saw
+type recordingProcessor struct {
+	ingestion.EventProcessor
+	changes   []*types.AgentStateChange
+	completes []*types.CallComplete
+}
+
+func (p *recordingProcessor) ProcessStateChange(sc *types.AgentStateChange) {
+	p.changes = append(p.changes, sc)
+	p.EventProcessor.ProcessStateChange(sc)
+}
+
+func (p *recordingProcessor) ProcessCallComplete(cc *types.CallComplete) {
+	p.completes = append(p.completes, cc)
+}
+
+func newTestAdapter() (*Adapter, *cache.AgentStateTracker, *recordingProcessor) {
+	tracker := cache.NewAgentStateTracker()
+	a := NewAdapter(tracker, nil, zerolog.Nop())
+	p := &recordingProcessor{EventProcessor: ingestion.NewDefaultProcessor(tracker, zerolog.Nop())}
+	a.processor = p
+	return a, tracker, p
+}
+
+func snapshot(statusType string, contacts ...Contact) *AgentSnapshot {
+	return &AgentSnapshot{
+		AgentStatus: AgentStatus{Name: "Available", Type: statusType},
+		Configuration: Configuration{
+			Username: "jdoe",
+			AgentHierarchyGroups: map[string]*HierarchyGroup{
+				"Level1": {Name: "Berlin"},
+				"Level2": {Name: "Sales"},
+				"Level3": {Name: "Sales-Team-1"},
+			},
+		},
+		Contacts: contacts,
+	}
+}
+
+func TestAdapterTranslatesContactLifecycle(t *testing.T) {
+	a, tracker, p := newTestAdapter()
+	start := time.Now().Add(-time.Minute)
+	connected := start.Add(5 * time.Second)
+	ended := start.Add(65 * time.Second)
+
+	idle := snapshot("ROUTABLE")
+	onCall := snapshot("ROUTABLE", Contact{ContactID: "c-1", State: contactConnected, ConnectedToAgentTimestamp: &connected})
+	acw := snapshot("ROUTABLE", Contact{ContactID: "c-1", State: contactEnded, StateStartTimestamp: &ended, ConnectedToAgentTimestamp: &connected})
+
+	a.Process(AgentEvent{EventType: EventLogin, EventTimestamp: start, CurrentAgentSnapshot: idle})
+	info, ok := tracker.Get("jdoe")
+	if !ok {
+		t.Fatal("expected agent to be registered")
+	}
+	if info.Department != types.DeptSales || info.Location != types.LocationBerlin || info.Team != "Sales-Team-1" {
+		t.Errorf("expected hierarchy sales/berlin/Sales-Team-1, got %s/%s/%s", info.Department, info.Location, info.Team)
+	}
+	if info.State != types.StateAvailable {
+		t.Errorf("expected available, got %s", info.State)
+	}
+
+	a.Process(AgentEvent{EventType: EventStateChange, EventTimestamp: connected, CurrentAgentSnapshot: onCall, PreviousAgentSnapshot: idle})
+	a.Process(AgentEvent{EventType: EventStateChange, EventTimestamp: ended, CurrentAgentSnapshot: acw, PreviousAgentSnapshot: onCall})
+
+	if len(p.changes) != 2 || p.changes[0].NewState != types.StateOnCall || p.changes[1].NewState != types.StateAfterCallWork {
+		t.Fatalf("expected on_call then after_call_work, got %+v", p.changes)
+	}
+	if len(p.completes) != 1 || p.completes[0].CallID != "c-1" || p.completes[0].TalkTime != 60 {
+		t.Fatalf("expected one 60s call_complete for c-1, got %+v", p.completes)
+	}
+
+	// The contact leaving the snapshot after ACW must not complete it twice
+	a.Process(AgentEvent{EventType: EventStateChange, EventTimestamp: ended.Add(10 * time.Second), CurrentAgentSnapshot: idle, PreviousAgentSnapshot: acw})
+	if len(p.completes) != 1 {
+		t.Errorf("expected no second call_complete, got %d", len(p.completes))
+	}
+
+	a.Process(AgentEvent{EventType: EventLogout, AgentARN: "arn:aws:connect:eu-central-1:1:instance/i/agent/jdoe"})
+	info, _ = tracker.Get("jdoe")
+	if info.ConnectionStatus != types.StatusDisconnected || a.AgentCount() != 0 {
+		t.Errorf("expected agent disconnected after logout, got %s", info.ConnectionStatus)
+	}
+}
+
+func TestMapStateCustomStatus(t *testing.T) {
+	tests := []struct {
+		status AgentStatus
+		want   types.AgentState
+	}{
+		{AgentStatus{Name: "Lunch Break", Type: "CUSTOM"}, types.StateLunch},
+		{AgentStatus{Name: "Coffee break", Type: "CUSTOM"}, types.StateBreak},
+		{AgentStatus{Name: "Coaching", Type: "CUSTOM"}, types.StateBusy},
+		{AgentStatus{Name: "Offline", Type: "OFFLINE"}, types.StateOffline},
+	}
+	for _, tt := range tests {
+		if got := mapState(&AgentSnapshot{AgentStatus: tt.status}); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.status.Name, tt.want, got)
+		}
+	}
+}
+
+func TestFirehoseHandler(t *testing.T) {
+	a, tracker, _ := newTestAdapter()
+	handler := a.FirehoseHandler("key")
+
+	event, _ := json.Marshal(AgentEvent{EventType: EventLogin, CurrentAgentSnapshot: snapshot("ROUTABLE")})
+	body, _ := json.Marshal(map[string]interface{}{
+		"requestId": "req-1",
+		"records": []map[string]string{
+			{"data": base64.StdEncoding.EncodeToString(event)},
+			{"data": base64.StdEncoding.EncodeToString([]byte("not json"))},
+		},
+	})
+
+	tests := []struct {
+		name   string
+		key    string
+		status int
+	}{
+		{"wrong key", "nope", http.StatusUnauthorized},
+		{"valid key", "key", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/ingest/connect", bytes.NewReader(body))
+		req.Header.Set(AccessKeyHeader, tt.key)
+		req.Header.Set("X-Amz-Firehose-Request-Id", "req-1")
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.status, rec.Code)
+		}
+		var resp firehoseResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.RequestID != "req-1" {
+			t.Errorf("%s: expected response echoing req-1, got %+v (%v)", tt.name, resp, err)
+		}
+	}
+
+	if _, ok := tracker.Get("jdoe"); !ok {
+		t.Error("expected delivered event to register the agent")
+	}
+
+	// The key is checked before the body is decoded
+	req := httptest.NewRequest(http.MethodPost, "/ingest/connect", bytes.NewReader([]byte("not json")))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected an unauthenticated invalid body to be rejected with 401, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,96 @@
+package connect
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Amazon Connect agent event types
+const (
+	EventLogin       = "LOGIN"
+	EventLogout      = "LOGOUT"
+	EventStateChange = "STATE_CHANGE"
+	EventHeartbeat   = "HEARTBEAT"
+)
+
+// Amazon Connect contact states as they appear in agent snapshots
+const (
+	contactIncoming   = "INCOMING"
+	contactPending    = "PENDING"
+	contactConnecting = "CONNECTING"
+	contactConnected  = "CONNECTED"
+	contactOnHold     = "CONNECTED_ONHOLD"
+	contactEnded      = "ENDED"
+)
+
+// AgentEvent is one record of an Amazon Connect agent event stream
+type AgentEvent struct {
+	EventID               string         `json:"EventId"`
+	EventType             string         `json:"EventType"`
+	EventTimestamp        time.Time      `json:"EventTimestamp"`
+	AgentARN              string         `json:"AgentARN"`
+	CurrentAgentSnapshot  *AgentSnapshot `json:"CurrentAgentSnapshot"`
+	PreviousAgentSnapshot *AgentSnapshot `json:"PreviousAgentSnapshot"`
+}
+
+// AgentSnapshot is the agent's status, configuration and contacts at the time of an event
+type AgentSnapshot struct {
+	AgentStatus   AgentStatus   `json:"AgentStatus"`
+	Configuration Configuration `json:"Configuration"`
+	Contacts      []Contact     `json:"Contacts"`
+}
+
+// AgentStatus is the agent status selected in the Connect CCP
+type AgentStatus struct {
+	Name string `json:"Name"`
+	Type string `json:"Type"` // ROUTABLE, CUSTOM or OFFLINE
+}
+
+// Configuration is the agent's Connect user configuration
+type Configuration struct {
+	Username             string                     `json:"Username"`
+	AgentHierarchyGroups map[string]*HierarchyGroup `json:"AgentHierarchyGroups"`
+}
+
+// HierarchyGroup is one level of the agent hierarchy (keys "Level1" to "Level5")
+type HierarchyGroup struct {
+	Name string `json:"Name"`
+}
+
+// Contact is a contact assigned to the agent
+type Contact struct {
+	ContactID                 string     `json:"ContactId"`
+	Channel                   string     `json:"Channel"`
+	State                     string     `json:"State"`
+	StateStartTimestamp       *time.Time `json:"StateStartTimestamp"`
+	ConnectedToAgentTimestamp *time.Time `json:"ConnectedToAgentTimestamp"`
+}
+
+// agentID returns the Connect username, falling back to the user ID at the end of the agent ARN
+func (e *AgentEvent) agentID() string {
+	if e.CurrentAgentSnapshot != nil && e.CurrentAgentSnapshot.Configuration.Username != "" {
+		return e.CurrentAgentSnapshot.Configuration.Username
+	}
+	if i := strings.LastIndex(e.AgentARN, "/"); i >= 0 {
+		return e.AgentARN[i+1:]
+	}
+	return e.AgentARN
+}
+
+// parseRecord decodes a stream record holding a single event or an array of events
+func parseRecord(data []byte) ([]AgentEvent, error) {
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "[") {
+		var events []AgentEvent
+		if err := json.Unmarshal(data, &events); err != nil {
+			return nil, err
+		}
+		return events, nil
+	}
+	var event AgentEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+	return []AgentEvent{event}, nil
+}
@@ -0,0 +1,87 @@
+package connect
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// AccessKeyHeader carries the access key configured on the Firehose HTTP endpoint destination
+const AccessKeyHeader = "X-Amz-Firehose-Access-Key"
+
+// MaxDeliveryBytes bounds a delivery body; the Firehose buffer size must stay below it (default 5 MiB)
+const MaxDeliveryBytes = 8 << 20
+
+// firehoseRequest is the body of a Kinesis Data Firehose HTTP endpoint delivery
+type firehoseRequest struct {
+	RequestID string `json:"requestId"`
+	Timestamp int64  `json:"timestamp"`
+	Records   []struct {
+		Data []byte `json:"data"` // base64 in JSON, one Connect agent event per record
+	} `json:"records"`
+}
+
+// firehoseResponse is the acknowledgement Firehose expects; a non-2xx answer makes it retry
+type firehoseResponse struct {
+	RequestID    string `json:"requestId"`
+	Timestamp    int64  `json:"timestamp"`
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// FirehoseHandler returns the HTTP endpoint for a Firehose delivery stream whose source is the
+// Connect agent event Kinesis stream. Requests must carry accessKey in AccessKeyHeader.
+func (a *Adapter) FirehoseHandler(accessKey string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// The key is checked before the body is read, so unauthenticated callers cannot make
+		// the backend decode deliveries of up to MaxDeliveryBytes
+		requestID := r.Header.Get("X-Amz-Firehose-Request-Id")
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(AccessKeyHeader)), []byte(accessKey)) != 1 {
+			a.logger.Warn().Str("remote_addr", r.RemoteAddr).Msg("rejected Firehose delivery with invalid access key")
+			writeFirehoseResponse(w, http.StatusUnauthorized, requestID, "invalid access key")
+			return
+		}
+		var req firehoseRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeFirehoseResponse(w, http.StatusBadRequest, requestID, "invalid delivery")
+			return
+		}
+		if !a.started() {
+			writeFirehoseResponse(w, http.StatusServiceUnavailable, req.RequestID, "adapter not started")
+			return
+		}
+
+		processed, skipped := 0, 0
+		for _, rec := range req.Records {
+			events, err := parseRecord(rec.Data)
+			if err != nil {
+				// Retrying would not fix a malformed record, so it is dropped rather than failing the batch
+				a.logger.Warn().Err(err).Str("request_id", req.RequestID).Msg("skipped malformed Connect record")
+				skipped++
+				continue
+			}
+			for _, e := range events {
+				a.Process(e)
+				processed++
+			}
+		}
+
+		a.logger.Debug().
+			Str("request_id", req.RequestID).
+			Int("events", processed).
+			Int("skipped", skipped).
+			Msg("processed Firehose delivery")
+		writeFirehoseResponse(w, http.StatusOK, req.RequestID, "")
+	}
+}
+
+// writeFirehoseResponse writes the acknowledgement body Firehose expects
+func writeFirehoseResponse(w http.ResponseWriter, status int, requestID, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(firehoseResponse{
+		RequestID:    requestID,
+		Timestamp:    time.Now().UnixMilli(),
+		ErrorMessage: message,
+	})
+}
@@ -7,12 +7,18 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
 	"github.com/dennisdiepolder/monti/backend/internal/cache"
 	"github.com/dennisdiepolder/monti/backend/internal/metrics"
+	"github.com/dennisdiepolder/monti/backend/internal/tracing"
 	"github.com/dennisdiepolder/monti/backend/internal/types"
 	"github.com/rs/zerolog"
 )
 
+// maxLoggedUnknown bounds the distinct unknown values a permissive receiver logs, so that a
+// sender emitting free-form values cannot grow the set without limit
+const maxLoggedUnknown = 256
+
 // Receiver handles incoming agent events from AgentSim
 type Receiver struct {
 	cache          *cache.EventCache
@@ -21,6 +27,12 @@ type Receiver struct {
 	eventsReceived int64
 	lastReceived   time.Time
 	mu             sync.RWMutex
+
+	// Permissive receivers accept states and locations outside the enums, for senders running
+	// a newer taxonomy; each distinct value is logged once. Departments stay strict: they key
+	// the snapshot and routing.
+	permissive    bool
+	loggedUnknown map[string]bool // "field=value", guarded by mu
 }
 
 // NewReceiver creates a new event receiver
@@ -32,12 +44,21 @@ func NewReceiver(cache *cache.EventCache, stateTracker *cache.AgentStateTracker,
 	}
 }
 
+// SetPermissive makes the receiver accept unknown states and locations instead of rejecting
+// the event. Events without an agent ID or with an unknown department are rejected either way.
+func (r *Receiver) SetPermissive(permissive bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.permissive = permissive
+	r.loggedUnknown = make(map[string]bool)
+}
+
 // HandleEvent receives and caches individual agent events
 func (r *Receiver) HandleEvent(w http.ResponseWriter, req *http.Request) {
 	m := metrics.Get()
 
 	if req.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		apierror.Write(w, req, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
@@ -45,19 +66,39 @@ func (r *Receiver) HandleEvent(w http.ResponseWriter, req *http.Request) {
 	if err := json.NewDecoder(req.Body).Decode(&event); err != nil {
 		r.logger.Error().Err(err).Msg("failed to decode event")
 		m.RecordEventError()
-		http.Error(w, "invalid event", http.StatusBadRequest)
+		apierror.WriteValidation(w, req, "invalid event", nil)
+		return
+	}
+	errs := event.Validate()
+	r.mu.RLock()
+	permissive := r.permissive
+	r.mu.RUnlock()
+	if permissive {
+		var unknown []types.FieldError
+		unknown, errs = types.SplitUnknownValues(errs)
+		unknown, errs = strictDepartment(unknown, errs)
+		if len(errs) == 0 {
+			r.noteUnknown(event.AgentID, unknown)
+		}
+	}
+	if len(errs) > 0 {
+		r.logger.Warn().Str("agent_id", event.AgentID).Interface("fields", errs).Msg("rejected invalid event")
+		m.RecordEventError()
+		apierror.WriteValidation(w, req, "invalid event", errs)
 		return
 	}
 
 	// Record metric
 	m.RecordEventReceived()
 
-	// Add event to cache
-	r.cache.Add(event)
-
 	// Update agent state tracker
 	r.stateTracker.Update(event)
 
+	// Add event to cache, scoped by the tracked agent
+	r.stateTracker.ScopeEvent(&event)
+	r.cache.Add(event)
+	tracing.AddPendingLink(req.Context())
+
 	// Record processed
 	m.RecordEventProcessed()
 
@@ -79,6 +120,27 @@ func (r *Receiver) HandleEvent(w http.ResponseWriter, req *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// noteUnknown counts the unknown values of an accepted event and logs the ones not seen before
+func (r *Receiver) noteUnknown(agentID string, unknown []types.FieldError) {
+	for _, fe := range unknown {
+		metrics.Get().RecordUnknownValue("event", fe.Field)
+		key := fe.Field + "=" + fe.Value
+		r.mu.Lock()
+		first := !r.loggedUnknown[key] && len(r.loggedUnknown) < maxLoggedUnknown
+		if first {
+			r.loggedUnknown[key] = true
+		}
+		r.mu.Unlock()
+		if first {
+			r.logger.Warn().
+				Str("agent_id", agentID).
+				Str("field", fe.Field).
+				Str("value", fe.Value).
+				Msg("accepted unknown value (permissive ingestion)")
+		}
+	}
+}
+
 // GetStats returns receiver statistics
 func (r *Receiver) GetStats(w http.ResponseWriter, req *http.Request) {
 	r.mu.RLock()
@@ -94,3 +156,17 @@ func (r *Receiver) GetStats(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(stats)
 }
+
+// strictDepartment moves an unknown department from the accepted unknown values back to the
+// errors
+func strictDepartment(unknown, errs []types.FieldError) (accepted, rest []types.FieldError) {
+	rest = errs
+	for _, fe := range unknown {
+		if fe.Field == "department" {
+			rest = append(rest, fe)
+		} else {
+			accepted = append(accepted, fe)
+		}
+	}
+	return accepted, rest
+}
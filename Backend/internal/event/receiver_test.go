@@ -0,0 +1,74 @@
+package event
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+func post(r *Receiver, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	r.HandleEvent(rec, httptest.NewRequest(http.MethodPost, "/internal/event", strings.NewReader(body)))
+	return rec
+}
+
+func TestHandleEventRejectsUnknownValues(t *testing.T) {
+	r := NewReceiver(cache.NewEventCache(time.Minute), cache.NewAgentStateTracker(), zerolog.Nop())
+
+	rec := post(r, `{"agentId":"a-1","state":"napping","department":"sales","location":"moon"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+	var resp types.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]types.FieldError{}
+	for _, fe := range resp.Fields {
+		got[fe.Field] = fe
+	}
+	if len(got) != 2 || got["state"].Code != types.FieldUnknownValue || got["state"].Value != "napping" || got["location"].Value != "moon" {
+		t.Errorf("expected unknown state and location, got %+v", resp.Fields)
+	}
+	if _, ok := r.stateTracker.Get("a-1"); ok {
+		t.Error("rejected event reached the tracker")
+	}
+}
+
+func TestHandleEventPermissive(t *testing.T) {
+	r := NewReceiver(cache.NewEventCache(time.Minute), cache.NewAgentStateTracker(), zerolog.Nop())
+	r.SetPermissive(true)
+
+	if rec := post(r, `{"agentId":"a-1","state":"napping","department":"sales","location":"berlin"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for an unknown state, got %d: %s", rec.Code, rec.Body)
+	}
+	if agent, ok := r.stateTracker.Get("a-1"); !ok || agent.State != "napping" {
+		t.Errorf("expected the event to be applied as sent, got %+v", agent)
+	}
+	if !r.loggedUnknown["state=napping"] {
+		t.Error("expected the unknown state to be logged")
+	}
+
+	// A missing agent ID is never accepted
+	if rec := post(r, `{"state":"napping","department":"sales","location":"berlin"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without agentId, got %d", rec.Code)
+	}
+
+	// Nor is an unknown department, which keys the snapshot
+	if rec := post(r, `{"agentId":"a-2","state":"available","department":"billing","location":"berlin"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown department, got %d", rec.Code)
+	}
+	if _, ok := r.stateTracker.Get("a-2"); ok {
+		t.Error("expected the event with an unknown department not to be applied")
+	}
+	if snapshot, _ := r.stateTracker.BuildSnapshot(nil); len(snapshot.Departments[types.DeptSales].Agents) != 1 {
+		t.Errorf("expected the snapshot built with the permissive agent, got %+v", snapshot.Departments)
+	}
+}
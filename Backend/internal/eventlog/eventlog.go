@@ -0,0 +1,313 @@
+// Package eventlog persists every agent message and queue mutation to an append-only
+// JSON-lines file, and replays such a file to rebuild tracker and queue state.
+package eventlog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/ingestion"
+	"github.com/dennisdiepolder/monti/backend/internal/metrics"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// Event types
+const (
+	TypeRegister     = "register"
+	TypeHeartbeat    = "heartbeat"
+	TypeStateChange  = "state_change"
+	TypeCallComplete = "call_complete"
+	TypeCallHold     = "call_hold" // call_hold_start and call_hold_end, told apart by the message's type
+	TypeCallEnqueue  = "call_enqueue"
+	TypeCallAssign   = "call_assign"
+	TypeCallAbandon  = "call_abandon"
+	TypeCallReap     = "call_reap"
+)
+
+const (
+	queueSize     = 10000
+	flushInterval = time.Second
+	// tailSize is how much of an existing log is read to continue its sequence
+	tailSize = 64 * 1024
+)
+
+// Event is one line of the log
+type Event struct {
+	Seq  int64           `json:"seq"`
+	Time time.Time       `json:"ts"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// CallEnqueue is the data of a call_enqueue event
+type CallEnqueue struct {
+	Tenant string       `json:"tenant,omitempty"`
+	VQ     types.VQName `json:"vq"`
+	CallID string       `json:"callId"`
+}
+
+// CallAssign is the data of a call_assign event
+type CallAssign struct {
+	CallID  string `json:"callId"`
+	AgentID string `json:"agentId"`
+}
+
+// CallAbandon is the data of a call_abandon event
+type CallAbandon struct {
+	CallID string `json:"callId"`
+}
+
+// CallReap is the data of a call_reap event
+type CallReap struct {
+	CallID string `json:"callId"`
+	Action string `json:"action"` // requeue or abandon
+}
+
+// Log appends events to a file. Appends never block: lines are queued for a background
+// writer and dropped (with a gap in seq) when the queue is full.
+type Log struct {
+	path   string
+	file   *os.File
+	logger zerolog.Logger
+
+	mu      sync.Mutex // orders seq with the queue
+	seq     int64
+	queue   chan []byte
+	flushes chan chan error
+	closed  bool
+
+	replaying atomic.Bool
+	done      chan struct{}
+}
+
+// Open opens (or creates) the log at path, continuing the sequence of an existing log.
+// Call Start to begin writing.
+func Open(path string, logger zerolog.Logger) (*Log, error) {
+	seq, err := lastSeq(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log: %w", err)
+	}
+	return &Log{
+		path:    path,
+		file:    f,
+		logger:  logger.With().Str("component", "eventlog").Logger(),
+		seq:     seq,
+		queue:   make(chan []byte, queueSize),
+		flushes: make(chan chan error),
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// lastSeq returns the seq of the last complete event in path, or 0 when there is none
+func lastSeq(path string) (int64, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to open event log: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	offset := info.Size() - tailSize
+	if offset < 0 {
+		offset = 0
+	}
+	tail := make([]byte, info.Size()-offset)
+	if _, err := f.ReadAt(tail, offset); err != nil && err != io.EOF {
+		return 0, fmt.Errorf("failed to read event log: %w", err)
+	}
+
+	lines := bytes.Split(bytes.TrimRight(tail, "\n"), []byte("\n"))
+	for i := len(lines) - 1; i >= 0; i-- {
+		var e Event
+		if json.Unmarshal(lines[i], &e) == nil && e.Seq > 0 {
+			return e.Seq, nil
+		}
+	}
+	return 0, nil
+}
+
+// Path returns the file the log writes to
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Start launches the background writer
+func (l *Log) Start() {
+	go l.run()
+}
+
+// Stop stops accepting events and waits for queued lines to be written, giving up when ctx is done
+func (l *Log) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	if !l.closed {
+		l.closed = true
+		close(l.queue)
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-l.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Flush waits until every event appended so far is written to the file
+func (l *Log) Flush() error {
+	reply := make(chan error, 1)
+	select {
+	case l.flushes <- reply:
+		return <-reply
+	case <-l.done:
+		return nil
+	}
+}
+
+// run writes queued lines, flushing the buffer periodically and on request
+func (l *Log) run() {
+	defer close(l.done)
+	w := bufio.NewWriter(l.file)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	write := func(line []byte) {
+		if _, err := w.Write(line); err != nil {
+			l.logger.Error().Err(err).Msg("failed to write event log")
+		}
+	}
+	for {
+		select {
+		case line, ok := <-l.queue:
+			if !ok {
+				if err := w.Flush(); err != nil {
+					l.logger.Error().Err(err).Msg("failed to flush event log")
+				}
+				l.file.Close()
+				return
+			}
+			write(line)
+		case reply := <-l.flushes:
+			// drain what was queued before the request
+			for n := len(l.queue); n > 0; n-- {
+				if line, ok := <-l.queue; ok {
+					write(line)
+				}
+			}
+			reply <- w.Flush()
+		case <-ticker.C:
+			if err := w.Flush(); err != nil {
+				l.logger.Error().Err(err).Msg("failed to flush event log")
+			}
+		}
+	}
+}
+
+// Append queues an event; it is a no-op while a replay is running so replays are not re-recorded
+func (l *Log) Append(eventType string, data interface{}) {
+	if l.replaying.Load() {
+		return
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		l.logger.Error().Err(err).Str("type", eventType).Msg("failed to marshal event")
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return
+	}
+	l.seq++
+	line, err := json.Marshal(Event{Seq: l.seq, Time: time.Now().UTC(), Type: eventType, Data: raw})
+	if err != nil {
+		return
+	}
+	select {
+	case l.queue <- append(line, '\n'):
+		metrics.Get().RecordEventLog(eventType, "written")
+	default:
+		metrics.Get().RecordEventLog(eventType, "dropped")
+		l.logger.Warn().Str("type", eventType).Int64("seq", l.seq).Msg("event log queue full, event dropped")
+	}
+}
+
+// CallEnqueued implements callqueue.CallLog
+func (l *Log) CallEnqueued(tenant string, vq types.VQName, callID string) {
+	if tenant == types.DefaultTenant {
+		tenant = ""
+	}
+	l.Append(TypeCallEnqueue, CallEnqueue{Tenant: tenant, VQ: vq, CallID: callID})
+}
+
+// CallAssigned implements callqueue.CallLog
+func (l *Log) CallAssigned(callID, agentID string) {
+	l.Append(TypeCallAssign, CallAssign{CallID: callID, AgentID: agentID})
+}
+
+// CallAbandoned implements callqueue.CallLog
+func (l *Log) CallAbandoned(callID string) {
+	l.Append(TypeCallAbandon, CallAbandon{CallID: callID})
+}
+
+// CallReaped implements callqueue.CallLog
+func (l *Log) CallReaped(callID, action string) {
+	l.Append(TypeCallReap, CallReap{CallID: callID, Action: action})
+}
+
+// Recorder is an ingestion.EventProcessor that appends every message to the log before
+// passing it on
+type Recorder struct {
+	log  *Log
+	next ingestion.EventProcessor
+}
+
+// NewRecorder records the messages handled by next
+func NewRecorder(log *Log, next ingestion.EventProcessor) *Recorder {
+	return &Recorder{log: log, next: next}
+}
+
+func (r *Recorder) ProcessRegister(reg *types.AgentRegister) {
+	r.log.Append(TypeRegister, reg)
+	r.next.ProcessRegister(reg)
+}
+
+func (r *Recorder) ProcessHeartbeat(hb *types.AgentHeartbeat) {
+	r.log.Append(TypeHeartbeat, hb)
+	r.next.ProcessHeartbeat(hb)
+}
+
+func (r *Recorder) ProcessStateChange(sc *types.AgentStateChange) {
+	r.log.Append(TypeStateChange, sc)
+	r.next.ProcessStateChange(sc)
+}
+
+func (r *Recorder) ProcessCallComplete(cc *types.CallComplete) {
+	r.log.Append(TypeCallComplete, cc)
+	r.next.ProcessCallComplete(cc)
+}
+
+func (r *Recorder) ProcessCallHold(ch *types.CallHold) {
+	r.log.Append(TypeCallHold, ch)
+	r.next.ProcessCallHold(ch)
+}
@@ -0,0 +1,167 @@
+package eventlog
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/callqueue"
+	"github.com/dennisdiepolder/monti/backend/internal/ingestion"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// backend is the tracker, queue and processor a log records from or replays into
+type backend struct {
+	tracker   *cache.AgentStateTracker
+	mgr       *callqueue.CallQueueManager
+	processor *ingestion.DefaultProcessor
+}
+
+func newBackend() *backend {
+	tracker := cache.NewAgentStateTracker()
+	mgr := callqueue.NewCallQueueManager(tracker, zerolog.Nop())
+	processor := ingestion.NewDefaultProcessor(tracker, zerolog.Nop())
+	processor.SetCallCompleter(mgr)
+	return &backend{tracker: tracker, mgr: mgr, processor: processor}
+}
+
+// record runs a short session through a recorded backend: two agents, three calls, one
+// routed and completed, one routed and still active, one waiting
+func record(t *testing.T, path string) *backend {
+	t.Helper()
+	l, err := Open(path, zerolog.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Start()
+	b := newBackend()
+	b.mgr.SetCallLog(l)
+	p := NewRecorder(l, b.processor)
+
+	for _, id := range []string{"agent-1", "agent-2"} {
+		p.ProcessRegister(&types.AgentRegister{AgentID: id, Department: types.DeptSales,
+			Location: types.LocationBerlin, Team: "Team A", State: types.StateAvailable})
+	}
+	b.mgr.EnqueueCall(types.VQSalesInbound, "call-1")
+	b.mgr.EnqueueCall(types.VQSalesInbound, "call-2")
+	if matches := b.mgr.TickRouting(); len(matches) != 2 {
+		t.Fatalf("expected 2 routed calls, got %d", len(matches))
+	}
+	b.mgr.EnqueueCall(types.VQSalesInbound, "call-3")
+	for _, id := range []string{"agent-1", "agent-2"} {
+		p.ProcessStateChange(&types.AgentStateChange{AgentID: id, PreviousState: types.StateAvailable,
+			NewState: types.StateOnCall, Timestamp: time.Now()})
+	}
+	p.ProcessHeartbeat(&types.AgentHeartbeat{AgentID: "agent-1", State: types.StateOnCall, Timestamp: time.Now()})
+	p.ProcessCallComplete(&types.CallComplete{AgentID: "agent-1", CallID: "call-1", TalkTime: 90})
+	p.ProcessStateChange(&types.AgentStateChange{AgentID: "agent-1", PreviousState: types.StateOnCall,
+		NewState: types.StateAfterCallWork, Timestamp: time.Now()})
+
+	if err := l.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestReplayRebuildsTrackerAndQueues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	live := record(t, path)
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	replayed := newBackend()
+	replayed.mgr.PauseRouting(true)
+	stats, err := Replay(f, time.Time{}, replayed.processor, replayed.mgr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Events != 12 || stats.Skipped != 0 || stats.FirstSeq != 1 || stats.LastSeq != 12 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if stats.ByType[TypeCallAssign] != 2 || stats.ByType[TypeCallComplete] != 1 {
+		t.Errorf("unexpected counts by type %v", stats.ByType)
+	}
+
+	for _, id := range []string{"agent-1", "agent-2"} {
+		want, _ := live.tracker.Get(id)
+		got, ok := replayed.tracker.Get(id)
+		if !ok || got.State != want.State {
+			t.Errorf("%s: expected state %s, got %s (found %t)", id, want.State, got.State, ok)
+		}
+	}
+	want := live.mgr.GetSnapshot(types.VQSalesInbound)
+	got := replayed.mgr.GetSnapshot(types.VQSalesInbound)
+	if got.WaitingCount != want.WaitingCount || got.ActiveCount != want.ActiveCount || got.CompletedCount != want.CompletedCount {
+		t.Errorf("expected queue %+v, got %+v", want, got)
+	}
+	if got.WaitingCount != 1 || got.ActiveCount != 1 || got.CompletedCount != 1 {
+		t.Errorf("unexpected replayed queue %+v", got)
+	}
+}
+
+func TestReplayStopsAtUntil(t *testing.T) {
+	log := strings.Join([]string{
+		`{"seq":1,"ts":"2026-01-01T10:00:00Z","type":"register","data":{"agentId":"a1","department":"sales","state":"available"}}`,
+		`not json`,
+		`{"seq":2,"ts":"2026-01-01T10:00:05Z","type":"mystery","data":{}}`,
+		`{"seq":3,"ts":"2026-01-01T10:01:00Z","type":"state_change","data":{"agentId":"a1","previousState":"available","newState":"break"}}`,
+	}, "\n")
+	b := newBackend()
+	until := time.Date(2026, 1, 1, 10, 0, 30, 0, time.UTC)
+	stats, err := Replay(strings.NewReader(log), until, b.processor, b.mgr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Events != 1 || stats.Skipped != 2 || stats.LastSeq != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if a, ok := b.tracker.Get("a1"); !ok || a.State != types.StateAvailable {
+		t.Errorf("expected a1 available, got %+v", a)
+	}
+}
+
+func TestOpenContinuesSequenceAndReplayIsNotRecorded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	record(t, path)
+
+	l, err := Open(path, zerolog.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Start()
+	defer l.Stop(context.Background())
+	b := newBackend()
+	b.mgr.SetCallLog(l)
+
+	// the replay's enqueues and assignments reach the call log but must not be appended
+	if _, err := l.Replay(time.Time{}, b.processor, b.mgr); err != nil {
+		t.Fatal(err)
+	}
+	l.CallAbandoned("call-3")
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	check := newBackend()
+	stats, err := Replay(f, time.Time{}, check.processor, check.mgr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Events != 13 || stats.LastSeq != 13 || stats.ByType[TypeCallAbandon] != 1 {
+		t.Errorf("expected the abandon appended as seq 13 and nothing else, got %+v", stats)
+	}
+}
@@ -0,0 +1,149 @@
+package eventlog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/ingestion"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// maxLineSize bounds a single event line (registers with long skill lists stay far below it)
+const maxLineSize = 1 << 20
+
+// CallQueue is the subset of callqueue.CallQueueManager a replay drives. Completions are
+// replayed through the processor, which forwards them to the queue.
+type CallQueue interface {
+	EnqueueCallContext(ctx context.Context, tenant string, vq types.VQName, callID string) *types.Call
+	AssignCall(callID, agentID string) *types.Call
+	AbandonCall(callID string) *types.Call
+	ReapCall(callID, action string) *types.Call
+}
+
+// ReplayStats summarizes a replay
+type ReplayStats = types.EventLogReplayStats
+
+// Replay applies the events read from r in order, stopping before the first event after until
+// (zero means no limit). Callers reset the tracker and queues first and pause routing, so that
+// assignments come only from the log.
+func Replay(r io.Reader, until time.Time, p ingestion.EventProcessor, q CallQueue) (ReplayStats, error) {
+	stats := ReplayStats{ByType: make(map[string]int)}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(line, &e); err != nil {
+			stats.Skipped++
+			continue
+		}
+		if !until.IsZero() && e.Time.After(until) {
+			break
+		}
+		if err := apply(e, p, q); err != nil {
+			stats.Skipped++
+			continue
+		}
+
+		stats.Events++
+		stats.ByType[e.Type]++
+		if stats.FirstSeq == 0 {
+			stats.FirstSeq = e.Seq
+		}
+		stats.LastSeq = e.Seq
+		t := e.Time
+		stats.LastEvent = &t
+	}
+	if err := scanner.Err(); err != nil {
+		return stats, fmt.Errorf("failed to read event log: %w", err)
+	}
+	return stats, nil
+}
+
+// apply decodes one event and hands it to the processor or queue
+func apply(e Event, p ingestion.EventProcessor, q CallQueue) error {
+	switch e.Type {
+	case TypeRegister:
+		var reg types.AgentRegister
+		if err := json.Unmarshal(e.Data, &reg); err != nil {
+			return err
+		}
+		p.ProcessRegister(&reg)
+	case TypeHeartbeat:
+		var hb types.AgentHeartbeat
+		if err := json.Unmarshal(e.Data, &hb); err != nil {
+			return err
+		}
+		p.ProcessHeartbeat(&hb)
+	case TypeStateChange:
+		var sc types.AgentStateChange
+		if err := json.Unmarshal(e.Data, &sc); err != nil {
+			return err
+		}
+		p.ProcessStateChange(&sc)
+	case TypeCallComplete:
+		var cc types.CallComplete
+		if err := json.Unmarshal(e.Data, &cc); err != nil {
+			return err
+		}
+		p.ProcessCallComplete(&cc)
+	case TypeCallHold:
+		var ch types.CallHold
+		if err := json.Unmarshal(e.Data, &ch); err != nil {
+			return err
+		}
+		p.ProcessCallHold(&ch)
+	case TypeCallEnqueue:
+		var ce CallEnqueue
+		if err := json.Unmarshal(e.Data, &ce); err != nil {
+			return err
+		}
+		q.EnqueueCallContext(context.Background(), ce.Tenant, ce.VQ, ce.CallID)
+	case TypeCallAssign:
+		var ca CallAssign
+		if err := json.Unmarshal(e.Data, &ca); err != nil {
+			return err
+		}
+		q.AssignCall(ca.CallID, ca.AgentID)
+	case TypeCallAbandon:
+		var ab CallAbandon
+		if err := json.Unmarshal(e.Data, &ab); err != nil {
+			return err
+		}
+		q.AbandonCall(ab.CallID)
+	case TypeCallReap:
+		var cr CallReap
+		if err := json.Unmarshal(e.Data, &cr); err != nil {
+			return err
+		}
+		q.ReapCall(cr.CallID, cr.Action)
+	default:
+		return fmt.Errorf("unknown event type %q", e.Type)
+	}
+	return nil
+}
+
+// Replay flushes the log and replays its file; events the replay itself causes are not recorded
+func (l *Log) Replay(until time.Time, p ingestion.EventProcessor, q CallQueue) (ReplayStats, error) {
+	if err := l.Flush(); err != nil {
+		return ReplayStats{}, fmt.Errorf("failed to flush event log: %w", err)
+	}
+	f, err := os.Open(l.path)
+	if err != nil {
+		return ReplayStats{}, fmt.Errorf("failed to open event log: %w", err)
+	}
+	defer f.Close()
+
+	l.replaying.Store(true)
+	defer l.replaying.Store(false)
+	return Replay(f, until, p, q)
+}
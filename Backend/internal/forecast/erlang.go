@@ -0,0 +1,60 @@
+package forecast
+
+import "math"
+
+// maxExtraAgents bounds the search for the required number of agents above the offered load
+const maxExtraAgents = 1000
+
+// ErlangC returns the probability that a call has to wait, given n agents and
+// an offered load of a erlangs. Returns 1 when the queue is unstable (n <= a).
+func ErlangC(n int, a float64) float64 {
+	if a <= 0 {
+		return 0
+	}
+	if float64(n) <= a {
+		return 1
+	}
+	// Erlang B via the stable recursion, then convert to Erlang C
+	b := 1.0
+	for k := 1; k <= n; k++ {
+		b = a * b / (float64(k) + a*b)
+	}
+	return float64(n) * b / (float64(n) - a*(1-b))
+}
+
+// ServiceLevel returns the fraction (0-1) of calls answered within thresholdSecs
+func ServiceLevel(n int, a, ahtSecs, thresholdSecs float64) float64 {
+	if a <= 0 {
+		return 1
+	}
+	if float64(n) <= a {
+		return 0
+	}
+	return 1 - ErlangC(n, a)*math.Exp(-(float64(n)-a)*thresholdSecs/ahtSecs)
+}
+
+// ASA returns the average speed of answer in seconds
+func ASA(n int, a, ahtSecs float64) float64 {
+	if a <= 0 {
+		return 0
+	}
+	if float64(n) <= a {
+		return math.Inf(1)
+	}
+	return ErlangC(n, a) * ahtSecs / (float64(n) - a)
+}
+
+// RequiredAgents returns the smallest number of agents that meets targetSL (0-1)
+// for the offered load, or 0 when there is no load.
+func RequiredAgents(a, ahtSecs, thresholdSecs, targetSL float64) int {
+	if a <= 0 || ahtSecs <= 0 {
+		return 0
+	}
+	n := int(math.Floor(a)) + 1
+	for limit := n + maxExtraAgents; n < limit; n++ {
+		if ServiceLevel(n, a, ahtSecs, thresholdSecs) >= targetSL {
+			break
+		}
+	}
+	return n
+}
@@ -0,0 +1,87 @@
+package forecast
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/callqueue"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+func TestErlangCKnownValues(t *testing.T) {
+	// 10 erlangs offered to 12 agents: P(wait) ~= 0.4494
+	if got := ErlangC(12, 10); math.Abs(got-0.4494) > 0.001 {
+		t.Errorf("ErlangC(12, 10) = %.4f, want ~0.4494", got)
+	}
+	if got := ErlangC(10, 10); got != 1 {
+		t.Errorf("expected unstable queue to always wait, got %.4f", got)
+	}
+}
+
+func TestRequiredAgents(t *testing.T) {
+	// 100 calls per 30 min, 180s AHT => 10 erlangs; 80/20 needs 14 agents
+	a := 100 * 180.0 / 1800
+	n := RequiredAgents(a, 180, 20, 0.8)
+	if n != 14 {
+		t.Errorf("RequiredAgents = %d, want 14", n)
+	}
+	if sl := ServiceLevel(n, a, 180, 20); sl < 0.8 {
+		t.Errorf("SL with %d agents = %.3f, want >= 0.8", n, sl)
+	}
+	if sl := ServiceLevel(n-1, a, 180, 20); sl >= 0.8 {
+		t.Errorf("expected %d agents to miss the target, got SL %.3f", n-1, sl)
+	}
+	if RequiredAgents(0, 180, 20, 0.8) != 0 {
+		t.Error("expected no agents for no load")
+	}
+}
+
+// fakeStats holds the interval history per tenant
+type fakeStats struct {
+	history map[string][]types.IntervalStat
+}
+
+func (f *fakeStats) IntervalHistory(tenant string, vq types.VQName, interval time.Duration, count int) []types.IntervalStat {
+	return f.history[types.TenantOf(tenant)]
+}
+
+func (f *fakeStats) VQConfig(vq types.VQName) (callqueue.VQConfig, bool) {
+	return callqueue.VQConfig{Name: vq, Department: types.DeptSales, SLTarget: 80, SLSeconds: 20}, vq == types.VQSalesInbound
+}
+
+type fakeSchedules int
+
+func (f fakeSchedules) ScheduledAgents(tenant string, dept types.Department, at time.Time) int {
+	return int(f)
+}
+
+func TestStaffingUsesRecentIntervals(t *testing.T) {
+	stats := &fakeStats{history: map[string][]types.IntervalStat{
+		types.DefaultTenant: {
+			{Offered: 90, Handled: 90, AHTSecs: 170},
+			{Offered: 110, Handled: 110, AHTSecs: 190},
+		},
+		"acme": {{Offered: 10, Handled: 10, AHTSecs: 100}},
+	}}
+
+	f, ok := Staffing(stats, fakeSchedules(11), "", types.VQSalesInbound, 30*time.Minute, time.Now())
+	if !ok {
+		t.Fatal("expected forecast for known VQ")
+	}
+	if f.ForecastCalls != 100 || math.Abs(f.AHTSecs-181) > 0.01 {
+		t.Errorf("unexpected volume/AHT: %.1f calls, %.1fs", f.ForecastCalls, f.AHTSecs)
+	}
+	if f.RequiredAgents != 14 || f.ScheduledAgents != 11 {
+		t.Errorf("unexpected staffing: required %d, scheduled %d", f.RequiredAgents, f.ScheduledAgents)
+	}
+
+	// Another tenant's forecast uses only its own traffic
+	if f, _ := Staffing(stats, nil, "acme", types.VQSalesInbound, 30*time.Minute, time.Now()); f.ForecastCalls != 10 {
+		t.Errorf("expected acme's 10 calls, got %.1f", f.ForecastCalls)
+	}
+
+	if _, ok := Staffing(stats, nil, "", types.VQName("nope"), 30*time.Minute, time.Now()); ok {
+		t.Error("expected unknown VQ to be rejected")
+	}
+}
@@ -0,0 +1,70 @@
+package forecast
+
+import (
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/callqueue"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// LookbackIntervals is how many recent intervals the forecast averages
+const LookbackIntervals = 4
+
+// StatsSource provides recent VQ traffic and SL configuration (implemented by callqueue.CallQueueManager)
+type StatsSource interface {
+	IntervalHistory(tenant string, vq types.VQName, interval time.Duration, count int) []types.IntervalStat
+	VQConfig(vq types.VQName) (callqueue.VQConfig, bool)
+}
+
+// ScheduleSource counts agents scheduled to work (implemented by adherence.Service)
+type ScheduleSource interface {
+	ScheduledAgents(tenant string, dept types.Department, at time.Time) int
+}
+
+// Staffing computes the Erlang C requirement for a tenant's VQ's next interval from the
+// average volume and AHT of its last LookbackIntervals intervals.
+func Staffing(stats StatsSource, schedules ScheduleSource, tenant string, vq types.VQName, interval time.Duration, now time.Time) (types.StaffingForecast, bool) {
+	cfg, ok := stats.VQConfig(vq)
+	if !ok {
+		return types.StaffingForecast{}, false
+	}
+
+	start := now.Truncate(interval).Add(interval)
+	result := types.StaffingForecast{
+		VQ:            vq,
+		Department:    cfg.Department,
+		IntervalStart: start,
+		IntervalEnd:   start.Add(interval),
+		TargetSL:      cfg.SLTarget,
+		ThresholdSecs: cfg.SLSeconds,
+		History:       stats.IntervalHistory(tenant, vq, interval, LookbackIntervals),
+	}
+
+	offered, handled := 0, 0
+	handleSecs := 0.0
+	for _, h := range result.History {
+		offered += h.Offered
+		handled += h.Handled
+		handleSecs += h.AHTSecs * float64(h.Handled)
+	}
+	if len(result.History) > 0 {
+		result.ForecastCalls = float64(offered) / float64(len(result.History))
+	}
+	if handled > 0 {
+		result.AHTSecs = handleSecs / float64(handled)
+	}
+	result.TrafficErlangs = result.ForecastCalls * result.AHTSecs / interval.Seconds()
+
+	n := RequiredAgents(result.TrafficErlangs, result.AHTSecs, float64(cfg.SLSeconds), float64(cfg.SLTarget)/100)
+	result.RequiredAgents = n
+	result.ExpectedSL = ServiceLevel(n, result.TrafficErlangs, result.AHTSecs, float64(cfg.SLSeconds)) * 100
+	result.ExpectedASA = ASA(n, result.TrafficErlangs, result.AHTSecs)
+	if n > 0 {
+		result.Occupancy = result.TrafficErlangs / float64(n) * 100
+	}
+
+	if schedules != nil {
+		result.ScheduledAgents = schedules.ScheduledAgents(tenant, cfg.Department, start.Add(interval/2))
+	}
+	return result, true
+}
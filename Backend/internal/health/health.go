@@ -0,0 +1,237 @@
+// Package health runs the deep health and readiness checks behind /health?verbose=1 and /ready.
+// A failing critical check takes the instance out of rotation; a failing non-critical check
+// only marks it degraded.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Check result states
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+// Check is a single named probe. Run returns nil when the dependency is healthy.
+type Check struct {
+	Name     string
+	Critical bool
+	Run      func(ctx context.Context) error
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Name       string  `json:"name"`
+	Status     string  `json:"status"`
+	Critical   bool    `json:"critical"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"durationMs"`
+}
+
+// Report is the aggregated outcome of all checks
+type Report struct {
+	Status  string   `json:"status"`
+	Service string   `json:"service"`
+	Checks  []Result `json:"checks,omitempty"`
+}
+
+// Checker runs registered checks concurrently, each bounded by a timeout
+type Checker struct {
+	service string
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []Check
+}
+
+// NewChecker creates a Checker; timeout bounds each individual check
+func NewChecker(service string, timeout time.Duration) *Checker {
+	return &Checker{service: service, timeout: timeout}
+}
+
+// Register adds a check
+func (c *Checker) Register(check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, check)
+}
+
+// Run executes all checks and returns the report in registration order
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]Check(nil), c.checks...)
+	c.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = c.runOne(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Service: c.service, Checks: results}
+	for _, r := range results {
+		if r.Status == StatusOK {
+			continue
+		}
+		if r.Critical {
+			report.Status = StatusDown
+			break
+		}
+		report.Status = StatusDegraded
+	}
+	return report
+}
+
+func (c *Checker) runOne(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() { errCh <- check.Run(ctx) }()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", c.timeout)
+	}
+
+	r := Result{
+		Name:       check.Name,
+		Status:     StatusOK,
+		Critical:   check.Critical,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		r.Status = StatusDown
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// HealthHandler serves /health: liveness answers plain requests, the full report answers
+// ?verbose=1 (with 503 when a critical check fails)
+func (c *Checker) HealthHandler(liveness http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if v := r.URL.Query().Get("verbose"); v == "" || v == "0" || v == "false" {
+			liveness(w, r)
+			return
+		}
+		report := c.Run(r.Context())
+		writeReport(w, statusCode(report), report)
+	}
+}
+
+// ReadyHandler serves /ready for load balancers and orchestrators: 200 while the instance can
+// take traffic, 503 once a critical check fails
+func (c *Checker) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	report := c.Run(r.Context())
+	writeReport(w, statusCode(report), report)
+}
+
+func statusCode(report Report) int {
+	if report.Status == StatusDown {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+func writeReport(w http.ResponseWriter, code int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(report)
+}
+
+// Pinger is implemented by dependencies that can verify their own connectivity
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// PingCheck wraps a Pinger
+func PingCheck(name string, critical bool, p Pinger) Check {
+	return Check{Name: name, Critical: critical, Run: p.Ping}
+}
+
+// HTTPCheck succeeds when a GET to url answers with a non-5xx status
+func HTTPCheck(name string, critical bool, client *http.Client, url string) Check {
+	return Check{
+		Name:     name,
+		Critical: critical,
+		Run: func(ctx context.Context) error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return err
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+			if resp.StatusCode >= http.StatusInternalServerError {
+				return fmt.Errorf("%s answered %d", url, resp.StatusCode)
+			}
+			return nil
+		},
+	}
+}
+
+// FreshnessCheck fails when lastUpdate is zero or older than maxAge
+func FreshnessCheck(name string, critical bool, lastUpdate func() time.Time, maxAge time.Duration) Check {
+	return Check{
+		Name:     name,
+		Critical: critical,
+		Run: func(context.Context) error {
+			last := lastUpdate()
+			if last.IsZero() {
+				return fmt.Errorf("never updated")
+			}
+			if age := time.Since(last); age > maxAge {
+				return fmt.Errorf("last updated %s ago (max %s)", age.Round(time.Second), maxAge)
+			}
+			return nil
+		},
+	}
+}
+
+// BacklogCheck fails when a queue is filled beyond maxFill (0..1) of its capacity
+func BacklogCheck(name string, critical bool, depth func() (length, capacity int), maxFill float64) Check {
+	return Check{
+		Name:     name,
+		Critical: critical,
+		Run: func(context.Context) error {
+			length, capacity := depth()
+			if capacity > 0 && float64(length) > float64(capacity)*maxFill {
+				return fmt.Errorf("backlog %d/%d", length, capacity)
+			}
+			return nil
+		},
+	}
+}
+
+// GoroutineCheck fails when the process runs more than max goroutines (0 disables)
+func GoroutineCheck(max int) Check {
+	return Check{
+		Name: "goroutines",
+		Run: func(context.Context) error {
+			if n := runtime.NumGoroutine(); max > 0 && n > max {
+				return fmt.Errorf("%d goroutines (max %d)", n, max)
+			}
+			return nil
+		},
+	}
+}
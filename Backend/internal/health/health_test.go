@@ -0,0 +1,103 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRunAggregatesStatus(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	fail := func(context.Context) error { return errors.New("boom") }
+
+	tests := []struct {
+		name   string
+		checks []Check
+		want   string
+	}{
+		{"all ok", []Check{{Name: "a", Critical: true, Run: ok}}, StatusOK},
+		{"non-critical fails", []Check{{Name: "a", Critical: true, Run: ok}, {Name: "b", Run: fail}}, StatusDegraded},
+		{"critical fails", []Check{{Name: "a", Critical: true, Run: fail}, {Name: "b", Run: fail}}, StatusDown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChecker("test", time.Second)
+			for _, check := range tt.checks {
+				c.Register(check)
+			}
+			if got := c.Run(context.Background()).Status; got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRunTimesOutHungCheck(t *testing.T) {
+	c := NewChecker("test", 20*time.Millisecond)
+	block := make(chan struct{})
+	defer close(block)
+	c.Register(Check{Name: "hung", Critical: true, Run: func(context.Context) error {
+		<-block
+		return nil
+	}})
+
+	report := c.Run(context.Background())
+	if report.Status != StatusDown || report.Checks[0].Error == "" {
+		t.Fatalf("expected hung check to fail, got %+v", report)
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	c := NewChecker("test", time.Second)
+	c.Register(Check{Name: "db", Critical: true, Run: func(context.Context) error { return errors.New("unreachable") }})
+	liveness := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	rec := httptest.NewRecorder()
+	c.HealthHandler(liveness)(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("plain /health should stay live, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	c.HealthHandler(liveness)(rec, httptest.NewRequest(http.MethodGet, "/health?verbose=1", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("verbose /health expected 503, got %d", rec.Code)
+	}
+	var report Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("failed to parse report: %v", err)
+	}
+	if len(report.Checks) != 1 || report.Checks[0].Error != "unreachable" {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	rec = httptest.NewRecorder()
+	c.ReadyHandler(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("/ready expected 503, got %d", rec.Code)
+	}
+}
+
+func TestFreshnessAndBacklogChecks(t *testing.T) {
+	stale := FreshnessCheck("jwks", true, func() time.Time { return time.Now().Add(-time.Hour) }, time.Minute)
+	if stale.Run(context.Background()) == nil {
+		t.Error("expected stale timestamp to fail")
+	}
+	fresh := FreshnessCheck("jwks", true, time.Now, time.Minute)
+	if err := fresh.Run(context.Background()); err != nil {
+		t.Errorf("expected fresh timestamp to pass, got %v", err)
+	}
+
+	full := BacklogCheck("q", false, func() (int, int) { return 90, 100 }, 0.8)
+	if full.Run(context.Background()) == nil {
+		t.Error("expected full backlog to fail")
+	}
+	light := BacklogCheck("q", false, func() (int, int) { return 10, 100 }, 0.8)
+	if err := light.Run(context.Background()); err != nil {
+		t.Errorf("expected light backlog to pass, got %v", err)
+	}
+}
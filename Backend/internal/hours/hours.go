@@ -0,0 +1,226 @@
+// Package hours holds the business hours and holiday calendar: a department is open during
+// its weekly opening hours unless the day is one of its holidays. Service level is only
+// tracked while a department is open, and AgentSim drops simulated volume while it is closed.
+package hours
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// weekdays are the keys of a Week, indexed by time.Weekday
+var weekdays = [7]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Interval is one opening period of a day, as "HH:MM" wall-clock times in the calendar's
+// time zone. Close may be "24:00" to stay open until midnight.
+type Interval struct {
+	Open  string `json:"open"`
+	Close string `json:"close"`
+
+	open, close int // minutes since midnight
+}
+
+// Week maps weekdays ("mon" .. "sun") to their opening intervals; missing days are closed
+type Week map[string][]Interval
+
+// Holiday closes all departments, or only the listed ones, for a day
+type Holiday struct {
+	Date        string             `json:"date"` // YYYY-MM-DD
+	Name        string             `json:"name,omitempty"`
+	Departments []types.Department `json:"departments,omitempty"` // empty = all departments
+}
+
+// Calendar is the business hours file (BUSINESS_HOURS_FILE):
+//
+//	{
+//	  "timezone": "Europe/Berlin",
+//	  "default": {"mon": [{"open": "08:00", "close": "18:00"}], ...},
+//	  "departments": {"support": {"sat": [{"open": "09:00", "close": "13:00"}], ...}},
+//	  "holidays": [{"date": "2026-12-25", "name": "Christmas"}]
+//	}
+//
+// A department entry replaces the default week for that department. Without a default
+// week departments that have no entry are always open (outside holidays).
+type Calendar struct {
+	Timezone    string                    `json:"timezone,omitempty"` // IANA name; empty = UTC
+	Default     Week                      `json:"default,omitempty"`
+	Departments map[types.Department]Week `json:"departments,omitempty"`
+	Holidays    []Holiday                 `json:"holidays,omitempty"`
+
+	loc *time.Location
+}
+
+// AlwaysOpen returns a calendar under which every department is always open
+func AlwaysOpen() *Calendar {
+	return &Calendar{loc: time.UTC}
+}
+
+// LoadFile reads and validates a business hours file
+func LoadFile(path string) (*Calendar, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read business hours: %w", err)
+	}
+	var c Calendar
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse business hours: %w", err)
+	}
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// init resolves the time zone and validates the weeks and holidays
+func (c *Calendar) init() error {
+	c.loc = time.UTC
+	if c.Timezone != "" {
+		loc, err := time.LoadLocation(c.Timezone)
+		if err != nil {
+			return fmt.Errorf("invalid timezone %q: %w", c.Timezone, err)
+		}
+		c.loc = loc
+	}
+	if err := c.Default.init(); err != nil {
+		return fmt.Errorf("default: %w", err)
+	}
+	for dept, week := range c.Departments {
+		if !dept.Valid() {
+			return fmt.Errorf("unknown department %q", dept)
+		}
+		if err := week.init(); err != nil {
+			return fmt.Errorf("%s: %w", dept, err)
+		}
+	}
+	for i, h := range c.Holidays {
+		if _, err := time.Parse("2006-01-02", h.Date); err != nil {
+			return fmt.Errorf("holiday %d: date must be YYYY-MM-DD", i+1)
+		}
+		for _, dept := range h.Departments {
+			if !dept.Valid() {
+				return fmt.Errorf("holiday %d: unknown department %q", i+1, dept)
+			}
+		}
+	}
+	return nil
+}
+
+// init parses the intervals' wall-clock times
+func (w Week) init() error {
+	for day, intervals := range w {
+		if !validDay(day) {
+			return fmt.Errorf("invalid weekday %q (use %s)", day, strings.Join(weekdays[:], ", "))
+		}
+		for i := range intervals {
+			iv := &intervals[i]
+			var err error
+			if iv.open, err = parseClock(iv.Open); err != nil {
+				return fmt.Errorf("%s: open: %w", day, err)
+			}
+			if iv.close, err = parseClock(iv.Close); err != nil {
+				return fmt.Errorf("%s: close: %w", day, err)
+			}
+			if iv.close <= iv.open {
+				return fmt.Errorf("%s: close %s must be after open %s", day, iv.Close, iv.Open)
+			}
+		}
+	}
+	return nil
+}
+
+func validDay(day string) bool {
+	for _, d := range weekdays {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock parses "HH:MM" (00:00 to 24:00) into minutes since midnight
+func parseClock(s string) (int, error) {
+	var h, m int
+	if n, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil || n != 2 || len(s) != 5 {
+		return 0, fmt.Errorf("%q must be HH:MM", s)
+	}
+	if h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("%q is not a time of day", s)
+	}
+	return h*60 + m, nil
+}
+
+// week returns the opening hours of dept, nil when it has none (always open)
+func (c *Calendar) week(dept types.Department) Week {
+	if week, ok := c.Departments[dept]; ok {
+		return week
+	}
+	return c.Default
+}
+
+// Holiday returns the holiday closing dept on the day of t
+func (c *Calendar) Holiday(dept types.Department, t time.Time) (Holiday, bool) {
+	date := t.In(c.location()).Format("2006-01-02")
+	for _, h := range c.Holidays {
+		if h.Date != date {
+			continue
+		}
+		if len(h.Departments) == 0 {
+			return h, true
+		}
+		for _, d := range h.Departments {
+			if d == dept {
+				return h, true
+			}
+		}
+	}
+	return Holiday{}, false
+}
+
+// IsOpen reports whether dept is open at t
+func (c *Calendar) IsOpen(dept types.Department, t time.Time) bool {
+	if _, ok := c.Holiday(dept, t); ok {
+		return false
+	}
+	week := c.week(dept)
+	if week == nil {
+		return true
+	}
+	local := t.In(c.location())
+	minute := local.Hour()*60 + local.Minute()
+	for _, iv := range week[weekdays[local.Weekday()]] {
+		if minute >= iv.open && minute < iv.close {
+			return true
+		}
+	}
+	return false
+}
+
+// Status returns every department's opening status at now, sorted by department
+func (c *Calendar) Status(now time.Time) []types.BusinessHoursStatus {
+	out := make([]types.BusinessHoursStatus, 0, len(types.AllDepartments))
+	for _, dept := range types.AllDepartments {
+		status := types.BusinessHoursStatus{Department: dept, Open: c.IsOpen(dept, now)}
+		if h, ok := c.Holiday(dept, now); ok {
+			status.Holiday = h.Name
+			if status.Holiday == "" {
+				status.Holiday = h.Date
+			}
+		}
+		out = append(out, status)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Department < out[j].Department })
+	return out
+}
+
+func (c *Calendar) location() *time.Location {
+	if c.loc == nil {
+		return time.UTC
+	}
+	return c.loc
+}
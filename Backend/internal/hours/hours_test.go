@@ -0,0 +1,84 @@
+package hours
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+func loadCalendar(t *testing.T, body string) (*Calendar, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "hours.json")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return LoadFile(path)
+}
+
+func TestIsOpen(t *testing.T) {
+	c, err := loadCalendar(t, `{
+		"timezone": "Europe/Berlin",
+		"default": {"mon": [{"open": "08:00", "close": "12:00"}, {"open": "13:00", "close": "18:00"}]},
+		"departments": {"support": {"mon": [{"open": "00:00", "close": "24:00"}]}},
+		"holidays": [{"date": "2026-03-09", "name": "Closure", "departments": ["sales"]}]
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 3, day, hour, minute, 0, 0, berlin).UTC()
+	}
+
+	cases := []struct {
+		dept types.Department
+		t    time.Time
+		want bool
+	}{
+		{types.DeptSales, at(2, 8, 0), true},    // Monday, opening minute
+		{types.DeptSales, at(2, 12, 30), false}, // lunch break
+		{types.DeptSales, at(2, 18, 0), false},  // closing minute
+		{types.DeptSales, at(3, 10, 0), false},  // Tuesday has no hours
+		{types.DeptSupport, at(2, 23, 59), true},
+		{types.DeptSupport, at(3, 10, 0), false}, // department week replaces the default
+		{types.DeptSales, at(9, 10, 0), false},   // holiday
+		{types.DeptTechnical, at(9, 10, 0), true},
+	}
+	for _, tc := range cases {
+		if got := c.IsOpen(tc.dept, tc.t); got != tc.want {
+			t.Errorf("%s at %s: expected open=%v, got %v", tc.dept, tc.t.In(berlin), tc.want, got)
+		}
+	}
+
+	for _, s := range c.Status(at(9, 10, 0)) {
+		if s.Department == types.DeptSales && (s.Open || s.Holiday != "Closure") {
+			t.Errorf("expected sales closed for Closure, got %+v", s)
+		}
+	}
+}
+
+func TestAlwaysOpen(t *testing.T) {
+	c := AlwaysOpen()
+	if !c.IsOpen(types.DeptSales, time.Date(2026, 12, 25, 3, 0, 0, 0, time.UTC)) {
+		t.Error("expected the default calendar to be always open")
+	}
+}
+
+func TestLoadFileValidates(t *testing.T) {
+	for _, body := range []string{
+		`{"timezone": "Mars/Olympus"}`,
+		`{"default": {"monday": [{"open": "08:00", "close": "18:00"}]}}`,
+		`{"default": {"mon": [{"open": "8:00", "close": "18:00"}]}}`,
+		`{"default": {"mon": [{"open": "18:00", "close": "08:00"}]}}`,
+		`{"default": {"mon": [{"open": "08:00", "close": "24:30"}]}}`,
+		`{"departments": {"billing": {}}}`,
+		`{"holidays": [{"date": "25.12.2026"}]}`,
+	} {
+		if _, err := loadCalendar(t, body); err == nil {
+			t.Errorf("expected %s to be rejected", body)
+		}
+	}
+}
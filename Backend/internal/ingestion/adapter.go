@@ -0,0 +1,156 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// SourceAdapter is a vendor ACD integration (Genesys, Avaya, ...) that feeds agent events into
+// the processor. Adapters are registered by type and configured via INGESTION_ADAPTERS_FILE,
+// so new integrations need no changes to the hub or processor.
+type SourceAdapter interface {
+	// Name identifies the adapter instance in logs
+	Name() string
+
+	// Start emits register/heartbeat/state_change/call_complete events to the processor.
+	// It blocks until ctx is done, Stop is called or the source fails.
+	Start(ctx context.Context, processor EventProcessor) error
+
+	// Stop ends a running Start and releases the connection to the ACD
+	Stop() error
+}
+
+// AdapterFactory builds an adapter from its settings in the adapters file
+type AdapterFactory func(spec AdapterSpec, logger zerolog.Logger) (SourceAdapter, error)
+
+// AdapterSpec configures one adapter instance
+type AdapterSpec struct {
+	Type     string            `json:"type"`
+	Name     string            `json:"name"`
+	Settings map[string]string `json:"settings"`
+}
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]AdapterFactory)
+)
+
+// RegisterAdapter makes an adapter type available to the loader, typically from the adapter
+// package's init. Registering a type twice panics.
+func RegisterAdapter(kind string, factory AdapterFactory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if _, dup := factories[kind]; dup {
+		panic("ingestion: adapter type registered twice: " + kind)
+	}
+	factories[kind] = factory
+}
+
+// AdapterTypes returns the registered adapter types
+func AdapterTypes() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	kinds := make([]string, 0, len(factories))
+	for kind := range factories {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// LoadAdapterFile reads a JSON array of adapter specs and builds the adapters
+func LoadAdapterFile(path string, logger zerolog.Logger) ([]SourceAdapter, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ingestion adapters: %w", err)
+	}
+	var specs []AdapterSpec
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return nil, fmt.Errorf("failed to parse ingestion adapters: %w", err)
+	}
+	return NewAdapters(specs, logger)
+}
+
+// NewAdapters builds adapters from their specs. Unnamed adapters are named after their type.
+func NewAdapters(specs []AdapterSpec, logger zerolog.Logger) ([]SourceAdapter, error) {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	adapters := make([]SourceAdapter, 0, len(specs))
+	seen := make(map[string]bool)
+	for i, spec := range specs {
+		factory, ok := factories[spec.Type]
+		if !ok {
+			return nil, fmt.Errorf("adapter %d: unknown type %q", i, spec.Type)
+		}
+		if spec.Name == "" {
+			spec.Name = spec.Type
+		}
+		if seen[spec.Name] {
+			return nil, fmt.Errorf("adapter %d: duplicate name %q", i, spec.Name)
+		}
+		seen[spec.Name] = true
+
+		adapter, err := factory(spec, logger.With().Str("component", "ingestion").Str("adapter", spec.Name).Logger())
+		if err != nil {
+			return nil, fmt.Errorf("adapter %q: %w", spec.Name, err)
+		}
+		adapters = append(adapters, adapter)
+	}
+	return adapters, nil
+}
+
+// Runner starts adapters in the background and stops them on shutdown
+type Runner struct {
+	adapters []SourceAdapter
+	logger   zerolog.Logger
+	wg       sync.WaitGroup
+}
+
+// NewRunner creates a runner for the given adapters
+func NewRunner(adapters []SourceAdapter, logger zerolog.Logger) *Runner {
+	return &Runner{adapters: adapters, logger: logger}
+}
+
+// Start runs every adapter in its own goroutine. An adapter that fails is logged and not restarted.
+func (r *Runner) Start(ctx context.Context, processor EventProcessor) {
+	for _, adapter := range r.adapters {
+		r.wg.Add(1)
+		go func(a SourceAdapter) {
+			defer r.wg.Done()
+			r.logger.Info().Str("adapter", a.Name()).Msg("ingestion adapter started")
+			if err := a.Start(ctx, processor); err != nil && ctx.Err() == nil {
+				r.logger.Error().Err(err).Str("adapter", a.Name()).Msg("ingestion adapter failed")
+				return
+			}
+			r.logger.Info().Str("adapter", a.Name()).Msg("ingestion adapter stopped")
+		}(adapter)
+	}
+}
+
+// Stop stops every adapter and waits for them to return, bounded by ctx
+func (r *Runner) Stop(ctx context.Context) error {
+	for _, adapter := range r.adapters {
+		if err := adapter.Stop(); err != nil {
+			r.logger.Warn().Err(err).Str("adapter", adapter.Name()).Msg("failed to stop ingestion adapter")
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -12,6 +12,7 @@ type EventProcessor interface {
 	ProcessHeartbeat(hb *types.AgentHeartbeat)
 	ProcessStateChange(sc *types.AgentStateChange)
 	ProcessCallComplete(cc *types.CallComplete)
+	ProcessCallHold(ch *types.CallHold)
 }
 
 // EventSource represents a source of agent events (AgentHub, Genesys adapter, etc.)
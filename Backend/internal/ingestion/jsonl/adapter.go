@@ -0,0 +1,162 @@
+// Package jsonl is the reference ingestion.SourceAdapter: it reads agent messages in the
+// /ws/agent protocol, one JSON object per line, from a file. Vendor adapters follow the same
+// shape: parse settings in the factory, translate vendor events in Start, register in init.
+package jsonl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/ingestion"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// Type is the adapter type used in INGESTION_ADAPTERS_FILE
+const Type = "jsonl"
+
+// pollInterval is how often a followed file is checked for new lines
+const pollInterval = 500 * time.Millisecond
+
+func init() {
+	ingestion.RegisterAdapter(Type, New)
+}
+
+// Adapter replays or follows a JSON-lines file of agent messages
+type Adapter struct {
+	name   string
+	path   string
+	follow bool
+	logger zerolog.Logger
+
+	mu   sync.Mutex
+	stop context.CancelFunc
+}
+
+// New creates the adapter. Settings: "path" (required) and "follow" ("true" keeps reading
+// lines appended to the file, like tail -f).
+func New(spec ingestion.AdapterSpec, logger zerolog.Logger) (ingestion.SourceAdapter, error) {
+	path := spec.Settings["path"]
+	if path == "" {
+		return nil, errors.New("setting \"path\" is required")
+	}
+	return &Adapter{
+		name:   spec.Name,
+		path:   path,
+		follow: spec.Settings["follow"] == "true",
+		logger: logger,
+	}, nil
+}
+
+// Name returns the configured adapter name
+func (a *Adapter) Name() string {
+	return a.name
+}
+
+// Start reads the file, forwarding each message to the processor
+func (a *Adapter) Start(ctx context.Context, processor ingestion.EventProcessor) error {
+	f, err := os.Open(a.path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", a.path, err)
+	}
+	defer f.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	a.mu.Lock()
+	a.stop = cancel
+	a.mu.Unlock()
+	defer cancel()
+
+	reader := bufio.NewReader(f)
+	var partial []byte
+	lines := 0
+	for {
+		chunk, err := reader.ReadBytes('\n')
+		partial = append(partial, chunk...)
+		if err == nil {
+			lines++
+			a.dispatch(partial, lines, processor)
+			partial = nil
+			continue
+		}
+		if !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to read %s: %w", a.path, err)
+		}
+		if !a.follow {
+			if len(partial) > 0 {
+				lines++
+				a.dispatch(partial, lines, processor)
+			}
+			a.logger.Info().Int("lines", lines).Msg("finished replaying file")
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// Stop ends a running Start
+func (a *Adapter) Stop() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.stop != nil {
+		a.stop()
+	}
+	return nil
+}
+
+// dispatch decodes one line by its "type" and hands it to the processor
+func (a *Adapter) dispatch(line []byte, n int, processor ingestion.EventProcessor) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return
+	}
+	var envelope struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(line, &envelope); err != nil {
+		a.logger.Warn().Err(err).Int("line", n).Msg("skipped malformed line")
+		return
+	}
+
+	var err error
+	switch envelope.Type {
+	case "register":
+		var reg types.AgentRegister
+		if err = json.Unmarshal(line, &reg); err == nil {
+			processor.ProcessRegister(&reg)
+		}
+	case "heartbeat":
+		var hb types.AgentHeartbeat
+		if err = json.Unmarshal(line, &hb); err == nil {
+			processor.ProcessHeartbeat(&hb)
+		}
+	case "state_change":
+		var sc types.AgentStateChange
+		if err = json.Unmarshal(line, &sc); err == nil {
+			processor.ProcessStateChange(&sc)
+		}
+	case "call_complete":
+		var cc types.CallComplete
+		if err = json.Unmarshal(line, &cc); err == nil {
+			processor.ProcessCallComplete(&cc)
+		}
+	default:
+		a.logger.Warn().Str("type", envelope.Type).Int("line", n).Msg("skipped unknown message type")
+	}
+	if err != nil {
+		a.logger.Warn().Err(err).Int("line", n).Msg("skipped malformed line")
+	}
+}
@@ -0,0 +1,66 @@
+package jsonl
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/ingestion"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+func TestReplayThroughLoader(t *testing.T) {
+	dir := t.TempDir()
+	events := filepath.Join(dir, "events.jsonl")
+	data := `{"type":"register","agentId":"agent-1","department":"sales","location":"berlin","team":"Sales-Team-1","state":"available"}
+
+not json
+{"type":"state_change","agentId":"agent-1","previousState":"available","newState":"on_call","department":"sales","location":"berlin","team":"Sales-Team-1"}
+{"type":"unknown"}`
+	if err := os.WriteFile(events, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	specs := filepath.Join(dir, "adapters.json")
+	if err := os.WriteFile(specs, []byte(`[{"type":"jsonl","name":"replay","settings":{"path":"`+events+`"}}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	adapters, err := ingestion.LoadAdapterFile(specs, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if len(adapters) != 1 || adapters[0].Name() != "replay" {
+		t.Fatalf("expected one adapter named replay, got %v", adapters)
+	}
+
+	tracker := cache.NewAgentStateTracker()
+	if err := adapters[0].Start(context.Background(), ingestion.NewDefaultProcessor(tracker, zerolog.Nop())); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	info, ok := tracker.Get("agent-1")
+	if !ok || info.State != types.StateOnCall || info.Team != "Sales-Team-1" {
+		t.Errorf("expected agent-1 on_call in Sales-Team-1, got %+v", info)
+	}
+}
+
+func TestNewAdaptersRejectsBadSpecs(t *testing.T) {
+	tests := []struct {
+		name  string
+		specs []ingestion.AdapterSpec
+	}{
+		{"unknown type", []ingestion.AdapterSpec{{Type: "avaya"}}},
+		{"missing path", []ingestion.AdapterSpec{{Type: Type}}},
+		{"duplicate name", []ingestion.AdapterSpec{
+			{Type: Type, Settings: map[string]string{"path": "a"}},
+			{Type: Type, Settings: map[string]string{"path": "b"}},
+		}},
+	}
+	for _, tt := range tests {
+		if _, err := ingestion.NewAdapters(tt.specs, zerolog.Nop()); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
@@ -1,29 +1,36 @@
 package ingestion
 
 import (
+	"time"
+
 	"github.com/dennisdiepolder/monti/backend/internal/cache"
 	"github.com/dennisdiepolder/monti/backend/internal/metrics"
 	"github.com/dennisdiepolder/monti/backend/internal/types"
 	"github.com/rs/zerolog"
 )
 
-// CallCompleter handles call completion events
+// CallCompleter handles the events of active calls: holds and completion. duplicate reports a
+// call the agent had completed before, whose KPIs were counted already.
 type CallCompleter interface {
-	CompleteCall(callID string, talkTime, holdTime float64) *types.Call
+	CompleteCall(agentID, callID string, talkTime, holdTime float64) (call *types.Call, duplicate bool)
+	HoldCall(agentID, callID string, onHold bool, at time.Time) *types.Call
 }
 
 // DefaultProcessor implements EventProcessor by delegating to AgentStateTracker
 type DefaultProcessor struct {
 	tracker       *cache.AgentStateTracker
 	callCompleter CallCompleter
+	events        *cache.EventCache // nil = state changes are not kept for GET /api/events
+	sequence      *sequencer
 	logger        zerolog.Logger
 }
 
 // NewDefaultProcessor creates a new DefaultProcessor
 func NewDefaultProcessor(tracker *cache.AgentStateTracker, logger zerolog.Logger) *DefaultProcessor {
 	return &DefaultProcessor{
-		tracker: tracker,
-		logger:  logger,
+		tracker:  tracker,
+		sequence: newSequencer(),
+		logger:   logger,
 	}
 }
 
@@ -32,9 +39,45 @@ func (p *DefaultProcessor) SetCallCompleter(cc CallCompleter) {
 	p.callCompleter = cc
 }
 
+// SetEventCache keeps registrations and state changes in the recent event history
+func (p *DefaultProcessor) SetEventCache(events *cache.EventCache) {
+	p.events = events
+}
+
+// ResetSequences forgets the message numbers applied so far, so that messages replayed into
+// an emptied tracker are not taken for late ones
+func (p *DefaultProcessor) ResetSequences() {
+	p.sequence.reset()
+}
+
+// ForgetAgent drops a retired agent's message number
+func (p *DefaultProcessor) ForgetAgent(agentID string) {
+	p.sequence.forget(agentID)
+}
+
 func (p *DefaultProcessor) ProcessRegister(reg *types.AgentRegister) {
-	p.tracker.RegisterAgent(reg)
+	if !p.sequence.restart(reg.AgentID, reg.Seq) {
+		return
+	}
+	if err := p.tracker.RegisterAgent(reg); err != nil {
+		p.logger.Warn().Err(err).
+			Str("agent_id", reg.AgentID).
+			Str("tenant", reg.Tenant).
+			Msg("agent registration rejected")
+		return
+	}
 	metrics.Get().RecordAgentRegister()
+	if p.events != nil {
+		p.addEvent(types.AgentEvent{
+			AgentID:    reg.AgentID,
+			State:      reg.State,
+			Department: reg.Department,
+			Location:   reg.Location,
+			Team:       reg.Team,
+			Timestamp:  time.Now(),
+			KPIs:       reg.KPIs,
+		})
+	}
 
 	p.logger.Debug().
 		Str("agent_id", reg.AgentID).
@@ -42,14 +85,46 @@ func (p *DefaultProcessor) ProcessRegister(reg *types.AgentRegister) {
 		Msg("agent registered via processor")
 }
 
+// addEvent keeps an event in the recent event history, scoped by its tracked agent
+func (p *DefaultProcessor) addEvent(e types.AgentEvent) {
+	p.tracker.ScopeEvent(&e)
+	p.events.Add(e)
+}
+
 func (p *DefaultProcessor) ProcessHeartbeat(hb *types.AgentHeartbeat) {
+	if !p.sequence.accept(hb.AgentID, hb.Seq, "heartbeat") {
+		return
+	}
 	p.tracker.UpdateFromHeartbeat(hb)
 	metrics.Get().RecordAgentHeartbeat()
 }
 
 func (p *DefaultProcessor) ProcessStateChange(sc *types.AgentStateChange) {
+	if !p.sequence.accept(sc.AgentID, sc.Seq, "state_change") {
+		p.logger.Debug().
+			Str("agent_id", sc.AgentID).
+			Uint64("seq", sc.Seq).
+			Msg("discarded duplicate or late state change")
+		return
+	}
 	p.tracker.UpdateFromStateChange(sc)
 	metrics.Get().RecordAgentStateChange()
+	if p.events != nil {
+		timestamp := sc.Timestamp
+		if timestamp.IsZero() {
+			timestamp = time.Now()
+		}
+		p.addEvent(types.AgentEvent{
+			AgentID:       sc.AgentID,
+			State:         sc.NewState,
+			Department:    sc.Department,
+			Location:      sc.Location,
+			Team:          sc.Team,
+			Timestamp:     timestamp,
+			StateDuration: sc.StateDuration,
+			KPIs:          sc.KPIs,
+		})
+	}
 
 	p.logger.Debug().
 		Str("agent_id", sc.AgentID).
@@ -60,9 +135,15 @@ func (p *DefaultProcessor) ProcessStateChange(sc *types.AgentStateChange) {
 }
 
 func (p *DefaultProcessor) ProcessCallComplete(cc *types.CallComplete) {
+	if !p.sequence.accept(cc.AgentID, cc.Seq, "call_complete") {
+		return
+	}
 	if p.callCompleter != nil {
-		p.callCompleter.CompleteCall(cc.CallID, cc.TalkTime, cc.HoldTime)
+		if _, duplicate := p.callCompleter.CompleteCall(cc.AgentID, cc.CallID, cc.TalkTime, cc.HoldTime); duplicate {
+			return
+		}
 	}
+	p.tracker.RecordCallComplete(cc)
 
 	p.logger.Debug().
 		Str("agent_id", cc.AgentID).
@@ -70,3 +151,22 @@ func (p *DefaultProcessor) ProcessCallComplete(cc *types.CallComplete) {
 		Float64("talk_time", cc.TalkTime).
 		Msg("call complete via processor")
 }
+
+func (p *DefaultProcessor) ProcessCallHold(ch *types.CallHold) {
+	if !p.sequence.accept(ch.AgentID, ch.Seq, "call_hold") {
+		return
+	}
+	if p.callCompleter != nil {
+		at := ch.Timestamp
+		if at.IsZero() {
+			at = time.Now()
+		}
+		p.callCompleter.HoldCall(ch.AgentID, ch.CallID, ch.OnHold(), at)
+	}
+
+	p.logger.Debug().
+		Str("agent_id", ch.AgentID).
+		Str("call_id", ch.CallID).
+		Bool("on_hold", ch.OnHold()).
+		Msg("call hold via processor")
+}
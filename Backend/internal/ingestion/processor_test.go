@@ -0,0 +1,84 @@
+package ingestion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+func TestProcessorDiscardsDuplicateAndLateMessages(t *testing.T) {
+	tracker := cache.NewAgentStateTracker()
+	p := NewDefaultProcessor(tracker, zerolog.Nop())
+	state := func() types.AgentState {
+		agent, _ := tracker.Get("a1")
+		return agent.State
+	}
+
+	p.ProcessRegister(&types.AgentRegister{AgentID: "a1", Department: types.DeptSales, State: types.StateAvailable, Seq: 1})
+	p.ProcessStateChange(&types.AgentStateChange{AgentID: "a1", NewState: types.StateOnCall, Seq: 3})
+
+	// State change 2 was overtaken by 3, and 3 is delivered again
+	p.ProcessStateChange(&types.AgentStateChange{AgentID: "a1", NewState: types.StateAvailable, Seq: 2})
+	p.ProcessStateChange(&types.AgentStateChange{AgentID: "a1", NewState: types.StateOnCall, Seq: 3})
+	if state() != types.StateOnCall {
+		t.Fatalf("expected the late state change to be discarded, got %s", state())
+	}
+
+	// Unnumbered messages always pass
+	p.ProcessStateChange(&types.AgentStateChange{AgentID: "a1", NewState: types.StateAfterCallWork})
+	if state() != types.StateAfterCallWork {
+		t.Fatalf("expected an unnumbered state change to apply, got %s", state())
+	}
+
+	// A restarted sender registers with a lower number and counts on from there
+	p.ProcessRegister(&types.AgentRegister{AgentID: "a1", Department: types.DeptSales, State: types.StateAvailable, Seq: 1})
+	p.ProcessStateChange(&types.AgentStateChange{AgentID: "a1", NewState: types.StateOnCall, Seq: 2})
+	if state() != types.StateOnCall {
+		t.Errorf("expected numbering to restart with the registration, got %s", state())
+	}
+
+	// Numbers are compared per message type: the hub may process another type's later
+	// message first
+	p.ProcessStateChange(&types.AgentStateChange{AgentID: "a1", NewState: types.StateAfterCallWork, Seq: 5})
+	p.ProcessHeartbeat(&types.AgentHeartbeat{AgentID: "a1", State: types.StateAfterCallWork, Seq: 4})
+	if agent, _ := tracker.Get("a1"); agent.LastHeartbeat.IsZero() {
+		t.Error("expected a heartbeat numbered below the last state change to apply")
+	}
+
+	// A retired agent's number is dropped
+	p.ForgetAgent("a1")
+	if _, ok := p.sequence.last["a1"]; ok {
+		t.Error("expected the retired agent's number to be dropped")
+	}
+}
+
+// onceCompleter reports every call after its first completion as a duplicate
+type onceCompleter map[string]bool
+
+func (c onceCompleter) CompleteCall(agentID, callID string, talkTime, holdTime float64) (*types.Call, bool) {
+	duplicate := c[callID]
+	c[callID] = true
+	return &types.Call{CallID: callID, AgentID: agentID}, duplicate
+}
+
+func (c onceCompleter) HoldCall(agentID, callID string, onHold bool, at time.Time) *types.Call {
+	return nil
+}
+
+func TestProcessorCountsCallCompleteOnce(t *testing.T) {
+	tracker := cache.NewAgentStateTracker()
+	p := NewDefaultProcessor(tracker, zerolog.Nop())
+	p.SetCallCompleter(onceCompleter{})
+
+	p.ProcessRegister(&types.AgentRegister{AgentID: "a1", Department: types.DeptSales, State: types.StateAvailable})
+	// Unnumbered, so only the call queue recognizes the repeat
+	for i := 0; i < 2; i++ {
+		p.ProcessCallComplete(&types.CallComplete{AgentID: "a1", CallID: "c1", TalkTime: 60})
+	}
+	if agent, _ := tracker.Get("a1"); agent.Observed == nil || agent.Observed.Session.CallsHandled != 1 {
+		t.Errorf("expected the call counted once, got %+v", agent.Observed)
+	}
+}
@@ -0,0 +1,94 @@
+package ingestion
+
+import (
+	"sync"
+
+	"github.com/dennisdiepolder/monti/backend/internal/metrics"
+)
+
+// Reasons a numbered agent message is discarded
+const (
+	discardDuplicate  = "duplicate"
+	discardOutOfOrder = "out_of_order"
+)
+
+// sequencer remembers the last message number applied per agent and message type, so a
+// message that was delivered twice or arrives after a newer one of its type (e.g. around a
+// multiplexed reconnect) is dropped instead of rolling the agent back. Senders number all
+// of an agent's messages from one counter, but the agent hub queues each type on its own
+// channel and may process them in any order across types, so numbers are only compared
+// within a type. Unnumbered messages (seq 0) always pass.
+type sequencer struct {
+	mu   sync.Mutex
+	last map[string]map[string]uint64 // agent ID -> message type -> number
+}
+
+func newSequencer() *sequencer {
+	return &sequencer{last: make(map[string]map[string]uint64)}
+}
+
+// accept reports whether the message numbered seq is newer than the agent's last one of
+// msgType, and records it if so; msgType also labels the discard metric
+func (s *sequencer) accept(agentID string, seq uint64, msgType string) bool {
+	if seq == 0 {
+		return true
+	}
+	s.mu.Lock()
+	byType, ok := s.last[agentID]
+	if !ok {
+		byType = make(map[string]uint64)
+		s.last[agentID] = byType
+	}
+	last := byType[msgType]
+	if seq > last {
+		byType[msgType] = seq
+	}
+	s.mu.Unlock()
+
+	switch {
+	case seq == last:
+		metrics.Get().RecordAgentMessageDiscarded(msgType, discardDuplicate)
+		return false
+	case seq < last:
+		metrics.Get().RecordAgentMessageDiscarded(msgType, discardOutOfOrder)
+		return false
+	}
+	return true
+}
+
+// restart accepts a registration, which starts the agent's count of every type anew (a
+// restarted sender numbers from 1 again) unless it repeats the last number applied
+func (s *sequencer) restart(agentID string, seq uint64) bool {
+	if seq == 0 {
+		return true
+	}
+	s.mu.Lock()
+	var last uint64
+	for _, n := range s.last[agentID] {
+		last = max(last, n)
+	}
+	duplicate := last == seq
+	if !duplicate {
+		s.last[agentID] = map[string]uint64{"register": seq}
+	}
+	s.mu.Unlock()
+
+	if duplicate {
+		metrics.Get().RecordAgentMessageDiscarded("register", discardDuplicate)
+	}
+	return !duplicate
+}
+
+// forget drops an agent's numbers
+func (s *sequencer) forget(agentID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.last, agentID)
+}
+
+// reset forgets all agents' numbers
+func (s *sequencer) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last = make(map[string]map[string]uint64)
+}
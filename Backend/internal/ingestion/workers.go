@@ -0,0 +1,75 @@
+package ingestion
+
+import (
+	"hash/fnv"
+	"runtime"
+	"sync"
+)
+
+// workerQueueSize is the number of events a worker holds before Submit blocks
+const workerQueueSize = 256
+
+// Workers processes agent events on a fixed set of goroutines. Events are sharded by agent
+// ID, so the events of one agent run one after another in submission order while different
+// agents are processed in parallel.
+type Workers struct {
+	queues []chan func()
+	wg     sync.WaitGroup
+}
+
+// NewWorkers starts n workers (n <= 0 = one per CPU)
+func NewWorkers(n int) *Workers {
+	if n <= 0 {
+		n = runtime.NumCPU()
+	}
+	w := &Workers{queues: make([]chan func(), n)}
+	for i := range w.queues {
+		w.queues[i] = make(chan func(), workerQueueSize)
+		w.wg.Add(1)
+		go w.run(w.queues[i])
+	}
+	return w
+}
+
+// Size returns the number of workers
+func (w *Workers) Size() int {
+	return len(w.queues)
+}
+
+// Submit queues fn on the worker owning agentID. It blocks while that worker's queue is
+// full, so a slow processor pushes back on the caller instead of losing events.
+func (w *Workers) Submit(agentID string, fn func()) {
+	w.queues[w.shard(agentID)] <- fn
+}
+
+// Backlog returns the combined length and capacity of the workers' queues
+func (w *Workers) Backlog() (int, int) {
+	length, capacity := 0, 0
+	for _, q := range w.queues {
+		length += len(q)
+		capacity += cap(q)
+	}
+	return length, capacity
+}
+
+// Stop processes the queued events and stops the workers; Submit must not be called afterwards
+func (w *Workers) Stop() {
+	for _, q := range w.queues {
+		close(q)
+	}
+	w.wg.Wait()
+}
+
+func (w *Workers) run(queue chan func()) {
+	defer w.wg.Done()
+	for fn := range queue {
+		fn()
+	}
+}
+
+// shard maps an agent ID to its worker
+func (w *Workers) shard(agentID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(agentID))
+	return int(h.Sum32() % uint32(len(w.queues)))
+}
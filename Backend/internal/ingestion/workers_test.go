@@ -0,0 +1,48 @@
+package ingestion
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestWorkersKeepPerAgentOrder(t *testing.T) {
+	w := NewWorkers(4)
+
+	var mu sync.Mutex
+	seen := make(map[string][]int)
+	for i := 0; i < 100; i++ {
+		for a := 0; a < 10; a++ {
+			agentID := fmt.Sprintf("agent-%d", a)
+			i := i
+			w.Submit(agentID, func() {
+				mu.Lock()
+				seen[agentID] = append(seen[agentID], i)
+				mu.Unlock()
+			})
+		}
+	}
+	w.Stop()
+
+	if len(seen) != 10 {
+		t.Fatalf("expected events of 10 agents, got %d", len(seen))
+	}
+	for agentID, order := range seen {
+		if len(order) != 100 {
+			t.Fatalf("%s: expected 100 events, got %d", agentID, len(order))
+		}
+		for i, n := range order {
+			if n != i {
+				t.Fatalf("%s: event %d processed at position %d", agentID, n, i)
+			}
+		}
+	}
+}
+
+func TestWorkersDefaultSize(t *testing.T) {
+	w := NewWorkers(0)
+	defer w.Stop()
+	if w.Size() < 1 {
+		t.Errorf("expected at least one worker, got %d", w.Size())
+	}
+}
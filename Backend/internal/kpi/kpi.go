@@ -0,0 +1,287 @@
+// Package kpi computes agent occupancy, AHT and calls handled from observed events, so the
+// backend does not have to trust the KPIs agents report
+package kpi
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+const (
+	// RollingWindow is the span of the rolling figures
+	RollingWindow = time.Hour
+
+	// DefaultHandleTimeWindow is how many recent calls the handle time percentiles cover
+	DefaultHandleTimeWindow = 50
+
+	bucketWidth = time.Minute
+	numBuckets  = int(RollingWindow / bucketWidth)
+)
+
+// Divergence tolerances between reported and observed session KPIs
+const (
+	callsTolerance     = 2    // calls, or 10% when more
+	ahtTolerance       = 0.25 // relative
+	ahtMinCalls        = 5
+	occupancyTolerance = 15 // percentage points
+	occupancyMinSecs   = 600
+)
+
+// totals are the observed sums of one window
+type totals struct {
+	handling  float64 // seconds in call handling states, ACW included
+	available float64
+	acw       float64
+	talkHold  float64 // from call_complete
+	calls     int
+}
+
+func (t *totals) add(o totals) {
+	t.handling += o.handling
+	t.available += o.available
+	t.acw += o.acw
+	t.talkHold += o.talkHold
+	t.calls += o.calls
+}
+
+func (t totals) window() types.KPIWindow {
+	w := types.KPIWindow{CallsHandled: t.calls}
+	if t.calls > 0 {
+		w.AvgHandleTime = (t.talkHold + t.acw) / float64(t.calls)
+	}
+	if t.handling+t.available > 0 {
+		w.Occupancy = t.handling / (t.handling + t.available) * 100
+	}
+	return w
+}
+
+type bucket struct {
+	minute int64 // Unix minute the bucket holds
+	totals
+}
+
+// Counter accumulates one agent's observed time and calls. It is not safe for concurrent
+// use; the tracker guards it with its lock.
+type Counter struct {
+	buckets [numBuckets]bucket
+	day     string // date of daily in Location
+	daily   totals
+	session totals
+
+	// Handle times (talk + hold + the ACW that followed) of the most recent calls. The
+	// oldest is overwritten once the ring is full.
+	handleTimes []float64
+	last        int       // index of the latest call
+	acwPending  bool      // the latest call's ACW is not added yet
+	sorted      []float64 // handleTimes sorted, handed out by Observed; replaced, never mutated
+
+	// Location is where the agent's day starts (nil = types.ReportingLocation)
+	Location *time.Location
+
+	// Window is how many recent calls the handle time percentiles cover
+	// (0 = DefaultHandleTimeWindow)
+	Window int
+}
+
+// ResetSession starts a new session, e.g. when the agent logs on
+func (c *Counter) ResetSession() {
+	c.session = totals{}
+}
+
+// AddState records that the agent was in state from start to end
+func (c *Counter) AddState(state types.AgentState, start, end time.Time) {
+	isHandling := Handling(state)
+	if !isHandling {
+		c.acwPending = false // the latest call ended without ACW
+	}
+	if !isHandling && state != types.StateAvailable || !end.After(start) {
+		return
+	}
+	add := func(t *totals, secs float64) {
+		if isHandling {
+			t.handling += secs
+		} else {
+			t.available += secs
+		}
+		if state == types.StateAfterCallWork {
+			t.acw += secs
+		}
+	}
+
+	add(&c.session, end.Sub(start).Seconds())
+	if state == types.StateAfterCallWork && c.acwPending {
+		c.handleTimes[c.last] += end.Sub(start).Seconds()
+		c.acwPending = false
+		c.sorted = nil
+	}
+
+	c.rollDay(end)
+	if dayStart := c.dayStart(end); start.Before(dayStart) {
+		add(&c.daily, end.Sub(dayStart).Seconds())
+	} else {
+		add(&c.daily, end.Sub(start).Seconds())
+	}
+
+	// Spread over the minute buckets, skipping what is older than the rolling window
+	if oldest := end.Add(-RollingWindow); start.Before(oldest) {
+		start = oldest
+	}
+	for start.Before(end) {
+		next := start.Truncate(bucketWidth).Add(bucketWidth)
+		if next.After(end) {
+			next = end
+		}
+		add(&c.bucket(start).totals, next.Sub(start).Seconds())
+		start = next
+	}
+}
+
+// AddCall records a completed call
+func (c *Counter) AddCall(talk, hold float64, at time.Time) {
+	call := totals{talkHold: talk + hold, calls: 1}
+	c.session.add(call)
+	c.rollDay(at)
+	c.daily.add(call)
+	c.bucket(at).add(call)
+
+	window := c.Window
+	if window <= 0 {
+		window = DefaultHandleTimeWindow
+	}
+	if len(c.handleTimes) < window {
+		c.handleTimes = append(c.handleTimes, talk+hold)
+		c.last = len(c.handleTimes) - 1
+	} else {
+		c.last = (c.last + 1) % len(c.handleTimes)
+		c.handleTimes[c.last] = talk + hold
+	}
+	c.acwPending = true
+	c.sorted = nil
+}
+
+// Observed returns the KPIs up to now, counting the agent's current state since stateStart.
+// reported are the KPIs the agent sent, checked against the session.
+func (c *Counter) Observed(state types.AgentState, stateStart, now time.Time, reported types.AgentKPIs) *types.ObservedKPIs {
+	open := *c
+	open.acwPending = false // the copy shares handleTimes; recent counts the open ACW
+	open.AddState(state, stateStart, now)
+
+	var rolling totals
+	nowMinute := now.Unix() / 60
+	for _, b := range open.buckets {
+		if b.minute > nowMinute-int64(numBuckets) && b.minute <= nowMinute {
+			rolling.add(b.totals)
+		}
+	}
+	var daily totals
+	if open.day == c.dateOf(now) {
+		daily = open.daily
+	}
+
+	obs := &types.ObservedKPIs{
+		Session: open.session.window(),
+		Rolling: rolling.window(),
+		Daily:   daily.window(),
+	}
+	obs.Divergent = divergent(open.session, obs.Session, reported)
+	obs.HandleTimes = c.recent(state, stateStart, now)
+	obs.RecentAHT = Percentiles(obs.HandleTimes)
+	return obs
+}
+
+// recent returns the handle times of the most recent calls, sorted, counting the ACW the
+// agent is still in towards the latest call
+func (c *Counter) recent(state types.AgentState, stateStart, now time.Time) []float64 {
+	if len(c.handleTimes) == 0 {
+		return nil
+	}
+	if c.acwPending && state == types.StateAfterCallWork && now.After(stateStart) {
+		open := append([]float64(nil), c.handleTimes...)
+		open[c.last] += now.Sub(stateStart).Seconds()
+		sort.Float64s(open)
+		return open
+	}
+	if c.sorted == nil {
+		c.sorted = append([]float64(nil), c.handleTimes...)
+		sort.Float64s(c.sorted)
+	}
+	return c.sorted
+}
+
+// Percentiles returns the median and 90th percentile (nearest rank) of sorted handle
+// times, or nil without any
+func Percentiles(sorted []float64) *types.AHTPercentiles {
+	if len(sorted) == 0 {
+		return nil
+	}
+	rank := func(p float64) float64 {
+		return sorted[int(math.Ceil(p*float64(len(sorted))))-1]
+	}
+	return &types.AHTPercentiles{Calls: len(sorted), P50: rank(0.5), P90: rank(0.9)}
+}
+
+// divergent lists the reported KPIs outside the tolerances of the observed session
+func divergent(session totals, observed types.KPIWindow, reported types.AgentKPIs) []string {
+	var out []string
+	callDiff := math.Abs(float64(reported.TotalCalls - observed.CallsHandled))
+	if callDiff > math.Max(callsTolerance, 0.1*float64(observed.CallsHandled)) {
+		out = append(out, "calls")
+	}
+	if observed.CallsHandled >= ahtMinCalls &&
+		math.Abs(reported.AvgHandleTime-observed.AvgHandleTime) > ahtTolerance*observed.AvgHandleTime {
+		out = append(out, "aht")
+	}
+	if session.handling+session.available >= occupancyMinSecs &&
+		math.Abs(reported.Occupancy-observed.Occupancy) > occupancyTolerance {
+		out = append(out, "occupancy")
+	}
+	return out
+}
+
+// bucket returns the bucket for t, clearing it when it still holds an older minute
+func (c *Counter) bucket(t time.Time) *bucket {
+	minute := t.Unix() / 60
+	b := &c.buckets[int(minute%int64(numBuckets))]
+	if b.minute != minute {
+		*b = bucket{minute: minute}
+	}
+	return b
+}
+
+// rollDay resets the daily totals when t is on a later day
+func (c *Counter) rollDay(t time.Time) {
+	if d := c.dateOf(t); d > c.day {
+		c.day = d
+		c.daily = totals{}
+	}
+}
+
+func (c *Counter) location() *time.Location {
+	if c.Location == nil {
+		return types.ReportingLocation
+	}
+	return c.Location
+}
+
+func (c *Counter) dateOf(t time.Time) string {
+	return t.In(c.location()).Format(types.DateLayout)
+}
+
+// dayStart returns the midnight that starts t's day
+func (c *Counter) dayStart(t time.Time) time.Time {
+	y, m, d := t.In(c.location()).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, c.location())
+}
+
+// Handling reports whether state counts as handling contacts
+func Handling(state types.AgentState) bool {
+	switch state {
+	case types.StateOnCall, types.StateOnHold, types.StateTransferring, types.StateConference,
+		types.StateMultiSession, types.StateAfterCallWork, types.StateBusy:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package kpi
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 0.01 }
+
+func TestObservedWindows(t *testing.T) {
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	c := &Counter{}
+
+	// Yesterday 23:00-23:30 available, then 23:30-00:30 on call across midnight
+	c.AddState(types.StateAvailable, day.Add(-time.Hour), day.Add(-30*time.Minute))
+	c.AddState(types.StateOnCall, day.Add(-30*time.Minute), day.Add(30*time.Minute))
+	c.AddCall(3000, 600, day.Add(30*time.Minute))
+	c.AddState(types.StateAfterCallWork, day.Add(30*time.Minute), day.Add(35*time.Minute))
+	// Breaks count towards neither handling nor available time
+	c.AddState(types.StateBreak, day.Add(35*time.Minute), day.Add(50*time.Minute))
+
+	// Still available since 01:20 when asked at 01:30
+	now := day.Add(90 * time.Minute)
+	obs := c.Observed(types.StateAvailable, day.Add(80*time.Minute), now, types.AgentKPIs{})
+
+	// Session: handling 60+5 min, available 30+10 min
+	if obs.Session.CallsHandled != 1 || !approx(obs.Session.AvgHandleTime, 3600+300) {
+		t.Errorf("session = %+v", obs.Session)
+	}
+	if !approx(obs.Session.Occupancy, 65.0/105*100) {
+		t.Errorf("session occupancy = %v", obs.Session.Occupancy)
+	}
+	// Daily: handling 30+5 min, available 10 min
+	if !approx(obs.Daily.Occupancy, 35.0/45*100) || obs.Daily.CallsHandled != 1 {
+		t.Errorf("daily = %+v", obs.Daily)
+	}
+	// Rolling, at minute resolution 00:31-01:30: ACW 4 min, available 10 min
+	if !approx(obs.Rolling.Occupancy, 4.0/14*100) || obs.Rolling.CallsHandled != 0 {
+		t.Errorf("rolling = %+v", obs.Rolling)
+	}
+
+	// Observed does not change the counter
+	again := c.Observed(types.StateAvailable, now, now, types.AgentKPIs{})
+	if !approx(again.Session.Occupancy, 65.0/95*100) {
+		t.Errorf("open state leaked into counter: %+v", again.Session)
+	}
+
+	// A new day drops the daily totals; a new session drops the session totals
+	next := now.Add(24 * time.Hour)
+	if obs := c.Observed(types.StateOffline, next, next, types.AgentKPIs{}); obs.Daily != (types.KPIWindow{}) || obs.Rolling != (types.KPIWindow{}) {
+		t.Errorf("next day = %+v", obs)
+	}
+	c.ResetSession()
+	if obs := c.Observed(types.StateOffline, now, now, types.AgentKPIs{}); obs.Session != (types.KPIWindow{}) {
+		t.Errorf("after reset session = %+v", obs.Session)
+	}
+}
+
+func TestDailyWindowFollowsLocation(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	c := &Counter{Location: berlin}
+
+	// 22:30-23:30 UTC on call is 23:30-00:30 in Berlin: only the last 30 minutes are today there
+	start := time.Date(2026, 3, 2, 22, 30, 0, 0, time.UTC)
+	c.AddState(types.StateOnCall, start, start.Add(time.Hour))
+	c.AddState(types.StateAvailable, start.Add(time.Hour), start.Add(90*time.Minute))
+
+	obs := c.Observed(types.StateAvailable, start.Add(90*time.Minute), start.Add(90*time.Minute), types.AgentKPIs{})
+	if !approx(obs.Daily.Occupancy, 50) {
+		t.Errorf("expected 30 min handling and 30 min available in the Berlin day, got %+v", obs.Daily)
+	}
+}
+
+func TestDivergence(t *testing.T) {
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	c := &Counter{}
+	at := start
+	for i := 0; i < 6; i++ {
+		c.AddState(types.StateAvailable, at, at.Add(time.Minute))
+		c.AddState(types.StateOnCall, at.Add(time.Minute), at.Add(4*time.Minute))
+		c.AddCall(150, 30, at.Add(4*time.Minute))
+		c.AddState(types.StateAfterCallWork, at.Add(4*time.Minute), at.Add(5*time.Minute))
+		at = at.Add(5 * time.Minute)
+	}
+	// Observed: 6 calls, AHT 240s, occupancy 80%
+
+	honest := types.AgentKPIs{TotalCalls: 6, AvgHandleTime: 230, Occupancy: 78}
+	if obs := c.Observed(types.StateAvailable, at, at, honest); len(obs.Divergent) != 0 {
+		t.Errorf("honest KPIs flagged: %v", obs.Divergent)
+	}
+
+	inflated := types.AgentKPIs{TotalCalls: 20, AvgHandleTime: 90, Occupancy: 40}
+	obs := c.Observed(types.StateAvailable, at, at, inflated)
+	if len(obs.Divergent) != 3 || obs.Divergent[0] != "calls" || obs.Divergent[1] != "aht" || obs.Divergent[2] != "occupancy" {
+		t.Errorf("divergent = %v, want [calls aht occupancy]", obs.Divergent)
+	}
+}
+
+func TestRecentHandleTimes(t *testing.T) {
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	c := &Counter{Window: 10}
+	at := start
+	// 12 calls of 100..210s talk and hold, each followed by 30s ACW; the first two drop out
+	for i := 0; i < 12; i++ {
+		c.AddCall(float64(100+10*i), 0, at)
+		c.AddState(types.StateAfterCallWork, at, at.Add(30*time.Second))
+		at = at.Add(time.Minute)
+	}
+	obs := c.Observed(types.StateAvailable, at, at, types.AgentKPIs{})
+	if r := obs.RecentAHT; r == nil || r.Calls != 10 || r.P50 != 190 || r.P90 != 230 {
+		t.Errorf("expected 10 calls, p50 190s, p90 230s, got %+v", r)
+	}
+
+	// A call still in ACW counts its ACW so far, without changing the counter
+	c.AddCall(1000, 0, at)
+	obs = c.Observed(types.StateAfterCallWork, at, at.Add(20*time.Second), types.AgentKPIs{})
+	if last := obs.HandleTimes[len(obs.HandleTimes)-1]; last != 1020 {
+		t.Errorf("expected the open call at 1020s, got %v", last)
+	}
+	c.AddState(types.StateAfterCallWork, at, at.Add(5*time.Second))
+	obs = c.Observed(types.StateAvailable, at, at, types.AgentKPIs{})
+	if last := obs.HandleTimes[len(obs.HandleTimes)-1]; last != 1005 || obs.RecentAHT.Calls != 10 {
+		t.Errorf("expected the call closed at 1005s within 10 calls, got %v of %+v", last, obs.RecentAHT)
+	}
+	// A call the agent leaves without ACW is closed; later ACW does not add to it
+	c.AddCall(500, 0, at)
+	c.AddState(types.StateAvailable, at, at.Add(time.Minute))
+	c.AddState(types.StateAfterCallWork, at.Add(time.Minute), at.Add(2*time.Minute))
+	obs = c.Observed(types.StateAfterCallWork, at.Add(2*time.Minute), at.Add(3*time.Minute), types.AgentKPIs{})
+	if !slices.Contains(obs.HandleTimes, 500) {
+		t.Errorf("expected the call without ACW kept at 500s, got %v", obs.HandleTimes)
+	}
+}
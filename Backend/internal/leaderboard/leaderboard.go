@@ -0,0 +1,98 @@
+package leaderboard
+
+import (
+	"sort"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// MaxSize caps the number of entries per top/bottom list
+const MaxSize = 50
+
+// Build ranks logged-in agents for every leaderboard metric and returns the top and
+// bottom size entries for each. AHT and CSAT only rank agents that have handled calls.
+func Build(agents []types.AgentInfo, size int) []types.Leaderboard {
+	if size <= 0 {
+		return nil
+	}
+	if size > MaxSize {
+		size = MaxSize
+	}
+
+	boards := make([]types.Leaderboard, 0, len(types.AllLeaderboardMetrics))
+	for _, metric := range types.AllLeaderboardMetrics {
+		entries := make([]types.LeaderboardEntry, 0, len(agents))
+		for _, agent := range agents {
+			if agent.ConnectionStatus != types.StatusConnected || agent.State == types.StateOffline {
+				continue
+			}
+			if needsCalls(metric) && agent.KPIs.TotalCalls == 0 {
+				continue
+			}
+			entries = append(entries, types.LeaderboardEntry{
+				AgentID:  agent.AgentID,
+				Team:     agent.Team,
+				Location: agent.Location,
+				Value:    value(metric, agent.KPIs),
+			})
+		}
+
+		// Sort best-first; agent ID breaks ties so rankings are stable between cycles
+		lowerIsBetter := metric == types.LeaderboardAHT
+		sort.Slice(entries, func(i, j int) bool {
+			if entries[i].Value != entries[j].Value {
+				if lowerIsBetter {
+					return entries[i].Value < entries[j].Value
+				}
+				return entries[i].Value > entries[j].Value
+			}
+			return entries[i].AgentID < entries[j].AgentID
+		})
+
+		n := size
+		if n > len(entries) {
+			n = len(entries)
+		}
+		board := types.Leaderboard{
+			Metric: metric,
+			Top:    append([]types.LeaderboardEntry{}, entries[:n]...),
+			Bottom: make([]types.LeaderboardEntry, 0, n),
+		}
+		// Bottom list runs worst-first
+		for i := len(entries) - 1; i >= len(entries)-n; i-- {
+			board.Bottom = append(board.Bottom, entries[i])
+		}
+		boards = append(boards, board)
+	}
+
+	return boards
+}
+
+// SizeOf returns the list size used to build a set of leaderboards
+func SizeOf(boards []types.Leaderboard) int {
+	size := 0
+	for _, b := range boards {
+		if len(b.Top) > size {
+			size = len(b.Top)
+		}
+	}
+	return size
+}
+
+func needsCalls(metric types.LeaderboardMetric) bool {
+	return metric == types.LeaderboardAHT || metric == types.LeaderboardSatisfaction
+}
+
+func value(metric types.LeaderboardMetric, kpis types.AgentKPIs) float64 {
+	switch metric {
+	case types.LeaderboardCallsHandled:
+		return float64(kpis.TotalCalls)
+	case types.LeaderboardAHT:
+		return kpis.AvgHandleTime
+	case types.LeaderboardOccupancy:
+		return kpis.Occupancy
+	case types.LeaderboardSatisfaction:
+		return kpis.CustomerSatisfaction
+	}
+	return 0
+}
@@ -0,0 +1,85 @@
+package leaderboard
+
+import (
+	"testing"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+func agent(id string, calls int, aht, occupancy, csat float64) types.AgentInfo {
+	return types.AgentInfo{
+		AgentID:          id,
+		State:            types.StateAvailable,
+		ConnectionStatus: types.StatusConnected,
+		KPIs: types.AgentKPIs{
+			TotalCalls:           calls,
+			AvgHandleTime:        aht,
+			Occupancy:            occupancy,
+			CustomerSatisfaction: csat,
+		},
+	}
+}
+
+func findBoard(boards []types.Leaderboard, metric types.LeaderboardMetric) types.Leaderboard {
+	for _, b := range boards {
+		if b.Metric == metric {
+			return b
+		}
+	}
+	return types.Leaderboard{}
+}
+
+func TestBuildRanksAgents(t *testing.T) {
+	agents := []types.AgentInfo{
+		agent("a1", 10, 300, 80, 4.5),
+		agent("a2", 25, 200, 90, 3.9),
+		agent("a3", 5, 400, 60, 4.8),
+		agent("a4", 0, 0, 10, 0), // no calls yet
+	}
+
+	boards := Build(agents, 2)
+	if len(boards) != len(types.AllLeaderboardMetrics) {
+		t.Fatalf("expected %d boards, got %d", len(types.AllLeaderboardMetrics), len(boards))
+	}
+
+	calls := findBoard(boards, types.LeaderboardCallsHandled)
+	if calls.Top[0].AgentID != "a2" || calls.Top[1].AgentID != "a1" {
+		t.Errorf("unexpected top calls: %+v", calls.Top)
+	}
+	if calls.Bottom[0].AgentID != "a4" {
+		t.Errorf("expected a4 at the bottom for calls, got %s", calls.Bottom[0].AgentID)
+	}
+
+	aht := findBoard(boards, types.LeaderboardAHT)
+	if aht.Top[0].AgentID != "a2" {
+		t.Errorf("expected lowest AHT (a2) on top, got %s", aht.Top[0].AgentID)
+	}
+	if aht.Bottom[0].AgentID != "a3" {
+		t.Errorf("expected highest AHT (a3) at the bottom, got %s", aht.Bottom[0].AgentID)
+	}
+	for _, e := range append(aht.Top, aht.Bottom...) {
+		if e.AgentID == "a4" {
+			t.Error("agents without calls should not be ranked by AHT")
+		}
+	}
+}
+
+func TestBuildSkipsOfflineAgents(t *testing.T) {
+	offline := agent("a1", 50, 100, 95, 5)
+	offline.State = types.StateOffline
+	boards := Build([]types.AgentInfo{offline, agent("a2", 1, 100, 50, 3)}, 5)
+
+	calls := findBoard(boards, types.LeaderboardCallsHandled)
+	if len(calls.Top) != 1 || calls.Top[0].AgentID != "a2" {
+		t.Errorf("expected only a2 ranked, got %+v", calls.Top)
+	}
+	if SizeOf(boards) != 1 {
+		t.Errorf("expected size 1, got %d", SizeOf(boards))
+	}
+}
+
+func TestBuildDisabledForZeroSize(t *testing.T) {
+	if Build([]types.AgentInfo{agent("a1", 1, 1, 1, 1)}, 0) != nil {
+		t.Error("expected nil leaderboards for size 0")
+	}
+}
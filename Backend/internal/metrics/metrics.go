@@ -7,47 +7,133 @@ import (
 	"time"
 
 	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-// Metrics holds all application metrics
+// Buckets for in-process work (aggregation, routing, broadcast fan-out): 100µs .. ~3s
+var fastBuckets = prometheus.ExponentialBuckets(0.0001, 2.5, 12)
+
+// Metrics holds all application metrics, registered on a dedicated Prometheus registry
 type Metrics struct {
-	mu sync.RWMutex
+	registry *prometheus.Registry
 
 	// Event metrics
-	EventsReceivedTotal   int64
-	EventsProcessedTotal  int64
-	EventProcessingErrors int64
+	eventsReceived  prometheus.Counter
+	eventsProcessed prometheus.Counter
+	eventErrors     prometheus.Counter
+	unknownValues   *prometheus.CounterVec
 
 	// WebSocket metrics (frontend clients)
-	WebSocketConnectionsTotal    int64
-	WebSocketDisconnectionsTotal int64
-	WebSocketMessagesTotal       int64
-	WebSocketErrorsTotal         int64
-	activeConnections            int64
+	wsConnections       prometheus.Counter
+	wsDisconnections    prometheus.Counter
+	wsActive            prometheus.Gauge
+	wsMessages          prometheus.Counter
+	wsErrors            prometheus.Counter
+	wsRejected          prometheus.Counter
+	wsClientsByRole     *prometheus.GaugeVec
+	wsDropped           *prometheus.CounterVec
+	wsBroadcastDuration *prometheus.HistogramVec
+	wsAckLatency        *prometheus.HistogramVec
+	wsStalledClients    prometheus.Gauge
+	broadcastLatency    *prometheus.SummaryVec
 
 	// Agent WebSocket metrics
-	AgentConnectionsTotal    int64
-	AgentDisconnectionsTotal int64
-	AgentHeartbeatsTotal     int64
-	AgentStateChangesTotal   int64
-	AgentRegistrationsTotal  int64
-	activeAgentConnections   int64
+	agentConnections    prometheus.Counter
+	agentDisconnections prometheus.Counter
+	agentActive         prometheus.Gauge
+	agentHeartbeats     prometheus.Counter
+	agentStateChanges   prometheus.Counter
+	agentRegistrations  prometheus.Counter
+	agentQueueDepth     *prometheus.GaugeVec
+	agentShed           *prometheus.CounterVec
+	agentBlocked        *prometheus.CounterVec
+	agentTransitions    *prometheus.CounterVec
+	agentStale          *prometheus.CounterVec
+	agentHubMessages    *prometheus.CounterVec
+	agentHubWait        *prometheus.HistogramVec
+	agentHubProcessing  *prometheus.HistogramVec
+	agentTakeovers      prometheus.Counter
+	agentThrottled      prometheus.Gauge
+	orphanedCalls       *prometheus.CounterVec
+	queueEvents         *prometheus.CounterVec
+	callCompleteIssues  *prometheus.CounterVec
+	talkTimeDiscrepancy *prometheus.HistogramVec
+	invalidTransitions  *prometheus.CounterVec
+	agentQuarantines    prometheus.Counter
+	agentDiscarded      *prometheus.CounterVec
 
 	// Aggregation metrics
-	AggregationCyclesTotal  int64
-	WidgetsBroadcastTotal   int64
-	AggregationErrorsTotal  int64
-	lastAggregationDuration time.Duration
+	aggregationCycles   prometheus.Counter
+	widgetsBroadcast    prometheus.Counter
+	aggregationErrors   prometheus.Counter
+	aggregationDuration prometheus.Histogram
+	loadShedLevel       prometheus.Gauge
+	loadShedChanges     *prometheus.CounterVec
+	snapshotsShed       prometheus.Counter
+
+	// Routing metrics
+	routingTickDuration prometheus.Histogram
+	callAssignLatency   prometheus.Histogram
+
+	// Per-VQ metrics, labelled by tenant, vq and department
+	vqOffered     *prometheus.CounterVec
+	vqRouted      *prometheus.CounterVec
+	vqAbandoned   *prometheus.CounterVec
+	vqCompleted   *prometheus.CounterVec
+	vqWaiting     *prometheus.GaugeVec
+	vqActive      *prometheus.GaugeVec
+	vqHolds       *prometheus.CounterVec
+	vqOnHold      *prometheus.GaugeVec
+	vqLongestWait *prometheus.GaugeVec
+	vqSL          *prometheus.GaugeVec
+	vqAvailable   *prometheus.GaugeVec
+
+	// Auth metrics
+	jwksRefreshes     prometheus.Counter
+	jwksRefreshErrors prometheus.Counter
+	jwksLastRefresh   prometheus.Gauge
+	authFailures      *prometheus.CounterVec
+	authLockouts      *prometheus.CounterVec
+	rateLimited       *prometheus.CounterVec
+
+	// Storage metrics
+	storageBreakerState prometheus.Gauge
+	storageSpooled      prometheus.Gauge
+	storageSpoolDropped prometheus.Counter
+
+	// Webhook metrics
+	webhookDeliveries *prometheus.CounterVec
+	webhookAttempts   *prometheus.CounterVec
+	webhookLatency    *prometheus.HistogramVec
+
+	// Event log metrics
+	eventLogEvents *prometheus.CounterVec
+
+	// Break metrics, labelled by department and reason code
+	breakSeconds  *prometheus.CounterVec
+	breakRequests *prometheus.CounterVec
+
+	// Reported agent KPIs starting to diverge from the backend's observed KPIs
+	kpiDivergence *prometheus.CounterVec
+
+	// Shadow routing strategy choices compared with the active strategy's
+	shadowDecisions *prometheus.CounterVec
 
 	// Agent metrics
-	agentsByState      map[types.AgentState]int
-	agentsByDepartment map[types.Department]int
-	agentsByLocation   map[types.Location]int
-	totalAgents        int
+	agentsByState      *prometheus.GaugeVec
+	agentsByDepartment *prometheus.GaugeVec
+	agentsByLocation   *prometheus.GaugeVec
+	totalAgents        prometheus.Gauge
 
 	// HTTP metrics
-	httpRequestsTotal    map[string]map[int]int64 // endpoint -> status -> count
-	httpRequestDurations map[string][]float64     // endpoint -> durations
+	httpRequests        *prometheus.CounterVec
+	httpRequestDuration *prometheus.HistogramVec
+
+	// agentStatsMu keeps UpdateAgentStats' reset-and-fill atomic with respect to other updates
+	agentStatsMu sync.Mutex
 
 	// Timing
 	startTime time.Time
@@ -60,261 +146,616 @@ var once sync.Once
 // Get returns the singleton metrics instance
 func Get() *Metrics {
 	once.Do(func() {
-		instance = &Metrics{
-			agentsByState:        make(map[types.AgentState]int),
-			agentsByDepartment:   make(map[types.Department]int),
-			agentsByLocation:     make(map[types.Location]int),
-			httpRequestsTotal:    make(map[string]map[int]int64),
-			httpRequestDurations: make(map[string][]float64),
-			startTime:            time.Now(),
-		}
+		instance = newMetrics(prometheus.NewRegistry())
 	})
 	return instance
 }
 
+// newMetrics registers all MONTI collectors plus Go runtime and process metrics on reg
+func newMetrics(reg *prometheus.Registry) *Metrics {
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	f := promauto.With(reg)
+	m := &Metrics{registry: reg, startTime: time.Now()}
+
+	counter := func(name, help string) prometheus.Counter {
+		return f.NewCounter(prometheus.CounterOpts{Name: name, Help: help})
+	}
+	gauge := func(name, help string) prometheus.Gauge {
+		return f.NewGauge(prometheus.GaugeOpts{Name: name, Help: help})
+	}
+
+	f.NewGaugeFunc(prometheus.GaugeOpts{Name: "monti_uptime_seconds", Help: "Seconds since the backend started"},
+		func() float64 { return time.Since(m.startTime).Seconds() })
+
+	m.eventsReceived = counter("monti_events_received_total", "Agent events received on /internal/event")
+	m.eventsProcessed = counter("monti_events_processed_total", "Agent events applied to the cache and tracker")
+	m.eventErrors = counter("monti_event_processing_errors_total", "Agent events rejected as malformed or invalid")
+	m.unknownValues = f.NewCounterVec(prometheus.CounterOpts{
+		Name: "monti_ingest_unknown_values_total", Help: "Values outside an enum accepted by permissive ingestion, by source and field",
+	}, []string{"source", "field"})
+
+	m.wsConnections = counter("monti_websocket_connections_total", "Frontend WebSocket connections opened")
+	m.wsDisconnections = counter("monti_websocket_disconnections_total", "Frontend WebSocket connections closed")
+	m.wsActive = gauge("monti_websocket_active_connections", "Open frontend WebSocket connections")
+	m.wsMessages = counter("monti_websocket_messages_total", "Messages broadcast to frontend clients")
+	m.wsErrors = counter("monti_websocket_errors_total", "Frontend WebSocket errors")
+	m.wsRejected = counter("monti_websocket_rejected_total", "Frontend WebSocket connections refused by connection limits")
+	m.wsClientsByRole = f.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "monti_websocket_clients", Help: "Open frontend WebSocket connections by user role",
+	}, []string{"role"})
+	m.wsDropped = f.NewCounterVec(prometheus.CounterOpts{
+		Name: "monti_websocket_dropped_messages_total", Help: "Messages not delivered to frontend clients because their send buffer was full, by user role",
+	}, []string{"role"})
+	m.wsBroadcastDuration = f.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "monti_websocket_broadcast_duration_seconds",
+		Help:    "Time to fan a broadcast out to all frontend clients, including per-client RBAC filtering",
+		Buckets: fastBuckets,
+	}, []string{"type"})
+
+	m.wsAckLatency = f.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "monti_websocket_ack_latency_seconds",
+		Help:    "Time from a snapshot being queued for a frontend client to the client acknowledging it, by user role",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"role"})
+	m.wsStalledClients = gauge("monti_websocket_stalled_clients", "Frontend clients that acknowledged snapshots before but stopped for WS_ACK_STALL_AFTER")
+
+	m.broadcastLatency = f.NewSummaryVec(prometheus.SummaryOpts{
+		Name:       "monti_event_broadcast_latency_seconds",
+		Help:       "Time from an agent state change to the snapshot carrying it being queued for clients, measured from the sender timestamp (from=origin) or backend ingest (from=ingest)",
+		Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.95: 0.005, 0.99: 0.001},
+		MaxAge:     5 * time.Minute,
+	}, []string{"from"})
+
+	m.agentConnections = counter("monti_agent_connections_total", "Agent WebSocket connections opened")
+	m.agentDisconnections = counter("monti_agent_disconnections_total", "Agent WebSocket connections closed")
+	m.agentActive = gauge("monti_agent_active_connections", "Open agent WebSocket connections")
+	m.agentHeartbeats = counter("monti_agent_heartbeats_total", "Agent heartbeats received")
+	m.agentStateChanges = counter("monti_agent_state_changes_total", "Agent state changes received")
+	m.agentRegistrations = counter("monti_agent_registrations_total", "Agent registrations received")
+	m.agentQueueDepth = f.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "monti_agent_hub_queue_depth", Help: "Agent messages waiting in the AgentHub's inbound queues, by queue",
+	}, []string{"channel"})
+	m.agentShed = f.NewCounterVec(prometheus.CounterOpts{
+		Name: "monti_agent_hub_shed_total", Help: "Agent messages dropped because their AgentHub queue was full, by queue",
+	}, []string{"channel"})
+	m.agentBlocked = f.NewCounterVec(prometheus.CounterOpts{
+		Name: "monti_agent_hub_blocked_total", Help: "Agent messages that found their AgentHub queue full and made the connection wait, by queue",
+	}, []string{"channel"})
+	m.agentTransitions = f.NewCounterVec(prometheus.CounterOpts{
+		Name: "monti_agent_connection_transitions_total", Help: "Agent connection status changes between connected, stale and disconnected",
+	}, []string{"from", "to"})
+	m.agentStale = f.NewCounterVec(prometheus.CounterOpts{
+		Name: "monti_agent_hub_stale_total", Help: "Agent messages ignored because a newer connection of the agent had taken over, by queue",
+	}, []string{"channel"})
+	m.agentHubMessages = f.NewCounterVec(prometheus.CounterOpts{
+		Name: "monti_agent_hub_messages_total", Help: "Agent messages processed by the AgentHub's workers, by queue",
+	}, []string{"channel"})
+	m.agentHubWait = f.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "monti_agent_hub_worker_wait_seconds",
+		Help:    "Time an agent message waited in its processing worker's queue after the AgentHub Run loop dispatched it, by queue",
+		Buckets: fastBuckets,
+	}, []string{"channel"})
+	m.agentHubProcessing = f.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "monti_agent_hub_processing_seconds",
+		Help:    "Time spent processing an agent message, by queue",
+		Buckets: fastBuckets,
+	}, []string{"channel"})
+	m.agentTakeovers = counter("monti_agent_takeovers_total", "Agent connections superseded by a newer connection of the same agent")
+	m.agentThrottled = gauge("monti_agent_hub_throttled", "1 while agent connections are asked to slow their heartbeats because heartbeats are shed")
+	m.invalidTransitions = f.NewCounterVec(prometheus.CounterOpts{
+		Name: "monti_agent_invalid_transitions_total", Help: "Agent state changes the transition table does not allow, by source, states and action (flagged, rejected, quarantined)",
+	}, []string{"source", "from", "to", "action"})
+	m.agentQuarantines = counter("monti_agent_quarantines_total", "Agents quarantined for repeated invalid state transitions")
+	m.agentDiscarded = f.NewCounterVec(prometheus.CounterOpts{
+		Name: "monti_agent_messages_discarded_total", Help: "Numbered agent messages dropped as duplicates or for arriving after a newer one, by message type and reason",
+	}, []string{"type", "reason"})
+
+	m.aggregationCycles = counter("monti_aggregation_cycles_total", "Aggregation cycles completed")
+	m.widgetsBroadcast = counter("monti_widgets_broadcast_total", "Widgets/snapshots broadcast by the aggregator")
+	m.aggregationErrors = counter("monti_aggregation_errors_total", "Aggregation cycles that failed")
+	m.aggregationDuration = f.NewHistogram(prometheus.HistogramOpts{
+		Name:    "monti_aggregation_duration_seconds",
+		Help:    "Duration of an aggregation cycle",
+		Buckets: fastBuckets,
+	})
+	m.loadShedLevel = gauge("monti_load_shed_level", "Snapshot degradation under overload (0 = full fidelity, 1 = reduced, 2 = summary-only)")
+	m.loadShedChanges = f.NewCounterVec(prometheus.CounterOpts{
+		Name: "monti_load_shed_changes_total", Help: "Changes of the snapshot degradation level, by the level entered",
+	}, []string{"level"})
+	m.snapshotsShed = counter("monti_snapshots_shed_total", "Aggregation intervals skipped while shedding load")
+
+	m.routingTickDuration = f.NewHistogram(prometheus.HistogramOpts{
+		Name:    "monti_routing_tick_duration_seconds",
+		Help:    "Duration of a routing pass, including sending call_assign messages",
+		Buckets: fastBuckets,
+	})
+	m.callAssignLatency = f.NewHistogram(prometheus.HistogramOpts{
+		Name:    "monti_call_assign_latency_seconds",
+		Help:    "Time from enqueue until a call is assigned to an agent",
+		Buckets: []float64{0.5, 1, 2, 5, 10, 20, 30, 60, 120, 300, 600},
+	})
+
+	vqLabels := []string{"tenant", "vq", "department"}
+	vqCounter := func(name, help string) *prometheus.CounterVec {
+		return f.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, vqLabels)
+	}
+	vqGauge := func(name, help string) *prometheus.GaugeVec {
+		return f.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help}, vqLabels)
+	}
+	m.vqOffered = vqCounter("monti_vq_calls_offered_total", "Calls enqueued per VQ")
+	m.vqRouted = vqCounter("monti_vq_calls_routed_total", "Calls assigned to an agent per VQ")
+	m.vqAbandoned = vqCounter("monti_vq_calls_abandoned_total", "Calls abandoned while waiting per VQ")
+	m.vqCompleted = vqCounter("monti_vq_calls_completed_total", "Calls completed per VQ")
+	m.vqWaiting = vqGauge("monti_vq_waiting_calls", "Calls currently waiting per VQ")
+	m.vqActive = vqGauge("monti_vq_active_calls", "Calls currently connected to an agent per VQ")
+	m.vqHolds = vqCounter("monti_vq_call_holds_total", "Holds started on active calls per VQ")
+	m.vqOnHold = vqGauge("monti_vq_on_hold_calls", "Active calls whose caller is currently on hold per VQ")
+	m.vqLongestWait = vqGauge("monti_vq_longest_wait_seconds", "Wait time of the oldest waiting call per VQ")
+	m.vqSL = vqGauge("monti_vq_service_level_percent", "Share of answered calls within the SL threshold per VQ")
+	m.vqAvailable = vqGauge("monti_vq_available_agents", "Available agents in the VQ's department")
+	m.orphanedCalls = f.NewCounterVec(prometheus.CounterOpts{
+		Name: "monti_orphaned_calls_total", Help: "Active calls taken from agents disconnected longer than ORPHANED_CALL_GRACE, by action (requeue, abandon)",
+	}, []string{"tenant", "vq", "action"})
+	m.queueEvents = f.NewCounterVec(prometheus.CounterOpts{
+		Name: "monti_queue_events_total", Help: "Queue operational events (paused, resumed, sl_target_changed, overflow_started, overflow_ended) per VQ",
+	}, []string{"tenant", "vq", "type"})
+	m.callCompleteIssues = f.NewCounterVec(prometheus.CounterOpts{
+		Name: "monti_call_complete_discrepancies_total", Help: "call_complete messages for calls that were no longer active with the agent, by result (duplicate, conflict, reconciled, late)",
+	}, []string{"tenant", "vq", "result"})
+	m.talkTimeDiscrepancy = f.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "monti_call_talk_time_discrepancy_seconds",
+		Help:    "Difference between a call's recorded talk time and a later call_complete's, by result (conflict, reconciled)",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+	}, []string{"result"})
+
+	m.jwksRefreshes = counter("monti_jwks_refreshes_total", "Successful JWKS fetches")
+	m.jwksRefreshErrors = counter("monti_jwks_refresh_errors_total", "Failed JWKS fetches")
+	m.jwksLastRefresh = gauge("monti_jwks_last_refresh_timestamp_seconds", "Unix time of the last successful JWKS fetch")
+	m.storageBreakerState = gauge("monti_storage_breaker_state", "DynamoDB circuit breaker state (0 = closed, 1 = half-open, 2 = open)")
+	m.storageSpooled = gauge("monti_storage_spooled_records", "Records buffered on disk while DynamoDB is unavailable")
+	m.storageSpoolDropped = counter("monti_storage_spool_dropped_total", "Records dropped because the disk spool was full or unwritable")
+
+	m.webhookDeliveries = f.NewCounterVec(prometheus.CounterOpts{
+		Name: "monti_webhook_deliveries_total", Help: "Webhook events by subscriber and outcome (delivered/failed/dropped)",
+	}, []string{"subscriber", "event", "result"})
+	m.webhookAttempts = f.NewCounterVec(prometheus.CounterOpts{
+		Name: "monti_webhook_attempts_total", Help: "Webhook HTTP requests by subscriber, including retries",
+	}, []string{"subscriber"})
+	m.webhookLatency = f.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "monti_webhook_request_duration_seconds",
+		Help:    "Duration of webhook HTTP requests",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"subscriber"})
+
+	m.eventLogEvents = f.NewCounterVec(prometheus.CounterOpts{
+		Name: "monti_event_log_events_total", Help: "Event log appends by type and outcome (written/dropped)",
+	}, []string{"type", "result"})
+
+	m.breakSeconds = f.NewCounterVec(prometheus.CounterOpts{
+		Name: "monti_break_seconds_total", Help: "Finished break time by department and reason",
+	}, []string{"department", "reason"})
+	m.breakRequests = f.NewCounterVec(prometheus.CounterOpts{
+		Name: "monti_break_requests_total", Help: "Break requests by department, reason and outcome (granted/denied)",
+	}, []string{"department", "reason", "result"})
+
+	m.kpiDivergence = f.NewCounterVec(prometheus.CounterOpts{
+		Name: "monti_kpi_divergence_total", Help: "Agents whose reported KPI started diverging from the observed one, by KPI (calls/aht/occupancy)",
+	}, []string{"kpi"})
+
+	m.shadowDecisions = f.NewCounterVec(prometheus.CounterOpts{
+		Name: "monti_routing_shadow_decisions_total", Help: "Routing choices with several candidates, by whether the shadow strategy picked the same agent (same/divergent)",
+	}, []string{"result"})
+
+	m.authFailures = f.NewCounterVec(prometheus.CounterOpts{
+		Name: "monti_auth_failures_total", Help: "Rejected authentication attempts by reason",
+	}, []string{"reason"})
+	m.authLockouts = f.NewCounterVec(prometheus.CounterOpts{
+		Name: "monti_auth_lockouts_total", Help: "Auth lockouts triggered by scope (ip/user)",
+	}, []string{"scope"})
+	m.rateLimited = f.NewCounterVec(prometheus.CounterOpts{
+		Name: "monti_rate_limited_total", Help: "Requests rejected by a rate limiter",
+	}, []string{"limiter"})
+
+	m.agentsByState = f.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "monti_agents_by_state", Help: "Agents per state",
+	}, []string{"state"})
+	m.agentsByDepartment = f.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "monti_agents_by_department", Help: "Agents per department",
+	}, []string{"department"})
+	m.agentsByLocation = f.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "monti_agents_by_location", Help: "Agents per location",
+	}, []string{"location"})
+	m.totalAgents = gauge("monti_agents_total", "Agents known to the backend")
+
+	m.httpRequests = f.NewCounterVec(prometheus.CounterOpts{
+		Name: "monti_http_requests_total", Help: "HTTP requests by route and status",
+	}, []string{"endpoint", "status"})
+	m.httpRequestDuration = f.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "monti_http_request_duration_seconds",
+		Help:    "HTTP request duration by route",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"endpoint"})
+
+	return m
+}
+
 // RecordEventReceived increments the events received counter
 func (m *Metrics) RecordEventReceived() {
-	m.mu.Lock()
-	m.EventsReceivedTotal++
-	m.mu.Unlock()
+	m.eventsReceived.Inc()
 }
 
 // RecordEventProcessed increments the events processed counter
 func (m *Metrics) RecordEventProcessed() {
-	m.mu.Lock()
-	m.EventsProcessedTotal++
-	m.mu.Unlock()
+	m.eventsProcessed.Inc()
 }
 
 // RecordEventError increments the event processing error counter
 func (m *Metrics) RecordEventError() {
-	m.mu.Lock()
-	m.EventProcessingErrors++
-	m.mu.Unlock()
+	m.eventErrors.Inc()
+}
+
+// RecordUnknownValue counts an unknown enum value accepted by permissive ingestion
+func (m *Metrics) RecordUnknownValue(source, field string) {
+	m.unknownValues.WithLabelValues(source, field).Inc()
 }
 
 // RecordWebSocketConnect increments connection counters
 func (m *Metrics) RecordWebSocketConnect() {
-	m.mu.Lock()
-	m.WebSocketConnectionsTotal++
-	m.activeConnections++
-	m.mu.Unlock()
+	m.wsConnections.Inc()
+	m.wsActive.Inc()
 }
 
 // RecordWebSocketDisconnect increments disconnection counter
 func (m *Metrics) RecordWebSocketDisconnect() {
-	m.mu.Lock()
-	m.WebSocketDisconnectionsTotal++
-	m.activeConnections--
-	m.mu.Unlock()
+	m.wsDisconnections.Inc()
+	m.wsActive.Dec()
 }
 
 // RecordWebSocketMessage increments message counter
 func (m *Metrics) RecordWebSocketMessage() {
-	m.mu.Lock()
-	m.WebSocketMessagesTotal++
-	m.mu.Unlock()
+	m.wsMessages.Inc()
 }
 
 // RecordWebSocketError increments WebSocket error counter
 func (m *Metrics) RecordWebSocketError() {
-	m.mu.Lock()
-	m.WebSocketErrorsTotal++
-	m.mu.Unlock()
+	m.wsErrors.Inc()
+}
+
+// RecordWebSocketRejected increments the counter of connections refused by connection limits
+func (m *Metrics) RecordWebSocketRejected() {
+	m.wsRejected.Inc()
+}
+
+// SetWebSocketClientsByRole replaces the open frontend connections per user role
+func (m *Metrics) SetWebSocketClientsByRole(counts map[string]int) {
+	m.wsClientsByRole.Reset()
+	for role, n := range counts {
+		m.wsClientsByRole.WithLabelValues(role).Set(float64(n))
+	}
+}
+
+// RecordWebSocketDropped increments the undelivered message counter of a user role
+func (m *Metrics) RecordWebSocketDropped(role string) {
+	m.wsDropped.WithLabelValues(role).Inc()
+}
+
+// RecordWebSocketBroadcast records how long fanning out one message of msgType took
+func (m *Metrics) RecordWebSocketBroadcast(msgType string, duration time.Duration) {
+	m.wsBroadcastDuration.WithLabelValues(msgType).Observe(duration.Seconds())
+}
+
+// RecordWebSocketAckLatency records the broadcast-to-ack latency of one snapshot for a user role
+func (m *Metrics) RecordWebSocketAckLatency(role string, latency time.Duration) {
+	m.wsAckLatency.WithLabelValues(role).Observe(latency.Seconds())
+}
+
+// SetWebSocketStalledClients sets the number of frontend clients that stopped acknowledging snapshots
+func (m *Metrics) SetWebSocketStalledClients(n int) {
+	m.wsStalledClients.Set(float64(n))
+}
+
+// RecordBroadcastLatency records the end-to-end latency of one state change reaching the broadcast
+func (m *Metrics) RecordBroadcastLatency(fromOrigin, fromIngest time.Duration) {
+	m.broadcastLatency.WithLabelValues("origin").Observe(fromOrigin.Seconds())
+	m.broadcastLatency.WithLabelValues("ingest").Observe(fromIngest.Seconds())
 }
 
 // RecordAgentConnect increments agent connection counters
 func (m *Metrics) RecordAgentConnect() {
-	m.mu.Lock()
-	m.AgentConnectionsTotal++
-	m.activeAgentConnections++
-	m.mu.Unlock()
+	m.agentConnections.Inc()
+	m.agentActive.Inc()
 }
 
 // RecordAgentDisconnect increments agent disconnection counter
 func (m *Metrics) RecordAgentDisconnect() {
-	m.mu.Lock()
-	m.AgentDisconnectionsTotal++
-	m.activeAgentConnections--
-	m.mu.Unlock()
+	m.agentDisconnections.Inc()
+	m.agentActive.Dec()
 }
 
 // RecordAgentHeartbeat increments agent heartbeat counter
 func (m *Metrics) RecordAgentHeartbeat() {
-	m.mu.Lock()
-	m.AgentHeartbeatsTotal++
-	m.mu.Unlock()
+	m.agentHeartbeats.Inc()
 }
 
 // RecordAgentStateChange increments agent state change counter
 func (m *Metrics) RecordAgentStateChange() {
-	m.mu.Lock()
-	m.AgentStateChangesTotal++
-	m.mu.Unlock()
+	m.agentStateChanges.Inc()
 }
 
 // RecordAgentRegister increments agent registration counter
 func (m *Metrics) RecordAgentRegister() {
-	m.mu.Lock()
-	m.AgentRegistrationsTotal++
-	m.mu.Unlock()
+	m.agentRegistrations.Inc()
+}
+
+// SetAgentHubQueueDepth records how many messages wait in one of the AgentHub's inbound queues
+func (m *Metrics) SetAgentHubQueueDepth(channel string, depth int) {
+	m.agentQueueDepth.WithLabelValues(channel).Set(float64(depth))
+}
+
+// RecordAgentHubShed increments the counter of agent messages dropped from a full queue
+func (m *Metrics) RecordAgentHubShed(channel string) {
+	m.agentShed.WithLabelValues(channel).Inc()
+}
+
+// RecordAgentHubBlocked increments the counter of agent messages that waited for a full queue
+func (m *Metrics) RecordAgentHubBlocked(channel string) {
+	m.agentBlocked.WithLabelValues(channel).Inc()
+}
+
+// RecordAgentConnectionTransition counts an agent's connection status change
+func (m *Metrics) RecordAgentConnectionTransition(from, to string) {
+	m.agentTransitions.WithLabelValues(from, to).Inc()
+}
+
+// RecordAgentHubStale increments the counter of agent messages from superseded connections
+func (m *Metrics) RecordAgentHubStale(channel string) {
+	m.agentStale.WithLabelValues(channel).Inc()
+}
+
+// RecordAgentHubMessage counts a processed agent message with its worker queue wait and
+// processing time
+func (m *Metrics) RecordAgentHubMessage(channel string, wait, processing time.Duration) {
+	m.agentHubMessages.WithLabelValues(channel).Inc()
+	m.agentHubWait.WithLabelValues(channel).Observe(wait.Seconds())
+	m.agentHubProcessing.WithLabelValues(channel).Observe(processing.Seconds())
+}
+
+// RecordAgentTakeover increments the counter of superseded agent connections
+func (m *Metrics) RecordAgentTakeover() {
+	m.agentTakeovers.Inc()
+}
+
+// SetAgentHubThrottled records whether agent heartbeats are throttled
+func (m *Metrics) SetAgentHubThrottled(throttled bool) {
+	if throttled {
+		m.agentThrottled.Set(1)
+	} else {
+		m.agentThrottled.Set(0)
+	}
+}
+
+// RecordInvalidTransition counts a state change the transition table does not allow
+func (m *Metrics) RecordInvalidTransition(source string, from, to types.AgentState, action string) {
+	m.invalidTransitions.WithLabelValues(source, string(from), string(to), action).Inc()
+}
+
+// RecordAgentQuarantine increments the counter of agents quarantined for invalid transitions
+func (m *Metrics) RecordAgentQuarantine() {
+	m.agentQuarantines.Inc()
+}
+
+// RecordAgentMessageDiscarded counts a numbered agent message dropped by the processor
+func (m *Metrics) RecordAgentMessageDiscarded(msgType, reason string) {
+	m.agentDiscarded.WithLabelValues(msgType, reason).Inc()
 }
 
-// GetActiveAgentConnections returns current agent WebSocket connections
-func (m *Metrics) GetActiveAgentConnections() int64 {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	return m.activeAgentConnections
+// SetLoadShedLevel records a change of the snapshot degradation level (0 = full fidelity)
+func (m *Metrics) SetLoadShedLevel(level int, name string) {
+	m.loadShedLevel.Set(float64(level))
+	m.loadShedChanges.WithLabelValues(name).Inc()
+}
+
+// RecordSnapshotShed increments the counter of aggregation intervals skipped under load
+func (m *Metrics) RecordSnapshotShed() {
+	m.snapshotsShed.Inc()
 }
 
 // RecordAggregationCycle records an aggregation cycle
 func (m *Metrics) RecordAggregationCycle(duration time.Duration, widgetCount int) {
-	m.mu.Lock()
-	m.AggregationCyclesTotal++
-	m.WidgetsBroadcastTotal += int64(widgetCount)
-	m.lastAggregationDuration = duration
-	m.mu.Unlock()
+	m.aggregationCycles.Inc()
+	m.widgetsBroadcast.Add(float64(widgetCount))
+	m.aggregationDuration.Observe(duration.Seconds())
 }
 
 // RecordAggregationError increments aggregation error counter
 func (m *Metrics) RecordAggregationError() {
-	m.mu.Lock()
-	m.AggregationErrorsTotal++
-	m.mu.Unlock()
+	m.aggregationErrors.Inc()
 }
 
-// UpdateAgentStats updates agent distribution metrics
-func (m *Metrics) UpdateAgentStats(agents []types.AgentInfo) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// RecordRoutingTick records the duration of one routing pass
+func (m *Metrics) RecordRoutingTick(duration time.Duration) {
+	m.routingTickDuration.Observe(duration.Seconds())
+}
 
-	// Reset counts
-	m.agentsByState = make(map[types.AgentState]int)
-	m.agentsByDepartment = make(map[types.Department]int)
-	m.agentsByLocation = make(map[types.Location]int)
-	m.totalAgents = len(agents)
+// RecordCallOffered counts a call entering a VQ
+func (m *Metrics) RecordCallOffered(tenant string, vq types.VQName, dept types.Department) {
+	m.vqOffered.WithLabelValues(types.TenantOf(tenant), string(vq), string(dept)).Inc()
+}
 
-	for _, agent := range agents {
-		m.agentsByState[agent.State]++
-		m.agentsByDepartment[agent.Department]++
-		m.agentsByLocation[agent.Location]++
+// RecordCallRouted counts a call assigned to an agent and how long it waited for the assignment
+func (m *Metrics) RecordCallRouted(tenant string, vq types.VQName, dept types.Department, latency time.Duration) {
+	m.vqRouted.WithLabelValues(types.TenantOf(tenant), string(vq), string(dept)).Inc()
+	m.callAssignLatency.Observe(latency.Seconds())
+}
+
+// RecordCallAbandoned counts a call abandoned while waiting in a VQ
+func (m *Metrics) RecordCallAbandoned(tenant string, vq types.VQName, dept types.Department) {
+	m.vqAbandoned.WithLabelValues(types.TenantOf(tenant), string(vq), string(dept)).Inc()
+}
+
+// RecordOrphanedCall counts an active call released from a disconnected agent
+func (m *Metrics) RecordOrphanedCall(tenant string, vq types.VQName, action string) {
+	m.orphanedCalls.WithLabelValues(types.TenantOf(tenant), string(vq), action).Inc()
+}
+
+// RecordCallCompleteDiscrepancy counts a call_complete for a call no longer active with its
+// agent, and how far its talk time was off from the recorded one (0 = not compared)
+func (m *Metrics) RecordCallCompleteDiscrepancy(tenant string, vq types.VQName, result string, talkDiffSecs float64) {
+	m.callCompleteIssues.WithLabelValues(types.TenantOf(tenant), string(vq), result).Inc()
+	if talkDiffSecs > 0 {
+		m.talkTimeDiscrepancy.WithLabelValues(result).Observe(talkDiffSecs)
 	}
 }
 
-// RecordHTTPRequest records an HTTP request
-func (m *Metrics) RecordHTTPRequest(endpoint string, statusCode int, duration time.Duration) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// RecordQueueEvent counts an operational event of a VQ
+func (m *Metrics) RecordQueueEvent(tenant string, vq types.VQName, eventType types.QueueEventType) {
+	m.queueEvents.WithLabelValues(types.TenantOf(tenant), string(vq), string(eventType)).Inc()
+}
+
+// RecordCallCompleted counts a completed call
+func (m *Metrics) RecordCallCompleted(tenant string, vq types.VQName, dept types.Department) {
+	m.vqCompleted.WithLabelValues(types.TenantOf(tenant), string(vq), string(dept)).Inc()
+}
+
+// RecordCallHold counts a hold started on an active call
+func (m *Metrics) RecordCallHold(tenant string, vq types.VQName, dept types.Department) {
+	m.vqHolds.WithLabelValues(types.TenantOf(tenant), string(vq), string(dept)).Inc()
+}
 
-	if m.httpRequestsTotal[endpoint] == nil {
-		m.httpRequestsTotal[endpoint] = make(map[int]int64)
+// UpdateQueueStats sets the per-VQ gauges from the current queue snapshots
+func (m *Metrics) UpdateQueueStats(queues []types.VQSnapshot) {
+	for _, q := range queues {
+		tenant, vq, dept := types.TenantOf(q.Tenant), string(q.VQ), string(q.Department)
+		m.vqWaiting.WithLabelValues(tenant, vq, dept).Set(float64(q.WaitingCount))
+		m.vqActive.WithLabelValues(tenant, vq, dept).Set(float64(q.ActiveCount))
+		m.vqOnHold.WithLabelValues(tenant, vq, dept).Set(float64(q.OnHoldCount))
+		m.vqLongestWait.WithLabelValues(tenant, vq, dept).Set(q.LongestWaitSecs)
+		m.vqSL.WithLabelValues(tenant, vq, dept).Set(q.ServiceLevel.CurrentSL)
+		m.vqAvailable.WithLabelValues(tenant, vq, dept).Set(float64(q.AvailableAgents))
 	}
-	m.httpRequestsTotal[endpoint][statusCode]++
+}
+
+// RecordJWKSRefresh records a successful JWKS fetch
+func (m *Metrics) RecordJWKSRefresh() {
+	m.jwksRefreshes.Inc()
+	m.jwksLastRefresh.SetToCurrentTime()
+}
+
+// RecordJWKSRefreshError increments the JWKS fetch failure counter
+func (m *Metrics) RecordJWKSRefreshError() {
+	m.jwksRefreshErrors.Inc()
+}
+
+// SetStorageBreakerState records the DynamoDB circuit breaker state (0 closed, 1 half-open, 2 open)
+func (m *Metrics) SetStorageBreakerState(state int) {
+	m.storageBreakerState.Set(float64(state))
+}
 
-	// Keep last 100 durations for percentile calculation
-	if len(m.httpRequestDurations[endpoint]) >= 100 {
-		m.httpRequestDurations[endpoint] = m.httpRequestDurations[endpoint][1:]
+// SetStorageSpooled records how many records wait in the disk spool
+func (m *Metrics) SetStorageSpooled(n int) {
+	m.storageSpooled.Set(float64(n))
+}
+
+// RecordStorageSpoolDropped increments the dropped spool record counter
+func (m *Metrics) RecordStorageSpoolDropped() {
+	m.storageSpoolDropped.Inc()
+}
+
+// RecordWebhookDelivery records the outcome of a webhook event (delivered, failed or dropped)
+func (m *Metrics) RecordWebhookDelivery(subscriber, event, result string) {
+	m.webhookDeliveries.WithLabelValues(subscriber, event, result).Inc()
+}
+
+// RecordEventLog records an event log append (written or dropped)
+func (m *Metrics) RecordEventLog(eventType, result string) {
+	m.eventLogEvents.WithLabelValues(eventType, result).Inc()
+}
+
+// RecordBreakTime adds a finished break to the per-reason break time
+func (m *Metrics) RecordBreakTime(dept types.Department, reason types.BreakReason, secs float64) {
+	m.breakSeconds.WithLabelValues(string(dept), string(reason)).Add(secs)
+}
+
+// RecordBreakRequest records the outcome of a break request (granted or denied)
+func (m *Metrics) RecordBreakRequest(dept types.Department, reason types.BreakReason, result string) {
+	m.breakRequests.WithLabelValues(string(dept), string(reason), result).Inc()
+}
+
+// RecordShadowDecision records whether the shadow routing strategy agreed with the active one
+func (m *Metrics) RecordShadowDecision(divergent bool) {
+	result := "same"
+	if divergent {
+		result = "divergent"
+	}
+	m.shadowDecisions.WithLabelValues(result).Inc()
+}
+
+// RecordKPIDivergence records an agent's reported KPI starting to diverge from the observed one
+func (m *Metrics) RecordKPIDivergence(kpi string) {
+	m.kpiDivergence.WithLabelValues(kpi).Inc()
+}
+
+// RecordWebhookAttempt records one webhook HTTP request and its duration
+func (m *Metrics) RecordWebhookAttempt(subscriber string, d time.Duration) {
+	m.webhookAttempts.WithLabelValues(subscriber).Inc()
+	m.webhookLatency.WithLabelValues(subscriber).Observe(d.Seconds())
+}
+
+// RecordAuthFailure increments the auth failure counter for reason
+func (m *Metrics) RecordAuthFailure(reason string) {
+	m.authFailures.WithLabelValues(reason).Inc()
+}
+
+// RecordAuthLockout increments the lockout counter for scope ("ip" or "user")
+func (m *Metrics) RecordAuthLockout(scope string) {
+	m.authLockouts.WithLabelValues(scope).Inc()
+}
+
+// RecordRateLimited increments the rejected request counter for a rate limiter
+func (m *Metrics) RecordRateLimited(limiter string) {
+	m.rateLimited.WithLabelValues(limiter).Inc()
+}
+
+// UpdateAgentStats updates agent distribution metrics
+func (m *Metrics) UpdateAgentStats(agents []types.AgentInfo) {
+	byState := make(map[types.AgentState]int)
+	byDept := make(map[types.Department]int)
+	byLoc := make(map[types.Location]int)
+	for _, agent := range agents {
+		byState[agent.State]++
+		byDept[agent.Department]++
+		byLoc[agent.Location]++
+	}
+
+	m.agentStatsMu.Lock()
+	defer m.agentStatsMu.Unlock()
+
+	// Reset so states/departments that dropped to zero agents disappear
+	m.agentsByState.Reset()
+	m.agentsByDepartment.Reset()
+	m.agentsByLocation.Reset()
+	m.totalAgents.Set(float64(len(agents)))
+	for state, count := range byState {
+		m.agentsByState.WithLabelValues(string(state)).Set(float64(count))
+	}
+	for dept, count := range byDept {
+		m.agentsByDepartment.WithLabelValues(string(dept)).Set(float64(count))
+	}
+	for loc, count := range byLoc {
+		m.agentsByLocation.WithLabelValues(string(loc)).Set(float64(count))
 	}
-	m.httpRequestDurations[endpoint] = append(m.httpRequestDurations[endpoint], duration.Seconds())
 }
 
-// GetActiveConnections returns current WebSocket connections
-func (m *Metrics) GetActiveConnections() int64 {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	return m.activeConnections
+// RecordHTTPRequest records an HTTP request; endpoint should be a route pattern, not a raw path
+func (m *Metrics) RecordHTTPRequest(endpoint string, statusCode int, duration time.Duration) {
+	m.httpRequests.WithLabelValues(endpoint, strconv.Itoa(statusCode)).Inc()
+	m.httpRequestDuration.WithLabelValues(endpoint).Observe(duration.Seconds())
 }
 
 // Handler returns an HTTP handler for the /metrics endpoint
 func (m *Metrics) Handler() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		m.mu.RLock()
-		defer m.mu.RUnlock()
-
-		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-
-		// Helper to write metric
-		write := func(name string, value interface{}, labels ...string) {
-			labelStr := ""
-			if len(labels) > 0 {
-				labelStr = "{"
-				for i := 0; i < len(labels); i += 2 {
-					if i > 0 {
-						labelStr += ","
-					}
-					labelStr += labels[i] + "=\"" + labels[i+1] + "\""
-				}
-				labelStr += "}"
-			}
-
-			switch v := value.(type) {
-			case int:
-				w.Write([]byte(name + labelStr + " " + strconv.Itoa(v) + "\n"))
-			case int64:
-				w.Write([]byte(name + labelStr + " " + strconv.FormatInt(v, 10) + "\n"))
-			case float64:
-				w.Write([]byte(name + labelStr + " " + strconv.FormatFloat(v, 'f', 6, 64) + "\n"))
-			}
-		}
-
-		// System metrics
-		write("monti_uptime_seconds", time.Since(m.startTime).Seconds())
-
-		// Event metrics
-		write("monti_events_received_total", m.EventsReceivedTotal)
-		write("monti_events_processed_total", m.EventsProcessedTotal)
-		write("monti_event_processing_errors_total", m.EventProcessingErrors)
-
-		// Calculate events per second
-		uptimeSeconds := time.Since(m.startTime).Seconds()
-		if uptimeSeconds > 0 {
-			write("monti_events_per_second", float64(m.EventsReceivedTotal)/uptimeSeconds)
-		}
-
-		// WebSocket metrics (frontend clients)
-		write("monti_websocket_connections_total", m.WebSocketConnectionsTotal)
-		write("monti_websocket_disconnections_total", m.WebSocketDisconnectionsTotal)
-		write("monti_websocket_active_connections", m.activeConnections)
-		write("monti_websocket_messages_total", m.WebSocketMessagesTotal)
-		write("monti_websocket_errors_total", m.WebSocketErrorsTotal)
-
-		// Agent WebSocket metrics
-		write("monti_agent_connections_total", m.AgentConnectionsTotal)
-		write("monti_agent_disconnections_total", m.AgentDisconnectionsTotal)
-		write("monti_agent_active_connections", m.activeAgentConnections)
-		write("monti_agent_heartbeats_total", m.AgentHeartbeatsTotal)
-		write("monti_agent_state_changes_total", m.AgentStateChangesTotal)
-		write("monti_agent_registrations_total", m.AgentRegistrationsTotal)
-
-		// Aggregation metrics
-		write("monti_aggregation_cycles_total", m.AggregationCyclesTotal)
-		write("monti_widgets_broadcast_total", m.WidgetsBroadcastTotal)
-		write("monti_aggregation_errors_total", m.AggregationErrorsTotal)
-		write("monti_aggregation_duration_seconds", m.lastAggregationDuration.Seconds())
-
-		// Agent metrics
-		write("monti_agents_total", m.totalAgents)
-
-		// Agents by state
-		for state, count := range m.agentsByState {
-			write("monti_agents_by_state", count, "state", string(state))
-		}
-
-		// Agents by department
-		for dept, count := range m.agentsByDepartment {
-			write("monti_agents_by_department", count, "department", string(dept))
-		}
-
-		// Agents by location
-		for loc, count := range m.agentsByLocation {
-			write("monti_agents_by_location", count, "location", string(loc))
-		}
-
-		// HTTP metrics
-		for endpoint, statusCodes := range m.httpRequestsTotal {
-			for status, count := range statusCodes {
-				write("monti_http_requests_total", count, "endpoint", endpoint, "status", strconv.Itoa(status))
-			}
-		}
-	}
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}).ServeHTTP
 }
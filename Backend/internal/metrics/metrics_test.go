@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestHandlerExposition(t *testing.T) {
+	m := newMetrics(prometheus.NewRegistry())
+	m.RecordEventReceived()
+	m.RecordHTTPRequest("GET /api/agents", http.StatusOK, 20*time.Millisecond)
+	m.RecordAggregationCycle(3*time.Millisecond, 1)
+	m.RecordAuthFailure(`bad"reason`)
+	m.UpdateAgentStats([]types.AgentInfo{
+		{State: types.StateAvailable, Department: types.DeptSales, Location: types.LocationBerlin},
+	})
+	m.UpdateAgentStats(nil)
+	m.RecordCallRouted("", types.VQSalesInbound, types.DeptSales, 4*time.Second)
+	m.RecordAgentHubMessage("state_change", time.Millisecond, 2*time.Millisecond)
+	m.UpdateQueueStats([]types.VQSnapshot{
+		{VQ: types.VQSalesInbound, Department: types.DeptSales, WaitingCount: 3, LongestWaitSecs: 42},
+		{VQ: types.VQSalesInbound, Tenant: "acme", Department: types.DeptSales, WaitingCount: 5},
+	})
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		"monti_events_received_total 1",
+		`monti_http_requests_total{endpoint="GET /api/agents",status="200"} 1`,
+		`monti_http_request_duration_seconds_bucket{endpoint="GET /api/agents",le="0.025"} 1`,
+		"monti_aggregation_duration_seconds_count 1",
+		`monti_auth_failures_total{reason="bad\"reason"} 1`,
+		"monti_agents_total 0",
+		"go_goroutines",
+		`monti_agent_hub_messages_total{channel="state_change"} 1`,
+		`monti_agent_hub_processing_seconds_count{channel="state_change"} 1`,
+		`monti_vq_calls_routed_total{department="sales",tenant="default",vq="sales_inbound"} 1`,
+		`monti_vq_waiting_calls{department="sales",tenant="default",vq="sales_inbound"} 3`,
+		`monti_vq_waiting_calls{department="sales",tenant="acme",vq="sales_inbound"} 5`,
+		`monti_vq_longest_wait_seconds{department="sales",tenant="default",vq="sales_inbound"} 42`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected exposition to contain %q", want)
+		}
+	}
+	if strings.Contains(body, `monti_agents_by_state{state="available"}`) {
+		t.Error("expected agent state gauges to be reset when agents disappear")
+	}
+}
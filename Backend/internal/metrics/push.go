@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/rs/zerolog"
+)
+
+// pushFlushTimeout bounds the final push on shutdown
+const pushFlushTimeout = 5 * time.Second
+
+// Sample is the value of one metric series at push time
+type Sample struct {
+	Name    string
+	Labels  map[string]string
+	Value   float64
+	Counter bool // cumulative; statsd sends the increase since the previous push
+}
+
+// Sink receives pushed samples (Prometheus remote write, statsd)
+type Sink interface {
+	Name() string
+	Push(ctx context.Context, samples []Sample, at time.Time) error
+}
+
+// Pusher pushes the metrics to sinks every interval, for deployments without a Prometheus
+// scraping /metrics (e.g. short-lived load-test pods)
+type Pusher struct {
+	gather   func() ([]Sample, error)
+	sinks    []Sink
+	interval time.Duration
+	logger   zerolog.Logger
+}
+
+// NewPusher creates a pusher of the samples returned by gather
+func NewPusher(gather func() ([]Sample, error), interval time.Duration, logger zerolog.Logger) *Pusher {
+	return &Pusher{
+		gather:   gather,
+		interval: interval,
+		logger:   logger.With().Str("component", "metrics_push").Logger(),
+	}
+}
+
+// AddSink adds a push target; must be called before Run
+func (p *Pusher) AddSink(s Sink) {
+	p.sinks = append(p.sinks, s)
+}
+
+// Enabled reports whether any sink is configured
+func (p *Pusher) Enabled() bool {
+	return len(p.sinks) > 0
+}
+
+// Run pushes every interval until ctx is done, then pushes once more so the last values of a
+// short-lived process are not lost
+func (p *Pusher) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), pushFlushTimeout)
+			p.push(flushCtx)
+			cancel()
+			return
+		case <-ticker.C:
+			p.push(ctx)
+		}
+	}
+}
+
+// push sends the current samples to every sink; failures are logged and retried next interval
+func (p *Pusher) push(ctx context.Context) {
+	samples, err := p.gather()
+	if err != nil {
+		p.logger.Warn().Err(err).Msg("failed to gather metrics for push")
+	}
+	now := time.Now()
+	for _, s := range p.sinks {
+		if err := s.Push(ctx, samples, now); err != nil {
+			p.logger.Warn().Err(err).Str("sink", s.Name()).Int("samples", len(samples)).Msg("metrics push failed")
+		}
+	}
+}
+
+// Samples flattens the registry into samples the way /metrics exposes them: histograms and
+// summaries become their _bucket/quantile, _sum and _count series
+func (m *Metrics) Samples() ([]Sample, error) {
+	families, err := m.registry.Gather()
+	var samples []Sample
+	for _, mf := range families {
+		name := mf.GetName()
+		for _, metric := range mf.GetMetric() {
+			labels := make(map[string]string, len(metric.GetLabel()))
+			for _, lp := range metric.GetLabel() {
+				labels[lp.GetName()] = lp.GetValue()
+			}
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				samples = append(samples, Sample{Name: name, Labels: labels, Value: metric.GetCounter().GetValue(), Counter: true})
+			case dto.MetricType_GAUGE:
+				samples = append(samples, Sample{Name: name, Labels: labels, Value: metric.GetGauge().GetValue()})
+			case dto.MetricType_UNTYPED:
+				samples = append(samples, Sample{Name: name, Labels: labels, Value: metric.GetUntyped().GetValue()})
+			case dto.MetricType_HISTOGRAM:
+				h := metric.GetHistogram()
+				for _, b := range h.GetBucket() {
+					samples = append(samples, Sample{Name: name + "_bucket", Labels: withLabel(labels, "le", formatBound(b.GetUpperBound())),
+						Value: float64(b.GetCumulativeCount()), Counter: true})
+				}
+				samples = append(samples,
+					Sample{Name: name + "_bucket", Labels: withLabel(labels, "le", "+Inf"), Value: float64(h.GetSampleCount()), Counter: true},
+					Sample{Name: name + "_sum", Labels: labels, Value: h.GetSampleSum(), Counter: true},
+					Sample{Name: name + "_count", Labels: labels, Value: float64(h.GetSampleCount()), Counter: true})
+			case dto.MetricType_SUMMARY:
+				s := metric.GetSummary()
+				for _, q := range s.GetQuantile() {
+					samples = append(samples, Sample{Name: name, Labels: withLabel(labels, "quantile", formatBound(q.GetQuantile())), Value: q.GetValue()})
+				}
+				samples = append(samples,
+					Sample{Name: name + "_sum", Labels: labels, Value: s.GetSampleSum(), Counter: true},
+					Sample{Name: name + "_count", Labels: labels, Value: float64(s.GetSampleCount()), Counter: true})
+			}
+		}
+	}
+	return samples, err
+}
+
+// withLabel returns a copy of labels with name set to value
+func withLabel(labels map[string]string, name, value string) map[string]string {
+	out := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		out[k] = v
+	}
+	out[name] = value
+	return out
+}
+
+// formatBound formats a bucket bound or quantile like the Prometheus text format
+func formatBound(v float64) string {
+	if math.IsInf(v, +1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
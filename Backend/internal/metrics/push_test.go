@@ -0,0 +1,193 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestSamplesFlattenHistograms(t *testing.T) {
+	m := newMetrics(prometheus.NewRegistry())
+	m.RecordEventReceived()
+	m.RecordAggregationCycle(3*time.Millisecond, 1)
+
+	samples, err := m.Samples()
+	if err != nil {
+		t.Fatalf("Samples: %v", err)
+	}
+	found := map[string]Sample{}
+	for _, s := range samples {
+		if s.Name == "monti_aggregation_duration_seconds_bucket" && s.Labels["le"] != "+Inf" {
+			continue
+		}
+		found[s.Name] = s
+	}
+	if s := found["monti_events_received_total"]; !s.Counter || s.Value != 1 {
+		t.Errorf("expected the events counter at 1, got %+v", s)
+	}
+	if s := found["monti_aggregation_duration_seconds_count"]; s.Value != 1 {
+		t.Errorf("expected a histogram count of 1, got %+v", s)
+	}
+	if s := found["monti_aggregation_duration_seconds_bucket"]; s.Value != 1 {
+		t.Errorf("expected the +Inf bucket at 1, got %+v", s)
+	}
+}
+
+func TestRemoteWriteSinkPush(t *testing.T) {
+	var body []byte
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	sink := NewRemoteWriteSink(srv.URL, map[string]string{"job": "monti-backend"})
+	samples := []Sample{{Name: "monti_agents_total", Labels: map[string]string{"state": "available"}, Value: 12}}
+	if err := sink.Push(context.Background(), samples, time.Now()); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+
+	if header.Get("Content-Encoding") != "snappy" || header.Get("X-Prometheus-Remote-Write-Version") != "0.1.0" {
+		t.Errorf("unexpected headers %v", header)
+	}
+	series := decodeLabels(t, body)
+	want := map[string]string{"__name__": "monti_agents_total", "job": "monti-backend", "state": "available"}
+	if len(series) != 1 || len(series[0]) != len(want) {
+		t.Fatalf("expected one series labelled %v, got %v", want, series)
+	}
+	for k, v := range want {
+		if series[0][k] != v {
+			t.Errorf("expected %s=%q, got %q", k, v, series[0][k])
+		}
+	}
+}
+
+func TestRemoteWriteSinkError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "out of order sample", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	err := NewRemoteWriteSink(srv.URL, nil).Push(context.Background(), []Sample{{Name: "up", Value: 1}}, time.Now())
+	if err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("expected a 400 error, got %v", err)
+	}
+}
+
+func TestStatsdSinkSendsCounterIncreases(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no UDP socket: %v", err)
+	}
+	defer conn.Close()
+
+	sink := NewStatsdSink(conn.LocalAddr().String(), StatsdFormatDatadog)
+	read := func() string {
+		buf := make([]byte, statsdPacketSize)
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		return string(buf[:n])
+	}
+
+	push := func(calls float64) {
+		samples := []Sample{
+			{Name: "monti_vq_calls_routed_total", Labels: map[string]string{"vq": "sales"}, Value: calls, Counter: true},
+			{Name: "monti_vq_waiting_calls", Labels: map[string]string{"vq": "sales"}, Value: 3},
+		}
+		if err := sink.Push(context.Background(), samples, time.Now()); err != nil {
+			t.Fatalf("Push: %v", err)
+		}
+	}
+
+	push(5)
+	if got, want := read(), "monti_vq_calls_routed_total:5|c|#vq:sales\nmonti_vq_waiting_calls:3|g|#vq:sales"; got != want {
+		t.Errorf("first push: got %q, want %q", got, want)
+	}
+	push(7)
+	if got, want := read(), "monti_vq_calls_routed_total:2|c|#vq:sales\nmonti_vq_waiting_calls:3|g|#vq:sales"; got != want {
+		t.Errorf("second push: got %q, want %q", got, want)
+	}
+}
+
+// decodeLabels decodes a snappy-compressed WriteRequest into the label sets of its series
+func decodeLabels(t *testing.T, body []byte) []map[string]string {
+	t.Helper()
+	raw, err := snappy.Decode(nil, body)
+	if err != nil {
+		t.Fatalf("snappy: %v", err)
+	}
+	// fields walks the length-delimited fields of a message, calling fn with their number
+	fields := func(b []byte, fn func(num protowire.Number, v []byte)) {
+		for len(b) > 0 {
+			num, typ, n := protowire.ConsumeTag(b)
+			if n < 0 {
+				t.Fatalf("bad tag: %v", protowire.ParseError(n))
+			}
+			b = b[n:]
+			if typ != protowire.BytesType {
+				n = protowire.ConsumeFieldValue(num, typ, b)
+				b = b[n:]
+				continue
+			}
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				t.Fatalf("bad field %d: %v", num, protowire.ParseError(n))
+			}
+			fn(num, v)
+			b = b[n:]
+		}
+	}
+	var series []map[string]string
+	fields(raw, func(_ protowire.Number, ts []byte) {
+		labels := map[string]string{}
+		fields(ts, func(num protowire.Number, v []byte) {
+			if num != 1 {
+				return
+			}
+			var name string
+			fields(v, func(num protowire.Number, s []byte) {
+				if num == 1 {
+					name = string(s)
+				} else {
+					labels[name] = string(s)
+				}
+			})
+		})
+		series = append(series, labels)
+	})
+	return series
+}
+
+// writeRequestVector is a WriteRequest of two series with an extra job label. AgentSim's copy of
+// encodeWriteRequest is tested against the same bytes, which keeps the two in sync.
+const writeRequestVector = "0a470a140a085f5f6e616d655f5f12086d6f6e74695f75700a0c0a036a6f6212056d6f6e74690a0f0a04726f6c6512076261636b656e64121009000000000000f03f1080d095ffbc310a540a1d0a085f5f6e616d655f5f12116d6f6e74695f63616c6c735f746f74616c0a0c0a036a6f6212056d6f6e74690a130a027671120d73616c65735f696e626f756e6412100900000000004045401080d095ffbc31"
+
+func TestEncodeWriteRequestVector(t *testing.T) {
+	samples := []Sample{
+		{Name: "monti_up", Labels: map[string]string{"role": "backend"}, Value: 1},
+		{Name: "monti_calls_total", Labels: map[string]string{"vq": "sales_inbound"}, Value: 42.5, Counter: true},
+	}
+	got := encodeWriteRequest(samples, map[string]string{"job": "monti"}, 1700000000000)
+	if hex.EncodeToString(got) != writeRequestVector {
+		t.Fatalf("WriteRequest encoding changed:\n got %x\nwant %s", got, writeRequestVector)
+	}
+	if decoded, err := snappy.Decode(nil, snappy.Encode(nil, got)); err != nil || !bytes.Equal(decoded, got) {
+		t.Errorf("expected the request to round-trip through snappy, got %v", err)
+	}
+}
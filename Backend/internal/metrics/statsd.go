@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// statsdPacketSize keeps UDP packets below the usual 1500 byte MTU
+const statsdPacketSize = 1432
+
+// Statsd line formats
+const (
+	StatsdFormatDatadog = "datadog" // DogStatsD: labels become tags (name:value|g|#label:value)
+	StatsdFormatPlain   = "statsd"  // plain statsd: label values are appended to the name
+)
+
+// StatsdSink pushes samples over UDP in statsd or DogStatsD format. Gauges are sent as
+// gauges, counters as the increase since the previous push.
+type StatsdSink struct {
+	addr   string
+	format string
+	conn   net.Conn
+	last   map[string]float64 // counter values at the previous push, by series
+}
+
+// NewStatsdSink creates a sink sending to addr (host:port) in format; the socket is opened lazily
+func NewStatsdSink(addr, format string) *StatsdSink {
+	return &StatsdSink{addr: addr, format: format, last: make(map[string]float64)}
+}
+
+// Name identifies the sink in logs
+func (s *StatsdSink) Name() string {
+	return "statsd"
+}
+
+// Push writes the samples in packets of up to statsdPacketSize bytes
+func (s *StatsdSink) Push(_ context.Context, samples []Sample, _ time.Time) error {
+	if s.conn == nil {
+		conn, err := net.Dial("udp", s.addr)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	var packet []byte
+	for _, smp := range samples {
+		line := s.line(smp)
+		if line == "" {
+			continue
+		}
+		if len(packet) > 0 && len(packet)+1+len(line) > statsdPacketSize {
+			if _, err := s.conn.Write(packet); err != nil {
+				return err
+			}
+			packet = packet[:0]
+		}
+		if len(packet) > 0 {
+			packet = append(packet, '\n')
+		}
+		packet = append(packet, line...)
+	}
+	if len(packet) > 0 {
+		_, err := s.conn.Write(packet)
+		return err
+	}
+	return nil
+}
+
+// line formats one sample; counters that did not grow are skipped
+func (s *StatsdSink) line(smp Sample) string {
+	keys := make([]string, 0, len(smp.Labels))
+	for k := range smp.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	name := smp.Name
+	var tags []string
+	for _, k := range keys {
+		if s.format == StatsdFormatPlain {
+			name += "." + statsdSanitize(smp.Labels[k])
+		} else {
+			tags = append(tags, statsdSanitize(k)+":"+statsdSanitize(smp.Labels[k]))
+		}
+	}
+
+	value, kind := smp.Value, "g"
+	if smp.Counter {
+		key := name + "|" + strings.Join(tags, ",")
+		value -= s.last[key]
+		s.last[key] = smp.Value
+		if value <= 0 {
+			// Unchanged, or reset by a restart of the process
+			return ""
+		}
+		kind = "c"
+	}
+
+	line := name + ":" + strconv.FormatFloat(value, 'g', -1, 64) + "|" + kind
+	if len(tags) > 0 {
+		line += "|#" + strings.Join(tags, ",")
+	}
+	return line
+}
+
+// statsdSanitize replaces the characters statsd uses as separators
+func statsdSanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '|', ',', '#', '@', '\n', ' ':
+			return '_'
+		}
+		return r
+	}, s)
+}
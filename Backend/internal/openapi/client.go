@@ -0,0 +1,300 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// GenerateClient renders one Client method per operation for package pkg. The methods call
+// the hand-written do and doRaw helpers of pkg/client. Extra types are only aliased.
+func GenerateClient(pkg string, ops []Operation, extra ...interface{}) ([]byte, error) {
+	g := &generator{
+		imports: map[string]string{"context": "context"},
+		aliases: make(map[string]reflect.Type),
+	}
+	for _, v := range extra {
+		g.aliasFields(reflect.TypeOf(v))
+	}
+	var body bytes.Buffer
+	for _, op := range ops {
+		if err := g.method(&body, op); err != nil {
+			return nil, fmt.Errorf("operation %s: %w", op.ID, err)
+		}
+	}
+
+	// Aliases are rendered first since qualifying their targets adds imports
+	var aliases bytes.Buffer
+	if len(g.aliases) > 0 {
+		names := make([]string, 0, len(g.aliases))
+		for name := range g.aliases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		aliases.WriteString("\n// Types of the API bodies, re-exported because their package is internal\ntype (\n")
+		for _, name := range names {
+			fmt.Fprintf(&aliases, "\t%s = %s\n", name, g.qualified(g.aliases[name]))
+		}
+		aliases.WriteString(")\n")
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by cmd/clientgen from internal/api.Operations; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\nimport (\n", pkg)
+	paths := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+	// standard library first, like goimports
+	sort.Slice(paths, func(i, j int) bool {
+		if si, sj := isStdlib(paths[i]), isStdlib(paths[j]); si != sj {
+			return si
+		}
+		return paths[i] < paths[j]
+	})
+	for i, path := range paths {
+		if i > 0 && isStdlib(paths[i-1]) && !isStdlib(path) {
+			out.WriteString("\n")
+		}
+		fmt.Fprintf(&out, "\t%q\n", path)
+	}
+	out.WriteString(")\n")
+	out.Write(aliases.Bytes())
+	out.Write(body.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated client does not parse: %w", err)
+	}
+	return src, nil
+}
+
+type generator struct {
+	imports map[string]string       // import path -> package name
+	aliases map[string]reflect.Type // alias name -> internal type
+}
+
+// method writes one client method
+func (g *generator) method(w *bytes.Buffer, op Operation) error {
+	params := []string{"ctx context.Context"}
+	pathExpr, err := g.pathExpr(op, &params)
+	if err != nil {
+		return err
+	}
+
+	query := "nil"
+	if len(op.Query) > 0 {
+		g.imports["net/url"] = "url"
+		params = append(params, "query url.Values")
+		query = "query"
+	}
+
+	body := "nil"
+	if op.Request != nil {
+		if op.requestType() == ContentJSON {
+			params = append(params, "body "+g.typeExpr(reflect.TypeOf(op.Request)))
+		} else {
+			g.imports["io"] = "io"
+			params = append(params, "body io.Reader")
+		}
+		body = "body"
+	}
+
+	fmt.Fprintf(w, "\n// %s calls %s %s.\n", op.ID, op.Method, op.Path)
+	if op.Summary != "" {
+		fmt.Fprintf(w, "// %s\n", strings.TrimSuffix(op.Summary, ".")+".")
+	}
+	sig := fmt.Sprintf("func (c *Client) %s(%s)", op.ID, strings.Join(params, ", "))
+	method := fmt.Sprintf("%q", op.Method)
+
+	switch {
+	case op.Request != nil && op.requestType() != ContentJSON:
+		// raw request body; the response is decoded as usual
+		if op.Response == nil {
+			fmt.Fprintf(w, "%s error {\n\t_, err := c.doRaw(ctx, %s, %s, %s, %q, body)\n\treturn err\n}\n", sig, method, pathExpr, query, op.requestType())
+			return nil
+		}
+		out := g.resultType(reflect.TypeOf(op.Response))
+		fmt.Fprintf(w, "%s (%s, error) {\n\tdata, err := c.doRaw(ctx, %s, %s, %s, %q, body)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n", sig, out, method, pathExpr, query, op.requestType())
+		fmt.Fprintf(w, "\tvar out %s\n\tif err := decode(data, &out); err != nil {\n\t\treturn nil, err\n\t}\n\treturn %s, nil\n}\n", g.typeExpr(elem(reflect.TypeOf(op.Response))), resultValue(reflect.TypeOf(op.Response)))
+	case op.Response != nil && op.responseType() != ContentJSON:
+		fmt.Fprintf(w, "%s ([]byte, error) {\n\treturn c.doRaw(ctx, %s, %s, %s, \"\", nil)\n}\n", sig, method, pathExpr, query)
+	case op.Response == nil:
+		fmt.Fprintf(w, "%s error {\n\treturn c.do(ctx, %s, %s, %s, %s, nil)\n}\n", sig, method, pathExpr, query, jsonBody(op, body))
+	default:
+		t := reflect.TypeOf(op.Response)
+		fmt.Fprintf(w, "%s (%s, error) {\n\tvar out %s\n", sig, g.resultType(t), g.typeExpr(elem(t)))
+		fmt.Fprintf(w, "\tif err := c.do(ctx, %s, %s, %s, %s, &out); err != nil {\n\t\treturn nil, err\n\t}\n\treturn %s, nil\n}\n", method, pathExpr, query, jsonBody(op, body), resultValue(t))
+	}
+	return nil
+}
+
+// jsonBody returns the body argument for c.do
+func jsonBody(op Operation, body string) string {
+	if op.Request == nil {
+		return "nil"
+	}
+	return body
+}
+
+// pathExpr turns "/api/agents/{agentId}" into `"/api/agents/" + url.PathEscape(agentID)`
+func (g *generator) pathExpr(op Operation, params *[]string) (string, error) {
+	names := op.PathParams()
+	if len(names) == 0 {
+		return fmt.Sprintf("%q", op.Path), nil
+	}
+	g.imports["net/url"] = "url"
+
+	var parts []string
+	rest := op.Path
+	for _, name := range names {
+		placeholder := "{" + name + "}"
+		i := strings.Index(rest, placeholder)
+		if rest[:i] != "" {
+			parts = append(parts, fmt.Sprintf("%q", rest[:i]))
+		}
+		ident := goIdent(name)
+		switch ident {
+		case "", "ctx", "query", "body", "c", "out", "data", "err":
+			return "", fmt.Errorf("path parameter %q cannot be used as a Go parameter name", name)
+		}
+		*params = append(*params, ident+" string")
+		parts = append(parts, "url.PathEscape("+ident+")")
+		rest = rest[i+len(placeholder):]
+	}
+	if rest != "" {
+		parts = append(parts, fmt.Sprintf("%q", rest))
+	}
+	return strings.Join(parts, " + "), nil
+}
+
+// resultType is the method's return type: structs by pointer, slices and maps by value
+func (g *generator) resultType(t reflect.Type) string {
+	t = elem(t)
+	if t.Kind() == reflect.Struct {
+		return "*" + g.typeExpr(t)
+	}
+	return g.typeExpr(t)
+}
+
+// resultValue returns the expression that turns out into the result type
+func resultValue(t reflect.Type) string {
+	if elem(t).Kind() == reflect.Struct {
+		return "&out"
+	}
+	return "out"
+}
+
+func elem(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// typeExpr renders t as Go source, recording the imports it needs. Named types from internal
+// packages are referenced through an alias so callers outside the module can name them.
+func (g *generator) typeExpr(t reflect.Type) string {
+	if t.Name() != "" {
+		if isInternal(t.PkgPath()) {
+			return g.alias(t)
+		}
+		return g.qualified(t)
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return "*" + g.typeExpr(t.Elem())
+	case reflect.Slice:
+		return "[]" + g.typeExpr(t.Elem())
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), g.typeExpr(t.Elem()))
+	case reflect.Map:
+		return "map[" + g.typeExpr(t.Key()) + "]" + g.typeExpr(t.Elem())
+	case reflect.Interface:
+		return "interface{}"
+	}
+	return t.String()
+}
+
+// qualified renders a named type as pkg.Name
+func (g *generator) qualified(t reflect.Type) string {
+	if t.PkgPath() == "" {
+		return t.Name()
+	}
+	name := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+	g.imports[t.PkgPath()] = name
+	return name + "." + t.Name()
+}
+
+// alias registers an alias for an internal type and, for structs, for the types of its fields
+func (g *generator) alias(t reflect.Type) string {
+	name := t.Name()
+	if other, ok := g.aliases[name]; ok {
+		if other == t {
+			return name
+		}
+		pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+		if _, ok := g.aliases[name]; ok {
+			return name
+		}
+	}
+	g.aliases[name] = t
+	if t.Kind() == reflect.Struct {
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.IsExported() {
+				g.aliasFields(f.Type)
+			}
+		}
+	}
+	return name
+}
+
+// aliasFields aliases the internal types reachable from a field without importing anything else
+func (g *generator) aliasFields(t reflect.Type) {
+	switch {
+	case t.Name() != "":
+		if isInternal(t.PkgPath()) {
+			g.alias(t)
+		}
+	case t.Kind() == reflect.Map:
+		g.aliasFields(t.Key())
+		g.aliasFields(t.Elem())
+	case t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		g.aliasFields(t.Elem())
+	}
+}
+
+func isStdlib(path string) bool {
+	return !strings.Contains(strings.SplitN(path, "/", 2)[0], ".")
+}
+
+func isInternal(pkgPath string) bool {
+	return strings.HasSuffix(pkgPath, "/internal") || strings.Contains(pkgPath, "/internal/")
+}
+
+// goIdent turns a path parameter such as "agentId" or "vq-name" into a Go identifier
+func goIdent(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = b.Len() > 0
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	s := b.String()
+	if strings.HasSuffix(s, "Id") {
+		s = strings.TrimSuffix(s, "Id") + "ID"
+	}
+	return s
+}
@@ -0,0 +1,63 @@
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Message is a WebSocket message type published by MessageSchema
+type Message struct {
+	Channel     string      // WebSocket path, e.g. "/ws"
+	Direction   string      // "server" (sent by the backend) or "client" (sent to it)
+	Types       []string    // values of the message's "type" field
+	Description string      // optional
+	Value       interface{} // Go struct the message is encoded from
+}
+
+// MessageSchema builds a JSON Schema (draft 2020-12) document for WebSocket messages. Every
+// message struct becomes a definition under $defs whose "type" property is restricted to the
+// message's Types. The document validates any of the messages, and x-messages maps each
+// channel, direction and type value to its definition.
+func MessageSchema(title, version string, messages []Message) (map[string]interface{}, error) {
+	schemas := newSchemaSet("#/$defs/")
+	seen := make(map[reflect.Type]bool)
+	var refs, index []interface{}
+
+	for _, m := range messages {
+		t := reflect.TypeOf(m.Value)
+		if t == nil || t.Kind() != reflect.Struct || t.Name() == "" {
+			return nil, fmt.Errorf("message %v on %s: value must be a named struct", m.Types, m.Channel)
+		}
+		if len(m.Types) == 0 || seen[t] {
+			return nil, fmt.Errorf("message %s on %s: missing type values or struct used twice", t.Name(), m.Channel)
+		}
+		seen[t] = true
+
+		ref := schemas.schema(t)
+		def := schemas.defs[schemas.name(t)].(map[string]interface{})
+		props := def["properties"].(map[string]interface{})
+		if _, ok := props["type"]; !ok {
+			return nil, fmt.Errorf("message %s on %s: struct has no type field", t.Name(), m.Channel)
+		}
+		props["type"] = map[string]interface{}{"type": "string", "enum": m.Types}
+		if m.Description != "" {
+			def["description"] = m.Description
+		}
+		refs = append(refs, ref)
+
+		for _, typ := range m.Types {
+			index = append(index, map[string]interface{}{
+				"channel": m.Channel, "direction": m.Direction, "type": typ, "schema": ref["$ref"],
+			})
+		}
+	}
+
+	return map[string]interface{}{
+		"$schema":    "https://json-schema.org/draft/2020-12/schema",
+		"title":      title,
+		"$comment":   "version " + version,
+		"$defs":      schemas.defs,
+		"anyOf":      refs,
+		"x-messages": index,
+	}, nil
+}
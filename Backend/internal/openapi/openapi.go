@@ -0,0 +1,245 @@
+// Package openapi builds the OpenAPI 3 document and the Go client (pkg/client) from a table
+// of operations whose request and response bodies are Go types, so neither can drift from the
+// structs the handlers encode.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Auth is how an operation authenticates
+type Auth string
+
+const (
+	AuthNone    Auth = ""        // public
+	AuthBearer  Auth = "bearer"  // OIDC JWT in Authorization: Bearer
+	AuthService Auth = "service" // shared INTERNAL_AUTH_TOKEN in X-Internal-Token
+)
+
+// Content types other than JSON
+const (
+	ContentJSON = "application/json"
+	ContentCSV  = "text/csv"
+	ContentICS  = "text/calendar"
+)
+
+// Param is a query parameter
+type Param struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// Operation describes one route. Request and Response hold a zero value of the body type
+// (nil for no body); a nil Response with Status 204 means an empty success response.
+type Operation struct {
+	ID          string // operationId and client method name, e.g. "ListAgents"
+	Method      string
+	Path        string // chi pattern, e.g. "/api/agents/{agentId}/history"
+	Tag         string
+	Summary     string
+	Description string
+	Auth        Auth
+	Roles       []string // roles allowed besides the route's default scope, for the description
+	Query       []Param
+
+	Request     interface{}
+	RequestType string // content type of the request body; default ContentJSON
+
+	Response     interface{}
+	ResponseType string // content type of the success body; default ContentJSON
+	Status       int    // success status; default 200
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// PathParams returns the {name} segments of the path in order
+func (op Operation) PathParams() []string {
+	var names []string
+	for _, m := range pathParam.FindAllStringSubmatch(op.Path, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+func (op Operation) status() int {
+	if op.Status == 0 {
+		return http.StatusOK
+	}
+	return op.Status
+}
+
+func (op Operation) requestType() string {
+	if op.RequestType == "" {
+		return ContentJSON
+	}
+	return op.RequestType
+}
+
+func (op Operation) responseType() string {
+	if op.ResponseType == "" {
+		return ContentJSON
+	}
+	return op.ResponseType
+}
+
+// Document builds the OpenAPI 3.0 document for ops. Extra types (e.g. WebSocket messages) are
+// added to components/schemas without being referenced by an operation.
+func Document(title, version string, ops []Operation, extra ...interface{}) (map[string]interface{}, error) {
+	schemas := newSchemaSet("#/components/schemas/")
+	for _, v := range extra {
+		schemas.schema(reflect.TypeOf(v))
+	}
+	paths := make(map[string]map[string]interface{})
+	ids := make(map[string]bool)
+
+	for _, op := range ops {
+		if op.ID == "" || ids[op.ID] {
+			return nil, fmt.Errorf("operation %s %s: missing or duplicate ID %q", op.Method, op.Path, op.ID)
+		}
+		ids[op.ID] = true
+
+		item, ok := paths[op.Path]
+		if !ok {
+			item = make(map[string]interface{})
+			paths[op.Path] = item
+		}
+		item[strings.ToLower(op.Method)] = operationObject(op, schemas)
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info":    map[string]interface{}{"title": title, "version": version},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": schemas.defs,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth":   map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+				"serviceToken": map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-Internal-Token"},
+			},
+		},
+	}, nil
+}
+
+// operationObject renders one operation
+func operationObject(op Operation, schemas *schemaSet) map[string]interface{} {
+	obj := map[string]interface{}{
+		"operationId": op.ID,
+		"summary":     op.Summary,
+	}
+	if op.Tag != "" {
+		obj["tags"] = []string{op.Tag}
+	}
+	description := op.Description
+	if len(op.Roles) > 0 {
+		description = strings.TrimSpace(description + "\n\nRequires role: " + strings.Join(op.Roles, ", ") + ".")
+	}
+	if description != "" {
+		obj["description"] = description
+	}
+
+	var params []interface{}
+	for _, name := range op.PathParams() {
+		params = append(params, map[string]interface{}{
+			"name": name, "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"},
+		})
+	}
+	for _, p := range op.Query {
+		param := map[string]interface{}{"name": p.Name, "in": "query", "schema": map[string]interface{}{"type": "string"}}
+		if p.Description != "" {
+			param["description"] = p.Description
+		}
+		if p.Required {
+			param["required"] = true
+		}
+		params = append(params, param)
+	}
+	if params != nil {
+		obj["parameters"] = params
+	}
+
+	if op.Request != nil {
+		obj["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  map[string]interface{}{op.requestType(): map[string]interface{}{"schema": bodySchema(op.Request, op.requestType(), schemas)}},
+		}
+	}
+
+	success := map[string]interface{}{"description": http.StatusText(op.status())}
+	if op.Response != nil {
+		success["content"] = map[string]interface{}{op.responseType(): map[string]interface{}{"schema": bodySchema(op.Response, op.responseType(), schemas)}}
+	}
+	errorBody := map[string]interface{}{
+		"description": "Error",
+		"content":     map[string]interface{}{ContentJSON: map[string]interface{}{"schema": map[string]interface{}{"$ref": "#/components/schemas/ErrorResponse"}}},
+	}
+	schemas.add(reflect.TypeOf(errorResponse{}), "ErrorResponse")
+	obj["responses"] = map[string]interface{}{
+		fmt.Sprint(op.status()): success,
+		"default":               errorBody,
+	}
+
+	switch op.Auth {
+	case AuthBearer:
+		obj["security"] = []interface{}{map[string]interface{}{"bearerAuth": []string{}}}
+	case AuthService:
+		obj["security"] = []interface{}{map[string]interface{}{"serviceToken": []string{}}}
+	}
+	return obj
+}
+
+// errorResponse mirrors types.ErrorResponse without importing it into this package
+type errorResponse struct {
+	Error     string       `json:"error"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"requestId,omitempty"`
+	Fields    []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError mirrors types.FieldError
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
+	Value   string `json:"value,omitempty"`
+}
+
+// bodySchema renders a body; non-JSON bodies are plain strings
+func bodySchema(v interface{}, contentType string, schemas *schemaSet) map[string]interface{} {
+	if contentType != ContentJSON {
+		return map[string]interface{}{"type": "string"}
+	}
+	return schemas.schema(reflect.TypeOf(v))
+}
+
+// Route is a method and chi pattern served by the router
+type Route struct {
+	Method string
+	Path   string
+}
+
+// Undocumented returns the routes with the given prefix that have no operation, sorted
+func Undocumented(ops []Operation, routes []Route, prefix string) []Route {
+	known := make(map[Route]bool, len(ops))
+	for _, op := range ops {
+		known[Route{op.Method, op.Path}] = true
+	}
+	var missing []Route
+	for _, r := range routes {
+		if strings.HasPrefix(r.Path, prefix) && !known[r] {
+			missing = append(missing, r)
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		if missing[i].Path != missing[j].Path {
+			return missing[i].Path < missing[j].Path
+		}
+		return missing[i].Method < missing[j].Method
+	})
+	return missing
+}
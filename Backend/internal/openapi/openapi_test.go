@@ -0,0 +1,148 @@
+package openapi
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type base struct {
+	ID string `json:"id"`
+}
+
+type widget struct {
+	base
+	Name     string            `json:"name"`
+	Tags     []string          `json:"tags,omitempty"`
+	Seen     time.Time         `json:"seen"`
+	Parent   *widget           `json:"parent,omitempty"`
+	Labels   map[string]string `json:"labels"`
+	internal string
+	Skipped  string `json:"-"`
+}
+
+func testOps() []Operation {
+	return []Operation{
+		{ID: "GetWidget", Method: http.MethodGet, Path: "/api/widgets/{widgetId}", Auth: AuthBearer, Response: widget{}},
+		{ID: "ListWidgets", Method: http.MethodGet, Path: "/api/widgets", Query: []Param{{Name: "name"}}, Response: []widget{}},
+		{ID: "PutWidget", Method: http.MethodPut, Path: "/api/widgets/{widgetId}", Request: widget{}, Response: widget{}},
+		{ID: "DeleteWidget", Method: http.MethodDelete, Path: "/api/widgets/{widgetId}", Status: http.StatusNoContent},
+		{ID: "ImportWidgets", Method: http.MethodPost, Path: "/api/widgets/import", Request: "", RequestType: ContentCSV},
+	}
+}
+
+func TestDocumentSchemas(t *testing.T) {
+	doc, err := Document("test", "1", testOps())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	w, ok := schemas["widget"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected widget schema, got %v", schemas)
+	}
+	props := w["properties"].(map[string]interface{})
+	for _, name := range []string{"id", "name", "tags", "seen", "parent", "labels"} {
+		if _, ok := props[name]; !ok {
+			t.Errorf("expected property %s", name)
+		}
+	}
+	for _, name := range []string{"internal", "Skipped", "base"} {
+		if _, ok := props[name]; ok {
+			t.Errorf("unexpected property %s", name)
+		}
+	}
+	if seen := props["seen"].(map[string]interface{}); seen["format"] != "date-time" {
+		t.Errorf("expected date-time for time.Time, got %v", seen)
+	}
+	if parent := props["parent"].(map[string]interface{}); parent["$ref"] != "#/components/schemas/widget" {
+		t.Errorf("expected self reference, got %v", parent)
+	}
+	if req := w["required"].([]string); strings.Join(req, ",") != "id,name,seen,labels" {
+		t.Errorf("unexpected required fields %v", req)
+	}
+
+	paths := doc["paths"].(map[string]map[string]interface{})
+	get := paths["/api/widgets/{widgetId}"]["get"].(map[string]interface{})
+	if params := get["parameters"].([]interface{}); len(params) != 1 {
+		t.Errorf("expected the widgetId path parameter, got %v", params)
+	}
+	if _, ok := get["security"]; !ok {
+		t.Error("expected bearer security on GetWidget")
+	}
+}
+
+func TestDocumentRejectsDuplicateIDs(t *testing.T) {
+	ops := append(testOps(), Operation{ID: "GetWidget", Method: http.MethodGet, Path: "/api/other"})
+	if _, err := Document("test", "1", ops); err == nil {
+		t.Error("expected error for duplicate operation ID")
+	}
+}
+
+func TestGenerateClient(t *testing.T) {
+	src, err := GenerateClient("client", testOps())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "gen.go", src, 0); err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, src)
+	}
+	for _, want := range []string{
+		"func (c *Client) GetWidget(ctx context.Context, widgetID string) (*widget, error)",
+		"func (c *Client) ListWidgets(ctx context.Context, query url.Values) ([]widget, error)",
+		"func (c *Client) PutWidget(ctx context.Context, widgetID string, body widget) (*widget, error)",
+		"func (c *Client) DeleteWidget(ctx context.Context, widgetID string) error",
+		"func (c *Client) ImportWidgets(ctx context.Context, body io.Reader) error",
+		`"/api/widgets/"+url.PathEscape(widgetID)`,
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated code is missing %q", want)
+		}
+	}
+}
+
+func TestUndocumented(t *testing.T) {
+	routes := []Route{
+		{http.MethodGet, "/api/widgets"},
+		{http.MethodPost, "/api/gadgets"},
+		{http.MethodGet, "/health"},
+	}
+	missing := Undocumented(testOps(), routes, "/api/")
+	if len(missing) != 1 || missing[0].Path != "/api/gadgets" {
+		t.Errorf("expected only /api/gadgets, got %v", missing)
+	}
+}
+
+type ping struct {
+	Type string `json:"type"`
+	At   widget `json:"at"`
+}
+
+func TestMessageSchema(t *testing.T) {
+	doc, err := MessageSchema("test", "1", []Message{
+		{Channel: "/ws", Direction: "client", Types: []string{"ping", "pong"}, Value: ping{}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defs := doc["$defs"].(map[string]interface{})
+	props := defs["ping"].(map[string]interface{})["properties"].(map[string]interface{})
+	if typ := props["type"].(map[string]interface{}); fmt.Sprint(typ["enum"]) != "[ping pong]" {
+		t.Errorf("expected the type restricted to the message types, got %v", typ)
+	}
+	if ref := props["at"].(map[string]interface{})["$ref"]; ref != "#/$defs/widget" {
+		t.Errorf("expected a $defs reference, got %v", ref)
+	}
+	if index := doc["x-messages"].([]interface{}); len(index) != 2 {
+		t.Errorf("expected one x-messages entry per type value, got %v", index)
+	}
+
+	if _, err := MessageSchema("test", "1", []Message{{Channel: "/ws", Types: []string{"w"}, Value: widget{}}}); err == nil {
+		t.Error("expected an error for a struct without a type field")
+	}
+}
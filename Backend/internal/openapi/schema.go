@@ -0,0 +1,155 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaSet collects named struct schemas, referenced as refPrefix + name
+type schemaSet struct {
+	refPrefix string
+	defs      map[string]interface{}
+	names     map[reflect.Type]string
+	taken     map[string]reflect.Type
+}
+
+func newSchemaSet(refPrefix string) *schemaSet {
+	return &schemaSet{
+		refPrefix: refPrefix,
+		defs:      make(map[string]interface{}),
+		names:     make(map[reflect.Type]string),
+		taken:     make(map[string]reflect.Type),
+	}
+}
+
+// name returns the component name of a struct type, prefixing the package on collision
+func (s *schemaSet) name(t reflect.Type) string {
+	if n, ok := s.names[t]; ok {
+		return n
+	}
+	n := t.Name()
+	if other, ok := s.taken[n]; ok && other != t {
+		pkg := t.PkgPath()
+		pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+		n = strings.ToUpper(pkg[:1]) + pkg[1:] + n
+	}
+	s.names[t] = n
+	s.taken[n] = t
+	return n
+}
+
+// add registers t under an explicit component name
+func (s *schemaSet) add(t reflect.Type, name string) {
+	if _, ok := s.defs[name]; ok {
+		return
+	}
+	s.names[t] = name
+	s.taken[name] = t
+	s.defs[name] = nil // reserve before recursing
+	s.defs[name] = s.object(t)
+}
+
+// schema returns the JSON schema of t, referencing named structs
+func (s *schemaSet) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		if t.PkgPath() == "time" && t.Name() == "Duration" {
+			return map[string]interface{}{"type": "integer", "format": "int64", "description": "nanoseconds"}
+		}
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": s.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		name := s.name(t)
+		if _, ok := s.defs[name]; !ok {
+			s.add(t, name)
+		}
+		return map[string]interface{}{"$ref": s.refPrefix + name}
+	}
+	return map[string]interface{}{} // interface{}: any value
+}
+
+// object renders a struct's exported JSON fields, flattening embedded structs
+func (s *schemaSet) object(t reflect.Type) map[string]interface{} {
+	props := make(map[string]interface{})
+	var required []string
+	s.fields(t, props, &required)
+
+	obj := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		obj["required"] = required
+	}
+	return obj
+}
+
+func (s *schemaSet) fields(t reflect.Type, props map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, omitempty, skip := jsonField(f)
+		if skip {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				s.fields(ft, props, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = s.schema(f.Type)
+		if !omitempty && f.Type.Kind() != reflect.Ptr {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// jsonField parses a field's json tag
+func jsonField(f reflect.StructField) (name string, omitempty, skip bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	return parts[0], omitempty, false
+}
@@ -0,0 +1,231 @@
+package rollup
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dennisdiepolder/monti/backend/internal/kpi"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// ParseDimensions parses a comma-separated list of rollup dimensions (e.g. "team,location").
+// An empty string disables rollups.
+func ParseDimensions(s string) ([]types.RollupDimension, error) {
+	var dims []types.RollupDimension
+	seen := make(map[types.RollupDimension]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(strings.ToLower(part))
+		if part == "" {
+			continue
+		}
+		dim := types.RollupDimension(part)
+		if !isSupported(dim) {
+			return nil, fmt.Errorf("unknown rollup dimension %q", part)
+		}
+		if !seen[dim] {
+			seen[dim] = true
+			dims = append(dims, dim)
+		}
+	}
+	return dims, nil
+}
+
+// Dimensions returns the distinct dimensions present in a set of rollups
+func Dimensions(rollups []types.Rollup) []types.RollupDimension {
+	var dims []types.RollupDimension
+	seen := make(map[types.RollupDimension]bool)
+	for _, r := range rollups {
+		if !seen[r.Dimension] {
+			seen[r.Dimension] = true
+			dims = append(dims, r.Dimension)
+		}
+	}
+	return dims
+}
+
+// group accumulates stats for a single rollup key
+type group struct {
+	rollup       types.Rollup
+	depts        map[types.Department]bool
+	occupancySum float64
+	handleTimes  []float64 // team groups: the agents' recent handle times
+}
+
+// Build computes rollups for the requested dimensions from a snapshot's departments.
+// Queue stats (waiting calls, SL) are taken from the queues of every department the
+// group's agents belong to. Output is ordered by dimension, then key.
+func Build(departments map[types.Department]*types.DepartmentData, dims []types.RollupDimension) []types.Rollup {
+	if len(dims) == 0 {
+		return nil
+	}
+
+	var rollups []types.Rollup
+	for _, dim := range types.AllRollupDimensions {
+		if !contains(dims, dim) {
+			continue
+		}
+
+		groups := make(map[string]*group)
+		for dept, data := range departments {
+			for _, agent := range data.Agents {
+				key := groupKey(dim, dept, agent)
+				if key == "" {
+					continue
+				}
+				g, ok := groups[key]
+				if !ok {
+					g = &group{
+						rollup: types.Rollup{
+							Dimension:      dim,
+							Key:            key,
+							StateBreakdown: make(map[types.AgentState]int),
+						},
+						depts: make(map[types.Department]bool),
+					}
+					groups[key] = g
+				}
+				g.depts[dept] = true
+				g.rollup.TotalAgents++
+				g.rollup.StateBreakdown[agent.State]++
+				if agent.State != types.StateOffline {
+					g.rollup.LoggedIn++
+					g.occupancySum += agent.KPIs.Occupancy
+				}
+				if dim == types.RollupTeam && agent.Observed != nil {
+					g.handleTimes = append(g.handleTimes, agent.Observed.HandleTimes...)
+				}
+			}
+		}
+
+		keys := make([]string, 0, len(groups))
+		for key := range groups {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			g := groups[key]
+			if g.rollup.LoggedIn > 0 {
+				g.rollup.Occupancy = g.occupancySum / float64(g.rollup.LoggedIn)
+			}
+			g.rollup.Departments = sortedDepartments(g.depts)
+			g.rollup.WaitingCalls, g.rollup.ServiceLevel = QueueTotals(departments, g.rollup.Departments)
+			g.rollup.RecentAHT = PooledAHT(g.handleTimes)
+			rollups = append(rollups, g.rollup)
+		}
+	}
+
+	return rollups
+}
+
+// SetSupervisors fills in the supervisor of every team rollup using lookup
+func SetSupervisors(rollups []types.Rollup, lookup func(team string) string) {
+	for i := range rollups {
+		if rollups[i].Dimension == types.RollupTeam {
+			rollups[i].Supervisor = lookup(rollups[i].Key)
+		}
+	}
+}
+
+// CopySupervisors carries team supervisors from src into rollups rebuilt from a subset of its agents
+func CopySupervisors(dst, src []types.Rollup) {
+	supervisors := make(map[string]string)
+	for _, r := range src {
+		if r.Dimension == types.RollupTeam && r.Supervisor != "" {
+			supervisors[r.Key] = r.Supervisor
+		}
+	}
+	if len(supervisors) > 0 {
+		SetSupervisors(dst, func(team string) string { return supervisors[team] })
+	}
+}
+
+// Total summarizes every agent and queue of the given departments as a single rollup
+func Total(departments map[types.Department]*types.DepartmentData) types.Rollup {
+	total := types.Rollup{
+		Key:            "all",
+		StateBreakdown: make(map[types.AgentState]int),
+	}
+	depts := make(map[types.Department]bool, len(departments))
+	occupancySum := 0.0
+	for dept, data := range departments {
+		depts[dept] = true
+		for _, agent := range data.Agents {
+			total.TotalAgents++
+			total.StateBreakdown[agent.State]++
+			if agent.State != types.StateOffline {
+				total.LoggedIn++
+				occupancySum += agent.KPIs.Occupancy
+			}
+		}
+	}
+	if total.LoggedIn > 0 {
+		total.Occupancy = occupancySum / float64(total.LoggedIn)
+	}
+	total.Departments = sortedDepartments(depts)
+	total.WaitingCalls, total.ServiceLevel = QueueTotals(departments, total.Departments)
+	return total
+}
+
+// PooledAHT returns the handle time percentiles over the recent calls of several agents.
+// handleTimes is sorted in place.
+func PooledAHT(handleTimes []float64) *types.AHTPercentiles {
+	sort.Float64s(handleTimes)
+	return kpi.Percentiles(handleTimes)
+}
+
+// groupKey returns the rollup key for an agent in the given dimension
+func groupKey(dim types.RollupDimension, dept types.Department, agent types.AgentInfo) string {
+	switch dim {
+	case types.RollupTeam:
+		return agent.Team
+	case types.RollupLocation:
+		return string(agent.Location)
+	case types.RollupDepartment:
+		return string(dept)
+	}
+	return ""
+}
+
+// QueueTotals sums waiting calls and combines SL across the queues of the given departments
+func QueueTotals(departments map[types.Department]*types.DepartmentData, depts []types.Department) (waiting int, sl float64) {
+	answeredInSL, totalAnswered := 0, 0
+	for _, dept := range depts {
+		data, ok := departments[dept]
+		if !ok {
+			continue
+		}
+		for _, q := range data.Queues {
+			waiting += q.WaitingCount
+			answeredInSL += q.ServiceLevel.AnsweredInSL
+			totalAnswered += q.ServiceLevel.TotalAnswered
+		}
+	}
+	if totalAnswered == 0 {
+		return waiting, 100.0 // No calls answered yet, SL is 100%
+	}
+	return waiting, float64(answeredInSL) / float64(totalAnswered) * 100.0
+}
+
+func sortedDepartments(set map[types.Department]bool) []types.Department {
+	depts := make([]types.Department, 0, len(set))
+	for dept := range set {
+		depts = append(depts, dept)
+	}
+	sort.Slice(depts, func(i, j int) bool { return depts[i] < depts[j] })
+	return depts
+}
+
+func isSupported(dim types.RollupDimension) bool {
+	return contains(types.AllRollupDimensions, dim)
+}
+
+func contains(dims []types.RollupDimension, dim types.RollupDimension) bool {
+	for _, d := range dims {
+		if d == dim {
+			return true
+		}
+	}
+	return false
+}
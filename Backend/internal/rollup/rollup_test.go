@@ -0,0 +1,143 @@
+package rollup
+
+import (
+	"testing"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+func TestParseDimensions(t *testing.T) {
+	dims, err := ParseDimensions(" Team ,location,team,")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(dims) != 2 || dims[0] != types.RollupTeam || dims[1] != types.RollupLocation {
+		t.Errorf("unexpected dimensions: %v", dims)
+	}
+
+	if dims, err := ParseDimensions(""); err != nil || dims != nil {
+		t.Errorf("expected empty string to disable rollups, got %v, %v", dims, err)
+	}
+
+	if _, err := ParseDimensions("team,floor"); err == nil {
+		t.Error("expected error for unknown dimension")
+	}
+}
+
+func TestBuildTeamRollups(t *testing.T) {
+	departments := map[types.Department]*types.DepartmentData{
+		types.DeptSales: {
+			Agents: []types.AgentInfo{
+				{AgentID: "a1", Team: "Sales-Team-1", Location: types.LocationBerlin, State: types.StateAvailable, KPIs: types.AgentKPIs{Occupancy: 60},
+					Observed: &types.ObservedKPIs{HandleTimes: []float64{100, 200, 300}}},
+				{AgentID: "a2", Team: "Sales-Team-1", Location: types.LocationMunich, State: types.StateOnCall, KPIs: types.AgentKPIs{Occupancy: 80},
+					Observed: &types.ObservedKPIs{HandleTimes: []float64{150, 900}}},
+				{AgentID: "a3", Team: "Sales-Team-2", Location: types.LocationBerlin, State: types.StateOffline},
+			},
+			Queues: []types.VQSnapshot{
+				{VQ: types.VQSalesInbound, WaitingCount: 2, ServiceLevel: types.ServiceLevel{AnsweredInSL: 3, TotalAnswered: 4}},
+				{VQ: types.VQSalesChat, WaitingCount: 1, ServiceLevel: types.ServiceLevel{AnsweredInSL: 1, TotalAnswered: 1}},
+			},
+		},
+	}
+
+	rollups := Build(departments, []types.RollupDimension{types.RollupTeam})
+	if len(rollups) != 2 {
+		t.Fatalf("expected 2 team rollups, got %d", len(rollups))
+	}
+
+	team1 := rollups[0]
+	if team1.Key != "Sales-Team-1" {
+		t.Fatalf("expected rollups sorted by key, got %s first", team1.Key)
+	}
+	if team1.TotalAgents != 2 || team1.LoggedIn != 2 {
+		t.Errorf("expected 2 total/2 logged in, got %d/%d", team1.TotalAgents, team1.LoggedIn)
+	}
+	if team1.StateBreakdown[types.StateOnCall] != 1 {
+		t.Errorf("expected 1 on_call, got %d", team1.StateBreakdown[types.StateOnCall])
+	}
+	if team1.Occupancy != 70 {
+		t.Errorf("expected 70%% occupancy, got %.1f", team1.Occupancy)
+	}
+	if team1.WaitingCalls != 3 {
+		t.Errorf("expected 3 waiting calls, got %d", team1.WaitingCalls)
+	}
+	if team1.ServiceLevel != 80 {
+		t.Errorf("expected 80%% SL, got %.1f", team1.ServiceLevel)
+	}
+	// Pooled over both agents' recent calls: 100, 150, 200, 300, 900
+	if r := team1.RecentAHT; r == nil || r.Calls != 5 || r.P50 != 200 || r.P90 != 900 {
+		t.Errorf("expected p50 200s and p90 900s over 5 calls, got %+v", r)
+	}
+
+	team2 := rollups[1]
+	if team2.LoggedIn != 0 || team2.Occupancy != 0 || team2.RecentAHT != nil {
+		t.Errorf("expected offline team to have no logged-in agents, got %d (%.1f%%)", team2.LoggedIn, team2.Occupancy)
+	}
+}
+
+func TestBuildOrdersByDimension(t *testing.T) {
+	departments := map[types.Department]*types.DepartmentData{
+		types.DeptSupport: {
+			Agents: []types.AgentInfo{
+				{AgentID: "a1", Team: "Support-Team-1", Location: types.LocationHamburg, State: types.StateAvailable},
+			},
+		},
+	}
+
+	rollups := Build(departments, []types.RollupDimension{types.RollupTeam, types.RollupLocation})
+	if len(rollups) != 2 {
+		t.Fatalf("expected 2 rollups, got %d", len(rollups))
+	}
+	if rollups[0].Dimension != types.RollupLocation || rollups[1].Dimension != types.RollupTeam {
+		t.Errorf("expected location before team, got %s, %s", rollups[0].Dimension, rollups[1].Dimension)
+	}
+	if rollups[0].ServiceLevel != 100 {
+		t.Errorf("expected 100%% SL with no answered calls, got %.1f", rollups[0].ServiceLevel)
+	}
+
+	if Build(departments, nil) != nil {
+		t.Error("expected nil rollups when no dimensions configured")
+	}
+}
+
+func TestTotal(t *testing.T) {
+	departments := map[types.Department]*types.DepartmentData{
+		types.DeptSales: {
+			Agents: []types.AgentInfo{
+				{AgentID: "a1", State: types.StateAvailable, KPIs: types.AgentKPIs{Occupancy: 60}},
+				{AgentID: "a2", State: types.StateOffline},
+			},
+			Queues: []types.VQSnapshot{{WaitingCount: 2, ServiceLevel: types.ServiceLevel{AnsweredInSL: 8, TotalAnswered: 10}}},
+		},
+		types.DeptSupport: {
+			Agents: []types.AgentInfo{
+				{AgentID: "a3", State: types.StateOnCall, KPIs: types.AgentKPIs{Occupancy: 80}},
+			},
+			Queues: []types.VQSnapshot{{WaitingCount: 1, ServiceLevel: types.ServiceLevel{AnsweredInSL: 2, TotalAnswered: 10}}},
+		},
+	}
+
+	total := Total(departments)
+	if total.TotalAgents != 3 || total.LoggedIn != 2 || total.Occupancy != 70 {
+		t.Errorf("unexpected agent totals: %+v", total)
+	}
+	if total.WaitingCalls != 3 || total.ServiceLevel != 50 {
+		t.Errorf("unexpected queue totals: waiting %d, SL %.1f", total.WaitingCalls, total.ServiceLevel)
+	}
+}
+
+func TestCopySupervisors(t *testing.T) {
+	src := []types.Rollup{
+		{Dimension: types.RollupTeam, Key: "alpha", Supervisor: "lead@monti.local"},
+		{Dimension: types.RollupLocation, Key: "berlin"},
+	}
+	dst := []types.Rollup{
+		{Dimension: types.RollupLocation, Key: "berlin"},
+		{Dimension: types.RollupTeam, Key: "alpha"},
+	}
+	CopySupervisors(dst, src)
+	if dst[1].Supervisor != "lead@monti.local" || dst[0].Supervisor != "" {
+		t.Errorf("expected only the team rollup labelled, got %+v", dst)
+	}
+}
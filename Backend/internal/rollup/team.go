@@ -0,0 +1,82 @@
+package rollup
+
+import (
+	"sort"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// TeamSummary builds the team lead view of one team from a snapshot's departments and the
+// active alerts. The queues are those of the team's department in the directory plus the
+// departments its agents belong to; at most maxAlerts of the team's agent and team alerts
+// are kept, critical first, then oldest.
+func TeamSummary(departments map[types.Department]*types.DepartmentData, team types.Team, active []types.AlertRecord, maxAlerts int) types.TeamSummary {
+	own := make(map[types.Department]*types.DepartmentData)
+	for dept, data := range departments {
+		var agents []types.AgentInfo
+		for _, agent := range data.Agents {
+			if agent.Team == team.Name {
+				agents = append(agents, agent)
+			}
+		}
+		if len(agents) > 0 || dept == team.Department {
+			own[dept] = &types.DepartmentData{Agents: agents, Queues: data.Queues}
+		}
+	}
+
+	summary := types.TeamSummary{
+		Rollup: Total(own),
+		Queues: []types.VQSnapshot{},
+		Alerts: []types.AlertRecord{},
+	}
+	summary.Dimension = types.RollupTeam
+	summary.Key = team.Name
+	summary.Supervisor = team.Supervisor
+
+	var ahtSum, occupancySum float64
+	var handleTimes []float64
+	present := 0
+	for _, dept := range summary.Departments {
+		data := own[dept]
+		summary.Queues = append(summary.Queues, data.Queues...)
+		for _, agent := range data.Agents {
+			if agent.Observed == nil {
+				continue
+			}
+			daily := agent.Observed.Daily
+			handleTimes = append(handleTimes, agent.Observed.HandleTimes...)
+			summary.Today.CallsHandled += daily.CallsHandled
+			ahtSum += daily.AvgHandleTime * float64(daily.CallsHandled)
+			// Agents that have been offline all day don't dilute the team's occupancy
+			if agent.State != types.StateOffline || daily.CallsHandled > 0 {
+				occupancySum += daily.Occupancy
+				present++
+			}
+		}
+	}
+	if summary.Today.CallsHandled > 0 {
+		summary.Today.AvgHandleTime = ahtSum / float64(summary.Today.CallsHandled)
+	}
+	if present > 0 {
+		summary.Today.Occupancy = occupancySum / float64(present)
+	}
+	summary.RecentAHT = PooledAHT(handleTimes)
+
+	for _, rec := range active {
+		if (rec.Scope == types.AlertScopeAgent && rec.Team == team.Name) ||
+			(rec.Scope == types.AlertScopeTeam && rec.Target == team.Name) {
+			summary.Alerts = append(summary.Alerts, rec)
+		}
+	}
+	sort.SliceStable(summary.Alerts, func(i, j int) bool {
+		a, b := summary.Alerts[i], summary.Alerts[j]
+		if (a.Severity == types.SeverityCritical) != (b.Severity == types.SeverityCritical) {
+			return a.Severity == types.SeverityCritical
+		}
+		return a.FiredAt.Before(b.FiredAt)
+	})
+	if len(summary.Alerts) > maxAlerts {
+		summary.Alerts = summary.Alerts[:maxAlerts]
+	}
+	return summary
+}
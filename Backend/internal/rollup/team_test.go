@@ -0,0 +1,64 @@
+package rollup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+func TestTeamSummary(t *testing.T) {
+	observed := func(calls int, aht, occupancy float64) *types.ObservedKPIs {
+		return &types.ObservedKPIs{Daily: types.KPIWindow{CallsHandled: calls, AvgHandleTime: aht, Occupancy: occupancy}}
+	}
+	departments := map[types.Department]*types.DepartmentData{
+		types.DeptSales: {
+			Agents: []types.AgentInfo{
+				{AgentID: "a1", Team: "Sales-Team-1", State: types.StateOnCall, Observed: observed(3, 200, 70)},
+				{AgentID: "a2", Team: "Sales-Team-1", State: types.StateAvailable, Observed: observed(1, 400, 50)},
+				{AgentID: "a3", Team: "Sales-Team-1", State: types.StateOffline, Observed: observed(0, 0, 0)},
+				{AgentID: "a4", Team: "Sales-Team-2", State: types.StateOnCall, Observed: observed(9, 100, 90)},
+			},
+			Queues: []types.VQSnapshot{
+				{VQ: types.VQSalesInbound, WaitingCount: 2, ServiceLevel: types.ServiceLevel{AnsweredInSL: 3, TotalAnswered: 4}},
+			},
+		},
+		types.DeptSupport: {
+			Agents: []types.AgentInfo{{AgentID: "a5", Team: "Support-Team-1", State: types.StateAvailable}},
+			Queues: []types.VQSnapshot{{VQ: types.VQSupportGeneral, WaitingCount: 5}},
+		},
+	}
+	now := time.Now()
+	active := []types.AlertRecord{
+		{AlertID: "1", Scope: types.AlertScopeAgent, Team: "Sales-Team-1", Severity: types.SeverityWarning, FiredAt: now.Add(-3 * time.Minute)},
+		{AlertID: "2", Scope: types.AlertScopeTeam, Target: "Sales-Team-1", Severity: types.SeverityCritical, FiredAt: now.Add(-1 * time.Minute)},
+		{AlertID: "3", Scope: types.AlertScopeAgent, Team: "Sales-Team-1", Severity: types.SeverityWarning, FiredAt: now.Add(-5 * time.Minute)},
+		{AlertID: "4", Scope: types.AlertScopeAgent, Team: "Sales-Team-2", Severity: types.SeverityCritical, FiredAt: now},
+		{AlertID: "5", Scope: types.AlertScopeDepartment, Target: "sales", Severity: types.SeverityCritical, FiredAt: now},
+	}
+	team := types.Team{Name: "Sales-Team-1", Department: types.DeptSales, Supervisor: "lead@example.com"}
+
+	s := TeamSummary(departments, team, active, 2)
+	if s.Dimension != types.RollupTeam || s.Key != "Sales-Team-1" || s.Supervisor != "lead@example.com" {
+		t.Errorf("unexpected rollup header: %+v", s.Rollup)
+	}
+	if s.TotalAgents != 3 || s.LoggedIn != 2 || s.StateBreakdown[types.StateOnCall] != 1 {
+		t.Errorf("expected 3 agents, 2 logged in, 1 on call, got %+v", s.Rollup)
+	}
+	// (3*200 + 1*400) / 4 calls; the agent offline all day doesn't count toward occupancy
+	if s.Today.CallsHandled != 4 || s.Today.AvgHandleTime != 250 || s.Today.Occupancy != 60 {
+		t.Errorf("unexpected today KPIs: %+v", s.Today)
+	}
+	if len(s.Queues) != 1 || s.Queues[0].VQ != types.VQSalesInbound || s.ServiceLevel != 75 || s.WaitingCalls != 2 {
+		t.Errorf("expected only the sales queue at 75%% SL, got %+v (SL %.1f)", s.Queues, s.ServiceLevel)
+	}
+	if len(s.Alerts) != 2 || s.Alerts[0].AlertID != "2" || s.Alerts[1].AlertID != "3" {
+		t.Errorf("expected the critical team alert, then the oldest agent alert, got %+v", s.Alerts)
+	}
+
+	// A managed team without agents still shows its department's queues
+	empty := TeamSummary(departments, types.Team{Name: "Support-Team-9", Department: types.DeptSupport}, nil, 5)
+	if empty.TotalAgents != 0 || len(empty.Queues) != 1 || empty.WaitingCalls != 5 || empty.Alerts == nil {
+		t.Errorf("expected the support queue and no agents, got %+v", empty)
+	}
+}
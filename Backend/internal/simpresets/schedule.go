@@ -0,0 +1,268 @@
+package simpresets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// catchUpRetry is how often the entries due at startup are retried while they fail
+const catchUpRetry = time.Minute
+
+// weekdays are the day names of schedule entries, indexed by time.Weekday
+var weekdays = [7]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Schedule is the sim schedule file (SIM_SCHEDULE_FILE):
+//
+//	{
+//	  "timezone": "Europe/Berlin",
+//	  "entries": [
+//	    {"days": ["mon", "tue", "wed", "thu", "fri"], "at": "08:00", "preset": "weekday-morning"},
+//	    {"days": ["mon", "tue", "wed", "thu", "fri"], "at": "18:00", "activeAgents": 20, "callRates": {"sales": 1}},
+//	    {"at": "22:00", "stop": true}
+//	  ]
+//	}
+//
+// Each entry names a saved preset, sets stop or carries inline settings. Entries due at the
+// same time are applied in file order.
+type Schedule struct {
+	Timezone string                   `json:"timezone,omitempty"` // IANA name; empty = UTC
+	Entries  []types.SimScheduleEntry `json:"entries"`
+
+	loc  *time.Location
+	at   []int     // minutes since midnight, per entry
+	days [][7]bool // by time.Weekday, per entry
+}
+
+// LoadSchedule reads and validates a sim schedule file
+func LoadSchedule(path string) (*Schedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sim schedule: %w", err)
+	}
+	var s Schedule
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse sim schedule: %w", err)
+	}
+	if err := s.init(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// init resolves the time zone and validates the entries
+func (s *Schedule) init() error {
+	s.loc = time.UTC
+	if s.Timezone != "" {
+		loc, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			return fmt.Errorf("invalid timezone %q: %w", s.Timezone, err)
+		}
+		s.loc = loc
+	}
+	s.at = make([]int, len(s.Entries))
+	s.days = make([][7]bool, len(s.Entries))
+	for i, e := range s.Entries {
+		if err := s.initEntry(i, e); err != nil {
+			return fmt.Errorf("entry %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (s *Schedule) initEntry(i int, e types.SimScheduleEntry) error {
+	var h, m int
+	if n, err := fmt.Sscanf(e.At, "%d:%d", &h, &m); err != nil || n != 2 || len(e.At) != 5 || h < 0 || h > 23 || m < 0 || m > 59 {
+		return fmt.Errorf("at %q must be HH:MM", e.At)
+	}
+	s.at[i] = h*60 + m
+
+	if len(e.Days) == 0 {
+		s.days[i] = [7]bool{true, true, true, true, true, true, true}
+	}
+	for _, day := range e.Days {
+		found := false
+		for d, name := range weekdays {
+			if name == day {
+				s.days[i][d], found = true, true
+			}
+		}
+		if !found {
+			return fmt.Errorf("invalid weekday %q (use %s)", day, strings.Join(weekdays[:], ", "))
+		}
+	}
+
+	inline := e.ActiveAgents != 0 || e.PeakHourFactor != 0 || len(e.CallRates) > 0
+	switch {
+	case e.Stop && (e.Preset != "" || inline), e.Preset != "" && inline:
+		return fmt.Errorf("set only one of preset, stop or inline settings")
+	case !e.Stop && e.Preset == "" && !inline:
+		return fmt.Errorf("set a preset, stop or inline settings")
+	case inline:
+		if errs := inlinePreset(e).Validate(); len(errs) > 0 {
+			return fmt.Errorf("%s: %s", errs[0].Field, errs[0].Message)
+		}
+	}
+	return nil
+}
+
+// inlinePreset returns the preset made of an entry's inline settings
+func inlinePreset(e types.SimScheduleEntry) types.SimPreset {
+	return types.SimPreset{
+		Name:           "schedule " + e.At,
+		ActiveAgents:   e.ActiveAgents,
+		PeakHourFactor: e.PeakHourFactor,
+		CallRates:      e.CallRates,
+	}
+}
+
+// Next returns the first time after t at which entries are due, and those entries
+func (s *Schedule) Next(t time.Time) (time.Time, []int) {
+	return s.find(t, 1)
+}
+
+// Last returns the latest time at or before t at which entries were due, and those entries
+func (s *Schedule) Last(t time.Time) (time.Time, []int) {
+	return s.find(t, -1)
+}
+
+// find looks up to a week from t in direction dir (1 = after t, -1 = at or before t) for the
+// closest time entries are due
+func (s *Schedule) find(t time.Time, dir int) (time.Time, []int) {
+	local := t.In(s.loc)
+	for offset := 0; offset <= 7; offset++ {
+		day := local.Day() + dir*offset
+		var best time.Time
+		var due []int
+		for i := range s.Entries {
+			at := time.Date(local.Year(), local.Month(), day, 0, s.at[i], 0, 0, s.loc)
+			if !s.days[i][at.Weekday()] || (dir > 0 && !at.After(t)) || (dir < 0 && at.After(t)) {
+				continue
+			}
+			switch {
+			case due == nil || (dir > 0 && at.Before(best)) || (dir < 0 && at.After(best)):
+				best, due = at, []int{i}
+			case at.Equal(best):
+				due = append(due, i)
+			}
+		}
+		if due != nil {
+			return best, due
+		}
+	}
+	return time.Time{}, nil
+}
+
+// Scheduler applies the schedule's entries as they come due. On start it applies the
+// entries due last, so a restarted server picks up the current part of the day.
+type Scheduler struct {
+	schedule *Schedule
+	library  *Library
+	runner   *Runner
+	logger   zerolog.Logger
+
+	mu       sync.Mutex
+	lastRuns []types.SimScheduleRun
+}
+
+// NewScheduler creates a Scheduler applying schedule's entries through runner
+func NewScheduler(schedule *Schedule, library *Library, runner *Runner, logger zerolog.Logger) *Scheduler {
+	return &Scheduler{
+		schedule: schedule,
+		library:  library,
+		runner:   runner,
+		logger:   logger.With().Str("component", "sim_schedule").Logger(),
+		lastRuns: []types.SimScheduleRun{},
+	}
+}
+
+// Start applies entries as they come due until ctx is done
+func (s *Scheduler) Start(ctx context.Context) {
+	// AgentSim may still be starting: retry the catch-up until the next entry is close
+	if at, due := s.schedule.Last(time.Now()); due != nil {
+		s.logger.Info().Time("due", at).Ints("entries", due).Msg("applying the latest sim schedule entries")
+		for !s.run(ctx, at, due) {
+			if next, _ := s.schedule.Next(time.Now()); time.Until(next) < catchUpRetry {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(catchUpRetry):
+			}
+		}
+	}
+	for {
+		at, due := s.schedule.Next(time.Now())
+		if due == nil {
+			return
+		}
+		timer := time.NewTimer(time.Until(at))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.run(ctx, at, due)
+	}
+}
+
+// run applies the entries due at, in order, and reports whether all were applied
+func (s *Scheduler) run(ctx context.Context, at time.Time, due []int) bool {
+	ok := true
+	runs := make([]types.SimScheduleRun, 0, len(due))
+	for _, i := range due {
+		run := types.SimScheduleRun{At: at, Entry: i}
+		stepCtx, cancel := context.WithTimeout(ctx, stepTimeout)
+		err := s.apply(stepCtx, s.schedule.Entries[i])
+		cancel()
+		if err != nil {
+			run.Error, ok = err.Error(), false
+			s.logger.Error().Err(err).Int("entry", i+1).Str("at", s.schedule.Entries[i].At).Msg("sim schedule entry failed")
+		} else {
+			s.logger.Info().Int("entry", i+1).Str("at", s.schedule.Entries[i].At).Msg("sim schedule entry applied")
+		}
+		runs = append(runs, run)
+	}
+	s.mu.Lock()
+	s.lastRuns = runs
+	s.mu.Unlock()
+	return ok
+}
+
+func (s *Scheduler) apply(ctx context.Context, e types.SimScheduleEntry) error {
+	switch {
+	case e.Stop:
+		return s.runner.Stop(ctx)
+	case e.Preset != "":
+		preset, ok := s.library.Get(e.Preset)
+		if !ok {
+			return fmt.Errorf("preset %q: %w", e.Preset, ErrPresetNotFound)
+		}
+		_, err := s.runner.Apply(ctx, preset)
+		return err
+	default:
+		_, err := s.runner.Apply(ctx, inlinePreset(e))
+		return err
+	}
+}
+
+// Status returns the schedule, its next run time and the outcome of its latest run
+func (s *Scheduler) Status() types.SimScheduleStatus {
+	status := types.SimScheduleStatus{Timezone: s.schedule.loc.String(), Entries: s.schedule.Entries}
+	if next, due := s.schedule.Next(time.Now()); due != nil {
+		status.NextRun = &next
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status.LastRuns = append([]types.SimScheduleRun(nil), s.lastRuns...)
+	return status
+}
@@ -0,0 +1,119 @@
+package simpresets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/storage"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+func loadSchedule(t *testing.T, body string) (*Schedule, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "schedule.json")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return LoadSchedule(path)
+}
+
+func TestLoadScheduleValidation(t *testing.T) {
+	cases := map[string]string{
+		`{"entries": [{"at": "8:00", "stop": true}]}`:                         "must be HH:MM",
+		`{"entries": [{"at": "24:00", "stop": true}]}`:                        "must be HH:MM",
+		`{"entries": [{"days": ["monday"], "at": "08:00", "stop": true}]}`:    "invalid weekday",
+		`{"entries": [{"at": "08:00"}]}`:                                      "set a preset, stop or inline settings",
+		`{"entries": [{"at": "08:00", "preset": "demo", "stop": true}]}`:      "set only one",
+		`{"entries": [{"at": "08:00", "preset": "demo", "activeAgents": 5}]}`: "set only one",
+		`{"entries": [{"at": "08:00", "callRates": {"billing": 3}}]}`:         "callRates",
+		`{"timezone": "Mars/Olympus", "entries": []}`:                         "invalid timezone",
+	}
+	for body, want := range cases {
+		if _, err := loadSchedule(t, body); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", body, want, err)
+		}
+	}
+}
+
+func TestScheduleNextAndLast(t *testing.T) {
+	s, err := loadSchedule(t, `{
+		"timezone": "Europe/Berlin",
+		"entries": [
+			{"days": ["mon", "tue", "wed", "thu", "fri"], "at": "08:00", "preset": "morning"},
+			{"days": ["mon", "tue", "wed", "thu", "fri"], "at": "18:00", "activeAgents": 20},
+			{"days": ["fri"], "at": "18:00", "callRates": {"sales": 1}},
+			{"at": "22:00", "stop": true}
+		]
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 3, day, hour, minute, 0, 0, berlin) // March 2nd 2026 is a Monday
+	}
+
+	cases := []struct {
+		name    string
+		got     func() (time.Time, []int)
+		want    time.Time
+		wantDue []int
+	}{
+		{"next on a weekday", func() (time.Time, []int) { return s.Next(at(2, 7, 0)) }, at(2, 8, 0), []int{0}},
+		{"next skips the current minute", func() (time.Time, []int) { return s.Next(at(2, 8, 0)) }, at(2, 18, 0), []int{1}},
+		{"same time in file order", func() (time.Time, []int) { return s.Next(at(6, 12, 0)) }, at(6, 18, 0), []int{1, 2}},
+		{"next over the weekend", func() (time.Time, []int) { return s.Next(at(7, 23, 0)) }, at(8, 22, 0), []int{3}},
+		{"weekend stop to monday", func() (time.Time, []int) { return s.Next(at(8, 22, 30)) }, at(9, 8, 0), []int{0}},
+		{"last includes the current minute", func() (time.Time, []int) { return s.Last(at(3, 8, 0)) }, at(3, 8, 0), []int{0}},
+		{"last on sunday morning", func() (time.Time, []int) { return s.Last(at(8, 9, 0)) }, at(7, 22, 0), []int{3}},
+		{"last across DST", func() (time.Time, []int) { return s.Last(at(30, 7, 0)) }, at(29, 22, 0), []int{3}},
+	}
+	for _, tc := range cases {
+		got, due := tc.got()
+		if !got.Equal(tc.want) || fmt.Sprint(due) != fmt.Sprint(tc.wantDue) {
+			t.Errorf("%s: expected %s %v, got %s %v", tc.name, tc.want, tc.wantDue, got.In(berlin), due)
+		}
+	}
+}
+
+func TestSchedulerRun(t *testing.T) {
+	s, err := loadSchedule(t, `{"entries": [
+		{"at": "08:00", "preset": "morning"},
+		{"at": "08:00", "peakHourFactor": 2},
+		{"at": "08:00", "preset": "missing"},
+		{"at": "22:00", "stop": true}
+	]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lib := NewLibrary(storage.NewMemoryStore())
+	lib.Put(types.SimPreset{Name: "morning", ActiveAgents: 100})
+	sim := &fakeSim{}
+	scheduler := NewScheduler(s, lib, NewRunner(context.Background(), sim, zerolog.Nop()), zerolog.Nop())
+
+	now := time.Now()
+	if scheduler.run(context.Background(), now, []int{0, 1, 2}) {
+		t.Error("expected the run with a missing preset to fail")
+	}
+	scheduler.run(context.Background(), now, []int{3})
+	scheduler.run(context.Background(), now, []int{3}) // already stopped
+
+	want := []string{"start 100", "config 2.0 map[]", "stop"}
+	if got := sim.recorded(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	status := scheduler.Status()
+	if status.Timezone != "UTC" || len(status.Entries) != 4 || status.NextRun == nil {
+		t.Errorf("unexpected status: %+v", status)
+	}
+	if len(status.LastRuns) != 1 || status.LastRuns[0].Entry != 3 || status.LastRuns[0].Error != "" {
+		t.Errorf("expected the stop as the latest run, got %+v", status.LastRuns)
+	}
+}
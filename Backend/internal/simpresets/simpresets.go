@@ -0,0 +1,240 @@
+// Package simpresets keeps named AgentSim setups and applies them: call rates and peak factor,
+// the agent count, then the preset's scenario script in the background.
+package simpresets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// ErrPresetNotFound is returned for an unknown preset name
+var ErrPresetNotFound = errors.New("preset not found")
+
+// stepTimeout bounds each AgentSim request of a scenario script
+const stepTimeout = 10 * time.Second
+
+// Store persists presets (implemented by storage.Store)
+type Store interface {
+	SaveSimPreset(preset types.SimPreset) error
+	DeleteSimPreset(name string) error
+	GetSimPresets() ([]types.SimPreset, error)
+}
+
+// Sim is the simulation control a preset is applied through
+type Sim interface {
+	Status(ctx context.Context) (types.SimStatus, error)
+	Start(ctx context.Context, agents int) error
+	Scale(ctx context.Context, agents int) error
+	Stop(ctx context.Context) error
+	SetCallConfig(ctx context.Context, peakHourFactor float64, rates map[types.Department]float64) error
+	Inject(ctx context.Context, count int, vq string) error
+}
+
+// Library holds the presets in memory, backed by a store
+type Library struct {
+	mu      sync.RWMutex
+	presets map[string]types.SimPreset // by name
+	store   Store
+}
+
+// NewLibrary creates an empty library
+func NewLibrary(store Store) *Library {
+	return &Library{presets: make(map[string]types.SimPreset), store: store}
+}
+
+// Load replaces the library contents with the persisted presets
+func (l *Library) Load() (int, error) {
+	presets, err := l.store.GetSimPresets()
+	if err != nil {
+		return 0, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.presets = make(map[string]types.SimPreset, len(presets))
+	for _, p := range presets {
+		l.presets[p.Name] = p
+	}
+	return len(presets), nil
+}
+
+// Put creates or replaces a preset and persists it
+func (l *Library) Put(preset types.SimPreset) (types.SimPreset, error) {
+	preset.UpdatedAt = time.Now().UTC()
+	if err := l.store.SaveSimPreset(preset); err != nil {
+		return preset, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.presets[preset.Name] = preset
+	return preset, nil
+}
+
+// Delete removes a preset and its persisted record
+func (l *Library) Delete(name string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.presets[name]; !ok {
+		return ErrPresetNotFound
+	}
+	if err := l.store.DeleteSimPreset(name); err != nil {
+		return err
+	}
+	delete(l.presets, name)
+	return nil
+}
+
+// Get returns a preset by name
+func (l *Library) Get(name string) (types.SimPreset, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	p, ok := l.presets[name]
+	return p, ok
+}
+
+// List returns all presets sorted by name
+func (l *Library) List() []types.SimPreset {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	out := make([]types.SimPreset, 0, len(l.presets))
+	for _, p := range l.presets {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Runner applies presets. At most one scenario script runs at a time: applying a preset
+// cancels the script of the previous one. The lock is not held across calls to AgentSim.
+type Runner struct {
+	ctx    context.Context // scripts stop when it is done
+	sim    Sim
+	logger zerolog.Logger
+
+	mu     sync.Mutex
+	cancel context.CancelFunc // of the running script
+	seq    uint64             // bumped by every Apply and Stop
+}
+
+// NewRunner creates a Runner whose scripts run until ctx is done
+func NewRunner(ctx context.Context, sim Sim, logger zerolog.Logger) *Runner {
+	return &Runner{ctx: ctx, sim: sim, logger: logger.With().Str("component", "sim_presets").Logger()}
+}
+
+// Apply sends the preset's call config, starts or scales the simulation to its agent count
+// and starts its scenario script
+func (r *Runner) Apply(ctx context.Context, preset types.SimPreset) (types.SimPresetApplyResponse, error) {
+	resp := types.SimPresetApplyResponse{Preset: preset.Name}
+
+	seq := r.supersede()
+
+	if preset.PeakHourFactor > 0 || len(preset.CallRates) > 0 {
+		if err := r.sim.SetCallConfig(ctx, preset.PeakHourFactor, preset.CallRates); err != nil {
+			return resp, fmt.Errorf("set call config: %w", err)
+		}
+		resp.CallConfig = true
+	}
+
+	if preset.ActiveAgents > 0 {
+		status, err := r.sim.Status(ctx)
+		if err != nil {
+			return resp, fmt.Errorf("get status: %w", err)
+		}
+		change, done := r.sim.Start, "started"
+		if status.Running {
+			change, done = r.sim.Scale, "scaled"
+		}
+		if err := change(ctx, preset.ActiveAgents); err != nil {
+			return resp, fmt.Errorf("agents not %s: %w", done, err)
+		}
+		resp.Agents = done
+	}
+
+	if len(preset.Steps) > 0 {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.seq != seq {
+			// a later Apply or Stop took over while AgentSim was being called
+			return resp, nil
+		}
+		scriptCtx, cancel := context.WithCancel(r.ctx)
+		r.cancel = cancel
+		go r.run(scriptCtx, preset.Name, preset.Steps)
+		resp.Steps = len(preset.Steps)
+	}
+	return resp, nil
+}
+
+// supersede cancels the running script and returns the new sequence number
+func (r *Runner) supersede() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel != nil {
+		r.cancel()
+		r.cancel = nil
+	}
+	r.seq++
+	return r.seq
+}
+
+// run executes a script's steps in order, stopping at the first failure or when ctx is done
+func (r *Runner) run(ctx context.Context, name string, steps []types.SimStep) {
+	for i, step := range steps {
+		if step.Action == "wait" {
+			d, _ := time.ParseDuration(step.Duration)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(d):
+			}
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		stepCtx, cancel := context.WithTimeout(ctx, stepTimeout)
+		err := r.apply(stepCtx, step)
+		cancel()
+		if err != nil {
+			r.logger.Error().Err(err).Str("preset", name).Int("step", i+1).Str("action", step.Action).
+				Msg("scenario step failed, script stopped")
+			return
+		}
+	}
+	r.logger.Info().Str("preset", name).Int("steps", len(steps)).Msg("scenario script finished")
+}
+
+func (r *Runner) apply(ctx context.Context, step types.SimStep) error {
+	switch step.Action {
+	case "start":
+		return r.sim.Start(ctx, step.Agents)
+	case "scale":
+		return r.sim.Scale(ctx, step.Agents)
+	case "stop":
+		return r.sim.Stop(ctx)
+	case "inject":
+		return r.sim.Inject(ctx, step.Count, step.VQ)
+	}
+	return nil
+}
+
+// Stop cancels the running scenario script and stops the simulation if it is running
+func (r *Runner) Stop(ctx context.Context) error {
+	r.supersede()
+
+	status, err := r.sim.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("get status: %w", err)
+	}
+	if !status.Running {
+		return nil
+	}
+	return r.sim.Stop(ctx)
+}
@@ -0,0 +1,133 @@
+package simpresets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/storage"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// fakeSim records the calls made to it
+type fakeSim struct {
+	mu      sync.Mutex
+	running bool
+	calls   []string
+}
+
+func (s *fakeSim) record(call string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, call)
+}
+
+func (s *fakeSim) recorded() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.calls...)
+}
+
+func (s *fakeSim) Status(ctx context.Context) (types.SimStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return types.SimStatus{Running: s.running}, nil
+}
+
+func (s *fakeSim) Start(ctx context.Context, agents int) error {
+	s.record(fmt.Sprintf("start %d", agents))
+	s.mu.Lock()
+	s.running = true
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *fakeSim) Scale(ctx context.Context, agents int) error {
+	s.record(fmt.Sprintf("scale %d", agents))
+	return nil
+}
+
+func (s *fakeSim) Stop(ctx context.Context) error {
+	s.record("stop")
+	s.mu.Lock()
+	s.running = false
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *fakeSim) SetCallConfig(ctx context.Context, peak float64, rates map[types.Department]float64) error {
+	s.record(fmt.Sprintf("config %.1f %v", peak, rates))
+	return nil
+}
+
+func (s *fakeSim) Inject(ctx context.Context, count int, vq string) error {
+	s.record(fmt.Sprintf("inject %d %s", count, vq))
+	return nil
+}
+
+func TestLibraryPersistsPresets(t *testing.T) {
+	store := storage.NewMemoryStore()
+	lib := NewLibrary(store)
+	lib.Put(types.SimPreset{Name: "demo", ActiveAgents: 50})
+	lib.Put(types.SimPreset{Name: "peak", ActiveAgents: 400})
+
+	restored := NewLibrary(store)
+	if n, err := restored.Load(); err != nil || n != 2 {
+		t.Fatalf("expected 2 presets restored, got %d, %v", n, err)
+	}
+	if list := restored.List(); list[0].Name != "demo" || list[1].Name != "peak" || list[0].UpdatedAt.IsZero() {
+		t.Errorf("expected presets sorted by name with UpdatedAt, got %+v", list)
+	}
+	if err := restored.Delete("demo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := restored.Delete("demo"); !errors.Is(err, ErrPresetNotFound) {
+		t.Errorf("expected ErrPresetNotFound, got %v", err)
+	}
+}
+
+func TestRunnerApply(t *testing.T) {
+	sim := &fakeSim{}
+	runner := NewRunner(context.Background(), sim, zerolog.Nop())
+
+	resp, err := runner.Apply(context.Background(), types.SimPreset{
+		Name:           "morning",
+		ActiveAgents:   100,
+		PeakHourFactor: 1.5,
+		CallRates:      map[types.Department]float64{types.DeptSales: 8},
+		Steps: []types.SimStep{
+			{Action: "inject", Count: 5, VQ: "sales_inbound"},
+			{Action: "wait", Duration: "10ms"},
+			{Action: "scale", Agents: 200},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.CallConfig || resp.Agents != "started" || resp.Steps != 3 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	want := []string{"config 1.5 map[sales:8]", "start 100", "inject 5 sales_inbound", "scale 200"}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(sim.recorded()) < len(want) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := sim.recorded(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	// A running simulation is scaled, and the new preset's script replaces the old one
+	runner.Apply(context.Background(), types.SimPreset{Name: "long", Steps: []types.SimStep{{Action: "wait", Duration: "1h"}, {Action: "stop"}}})
+	resp, _ = runner.Apply(context.Background(), types.SimPreset{Name: "quiet", ActiveAgents: 10})
+	if resp.Agents != "scaled" || resp.CallConfig || resp.Steps != 0 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if runner.cancel != nil {
+		t.Error("expected the script of the previous preset to be cancelled")
+	}
+}
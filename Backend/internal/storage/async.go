@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// AsyncStore performs writes in the background so callers on hot paths never wait on
+// DynamoDB. Reads pass straight through. Writes of the same record run one after the other
+// in the order they were made, so e.g. an alert's resolution never lands before its firing.
+// Flush waits for in-flight writes on shutdown; writes arriving after Flush run synchronously
+// so nothing is lost.
+type AsyncStore struct {
+	Store
+
+	mu       sync.Mutex
+	flushing bool
+	pending  map[string][]func() // writes waiting behind a running one, by record
+	writes   sync.WaitGroup
+	logger   zerolog.Logger
+}
+
+// NewAsyncStore wraps store with background writes
+func NewAsyncStore(store Store, logger zerolog.Logger) *AsyncStore {
+	return &AsyncStore{
+		Store:   store,
+		pending: make(map[string][]func()),
+		logger:  logger.With().Str("component", "storage").Logger(),
+	}
+}
+
+// write runs fn in the background once the record's earlier writes are done (errors are
+// logged) or, once flushing and with none of them left, inline. Deletes are ordered with the
+// saves of the same record.
+func (s *AsyncStore) write(kind, id string, fn func() error) error {
+	key := recordKey(kind, id)
+	run := func() {
+		defer s.writes.Done()
+		if err := fn(); err != nil {
+			s.logger.Error().Err(err).Str("kind", kind).Str("id", id).Msg("failed to save record")
+		}
+	}
+
+	s.mu.Lock()
+	queue, running := s.pending[key]
+	if s.flushing && !running {
+		s.mu.Unlock()
+		return fn()
+	}
+	s.writes.Add(1)
+	if running {
+		s.pending[key] = append(queue, run)
+		s.mu.Unlock()
+		return nil
+	}
+	s.pending[key] = nil
+	s.mu.Unlock()
+
+	go s.drain(key, run)
+	return nil
+}
+
+// recordKey identifies the record a write of kind touches; a delete shares its record's key
+func recordKey(kind, id string) string {
+	return strings.TrimSuffix(kind, "_delete") + "|" + id
+}
+
+// drain runs a record's writes in order until none are left
+func (s *AsyncStore) drain(key string, run func()) {
+	for {
+		run()
+		s.mu.Lock()
+		queue := s.pending[key]
+		if len(queue) == 0 {
+			delete(s.pending, key)
+			s.mu.Unlock()
+			return
+		}
+		run, s.pending[key] = queue[0], queue[1:]
+		s.mu.Unlock()
+	}
+}
+
+func (s *AsyncStore) SaveCallRecord(record types.CallRecord) error {
+	return s.write("call_record", record.CallID, func() error { return s.Store.SaveCallRecord(record) })
+}
+
+func (s *AsyncStore) SaveAgentDailyStats(stats types.AgentDailyStats) error {
+	return s.write("agent_daily_stats", stats.AgentID, func() error { return s.Store.SaveAgentDailyStats(stats) })
+}
+
+func (s *AsyncStore) SaveAlert(record types.AlertRecord) error {
+	return s.write("alert", record.AlertID, func() error { return s.Store.SaveAlert(record) })
+}
+
+func (s *AsyncStore) SaveVQDailyStats(stats types.VQDailyStats) error {
+	return s.write("vq_daily_stats", string(stats.VQ), func() error { return s.Store.SaveVQDailyStats(stats) })
+}
+
+func (s *AsyncStore) SaveQueueEvent(event types.QueueEvent) error {
+	return s.write("queue_event", string(event.VQ), func() error { return s.Store.SaveQueueEvent(event) })
+}
+
+func (s *AsyncStore) SaveStateSegment(seg types.StateSegment) error {
+	return s.write("state_segment", seg.AgentID, func() error { return s.Store.SaveStateSegment(seg) })
+}
+
+func (s *AsyncStore) SaveRosterRecord(rec types.RosterRecord) error {
+	return s.write("roster", rec.AgentID, func() error { return s.Store.SaveRosterRecord(rec) })
+}
+
+func (s *AsyncStore) DeleteRosterRecord(agentID string) error {
+	return s.write("roster_delete", agentID, func() error { return s.Store.DeleteRosterRecord(agentID) })
+}
+
+func (s *AsyncStore) SaveTeam(team types.Team) error {
+	return s.write("team", team.Name, func() error { return s.Store.SaveTeam(team) })
+}
+
+func (s *AsyncStore) DeleteTeam(name string) error {
+	return s.write("team_delete", name, func() error { return s.Store.DeleteTeam(name) })
+}
+
+func (s *AsyncStore) SaveSimPreset(preset types.SimPreset) error {
+	return s.write("sim_preset", preset.Name, func() error { return s.Store.SaveSimPreset(preset) })
+}
+
+func (s *AsyncStore) DeleteSimPreset(name string) error {
+	return s.write("sim_preset_delete", name, func() error { return s.Store.DeleteSimPreset(name) })
+}
+
+// Flush waits until all background writes have finished or ctx is done
+func (s *AsyncStore) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	s.flushing = true
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.writes.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
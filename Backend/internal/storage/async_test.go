@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// slowStore delays call record writes and counts completed ones
+type slowStore struct {
+	NoopStore
+	saved atomic.Int32
+}
+
+func (s *slowStore) SaveCallRecord(_ types.CallRecord) error {
+	time.Sleep(20 * time.Millisecond)
+	s.saved.Add(1)
+	return nil
+}
+
+func TestAsyncStoreFlushWaitsForWrites(t *testing.T) {
+	inner := &slowStore{}
+	store := NewAsyncStore(inner, zerolog.Nop())
+
+	for i := 0; i < 5; i++ {
+		if err := store.SaveCallRecord(types.CallRecord{CallID: "c"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := store.Flush(context.Background()); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	if got := inner.saved.Load(); got != 5 {
+		t.Errorf("expected 5 writes after flush, got %d", got)
+	}
+
+	// Writes after Flush run inline
+	store.SaveCallRecord(types.CallRecord{CallID: "late"})
+	if got := inner.saved.Load(); got != 6 {
+		t.Errorf("expected late write to be saved inline, got %d", got)
+	}
+}
+
+func TestAsyncStoreFlushTimeout(t *testing.T) {
+	store := NewAsyncStore(&slowStore{}, zerolog.Nop())
+	store.SaveCallRecord(types.CallRecord{CallID: "c"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := store.Flush(ctx); err == nil {
+		t.Error("expected flush to time out")
+	}
+}
+
+// alertLog records the statuses saved per alert, the first write of each alert being slow
+type alertLog struct {
+	NoopStore
+	mu    sync.Mutex
+	saved map[string][]types.AlertStatus
+}
+
+func (s *alertLog) SaveAlert(rec types.AlertRecord) error {
+	s.mu.Lock()
+	first := len(s.saved[rec.AlertID]) == 0
+	s.mu.Unlock()
+	if first {
+		time.Sleep(20 * time.Millisecond)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saved[rec.AlertID] = append(s.saved[rec.AlertID], rec.Status)
+	return nil
+}
+
+func TestAsyncStoreOrdersWritesPerRecord(t *testing.T) {
+	inner := &alertLog{saved: make(map[string][]types.AlertStatus)}
+	store := NewAsyncStore(inner, zerolog.Nop())
+
+	statuses := []types.AlertStatus{types.AlertFiring, types.AlertAcknowledged, types.AlertResolved}
+	for _, id := range []string{"a1", "a2"} {
+		for _, status := range statuses {
+			store.SaveAlert(types.AlertRecord{AlertID: id, Status: status})
+		}
+	}
+	if err := store.Flush(context.Background()); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	for _, id := range []string{"a1", "a2"} {
+		got := inner.saved[id]
+		if len(got) != 3 || got[0] != statuses[0] || got[1] != statuses[1] || got[2] != statuses[2] {
+			t.Errorf("expected %s saved as %v, got %v", id, statuses, got)
+		}
+	}
+}
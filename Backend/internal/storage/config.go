@@ -1,14 +1,20 @@
 package storage
 
-import "os"
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
 
 // DynamoMode represents the DynamoDB connection mode
 type DynamoMode string
 
 const (
-	DynamoModeLocal DynamoMode = "local"
-	DynamoModeAWS   DynamoMode = "aws"
-	DynamoModeNone  DynamoMode = "none"
+	DynamoModeLocal  DynamoMode = "local"
+	DynamoModeAWS    DynamoMode = "aws"
+	DynamoModeNone   DynamoMode = "none"
+	DynamoModeMemory DynamoMode = "memory" // in-process maps instead of DynamoDB, for local runs and tests
 )
 
 // DynamoConfig holds DynamoDB configuration
@@ -18,12 +24,27 @@ type DynamoConfig struct {
 	Region            string
 	CallRecordsTable  string
 	AgentDailyTable   string
+	AlertsTable       string
+	VQDailyTable      string
+	QueueEventsTable  string
+	AgentStatesTable  string
+	RosterTable       string
+	TeamsTable        string
+	SimPresetsTable   string
+
+	// Circuit breaker: consecutive failures before opening, and how long it stays open
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+
+	// Disk spool for writes while the breaker is open
+	SpoolDir      string
+	SpoolMaxBytes int64
 }
 
 // LoadDynamoConfig loads DynamoDB config from environment
 func LoadDynamoConfig() DynamoConfig {
 	mode := DynamoMode(getEnv("DYNAMO_MODE", "none"))
-	if mode != DynamoModeLocal && mode != DynamoModeAWS {
+	if mode != DynamoModeLocal && mode != DynamoModeAWS && mode != DynamoModeMemory {
 		mode = DynamoModeNone
 	}
 
@@ -33,6 +54,17 @@ func LoadDynamoConfig() DynamoConfig {
 		Region:           getEnv("DYNAMO_REGION", "eu-central-1"),
 		CallRecordsTable: getEnv("DYNAMO_CALL_RECORDS_TABLE", "monti-call-records"),
 		AgentDailyTable:  getEnv("DYNAMO_AGENT_DAILY_TABLE", "monti-agent-daily-stats"),
+		AlertsTable:      getEnv("DYNAMO_ALERTS_TABLE", "monti-alerts"),
+		VQDailyTable:     getEnv("DYNAMO_VQ_DAILY_TABLE", "monti-vq-daily-stats"),
+		QueueEventsTable: getEnv("DYNAMO_QUEUE_EVENTS_TABLE", "monti-queue-events"),
+		AgentStatesTable: getEnv("DYNAMO_AGENT_STATES_TABLE", "monti-agent-states"),
+		RosterTable:      getEnv("DYNAMO_ROSTER_TABLE", "monti-roster"),
+		TeamsTable:       getEnv("DYNAMO_TEAMS_TABLE", "monti-teams"),
+		SimPresetsTable:  getEnv("DYNAMO_SIM_PRESETS_TABLE", "monti-sim-presets"),
+		BreakerThreshold: getEnvInt("DYNAMO_BREAKER_THRESHOLD", 5),
+		BreakerCooldown:  getEnvDuration("DYNAMO_BREAKER_COOLDOWN", 30*time.Second),
+		SpoolDir:         getEnv("DYNAMO_SPOOL_DIR", filepath.Join(os.TempDir(), "monti-spool")),
+		SpoolMaxBytes:    int64(getEnvInt("DYNAMO_SPOOL_MAX_MB", 256)) << 20,
 	}
 }
 
@@ -42,3 +74,21 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvInt reads a positive integer, falling back to defaultValue when unset or invalid
+func getEnvInt(key string, defaultValue int) int {
+	n, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || n <= 0 {
+		return defaultValue
+	}
+	return n
+}
+
+// getEnvDuration reads a positive duration, falling back to defaultValue when unset or invalid
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || d <= 0 {
+		return defaultValue
+	}
+	return d
+}
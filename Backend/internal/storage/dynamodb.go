@@ -194,20 +194,372 @@ func (s *DynamoDBStore) GetAgentCallsByDate(agentID, date string) ([]types.CallR
 	return records, nil
 }
 
+func (s *DynamoDBStore) SaveAlert(record types.AlertRecord) error {
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+
+	_, err = s.client.PutItem(context.Background(), &dynamodb.PutItemInput{
+		TableName: aws.String(s.config.AlertsTable),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save alert: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) GetAlerts(dateKey string) ([]types.AlertRecord, error) {
+	keyCond := expression.Key("DateKey").Equal(expression.Value(dateKey))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expression: %w", err)
+	}
+
+	result, err := s.client.Query(context.Background(), &dynamodb.QueryInput{
+		TableName:                 aws.String(s.config.AlertsTable),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alerts: %w", err)
+	}
+
+	var records []types.AlertRecord
+	if err := attributevalue.UnmarshalListOfMaps(result.Items, &records); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal alerts: %w", err)
+	}
+	return records, nil
+}
+
+func (s *DynamoDBStore) SaveVQDailyStats(stats types.VQDailyStats) error {
+	item, err := attributevalue.MarshalMap(stats)
+	if err != nil {
+		return fmt.Errorf("failed to marshal VQ daily stats: %w", err)
+	}
+
+	_, err = s.client.PutItem(context.Background(), &dynamodb.PutItemInput{
+		TableName: aws.String(s.config.VQDailyTable),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save VQ daily stats: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) GetVQDailyStats(dateKey string) ([]types.VQDailyStats, error) {
+	keyCond := expression.Key("DateKey").Equal(expression.Value(dateKey))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expression: %w", err)
+	}
+
+	result, err := s.client.Query(context.Background(), &dynamodb.QueryInput{
+		TableName:                 aws.String(s.config.VQDailyTable),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query VQ daily stats: %w", err)
+	}
+
+	var stats []types.VQDailyStats
+	if err := attributevalue.UnmarshalListOfMaps(result.Items, &stats); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal VQ daily stats: %w", err)
+	}
+	return stats, nil
+}
+
+func (s *DynamoDBStore) SaveQueueEvent(event types.QueueEvent) error {
+	item, err := attributevalue.MarshalMap(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal queue event: %w", err)
+	}
+
+	_, err = s.client.PutItem(context.Background(), &dynamodb.PutItemInput{
+		TableName: aws.String(s.config.QueueEventsTable),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save queue event: %w", err)
+	}
+	return nil
+}
+
+// GetQueueEvents returns one VQ's events of a day in time order; their sort keys start with the VQ
+func (s *DynamoDBStore) GetQueueEvents(dateKey string, vq types.VQName) ([]types.QueueEvent, error) {
+	keyCond := expression.Key("DateKey").Equal(expression.Value(dateKey)).
+		And(expression.Key("EventKey").BeginsWith(string(vq) + "#"))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expression: %w", err)
+	}
+
+	result, err := s.client.Query(context.Background(), &dynamodb.QueryInput{
+		TableName:                 aws.String(s.config.QueueEventsTable),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query queue events: %w", err)
+	}
+
+	var events []types.QueueEvent
+	if err := attributevalue.UnmarshalListOfMaps(result.Items, &events); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal queue events: %w", err)
+	}
+	return events, nil
+}
+
+func (s *DynamoDBStore) SaveStateSegment(seg types.StateSegment) error {
+	item, err := attributevalue.MarshalMap(seg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state segment: %w", err)
+	}
+
+	_, err = s.client.PutItem(context.Background(), &dynamodb.PutItemInput{
+		TableName: aws.String(s.config.AgentStatesTable),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save state segment: %w", err)
+	}
+	return nil
+}
+
+// GetStateSegments returns an agent's state segments that overlap [from, to), in start order.
+// Segments that started up to types.SegmentLookback before from are included.
+func (s *DynamoDBStore) GetStateSegments(agentID string, from, to time.Time) ([]types.StateSegment, error) {
+	// Start keys have millisecond resolution, and BETWEEN includes both ends
+	keyCond := expression.Key("AgentID").Equal(expression.Value(agentID)).
+		And(expression.Key("StartKey").Between(
+			expression.Value(types.SegmentStartKey(from.Add(-types.SegmentLookback))),
+			expression.Value(types.SegmentStartKey(to.Add(-time.Millisecond)))))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expression: %w", err)
+	}
+
+	var segments []types.StateSegment
+	var lastKey map[string]dbtypes.AttributeValue
+	for {
+		result, err := s.client.Query(context.Background(), &dynamodb.QueryInput{
+			TableName:                 aws.String(s.config.AgentStatesTable),
+			KeyConditionExpression:    expr.KeyCondition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			ExclusiveStartKey:         lastKey,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to query state segments: %w", err)
+		}
+
+		var page []types.StateSegment
+		if err := attributevalue.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal state segments: %w", err)
+		}
+		for _, seg := range page {
+			if seg.Overlaps(from, to) {
+				segments = append(segments, seg)
+			}
+		}
+
+		lastKey = result.LastEvaluatedKey
+		if lastKey == nil {
+			break
+		}
+	}
+	return segments, nil
+}
+
+// SaveRosterRecord creates or replaces a managed roster entry
+func (s *DynamoDBStore) SaveRosterRecord(rec types.RosterRecord) error {
+	item, err := attributevalue.MarshalMap(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal roster record: %w", err)
+	}
+
+	_, err = s.client.PutItem(context.Background(), &dynamodb.PutItemInput{
+		TableName: aws.String(s.config.RosterTable),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save roster record: %w", err)
+	}
+	return nil
+}
+
+// DeleteRosterRecord removes a retired agent from the managed roster
+func (s *DynamoDBStore) DeleteRosterRecord(agentID string) error {
+	_, err := s.client.DeleteItem(context.Background(), &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.config.RosterTable),
+		Key: map[string]dbtypes.AttributeValue{
+			"AgentID": &dbtypes.AttributeValueMemberS{Value: agentID},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete roster record: %w", err)
+	}
+	return nil
+}
+
+// GetRoster returns all managed roster entries
+func (s *DynamoDBStore) GetRoster() ([]types.RosterRecord, error) {
+	items, err := s.scanAll(s.config.RosterTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan roster: %w", err)
+	}
+	var records []types.RosterRecord
+	if err := attributevalue.UnmarshalListOfMaps(items, &records); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal roster: %w", err)
+	}
+	return records, nil
+}
+
+// SaveTeam creates or replaces a team
+func (s *DynamoDBStore) SaveTeam(team types.Team) error {
+	item, err := attributevalue.MarshalMap(team)
+	if err != nil {
+		return fmt.Errorf("failed to marshal team: %w", err)
+	}
+
+	_, err = s.client.PutItem(context.Background(), &dynamodb.PutItemInput{
+		TableName: aws.String(s.config.TeamsTable),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save team: %w", err)
+	}
+	return nil
+}
+
+// DeleteTeam removes a team
+func (s *DynamoDBStore) DeleteTeam(name string) error {
+	_, err := s.client.DeleteItem(context.Background(), &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.config.TeamsTable),
+		Key: map[string]dbtypes.AttributeValue{
+			"Name": &dbtypes.AttributeValueMemberS{Value: name},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete team: %w", err)
+	}
+	return nil
+}
+
+// GetTeams returns all teams
+func (s *DynamoDBStore) GetTeams() ([]types.Team, error) {
+	items, err := s.scanAll(s.config.TeamsTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan teams: %w", err)
+	}
+	var teams []types.Team
+	if err := attributevalue.UnmarshalListOfMaps(items, &teams); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal teams: %w", err)
+	}
+	return teams, nil
+}
+
+// SaveSimPreset creates or replaces a sim preset
+func (s *DynamoDBStore) SaveSimPreset(preset types.SimPreset) error {
+	item, err := attributevalue.MarshalMap(preset)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sim preset: %w", err)
+	}
+
+	_, err = s.client.PutItem(context.Background(), &dynamodb.PutItemInput{
+		TableName: aws.String(s.config.SimPresetsTable),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save sim preset: %w", err)
+	}
+	return nil
+}
+
+// DeleteSimPreset removes a sim preset
+func (s *DynamoDBStore) DeleteSimPreset(name string) error {
+	_, err := s.client.DeleteItem(context.Background(), &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.config.SimPresetsTable),
+		Key: map[string]dbtypes.AttributeValue{
+			"Name": &dbtypes.AttributeValueMemberS{Value: name},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete sim preset: %w", err)
+	}
+	return nil
+}
+
+// GetSimPresets returns all sim presets
+func (s *DynamoDBStore) GetSimPresets() ([]types.SimPreset, error) {
+	items, err := s.scanAll(s.config.SimPresetsTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan sim presets: %w", err)
+	}
+	var presets []types.SimPreset
+	if err := attributevalue.UnmarshalListOfMaps(items, &presets); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal sim presets: %w", err)
+	}
+	return presets, nil
+}
+
+// scanAll reads every item of a (small) table, following pagination
+func (s *DynamoDBStore) scanAll(table string) ([]map[string]dbtypes.AttributeValue, error) {
+	var items []map[string]dbtypes.AttributeValue
+	var lastKey map[string]dbtypes.AttributeValue
+	for {
+		result, err := s.client.Scan(context.Background(), &dynamodb.ScanInput{
+			TableName:         aws.String(table),
+			ExclusiveStartKey: lastKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, result.Items...)
+
+		lastKey = result.LastEvaluatedKey
+		if lastKey == nil {
+			return items, nil
+		}
+	}
+}
+
+// Ping verifies DynamoDB is reachable by describing the call records table
+func (s *DynamoDBStore) Ping(ctx context.Context) error {
+	_, err := s.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(s.config.CallRecordsTable),
+	})
+	return err
+}
+
 // NewStore creates the appropriate store based on configuration
 func NewStore(ctx context.Context, logger zerolog.Logger) (Store, error) {
 	cfg := LoadDynamoConfig()
 
 	switch cfg.Mode {
 	case DynamoModeLocal, DynamoModeAWS:
-		return NewDynamoDBStore(ctx, cfg, logger)
+		store, err := NewDynamoDBStore(ctx, cfg, logger)
+		if err != nil {
+			return nil, err
+		}
+		return NewResilientStore(ctx, store, cfg, logger)
+	case DynamoModeMemory:
+		logger.Info().Msg("DynamoDB replaced by an in-memory store (DYNAMO_MODE=memory)")
+		return NewMemoryStore(), nil
 	default:
 		logger.Info().Msg("DynamoDB disabled (DYNAMO_MODE=none)")
 		return NewNoopStore(), nil
 	}
 }
 
-// TruncateAll deletes all items from both DynamoDB tables (scan + batch delete)
+// TruncateAll deletes all items from the history tables (scan + batch delete).
+// The managed roster, teams and sim presets are master data and are kept.
 func (s *DynamoDBStore) TruncateAll() error {
 	tables := []struct {
 		name string
@@ -216,6 +568,10 @@ func (s *DynamoDBStore) TruncateAll() error {
 	}{
 		{s.config.CallRecordsTable, "DateKey", "CallID"},
 		{s.config.AgentDailyTable, "AgentID", "Date"},
+		{s.config.AlertsTable, "DateKey", "AlertID"},
+		{s.config.VQDailyTable, "DateKey", "VQ"},
+		{s.config.QueueEventsTable, "DateKey", "EventKey"},
+		{s.config.AgentStatesTable, "AgentID", "StartKey"},
 	}
 
 	for _, table := range tables {
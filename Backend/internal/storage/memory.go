@@ -0,0 +1,236 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// MemoryStore keeps records in process memory with the DynamoDB tables' keys and query
+// semantics, for local runs and end-to-end tests (DYNAMO_MODE=memory). Everything is lost on
+// restart.
+type MemoryStore struct {
+	mu         sync.RWMutex
+	calls      map[string]map[string]types.CallRecord      // DateKey -> CallID
+	dailyStats map[string]map[string]types.AgentDailyStats // AgentID -> Date
+	alerts     map[string]map[string]types.AlertRecord     // DateKey -> AlertID
+	vqDaily    map[string]map[string]types.VQDailyStats    // DateKey -> VQ
+	queueEvts  map[string]map[string]types.QueueEvent      // DateKey -> EventKey
+	segments   map[string]map[string]types.StateSegment    // AgentID -> StartKey
+	roster     map[string]types.RosterRecord
+	teams      map[string]types.Team
+	presets    map[string]types.SimPreset
+}
+
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{
+		roster:  make(map[string]types.RosterRecord),
+		teams:   make(map[string]types.Team),
+		presets: make(map[string]types.SimPreset),
+	}
+	s.truncate()
+	return s
+}
+
+// put stores v under partition and sort key
+func put[T any](table map[string]map[string]T, pk, sk string, v T) {
+	if table[pk] == nil {
+		table[pk] = make(map[string]T)
+	}
+	table[pk][sk] = v
+}
+
+// query returns a partition's items in sort key order, as a DynamoDB Query does
+func query[T any](table map[string]map[string]T, pk string, keep func(sk string, v T) bool) []T {
+	keys := make([]string, 0, len(table[pk]))
+	for sk, v := range table[pk] {
+		if keep == nil || keep(sk, v) {
+			keys = append(keys, sk)
+		}
+	}
+	sort.Strings(keys)
+	out := make([]T, len(keys))
+	for i, sk := range keys {
+		out[i] = table[pk][sk]
+	}
+	return out
+}
+
+func (s *MemoryStore) SaveCallRecord(record types.CallRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	put(s.calls, record.DateKey, record.CallID, record)
+	return nil
+}
+
+func (s *MemoryStore) SaveAgentDailyStats(stats types.AgentDailyStats) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	put(s.dailyStats, stats.AgentID, stats.Date, stats)
+	return nil
+}
+
+func (s *MemoryStore) GetCallRecords(dateKey string) ([]types.CallRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return query(s.calls, dateKey, nil), nil
+}
+
+func (s *MemoryStore) GetAgentDailyStats(agentID string) ([]types.AgentDailyStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return query(s.dailyStats, agentID, nil), nil
+}
+
+func (s *MemoryStore) GetAgentCallsByDate(agentID, date string) ([]types.CallRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return query(s.calls, date, func(_ string, r types.CallRecord) bool { return r.AgentID == agentID }), nil
+}
+
+func (s *MemoryStore) SaveAlert(record types.AlertRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	put(s.alerts, record.DateKey, record.AlertID, record)
+	return nil
+}
+
+func (s *MemoryStore) GetAlerts(dateKey string) ([]types.AlertRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return query(s.alerts, dateKey, nil), nil
+}
+
+func (s *MemoryStore) SaveVQDailyStats(stats types.VQDailyStats) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	put(s.vqDaily, stats.DateKey, string(stats.VQ), stats)
+	return nil
+}
+
+func (s *MemoryStore) GetVQDailyStats(dateKey string) ([]types.VQDailyStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return query(s.vqDaily, dateKey, nil), nil
+}
+
+func (s *MemoryStore) SaveQueueEvent(event types.QueueEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	put(s.queueEvts, event.DateKey, event.EventKey, event)
+	return nil
+}
+
+func (s *MemoryStore) GetQueueEvents(dateKey string, vq types.VQName) ([]types.QueueEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return query(s.queueEvts, dateKey, func(sk string, _ types.QueueEvent) bool { return strings.HasPrefix(sk, string(vq)+"#") }), nil
+}
+
+func (s *MemoryStore) SaveStateSegment(seg types.StateSegment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	put(s.segments, seg.AgentID, seg.StartKey, seg)
+	return nil
+}
+
+func (s *MemoryStore) GetStateSegments(agentID string, from, to time.Time) ([]types.StateSegment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fromKey := types.SegmentStartKey(from.Add(-types.SegmentLookback))
+	return query(s.segments, agentID, func(sk string, seg types.StateSegment) bool {
+		return sk >= fromKey && seg.Overlaps(from, to)
+	}), nil
+}
+
+func (s *MemoryStore) SaveRosterRecord(rec types.RosterRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.roster[rec.AgentID] = rec
+	return nil
+}
+
+func (s *MemoryStore) DeleteRosterRecord(agentID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.roster, agentID)
+	return nil
+}
+
+func (s *MemoryStore) GetRoster() ([]types.RosterRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]types.RosterRecord, 0, len(s.roster))
+	for _, rec := range s.roster {
+		out = append(out, rec)
+	}
+	return out, nil
+}
+
+func (s *MemoryStore) SaveTeam(team types.Team) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.teams[team.Name] = team
+	return nil
+}
+
+func (s *MemoryStore) DeleteTeam(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.teams, name)
+	return nil
+}
+
+func (s *MemoryStore) GetTeams() ([]types.Team, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]types.Team, 0, len(s.teams))
+	for _, team := range s.teams {
+		out = append(out, team)
+	}
+	return out, nil
+}
+
+func (s *MemoryStore) SaveSimPreset(preset types.SimPreset) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.presets[preset.Name] = preset
+	return nil
+}
+
+func (s *MemoryStore) DeleteSimPreset(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.presets, name)
+	return nil
+}
+
+func (s *MemoryStore) GetSimPresets() ([]types.SimPreset, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]types.SimPreset, 0, len(s.presets))
+	for _, preset := range s.presets {
+		out = append(out, preset)
+	}
+	return out, nil
+}
+
+// TruncateAll clears the history tables and keeps the roster, teams and sim presets, like DynamoDBStore
+func (s *MemoryStore) TruncateAll() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.truncate()
+	return nil
+}
+
+func (s *MemoryStore) truncate() {
+	s.calls = make(map[string]map[string]types.CallRecord)
+	s.dailyStats = make(map[string]map[string]types.AgentDailyStats)
+	s.alerts = make(map[string]map[string]types.AlertRecord)
+	s.vqDaily = make(map[string]map[string]types.VQDailyStats)
+	s.queueEvts = make(map[string]map[string]types.QueueEvent)
+	s.segments = make(map[string]map[string]types.StateSegment)
+}
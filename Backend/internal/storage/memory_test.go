@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+func TestMemoryStoreQueries(t *testing.T) {
+	s := NewMemoryStore()
+	s.SaveCallRecord(types.CallRecord{DateKey: "2026-03-02", CallID: "c2", AgentID: "a1"})
+	s.SaveCallRecord(types.CallRecord{DateKey: "2026-03-02", CallID: "c1", AgentID: "a2"})
+	s.SaveCallRecord(types.CallRecord{DateKey: "2026-03-02", CallID: "c3", AgentID: "a1"})
+	s.SaveCallRecord(types.CallRecord{DateKey: "2026-03-01", CallID: "c0", AgentID: "a1"})
+	// Same keys overwrite, as a DynamoDB PutItem does
+	s.SaveCallRecord(types.CallRecord{DateKey: "2026-03-02", CallID: "c3", AgentID: "a1", TalkTime: 42})
+
+	records, _ := s.GetCallRecords("2026-03-02")
+	if len(records) != 3 || records[0].CallID != "c1" || records[2].CallID != "c3" || records[2].TalkTime != 42 {
+		t.Fatalf("GetCallRecords = %+v, want c1, c2, c3 in order with the overwritten c3", records)
+	}
+	records, _ = s.GetAgentCallsByDate("a1", "2026-03-02")
+	if len(records) != 2 || records[0].CallID != "c2" || records[1].CallID != "c3" {
+		t.Fatalf("GetAgentCallsByDate = %+v, want c2 and c3", records)
+	}
+
+	day := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	for _, start := range []time.Time{day, day.Add(-24 * time.Hour), day.Add(-10 * time.Hour), day.Add(time.Hour)} {
+		s.SaveStateSegment(types.StateSegment{AgentID: "a1", StartKey: types.SegmentStartKey(start), Start: start, End: start.Add(2 * time.Hour)})
+	}
+	segments, _ := s.GetStateSegments("a1", day.Add(-9*time.Hour), day.Add(15*time.Hour))
+	if len(segments) != 3 || !segments[0].Start.Equal(day.Add(-10*time.Hour)) || !segments[1].Start.Equal(day) {
+		t.Fatalf("GetStateSegments = %+v, want the segment crossing midnight and the two of 2026-03-02 in order", segments)
+	}
+
+	for _, at := range []time.Time{day.Add(time.Hour), day} {
+		s.SaveQueueEvent(types.QueueEvent{DateKey: "2026-03-02", EventKey: types.QueueEventKey("sales", at), VQ: "sales", At: at})
+	}
+	s.SaveQueueEvent(types.QueueEvent{DateKey: "2026-03-02", EventKey: types.QueueEventKey("sales_vip", day), VQ: "sales_vip", At: day})
+	events, _ := s.GetQueueEvents("2026-03-02", "sales")
+	if len(events) != 2 || !events[0].At.Equal(day) {
+		t.Fatalf("GetQueueEvents = %+v, want the two sales events in order", events)
+	}
+
+	s.SaveRosterRecord(types.RosterRecord{AgentID: "a1"})
+	s.SaveTeam(types.Team{Name: "t1"})
+	if err := s.TruncateAll(); err != nil {
+		t.Fatal(err)
+	}
+	if records, _ := s.GetCallRecords("2026-03-02"); len(records) != 0 {
+		t.Errorf("call records survived TruncateAll: %+v", records)
+	}
+	roster, _ := s.GetRoster()
+	teams, _ := s.GetTeams()
+	if len(roster) != 1 || len(teams) != 1 {
+		t.Errorf("TruncateAll must keep roster and teams, got %d and %d", len(roster), len(teams))
+	}
+}
@@ -1,6 +1,10 @@
 package storage
 
-import "github.com/dennisdiepolder/monti/backend/internal/types"
+import (
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
 
 // Store defines the storage interface
 type Store interface {
@@ -9,6 +13,23 @@ type Store interface {
 	GetCallRecords(dateKey string) ([]types.CallRecord, error)
 	GetAgentDailyStats(agentID string) ([]types.AgentDailyStats, error)
 	GetAgentCallsByDate(agentID, date string) ([]types.CallRecord, error)
+	SaveAlert(record types.AlertRecord) error
+	GetAlerts(dateKey string) ([]types.AlertRecord, error)
+	SaveVQDailyStats(stats types.VQDailyStats) error
+	GetVQDailyStats(dateKey string) ([]types.VQDailyStats, error)
+	SaveQueueEvent(event types.QueueEvent) error
+	GetQueueEvents(dateKey string, vq types.VQName) ([]types.QueueEvent, error)
+	SaveStateSegment(seg types.StateSegment) error
+	GetStateSegments(agentID string, from, to time.Time) ([]types.StateSegment, error)
+	SaveRosterRecord(rec types.RosterRecord) error
+	DeleteRosterRecord(agentID string) error
+	GetRoster() ([]types.RosterRecord, error)
+	SaveTeam(team types.Team) error
+	DeleteTeam(name string) error
+	GetTeams() ([]types.Team, error)
+	SaveSimPreset(preset types.SimPreset) error
+	DeleteSimPreset(name string) error
+	GetSimPresets() ([]types.SimPreset, error)
 	TruncateAll() error
 }
 
@@ -22,4 +43,21 @@ func (s *NoopStore) SaveAgentDailyStats(_ types.AgentDailyStats) error    { retu
 func (s *NoopStore) GetCallRecords(_ string) ([]types.CallRecord, error)  { return nil, nil }
 func (s *NoopStore) GetAgentDailyStats(_ string) ([]types.AgentDailyStats, error) { return nil, nil }
 func (s *NoopStore) GetAgentCallsByDate(_, _ string) ([]types.CallRecord, error)  { return nil, nil }
+func (s *NoopStore) SaveAlert(_ types.AlertRecord) error                   { return nil }
+func (s *NoopStore) GetAlerts(_ string) ([]types.AlertRecord, error)         { return nil, nil }
+func (s *NoopStore) SaveVQDailyStats(_ types.VQDailyStats) error           { return nil }
+func (s *NoopStore) GetVQDailyStats(_ string) ([]types.VQDailyStats, error) { return nil, nil }
+func (s *NoopStore) SaveQueueEvent(_ types.QueueEvent) error { return nil }
+func (s *NoopStore) GetQueueEvents(_ string, _ types.VQName) ([]types.QueueEvent, error) { return nil, nil }
+func (s *NoopStore) SaveStateSegment(_ types.StateSegment) error           { return nil }
+func (s *NoopStore) GetStateSegments(_ string, _, _ time.Time) ([]types.StateSegment, error) { return nil, nil }
+func (s *NoopStore) SaveRosterRecord(_ types.RosterRecord) error { return nil }
+func (s *NoopStore) DeleteRosterRecord(_ string) error             { return nil }
+func (s *NoopStore) GetRoster() ([]types.RosterRecord, error)       { return nil, nil }
+func (s *NoopStore) SaveTeam(_ types.Team) error                  { return nil }
+func (s *NoopStore) DeleteTeam(_ string) error                     { return nil }
+func (s *NoopStore) GetTeams() ([]types.Team, error)               { return nil, nil }
+func (s *NoopStore) SaveSimPreset(_ types.SimPreset) error { return nil }
+func (s *NoopStore) DeleteSimPreset(_ string) error          { return nil }
+func (s *NoopStore) GetSimPresets() ([]types.SimPreset, error) { return nil, nil }
 func (s *NoopStore) TruncateAll() error                                           { return nil }
@@ -0,0 +1,529 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/metrics"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+const (
+	spoolFile  = "spool.jsonl"
+	replayFile = "spool.replay.jsonl"
+)
+
+// spoolEntry is one buffered write in the spool file
+type spoolEntry struct {
+	Kind string          `json:"kind"`
+	Key  string          `json:"key,omitempty"` // recordKey; empty in spools written before it
+	Data json.RawMessage `json:"data"`
+}
+
+// ResilientStore guards a store with a circuit breaker. Failed or rejected writes are
+// spooled to disk and replayed once the store recovers; reads fail fast while the breaker is open.
+// While a record has spooled writes, its later writes are spooled behind them, so a replay
+// never puts back an older version of it.
+type ResilientStore struct {
+	Store
+
+	breaker *CircuitBreaker
+	logger  zerolog.Logger
+
+	// Spool file state (protected by spoolMu)
+	spoolMu   sync.Mutex
+	spoolDir  string
+	spoolMax  int64
+	spooled   int
+	held      map[string]int // spooled writes by record key, including those being replayed
+	replaying bool
+}
+
+// NewResilientStore wraps store and starts replaying the spool until ctx is done
+func NewResilientStore(ctx context.Context, store Store, cfg DynamoConfig, logger zerolog.Logger) (*ResilientStore, error) {
+	if err := os.MkdirAll(cfg.SpoolDir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create spool dir: %w", err)
+	}
+
+	m := metrics.Get()
+	r := &ResilientStore{
+		Store:    store,
+		breaker:  NewCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+		logger:   logger.With().Str("component", "storage").Logger(),
+		spoolDir: cfg.SpoolDir,
+		spoolMax: cfg.SpoolMaxBytes,
+	}
+	r.breaker.onChange = func(state int) {
+		m.SetStorageBreakerState(state)
+		if state == breakerOpen {
+			r.logger.Warn().Msg("DynamoDB circuit breaker opened, spooling writes to disk")
+		} else if state == breakerClosed {
+			r.logger.Info().Msg("DynamoDB circuit breaker closed")
+		}
+	}
+
+	// A replay interrupted by a restart leaves its file behind; fold it back into the spool
+	if err := r.recoverReplayFile(); err != nil {
+		return nil, err
+	}
+	lines, err := readLines(r.path(spoolFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	n := len(lines)
+	r.spooled = n
+	r.held = heldKeys(lines)
+	m.SetStorageSpooled(n)
+	if n > 0 {
+		r.logger.Info().Int("records", n).Str("dir", r.spoolDir).Msg("found spooled records, will replay")
+	}
+
+	go r.replayLoop(ctx, cfg.BreakerCooldown)
+	return r, nil
+}
+
+func (r *ResilientStore) SaveCallRecord(record types.CallRecord) error {
+	return r.save("call_record", record.CallID, record, func() error { return r.Store.SaveCallRecord(record) })
+}
+
+func (r *ResilientStore) SaveAgentDailyStats(stats types.AgentDailyStats) error {
+	return r.save("agent_daily_stats", stats.AgentID, stats, func() error { return r.Store.SaveAgentDailyStats(stats) })
+}
+
+func (r *ResilientStore) SaveAlert(record types.AlertRecord) error {
+	return r.save("alert", record.AlertID, record, func() error { return r.Store.SaveAlert(record) })
+}
+
+func (r *ResilientStore) SaveVQDailyStats(stats types.VQDailyStats) error {
+	return r.save("vq_daily_stats", string(stats.VQ), stats, func() error { return r.Store.SaveVQDailyStats(stats) })
+}
+
+func (r *ResilientStore) SaveQueueEvent(event types.QueueEvent) error {
+	return r.save("queue_event", string(event.VQ), event, func() error { return r.Store.SaveQueueEvent(event) })
+}
+
+func (r *ResilientStore) SaveStateSegment(seg types.StateSegment) error {
+	return r.save("state_segment", seg.AgentID, seg, func() error { return r.Store.SaveStateSegment(seg) })
+}
+
+func (r *ResilientStore) SaveRosterRecord(rec types.RosterRecord) error {
+	return r.save("roster", rec.AgentID, rec, func() error { return r.Store.SaveRosterRecord(rec) })
+}
+
+func (r *ResilientStore) DeleteRosterRecord(agentID string) error {
+	return r.save("roster_delete", agentID, agentID, func() error { return r.Store.DeleteRosterRecord(agentID) })
+}
+
+func (r *ResilientStore) GetRoster() (records []types.RosterRecord, err error) {
+	err = r.breaker.Do(func() error { records, err = r.Store.GetRoster(); return err })
+	return records, err
+}
+
+func (r *ResilientStore) SaveTeam(team types.Team) error {
+	return r.save("team", team.Name, team, func() error { return r.Store.SaveTeam(team) })
+}
+
+func (r *ResilientStore) DeleteTeam(name string) error {
+	return r.save("team_delete", name, name, func() error { return r.Store.DeleteTeam(name) })
+}
+
+func (r *ResilientStore) GetTeams() (teams []types.Team, err error) {
+	err = r.breaker.Do(func() error { teams, err = r.Store.GetTeams(); return err })
+	return teams, err
+}
+
+func (r *ResilientStore) SaveSimPreset(preset types.SimPreset) error {
+	return r.save("sim_preset", preset.Name, preset, func() error { return r.Store.SaveSimPreset(preset) })
+}
+
+func (r *ResilientStore) DeleteSimPreset(name string) error {
+	return r.save("sim_preset_delete", name, name, func() error { return r.Store.DeleteSimPreset(name) })
+}
+
+func (r *ResilientStore) GetSimPresets() (presets []types.SimPreset, err error) {
+	err = r.breaker.Do(func() error { presets, err = r.Store.GetSimPresets(); return err })
+	return presets, err
+}
+
+func (r *ResilientStore) GetCallRecords(dateKey string) (records []types.CallRecord, err error) {
+	err = r.breaker.Do(func() error { records, err = r.Store.GetCallRecords(dateKey); return err })
+	return records, err
+}
+
+func (r *ResilientStore) GetAgentDailyStats(agentID string) (stats []types.AgentDailyStats, err error) {
+	err = r.breaker.Do(func() error { stats, err = r.Store.GetAgentDailyStats(agentID); return err })
+	return stats, err
+}
+
+func (r *ResilientStore) GetAgentCallsByDate(agentID, date string) (records []types.CallRecord, err error) {
+	err = r.breaker.Do(func() error { records, err = r.Store.GetAgentCallsByDate(agentID, date); return err })
+	return records, err
+}
+
+func (r *ResilientStore) GetAlerts(dateKey string) (records []types.AlertRecord, err error) {
+	err = r.breaker.Do(func() error { records, err = r.Store.GetAlerts(dateKey); return err })
+	return records, err
+}
+
+func (r *ResilientStore) GetVQDailyStats(dateKey string) (stats []types.VQDailyStats, err error) {
+	err = r.breaker.Do(func() error { stats, err = r.Store.GetVQDailyStats(dateKey); return err })
+	return stats, err
+}
+
+func (r *ResilientStore) GetQueueEvents(dateKey string, vq types.VQName) (events []types.QueueEvent, err error) {
+	err = r.breaker.Do(func() error { events, err = r.Store.GetQueueEvents(dateKey, vq); return err })
+	return events, err
+}
+
+func (r *ResilientStore) GetStateSegments(agentID string, from, to time.Time) (segs []types.StateSegment, err error) {
+	err = r.breaker.Do(func() error { segs, err = r.Store.GetStateSegments(agentID, from, to); return err })
+	return segs, err
+}
+
+// TruncateAll empties the tables and discards spooled writes, which would otherwise reappear
+func (r *ResilientStore) TruncateAll() error {
+	if err := r.breaker.Do(r.Store.TruncateAll); err != nil {
+		return err
+	}
+	r.spoolMu.Lock()
+	defer r.spoolMu.Unlock()
+	if err := os.Remove(r.path(spoolFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	r.spooled = 0
+	r.held = make(map[string]int)
+	metrics.Get().SetStorageSpooled(0)
+	return nil
+}
+
+// Ping checks the wrapped store directly so health reflects DynamoDB, not the breaker
+func (r *ResilientStore) Ping(ctx context.Context) error {
+	if p, ok := r.Store.(interface{ Ping(context.Context) error }); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// save writes through the breaker and spools the record when the write fails or is rejected.
+// A record that already has spooled writes is spooled behind them without trying the store.
+func (r *ResilientStore) save(kind, id string, v any, fn func() error) error {
+	key := recordKey(kind, id)
+	r.spoolMu.Lock()
+	held := r.held[key] > 0
+	r.spoolMu.Unlock()
+
+	err := ErrCircuitOpen
+	if !held {
+		if err = r.breaker.Do(fn); err == nil {
+			return nil
+		}
+	}
+	if spoolErr := r.spool(kind, key, v); spoolErr != nil {
+		r.logger.Error().Err(spoolErr).Str("kind", kind).Msg("failed to spool record")
+		return err
+	}
+	return nil
+}
+
+// spool appends a record to the spool file
+func (r *ResilientStore) spool(kind, key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(spoolEntry{Kind: kind, Key: key, Data: data})
+	if err != nil {
+		return err
+	}
+
+	r.spoolMu.Lock()
+	defer r.spoolMu.Unlock()
+	if err := r.appendLines(spoolFile, [][]byte{line}); err != nil {
+		metrics.Get().RecordStorageSpoolDropped()
+		return err
+	}
+	r.spooled++
+	r.held[key]++
+	metrics.Get().SetStorageSpooled(r.spooled)
+	return nil
+}
+
+// appendLines appends lines to a spool dir file, refusing to grow it past spoolMax (caller holds spoolMu)
+func (r *ResilientStore) appendLines(name string, lines [][]byte) error {
+	f, err := os.OpenFile(r.path(name), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	size := int64(0)
+	if info, err := f.Stat(); err == nil {
+		size = info.Size()
+	}
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	if r.spoolMax > 0 && size+int64(buf.Len()) > r.spoolMax {
+		return fmt.Errorf("spool full (%d bytes)", size)
+	}
+	_, err = f.Write(buf.Bytes())
+	return err
+}
+
+// replayLoop periodically drains the spool into the store
+func (r *ResilientStore) replayLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Replay()
+		}
+	}
+}
+
+// Replay writes spooled records to the store. Records that still fail go back to the spool,
+// together with their record's later writes, ahead of the writes spooled meanwhile.
+func (r *ResilientStore) Replay() {
+	r.spoolMu.Lock()
+	if r.spooled == 0 || r.replaying {
+		r.spoolMu.Unlock()
+		return
+	}
+	if err := os.Rename(r.path(spoolFile), r.path(replayFile)); err != nil {
+		r.spoolMu.Unlock()
+		r.logger.Error().Err(err).Msg("failed to start spool replay")
+		return
+	}
+	r.replaying = true
+	r.spooled = 0
+	r.spoolMu.Unlock()
+
+	lines, err := readLines(r.path(replayFile))
+	if err != nil {
+		r.logger.Error().Err(err).Msg("failed to read spool")
+	}
+
+	var remaining [][]byte
+	failed := make(map[string]bool) // records whose later writes must wait for a failed one
+	replayed := 0
+	for i, line := range lines {
+		if key := entryKey(line); key != "" && failed[key] {
+			remaining = append(remaining, line)
+			continue
+		}
+		if err := r.breaker.Do(func() error { return r.replayEntry(line) }); err != nil {
+			if errors.Is(err, ErrCircuitOpen) {
+				remaining = append(remaining, lines[i:]...)
+				break
+			}
+			r.logger.Warn().Err(err).Msg("failed to replay spooled record")
+			remaining = append(remaining, line)
+			if key := entryKey(line); key != "" {
+				failed[key] = true
+			}
+			continue
+		}
+		replayed++
+	}
+
+	r.spoolMu.Lock()
+	defer r.spoolMu.Unlock()
+	r.replaying = false
+	if len(remaining) > 0 {
+		if err := r.prependSpool(remaining); err != nil {
+			r.logger.Error().Err(err).Int("records", len(remaining)).Msg("failed to return records to spool")
+			metrics.Get().RecordStorageSpoolDropped()
+			remaining = nil
+		}
+	}
+	os.Remove(r.path(replayFile))
+	r.spooled += len(remaining)
+	spooled, _ := readLines(r.path(spoolFile))
+	r.held = heldKeys(spooled)
+	metrics.Get().SetStorageSpooled(r.spooled)
+
+	if replayed > 0 {
+		r.logger.Info().Int("replayed", replayed).Int("remaining", r.spooled).Msg("replayed spooled records")
+	}
+}
+
+// replayEntry decodes one spool line and writes it to the wrapped store
+func (r *ResilientStore) replayEntry(line []byte) error {
+	var e spoolEntry
+	if err := json.Unmarshal(line, &e); err != nil {
+		r.logger.Error().Err(err).Msg("dropping corrupt spool entry")
+		return nil
+	}
+	switch e.Kind {
+	case "call_record":
+		var v types.CallRecord
+		if err := json.Unmarshal(e.Data, &v); err != nil {
+			return nil
+		}
+		return r.Store.SaveCallRecord(v)
+	case "agent_daily_stats":
+		var v types.AgentDailyStats
+		if err := json.Unmarshal(e.Data, &v); err != nil {
+			return nil
+		}
+		return r.Store.SaveAgentDailyStats(v)
+	case "alert":
+		var v types.AlertRecord
+		if err := json.Unmarshal(e.Data, &v); err != nil {
+			return nil
+		}
+		return r.Store.SaveAlert(v)
+	case "vq_daily_stats":
+		var v types.VQDailyStats
+		if err := json.Unmarshal(e.Data, &v); err != nil {
+			return nil
+		}
+		return r.Store.SaveVQDailyStats(v)
+	case "queue_event":
+		var v types.QueueEvent
+		if err := json.Unmarshal(e.Data, &v); err != nil {
+			return nil
+		}
+		return r.Store.SaveQueueEvent(v)
+	case "state_segment":
+		var v types.StateSegment
+		if err := json.Unmarshal(e.Data, &v); err != nil {
+			return nil
+		}
+		return r.Store.SaveStateSegment(v)
+	case "roster":
+		var v types.RosterRecord
+		if err := json.Unmarshal(e.Data, &v); err != nil {
+			return nil
+		}
+		return r.Store.SaveRosterRecord(v)
+	case "roster_delete":
+		var agentID string
+		if err := json.Unmarshal(e.Data, &agentID); err != nil {
+			return nil
+		}
+		return r.Store.DeleteRosterRecord(agentID)
+	case "team":
+		var v types.Team
+		if err := json.Unmarshal(e.Data, &v); err != nil {
+			return nil
+		}
+		return r.Store.SaveTeam(v)
+	case "team_delete":
+		var name string
+		if err := json.Unmarshal(e.Data, &name); err != nil {
+			return nil
+		}
+		return r.Store.DeleteTeam(name)
+	case "sim_preset":
+		var v types.SimPreset
+		if err := json.Unmarshal(e.Data, &v); err != nil {
+			return nil
+		}
+		return r.Store.SaveSimPreset(v)
+	case "sim_preset_delete":
+		var name string
+		if err := json.Unmarshal(e.Data, &name); err != nil {
+			return nil
+		}
+		return r.Store.DeleteSimPreset(name)
+	default:
+		r.logger.Error().Str("kind", e.Kind).Msg("dropping spool entry of unknown kind")
+		return nil
+	}
+}
+
+// recoverReplayFile moves leftovers of an interrupted replay back into the spool, ahead of
+// the writes spooled after them
+func (r *ResilientStore) recoverReplayFile() error {
+	lines, err := readLines(r.path(replayFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(lines) > 0 {
+		if err := r.prependSpool(lines); err != nil {
+			return err
+		}
+	}
+	return os.Remove(r.path(replayFile))
+}
+
+// prependSpool puts lines ahead of the spool file's records, ignoring spoolMax since they
+// were accepted once already (caller holds spoolMu)
+func (r *ResilientStore) prependSpool(lines [][]byte) error {
+	newer, err := readLines(r.path(spoolFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	tmp := spoolFile + ".tmp"
+	os.Remove(r.path(tmp))
+	max := r.spoolMax
+	r.spoolMax = 0
+	err = r.appendLines(tmp, append(lines, newer...))
+	r.spoolMax = max
+	if err != nil {
+		return err
+	}
+	return os.Rename(r.path(tmp), r.path(spoolFile))
+}
+
+// entryKey returns the record key of a spool line, empty when it has none
+func entryKey(line []byte) string {
+	var e spoolEntry
+	if err := json.Unmarshal(line, &e); err != nil {
+		return ""
+	}
+	return e.Key
+}
+
+// heldKeys counts spool lines by record key
+func heldKeys(lines [][]byte) map[string]int {
+	held := make(map[string]int)
+	for _, line := range lines {
+		if key := entryKey(line); key != "" {
+			held[key]++
+		}
+	}
+	return held
+}
+
+func (r *ResilientStore) path(name string) string {
+	return filepath.Join(r.spoolDir, name)
+}
+
+// readLines returns the non-empty lines of a file
+func readLines(path string) ([][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		lines = append(lines, append([]byte(nil), scanner.Bytes()...))
+	}
+	return lines, scanner.Err()
+}
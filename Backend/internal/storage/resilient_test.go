@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// flakyStore fails call record writes while down and remembers saved IDs
+type flakyStore struct {
+	NoopStore
+	mu     sync.Mutex
+	down   bool
+	calls  int
+	saved  []string
+	roster map[string]types.RosterRecord
+}
+
+func (s *flakyStore) SaveCallRecord(r types.CallRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.down {
+		return errors.New("throttled")
+	}
+	s.saved = append(s.saved, r.CallID)
+	return nil
+}
+
+func (s *flakyStore) SaveRosterRecord(rec types.RosterRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.down {
+		return errors.New("throttled")
+	}
+	if s.roster == nil {
+		s.roster = make(map[string]types.RosterRecord)
+	}
+	s.roster[rec.AgentID] = rec
+	return nil
+}
+
+func (s *flakyStore) DeleteRosterRecord(agentID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.down {
+		return errors.New("throttled")
+	}
+	delete(s.roster, agentID)
+	return nil
+}
+
+func (s *flakyStore) setDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down = down
+}
+
+func TestCircuitBreakerOpensAndProbes(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := NewCircuitBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	fail := func() error { return errors.New("boom") }
+	b.Do(fail)
+	if !b.Closed() {
+		t.Fatal("expected breaker to stay closed below threshold")
+	}
+	b.Do(fail)
+	if err := b.Do(func() error { return nil }); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+
+	// After the cooldown one probe goes through and closes the breaker
+	now = now.Add(time.Minute)
+	if err := b.Do(func() error { return nil }); err != nil {
+		t.Fatalf("expected probe to run, got %v", err)
+	}
+	if !b.Closed() {
+		t.Error("expected breaker to close after a successful probe")
+	}
+}
+
+func TestResilientStoreSpoolsAndReplays(t *testing.T) {
+	inner := &flakyStore{down: true}
+	cfg := DynamoConfig{BreakerThreshold: 2, BreakerCooldown: time.Hour, SpoolDir: t.TempDir()}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store, err := NewResilientStore(ctx, inner, cfg, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, id := range []string{"c1", "c2", "c3", "c4"} {
+		if err := store.SaveCallRecord(types.CallRecord{CallID: id}); err != nil {
+			t.Fatalf("expected spooled write to succeed, got %v", err)
+		}
+	}
+	// Two failures open the breaker; the rest never reach the store
+	if inner.calls != 2 {
+		t.Errorf("expected 2 store calls before opening, got %d", inner.calls)
+	}
+
+	// The spool survives a restart
+	restarted, err := NewResilientStore(ctx, inner, cfg, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if restarted.spooled != 4 {
+		t.Fatalf("expected 4 spooled records, got %d", restarted.spooled)
+	}
+
+	inner.setDown(false)
+	restarted.Replay()
+	if len(inner.saved) != 4 || restarted.spooled != 0 {
+		t.Errorf("expected all records replayed, saved %v, %d left", inner.saved, restarted.spooled)
+	}
+}
+
+func TestResilientStoreKeepsRecordsWhileStillDown(t *testing.T) {
+	inner := &flakyStore{down: true}
+	cfg := DynamoConfig{BreakerThreshold: 1, BreakerCooldown: time.Hour, SpoolDir: t.TempDir()}
+	store, err := NewResilientStore(context.Background(), inner, cfg, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	store.SaveCallRecord(types.CallRecord{CallID: "c1"})
+	store.SaveCallRecord(types.CallRecord{CallID: "c2"})
+
+	store.Replay()
+	if store.spooled != 2 {
+		t.Errorf("expected records to stay spooled, got %d", store.spooled)
+	}
+}
+
+func TestResilientStoreReplaysRosterChangesInOrder(t *testing.T) {
+	inner := &flakyStore{down: true}
+	cfg := DynamoConfig{BreakerThreshold: 1, BreakerCooldown: time.Hour, SpoolDir: t.TempDir()}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store, err := NewResilientStore(ctx, inner, cfg, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	store.SaveRosterRecord(types.RosterRecord{AgentID: "a1", Team: "Alpha", Skills: []string{"billing"}})
+	store.SaveRosterRecord(types.RosterRecord{AgentID: "a2", Team: "Beta"})
+	store.DeleteRosterRecord("a2")
+
+	// Let the cooldown pass so replay may probe the store
+	store.breaker.now = func() time.Time { return time.Now().Add(time.Hour) }
+	inner.setDown(false)
+	store.Replay()
+	if _, ok := inner.roster["a2"]; ok {
+		t.Error("expected retired agent to stay deleted after replay")
+	}
+	if rec := inner.roster["a1"]; rec.Team != "Alpha" || len(rec.Skills) != 1 {
+		t.Errorf("expected a1 replayed with skills, got %+v", rec)
+	}
+}
+
+func TestResilientStoreSpoolsLaterWritesOfSpooledRecord(t *testing.T) {
+	inner := &flakyStore{down: true}
+	cfg := DynamoConfig{BreakerThreshold: 5, BreakerCooldown: time.Hour, SpoolDir: t.TempDir()}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store, err := NewResilientStore(ctx, inner, cfg, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The save is spooled; the store recovers before the agent is retired
+	store.SaveRosterRecord(types.RosterRecord{AgentID: "a1", Team: "Alpha"})
+	inner.setDown(false)
+	store.DeleteRosterRecord("a1")
+	store.SaveRosterRecord(types.RosterRecord{AgentID: "a2", Team: "Beta"})
+	if _, ok := inner.roster["a2"]; !ok {
+		t.Error("expected a record without spooled writes to be written directly")
+	}
+	if store.spooled != 2 {
+		t.Fatalf("expected the retirement spooled behind the save, got %d spooled", store.spooled)
+	}
+
+	store.Replay()
+	if _, ok := inner.roster["a1"]; ok {
+		t.Error("expected the retired agent to stay deleted after replay")
+	}
+	if store.spooled != 0 || len(store.held) != 0 {
+		t.Errorf("expected the spool drained, got %d spooled, held %v", store.spooled, store.held)
+	}
+	store.SaveRosterRecord(types.RosterRecord{AgentID: "a1", Team: "Gamma"})
+	if rec := inner.roster["a1"]; rec.Team != "Gamma" {
+		t.Errorf("expected writes to go straight to the store once replayed, got %+v", rec)
+	}
+}
@@ -20,6 +20,13 @@ func CreateTablesIfNotExist(ctx context.Context, client *dynamodb.Client, config
 	}{
 		{config.CallRecordsTable, "DateKey", "CallID"},
 		{config.AgentDailyTable, "AgentID", "Date"},
+		{config.AlertsTable, "DateKey", "AlertID"},
+		{config.VQDailyTable, "DateKey", "VQ"},
+		{config.QueueEventsTable, "DateKey", "EventKey"},
+		{config.AgentStatesTable, "AgentID", "StartKey"},
+		{config.RosterTable, "AgentID", ""}, // hash key only
+		{config.TeamsTable, "Name", ""},
+		{config.SimPresetsTable, "Name", ""},
 	}
 
 	for _, table := range tables {
@@ -31,17 +38,22 @@ func CreateTablesIfNotExist(ctx context.Context, client *dynamodb.Client, config
 			continue
 		}
 
+		keySchema := []dbtypes.KeySchemaElement{
+			{AttributeName: aws.String(table.pk), KeyType: dbtypes.KeyTypeHash},
+		}
+		attrs := []dbtypes.AttributeDefinition{
+			{AttributeName: aws.String(table.pk), AttributeType: dbtypes.ScalarAttributeTypeS},
+		}
+		if table.sk != "" {
+			keySchema = append(keySchema, dbtypes.KeySchemaElement{AttributeName: aws.String(table.sk), KeyType: dbtypes.KeyTypeRange})
+			attrs = append(attrs, dbtypes.AttributeDefinition{AttributeName: aws.String(table.sk), AttributeType: dbtypes.ScalarAttributeTypeS})
+		}
+
 		_, err = client.CreateTable(ctx, &dynamodb.CreateTableInput{
-			TableName: aws.String(table.name),
-			KeySchema: []dbtypes.KeySchemaElement{
-				{AttributeName: aws.String(table.pk), KeyType: dbtypes.KeyTypeHash},
-				{AttributeName: aws.String(table.sk), KeyType: dbtypes.KeyTypeRange},
-			},
-			AttributeDefinitions: []dbtypes.AttributeDefinition{
-				{AttributeName: aws.String(table.pk), AttributeType: dbtypes.ScalarAttributeTypeS},
-				{AttributeName: aws.String(table.sk), AttributeType: dbtypes.ScalarAttributeTypeS},
-			},
-			BillingMode: dbtypes.BillingModePayPerRequest,
+			TableName:            aws.String(table.name),
+			KeySchema:            keySchema,
+			AttributeDefinitions: attrs,
+			BillingMode:          dbtypes.BillingModePayPerRequest,
 		})
 		if err != nil {
 			var riue *dbtypes.ResourceInUseException
@@ -0,0 +1,144 @@
+package teams
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// ErrTeamNotFound is returned when deleting an unknown team
+var ErrTeamNotFound = errors.New("team not found")
+
+// Store persists teams (implemented by storage.Store)
+type Store interface {
+	SaveTeam(team types.Team) error
+	DeleteTeam(name string) error
+	GetTeams() ([]types.Team, error)
+}
+
+// Directory holds the team → supervisor → department mapping in memory, backed by a store
+type Directory struct {
+	mu           sync.RWMutex
+	teams        map[string]types.Team // by name
+	bySupervisor map[string][]string   // lower-cased supervisor email -> team names
+	store        Store
+}
+
+// NewDirectory creates an empty directory
+func NewDirectory(store Store) *Directory {
+	return &Directory{
+		teams:        make(map[string]types.Team),
+		bySupervisor: make(map[string][]string),
+		store:        store,
+	}
+}
+
+// Load replaces the directory contents with the persisted teams
+func (d *Directory) Load() (int, error) {
+	teams, err := d.store.GetTeams()
+	if err != nil {
+		return 0, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.teams = make(map[string]types.Team, len(teams))
+	for _, t := range teams {
+		d.teams[t.Name] = t
+	}
+	d.reindex()
+	return len(teams), nil
+}
+
+// Put creates or replaces a team and persists it
+func (d *Directory) Put(team types.Team) (types.Team, error) {
+	team.UpdatedAt = time.Now().UTC()
+	if err := d.store.SaveTeam(team); err != nil {
+		return team, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.teams[team.Name] = team
+	d.reindex()
+	return team, nil
+}
+
+// Delete removes a team and its persisted record
+func (d *Directory) Delete(name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.teams[name]; !ok {
+		return ErrTeamNotFound
+	}
+	if err := d.store.DeleteTeam(name); err != nil {
+		return err
+	}
+	delete(d.teams, name)
+	d.reindex()
+	return nil
+}
+
+// Get returns a team by name
+func (d *Directory) Get(name string) (types.Team, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	t, ok := d.teams[name]
+	return t, ok
+}
+
+// List returns all teams sorted by name
+func (d *Directory) List() []types.Team {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	out := make([]types.Team, 0, len(d.teams))
+	for _, t := range d.teams {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Empty reports whether no teams are defined (teams are then free-form strings)
+func (d *Directory) Empty() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.teams) == 0
+}
+
+// Supervisor returns the supervisor email of a team, or "" if unknown
+func (d *Directory) Supervisor(team string) string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.teams[team].Supervisor
+}
+
+// SupervisedBy returns the names of the teams supervised by email (case-insensitive)
+func (d *Directory) SupervisedBy(email string) []string {
+	if email == "" {
+		return nil
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	names := d.bySupervisor[strings.ToLower(email)]
+	out := make([]string, len(names))
+	copy(out, names)
+	return out
+}
+
+// reindex rebuilds the supervisor index (caller holds mu)
+func (d *Directory) reindex() {
+	d.bySupervisor = make(map[string][]string)
+	for name, t := range d.teams {
+		if t.Supervisor == "" {
+			continue
+		}
+		key := strings.ToLower(t.Supervisor)
+		d.bySupervisor[key] = append(d.bySupervisor[key], name)
+	}
+	for _, names := range d.bySupervisor {
+		sort.Strings(names)
+	}
+}
@@ -0,0 +1,67 @@
+package teams
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// memStore keeps teams in a map
+type memStore struct {
+	teams map[string]types.Team
+}
+
+func (s *memStore) SaveTeam(t types.Team) error {
+	s.teams[t.Name] = t
+	return nil
+}
+
+func (s *memStore) DeleteTeam(name string) error {
+	delete(s.teams, name)
+	return nil
+}
+
+func (s *memStore) GetTeams() ([]types.Team, error) {
+	var out []types.Team
+	for _, t := range s.teams {
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+func TestDirectorySupervisorIndex(t *testing.T) {
+	store := &memStore{teams: make(map[string]types.Team)}
+	d := NewDirectory(store)
+
+	d.Put(types.Team{Name: "beta", Department: types.DeptSales, Supervisor: "Lead@monti.local"})
+	d.Put(types.Team{Name: "alpha", Department: types.DeptSales, Supervisor: "lead@monti.local"})
+	d.Put(types.Team{Name: "gamma", Department: types.DeptSupport})
+
+	got := d.SupervisedBy("LEAD@monti.local")
+	if len(got) != 2 || got[0] != "alpha" || got[1] != "beta" {
+		t.Errorf("expected case-insensitive, sorted teams, got %v", got)
+	}
+
+	// Reassigning a team moves it to the new supervisor
+	d.Put(types.Team{Name: "beta", Department: types.DeptSales, Supervisor: "other@monti.local"})
+	if got := d.SupervisedBy("lead@monti.local"); len(got) != 1 {
+		t.Errorf("expected one team after reassignment, got %v", got)
+	}
+
+	if err := d.Delete("alpha"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.Delete("alpha"); !errors.Is(err, ErrTeamNotFound) {
+		t.Errorf("expected ErrTeamNotFound, got %v", err)
+	}
+
+	// A fresh directory restores the persisted teams
+	restored := NewDirectory(store)
+	if n, err := restored.Load(); err != nil || n != 2 {
+		t.Fatalf("expected 2 teams loaded, got %d, %v", n, err)
+	}
+	if restored.Supervisor("beta") != "other@monti.local" {
+		t.Errorf("expected persisted supervisor, got %q", restored.Supervisor("beta"))
+	}
+}
@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const remoteParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func useRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return rec
+}
+
+func TestMiddlewareContinuesTrace(t *testing.T) {
+	rec := useRecorder(t)
+
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Post("/internal/event", func(w http.ResponseWriter, r *http.Request) {
+		AddPendingLink(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/internal/event", nil)
+	req.Header.Set("traceparent", remoteParent)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := rec.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "POST /internal/event" {
+		t.Errorf("expected span named after the route, got %q", span.Name())
+	}
+	if got := span.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected the incoming trace to be continued, got trace %s", got)
+	}
+
+	links := DrainPendingLinks()
+	if len(links) != 1 || links[0].SpanContext.SpanID() != span.SpanContext().SpanID() {
+		t.Fatalf("expected the request span as pending link, got %+v", links)
+	}
+	if len(DrainPendingLinks()) != 0 {
+		t.Error("expected links to be cleared after draining")
+	}
+}
+
+func TestTraceParentRoundTrip(t *testing.T) {
+	useRecorder(t)
+
+	ctx := ContextFromTraceParent(context.Background(), remoteParent)
+	ctx, span := Tracer().Start(ctx, "child")
+	defer span.End()
+
+	tp := TraceParent(ctx)
+	back := trace.SpanContextFromContext(ContextFromTraceParent(context.Background(), tp))
+	if back.TraceID() != span.SpanContext().TraceID() || back.SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("expected %q to describe the child span", tp)
+	}
+	if TraceParent(context.Background()) != "" {
+		t.Error("expected no traceparent without a span")
+	}
+}
@@ -0,0 +1,55 @@
+package types
+
+import "time"
+
+// AgentDetailEvent identifies what triggered an agent_detail message
+type AgentDetailEvent string
+
+const (
+	AgentDetailInitial      AgentDetailEvent = "initial" // sent once on subscribe, from the latest snapshot
+	AgentDetailHeartbeat    AgentDetailEvent = "heartbeat"
+	AgentDetailStateChange  AgentDetailEvent = "state_change"
+	AgentDetailCallComplete AgentDetailEvent = "call_complete"
+	AgentDetailCallHold     AgentDetailEvent = "call_hold" // the caller was put on hold or picked up again
+	AgentDetailAlerts       AgentDetailEvent = "alerts"    // the agent's active alerts changed
+)
+
+// AgentDetail is pushed to frontend clients subscribed to a single agent
+type AgentDetail struct {
+	Type      string           `json:"type"` // always "agent_detail"
+	Event     AgentDetailEvent `json:"event"`
+	Timestamp time.Time        `json:"timestamp"`
+	Agent     AgentInfo        `json:"agent"`            // current tracker state incl. alerts from the last snapshot
+	CallID    string           `json:"callId,omitempty"` // call_complete and call_hold only
+}
+
+// ClientCommand is sent from a frontend client over /ws
+// Types: "subscribe_agent", "unsubscribe_agent", "ack" (acknowledges snapshot Seq, unanswered),
+// "hello" (declares the snapshot schema versions the client understands), and the on-demand
+// requests "get_snapshot", "get_agent" and "get_queue_history"
+type ClientCommand struct {
+	Type           string `json:"type"`
+	RequestID      string `json:"requestId,omitempty"` // echoed in the result to correlate it
+	AgentID        string `json:"agentId"`
+	Seq            int64  `json:"seq,omitempty"`            // ack only
+	SchemaVersions []int  `json:"schemaVersions,omitempty"` // hello only
+
+	VQ              VQName `json:"vq,omitempty"`              // get_queue_history only
+	Minutes         int    `json:"minutes,omitempty"`         // get_queue_history: window before now; default 60, at most 1440
+	IntervalMinutes int    `json:"intervalMinutes,omitempty"` // get_queue_history: default 1
+}
+
+// ClientCommandResult answers a ClientCommand
+type ClientCommandResult struct {
+	Type      string `json:"type"`    // always "command_result"
+	Command   string `json:"command"` // the command type being answered
+	RequestID string `json:"requestId,omitempty"`
+	AgentID   string `json:"agentId,omitempty"`
+	OK        bool   `json:"ok"`
+	Error     string `json:"error,omitempty"`
+
+	SchemaVersion int            `json:"schemaVersion,omitempty"` // hello only: the version snapshots are sent in
+	Snapshot      *Snapshot      `json:"snapshot,omitempty"`      // get_snapshot: the latest snapshot, filtered like broadcasts
+	Agent         *AgentInfo     `json:"agent,omitempty"`         // get_agent: the agent in the latest snapshot
+	Intervals     []IntervalStat `json:"intervals,omitempty"`     // get_queue_history: completed intervals, oldest first
+}
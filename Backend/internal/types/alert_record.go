@@ -0,0 +1,52 @@
+package types
+
+import "time"
+
+// AlertStatus is the lifecycle state of an alert
+type AlertStatus string
+
+const (
+	AlertFiring       AlertStatus = "firing"
+	AlertAcknowledged AlertStatus = "acknowledged"
+	AlertResolved     AlertStatus = "resolved"
+)
+
+// AlertRecord is a stateful alert tracked from firing to resolution and persisted to DynamoDB
+type AlertRecord struct {
+	DateKey        string        `json:"dateKey" dynamodbav:"DateKey"` // YYYY-MM-DD of FiredAt (partition key)
+	AlertID        string        `json:"alertId" dynamodbav:"AlertID"` // sort key
+	Rule           string        `json:"rule" dynamodbav:"Rule"`
+	Severity       AlertSeverity `json:"severity" dynamodbav:"Severity"`
+	Message        string        `json:"message" dynamodbav:"Message"` // latest message while firing
+	Scope          AlertScope    `json:"scope" dynamodbav:"Scope"`
+	Target         string        `json:"target" dynamodbav:"Target"` // agent ID, team name, department or VQ
+	Department     Department    `json:"department" dynamodbav:"Department"`
+	Tenant         string        `json:"tenant,omitempty" dynamodbav:"Tenant,omitempty"`     // empty = DefaultTenant
+	Location       Location      `json:"location,omitempty" dynamodbav:"Location,omitempty"` // agent alerts only
+	Team           string        `json:"team,omitempty" dynamodbav:"Team,omitempty"`         // agent alerts only
+	Status         AlertStatus   `json:"status" dynamodbav:"Status"`
+	FiredAt        time.Time     `json:"firedAt" dynamodbav:"FiredAt"`
+	AcknowledgedAt *time.Time    `json:"acknowledgedAt,omitempty" dynamodbav:"AcknowledgedAt,omitempty"`
+	AcknowledgedBy string        `json:"acknowledgedBy,omitempty" dynamodbav:"AcknowledgedBy,omitempty"`
+	ResolvedAt     *time.Time    `json:"resolvedAt,omitempty" dynamodbav:"ResolvedAt,omitempty"`
+	Duration       float64       `json:"duration,omitempty" dynamodbav:"Duration,omitempty"` // seconds from firing to resolution
+}
+
+// AgentAlertHistory is the response of GET /api/agents/{agentId}/alerts: the agent's alerts
+// of one day, oldest first, and how often and how long each rule was breached
+type AgentAlertHistory struct {
+	AgentID string             `json:"agentId"`
+	Date    string             `json:"date"` // YYYY-MM-DD in the reporting timezone
+	Alerts  []AlertRecord      `json:"alerts"`
+	Rules   []AlertRuleSummary `json:"rules"` // by rule name
+}
+
+// AlertRuleSummary counts one rule's alerts in an AgentAlertHistory. Alerts still active
+// count with the time they have been firing so far.
+type AlertRuleSummary struct {
+	Rule           string  `json:"rule"`
+	Count          int     `json:"count"`
+	Critical       int     `json:"critical"` // occurrences that were critical when last seen
+	TotalSeconds   float64 `json:"totalSeconds"`
+	LongestSeconds float64 `json:"longestSeconds"`
+}
@@ -0,0 +1,74 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// AnnouncementSeverity styles an announcement banner
+type AnnouncementSeverity string
+
+const (
+	AnnouncementInfo     AnnouncementSeverity = "info"
+	AnnouncementWarning  AnnouncementSeverity = "warning"
+	AnnouncementCritical AnnouncementSeverity = "critical"
+)
+
+// MaxAnnouncementLength caps the text of an announcement
+const MaxAnnouncementLength = 500
+
+// Announcement is a shift-wide notice (system maintenance, weather, ...) embedded in every
+// snapshot until it expires or is withdrawn
+type Announcement struct {
+	ID          string               `json:"id"`
+	Tenant      string               `json:"tenant,omitempty"` // publishing admin's tenant; empty = DefaultTenant
+	Text        string               `json:"text"`
+	Severity    AnnouncementSeverity `json:"severity"`
+	Departments []Department         `json:"departments,omitempty"` // shown to dashboards of these departments; empty = all
+	Locations   []Location           `json:"locations,omitempty"`   // shown to dashboards of these locations; empty = all
+	CreatedAt   time.Time            `json:"createdAt"`
+	CreatedBy   string               `json:"createdBy,omitempty"`
+	ExpiresAt   *time.Time           `json:"expiresAt,omitempty"` // nil = until withdrawn
+}
+
+// AnnouncementRequest is the body of POST /api/admin/announcements
+type AnnouncementRequest struct {
+	Text        string               `json:"text"`
+	Severity    AnnouncementSeverity `json:"severity,omitempty"` // empty = info
+	Departments []Department         `json:"departments,omitempty"`
+	Locations   []Location           `json:"locations,omitempty"`
+	ExpiresAt   *time.Time           `json:"expiresAt,omitempty"`
+}
+
+// Valid reports whether s is a known severity
+func (s AnnouncementSeverity) Valid() bool {
+	return s == AnnouncementInfo || s == AnnouncementWarning || s == AnnouncementCritical
+}
+
+// Validate checks the text, severity, audience and that the announcement expires after now
+func (r AnnouncementRequest) Validate(now time.Time) []FieldError {
+	var errs []FieldError
+	switch {
+	case r.Text == "":
+		errs = append(errs, FieldError{Field: "text", Message: "required"})
+	case len(r.Text) > MaxAnnouncementLength:
+		errs = append(errs, FieldError{Field: "text", Message: fmt.Sprintf("must be at most %d characters", MaxAnnouncementLength)})
+	}
+	if r.Severity != "" && !r.Severity.Valid() {
+		errs = append(errs, FieldError{Field: "severity", Message: fmt.Sprintf("unknown severity %q", r.Severity)})
+	}
+	for i, dept := range r.Departments {
+		if !dept.Valid() {
+			errs = append(errs, FieldError{Field: fmt.Sprintf("departments[%d]", i), Message: fmt.Sprintf("unknown department %q", dept)})
+		}
+	}
+	for i, loc := range r.Locations {
+		if !loc.Valid() {
+			errs = append(errs, FieldError{Field: fmt.Sprintf("locations[%d]", i), Message: fmt.Sprintf("unknown location %q", loc)})
+		}
+	}
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(now) {
+		errs = append(errs, FieldError{Field: "expiresAt", Message: "must be in the future"})
+	}
+	return errs
+}
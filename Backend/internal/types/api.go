@@ -0,0 +1,397 @@
+package types
+
+import "time"
+
+// Request and response bodies of the REST API. Handlers encode these types so the OpenAPI
+// document and the generated client (pkg/client) are derived from the same definitions.
+
+// ErrorResponse is the body of every 4xx/5xx response of the REST API
+type ErrorResponse struct {
+	Error     string       `json:"error"`               // human-readable message
+	Code      string       `json:"code"`                // one of the ErrCode constants
+	RequestID string       `json:"requestId,omitempty"` // X-Request-Id of the request, as logged by the backend
+	Fields    []FieldError `json:"fields,omitempty"`    // rejected fields of a validation_failed error
+}
+
+// Error codes of ErrorResponse. Clients branch on these rather than on messages; most follow
+// from the status, the rest single out failures a client handles differently.
+const (
+	ErrCodeBadRequest        = "bad_request"
+	ErrCodeValidation        = "validation_failed"
+	ErrCodeUnauthorized      = "unauthorized"
+	ErrCodeForbidden         = "forbidden"
+	ErrCodeNotFound          = "not_found"
+	ErrCodeMethodNotAllowed  = "method_not_allowed"
+	ErrCodeConflict          = "conflict"
+	ErrCodePayloadTooLarge   = "payload_too_large"
+	ErrCodeRateLimited       = "rate_limited"
+	ErrCodeInternal          = "internal"
+	ErrCodeUpstream          = "upstream_unavailable"
+	ErrCodeUnavailable       = "unavailable"
+	ErrCodeAgentNotConnected = "agent_not_connected"
+	ErrCodeAgentOnCall       = "agent_on_call"
+	ErrCodeLockedOut         = "locked_out"
+	ErrCodeSimNotConfigured  = "sim_not_configured"
+)
+
+// MessageResponse acknowledges an admin action
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// ConfigSetting is one effective configuration value and where it came from
+type ConfigSetting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"` // env, file or default
+}
+
+// ConfigResponse is the body of GET /api/admin/config
+type ConfigResponse struct {
+	Settings []ConfigSetting `json:"settings"`
+}
+
+// Capabilities is the body of GET /api/admin/capabilities: the optional features this
+// deployment has, so clients can hide the controls of the others
+type Capabilities struct {
+	Simulation bool `json:"simulation"` // AGENTSIM_URL is set: the sim and call config endpoints work
+	EventLog   bool `json:"eventLog"`   // EVENT_LOG_FILE is set: the event log can be replayed
+}
+
+// AgentFilter selects agents for GET /api/agents and bulk actions (empty fields = any)
+type AgentFilter struct {
+	Department       Department            `json:"department,omitempty"`
+	Location         Location              `json:"location,omitempty"`
+	Team             string                `json:"team,omitempty"`
+	State            AgentState            `json:"state,omitempty"`
+	ConnectionStatus AgentConnectionStatus `json:"connectionStatus,omitempty"`
+}
+
+// IsEmpty reports whether the filter selects every agent
+func (f AgentFilter) IsEmpty() bool {
+	return f == AgentFilter{}
+}
+
+// Matches reports whether the agent passes every set field
+func (f AgentFilter) Matches(agent AgentInfo) bool {
+	return (f.Department == "" || agent.Department == f.Department) &&
+		(f.Location == "" || agent.Location == f.Location) &&
+		(f.Team == "" || agent.Team == f.Team) &&
+		(f.State == "" || agent.State == f.State) &&
+		(f.ConnectionStatus == "" || agent.ConnectionStatus == f.ConnectionStatus)
+}
+
+// AgentList is the body of GET /api/agents
+type AgentList struct {
+	Agents []AgentInfo `json:"agents"`
+	Count  int         `json:"count"`
+}
+
+// AgentEventList is the body of GET /api/events
+type AgentEventList struct {
+	Events []AgentEvent `json:"events"` // oldest first
+	Count  int          `json:"count"`
+}
+
+// QueueList is the body of GET /api/queues
+type QueueList struct {
+	Queues []VQSnapshot `json:"queues"`
+	Count  int          `json:"count"`
+}
+
+// ForceStateRequest is the body of POST /api/agents/{agentId}/state
+type ForceStateRequest struct {
+	State AgentState `json:"state"`
+}
+
+// AgentActionResponse acknowledges a supervisor action on one agent
+type AgentActionResponse struct {
+	Message string     `json:"message"`
+	AgentID string     `json:"agentId"`
+	CallID  string     `json:"callId,omitempty"`
+	State   AgentState `json:"state,omitempty"`
+}
+
+// BulkActionRequest is the body of POST /api/agents/bulk
+type BulkActionRequest struct {
+	Action string      `json:"action"`          // "logout" or "force_state"
+	State  AgentState  `json:"state,omitempty"` // target state for force_state
+	Filter AgentFilter `json:"filter"`
+	DryRun bool        `json:"dryRun"`
+}
+
+// BulkActionResult reports the outcome for one matched agent
+type BulkActionResult struct {
+	AgentID  string     `json:"agentId"`
+	State    AgentState `json:"state"`
+	Location Location   `json:"location"`
+	Team     string     `json:"team"`
+	OK       bool       `json:"ok"`              // applied (or would be applied in dry-run)
+	Error    string     `json:"error,omitempty"` // why the agent was skipped
+}
+
+// BulkActionResponse is the body returned by POST /api/agents/bulk
+type BulkActionResponse struct {
+	Action  string             `json:"action"`
+	DryRun  bool               `json:"dryRun"`
+	Matched int                `json:"matched"`
+	Applied int                `json:"applied"`
+	Results []BulkActionResult `json:"results"`
+}
+
+// SimStatus is the AgentSim status proxied by GET /api/admin/sim/status
+type SimStatus struct {
+	Running      bool       `json:"running"`
+	TotalAgents  int        `json:"totalAgents"`
+	ActiveAgents int        `json:"activeAgents"`
+	EventsSent   int64      `json:"eventsSent"`
+	StartedAt    *time.Time `json:"startedAt,omitempty"`
+}
+
+// SimScaleRequest is the body of POST /api/admin/sim/start and /api/admin/sim/scale
+type SimScaleRequest struct {
+	ActiveAgents int `json:"activeAgents"`
+}
+
+// InjectCallsRequest is the body of POST /api/admin/calls/inject (vq empty = round-robin)
+type InjectCallsRequest struct {
+	Count int    `json:"count"`
+	VQ    string `json:"vq,omitempty"`
+}
+
+// InjectCallsResponse reports how many calls were enqueued
+type InjectCallsResponse struct {
+	Message  string `json:"message"`
+	Injected int    `json:"injected"`
+	Errors   int    `json:"errors"`
+}
+
+// WipeCallsResponse reports how many calls were cleared
+type WipeCallsResponse struct {
+	Message string `json:"message"`
+	Cleared int    `json:"cleared"`
+}
+
+// ResetResponse reports what a memory reset or logoff-all cleared
+type ResetResponse struct {
+	Message       string `json:"message"`
+	AgentsCleared int    `json:"agentsCleared"`
+	CallsCleared  int    `json:"callsCleared"`
+}
+
+// BreakStatus is the response of GET /api/breaks
+type BreakStatus struct {
+	Departments []BreakDepartmentStatus `json:"departments"`
+}
+
+// BreakDepartmentStatus is one department in GET /api/breaks: its breaks against the break policy
+type BreakDepartmentStatus struct {
+	Department Department          `json:"department"`
+	Agents     int                 `json:"agents"`          // connected agents the caps are computed from
+	OnBreak    int                 `json:"onBreak"`         // including granted breaks not started yet
+	Limit      int                 `json:"limit,omitempty"` // omitted when unlimited
+	Reasons    []BreakReasonStatus `json:"reasons"`
+}
+
+// BreakReasonStatus is one reason code of a department's breaks
+type BreakReasonStatus struct {
+	Reason       BreakReason `json:"reason"`
+	OnBreak      int         `json:"onBreak"`
+	Limit        int         `json:"limit,omitempty"` // omitted when the reason has no own cap
+	BreakSeconds float64     `json:"breakSeconds"`    // finished breaks of the department's agents
+}
+
+// ShadowRoutingReport compares a shadow routing strategy's choices with the active
+// strategy's on live traffic (GET /api/admin/routing/shadow)
+type ShadowRoutingReport struct {
+	Strategy                string    `json:"strategy"`                // active strategy
+	Shadow                  string    `json:"shadow"`                  // evaluated strategy
+	Since                   time.Time `json:"since"`                   // start of the evaluation
+	Decisions               int       `json:"decisions"`               // routing choices among several agents
+	Divergent               int       `json:"divergent"`               // choices where the shadow picked another agent
+	DivergenceRate          float64   `json:"divergenceRate"`          // divergent / decisions, 0-100%
+	ProjectedWaitImpactSecs float64   `json:"projectedWaitImpactSecs"` // mean projected change of the next call's wait per divergent choice; negative = shorter
+}
+
+// ShadowRoutingRequest is the body of PUT /api/admin/routing/shadow
+type ShadowRoutingRequest struct {
+	Strategy string `json:"strategy"` // strategy to evaluate; empty = stop evaluating
+}
+
+// RoutingDryRunRequest is the body of POST /api/admin/routing/dry-run: a hypothetical queue
+// state and agent availability, routed once with the live routing configuration
+type RoutingDryRunRequest struct {
+	Calls  []DryRunCall  `json:"calls"`
+	Agents []DryRunAgent `json:"agents"`
+}
+
+// DryRunCall is a waiting call of a RoutingDryRunRequest
+type DryRunCall struct {
+	CallID   string  `json:"callId"`
+	Tenant   string  `json:"tenant,omitempty"` // empty = DefaultTenant
+	VQ       VQName  `json:"vq"`
+	WaitSecs float64 `json:"waitSecs,omitempty"` // time waited so far; longer-waiting calls of a VQ go first
+}
+
+// DryRunAgent is a connected agent of a RoutingDryRunRequest
+type DryRunAgent struct {
+	AgentID       string         `json:"agentId"`
+	Tenant        string         `json:"tenant,omitempty"` // empty = DefaultTenant
+	Department    Department     `json:"department"`
+	State         AgentState     `json:"state,omitempty"`    // empty = available
+	IdleSecs      float64        `json:"idleSecs,omitempty"` // time in the current state
+	TotalCalls    int            `json:"totalCalls,omitempty"`
+	AvgHandleTime float64        `json:"avgHandleTime,omitempty"` // seconds
+	Skills        []string       `json:"skills,omitempty"`
+	Proficiency   map[string]int `json:"proficiency,omitempty"` // skill -> 1 to 5, as on the roster
+}
+
+// RoutingDryRunResponse is the result of POST /api/admin/routing/dry-run
+type RoutingDryRunResponse struct {
+	Strategy    string             `json:"strategy"` // active routing strategy
+	Assignments []DryRunAssignment `json:"assignments"`
+	Unassigned  []string           `json:"unassigned"` // calls left waiting
+}
+
+// DryRunAssignment is a call the dry run routed, in routing order
+type DryRunAssignment struct {
+	CallID             string     `json:"callId"`
+	VQ                 VQName     `json:"vq"`
+	AgentID            string     `json:"agentId"`
+	Candidates         []string   `json:"candidates"`                   // agents the strategy chose among, sorted
+	OverflowDepartment Department `json:"overflowDepartment,omitempty"` // fallback department of the agent, if the call overflowed
+}
+
+// BusinessHoursResponse is the body of GET /api/hours and /internal/hours
+type BusinessHoursResponse struct {
+	Departments []BusinessHoursStatus `json:"departments"`
+}
+
+// BusinessHoursStatus is one department of a BusinessHoursResponse
+type BusinessHoursStatus struct {
+	Department Department `json:"department"`
+	Open       bool       `json:"open"`
+	Holiday    string     `json:"holiday,omitempty"` // name (or date) of today's holiday closing the department
+}
+
+// EventLogReplayRequest is the optional body of POST /api/admin/eventlog/replay
+type EventLogReplayRequest struct {
+	Until *time.Time `json:"until,omitempty"` // replay only events up to this instant
+}
+
+// EventLogReplayStats summarizes an event log replay
+type EventLogReplayStats struct {
+	Events    int            `json:"events"`
+	ByType    map[string]int `json:"byType"`
+	Skipped   int            `json:"skipped"` // undecodable lines and unknown types
+	FirstSeq  int64          `json:"firstSeq,omitempty"`
+	LastSeq   int64          `json:"lastSeq,omitempty"`
+	LastEvent *time.Time     `json:"lastEvent,omitempty"`
+}
+
+// EventLogReplayResponse reports what a replay cleared and re-applied
+type EventLogReplayResponse struct {
+	ResetResponse
+	EventLogReplayStats
+}
+
+// BackfillRequest is the body of POST /api/admin/backfill/daily-stats
+type BackfillRequest struct {
+	From string `json:"from"` // first day, YYYY-MM-DD
+	To   string `json:"to"`   // last day (inclusive), YYYY-MM-DD
+}
+
+// BackfillResponse reports what a backfill recomputed
+type BackfillResponse struct {
+	Days        int `json:"days"`
+	CallRecords int `json:"callRecords"` // call records read
+	AgentDays   int `json:"agentDays"`   // agent daily stats written
+	VQDays      int `json:"vqDays"`      // VQ daily stats written
+}
+
+// LogLevel is the body of GET/PUT /api/admin/loglevel (previous is set by PUT responses)
+type LogLevel struct {
+	Level    string `json:"level"`
+	Previous string `json:"previous,omitempty"`
+}
+
+// LeaderboardUpdate is the body of PUT /api/admin/leaderboards; omitted fields keep their value
+type LeaderboardUpdate struct {
+	Enabled *bool `json:"enabled,omitempty"`
+	Size    *int  `json:"size,omitempty"`
+}
+
+// ScheduleImportResponse reports how many intervals and agent-days were replaced
+type ScheduleImportResponse struct {
+	Intervals int `json:"intervals"`
+	AgentDays int `json:"agentDays"`
+}
+
+// CalendarImportResponse returns the imported calendar events with their IDs
+type CalendarImportResponse struct {
+	Events []CalendarEvent `json:"events"`
+}
+
+// RosterImportResponse reports the outcome of a roster CSV import
+type RosterImportResponse struct {
+	Imported int `json:"imported"`
+	Added    int `json:"added"`
+	Updated  int `json:"updated"`
+}
+
+// WSClientsResponse is the body of GET /api/admin/ws/clients
+type WSClientsResponse struct {
+	LatestSeq      int64          `json:"latestSeq"`      // sequence number of the latest snapshot broadcast
+	StalledClients int            `json:"stalledClients"` // clients with Stalled set
+	Clients        []WSClientInfo `json:"clients"`        // oldest connection first
+}
+
+// WSClientInfo describes one connected dashboard client
+type WSClientInfo struct {
+	ID               string     `json:"id"`
+	User             string     `json:"user"` // token subject, else email
+	Role             string     `json:"role,omitempty"`
+	AllowedLocations []Location `json:"allowedLocations,omitempty"`
+	ConnectedAt      time.Time  `json:"connectedAt"`
+	DroppedMessages  int64      `json:"droppedMessages"` // messages skipped because the client's send buffer was full
+	LastAckSeq       int64      `json:"lastAckSeq"`      // last snapshot the client acknowledged; 0 = none
+	Lag              int64      `json:"lag"`             // snapshots broadcast since LastAckSeq; 0 for clients that never acknowledged
+	SchemaVersion    int        `json:"schemaVersion"`   // snapshot schema version the client receives
+
+	// Delivery tracking, for clients that acknowledge snapshots
+	SnapshotsSent  int64      `json:"snapshotsSent"`           // snapshots queued for the client
+	SnapshotsAcked int64      `json:"snapshotsAcked"`          // snapshots the client acknowledged
+	DeliveryRatio  float64    `json:"deliveryRatio,omitempty"` // SnapshotsAcked / SnapshotsSent; absent before the first ack
+	AckLatencyMs   float64    `json:"ackLatencyMs,omitempty"`  // average time from broadcast to acknowledgement
+	LastAckAt      *time.Time `json:"lastAckAt,omitempty"`
+	Stalled        bool       `json:"stalled,omitempty"` // acknowledged before, but not for WS_ACK_STALL_AFTER while snapshots kept coming
+}
+
+// Control message actions
+const (
+	ControlReload = "reload" // the dashboard reloads, e.g. after a config change
+	ControlNotice = "notice" // the dashboard shows Message, e.g. "maintenance starting"
+)
+
+// ControlMessage is pushed to frontend clients outside the regular snapshot
+type ControlMessage struct {
+	Type      string    `json:"type"` // always "control"
+	Action    string    `json:"action"`
+	Message   string    `json:"message,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ControlMessageRequest is the body of POST /api/admin/ws/control. Clients matching any of
+// ClientIDs or Roles receive the message; without either, every client does.
+type ControlMessageRequest struct {
+	Action    string   `json:"action"`            // "reload" or "notice"
+	Message   string   `json:"message,omitempty"` // required for notices
+	ClientIDs []string `json:"clientIds,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+}
+
+// ControlMessageResponse reports how many clients a control message was queued for
+type ControlMessageResponse struct {
+	Delivered int `json:"delivered"`
+	Dropped   int `json:"dropped"` // matching clients whose send buffer was full
+}
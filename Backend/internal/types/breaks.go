@@ -0,0 +1,52 @@
+package types
+
+// BreakReason is the reason code an agent gives for a break. Deployments may use their own
+// codes; the built-in ones are what AgentSim sends.
+type BreakReason string
+
+const (
+	BreakRest      BreakReason = "rest"
+	BreakPersonal  BreakReason = "personal"
+	BreakWellness  BreakReason = "wellness"
+	BreakTechnical BreakReason = "technical"
+
+	// DefaultBreakReason is assumed for breaks that carry no reason
+	DefaultBreakReason = BreakRest
+)
+
+// Valid reports whether r is a usable reason code: 1-32 lowercase letters, digits or underscores
+func (r BreakReason) Valid() bool {
+	if len(r) == 0 || len(r) > 32 {
+		return false
+	}
+	for _, c := range r {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// OrDefault returns r, or DefaultBreakReason when r is empty
+func (r BreakReason) OrDefault() BreakReason {
+	if r == "" {
+		return DefaultBreakReason
+	}
+	return r
+}
+
+// BreakRequest is sent from agent to backend before going on break
+type BreakRequest struct {
+	Type    string      `json:"type"` // "break_request"
+	AgentID string      `json:"agentId"`
+	Reason  BreakReason `json:"reason"`
+}
+
+// BreakResponse answers a break_request; the agent only goes on break when Granted
+type BreakResponse struct {
+	Type    string      `json:"type"` // "break_response"
+	AgentID string      `json:"agentId"`
+	Reason  BreakReason `json:"reason"`
+	Granted bool        `json:"granted"`
+	Message string      `json:"message,omitempty"` // why the break was refused
+}
@@ -15,6 +15,7 @@ const (
 // Call represents an active or queued call in the system
 type Call struct {
 	CallID      string     `json:"callId"`
+	Tenant      string     `json:"tenant,omitempty"` // empty = DefaultTenant
 	VQ          VQName     `json:"vq"`
 	Department  Department `json:"department"`
 	Status      CallStatus `json:"status"`
@@ -23,9 +24,13 @@ type Call struct {
 	CompleteTime *time.Time `json:"completeTime,omitempty"`
 	AgentID     string     `json:"agentId,omitempty"`
 	TalkTime    float64    `json:"talkTime,omitempty"`    // seconds
-	HoldTime    float64    `json:"holdTime,omitempty"`    // seconds
+	HoldTime    float64    `json:"holdTime,omitempty"`    // seconds; while active, of the holds ended so far
+	HoldStart   *time.Time `json:"holdStart,omitempty"`   // when the current hold started; nil unless on hold
+	Holds       int        `json:"holds,omitempty"`       // call_hold_start events while active
 	WrapTime    float64    `json:"wrapTime,omitempty"`    // seconds
 	WaitTime    float64    `json:"waitTime,omitempty"`    // seconds in queue
+	OverflowDepartment Department `json:"overflowDepartment,omitempty"` // fallback department that answered the call
+	Requeues    int        `json:"requeues,omitempty"` // times taken back from a disconnected agent
 }
 
 // ServiceLevel tracks SL metrics for a VQ
@@ -40,14 +45,28 @@ type ServiceLevel struct {
 // VQSnapshot represents the current state of a virtual queue
 type VQSnapshot struct {
 	VQ              VQName     `json:"vq"`
+	Tenant          string     `json:"tenant,omitempty"` // empty = DefaultTenant
 	Department      Department `json:"department"`
+	Channel         Channel    `json:"channel"`
 	WaitingCount    int        `json:"waitingCount"`
 	ActiveCount     int        `json:"activeCount"`
+	OnHoldCount     int        `json:"onHoldCount"`                // active calls whose caller is on hold
 	CompletedCount  int        `json:"completedCount"`
 	AbandonedCount  int        `json:"abandonedCount"`
 	LongestWaitSecs float64    `json:"longestWaitSecs"`
 	AvailableAgents int        `json:"availableAgents"`
 	ServiceLevel    ServiceLevel `json:"serviceLevel"`
+	Closed          bool       `json:"closed,omitempty"` // outside business hours: answers don't count toward SL
+	Paused          bool       `json:"paused,omitempty"` // routing suspended by an admin
+	Reserve         *ReserveStatus `json:"reserve,omitempty"` // agents held for this VQ; nil without a reserve
+	ResetAt         time.Time  `json:"resetAt"`                   // since when the completed, abandoned and SL counts run
+}
+
+// ReserveStatus is how much of the idle agents reserved for a VQ are standing by
+type ReserveStatus struct {
+	Reserved    int     `json:"reserved"`    // idle agents held for the VQ
+	Idle        int     `json:"idle"`        // idle agents currently covering the reserve
+	Utilization float64 `json:"utilization"` // share of the reserve not standing by, 0-100%
 }
 
 // VQWidget contains all VQ snapshots for a department
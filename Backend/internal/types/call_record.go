@@ -1,9 +1,12 @@
 package types
 
+import "time"
+
 // CallRecord represents a completed call for DynamoDB persistence
 type CallRecord struct {
-	DateKey      string  `json:"dateKey" dynamodbav:"DateKey"`           // YYYY-MM-DD (partition key)
+	DateKey      string  `json:"dateKey" dynamodbav:"DateKey"`           // YYYY-MM-DD, tenant-prefixed by TenantKey (partition key)
 	CallID       string  `json:"callId" dynamodbav:"CallID"`            // sort key
+	Tenant       string  `json:"tenant,omitempty" dynamodbav:"Tenant,omitempty"` // empty = DefaultTenant
 	VQ           VQName  `json:"vq" dynamodbav:"VQ"`
 	Department   string  `json:"department" dynamodbav:"Department"`
 	AgentID      string  `json:"agentId" dynamodbav:"AgentID"`
@@ -17,6 +20,7 @@ type CallRecord struct {
 	HandleTime   float64 `json:"handleTime" dynamodbav:"HandleTime"`    // talk + hold + wrap
 	Abandoned    bool    `json:"abandoned" dynamodbav:"Abandoned"`
 	AnsweredInSL bool    `json:"answeredInSL" dynamodbav:"AnsweredInSL"`
+	OverflowDepartment string `json:"overflowDepartment,omitempty" dynamodbav:"OverflowDepartment,omitempty"` // fallback department that answered the call
 }
 
 // AgentDailyStats represents an agent's daily aggregated stats for DynamoDB
@@ -33,3 +37,19 @@ type AgentDailyStats struct {
 	Occupancy     float64 `json:"occupancy" dynamodbav:"Occupancy"`         // 0-100%
 	LoginDuration float64 `json:"loginDuration" dynamodbav:"LoginDuration"` // seconds
 }
+
+// VQDailyStats is one tenant's totals of a VQ for a business day, saved when the live
+// counters roll over
+type VQDailyStats struct {
+	DateKey       string     `json:"dateKey" dynamodbav:"DateKey"`                   // tenant-prefixed YYYY-MM-DD (partition key)
+	VQ            VQName     `json:"vq" dynamodbav:"VQ"`                             // sort key
+	Tenant        string     `json:"tenant,omitempty" dynamodbav:"Tenant,omitempty"` // empty = DefaultTenant
+	Department    Department `json:"department" dynamodbav:"Department"`
+	Completed     int        `json:"completed" dynamodbav:"Completed"`
+	Abandoned     int        `json:"abandoned" dynamodbav:"Abandoned"`
+	AnsweredInSL  int        `json:"answeredInSL" dynamodbav:"AnsweredInSL"`
+	TotalAnswered int        `json:"totalAnswered" dynamodbav:"TotalAnswered"`
+	ServiceLevel  float64    `json:"serviceLevel" dynamodbav:"ServiceLevel"` // 0-100%
+	From          time.Time  `json:"from" dynamodbav:"From"`                 // last reset of the counters
+	To            time.Time  `json:"to" dynamodbav:"To"`                     // rollover that saved them
+}
@@ -0,0 +1,59 @@
+package types
+
+import "strings"
+
+// Channel is the media type of the contacts a VQ routes
+type Channel string
+
+const (
+	ChannelVoice Channel = "voice"
+	ChannelChat  Channel = "chat"
+	ChannelEmail Channel = "email"
+)
+
+// AllChannels lists every channel
+var AllChannels = []Channel{ChannelVoice, ChannelChat, ChannelEmail}
+
+// VQChannels maps VQs to their channel where it is set explicitly (replaced by SetTaxonomy)
+var VQChannels = map[VQName]Channel{}
+
+// Valid reports whether c is a known channel
+func (c Channel) Valid() bool {
+	for _, known := range AllChannels {
+		if c == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Concurrent reports whether an agent may handle several contacts of the channel at once
+func (c Channel) Concurrent() bool {
+	return c == ChannelChat
+}
+
+// ChannelOf returns the channel of a VQ: the taxonomy's setting, else "chat" or "email" for
+// VQs named "*_chat" or "*_email", else voice
+func ChannelOf(vq VQName) Channel {
+	if c, ok := VQChannels[vq]; ok {
+		return c
+	}
+	switch {
+	case strings.HasSuffix(string(vq), "_chat"):
+		return ChannelChat
+	case strings.HasSuffix(string(vq), "_email"):
+		return ChannelEmail
+	}
+	return ChannelVoice
+}
+
+// ChannelOccupancy is how busy a department's agents are on one channel. Every active contact
+// takes a slot; free slots are available agents for voice and email, and the sessions left
+// below the concurrency limit of available and multi-session agents for chat.
+type ChannelOccupancy struct {
+	Channel   Channel `json:"channel"`
+	Tenant    string  `json:"tenant,omitempty"` // empty = DefaultTenant
+	Active    int     `json:"active"`           // contacts being handled
+	Capacity  int     `json:"capacity"`         // active contacts plus free slots
+	Occupancy float64 `json:"occupancy"`        // active / capacity in percent; 0 without capacity
+}
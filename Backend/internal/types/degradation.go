@@ -0,0 +1,49 @@
+package types
+
+import "time"
+
+// DegradationLevel is how far the backend has reduced snapshot fidelity to keep up under overload
+type DegradationLevel string
+
+const (
+	DegradationNormal  DegradationLevel = ""        // full snapshots every aggregation interval
+	DegradationReduced DegradationLevel = "reduced" // every 2nd interval, without event timings
+	DegradationSummary DegradationLevel = "summary" // every 4th interval, departments as summaries without agents
+)
+
+// Degradation tells dashboards that the snapshot they received has reduced fidelity
+type Degradation struct {
+	Level           DegradationLevel `json:"level"`
+	Since           time.Time        `json:"since"`
+	Reason          string           `json:"reason"`          // "aggregation" or "broadcast": the latency that crossed its threshold
+	IntervalSeconds float64          `json:"intervalSeconds"` // time between snapshots at this level
+}
+
+// SummaryOnly returns the snapshot as sent while degraded to summary-only: a copy whose
+// departments carry a summary of their agents instead of the agents and leaderboards. Other
+// snapshots are returned as they are.
+func (s *Snapshot) SummaryOnly() *Snapshot {
+	if s.Degradation == nil || s.Degradation.Level != DegradationSummary {
+		return s
+	}
+	out := *s
+	out.Departments = make(map[Department]*DepartmentData, len(s.Departments))
+	for dept, data := range s.Departments {
+		summary := &WidgetSummary{
+			TotalAgents:       len(data.Agents),
+			StateBreakdown:    make(map[AgentState]int),
+			LocationBreakdown: make(map[Location]int),
+		}
+		for _, agent := range data.Agents {
+			summary.StateBreakdown[agent.State]++
+			summary.LocationBreakdown[agent.Location]++
+		}
+		out.Departments[dept] = &DepartmentData{
+			Agents:   []AgentInfo{},
+			Queues:   data.Queues,
+			Channels: data.Channels,
+			Summary:  summary,
+		}
+	}
+	return &out
+}
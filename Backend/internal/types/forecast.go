@@ -0,0 +1,33 @@
+package types
+
+import "time"
+
+// IntervalStat summarizes a VQ's traffic over one interval
+type IntervalStat struct {
+	VQ        VQName    `json:"vq"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Offered   int       `json:"offered"`   // calls enqueued
+	Handled   int       `json:"handled"`   // calls completed
+	Abandoned int       `json:"abandoned"` // calls abandoned while waiting
+	AHTSecs   float64   `json:"ahtSecs"`   // average handle time of handled calls
+}
+
+// StaffingForecast is the Erlang C staffing requirement for a VQ's next interval
+type StaffingForecast struct {
+	VQ              VQName         `json:"vq"`
+	Department      Department     `json:"department"`
+	IntervalStart   time.Time      `json:"intervalStart"`
+	IntervalEnd     time.Time      `json:"intervalEnd"`
+	ForecastCalls   float64        `json:"forecastCalls"`  // average offered calls of the recent intervals
+	AHTSecs         float64        `json:"ahtSecs"`        // average handle time of the recent intervals
+	TrafficErlangs  float64        `json:"trafficErlangs"` // offered load
+	TargetSL        int            `json:"targetSL"`       // percentage
+	ThresholdSecs   int            `json:"thresholdSecs"`
+	RequiredAgents  int            `json:"requiredAgents"`
+	ExpectedSL      float64        `json:"expectedSL"`      // 0-100% with RequiredAgents
+	ExpectedASA     float64        `json:"expectedASA"`     // seconds with RequiredAgents
+	Occupancy       float64        `json:"occupancy"`       // 0-100% with RequiredAgents
+	ScheduledAgents int            `json:"scheduledAgents"` // agents of the department scheduled to work (shared by its VQs)
+	History         []IntervalStat `json:"history"`         // recent intervals the forecast is based on
+}
@@ -0,0 +1,68 @@
+package types
+
+// Types of the Grafana JSON datasource protocol served under /api/grafana
+
+// GrafanaRange is the dashboard time range of a query or annotation request (RFC3339)
+type GrafanaRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// GrafanaSearchRequest is the body of POST /api/grafana/search
+type GrafanaSearchRequest struct {
+	Target string `json:"target"` // only metrics containing this text; empty = all
+}
+
+// GrafanaTarget is one query of a panel
+type GrafanaTarget struct {
+	RefID  string `json:"refId"`
+	Target string `json:"target"`
+	Type   string `json:"type,omitempty"` // timeserie (default) or table
+	Hide   bool   `json:"hide,omitempty"`
+}
+
+// GrafanaQueryRequest is the body of POST /api/grafana/query
+type GrafanaQueryRequest struct {
+	Range         GrafanaRange    `json:"range"`
+	IntervalMs    int64           `json:"intervalMs"`
+	MaxDataPoints int             `json:"maxDataPoints"`
+	Targets       []GrafanaTarget `json:"targets"`
+}
+
+// GrafanaQueryResult is one target's result: a time series of [value, unix ms] points, or a
+// table with type "table"
+type GrafanaQueryResult struct {
+	Target     string          `json:"target,omitempty"`
+	Datapoints [][2]float64    `json:"datapoints"`
+	Type       string          `json:"type,omitempty"`
+	Columns    []GrafanaColumn `json:"columns,omitempty"`
+	Rows       [][]interface{} `json:"rows,omitempty"`
+}
+
+// GrafanaColumn is a column of a table result
+type GrafanaColumn struct {
+	Text string `json:"text"`
+	Type string `json:"type"` // time, string or number
+}
+
+// GrafanaAnnotation is the annotation a GrafanaAnnotationRequest is made for
+type GrafanaAnnotation struct {
+	Name  string `json:"name"`
+	Query string `json:"query,omitempty"` // only alerts of this rule or severity; empty = all
+}
+
+// GrafanaAnnotationRequest is the body of POST /api/grafana/annotations
+type GrafanaAnnotationRequest struct {
+	Range      GrafanaRange      `json:"range"`
+	Annotation GrafanaAnnotation `json:"annotation"`
+}
+
+// GrafanaAnnotationEvent is one alert shown as an annotation
+type GrafanaAnnotationEvent struct {
+	Annotation GrafanaAnnotation `json:"annotation"`
+	Time       int64             `json:"time"`              // fired, unix ms
+	TimeEnd    int64             `json:"timeEnd,omitempty"` // resolved, unix ms
+	Title      string            `json:"title"`
+	Text       string            `json:"text"`
+	Tags       []string          `json:"tags"`
+}
@@ -0,0 +1,34 @@
+package types
+
+// ObservedKPIs are computed by the backend from an agent's state changes and call_complete
+// events, independent of the KPIs the agent reports
+type ObservedKPIs struct {
+	Session KPIWindow `json:"session"` // since the agent logged on
+	Rolling KPIWindow `json:"rolling"` // last hour, at minute resolution
+	Daily   KPIWindow `json:"daily"`   // since midnight in the timezone of the agent's location
+
+	// Reported KPIs that disagree with Session: "calls", "aht" or "occupancy"
+	Divergent []string `json:"divergent,omitempty"`
+
+	// Handle time percentiles over the most recent calls (KPI_HISTORY_SIZE), nil before the first
+	RecentAHT *AHTPercentiles `json:"recentAht,omitempty"`
+
+	// The handle times RecentAHT covers, sorted, for pooling into team percentiles.
+	// Shared between copies, never mutated.
+	HandleTimes []float64 `json:"-"`
+}
+
+// KPIWindow holds the observed KPIs of one time window
+type KPIWindow struct {
+	CallsHandled  int     `json:"callsHandled"`  // call_complete events
+	AvgHandleTime float64 `json:"avgHandleTime"` // seconds: (talk + hold + ACW) / calls
+	Occupancy     float64 `json:"occupancy"`     // handling / (handling + available), 0-100%
+}
+
+// AHTPercentiles are handle time percentiles over a set of recent calls, in seconds. They
+// show the long calls an average hides.
+type AHTPercentiles struct {
+	Calls int     `json:"calls"` // calls covered
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+}
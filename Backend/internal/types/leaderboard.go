@@ -0,0 +1,41 @@
+package types
+
+// LeaderboardMetric identifies the KPI an agent leaderboard is ranked by
+type LeaderboardMetric string
+
+const (
+	LeaderboardCallsHandled LeaderboardMetric = "totalCalls"
+	LeaderboardAHT          LeaderboardMetric = "avgHandleTime"
+	LeaderboardOccupancy    LeaderboardMetric = "occupancy"
+	LeaderboardSatisfaction LeaderboardMetric = "customerSatisfaction"
+)
+
+// AllLeaderboardMetrics lists the metrics leaderboards are computed for
+var AllLeaderboardMetrics = []LeaderboardMetric{
+	LeaderboardCallsHandled,
+	LeaderboardAHT,
+	LeaderboardOccupancy,
+	LeaderboardSatisfaction,
+}
+
+// LeaderboardEntry is a single ranked agent
+type LeaderboardEntry struct {
+	AgentID  string   `json:"agentId"`
+	Team     string   `json:"team"`
+	Location Location `json:"location"`
+	Value    float64  `json:"value"`
+}
+
+// Leaderboard holds the best (Top) and worst (Bottom) agents for one metric.
+// For AHT lower is better, so Top holds the lowest handle times.
+type Leaderboard struct {
+	Metric LeaderboardMetric  `json:"metric"`
+	Top    []LeaderboardEntry `json:"top"`
+	Bottom []LeaderboardEntry `json:"bottom"`
+}
+
+// LeaderboardSettings controls leaderboard computation in the aggregator
+type LeaderboardSettings struct {
+	Enabled bool `json:"enabled"`
+	Size    int  `json:"size"` // N for top-N / bottom-N
+}
@@ -9,6 +9,7 @@ type CallAssign struct {
 	CallID   string    `json:"callId"`
 	VQ       VQName    `json:"vq"`
 	Timestamp time.Time `json:"timestamp"`
+	TraceParent string `json:"traceparent,omitempty"` // W3C trace context of the routing span
 }
 
 // CallComplete is sent from agent to backend when a call is finished
@@ -19,6 +20,27 @@ type CallComplete struct {
 	TalkTime  float64   `json:"talkTime"`  // seconds
 	HoldTime  float64   `json:"holdTime"`  // seconds
 	Timestamp time.Time `json:"timestamp"`
+	TraceParent string  `json:"traceparent,omitempty"` // W3C trace context, continuing the call_assign trace
+	Seq       uint64    `json:"seq,omitempty"` // per-agent message number; 0 = unnumbered
+
+	Generation uint64 `json:"-"` // connection generation, set by the AgentHub; 0 = untracked
+}
+
+// CallHold is sent from agent to backend when the agent puts the caller of an active call on
+// hold ("call_hold_start") or picks the call up again ("call_hold_end")
+type CallHold struct {
+	Type      string    `json:"type"` // "call_hold_start" or "call_hold_end"
+	AgentID   string    `json:"agentId"`
+	CallID    string    `json:"callId"`
+	Timestamp time.Time `json:"timestamp"`
+	Seq       uint64    `json:"seq,omitempty"` // per-agent message number; 0 = unnumbered
+
+	Generation uint64 `json:"-"` // connection generation, set by the AgentHub; 0 = untracked
+}
+
+// OnHold reports whether the message starts a hold
+func (h *CallHold) OnHold() bool {
+	return h.Type == "call_hold_start"
 }
 
 // ForceEndCall is sent from backend to agent to end an active call
@@ -34,6 +56,28 @@ type ForceDisconnect struct {
 	AgentID string `json:"agentId"`
 }
 
+// ConnectionSuperseded is sent from backend to agent when a newer connection of the same
+// agent has taken over; the receiving connection's messages are ignored from then on
+type ConnectionSuperseded struct {
+	Type       string `json:"type"` // "superseded"
+	AgentID    string `json:"agentId"`
+	Generation uint64 `json:"generation"` // generation of the connection that took over
+}
+
+// Throttle is sent from backend to agent connections while the AgentHub sheds heartbeats,
+// asking them to send heartbeats less often. It applies to every agent on the connection.
+type Throttle struct {
+	Type              string `json:"type"`              // "throttle"
+	HeartbeatInterval int    `json:"heartbeatInterval"` // seconds; 0 = back to the sender's default
+}
+
+// ForceState is sent from backend to agent to move it into another state
+type ForceState struct {
+	Type    string     `json:"type"`    // "force_state"
+	AgentID string     `json:"agentId"`
+	State   AgentState `json:"state"`
+}
+
 // IncomingCall represents a new call entering the system
 type IncomingCall struct {
 	Type       string     `json:"type"` // "incoming_call"
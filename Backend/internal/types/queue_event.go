@@ -0,0 +1,69 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// QueueEventType is an operational change of a VQ
+type QueueEventType string
+
+const (
+	QueueEventPaused          QueueEventType = "paused"  // routing of the VQ suspended by an admin
+	QueueEventResumed         QueueEventType = "resumed" // routing of the VQ resumed by an admin
+	QueueEventSLTargetChanged QueueEventType = "sl_target_changed"
+	QueueEventOverflowStarted QueueEventType = "overflow_started" // the first call went to the fallback department
+	QueueEventOverflowEnded   QueueEventType = "overflow_ended"   // no call overflowed for a minute
+)
+
+// QueueEvent is an operational change of a tenant's VQ, saved so SL anomalies in reports can
+// be explained by the configuration and routing changes behind them
+type QueueEvent struct {
+	DateKey  string         `json:"dateKey" dynamodbav:"DateKey"` // tenant-prefixed business day YYYY-MM-DD (partition key)
+	EventKey string         `json:"-" dynamodbav:"EventKey"`      // sort key, see QueueEventKey
+	VQ       VQName         `json:"vq" dynamodbav:"VQ"`
+	Tenant   string         `json:"tenant,omitempty" dynamodbav:"Tenant,omitempty"` // empty = DefaultTenant
+	Type     QueueEventType `json:"type" dynamodbav:"Type"`
+	At       time.Time      `json:"at" dynamodbav:"At"`
+	Actor    string         `json:"actor,omitempty" dynamodbav:"Actor,omitempty"` // admin who made the change; empty = the backend
+
+	// sl_target_changed: the SL target before and after
+	SLTarget            int `json:"slTarget,omitempty" dynamodbav:"SLTarget,omitempty"`
+	SLThresholdSecs     int `json:"slThresholdSecs,omitempty" dynamodbav:"SLThresholdSecs,omitempty"`
+	PrevSLTarget        int `json:"prevSlTarget,omitempty" dynamodbav:"PrevSLTarget,omitempty"`
+	PrevSLThresholdSecs int `json:"prevSlThresholdSecs,omitempty" dynamodbav:"PrevSLThresholdSecs,omitempty"`
+
+	// overflow_started/overflow_ended: where calls overflowed to, and how many (ended only)
+	FallbackDepartment Department `json:"fallbackDepartment,omitempty" dynamodbav:"FallbackDepartment,omitempty"`
+	Overflowed         int        `json:"overflowed,omitempty" dynamodbav:"Overflowed,omitempty"`
+}
+
+// QueueEventKey is the sort key of a VQ's event at: the VQ first, so one VQ's events of a day
+// are queried by prefix, then the time for chronological order
+func QueueEventKey(vq VQName, at time.Time) string {
+	return fmt.Sprintf("%s#%s", vq, at.UTC().Format("2006-01-02T15:04:05.000000000Z"))
+}
+
+// SLTargetRequest is the body of PUT /api/admin/queues/{vq}/sl
+type SLTargetRequest struct {
+	Target        int `json:"target"`        // percentage, 1-100
+	ThresholdSecs int `json:"thresholdSecs"` // answered within, > 0
+}
+
+// Validate checks the target and threshold
+func (r SLTargetRequest) Validate() []FieldError {
+	var errs []FieldError
+	if r.Target < 1 || r.Target > 100 {
+		errs = append(errs, FieldError{Field: "target", Message: "must be between 1 and 100"})
+	}
+	if r.ThresholdSecs <= 0 {
+		errs = append(errs, FieldError{Field: "thresholdSecs", Message: "must be positive"})
+	}
+	return errs
+}
+
+// QueuePauseResponse is the answer of POST /api/admin/queues/{vq}/pause and /resume
+type QueuePauseResponse struct {
+	VQ     VQName `json:"vq"`
+	Paused bool   `json:"paused"`
+}
@@ -0,0 +1,73 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// DateLayout is the format of date keys and date parameters
+const DateLayout = "2006-01-02"
+
+// ReportingLocation is the timezone days are cut in: the date keys of call records and alerts,
+// schedule days and the dates of reports. Defaults to UTC.
+var ReportingLocation = time.UTC
+
+// LocationTimezones are the timezones of the locations that set one in the taxonomy
+var LocationTimezones = map[Location]*time.Location{}
+
+// SetReportingTimezone sets ReportingLocation from an IANA name (call at startup, before
+// anything reads it)
+func SetReportingTimezone(name string) error {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("invalid timezone %q: %w", name, err)
+	}
+	ReportingLocation = loc
+	return nil
+}
+
+// DateOf returns the reporting day of t as YYYY-MM-DD
+func DateOf(t time.Time) string {
+	return t.In(ReportingLocation).Format(DateLayout)
+}
+
+// DayBounds returns the start and end of a reporting day (YYYY-MM-DD). Days around a DST
+// switch are 23 or 25 hours long.
+func DayBounds(date string) (start, end time.Time, err error) {
+	start, err = time.ParseInLocation(DateLayout, date, ReportingLocation)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return start, start.AddDate(0, 0, 1), nil
+}
+
+// BusinessDay returns the business day t falls in (the date it starts on) and when it ends.
+// Business days start at offset past midnight in ReportingLocation, as wall clock time, so a
+// 06:00 start stays at 06:00 across DST switches.
+func BusinessDay(t time.Time, offset time.Duration) (date string, end time.Time) {
+	secs := int(offset / time.Second)
+	y, mo, d := t.In(ReportingLocation).Date()
+	if t.Before(time.Date(y, mo, d, 0, 0, secs, 0, ReportingLocation)) {
+		y, mo, d = time.Date(y, mo, d-1, 0, 0, 0, 0, ReportingLocation).Date()
+	}
+	return time.Date(y, mo, d, 0, 0, 0, 0, ReportingLocation).Format(DateLayout), time.Date(y, mo, d+1, 0, 0, secs, 0, ReportingLocation)
+}
+
+// BusinessDayBounds returns the start and end of the business day starting on date
+func BusinessDayBounds(date string, offset time.Duration) (start, end time.Time, err error) {
+	day, err := time.ParseInLocation(DateLayout, date, ReportingLocation)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	secs := int(offset / time.Second)
+	y, mo, d := day.Date()
+	return time.Date(y, mo, d, 0, 0, secs, 0, ReportingLocation), time.Date(y, mo, d+1, 0, 0, secs, 0, ReportingLocation), nil
+}
+
+// LocationTimezone returns the timezone of a location, ReportingLocation when it sets none
+func LocationTimezone(loc Location) *time.Location {
+	if tz, ok := LocationTimezones[loc]; ok {
+		return tz
+	}
+	return ReportingLocation
+}
@@ -0,0 +1,42 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReportingDays(t *testing.T) {
+	defer func(loc *time.Location) { ReportingLocation = loc }(ReportingLocation)
+	if err := SetReportingTimezone("America/New_York"); err != nil {
+		t.Skip("no tzdata:", err)
+	}
+
+	// 02:00 UTC is still the previous evening in New York
+	if got := DateOf(time.Date(2026, 3, 3, 2, 0, 0, 0, time.UTC)); got != "2026-03-02" {
+		t.Errorf("DateOf = %s, want 2026-03-02", got)
+	}
+
+	// The day clocks go forward has 23 hours
+	start, end, err := DayBounds("2026-03-08")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !start.Equal(time.Date(2026, 3, 8, 5, 0, 0, 0, time.UTC)) || end.Sub(start) != 23*time.Hour {
+		t.Errorf("DayBounds = %v - %v", start.UTC(), end.UTC())
+	}
+
+	// 05:00 EDT still belongs to the business day that started at 06:00 EST the day before,
+	// which ends at 06:00 EDT after only 23 hours
+	date, dayEnd := BusinessDay(time.Date(2026, 3, 8, 9, 0, 0, 0, time.UTC), 6*time.Hour)
+	if date != "2026-03-07" || !dayEnd.Equal(time.Date(2026, 3, 8, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("BusinessDay = %s ending %v", date, dayEnd.UTC())
+	}
+	start, end, err = BusinessDayBounds("2026-03-07", 6*time.Hour)
+	if err != nil || !start.Equal(time.Date(2026, 3, 7, 11, 0, 0, 0, time.UTC)) || end.Sub(start) != 23*time.Hour {
+		t.Errorf("BusinessDayBounds = %v - %v (%v)", start.UTC(), end.UTC(), err)
+	}
+
+	if err := SetReportingTimezone("Mars/Olympus"); err == nil {
+		t.Error("expected an unknown timezone to be rejected")
+	}
+}
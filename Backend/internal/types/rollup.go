@@ -0,0 +1,32 @@
+package types
+
+// RollupDimension identifies how agents are grouped for snapshot rollups
+type RollupDimension string
+
+const (
+	RollupTeam       RollupDimension = "team"
+	RollupLocation   RollupDimension = "location"
+	RollupDepartment RollupDimension = "department"
+)
+
+// AllRollupDimensions lists the supported rollup dimensions in output order
+var AllRollupDimensions = []RollupDimension{
+	RollupDepartment,
+	RollupLocation,
+	RollupTeam,
+}
+
+// Rollup holds aggregated stats for one group of agents (e.g. a single team)
+type Rollup struct {
+	Dimension      RollupDimension    `json:"dimension"`
+	Key            string             `json:"key"`                   // team name, location, or department
+	Supervisor     string             `json:"supervisor,omitempty"`  // team rollups: supervisor from the team directory
+	Departments    []Department       `json:"departments,omitempty"` // departments the group's agents belong to
+	TotalAgents    int                `json:"totalAgents"`
+	LoggedIn       int                `json:"loggedIn"` // agents not offline
+	StateBreakdown map[AgentState]int `json:"stateBreakdown"`
+	Occupancy      float64            `json:"occupancy"`           // avg occupancy of logged-in agents, 0-100%
+	WaitingCalls   int                `json:"waitingCalls"`        // waiting calls in the mapped queues
+	ServiceLevel   float64            `json:"serviceLevel"`        // combined SL of the mapped queues, 0-100%
+	RecentAHT      *AHTPercentiles    `json:"recentAht,omitempty"` // team rollups: over the agents' recent calls pooled
+}
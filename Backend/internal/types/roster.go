@@ -0,0 +1,59 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// RosterRecord is a managed roster entry (e.g. from an HR feed), persisted to DynamoDB
+type RosterRecord struct {
+	AgentID    string     `json:"agentId" dynamodbav:"AgentID"` // partition key
+	Department Department `json:"department" dynamodbav:"Department"`
+	Location   Location   `json:"location" dynamodbav:"Location"`
+	Team       string     `json:"team" dynamodbav:"Team"`
+	Skills     []string   `json:"skills,omitempty" dynamodbav:"Skills,omitempty"`
+	// Proficiency rates some of the skills from MinProficiency to MaxProficiency
+	Proficiency map[string]int `json:"proficiency,omitempty" dynamodbav:"Proficiency,omitempty"`
+	UpdatedAt   time.Time      `json:"updatedAt" dynamodbav:"UpdatedAt"`
+}
+
+// Proficiency bounds of a skill; unrated skills count as DefaultProficiency
+const (
+	MinProficiency     = 1
+	MaxProficiency     = 5
+	DefaultProficiency = 3
+)
+
+// Validate checks the agent fields, that skills are non-empty and unique, and that each rated
+// skill is one of the agent's skills with a proficiency in range
+func (r RosterRecord) Validate() []FieldError {
+	errs := ValidateRosterEntry("", r.AgentID, r.Department, r.Location)
+	seen := make(map[string]bool, len(r.Skills))
+	for i, skill := range r.Skills {
+		field := fmt.Sprintf("skills[%d]", i)
+		switch {
+		case skill == "":
+			errs = append(errs, FieldError{Field: field, Message: "required"})
+		case seen[skill]:
+			errs = append(errs, FieldError{Field: field, Message: fmt.Sprintf("duplicate skill %q", skill)})
+		}
+		seen[skill] = true
+	}
+	rated := make([]string, 0, len(r.Proficiency))
+	for skill := range r.Proficiency {
+		rated = append(rated, skill)
+	}
+	sort.Strings(rated)
+	for _, skill := range rated {
+		level := r.Proficiency[skill]
+		field := "proficiency." + skill
+		switch {
+		case !seen[skill]:
+			errs = append(errs, FieldError{Field: field, Message: fmt.Sprintf("%q is not one of the agent's skills", skill)})
+		case level < MinProficiency || level > MaxProficiency:
+			errs = append(errs, FieldError{Field: field, Message: fmt.Sprintf("must be between %d and %d", MinProficiency, MaxProficiency)})
+		}
+	}
+	return errs
+}
@@ -0,0 +1,15 @@
+package types
+
+import "testing"
+
+func TestRosterProficiencyValidation(t *testing.T) {
+	rec := RosterRecord{
+		AgentID: "a-1", Department: DeptTechnical, Location: LocationBerlin,
+		Skills:      []string{"tech_l2"},
+		Proficiency: map[string]int{"tech_l2": 6, "sales": 3},
+	}
+	errs := rec.Validate()
+	if len(errs) != 2 || errs[0].Field != "proficiency.sales" || errs[1].Field != "proficiency.tech_l2" {
+		t.Errorf("expected sales (not a skill) and tech_l2 (out of range), got %+v", errs)
+	}
+}
@@ -0,0 +1,137 @@
+package types
+
+import "time"
+
+// PlannedActivity is what an agent is scheduled to be doing during an interval
+type PlannedActivity string
+
+const (
+	ActivityWork     PlannedActivity = "work" // handling contacts (available, on call, ACW, hold, ...)
+	ActivityBreak    PlannedActivity = "break"
+	ActivityLunch    PlannedActivity = "lunch"
+	ActivityTraining PlannedActivity = "training"
+	ActivityMeeting  PlannedActivity = "meeting"
+	ActivityOffline  PlannedActivity = "offline"
+)
+
+// AllActivities lists every planned activity
+var AllActivities = []PlannedActivity{ActivityWork, ActivityBreak, ActivityLunch, ActivityTraining, ActivityMeeting, ActivityOffline}
+
+// ActivityForState maps an actual agent state to the activity it counts as
+func ActivityForState(state AgentState) PlannedActivity {
+	switch state {
+	case StateBreak:
+		return ActivityBreak
+	case StateLunch:
+		return ActivityLunch
+	case StateTraining:
+		return ActivityTraining
+	case StateMeeting:
+		return ActivityMeeting
+	case StateOffline, "":
+		return ActivityOffline
+	default:
+		return ActivityWork
+	}
+}
+
+// ScheduleInterval is one planned activity block for an agent
+type ScheduleInterval struct {
+	AgentID  string          `json:"agentId"`
+	Start    time.Time       `json:"start"`
+	End      time.Time       `json:"end"`
+	Activity PlannedActivity `json:"activity"`
+}
+
+// CalendarEvent is a meeting or training scheduled for every agent of a team. It takes
+// precedence over the agents' own schedule intervals while it runs.
+type CalendarEvent struct {
+	ID       string          `json:"id"`               // assigned on import when empty
+	Tenant   string          `json:"tenant,omitempty"` // set from the importer's token; empty = DefaultTenant
+	Team     string          `json:"team"`
+	Title    string          `json:"title,omitempty"`
+	Activity PlannedActivity `json:"activity"` // meeting or training
+	Start    time.Time       `json:"start"`
+	End      time.Time       `json:"end"`
+}
+
+// StateSegment is a completed stretch of time an agent spent in one state, persisted to DynamoDB
+type StateSegment struct {
+	AgentID  string     `json:"agentId" dynamodbav:"AgentID"` // partition key
+	StartKey string     `json:"-" dynamodbav:"StartKey"`      // sort key: fixed-width UTC start (see SegmentStartKey)
+	State    AgentState `json:"state" dynamodbav:"State"`
+	Start    time.Time  `json:"start" dynamodbav:"Start"`
+	End      time.Time  `json:"end" dynamodbav:"End"`
+}
+
+// SegmentStartKey formats a segment start as a sortable key that begins with its YYYY-MM-DD date
+func SegmentStartKey(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// SegmentLookback is how long before a time range segments that overlap it are looked for.
+// Segments are found by their start key, so a longer one is missed by a range it started before.
+const SegmentLookback = 24 * time.Hour
+
+// Overlaps reports whether the segment overlaps [from, to)
+func (s StateSegment) Overlaps(from, to time.Time) bool {
+	return s.Start.Before(to) && s.End.After(from)
+}
+
+// AgentAdherence compares an agent's actual state timeline against their schedule
+type AgentAdherence struct {
+	AgentID           string  `json:"agentId"`
+	Team              string  `json:"team,omitempty"`
+	Date              string  `json:"date"`              // YYYY-MM-DD
+	ScheduledSecs     float64 `json:"scheduledSecs"`     // scheduled time elapsed so far
+	InAdherenceSecs   float64 `json:"inAdherenceSecs"`   // time the actual activity matched the schedule
+	Adherence         float64 `json:"adherence"`         // 0-100%
+	ScheduledWorkSecs float64 `json:"scheduledWorkSecs"` // scheduled work time elapsed so far
+	WorkedSecs        float64 `json:"workedSecs"`        // actual time in work states within the schedule
+	Conformance       float64 `json:"conformance"`       // worked vs scheduled work, 0-100%+
+
+	// Offline time is time logged in but not handling contacts (break, lunch, meeting, training)
+	ScheduledOfflineSecs   float64 `json:"scheduledOfflineSecs"`   // offline time planned as that activity
+	UnscheduledOfflineSecs float64 `json:"unscheduledOfflineSecs"` // offline time without a matching plan
+}
+
+// TeamAdherence aggregates adherence across the scheduled members of a team
+type TeamAdherence struct {
+	Team        string  `json:"team"`
+	Date        string  `json:"date"`
+	Adherence   float64 `json:"adherence"`   // time-weighted, 0-100%
+	Conformance float64 `json:"conformance"` // time-weighted, 0-100%+
+
+	ScheduledOfflineSecs   float64 `json:"scheduledOfflineSecs"`
+	UnscheduledOfflineSecs float64 `json:"unscheduledOfflineSecs"`
+
+	Agents []AgentAdherence `json:"agents"`
+}
+
+// ProductivityReport is the response of GET /api/reports/agents: every agent's day split into
+// fixed intervals, for intraday workforce management
+type ProductivityReport struct {
+	Date            string              `json:"date"` // YYYY-MM-DD in the reporting timezone
+	IntervalMinutes int                 `json:"intervalMinutes"`
+	Agents          []AgentProductivity `json:"agents"` // by agent ID; agents without any activity are left out
+}
+
+// AgentProductivity is one agent's intervals in a ProductivityReport
+type AgentProductivity struct {
+	AgentID    string          `json:"agentId"`
+	Team       string          `json:"team,omitempty"`
+	Department Department      `json:"department,omitempty"`
+	Intervals  []IntervalStats `json:"intervals"` // only intervals with scheduled time, logged-in time or calls
+}
+
+// IntervalStats is an agent's productivity in one interval, counted up to now
+type IntervalStats struct {
+	Start         time.Time `json:"start"`
+	CallsHandled  int       `json:"callsHandled"`  // calls completed in the interval
+	AvgHandleTime float64   `json:"avgHandleTime"` // seconds: talk + hold + wrap of those calls
+	HandlingSecs  float64   `json:"handlingSecs"`  // time in call handling states, ACW included
+	AvailableSecs float64   `json:"availableSecs"`
+	Occupancy     float64   `json:"occupancy"`     // handling / (handling + available), 0-100%
+	ScheduledSecs float64   `json:"scheduledSecs"` // scheduled time elapsed in the interval
+	Adherence     float64   `json:"adherence"`     // 0-100%, 0 when nothing was scheduled
+}
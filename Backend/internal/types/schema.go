@@ -0,0 +1,57 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Snapshot schema versions. A dashboard declares the versions it understands when it connects
+// to /ws; the Hub sends it the newest of them and down-converts snapshots for older dashboards.
+const (
+	// SnapshotSchemaV1 is the payload before versioning: no schemaVersion, no announcements
+	SnapshotSchemaV1 = 1
+	// SnapshotSchemaV2 adds schemaVersion and announcements
+	SnapshotSchemaV2 = 2
+
+	// SnapshotSchemaVersion is the version snapshots are built in
+	SnapshotSchemaVersion = SnapshotSchemaV2
+)
+
+// NegotiateSchemaVersion picks the newest snapshot schema version among a client's supported
+// versions; false when the server supports none of them
+func NegotiateSchemaVersion(supported []int) (int, bool) {
+	best := 0
+	for _, v := range supported {
+		if v >= SnapshotSchemaV1 && v <= SnapshotSchemaVersion && v > best {
+			best = v
+		}
+	}
+	return best, best > 0
+}
+
+// ParseSchemaVersions parses a comma-separated version list, e.g. "1,2"
+func ParseSchemaVersions(s string) ([]int, error) {
+	var versions []int
+	for _, part := range strings.Split(s, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema version %q", part)
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// ForSchemaVersion returns the snapshot as a dashboard on schema version v expects it: the
+// snapshot itself for the current version, otherwise a copy without the newer fields
+func (s *Snapshot) ForSchemaVersion(v int) *Snapshot {
+	if v >= SnapshotSchemaVersion {
+		return s
+	}
+	old := *s
+	old.SchemaVersion = 0
+	old.Announcements = nil
+	old.Degradation = nil
+	return &old
+}
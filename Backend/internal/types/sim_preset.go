@@ -0,0 +1,127 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxSimPresetSteps bounds the scenario script of a preset
+const MaxSimPresetSteps = 100
+
+// SimPreset is a named AgentSim setup: agent count, call rates, peak factor and a scenario
+// script, saved on the backend and applied in one request
+type SimPreset struct {
+	Name           string                 `json:"name" dynamodbav:"Name"`                                         // partition key
+	ActiveAgents   int                    `json:"activeAgents,omitempty" dynamodbav:"ActiveAgents,omitempty"`     // start with or scale to; 0 keeps the count
+	PeakHourFactor float64                `json:"peakHourFactor,omitempty" dynamodbav:"PeakHourFactor,omitempty"` // 0 keeps the factor
+	CallRates      map[Department]float64 `json:"callRates,omitempty" dynamodbav:"CallRates,omitempty"`           // calls per minute; unlisted departments keep theirs
+	Steps          []SimStep              `json:"steps,omitempty" dynamodbav:"Steps,omitempty"`                   // run in order once the rest is applied
+	UpdatedAt      time.Time              `json:"updatedAt" dynamodbav:"UpdatedAt"`
+}
+
+// SimStep is one action of a preset's scenario script, as in montictl scenario files
+type SimStep struct {
+	Action   string `json:"action" dynamodbav:"Action"` // start, scale, stop, inject or wait
+	Agents   int    `json:"agents,omitempty" dynamodbav:"Agents,omitempty"`
+	Count    int    `json:"count,omitempty" dynamodbav:"Count,omitempty"`
+	VQ       string `json:"vq,omitempty" dynamodbav:"VQ,omitempty"`             // inject; empty = round-robin over all VQs
+	Duration string `json:"duration,omitempty" dynamodbav:"Duration,omitempty"` // wait, e.g. "30s"
+}
+
+// Validate checks the preset's name, counts, departments and steps
+func (p SimPreset) Validate() []FieldError {
+	var errs []FieldError
+	if p.Name == "" {
+		errs = append(errs, requiredField("name"))
+	} else if strings.ContainsAny(p.Name, "/?#") {
+		errs = append(errs, FieldError{Field: "name", Message: "must not contain /, ? or #"})
+	}
+	if p.ActiveAgents < 0 {
+		errs = append(errs, FieldError{Field: "activeAgents", Message: "must not be negative"})
+	}
+	if p.PeakHourFactor < 0 {
+		errs = append(errs, FieldError{Field: "peakHourFactor", Message: "must not be negative"})
+	}
+	depts := make([]Department, 0, len(p.CallRates))
+	for dept := range p.CallRates {
+		depts = append(depts, dept)
+	}
+	sort.Slice(depts, func(i, j int) bool { return depts[i] < depts[j] })
+	for _, dept := range depts {
+		if rate := p.CallRates[dept]; !dept.Valid() {
+			errs = append(errs, unknownValue("callRates", "department", string(dept)))
+		} else if rate < 0 {
+			errs = append(errs, FieldError{Field: "callRates." + string(dept), Message: "must not be negative"})
+		}
+	}
+	if len(p.Steps) > MaxSimPresetSteps {
+		errs = append(errs, FieldError{Field: "steps", Message: fmt.Sprintf("at most %d steps", MaxSimPresetSteps)})
+	}
+	for i, s := range p.Steps {
+		errs = append(errs, s.validate(fmt.Sprintf("steps[%d]", i))...)
+	}
+	return errs
+}
+
+func (s SimStep) validate(field string) []FieldError {
+	switch s.Action {
+	case "start", "scale":
+		if s.Agents <= 0 {
+			return []FieldError{{Field: field + ".agents", Message: "must be positive"}}
+		}
+	case "stop":
+	case "inject":
+		if s.Count <= 0 || s.Count > 1000 {
+			return []FieldError{{Field: field + ".count", Message: "must be between 1 and 1000"}}
+		}
+		if _, ok := VQDepartmentMapping[VQName(s.VQ)]; s.VQ != "" && !ok {
+			return []FieldError{unknownValue(field+".vq", "VQ", s.VQ)}
+		}
+	case "wait":
+		if d, err := time.ParseDuration(s.Duration); err != nil || d <= 0 {
+			return []FieldError{{Field: field + ".duration", Message: "must be a positive duration, e.g. 30s"}}
+		}
+	case "":
+		return []FieldError{requiredField(field + ".action")}
+	default:
+		return []FieldError{unknownValue(field+".action", "action", s.Action)}
+	}
+	return nil
+}
+
+// SimPresetApplyResponse is the body returned by POST /api/admin/sim/presets/{name}/apply
+type SimPresetApplyResponse struct {
+	Preset     string `json:"preset"`
+	CallConfig bool   `json:"callConfig"`       // call rates or the peak factor were sent to AgentSim
+	Agents     string `json:"agents,omitempty"` // "started" or "scaled" to activeAgents; empty = untouched
+	Steps      int    `json:"steps"`            // scenario steps now running in the background
+}
+
+// SimScheduleEntry is one entry of the sim schedule (SIM_SCHEDULE_FILE). It applies a saved
+// preset, the inline settings or stops the simulation at a time of day.
+type SimScheduleEntry struct {
+	Days           []string               `json:"days,omitempty"` // mon .. sun; empty = every day
+	At             string                 `json:"at"`             // HH:MM in the schedule's time zone
+	Preset         string                 `json:"preset,omitempty"`
+	Stop           bool                   `json:"stop,omitempty"`
+	ActiveAgents   int                    `json:"activeAgents,omitempty"` // inline settings, as on SimPreset
+	PeakHourFactor float64                `json:"peakHourFactor,omitempty"`
+	CallRates      map[Department]float64 `json:"callRates,omitempty"`
+}
+
+// SimScheduleRun is the outcome of a scheduled entry
+type SimScheduleRun struct {
+	At    time.Time `json:"at"`
+	Entry int       `json:"entry"`           // index into the schedule's entries
+	Error string    `json:"error,omitempty"` // empty = applied
+}
+
+// SimScheduleStatus is the body returned by GET /api/admin/sim/schedule
+type SimScheduleStatus struct {
+	Timezone string             `json:"timezone"`
+	Entries  []SimScheduleEntry `json:"entries"`
+	NextRun  *time.Time         `json:"nextRun,omitempty"`
+	LastRuns []SimScheduleRun   `json:"lastRuns"` // the entries of the latest run time, in order
+}
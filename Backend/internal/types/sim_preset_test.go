@@ -0,0 +1,29 @@
+package types
+
+import "testing"
+
+func TestSimPresetValidation(t *testing.T) {
+	p := SimPreset{
+		Name:      "demo",
+		CallRates: map[Department]float64{DeptSupport: -1, "marketing": 3},
+		Steps: []SimStep{
+			{Action: "start", Agents: 100},
+			{Action: "inject", Count: 5, VQ: "nowhere"},
+			{Action: "wait", Duration: "soon"},
+			{Action: "dance"},
+		},
+	}
+	errs := p.Validate()
+	want := []string{"callRates", "callRates.support", "steps[1].vq", "steps[2].duration", "steps[3].action"}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %v, got %+v", want, errs)
+	}
+	for i, field := range want {
+		if errs[i].Field != field {
+			t.Errorf("error %d: expected field %s, got %+v", i, field, errs[i])
+		}
+	}
+	if errs[0].Code != FieldUnknownValue || errs[2].Code != FieldUnknownValue {
+		t.Errorf("expected unknown department and VQ to be unknown values, got %+v", errs)
+	}
+}
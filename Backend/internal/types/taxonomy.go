@@ -0,0 +1,183 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Taxonomy is the set of departments (with their VQs), locations and business units the
+// deployment models. The built-in defaults can be replaced from a JSON file at startup.
+type Taxonomy struct {
+	Departments   []DepartmentDef             `json:"departments"`
+	Locations     []LocationDef               `json:"locations"`
+	BusinessUnits map[BusinessUnit][]Location `json:"businessUnits"`
+}
+
+// DepartmentDef is one department and the virtual queues that route to it. Channels sets the
+// channel of VQs that don't follow the "*_chat" / "*_email" naming (see ChannelOf).
+type DepartmentDef struct {
+	Name     Department         `json:"name"`
+	VQs      []VQName           `json:"vqs"`
+	Channels map[VQName]Channel `json:"channels,omitempty"`
+}
+
+// LocationDef is one location; Weight is the simulator's share of generated agents. Timezone
+// (IANA name) is where the location's agents start their day; empty = the reporting timezone.
+type LocationDef struct {
+	Name     Location `json:"name"`
+	Weight   int      `json:"weight,omitempty"`
+	Timezone string   `json:"timezone,omitempty"`
+}
+
+// DefaultTaxonomy returns the built-in departments, locations and business units
+func DefaultTaxonomy() Taxonomy {
+	return Taxonomy{
+		Departments: []DepartmentDef{
+			{Name: DeptSales, VQs: []VQName{VQSalesInbound, VQSalesOutbound, VQSalesCallback, VQSalesChat}},
+			{Name: DeptSupport, VQs: []VQName{VQSupportGeneral, VQSupportBilling, VQSupportCallback, VQSupportChat}},
+			{Name: DeptTechnical, VQs: []VQName{VQTechL1, VQTechL2, VQTechCallback, VQTechChat}},
+			{Name: DeptRetention, VQs: []VQName{VQRetentionSave, VQRetentionCancel, VQRetentionCallback, VQRetentionChat}},
+		},
+		Locations: []LocationDef{
+			{Name: LocationBerlin, Weight: 25},
+			{Name: LocationMunich, Weight: 20},
+			{Name: LocationHamburg, Weight: 15},
+			{Name: LocationFrankfurt, Weight: 15},
+			{Name: LocationRemote, Weight: 25},
+		},
+		BusinessUnits: map[BusinessUnit][]Location{
+			BUSGB: {LocationMunich, LocationFrankfurt},
+			BUNGB: {LocationBerlin, LocationHamburg},
+			BURGB: {LocationRemote},
+		},
+	}
+}
+
+// LoadTaxonomyFile reads a JSON taxonomy; omitted sections keep their defaults
+func LoadTaxonomyFile(path string) (Taxonomy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Taxonomy{}, fmt.Errorf("failed to read taxonomy: %w", err)
+	}
+	var t Taxonomy
+	if err := json.Unmarshal(data, &t); err != nil {
+		return Taxonomy{}, fmt.Errorf("failed to parse taxonomy: %w", err)
+	}
+
+	def := DefaultTaxonomy()
+	if t.Departments == nil {
+		t.Departments = def.Departments
+	}
+	if t.Locations == nil {
+		t.Locations = def.Locations
+	}
+	if t.BusinessUnits == nil {
+		t.BusinessUnits = def.BusinessUnits
+	}
+	if err := t.Validate(); err != nil {
+		return Taxonomy{}, err
+	}
+	return t, nil
+}
+
+// Validate checks for empty or duplicate names, VQs shared between departments and
+// business units referencing unknown locations
+func (t Taxonomy) Validate() error {
+	if len(t.Departments) == 0 {
+		return fmt.Errorf("taxonomy needs at least one department")
+	}
+	if len(t.Locations) == 0 {
+		return fmt.Errorf("taxonomy needs at least one location")
+	}
+
+	depts := make(map[Department]bool, len(t.Departments))
+	vqs := make(map[VQName]Department)
+	for _, d := range t.Departments {
+		if d.Name == "" {
+			return fmt.Errorf("department without name")
+		}
+		if depts[d.Name] {
+			return fmt.Errorf("duplicate department %q", d.Name)
+		}
+		depts[d.Name] = true
+		for _, vq := range d.VQs {
+			if vq == "" {
+				return fmt.Errorf("department %q: empty VQ name", d.Name)
+			}
+			if other, dup := vqs[vq]; dup {
+				return fmt.Errorf("VQ %q belongs to both %q and %q", vq, other, d.Name)
+			}
+			vqs[vq] = d.Name
+		}
+		for vq, ch := range d.Channels {
+			if vqs[vq] != d.Name {
+				return fmt.Errorf("department %q: channel set for unknown VQ %q", d.Name, vq)
+			}
+			if !ch.Valid() {
+				return fmt.Errorf("department %q: VQ %q has unknown channel %q", d.Name, vq, ch)
+			}
+		}
+	}
+
+	locs := make(map[Location]bool, len(t.Locations))
+	for _, l := range t.Locations {
+		if l.Name == "" {
+			return fmt.Errorf("location without name")
+		}
+		if locs[l.Name] {
+			return fmt.Errorf("duplicate location %q", l.Name)
+		}
+		if l.Weight < 0 {
+			return fmt.Errorf("location %q: weight must not be negative", l.Name)
+		}
+		if l.Timezone != "" {
+			if _, err := time.LoadLocation(l.Timezone); err != nil {
+				return fmt.Errorf("location %q: invalid timezone %q", l.Name, l.Timezone)
+			}
+		}
+		locs[l.Name] = true
+	}
+
+	for bu, locations := range t.BusinessUnits {
+		for _, loc := range locations {
+			if !locs[loc] {
+				return fmt.Errorf("business unit %q: unknown location %q", bu, loc)
+			}
+		}
+	}
+	return nil
+}
+
+// SetTaxonomy replaces the active departments, VQs and their channels, locations and business units
+// (call at startup, before anything reads them)
+func SetTaxonomy(t Taxonomy) {
+	AllDepartments = make([]Department, 0, len(t.Departments))
+	VQDepartmentMapping = make(map[VQName]Department)
+	VQChannels = make(map[VQName]Channel)
+	DepartmentVQs = make(map[Department][]VQName, len(t.Departments))
+	AllVQs = nil
+	for _, d := range t.Departments {
+		AllDepartments = append(AllDepartments, d.Name)
+		DepartmentVQs[d.Name] = d.VQs
+		for _, vq := range d.VQs {
+			VQDepartmentMapping[vq] = d.Name
+			AllVQs = append(AllVQs, vq)
+		}
+		for vq, ch := range d.Channels {
+			VQChannels[vq] = ch
+		}
+	}
+
+	AllLocations = make([]Location, 0, len(t.Locations))
+	LocationTimezones = make(map[Location]*time.Location)
+	for _, l := range t.Locations {
+		AllLocations = append(AllLocations, l.Name)
+		if l.Timezone != "" {
+			LocationTimezones[l.Name], _ = time.LoadLocation(l.Timezone) // checked by Validate
+		}
+	}
+
+	BULocationMapping = t.BusinessUnits
+}
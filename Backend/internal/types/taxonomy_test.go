@@ -0,0 +1,111 @@
+package types
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadTaxonomyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "taxonomy.json")
+	data := `{
+		"departments": [
+			{"name": "sales", "vqs": ["sales_inbound"]},
+			{"name": "billing", "vqs": ["billing_inbound", "billing_chat"]}
+		],
+		"locations": [{"name": "berlin"}, {"name": "vienna", "weight": 10, "timezone": "Europe/Vienna"}],
+		"businessUnits": {"AT": ["vienna"]}
+	}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tax, err := LoadTaxonomyFile(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	def := DefaultTaxonomy()
+	defer SetTaxonomy(def)
+	SetTaxonomy(tax)
+
+	if !Department("billing").Valid() || DeptTechnical.Valid() {
+		t.Errorf("expected configured departments only, got %v", AllDepartments)
+	}
+	if !Location("vienna").Valid() || LocationMunich.Valid() {
+		t.Errorf("expected configured locations only, got %v", AllLocations)
+	}
+	if LocationTimezone("vienna").String() != "Europe/Vienna" || LocationTimezone("berlin") != ReportingLocation {
+		t.Errorf("expected vienna's own timezone and berlin on the reporting timezone, got %v", LocationTimezones)
+	}
+	if VQDepartmentMapping["billing_chat"] != "billing" || len(AllVQs) != 3 {
+		t.Errorf("expected VQs mapped to their departments, got %v", VQDepartmentMapping)
+	}
+	if len(BULocationMapping["AT"]) != 1 {
+		t.Errorf("expected configured business units, got %v", BULocationMapping)
+	}
+}
+
+func TestTaxonomyValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		tax  Taxonomy
+	}{
+		{"no departments", Taxonomy{Locations: []LocationDef{{Name: "berlin"}}}},
+		{"duplicate location", Taxonomy{
+			Departments: []DepartmentDef{{Name: "sales"}},
+			Locations:   []LocationDef{{Name: "berlin"}, {Name: "berlin"}},
+		}},
+		{"shared VQ", Taxonomy{
+			Departments: []DepartmentDef{{Name: "a", VQs: []VQName{"q"}}, {Name: "b", VQs: []VQName{"q"}}},
+			Locations:   []LocationDef{{Name: "berlin"}},
+		}},
+		{"unknown channel", Taxonomy{
+			Departments: []DepartmentDef{{Name: "a", VQs: []VQName{"q"}, Channels: map[VQName]Channel{"q": "fax"}}},
+			Locations:   []LocationDef{{Name: "berlin"}},
+		}},
+		{"channel for foreign VQ", Taxonomy{
+			Departments: []DepartmentDef{{Name: "a", VQs: []VQName{"q"}}, {Name: "b", Channels: map[VQName]Channel{"q": ChannelChat}}},
+			Locations:   []LocationDef{{Name: "berlin"}},
+		}},
+		{"unknown timezone", Taxonomy{
+			Departments: []DepartmentDef{{Name: "sales"}},
+			Locations:   []LocationDef{{Name: "berlin", Timezone: "Europe/Atlantis"}},
+		}},
+		{"unknown BU location", Taxonomy{
+			Departments:   []DepartmentDef{{Name: "sales"}},
+			Locations:     []LocationDef{{Name: "berlin"}},
+			BusinessUnits: map[BusinessUnit][]Location{"X": {"paris"}},
+		}},
+	}
+	for _, tt := range tests {
+		if err := tt.tax.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", tt.name)
+		}
+	}
+	if err := DefaultTaxonomy().Validate(); err != nil {
+		t.Errorf("expected defaults to be valid, got %v", err)
+	}
+}
+
+func TestChannelOf(t *testing.T) {
+	defer SetTaxonomy(DefaultTaxonomy())
+
+	if got := ChannelOf(VQSalesChat); got != ChannelChat {
+		t.Errorf("expected %s to be chat, got %s", VQSalesChat, got)
+	}
+	if got := ChannelOf(VQSalesInbound); got != ChannelVoice {
+		t.Errorf("expected %s to be voice, got %s", VQSalesInbound, got)
+	}
+
+	tax := DefaultTaxonomy()
+	tax.Departments[0].VQs = append(tax.Departments[0].VQs, "sales_messaging")
+	tax.Departments[0].Channels = map[VQName]Channel{"sales_messaging": ChannelChat, VQSalesCallback: ChannelEmail}
+	SetTaxonomy(tax)
+	if got := ChannelOf("sales_messaging"); got != ChannelChat {
+		t.Errorf("expected configured chat channel, got %s", got)
+	}
+	if got := ChannelOf(VQSalesCallback); got != ChannelEmail {
+		t.Errorf("expected configured email channel, got %s", got)
+	}
+}
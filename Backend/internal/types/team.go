@@ -0,0 +1,39 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// Team is a managed team: the unit supervisors are responsible for, owned by one department
+type Team struct {
+	Name           string     `json:"name" dynamodbav:"Name"`                         // partition key, matches AgentInfo.Team
+	Tenant         string     `json:"tenant,omitempty" dynamodbav:"Tenant,omitempty"` // owning tenant, set from the admin's token; empty = DefaultTenant
+	Department     Department `json:"department" dynamodbav:"Department"`
+	Supervisor     string     `json:"supervisor,omitempty" dynamodbav:"Supervisor,omitempty"` // supervisor's login email
+	SupervisorName string     `json:"supervisorName,omitempty" dynamodbav:"SupervisorName,omitempty"`
+	UpdatedAt      time.Time  `json:"updatedAt" dynamodbav:"UpdatedAt"`
+}
+
+// Validate checks that the team is named and belongs to a known department
+func (t Team) Validate() []FieldError {
+	var errs []FieldError
+	if t.Name == "" {
+		errs = append(errs, FieldError{Field: "name", Message: "required"})
+	}
+	if !t.Department.Valid() {
+		errs = append(errs, FieldError{Field: "department", Message: fmt.Sprintf("unknown department %q", t.Department)})
+	}
+	return errs
+}
+
+// TeamSummary is the response of GET /api/teams/{team}/summary: the team lead view in one
+// call. The embedded team rollup carries the live state breakdown, occupancy and the totals of
+// the team's queues.
+type TeamSummary struct {
+	Rollup
+	Timestamp time.Time     `json:"timestamp"` // of the snapshot the summary was built from
+	Today     KPIWindow     `json:"today"`     // the agents' daily observed KPIs: calls, call-weighted AHT, avg occupancy
+	Queues    []VQSnapshot  `json:"queues"`    // queues of the team's departments
+	Alerts    []AlertRecord `json:"alerts"`    // top active alerts, critical first, then oldest
+}
@@ -0,0 +1,67 @@
+package types
+
+import "fmt"
+
+// DefaultTenant is the tenant of agents, calls and users that name none
+const DefaultTenant = "default"
+
+// Tenants lists the contact centers hosted besides DefaultTenant (empty = single-tenant)
+var Tenants []string
+
+// SetTenants replaces the hosted tenants (call at startup, before anything reads them)
+func SetTenants(tenants []string) error {
+	seen := make(map[string]bool, len(tenants))
+	for _, t := range tenants {
+		if t == "" || t == DefaultTenant {
+			return fmt.Errorf("invalid tenant name %q", t)
+		}
+		if seen[t] {
+			return fmt.Errorf("duplicate tenant %q", t)
+		}
+		seen[t] = true
+	}
+	Tenants = tenants
+	return nil
+}
+
+// MultiTenant reports whether tenants besides DefaultTenant are hosted
+func MultiTenant() bool {
+	return len(Tenants) > 0
+}
+
+// AllTenants returns DefaultTenant followed by the hosted tenants
+func AllTenants() []string {
+	return append([]string{DefaultTenant}, Tenants...)
+}
+
+// TenantOf returns tenant, or DefaultTenant when empty
+func TenantOf(tenant string) string {
+	if tenant == "" {
+		return DefaultTenant
+	}
+	return tenant
+}
+
+// IsKnownTenant reports whether tenant (empty = DefaultTenant) is hosted
+func IsKnownTenant(tenant string) bool {
+	tenant = TenantOf(tenant)
+	if tenant == DefaultTenant {
+		return true
+	}
+	for _, t := range Tenants {
+		if t == tenant {
+			return true
+		}
+	}
+	return false
+}
+
+// TenantKey prefixes a storage partition key with the tenant, e.g. "acme#2026-03-02".
+// Keys of DefaultTenant are unchanged so single-tenant data keeps its layout.
+func TenantKey(tenant, key string) string {
+	tenant = TenantOf(tenant)
+	if tenant == DefaultTenant {
+		return key
+	}
+	return tenant + "#" + key
+}
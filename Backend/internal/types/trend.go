@@ -0,0 +1,45 @@
+package types
+
+// Trend describes a metric's current value against its moving average over the trend window
+type Trend struct {
+	Current float64 `json:"current"`
+	Average float64 `json:"average"` // moving average over the window
+	Delta   float64 `json:"delta"`   // change since the oldest sample in the window
+}
+
+// TrendSet holds trends for the headline metrics of one scope (a department or global)
+type TrendSet struct {
+	AvailableAgents *Trend `json:"availableAgents,omitempty"`
+	WaitingCalls    *Trend `json:"waitingCalls,omitempty"`
+	ServiceLevel    *Trend `json:"serviceLevel,omitempty"`
+	Occupancy       *Trend `json:"occupancy,omitempty"`
+}
+
+// SnapshotTrends holds short-window trends attached to a snapshot
+type SnapshotTrends struct {
+	WindowSecs  int                     `json:"windowSecs"`
+	Global      TrendSet                `json:"global"`
+	Departments map[Department]TrendSet `json:"departments"`
+}
+
+// QueueOnly returns a copy of the trends with agent-derived metrics removed and only the
+// given departments' queue trends kept. Used for restricted clients, since the agent counts
+// behind those trends span all locations. Global trends are kept only if every department is visible.
+func (t *SnapshotTrends) QueueOnly(departments map[Department]bool) *SnapshotTrends {
+	filtered := &SnapshotTrends{
+		WindowSecs:  t.WindowSecs,
+		Departments: make(map[Department]TrendSet, len(t.Departments)),
+	}
+	allVisible := true
+	for dept, set := range t.Departments {
+		if !departments[dept] {
+			allVisible = false
+			continue
+		}
+		filtered.Departments[dept] = TrendSet{WaitingCalls: set.WaitingCalls, ServiceLevel: set.ServiceLevel}
+	}
+	if allVisible {
+		filtered.Global = TrendSet{WaitingCalls: t.Global.WaitingCalls, ServiceLevel: t.Global.ServiceLevel}
+	}
+	return filtered
+}
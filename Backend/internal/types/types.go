@@ -23,6 +23,10 @@ const (
 	StateOnHold       AgentState = "on_hold"
 	StateTransferring AgentState = "transferring"
 	StateConference   AgentState = "conference"
+
+	// StateMultiSession is an agent handling one or more concurrent chats; chat VQs keep
+	// routing to it until it reaches the concurrency limit
+	StateMultiSession AgentState = "multi_session"
 )
 
 // Department represents different call center departments
@@ -55,14 +59,14 @@ const (
 	BURGB BusinessUnit = "RGB" // Remote Business - Remote
 )
 
-// BULocationMapping maps business units to their allowed locations
+// BULocationMapping maps business units to their allowed locations (replaced by SetTaxonomy)
 var BULocationMapping = map[BusinessUnit][]Location{
 	BUSGB: {LocationMunich, LocationFrankfurt},
 	BUNGB: {LocationBerlin, LocationHamburg},
 	BURGB: {LocationRemote},
 }
 
-// AllLocations returns all defined locations
+// AllLocations returns all defined locations (replaced by SetTaxonomy)
 var AllLocations = []Location{
 	LocationBerlin,
 	LocationMunich,
@@ -97,6 +101,7 @@ type AgentEvent struct {
 	Department    Department `json:"department"`
 	Location      Location   `json:"location"`
 	Team          string     `json:"team"`
+	Tenant        string     `json:"tenant,omitempty"` // the tracked agent's when recorded; empty = DefaultTenant
 	Timestamp     time.Time  `json:"timestamp"`
 	StateDuration float64    `json:"stateDuration"` // seconds in current state
 	KPIs          AgentKPIs  `json:"kpis"`
@@ -112,18 +117,46 @@ const (
 
 // AgentAlert represents an alert condition for an agent
 type AgentAlert struct {
+	ID       string        `json:"id,omitempty"` // lifecycle ID, used to acknowledge
 	Rule     string        `json:"rule"`
 	Severity AlertSeverity `json:"severity"`
 	Message  string        `json:"message"`
+	Status   AlertStatus   `json:"status,omitempty"`
+}
+
+// AlertScope identifies what a snapshot-level alert refers to
+type AlertScope string
+
+const (
+	AlertScopeAgent      AlertScope = "agent"
+	AlertScopeTeam       AlertScope = "team"
+	AlertScopeDepartment AlertScope = "department"
+	AlertScopeQueue      AlertScope = "queue"
+)
+
+// Alert is a team-, department- or queue-level alert condition (agent alerts live on AgentInfo)
+type Alert struct {
+	ID         string        `json:"id,omitempty"` // lifecycle ID, used to acknowledge
+	Rule       string        `json:"rule"`
+	Severity   AlertSeverity `json:"severity"`
+	Message    string        `json:"message"`
+	Scope      AlertScope    `json:"scope"`
+	Target     string        `json:"target"` // team name, department or VQ
+	Department Department    `json:"department"`
+	Tenant     string        `json:"tenant,omitempty"` // empty = DefaultTenant
+	Status     AlertStatus   `json:"status,omitempty"`
 }
 
 // AgentInfo represents the current state of an agent
 type AgentInfo struct {
 	AgentID          string                `json:"agentId"`
+	Tenant           string                `json:"tenant,omitempty"` // hosting contact center; empty = DefaultTenant
 	State            AgentState            `json:"state"`
 	Department       Department            `json:"department"`
 	Location         Location              `json:"location"`
 	Team             string                `json:"team"`
+	Skills           []string              `json:"skills,omitempty"` // from the managed roster
+	Proficiency      map[string]int        `json:"proficiency,omitempty"` // skill -> 1 (novice) to 5 (expert), from the managed roster
 	StateStart       time.Time             `json:"stateStart"`       // when current state started
 	LastUpdate       time.Time             `json:"lastUpdate"`       // last event received
 	LastHeartbeat    time.Time             `json:"lastHeartbeat"`    // last heartbeat received
@@ -132,8 +165,15 @@ type AgentInfo struct {
 	CurrentCallID    string                `json:"currentCallId,omitempty"`    // active call ID
 	CurrentVQ        VQName                `json:"currentVq,omitempty"`        // VQ of active call
 	CallStartTime    *time.Time            `json:"callStartTime,omitempty"`    // when current call started
+	CallHoldTime     float64               `json:"callHoldTime,omitempty"`     // seconds the current call's caller was on hold, holds ended so far
+	HoldStartTime    *time.Time            `json:"holdStartTime,omitempty"`    // when the current call's hold started; nil unless on hold
 	ACWStartTime     *time.Time            `json:"acwStartTime,omitempty"`     // when ACW started
 	BreakStartTime   *time.Time            `json:"breakStartTime,omitempty"`   // when break started
+	IdleSince        *time.Time            `json:"idleSince,omitempty"`        // when the agent became available; nil unless available
+	BreakReason      BreakReason           `json:"breakReason,omitempty"`      // reason of the current break
+	BreakTimeByReason map[BreakReason]float64 `json:"breakTimeByReason,omitempty"` // finished break seconds per reason since the tracker saw the agent; replaced, never mutated
+	Observed         *ObservedKPIs         `json:"observed,omitempty"`         // KPIs computed by the backend from call and state events; replaced, never mutated
+	Quarantined      bool                  `json:"quarantined,omitempty"`      // state changes are ignored after repeated invalid transitions
 	Alerts           []AgentAlert          `json:"alerts,omitempty"`           // active alerts
 }
 
@@ -159,16 +199,40 @@ type WidgetSummary struct {
 
 // DepartmentData holds agents and queues for a single department
 type DepartmentData struct {
-	Agents []AgentInfo  `json:"agents"`
-	Queues []VQSnapshot `json:"queues"`
+	Agents       []AgentInfo   `json:"agents"`
+	Queues       []VQSnapshot  `json:"queues"`
+	Channels     []ChannelOccupancy `json:"channels,omitempty"` // per-channel occupancy of the department's agents
+	Leaderboards []Leaderboard `json:"leaderboards,omitempty"` // top/bottom agents (admin toggle)
+	Summary      *WidgetSummary `json:"summary,omitempty"`     // sent instead of agents while degraded to summary-only
 }
 
 // Snapshot is the single payload sent to the frontend every tick
 // Contains all 2000 agents and all 16 queues in one message
 type Snapshot struct {
 	Type        string                     `json:"type"` // always "snapshot"
+	SchemaVersion int                      `json:"schemaVersion,omitempty"` // payload schema version (SnapshotSchemaVersion); absent in version 1
 	Timestamp   time.Time                  `json:"timestamp"`
+	Seq         int64                      `json:"seq,omitempty"` // broadcast sequence number, stamped by the Hub; clients acknowledge it
 	Departments map[Department]*DepartmentData `json:"departments"`
+	Rollups     []Rollup                   `json:"rollups,omitempty"` // team/location rollups (configurable dimensions)
+	Trends      *SnapshotTrends            `json:"trends,omitempty"`  // 5-minute moving averages and deltas
+	Alerts      []Alert                    `json:"alerts,omitempty"`  // team/department/queue alerts from the rules engine
+	Announcements []Announcement           `json:"announcements,omitempty"` // active shift-wide notices, most severe first
+	EventTimes  []EventTiming              `json:"eventTimes,omitempty"` // state changes since the last snapshot; stripped by the Hub before sending
+	Degradation *Degradation               `json:"degradation,omitempty"` // set while the backend sheds load; absent at full fidelity
+}
+
+// SnapshotHistory is sent to a frontend client once on connect with the latest snapshots,
+// oldest first
+type SnapshotHistory struct {
+	Type      string      `json:"type"` // always "snapshot_history"
+	Snapshots []*Snapshot `json:"snapshots"`
+}
+
+// EventTiming records when a state change happened at the source and when the tracker applied it
+type EventTiming struct {
+	Origin time.Time `json:"origin"` // sender timestamp (AgentSim clock)
+	Ingest time.Time `json:"ingest"` // backend receive time
 }
 
 // AgentConnectionStatus represents the connection status of an agent
@@ -177,7 +241,7 @@ type AgentConnectionStatus string
 const (
 	StatusConnected    AgentConnectionStatus = "connected"
 	StatusDisconnected AgentConnectionStatus = "disconnected"
-	StatusStale        AgentConnectionStatus = "stale" // no heartbeat > 6s
+	StatusStale        AgentConnectionStatus = "stale" // no heartbeat within STALE_THRESHOLD, or reconnecting within RECONNECT_GRACE
 )
 
 // AgentHeartbeat is sent from agent to backend periodically
@@ -187,6 +251,9 @@ type AgentHeartbeat struct {
 	State     AgentState `json:"state"`
 	Timestamp time.Time  `json:"timestamp"`
 	KPIs      AgentKPIs  `json:"kpis"`
+	Seq       uint64     `json:"seq,omitempty"` // per-agent message number; 0 = unnumbered
+
+	Generation uint64 `json:"-"` // connection generation, set by the AgentHub; 0 = untracked
 }
 
 // AgentStateChange is sent from agent to backend on state transitions
@@ -201,21 +268,31 @@ type AgentStateChange struct {
 	Department    Department `json:"department"`
 	Location      Location   `json:"location"`
 	Team          string     `json:"team"`
+	Reason        BreakReason `json:"reason,omitempty"`      // break reason code when NewState is break
+	TraceParent   string     `json:"traceparent,omitempty"` // W3C trace context of the sender's span
+	Seq           uint64     `json:"seq,omitempty"`         // per-agent message number; 0 = unnumbered
+
+	Generation uint64 `json:"-"` // connection generation, set by the AgentHub; 0 = untracked
 }
 
 // AgentRegister is sent when an agent first connects
 type AgentRegister struct {
 	Type       string     `json:"type"` // "register"
 	AgentID    string     `json:"agentId"`
+	Tenant     string     `json:"tenant,omitempty"` // empty = DefaultTenant
 	Department Department `json:"department"`
 	Location   Location   `json:"location"`
 	Team       string     `json:"team"`
 	State      AgentState `json:"state"`
 	KPIs       AgentKPIs  `json:"kpis"`
+	Seq        uint64     `json:"seq,omitempty"` // per-agent message number, restarting the count; 0 = unnumbered
+
+	Generation uint64 `json:"-"` // connection generation, set by the AgentHub; 0 = untracked
 }
 
 // ServerAck is sent from backend to agent as acknowledgment
 type ServerAck struct {
-	Type    string `json:"type"` // "ack"
-	AgentID string `json:"agentId"`
+	Type       string `json:"type"` // "ack"
+	AgentID    string `json:"agentId"`
+	Generation uint64 `json:"generation,omitempty"` // the connection's generation for register acks
 }
@@ -0,0 +1,195 @@
+package types
+
+import "fmt"
+
+// AllAgentStates lists every state an agent event may carry
+var AllAgentStates = []AgentState{
+	StateAvailable, StateBusy, StateOnCall, StateBreak, StateOffline,
+	StateAfterCallWork, StateTraining, StateMeeting, StateLunch,
+	StateOnHold, StateTransferring, StateConference, StateMultiSession,
+}
+
+// AllDepartments lists every department (replaced by SetTaxonomy)
+var AllDepartments = []Department{DeptSales, DeptSupport, DeptTechnical, DeptRetention}
+
+// Valid reports whether s is a known agent state
+func (s AgentState) Valid() bool {
+	for _, known := range AllAgentStates {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Valid reports whether d is a known department
+func (d Department) Valid() bool {
+	for _, known := range AllDepartments {
+		if d == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Valid reports whether l is a known location
+func (l Location) Valid() bool {
+	for _, known := range AllLocations {
+		if l == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Valid reports whether v is a known virtual queue
+func (v VQName) Valid() bool {
+	_, ok := VQDepartmentMapping[v]
+	return ok
+}
+
+// FieldError describes one rejected field of a request payload
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`  // FieldRequired or FieldUnknownValue where set
+	Value   string `json:"value,omitempty"` // the rejected value of a FieldUnknownValue error
+}
+
+// Codes of FieldError
+const (
+	FieldRequired     = "required"
+	FieldUnknownValue = "unknown_value" // a value outside the field's enum
+)
+
+func requiredField(field string) FieldError {
+	return FieldError{Field: field, Message: "required", Code: FieldRequired}
+}
+
+func unknownValue(field, what, value string) FieldError {
+	return FieldError{Field: field, Message: fmt.Sprintf("unknown %s %q", what, value), Code: FieldUnknownValue, Value: value}
+}
+
+// SplitUnknownValues separates errors about values outside an enum from the rest. Permissive
+// ingestion accepts a payload whose only errors are unknown values.
+func SplitUnknownValues(errs []FieldError) (unknown, rest []FieldError) {
+	for _, e := range errs {
+		if e.Code == FieldUnknownValue {
+			unknown = append(unknown, e)
+		} else {
+			rest = append(rest, e)
+		}
+	}
+	return unknown, rest
+}
+
+// Validate checks that an event names its agent and only references known states,
+// departments and locations
+func (e AgentEvent) Validate() []FieldError {
+	return validateAgent("", e.AgentID, e.Department, e.Location, &e.State)
+}
+
+// ValidateEnqueue checks a call enqueue: the VQ is required and must be known, and the tenant
+// (empty = default) must be hosted. Both decide where the call is routed, so unlike an event's
+// labels they are never accepted unknown.
+func ValidateEnqueue(vq VQName, tenant string) []FieldError {
+	var errs []FieldError
+	switch {
+	case vq == "":
+		errs = append(errs, requiredField("vq"))
+	case !vq.Valid():
+		errs = append(errs, unknownValue("vq", "vq", string(vq)))
+	}
+	if !IsKnownTenant(tenant) {
+		errs = append(errs, unknownValue("tenant", "tenant", tenant))
+	}
+	return errs
+}
+
+// ValidateRosterEntry checks one roster entry; prefix is prepended to field names (e.g. "[3].")
+func ValidateRosterEntry(prefix, agentID string, dept Department, loc Location) []FieldError {
+	return validateAgent(prefix, agentID, dept, loc, nil)
+}
+
+func validateAgent(prefix, agentID string, dept Department, loc Location, state *AgentState) []FieldError {
+	var errs []FieldError
+	if agentID == "" {
+		errs = append(errs, requiredField(prefix+"agentId"))
+	}
+	if state != nil && !state.Valid() {
+		errs = append(errs, unknownValue(prefix+"state", "state", string(*state)))
+	}
+	if !dept.Valid() {
+		errs = append(errs, unknownValue(prefix+"department", "department", string(dept)))
+	}
+	if !loc.Valid() {
+		errs = append(errs, unknownValue(prefix+"location", "location", string(loc)))
+	}
+	return errs
+}
+
+// Validate checks that a dry run's calls and agents are unique and only reference known
+// tenants, VQs, departments and states
+func (r RoutingDryRunRequest) Validate() []FieldError {
+	var errs []FieldError
+	calls := make(map[string]bool, len(r.Calls))
+	for i, c := range r.Calls {
+		prefix := fmt.Sprintf("calls[%d].", i)
+		switch {
+		case c.CallID == "":
+			errs = append(errs, FieldError{Field: prefix + "callId", Message: "required"})
+		case calls[c.CallID]:
+			errs = append(errs, FieldError{Field: prefix + "callId", Message: fmt.Sprintf("duplicate call %q", c.CallID)})
+		}
+		calls[c.CallID] = true
+		if !IsKnownTenant(c.Tenant) {
+			errs = append(errs, FieldError{Field: prefix + "tenant", Message: fmt.Sprintf("unknown tenant %q", c.Tenant)})
+		}
+		if !c.VQ.Valid() {
+			errs = append(errs, FieldError{Field: prefix + "vq", Message: fmt.Sprintf("unknown VQ %q", c.VQ)})
+		}
+		if c.WaitSecs < 0 {
+			errs = append(errs, FieldError{Field: prefix + "waitSecs", Message: "must not be negative"})
+		}
+	}
+
+	agents := make(map[string]bool, len(r.Agents))
+	for i, a := range r.Agents {
+		prefix := fmt.Sprintf("agents[%d].", i)
+		switch {
+		case a.AgentID == "":
+			errs = append(errs, FieldError{Field: prefix + "agentId", Message: "required"})
+		case agents[a.AgentID]:
+			errs = append(errs, FieldError{Field: prefix + "agentId", Message: fmt.Sprintf("duplicate agent %q", a.AgentID)})
+		}
+		agents[a.AgentID] = true
+		if !IsKnownTenant(a.Tenant) {
+			errs = append(errs, FieldError{Field: prefix + "tenant", Message: fmt.Sprintf("unknown tenant %q", a.Tenant)})
+		}
+		if !a.Department.Valid() {
+			errs = append(errs, FieldError{Field: prefix + "department", Message: fmt.Sprintf("unknown department %q", a.Department)})
+		}
+		if a.State != "" && !a.State.Valid() {
+			errs = append(errs, FieldError{Field: prefix + "state", Message: fmt.Sprintf("unknown state %q", a.State)})
+		}
+		if a.IdleSecs < 0 {
+			errs = append(errs, FieldError{Field: prefix + "idleSecs", Message: "must not be negative"})
+		}
+	}
+	return errs
+}
+
+// Validate checks the control message's action and that notices carry a message
+func (r ControlMessageRequest) Validate() []FieldError {
+	var errs []FieldError
+	switch r.Action {
+	case ControlReload:
+	case ControlNotice:
+		if r.Message == "" {
+			errs = append(errs, FieldError{Field: "message", Message: "required for notices"})
+		}
+	default:
+		errs = append(errs, FieldError{Field: "action", Message: fmt.Sprintf("unknown action %q (use %s or %s)", r.Action, ControlReload, ControlNotice)})
+	}
+	return errs
+}
@@ -0,0 +1,29 @@
+package types
+
+import "time"
+
+// Wallboard is a compact view of the latest snapshot for clients that poll
+// instead of holding a WebSocket open (TVs, integrations)
+type Wallboard struct {
+	Timestamp     time.Time             `json:"timestamp"`
+	Summary       Rollup                `json:"summary"` // all visible agents and all queues
+	Departments   []WallboardDepartment `json:"departments"`
+	Alerts        []Alert               `json:"alerts"`      // team/department/queue alerts
+	AgentAlerts   []WallboardAgentAlert `json:"agentAlerts"` // active agent alerts
+	Announcements []Announcement        `json:"announcements"`
+}
+
+// WallboardDepartment holds the agent summary and queues of one department
+type WallboardDepartment struct {
+	Department Department   `json:"department"`
+	Summary    Rollup       `json:"summary"`
+	Queues     []VQSnapshot `json:"queues"`
+}
+
+// WallboardAgentAlert is an agent alert with the agent it belongs to
+type WallboardAgentAlert struct {
+	AgentID    string     `json:"agentId"`
+	Department Department `json:"department"`
+	Team       string     `json:"team"`
+	AgentAlert
+}
@@ -0,0 +1,262 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/metrics"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// Request headers sent with every delivery
+const (
+	HeaderEvent     = "X-Monti-Event"
+	HeaderDelivery  = "X-Monti-Delivery"
+	HeaderTimestamp = "X-Monti-Timestamp"
+	HeaderSignature = "X-Monti-Signature"
+)
+
+const (
+	queueSize      = 1000
+	requestTimeout = 10 * time.Second
+	initialBackoff = time.Second
+	maxBackoff     = 30 * time.Second
+)
+
+// delivery is one event queued for one subscriber
+type delivery struct {
+	id    string
+	event string
+	body  []byte
+}
+
+// endpoint is a subscriber with its delivery queue
+type endpoint struct {
+	Subscriber
+	queue chan delivery
+}
+
+// Dispatcher fans events out to subscribers. Emit never blocks: each subscriber has a bounded
+// queue drained by its own worker, and events are dropped when the queue is full.
+type Dispatcher struct {
+	endpoints []*endpoint
+	client    *http.Client
+	logger    zerolog.Logger
+
+	// backoff is the wait before retry n (1-based); replaced in tests
+	backoff func(n int) time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewDispatcher creates a dispatcher for subs; call Start to begin delivering
+func NewDispatcher(subs []Subscriber, logger zerolog.Logger) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		client:  &http.Client{Timeout: requestTimeout},
+		logger:  logger.With().Str("component", "webhook").Logger(),
+		backoff: exponentialBackoff,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	for _, s := range subs {
+		if s.MaxAttempts == 0 {
+			s.MaxAttempts = DefaultMaxAttempts
+		}
+		d.endpoints = append(d.endpoints, &endpoint{Subscriber: s, queue: make(chan delivery, queueSize)})
+	}
+	return d
+}
+
+// exponentialBackoff doubles the wait per retry up to maxBackoff
+func exponentialBackoff(n int) time.Duration {
+	d := initialBackoff << (n - 1)
+	if d > maxBackoff || d <= 0 {
+		return maxBackoff
+	}
+	return d
+}
+
+// Start launches one delivery worker per subscriber
+func (d *Dispatcher) Start() {
+	for _, ep := range d.endpoints {
+		d.wg.Add(1)
+		go d.run(ep)
+	}
+}
+
+// Stop stops accepting events and waits for queued deliveries, abandoning them when ctx is done
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		for _, ep := range d.endpoints {
+			close(ep.queue)
+		}
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		d.cancel()
+		return ctx.Err()
+	}
+}
+
+// Emit queues an event for every subscriber that selected its type
+func (d *Dispatcher) Emit(event string, data interface{}) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return
+	}
+
+	var body []byte
+	id := ""
+	for _, ep := range d.endpoints {
+		if !ep.wants(event) {
+			continue
+		}
+		if body == nil {
+			id = uuid.New().String()
+			var err error
+			body, err = json.Marshal(Event{ID: id, Type: event, Timestamp: time.Now().UTC(), Data: data})
+			if err != nil {
+				d.logger.Error().Err(err).Str("event", event).Msg("failed to marshal webhook event")
+				return
+			}
+		}
+		select {
+		case ep.queue <- delivery{id: id, event: event, body: body}:
+		default:
+			metrics.Get().RecordWebhookDelivery(ep.Name, event, "dropped")
+			d.logger.Warn().Str("subscriber", ep.Name).Str("event", event).Msg("webhook queue full, event dropped")
+		}
+	}
+}
+
+// CallCompleted implements callqueue.CallObserver
+func (d *Dispatcher) CallCompleted(call types.Call) {
+	d.Emit(EventCallCompleted, call)
+}
+
+// CallAbandoned implements callqueue.CallObserver
+func (d *Dispatcher) CallAbandoned(call types.Call) {
+	d.Emit(EventCallAbandoned, call)
+}
+
+// AgentLoggedOn implements cache.PresenceObserver
+func (d *Dispatcher) AgentLoggedOn(agent types.AgentInfo) {
+	d.Emit(EventAgentLogon, agent)
+}
+
+// AgentLoggedOff implements cache.PresenceObserver
+func (d *Dispatcher) AgentLoggedOff(agent types.AgentInfo) {
+	d.Emit(EventAgentLogoff, agent)
+}
+
+// run delivers a subscriber's queue in order until it is closed
+func (d *Dispatcher) run(ep *endpoint) {
+	defer d.wg.Done()
+	for dl := range ep.queue {
+		result := "delivered"
+		if err := d.deliver(ep, dl); err != nil {
+			result = "failed"
+			d.logger.Warn().Err(err).
+				Str("subscriber", ep.Name).
+				Str("event", dl.event).
+				Str("delivery", dl.id).
+				Msg("webhook delivery failed")
+		}
+		metrics.Get().RecordWebhookDelivery(ep.Name, dl.event, result)
+	}
+}
+
+// deliver POSTs one event, retrying network errors, 429 and 5xx with exponential backoff
+func (d *Dispatcher) deliver(ep *endpoint, dl delivery) error {
+	var lastErr error
+	for attempt := 1; attempt <= ep.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-d.ctx.Done():
+				return lastErr
+			case <-time.After(d.backoff(attempt - 1)):
+			}
+		}
+
+		retry, err := d.post(ep, dl)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			return err
+		}
+	}
+	return fmt.Errorf("gave up after %d attempts: %w", ep.MaxAttempts, lastErr)
+}
+
+// post sends one request and reports whether a failure is worth retrying
+func (d *Dispatcher) post(ep *endpoint, dl delivery) (bool, error) {
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, ep.URL, bytes.NewReader(dl.body))
+	if err != nil {
+		return false, err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, dl.event)
+	req.Header.Set(HeaderDelivery, dl.id)
+	req.Header.Set(HeaderTimestamp, ts)
+	if ep.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(ep.Secret, ts, dl.body))
+	}
+
+	start := time.Now()
+	resp, err := d.client.Do(req)
+	metrics.Get().RecordWebhookAttempt(ep.Name, time.Since(start))
+	if err != nil {
+		return true, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("subscriber answered %d", resp.StatusCode)
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
+
+// Sign returns the X-Monti-Signature value: "sha256=" + hex HMAC-SHA256 of "<timestamp>.<body>".
+// Subscribers recompute it with their secret and should reject stale timestamps.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
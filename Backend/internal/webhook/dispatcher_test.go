@@ -0,0 +1,139 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+func TestDispatcherSignsAndRetries(t *testing.T) {
+	var mu sync.Mutex
+	var attempts int
+	var got []Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if want := Sign("s3cret", r.Header.Get(HeaderTimestamp), body); r.Header.Get(HeaderSignature) != want {
+			t.Errorf("expected signature %s, got %s", want, r.Header.Get(HeaderSignature))
+		}
+		var e Event
+		json.Unmarshal(body, &e)
+		got = append(got, e)
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher([]Subscriber{
+		{Name: "crm", URL: srv.URL, Secret: "s3cret", Events: []string{EventCallCompleted}},
+	}, zerolog.Nop())
+	d.backoff = func(int) time.Duration { return 0 }
+	d.Start()
+
+	d.CallAbandoned(types.Call{CallID: "ignored"})
+	d.CallCompleted(types.Call{CallID: "call-1", AgentID: "agent-1"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.Stop(ctx); err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+
+	if attempts != 2 {
+		t.Errorf("expected one retry after the 503, got %d attempts", attempts)
+	}
+	if len(got) != 1 || got[0].Type != EventCallCompleted {
+		t.Fatalf("expected one call.completed event, got %+v", got)
+	}
+	if data, _ := got[0].Data.(map[string]interface{}); data["callId"] != "call-1" {
+		t.Errorf("expected call-1 in payload, got %v", got[0].Data)
+	}
+}
+
+func TestDispatcherDoesNotRetryClientErrors(t *testing.T) {
+	var attempts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher([]Subscriber{{Name: "ticketing", URL: srv.URL}}, zerolog.Nop())
+	d.backoff = func(int) time.Duration { return 0 }
+	d.Start()
+	d.AgentLoggedOn(types.AgentInfo{AgentID: "agent-1"})
+	d.Stop(context.Background())
+
+	if attempts != 1 {
+		t.Errorf("expected a single attempt for 400, got %d", attempts)
+	}
+}
+
+func TestPresenceEvents(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		got = append(got, r.Header.Get(HeaderEvent))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher([]Subscriber{{Name: "crm", URL: srv.URL}}, zerolog.Nop())
+	d.Start()
+	tracker := cache.NewAgentStateTracker()
+	tracker.SetPresenceObserver(d)
+
+	reg := &types.AgentRegister{AgentID: "agent-1", Department: types.DeptSales, Location: types.LocationBerlin, State: types.StateAvailable}
+	tracker.RegisterAgent(reg)
+	tracker.RegisterAgent(reg) // re-register while logged on is not a new logon
+	tracker.SetDisconnected("agent-1")
+	tracker.SetDisconnected("agent-1")
+	tracker.RegisterAgent(reg)
+	d.Stop(context.Background())
+
+	want := []string{EventAgentLogon, EventAgentLogoff, EventAgentLogon}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d: expected %s, got %s", i, want[i], got[i])
+		}
+	}
+}
+
+func TestLoadFileRejectsInvalidSubscribers(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"relative url", `[{"name": "a", "url": "/hook"}]`},
+		{"unknown event", `[{"name": "a", "url": "https://crm.example.com/hook", "events": ["call.started"]}]`},
+		{"duplicate name", `[{"name": "a", "url": "https://x.example.com"}, {"name": "a", "url": "https://y.example.com"}]`},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "webhooks.json")
+		if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFile(path); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Package webhook delivers call and agent presence events to external subscribers (CRM,
+// ticketing) as signed JSON POSTs with retries.
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Event types
+const (
+	EventCallCompleted = "call.completed"
+	EventCallAbandoned = "call.abandoned"
+	EventAgentLogon    = "agent.logon"
+	EventAgentLogoff   = "agent.logoff"
+)
+
+// AllEvents lists every event type a subscriber can select
+var AllEvents = []string{EventCallCompleted, EventCallAbandoned, EventAgentLogon, EventAgentLogoff}
+
+// DefaultMaxAttempts is how often a delivery is tried before it counts as failed
+const DefaultMaxAttempts = 5
+
+// Subscriber is a webhook endpoint and the events it receives
+type Subscriber struct {
+	Name        string   `json:"name"`
+	URL         string   `json:"url"`
+	Secret      string   `json:"secret"`                // HMAC-SHA256 signing key; empty sends unsigned requests
+	Events      []string `json:"events,omitempty"`      // empty = all events
+	MaxAttempts int      `json:"maxAttempts,omitempty"` // 0 = DefaultMaxAttempts
+}
+
+// Event is the JSON body POSTed to subscribers
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// wants reports whether the subscriber selected the event type
+func (s *Subscriber) wants(event string) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, e := range s.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// validate checks the URL and event names
+func (s *Subscriber) validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http(s) URL")
+	}
+	for _, e := range s.Events {
+		known := false
+		for _, k := range AllEvents {
+			known = known || e == k
+		}
+		if !known {
+			return fmt.Errorf("unknown event %q", e)
+		}
+	}
+	if s.MaxAttempts < 0 {
+		return fmt.Errorf("maxAttempts must not be negative")
+	}
+	return nil
+}
+
+// LoadFile reads a JSON array of subscribers
+func LoadFile(path string) ([]Subscriber, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhooks: %w", err)
+	}
+	var subs []Subscriber
+	if err := json.Unmarshal(data, &subs); err != nil {
+		return nil, fmt.Errorf("failed to parse webhooks: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for i := range subs {
+		if err := subs[i].validate(); err != nil {
+			return nil, fmt.Errorf("webhook %d: %w", i, err)
+		}
+		if seen[subs[i].Name] {
+			return nil, fmt.Errorf("webhook %d: duplicate name %q", i, subs[i].Name)
+		}
+		seen[subs[i].Name] = true
+	}
+	return subs, nil
+}
@@ -24,11 +24,23 @@ const (
 	agentMaxMessageSize = 4096
 )
 
-// AgentClient represents a WebSocket connection from a simulated agent
+// AgentClient represents a WebSocket connection from a simulated agent or an agent desktop
 type AgentClient struct {
 	// Agent ID
 	agentID string
 
+	// Set for desktop clients: agentID comes from the token and messages may not name another agent
+	bound bool
+
+	// Desktop clients' tenant, from the token
+	tenant string
+
+	// Connection generation of the agent, issued when the connection registers
+	generation uint64
+
+	// Set for agents on a multiplexed connection, which shares its send channel
+	mux *MultiplexedAgentClient
+
 	// The hub this client belongs to
 	hub *AgentHub
 
@@ -44,8 +56,12 @@ type AgentClient struct {
 	// done channel to signal client shutdown
 	done chan struct{}
 
-	// closeOnce ensures send channel is closed only once
-	closeOnce sync.Once
+	// sendMu serializes sends with the close of send; closed is set once it is closed
+	sendMu sync.Mutex
+	closed bool
+
+	// Releases the hub's shutdown tracking when the write pump exits
+	untrack func()
 }
 
 // NewAgentClient creates a new AgentClient
@@ -63,6 +79,9 @@ func NewAgentClient(hub *AgentHub, conn *websocket.Conn, logger zerolog.Logger)
 func (c *AgentClient) readPump() {
 	defer func() {
 		close(c.done)
+		// Refuse sends before unregistering, so routing does not assign calls that never
+		// leave the buffer
+		c.Close()
 		c.hub.unregister <- c
 		c.conn.Close()
 	}()
@@ -105,15 +124,39 @@ func (c *AgentClient) handleMessage(message []byte) {
 			c.logger.Debug().Err(err).Msg("failed to parse register message")
 			return
 		}
-		c.agentID = reg.AgentID
-		c.logger = c.logger.With().Str("agent_id", c.agentID).Logger()
-		c.hub.agentRegister <- &reg
+		if c.bound {
+			if !c.ownMessage(&reg.AgentID) {
+				return
+			}
+			c.hub.fillFromRoster(&reg)
+			reg.Tenant = c.tenant
+		}
+		if !types.IsKnownTenant(reg.Tenant) {
+			c.logger.Warn().Str("tenant", reg.Tenant).Msg("register for unknown tenant rejected")
+			return
+		}
+		if err := c.hub.tracker.CheckTenant(reg.AgentID, reg.Tenant); err != nil {
+			c.logger.Warn().Err(err).Str("agent_id", reg.AgentID).Str("tenant", reg.Tenant).Msg("register rejected")
+			return
+		}
+		if c.agentID == "" {
+			// Simulated clients join the hub with their first registration
+			c.agentID = reg.AgentID
+			c.logger = c.logger.With().Str("agent_id", c.agentID).Logger()
+			c.generation = c.hub.nextGeneration(c.agentID)
+			c.hub.register <- c
+		}
+		reg.Generation = c.generationFor(reg.AgentID)
+		enqueueReliable(c.hub.agentRegister, &reg, queueRegister)
 
 		// Send acknowledgment (non-blocking, safe if client is closing)
-		ack := types.ServerAck{Type: "ack", AgentID: c.agentID}
+		ack := types.ServerAck{Type: "ack", AgentID: c.agentID, Generation: c.generation}
 		if data, err := json.Marshal(ack); err == nil {
 			c.safeSend(data)
 		}
+		if data := c.hub.throttleMessage(); data != nil {
+			c.safeSend(data)
+		}
 
 	case "heartbeat":
 		var hb types.AgentHeartbeat
@@ -121,7 +164,11 @@ func (c *AgentClient) handleMessage(message []byte) {
 			c.logger.Debug().Err(err).Msg("failed to parse heartbeat message")
 			return
 		}
-		c.hub.heartbeat <- &hb
+		if !c.ownMessage(&hb.AgentID) {
+			return
+		}
+		hb.Generation = c.generationFor(hb.AgentID)
+		c.hub.enqueueHeartbeat(&hb)
 
 	case "state_change":
 		var sc types.AgentStateChange
@@ -129,7 +176,11 @@ func (c *AgentClient) handleMessage(message []byte) {
 			c.logger.Debug().Err(err).Msg("failed to parse state_change message")
 			return
 		}
-		c.hub.stateChange <- &sc
+		if !c.ownMessage(&sc.AgentID) {
+			return
+		}
+		sc.Generation = c.generationFor(sc.AgentID)
+		enqueueReliable(c.hub.stateChange, &sc, queueStateChange)
 
 	case "call_complete":
 		var cc types.CallComplete
@@ -137,19 +188,72 @@ func (c *AgentClient) handleMessage(message []byte) {
 			c.logger.Debug().Err(err).Msg("failed to parse call_complete message")
 			return
 		}
-		c.hub.callComplete <- &cc
+		if !c.ownMessage(&cc.AgentID) {
+			return
+		}
+		cc.Generation = c.generationFor(cc.AgentID)
+		enqueueReliable(c.hub.callComplete, &cc, queueCallComplete)
+
+	case "call_hold_start", "call_hold_end":
+		var ch types.CallHold
+		if err := json.Unmarshal(message, &ch); err != nil {
+			c.logger.Debug().Err(err).Msg("failed to parse call hold message")
+			return
+		}
+		if !c.ownMessage(&ch.AgentID) {
+			return
+		}
+		ch.Generation = c.generationFor(ch.AgentID)
+		enqueueReliable(c.hub.callHold, &ch, queueCallHold)
+
+	case "break_request":
+		var req types.BreakRequest
+		if err := json.Unmarshal(message, &req); err != nil {
+			c.logger.Debug().Err(err).Msg("failed to parse break_request message")
+			return
+		}
+		if !c.ownMessage(&req.AgentID) {
+			return
+		}
+		if data := c.hub.requestBreak(&req); data != nil {
+			c.safeSend(data)
+		}
 
 	default:
 		c.logger.Debug().Str("type", msgType.Type).Msg("unknown message type")
 	}
 }
 
+// ownMessage fills in a bound client's agent ID and rejects messages naming another agent.
+// Simulated clients may send for any agent ID.
+func (c *AgentClient) ownMessage(agentID *string) bool {
+	if !c.bound {
+		return true
+	}
+	if *agentID != "" && *agentID != c.agentID {
+		c.logger.Warn().Str("claimed_agent_id", *agentID).Msg("desktop client sent a message for another agent")
+		return false
+	}
+	*agentID = c.agentID
+	return true
+}
+
+// generationFor returns the connection generation to tag a message for agentID with.
+// Messages a simulated client sends for other agents are untracked.
+func (c *AgentClient) generationFor(agentID string) uint64 {
+	if agentID != c.agentID {
+		return 0
+	}
+	return c.generation
+}
+
 // writePump pumps messages from the hub to the websocket connection
 func (c *AgentClient) writePump() {
 	ticker := time.NewTicker(agentPingPeriod)
 	defer func() {
 		ticker.Stop()
 		c.conn.Close()
+		c.untrack()
 	}()
 
 	for {
@@ -157,7 +261,7 @@ func (c *AgentClient) writePump() {
 		case message, ok := <-c.send:
 			c.conn.SetWriteDeadline(time.Now().Add(agentWriteWait))
 			if !ok {
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				c.conn.WriteMessage(websocket.CloseMessage, c.hub.drain.closeFrame())
 				return
 			}
 
@@ -176,33 +280,39 @@ func (c *AgentClient) writePump() {
 
 // Start starts the client's read and write pumps
 func (c *AgentClient) Start() {
+	c.untrack = c.hub.drain.track()
 	go c.writePump()
 	go c.readPump()
 }
 
-// Close safely closes the client's send channel (idempotent)
+// Close closes the client's send channel (idempotent). An agent on a multiplexed connection
+// shares the connection's channel, so closing it closes the connection.
 func (c *AgentClient) Close() {
-	c.closeOnce.Do(func() {
-		defer func() {
-			recover() // absorb panic if channel was already closed
-		}()
+	if c.mux != nil {
+		c.mux.Close()
+		return
+	}
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if !c.closed {
+		c.closed = true
 		close(c.send)
-	})
+	}
 }
 
-// safeSend attempts to send a message, recovering from panic if channel is closed
-func (c *AgentClient) safeSend(data []byte) (sent bool) {
-	defer func() {
-		if r := recover(); r != nil {
-			sent = false
-		}
-	}()
-
+// safeSend queues a message unless the client is closed or its buffer is full
+func (c *AgentClient) safeSend(data []byte) bool {
+	if c.mux != nil {
+		return c.mux.safeSend(data)
+	}
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.closed {
+		return false
+	}
 	select {
 	case c.send <- data:
 		return true
-	case <-c.done:
-		return false
 	default:
 		return false
 	}
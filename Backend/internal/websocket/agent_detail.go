@@ -0,0 +1,275 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// maxAgentSubscriptions caps how many agents one frontend client can drill into at once
+const maxAgentSubscriptions = 5
+
+var (
+	errAgentNotFound        = errors.New("agent not found")
+	errTooManySubscriptions = errors.New("too many agent subscriptions")
+)
+
+// SubscribeAgent subscribes client to agentID's detail stream and returns the agent's
+// state from the latest snapshot. Agents outside the client's scope are reported as not found.
+func (h *Hub) SubscribeAgent(client *Client, agentID string) (types.AgentInfo, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	agent, err := h.visibleAgent(client, agentID)
+	if err != nil {
+		return types.AgentInfo{}, err
+	}
+
+	if !h.subscriptions[agentID][client] {
+		count := 0
+		for _, clients := range h.subscriptions {
+			if clients[client] {
+				count++
+			}
+		}
+		if count >= maxAgentSubscriptions {
+			return types.AgentInfo{}, errTooManySubscriptions
+		}
+	}
+
+	if h.subscriptions[agentID] == nil {
+		h.subscriptions[agentID] = make(map[*Client]bool)
+		h.subscribedAlerts[agentID] = agent.Alerts
+	}
+	h.subscriptions[agentID][client] = true
+	return agent, nil
+}
+
+// visibleAgent returns agentID from the latest snapshot if it is within client's scope
+// (caller must hold h.mu)
+func (h *Hub) visibleAgent(client *Client, agentID string) (types.AgentInfo, error) {
+	agent, ok := findSnapshotAgent(h.latest, agentID)
+	if !ok || (client.claims != nil && !client.claims.IsAgentAllowed(agent.Tenant, agent.Location, agent.Department, agent.Team)) {
+		return types.AgentInfo{}, errAgentNotFound
+	}
+	return agent, nil
+}
+
+// UnsubscribeAgent removes client's subscription to agentID
+func (h *Hub) UnsubscribeAgent(client *Client, agentID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if clients, ok := h.subscriptions[agentID]; ok {
+		delete(clients, client)
+		if len(clients) == 0 {
+			delete(h.subscriptions, agentID)
+			delete(h.subscribedAlerts, agentID)
+		}
+	}
+}
+
+// removeSubscriptions drops all of client's subscriptions (caller must hold h.mu)
+func (h *Hub) removeSubscriptions(client *Client) {
+	for agentID, clients := range h.subscriptions {
+		delete(clients, client)
+		if len(clients) == 0 {
+			delete(h.subscriptions, agentID)
+			delete(h.subscribedAlerts, agentID)
+		}
+	}
+}
+
+// HasAgentSubscribers reports whether any client is subscribed to agentID
+func (h *Hub) HasAgentSubscribers(agentID string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscriptions[agentID]) > 0
+}
+
+// PublishAgentDetail sends detail to all clients subscribed to its agent.
+// The agent's alerts are taken from the last snapshot, since the tracker does not hold them.
+func (h *Hub) PublishAgentDetail(detail types.AgentDetail) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	clients := h.subscriptions[detail.Agent.AgentID]
+	if len(clients) == 0 {
+		return
+	}
+
+	detail.Type = "agent_detail"
+	detail.Agent.Alerts = h.subscribedAlerts[detail.Agent.AgentID]
+	data, err := json.Marshal(detail)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to marshal agent detail")
+		return
+	}
+	for client := range clients {
+		h.sendLocked(client, data)
+	}
+}
+
+// publishAlertChanges pushes an alerts detail for every subscribed agent whose alerts changed
+func (h *Hub) publishAlertChanges(snapshot *types.Snapshot) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.subscriptions) == 0 {
+		return
+	}
+
+	for _, data := range snapshot.Departments {
+		for _, agent := range data.Agents {
+			clients := h.subscriptions[agent.AgentID]
+			if len(clients) == 0 || alertsEqual(h.subscribedAlerts[agent.AgentID], agent.Alerts) {
+				continue
+			}
+			h.subscribedAlerts[agent.AgentID] = agent.Alerts
+
+			msg, err := json.Marshal(types.AgentDetail{
+				Type:      "agent_detail",
+				Event:     types.AgentDetailAlerts,
+				Timestamp: snapshot.Timestamp,
+				Agent:     agent,
+			})
+			if err != nil {
+				h.logger.Error().Err(err).Msg("failed to marshal agent detail")
+				continue
+			}
+			for client := range clients {
+				h.sendLocked(client, msg)
+			}
+		}
+	}
+}
+
+// sendToClient queues a message for one client, dropping it if the client is gone or its buffer is full
+func (h *Hub) sendToClient(client *Client, data []byte) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	h.sendLocked(client, data)
+}
+
+// sendLocked is sendToClient for callers already holding h.mu
+func (h *Hub) sendLocked(client *Client, data []byte) {
+	if !h.clients[client] {
+		return
+	}
+	select {
+	case client.send <- data:
+	default:
+		client.noteDropped()
+		h.logger.Warn().
+			Str("client_id", client.id).
+			Msg("client send buffer full, dropping agent detail")
+	}
+}
+
+// handleCommand processes a command message from the frontend client
+func (c *Client) handleCommand(message []byte) {
+	var cmd types.ClientCommand
+	if err := json.Unmarshal(message, &cmd); err != nil {
+		c.logger.Debug().Err(err).Msg("failed to parse client command")
+		return
+	}
+
+	result := types.ClientCommandResult{
+		Type:      "command_result",
+		Command:   cmd.Type,
+		RequestID: cmd.RequestID,
+		AgentID:   cmd.AgentID,
+		OK:        true,
+	}
+	var initial *types.AgentDetail
+	var err error
+
+	switch cmd.Type {
+	case "subscribe_agent":
+		var agent types.AgentInfo
+		if agent, err = c.hub.SubscribeAgent(c, cmd.AgentID); err != nil {
+			break
+		}
+		initial = &types.AgentDetail{
+			Type:      "agent_detail",
+			Event:     types.AgentDetailInitial,
+			Timestamp: time.Now(),
+			Agent:     agent,
+		}
+
+	case "unsubscribe_agent":
+		c.hub.UnsubscribeAgent(c, cmd.AgentID)
+
+	case "ack":
+		c.recordAck(cmd.Seq, time.Now())
+		return
+
+	case "hello":
+		v, ok := c.negotiateSchema(cmd.SchemaVersions)
+		if !ok {
+			result.OK = false
+			result.Error = unsupportedSchemaError()
+			break
+		}
+		result.SchemaVersion = v
+
+	case "get_snapshot":
+		result.Snapshot, err = c.latestSnapshot()
+
+	case "get_agent":
+		var agent types.AgentInfo
+		if agent, err = c.hub.Agent(c, cmd.AgentID); err == nil {
+			result.Agent = &agent
+		}
+
+	case "get_queue_history":
+		result.Intervals, err = c.queueHistory(cmd)
+
+	default:
+		c.logger.Debug().Str("type", cmd.Type).Msg("unknown client command")
+		return
+	}
+	if err != nil {
+		result.OK = false
+		result.Error = err.Error()
+	}
+
+	if data, err := json.Marshal(result); err == nil {
+		c.hub.sendToClient(c, data)
+	}
+	if initial != nil {
+		if data, err := json.Marshal(initial); err == nil {
+			c.hub.sendToClient(c, data)
+		}
+	}
+}
+
+// findSnapshotAgent looks up an agent in a snapshot
+func findSnapshotAgent(snapshot *types.Snapshot, agentID string) (types.AgentInfo, bool) {
+	if snapshot == nil {
+		return types.AgentInfo{}, false
+	}
+	for _, data := range snapshot.Departments {
+		for _, agent := range data.Agents {
+			if agent.AgentID == agentID {
+				return agent, true
+			}
+		}
+	}
+	return types.AgentInfo{}, false
+}
+
+// alertsEqual compares two alert lists by rule, lifecycle ID and status
+func alertsEqual(a, b []types.AgentAlert) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Rule != b[i].Rule || a[i].ID != b[i].ID || a[i].Status != b[i].Status {
+			return false
+		}
+	}
+	return true
+}
@@ -3,6 +3,8 @@ package websocket
 import (
 	"net/http"
 
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
 	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog"
 )
@@ -33,6 +35,11 @@ func NewAgentHandler(hub *AgentHub, logger zerolog.Logger) *AgentHandler {
 
 // ServeHTTP handles WebSocket upgrade requests from agents (single agent per connection)
 func (h *AgentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.hub.drain.isDraining() {
+		apierror.Write(w, r, http.StatusServiceUnavailable, "server shutting down")
+		return
+	}
+
 	conn, err := agentUpgrader.Upgrade(w, r, nil)
 	if err != nil {
 		h.logger.Error().Err(err).Msg("failed to upgrade agent connection")
@@ -42,10 +49,40 @@ func (h *AgentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Create new agent client
 	client := NewAgentClient(h.hub, conn, h.logger)
 
-	// Register client with hub
-	h.hub.register <- client
+	// Start client pumps; the client joins the hub with its register message
+	client.Start()
+}
+
+// ServeDesktopHTTP handles WebSocket upgrade requests from real agent desktops. The caller is
+// authenticated by the auth middleware and the agent ID is taken from its token, so a desktop
+// can only report its own state; the protocol is the same as for simulated agents.
+func (h *AgentHandler) ServeDesktopHTTP(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.GetUserFromContext(r.Context())
+	if !ok || claims.AgentID == "" {
+		apierror.Write(w, r, http.StatusForbidden, "token has no agent ID")
+		return
+	}
+	if h.hub.drain.isDraining() {
+		apierror.Write(w, r, http.StatusServiceUnavailable, "server shutting down")
+		return
+	}
 
-	// Start client pumps
+	conn, err := agentUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to upgrade agent desktop connection")
+		return
+	}
+
+	logger := h.logger.With().Str("agent_id", claims.AgentID).Str("client", "desktop").Logger()
+	client := NewAgentClient(h.hub, conn, logger)
+	client.agentID = claims.AgentID
+	client.bound = true
+	client.tenant = claims.Tenant
+	client.generation = h.hub.nextGeneration(claims.AgentID)
+
+	logger.Info().Str("user", claims.Email).Msg("agent desktop connected")
+
+	h.hub.register <- client
 	client.Start()
 }
 
@@ -59,6 +96,11 @@ func (h *AgentHandler) ServeMultiplexedHTTP(w http.ResponseWriter, r *http.Reque
 
 	// Create multiplexed client
 	client := NewMultiplexedAgentClient(h.hub, conn, h.logger)
+	if !h.hub.addMux(client) {
+		conn.WriteMessage(websocket.CloseMessage, h.hub.drain.closeFrame())
+		conn.Close()
+		return
+	}
 
 	// Start client pumps (registration happens per-agent via messages)
 	client.Start()
@@ -1,39 +1,79 @@
 package websocket
 
 import (
+	"context"
 	"encoding/json"
 	"sync"
+	"time"
 
 	"github.com/dennisdiepolder/monti/backend/internal/cache"
 	"github.com/dennisdiepolder/monti/backend/internal/ingestion"
 	"github.com/dennisdiepolder/monti/backend/internal/metrics"
+	"github.com/dennisdiepolder/monti/backend/internal/tracing"
 	"github.com/dennisdiepolder/monti/backend/internal/types"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// AgentDetailPublisher receives per-agent detail events for drill-down subscribers (implemented by Hub)
+type AgentDetailPublisher interface {
+	HasAgentSubscribers(agentID string) bool
+	PublishAgentDetail(detail types.AgentDetail)
+}
+
+// BreakGuard decides agents' break requests (implemented by breaks.Guard)
+type BreakGuard interface {
+	Request(req *types.BreakRequest) types.BreakResponse
+}
+
 // AgentHub maintains the set of active agent WebSocket connections
 type AgentHub struct {
 	// Registered agent clients
 	agents map[string]*AgentClient // agentID -> client
 
+	// Newest connection generation issued per agent (protected by mu)
+	generations map[string]uint64
+
+	// Generation of each agent's last connection that closed (protected by mu)
+	closed map[string]uint64
+
+	// Open multiplexed connections, closed on shutdown (protected by mu)
+	muxClients map[*MultiplexedAgentClient]bool
+
 	// Register requests from agent clients
 	register chan *AgentClient
 
 	// Unregister requests from agent clients
 	unregister chan *AgentClient
 
-	// Heartbeat messages from agents
+	// Heartbeat messages from agents; the oldest are shed when it is full
 	heartbeat chan *types.AgentHeartbeat
 
-	// State change messages from agents
+	// State change messages from agents; never dropped
 	stateChange chan *types.AgentStateChange
 
 	// Agent registration messages
 	agentRegister chan *types.AgentRegister
 
-	// Call complete messages from agents
+	// Call complete messages from agents; never dropped
 	callComplete chan *types.CallComplete
 
+	// Call hold start and end messages from agents; never dropped
+	callHold chan *types.CallHold
+
+	// Liveness probes answered by the Run loop
+	ping chan chan struct{}
+
+	// Shutdown draining of agent connections
+	drain drainState
+
+	// Heartbeats shed from the full queue since the last overload warning
+	shed shedLog
+
+	// Heartbeat throttle asked of agent connections while heartbeats are shed
+	throttle throttleState
+
 	// Mutex to protect agents map
 	mu sync.RWMutex
 
@@ -45,41 +85,123 @@ type AgentHub struct {
 
 	// Event processor (for processing agent events)
 	processor ingestion.EventProcessor
+
+	// Per-agent processing workers (nil = events are processed on the Run goroutine)
+	workers *ingestion.Workers
+
+	// Optional agent detail stream (nil = disabled)
+	detail AgentDetailPublisher
+
+	// Break policy (nil = every break is granted)
+	breaks BreakGuard
 }
 
 // NewAgentHub creates a new AgentHub
 func NewAgentHub(tracker *cache.AgentStateTracker, processor ingestion.EventProcessor, logger zerolog.Logger) *AgentHub {
 	return &AgentHub{
 		agents:        make(map[string]*AgentClient),
+		generations:   make(map[string]uint64),
+		closed:        make(map[string]uint64),
+		muxClients:    make(map[*MultiplexedAgentClient]bool),
 		register:      make(chan *AgentClient),
 		unregister:    make(chan *AgentClient),
 		heartbeat:     make(chan *types.AgentHeartbeat, 1000),
 		stateChange:   make(chan *types.AgentStateChange, 500),
 		agentRegister: make(chan *types.AgentRegister, 100),
 		callComplete:  make(chan *types.CallComplete, 500),
+		callHold:      make(chan *types.CallHold, 500),
+		ping:          make(chan chan struct{}),
 		logger:        logger,
 		tracker:       tracker,
 		processor:     processor,
 	}
 }
 
+// Ping succeeds once the Run loop has picked up the probe, proving it is not stuck
+func (h *AgentHub) Ping(ctx context.Context) error {
+	return pingLoop(ctx, h.ping)
+}
+
+// Shutdown sends every agent connection a server_shutdown close frame and waits until the
+// frames have been written or ctx is done. New connections are refused from then on.
+func (h *AgentHub) Shutdown(ctx context.Context) error {
+	if h.drain.begin() {
+		h.mu.Lock()
+		for _, client := range h.agents {
+			client.Close()
+		}
+		for client := range h.muxClients {
+			client.Close()
+		}
+		h.logger.Info().
+			Int("agents", len(h.agents)).
+			Int("multiplexed", len(h.muxClients)).
+			Msg("draining agent connections")
+		h.mu.Unlock()
+	}
+	return h.drain.wait(ctx)
+}
+
+// addMux tracks a multiplexed connection; it reports false once shutdown has begun
+func (h *AgentHub) addMux(client *MultiplexedAgentClient) bool {
+	if h.drain.isDraining() {
+		return false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.muxClients[client] = true
+	return true
+}
+
+// removeMux forgets a closed multiplexed connection
+func (h *AgentHub) removeMux(client *MultiplexedAgentClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.muxClients, client)
+}
+
+// MessageBacklog returns the combined length and capacity of the inbound message queues,
+// including those of the processing workers
+func (h *AgentHub) MessageBacklog() (int, int) {
+	length := len(h.heartbeat) + len(h.stateChange) + len(h.agentRegister) + len(h.callComplete) + len(h.callHold)
+	capacity := cap(h.heartbeat) + cap(h.stateChange) + cap(h.agentRegister) + cap(h.callComplete) + cap(h.callHold)
+	if h.workers != nil {
+		l, c := h.workers.Backlog()
+		length += l
+		capacity += c
+	}
+	return length, capacity
+}
+
 // Run starts the hub's main loop
 func (h *AgentHub) Run() {
 	m := metrics.Get()
+	go h.reportQueueDepths()
 
 	for {
 		select {
+		case reply := <-h.ping:
+			close(reply)
+
 		case client := <-h.register:
+			if h.drain.isDraining() {
+				client.Close()
+				continue
+			}
 			h.mu.Lock()
-			// Remove existing client with same agentID if any
-			if existing, ok := h.agents[client.agentID]; ok {
-				existing.Close()
-				delete(h.agents, client.agentID)
+			// Only the newest connection of an agent stays registered
+			if existing, ok := h.agents[client.agentID]; ok && existing != client {
+				if h.takeOver(existing, client) == existing {
+					h.mu.Unlock()
+					continue
+				}
 			}
 			h.agents[client.agentID] = client
 			h.mu.Unlock()
 
-			h.tracker.SetConnected(client.agentID, true)
+			// Connection status goes through the agent's worker to stay in order with its messages
+			agentID := client.agentID
+			h.dispatch(agentID, func() { h.tracker.SetConnected(agentID, true) })
 			m.RecordAgentConnect()
 
 			h.logger.Debug().
@@ -89,10 +211,13 @@ func (h *AgentHub) Run() {
 
 		case client := <-h.unregister:
 			h.mu.Lock()
-			if existing, ok := h.agents[client.agentID]; ok && existing == client {
+			existing, ok := h.agents[client.agentID]
+			// The multiplexed connection unregisters its agents by agent ID and generation
+			removed := ok && (existing == client || client.generation != 0 && existing.generation == client.generation)
+			if removed {
 				delete(h.agents, client.agentID)
+				h.closed[client.agentID] = existing.generation
 				client.Close()
-				h.tracker.SetDisconnected(client.agentID)
 				m.RecordAgentDisconnect()
 
 				h.logger.Debug().
@@ -102,21 +227,137 @@ func (h *AgentHub) Run() {
 			}
 			h.mu.Unlock()
 
+			// Outside mu: a full worker may be waiting for it in SendToAgent
+			if removed {
+				agentID := client.agentID
+				h.dispatch(agentID, func() { h.tracker.ConnectionLost(agentID) })
+			}
+
 		case reg := <-h.agentRegister:
-			h.processor.ProcessRegister(reg)
+			h.dispatchCurrent(reg.AgentID, reg.Generation, queueRegister, func() {
+				h.processor.ProcessRegister(reg)
+			})
 
 		case hb := <-h.heartbeat:
-			h.processor.ProcessHeartbeat(hb)
+			h.dispatchCurrent(hb.AgentID, hb.Generation, queueHeartbeat, func() {
+				h.processor.ProcessHeartbeat(hb)
+				h.publishDetail(types.AgentDetailHeartbeat, hb.AgentID, "")
+			})
 
 		case sc := <-h.stateChange:
-			h.processor.ProcessStateChange(sc)
+			h.dispatchCurrent(sc.AgentID, sc.Generation, queueStateChange, func() {
+				span := startMessageSpan("agent.state_change", sc.TraceParent,
+					attribute.String("agent_id", sc.AgentID),
+					attribute.String("state", string(sc.NewState)))
+				h.processor.ProcessStateChange(sc)
+				h.publishDetail(types.AgentDetailStateChange, sc.AgentID, "")
+				span.End()
+			})
 
 		case cc := <-h.callComplete:
-			h.processor.ProcessCallComplete(cc)
+			h.dispatchCurrent(cc.AgentID, cc.Generation, queueCallComplete, func() {
+				span := startMessageSpan("agent.call_complete", cc.TraceParent,
+					attribute.String("agent_id", cc.AgentID),
+					attribute.String("call_id", cc.CallID))
+				h.processor.ProcessCallComplete(cc)
+				h.publishDetail(types.AgentDetailCallComplete, cc.AgentID, cc.CallID)
+				span.End()
+			})
+
+		case ch := <-h.callHold:
+			h.dispatchCurrent(ch.AgentID, ch.Generation, queueCallHold, func() {
+				h.processor.ProcessCallHold(ch)
+				h.publishDetail(types.AgentDetailCallHold, ch.AgentID, ch.CallID)
+			})
 		}
 	}
 }
 
+// dispatch processes an agent's message on the agent's worker, or right away without workers
+func (h *AgentHub) dispatch(agentID string, process func()) {
+	if h.workers == nil {
+		process()
+		return
+	}
+	h.workers.Submit(agentID, process)
+}
+
+// startMessageSpan starts a consumer span for an agent message, continuing the sender's trace.
+// The span is linked from the next snapshot broadcast so the trace reaches the dashboard.
+func startMessageSpan(name, traceparent string, attrs ...attribute.KeyValue) trace.Span {
+	ctx := tracing.ContextFromTraceParent(context.Background(), traceparent)
+	ctx, span := tracing.Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attrs...))
+	tracing.AddPendingLink(ctx)
+	return span
+}
+
+// fillFromRoster completes a desktop registration with the department, location and team
+// the roster holds for the agent, so real clients only need to send their state
+func (h *AgentHub) fillFromRoster(reg *types.AgentRegister) {
+	known, ok := h.tracker.Get(reg.AgentID)
+	if !ok {
+		return
+	}
+	if reg.Department == "" {
+		reg.Department = known.Department
+	}
+	if reg.Location == "" {
+		reg.Location = known.Location
+	}
+	if reg.Team == "" {
+		reg.Team = known.Team
+	}
+}
+
+// SetDetailPublisher enables the per-agent detail stream (must be called before Run)
+func (h *AgentHub) SetDetailPublisher(p AgentDetailPublisher) {
+	h.detail = p
+}
+
+// SetWorkers spreads message processing over per-agent workers, keeping each agent's
+// messages in order (must be called before Run)
+func (h *AgentHub) SetWorkers(w *ingestion.Workers) {
+	h.workers = w
+}
+
+// SetBreakGuard enables break caps for break_request messages (must be called before Run)
+func (h *AgentHub) SetBreakGuard(g BreakGuard) {
+	h.breaks = g
+}
+
+// requestBreak answers a break_request; it runs on the client's read goroutine
+func (h *AgentHub) requestBreak(req *types.BreakRequest) []byte {
+	resp := types.BreakResponse{Type: "break_response", AgentID: req.AgentID, Reason: req.Reason.OrDefault(), Granted: true}
+	if h.breaks != nil {
+		resp = h.breaks.Request(req)
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to marshal break_response")
+		return nil
+	}
+	return data
+}
+
+// publishDetail forwards the agent's post-event tracker state to detail subscribers, if any
+func (h *AgentHub) publishDetail(event types.AgentDetailEvent, agentID, callID string) {
+	if h.detail == nil || !h.detail.HasAgentSubscribers(agentID) {
+		return
+	}
+	agent, ok := h.tracker.Get(agentID)
+	if !ok {
+		return
+	}
+	h.detail.PublishAgentDetail(types.AgentDetail{
+		Event:     event,
+		Timestamp: time.Now(),
+		Agent:     agent,
+		CallID:    callID,
+	})
+}
+
 // ForceEndCall sends a force_end_call message to the specified agent
 func (h *AgentHub) ForceEndCall(agentID, callID string) bool {
 	msg := types.ForceEndCall{
@@ -132,6 +373,21 @@ func (h *AgentHub) ForceEndCall(agentID, callID string) bool {
 	return h.SendToAgent(agentID, data)
 }
 
+// ForceState sends a force_state message to the specified agent
+func (h *AgentHub) ForceState(agentID string, state types.AgentState) bool {
+	msg := types.ForceState{
+		Type:    "force_state",
+		AgentID: agentID,
+		State:   state,
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to marshal force_state")
+		return false
+	}
+	return h.SendToAgent(agentID, data)
+}
+
 // ForceDisconnect sends a force_disconnect message to the agent, then closes the connection
 func (h *AgentHub) ForceDisconnect(agentID string) bool {
 	msg := types.ForceDisconnect{
@@ -152,6 +408,7 @@ func (h *AgentHub) ForceDisconnect(agentID string) bool {
 	client, ok := h.agents[agentID]
 	if ok {
 		delete(h.agents, agentID)
+		h.closed[agentID] = client.generation
 		client.Close()
 		h.tracker.SetDisconnected(agentID)
 		metrics.Get().RecordAgentDisconnect()
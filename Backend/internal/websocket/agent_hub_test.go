@@ -0,0 +1,53 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/ingestion"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+// completedCalls records the calls completed through the processor
+type completedCalls map[string]bool
+
+func (c completedCalls) CompleteCall(agentID, callID string, talkTime, holdTime float64) (*types.Call, bool) {
+	c[callID] = true
+	return &types.Call{CallID: callID, AgentID: agentID}, false
+}
+
+func (c completedCalls) HoldCall(agentID, callID string, onHold bool, at time.Time) *types.Call {
+	return nil
+}
+
+func TestHubAppliesNumberedMessagesAcrossChannels(t *testing.T) {
+	// One sender counter numbers all message types, but each type has its own channel and
+	// the hub picks among them in any order
+	for i := 0; i < 50; i++ {
+		tracker := cache.NewAgentStateTracker()
+		processor := ingestion.NewDefaultProcessor(tracker, zerolog.Nop())
+		completed := completedCalls{}
+		processor.SetCallCompleter(completed)
+		processor.ProcessRegister(&types.AgentRegister{AgentID: "a1", Department: types.DeptSales, State: types.StateOnCall, Seq: 1})
+
+		hub := NewAgentHub(tracker, processor, zerolog.Nop())
+		hub.callComplete <- &types.CallComplete{AgentID: "a1", CallID: "call-1", TalkTime: 60, Seq: 2}
+		hub.stateChange <- &types.AgentStateChange{AgentID: "a1", PreviousState: types.StateOnCall, NewState: types.StateAfterCallWork, Seq: 3}
+		hub.heartbeat <- &types.AgentHeartbeat{AgentID: "a1", State: types.StateAfterCallWork, Seq: 4}
+		go hub.Run()
+		for len(hub.callComplete)+len(hub.stateChange)+len(hub.heartbeat) > 0 {
+			time.Sleep(time.Millisecond)
+		}
+		reply := make(chan struct{})
+		hub.ping <- reply
+		<-reply
+
+		agent, _ := tracker.Get("a1")
+		if !completed["call-1"] || agent.State != types.StateAfterCallWork {
+			t.Fatalf("run %d: expected the call completed and the agent in ACW, got completed=%v state=%s",
+				i, completed["call-1"], agent.State)
+		}
+	}
+}
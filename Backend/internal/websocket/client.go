@@ -1,10 +1,16 @@
 package websocket
 
 import (
+	"fmt"
+	"slices"
+	"sync/atomic"
 	"time"
 
 	"github.com/dennisdiepolder/monti/backend/internal/auth"
 	"github.com/dennisdiepolder/monti/backend/internal/config"
+	"github.com/dennisdiepolder/monti/backend/internal/leaderboard"
+	"github.com/dennisdiepolder/monti/backend/internal/metrics"
+	"github.com/dennisdiepolder/monti/backend/internal/rollup"
 	"github.com/dennisdiepolder/monti/backend/internal/types"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
@@ -33,12 +39,40 @@ type Client struct {
 
 	// User claims with allowed locations for RBAC filtering
 	claims *auth.Claims
+
+	// User the connection slot is counted against
+	user string
+
+	// When the client connected
+	connectedAt time.Time
+
+	// Messages skipped because the send buffer was full
+	dropped atomic.Int64
+
+	// Sequence number of the last snapshot the client acknowledged
+	lastAck atomic.Int64
+
+	// Delivery tracking: snapshots queued and acknowledged, when the last ack arrived (unix
+	// nanos, 0 = never), the summed broadcast-to-ack latency of acks with a known broadcast
+	// time, and whether the client stopped acknowledging
+	sent            atomic.Int64
+	acked           atomic.Int64
+	lastAckAt       atomic.Int64
+	ackLatencySum   atomic.Int64
+	ackLatencyCount atomic.Int64
+	stalled         atomic.Bool
+
+	// Snapshot schema version the client receives, negotiated on connect or by "hello"
+	schemaVersion atomic.Int32
+
+	// Releases the hub's shutdown tracking when the write pump exits
+	untrack func()
 }
 
 // NewClient creates a new Client
 func NewClient(hub *Hub, conn *websocket.Conn, cfg *config.Config, logger zerolog.Logger, claims *auth.Claims) *Client {
 	clientID := uuid.New().String()
-	return &Client{
+	c := &Client{
 		id:     clientID,
 		hub:    hub,
 		conn:   conn,
@@ -46,7 +80,42 @@ func NewClient(hub *Hub, conn *websocket.Conn, cfg *config.Config, logger zerolo
 		config: cfg,
 		logger: logger.With().Str("client_id", clientID).Logger(),
 		claims: claims,
+		user:   connectionUser(claims),
+
+		connectedAt: time.Now(),
+	}
+	// Dashboards that declare no versions predate versioning
+	c.schemaVersion.Store(types.SnapshotSchemaV1)
+	return c
+}
+
+// negotiateSchema switches the client to the newest snapshot schema version it supports;
+// false when the server supports none of them
+func (c *Client) negotiateSchema(supported []int) (int, bool) {
+	v, ok := types.NegotiateSchemaVersion(supported)
+	if ok {
+		c.schemaVersion.Store(int32(v))
 	}
+	return v, ok
+}
+
+// unsupportedSchemaError describes a handshake without a version the server supports
+func unsupportedSchemaError() string {
+	return fmt.Sprintf("no supported snapshot schema version (server supports %d-%d)", types.SnapshotSchemaV1, types.SnapshotSchemaVersion)
+}
+
+// role is the client's user role, "anonymous" without claims
+func (c *Client) role() string {
+	if c.claims == nil || c.claims.Role == "" {
+		return "anonymous"
+	}
+	return c.claims.Role
+}
+
+// noteDropped counts a message skipped because the client's send buffer was full
+func (c *Client) noteDropped() {
+	c.dropped.Add(1)
+	metrics.Get().RecordWebSocketDropped(c.role())
 }
 
 // readPump pumps messages from the websocket connection to the hub
@@ -58,6 +127,7 @@ func (c *Client) readPump() {
 	defer func() {
 		c.hub.unregister <- c
 		c.conn.Close()
+		c.hub.releaseConnection(c.user)
 	}()
 
 	c.conn.SetReadDeadline(time.Now().Add(c.config.PongWait))
@@ -75,6 +145,7 @@ func (c *Client) readPump() {
 			break
 		}
 		c.logger.Debug().Str("message", string(message)).Msg("received message from client")
+		c.handleCommand(message)
 	}
 }
 
@@ -88,6 +159,7 @@ func (c *Client) writePump() {
 	defer func() {
 		ticker.Stop()
 		c.conn.Close()
+		c.untrack()
 	}()
 
 	for {
@@ -96,7 +168,7 @@ func (c *Client) writePump() {
 			c.conn.SetWriteDeadline(time.Now().Add(c.config.WriteWait))
 			if !ok {
 				// The hub closed the channel
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				c.conn.WriteMessage(websocket.CloseMessage, c.hub.drain.closeFrame())
 				return
 			}
 
@@ -128,11 +200,12 @@ func (c *Client) writePump() {
 
 // Start starts the client's read and write pumps
 func (c *Client) Start() {
+	c.untrack = c.hub.drain.track()
 	go c.writePump()
 	go c.readPump()
 }
 
-// FilterWidget filters a widget's agents based on the client's allowed locations
+// FilterWidget filters a widget's agents based on the client's location/department/team scope
 // Returns nil if no agents are visible to this client
 func (c *Client) FilterWidget(widget *types.Widget) *types.Widget {
 	// If no claims or no agents, return as-is
@@ -140,15 +213,15 @@ func (c *Client) FilterWidget(widget *types.Widget) *types.Widget {
 		return widget
 	}
 
-	// If user has all locations (admin), return original widget
-	if len(c.claims.AllowedLocations) == len(types.AllLocations) {
+	// If user sees everything (admin), return original widget
+	if c.claims.IsUnrestricted() {
 		return widget
 	}
 
-	// Filter agents by allowed locations
+	// Filter agents by scope
 	var filteredAgents []types.AgentInfo
 	for _, agent := range widget.Agents {
-		if c.claims.IsLocationAllowed(agent.Location) {
+		if c.claims.IsAgentAllowed(agent.Tenant, agent.Location, agent.Department, agent.Team) {
 			filteredAgents = append(filteredAgents, agent)
 		}
 	}
@@ -179,46 +252,146 @@ func (c *Client) FilterWidget(widget *types.Widget) *types.Widget {
 			LocationBreakdown:   locationBreakdown,
 		},
 		Agents: filteredAgents,
+		Queues: widget.Queues, // the client sees agents of this widget, so its queues too
 	}
 
 	return filteredWidget
 }
 
-// FilterSnapshot filters a snapshot's agents per department based on the client's allowed locations.
-// Queues are always sent unfiltered. Returns the snapshot (possibly filtered).
+// FilterSnapshot filters a snapshot's agents per department based on the client's scope.
+// Queues, queue trends and queue/department alerts are only kept for departments with
+// visible agents, and queues only of the client's tenant; rollups and leaderboards are
+// recomputed from the visible agents, and team alerts are limited to teams with at least
+// one visible agent.
+// While the backend sheds load at summary level, departments are reduced to summaries of the
+// visible agents; dashboards on schema version 1 cannot show those and keep getting agents.
+// Returns the snapshot (possibly filtered).
 func (c *Client) FilterSnapshot(snapshot *types.Snapshot) *types.Snapshot {
+	version := int(c.schemaVersion.Load())
+	filtered := FilterSnapshot(snapshot, c.claims)
+	if version >= types.SnapshotSchemaV2 {
+		filtered = filtered.SummaryOnly()
+	}
+	return filtered.ForSchemaVersion(version)
+}
+
+// FilterSnapshot applies location/department/team RBAC for the given claims (see Client.FilterSnapshot)
+func FilterSnapshot(snapshot *types.Snapshot, claims *auth.Claims) *types.Snapshot {
 	// If no claims, return as-is
-	if c.claims == nil {
+	if claims == nil {
 		return snapshot
 	}
 
-	// If user has all locations (admin), return original
-	if len(c.claims.AllowedLocations) == len(types.AllLocations) {
+	// If user sees everything (admin), return original
+	if claims.IsUnrestricted() {
 		return snapshot
 	}
 
-	// Filter agents per department by allowed locations, keep queues as-is
+	// Drop departments outside the scope and filter agents per department. Queues are only
+	// kept for departments where the client sees agents, so users restricted to other
+	// units never see their SL and volumes.
+	queuesVisible := make(map[types.Department]bool, len(snapshot.Departments))
 	filtered := &types.Snapshot{
-		Type:        snapshot.Type,
-		Timestamp:   snapshot.Timestamp,
-		Departments: make(map[types.Department]*types.DepartmentData, len(snapshot.Departments)),
+		Type:          snapshot.Type,
+		SchemaVersion: snapshot.SchemaVersion,
+		Timestamp:     snapshot.Timestamp,
+		Seq:           snapshot.Seq,
+		Departments:   make(map[types.Department]*types.DepartmentData, len(snapshot.Departments)),
+		Degradation:   snapshot.Degradation,
 	}
 
 	for dept, data := range snapshot.Departments {
+		if !claims.IsDepartmentAllowed(dept) {
+			continue
+		}
 		var filteredAgents []types.AgentInfo
 		for _, agent := range data.Agents {
-			if c.claims.IsLocationAllowed(agent.Location) {
+			if claims.IsAgentAllowed(agent.Tenant, agent.Location, agent.Department, agent.Team) {
 				filteredAgents = append(filteredAgents, agent)
 			}
 		}
 		if filteredAgents == nil {
 			filteredAgents = []types.AgentInfo{}
 		}
+		queues := []types.VQSnapshot{}
+		var channels []types.ChannelOccupancy
+		if len(filteredAgents) > 0 {
+			for _, q := range data.Queues {
+				if claims.IsTenantAllowed(q.Tenant) {
+					queues = append(queues, q)
+				}
+			}
+			for _, c := range data.Channels {
+				if claims.IsTenantAllowed(c.Tenant) {
+					channels = append(channels, c)
+				}
+			}
+			queuesVisible[dept] = true
+		}
 		filtered.Departments[dept] = &types.DepartmentData{
-			Agents: filteredAgents,
-			Queues: data.Queues,
+			Agents:   filteredAgents,
+			Queues:   queues,
+			Channels: channels,
+		}
+		if len(data.Leaderboards) > 0 {
+			filtered.Departments[dept].Leaderboards = leaderboard.Build(filteredAgents, leaderboard.SizeOf(data.Leaderboards))
+		}
+	}
+
+	if len(snapshot.Rollups) > 0 {
+		filtered.Rollups = rollup.Build(filtered.Departments, rollup.Dimensions(snapshot.Rollups))
+		rollup.CopySupervisors(filtered.Rollups, snapshot.Rollups)
+	}
+	for _, a := range snapshot.Announcements {
+		if AnnouncementVisible(a, claims) {
+			filtered.Announcements = append(filtered.Announcements, a)
+		}
+	}
+	// Trends are computed for the whole instance; only the default tenant gets them
+	if snapshot.Trends != nil && claims.IsTenantAllowed(types.DefaultTenant) {
+		filtered.Trends = snapshot.Trends.QueueOnly(queuesVisible)
+	}
+	for _, alert := range snapshot.Alerts {
+		if !claims.IsTenantAllowed(alert.Tenant) {
+			continue
+		}
+		visible := queuesVisible[alert.Department]
+		if alert.Scope == types.AlertScopeTeam {
+			visible = hasTeam(filtered.Departments[alert.Department], alert.Target)
+		}
+		if visible {
+			filtered.Alerts = append(filtered.Alerts, alert)
 		}
 	}
 
 	return filtered
 }
+
+// AnnouncementVisible reports whether an announcement is addressed to a dashboard with the
+// given claims: it must be of the user's tenant, and of one of the user's departments and
+// locations where it names any
+func AnnouncementVisible(a types.Announcement, claims *auth.Claims) bool {
+	if claims == nil {
+		return true
+	}
+	if !claims.IsTenantAllowed(a.Tenant) {
+		return false
+	}
+	if len(a.Departments) > 0 && !slices.ContainsFunc(a.Departments, claims.IsDepartmentAllowed) {
+		return false
+	}
+	return len(a.Locations) == 0 || slices.ContainsFunc(a.Locations, claims.IsLocationAllowed)
+}
+
+// hasTeam reports whether any agent in the department data belongs to team
+func hasTeam(data *types.DepartmentData, team string) bool {
+	if data == nil {
+		return false
+	}
+	for _, agent := range data.Agents {
+		if agent.Team == team {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+func TestFilterSnapshotTeamScope(t *testing.T) {
+	snapshot := &types.Snapshot{
+		Type: "snapshot",
+		Departments: map[types.Department]*types.DepartmentData{
+			types.DeptSales: {Agents: []types.AgentInfo{
+				{AgentID: "a1", Location: types.LocationBerlin, Department: types.DeptSales, Team: "alpha"},
+				{AgentID: "a2", Location: types.LocationBerlin, Department: types.DeptSales, Team: "beta"},
+			}},
+			types.DeptSupport: {Agents: []types.AgentInfo{
+				{AgentID: "a3", Location: types.LocationBerlin, Department: types.DeptSupport, Team: "alpha"},
+			}},
+		},
+		Alerts: []types.Alert{
+			{Scope: types.AlertScopeTeam, Target: "beta", Department: types.DeptSales},
+			{Scope: types.AlertScopeDepartment, Target: string(types.DeptSupport), Department: types.DeptSupport},
+		},
+	}
+
+	claims := &auth.Claims{
+		AllowedLocations: types.AllLocations,
+		Teams:            []string{"alpha"},
+		Departments:      []types.Department{types.DeptSales},
+	}
+	filtered := FilterSnapshot(snapshot, claims)
+
+	if _, ok := filtered.Departments[types.DeptSupport]; ok {
+		t.Error("expected support department to be dropped")
+	}
+	agents := filtered.Departments[types.DeptSales].Agents
+	if len(agents) != 1 || agents[0].AgentID != "a1" {
+		t.Errorf("expected only a1, got %+v", agents)
+	}
+	if len(filtered.Alerts) != 0 {
+		t.Errorf("expected out-of-scope alerts to be dropped, got %+v", filtered.Alerts)
+	}
+
+	// Location-only claims covering everything see the original snapshot
+	if got := FilterSnapshot(snapshot, &auth.Claims{AllowedLocations: types.AllLocations}); got != snapshot {
+		t.Error("expected unrestricted claims to return the original snapshot")
+	}
+}
+
+func TestFilterSnapshotQueueVisibility(t *testing.T) {
+	queues := []types.VQSnapshot{{VQ: types.VQSalesInbound, Department: types.DeptSales}}
+	snapshot := &types.Snapshot{
+		Type: "snapshot",
+		Departments: map[types.Department]*types.DepartmentData{
+			types.DeptSales: {
+				Agents: []types.AgentInfo{{AgentID: "a1", Location: types.LocationMunich, Department: types.DeptSales}},
+				Queues: queues,
+			},
+		},
+		Alerts: []types.Alert{{Scope: types.AlertScopeQueue, Target: string(types.VQSalesInbound), Department: types.DeptSales}},
+	}
+
+	// Berlin-only user sees no sales agents, so no sales queues or queue alerts either
+	filtered := FilterSnapshot(snapshot, &auth.Claims{AllowedLocations: []types.Location{types.LocationBerlin}})
+	if len(filtered.Departments[types.DeptSales].Queues) != 0 {
+		t.Error("expected queues to be hidden without visible agents")
+	}
+	if len(filtered.Alerts) != 0 {
+		t.Error("expected queue alerts to be hidden without visible agents")
+	}
+
+	filtered = FilterSnapshot(snapshot, &auth.Claims{AllowedLocations: []types.Location{types.LocationMunich}})
+	if len(filtered.Departments[types.DeptSales].Queues) != 1 || len(filtered.Alerts) != 1 {
+		t.Error("expected queues and queue alerts to be visible with visible agents")
+	}
+}
+
+func TestFilterSnapshotAlertTenant(t *testing.T) {
+	snapshot := &types.Snapshot{
+		Type: "snapshot",
+		Departments: map[types.Department]*types.DepartmentData{
+			types.DeptSales: {
+				Agents: []types.AgentInfo{
+					{AgentID: "a1", Location: types.LocationMunich, Department: types.DeptSales},
+					{AgentID: "a2", Tenant: "acme", Location: types.LocationMunich, Department: types.DeptSales},
+				},
+			},
+		},
+		Alerts: []types.Alert{
+			{ID: "default", Scope: types.AlertScopeDepartment, Target: string(types.DeptSales), Department: types.DeptSales},
+			{ID: "acme", Scope: types.AlertScopeDepartment, Target: string(types.DeptSales), Department: types.DeptSales, Tenant: "acme"},
+		},
+	}
+
+	for _, tenant := range []string{types.DefaultTenant, "acme"} {
+		filtered := FilterSnapshot(snapshot, &auth.Claims{Tenant: tenant, AllowedLocations: []types.Location{types.LocationMunich}})
+		if len(filtered.Alerts) != 1 || filtered.Alerts[0].ID != tenant {
+			t.Errorf("expected %s to see only its alert, got %+v", tenant, filtered.Alerts)
+		}
+	}
+}
+
+func TestFilterSnapshotAnnouncements(t *testing.T) {
+	snapshot := &types.Snapshot{
+		Type: "snapshot",
+		Announcements: []types.Announcement{
+			{ID: "all", Text: "Maintenance at 22:00"},
+			{ID: "sales", Text: "New promo", Departments: []types.Department{types.DeptSales}},
+			{ID: "munich", Text: "Storm warning", Locations: []types.Location{types.LocationMunich}},
+			{ID: "other-tenant", Text: "Hello", Tenant: "acme"},
+		},
+	}
+
+	filtered := FilterSnapshot(snapshot, &auth.Claims{
+		AllowedLocations: []types.Location{types.LocationBerlin},
+		Departments:      []types.Department{types.DeptSales},
+	})
+	var ids []string
+	for _, a := range filtered.Announcements {
+		ids = append(ids, a.ID)
+	}
+	if len(ids) != 2 || ids[0] != "all" || ids[1] != "sales" {
+		t.Errorf("expected announcements [all sales], got %v", ids)
+	}
+}
+
+func TestClientSnapshotSchemaNegotiation(t *testing.T) {
+	snapshot := &types.Snapshot{
+		Type:          "snapshot",
+		SchemaVersion: types.SnapshotSchemaVersion,
+		Announcements: []types.Announcement{{ID: "all", Text: "Maintenance at 22:00"}},
+	}
+	client := NewClient(NewHub(zerolog.Nop()), nil, nil, zerolog.Nop(), nil)
+
+	// Dashboards that declare no versions get the pre-versioning payload
+	legacy := client.FilterSnapshot(snapshot)
+	if legacy.SchemaVersion != 0 || legacy.Announcements != nil {
+		t.Errorf("expected a version 1 snapshot without schemaVersion and announcements, got %+v", legacy)
+	}
+	if snapshot.Announcements == nil {
+		t.Fatal("down-converting must not modify the shared snapshot")
+	}
+
+	if v, ok := client.negotiateSchema([]int{1, 2, 99}); !ok || v != types.SnapshotSchemaVersion {
+		t.Fatalf("expected version %d to be negotiated, got %d (ok=%v)", types.SnapshotSchemaVersion, v, ok)
+	}
+	if current := client.FilterSnapshot(snapshot); current != snapshot {
+		t.Error("expected the current version to be sent unchanged")
+	}
+
+	if _, ok := client.negotiateSchema([]int{99}); ok {
+		t.Error("expected no version to be negotiated from unsupported versions")
+	}
+	if v := client.schemaVersion.Load(); v != types.SnapshotSchemaVersion {
+		t.Errorf("expected a failed handshake to keep version %d, got %d", types.SnapshotSchemaVersion, v)
+	}
+}
+
+func TestClientSnapshotSummaryOnly(t *testing.T) {
+	snapshot := &types.Snapshot{
+		Type:          "snapshot",
+		SchemaVersion: types.SnapshotSchemaVersion,
+		Departments: map[types.Department]*types.DepartmentData{
+			types.DeptSales: {Agents: []types.AgentInfo{
+				{AgentID: "a1", State: types.StateAvailable, Location: types.LocationBerlin},
+				{AgentID: "a2", State: types.StateOnCall, Location: types.LocationBerlin},
+			}},
+		},
+		Degradation: &types.Degradation{Level: types.DegradationSummary},
+	}
+	client := NewClient(NewHub(zerolog.Nop()), nil, nil, zerolog.Nop(), nil)
+
+	// Version 1 dashboards cannot show summaries and keep getting agents
+	if legacy := client.FilterSnapshot(snapshot); len(legacy.Departments[types.DeptSales].Agents) != 2 || legacy.Degradation != nil {
+		t.Errorf("expected agents without degradation for version 1, got %+v", legacy.Departments[types.DeptSales])
+	}
+
+	client.negotiateSchema([]int{types.SnapshotSchemaVersion})
+	sales := client.FilterSnapshot(snapshot).Departments[types.DeptSales]
+	if len(sales.Agents) != 0 || sales.Summary == nil || sales.Summary.TotalAgents != 2 || sales.Summary.StateBreakdown[types.StateOnCall] != 1 {
+		t.Errorf("expected a summary instead of agents, got %+v", sales)
+	}
+	if len(snapshot.Departments[types.DeptSales].Agents) != 2 {
+		t.Fatal("summarizing must not modify the shared snapshot")
+	}
+}
@@ -0,0 +1,44 @@
+package websocket
+
+import (
+	"encoding/json"
+	"slices"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// SendControl queues a control message for the clients matching any of clientIDs or roles, or
+// for every client when both are empty. Clients whose send buffer is full miss it.
+func (h *Hub) SendControl(msg types.ControlMessage, clientIDs, roles []string) (delivered, dropped int) {
+	msg.Type = "control"
+	data, err := json.Marshal(msg)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to marshal control message")
+		return 0, 0
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for client := range h.clients {
+		if !client.targeted(clientIDs, roles) {
+			continue
+		}
+		select {
+		case client.send <- data:
+			delivered++
+		default:
+			client.noteDropped()
+			dropped++
+		}
+	}
+	return delivered, dropped
+}
+
+// targeted reports whether the client is one of clientIDs or has one of roles; everyone is
+// targeted when both are empty
+func (c *Client) targeted(clientIDs, roles []string) bool {
+	if len(clientIDs) == 0 && len(roles) == 0 {
+		return true
+	}
+	return slices.Contains(clientIDs, c.id) || slices.Contains(roles, c.role())
+}
@@ -0,0 +1,90 @@
+package websocket
+
+import (
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/metrics"
+)
+
+// ackWindow is how many recent broadcasts are remembered to measure ack latency; acks of
+// older snapshots are counted without a latency
+const ackWindow = 64
+
+// defaultAckStallAfter is how long an acknowledging client may go without an ack before
+// it counts as stalled
+const defaultAckStallAfter = 30 * time.Second
+
+// sentSnapshot is when a snapshot was queued for the clients
+type sentSnapshot struct {
+	seq int64
+	at  time.Time
+}
+
+// SetAckStallAfter sets how long a client that acknowledged snapshots may go without an ack
+// while snapshots keep coming before it counts as stalled, e.g. a frozen browser tab
+func (h *Hub) SetAckStallAfter(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ackStallAfter = d
+}
+
+// recordSent remembers when snapshot seq was queued (caller must hold h.mu)
+func (h *Hub) recordSent(seq int64, at time.Time) {
+	h.sent[seq%ackWindow] = sentSnapshot{seq: seq, at: at}
+}
+
+// sentAt returns when snapshot seq was queued, if it is recent enough to be remembered
+func (h *Hub) sentAt(seq int64) (time.Time, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	s := h.sent[seq%ackWindow]
+	return s.at, s.seq == seq && seq > 0
+}
+
+// latestSeq returns the seq of the latest broadcast snapshot, 0 before the first
+func (h *Hub) latestSeq() int64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.latest == nil {
+		return 0
+	}
+	return h.latest.Seq
+}
+
+// recordAck counts an acknowledged snapshot and its latency since the broadcast. Repeated
+// or out-of-order acks are ignored, and so are acks of snapshots not broadcast yet.
+func (c *Client) recordAck(seq int64, now time.Time) {
+	if seq <= c.lastAck.Load() || seq > c.hub.latestSeq() {
+		return
+	}
+	c.lastAck.Store(seq)
+	c.acked.Add(1)
+	c.lastAckAt.Store(now.UnixNano())
+
+	if at, ok := c.hub.sentAt(seq); ok {
+		latency := now.Sub(at)
+		c.ackLatencySum.Add(int64(latency))
+		c.ackLatencyCount.Add(1)
+		metrics.Get().RecordWebSocketAckLatency(c.role(), latency)
+	}
+	if c.stalled.Swap(false) {
+		c.logger.Info().Int64("seq", seq).Msg("dashboard acknowledges snapshots again")
+	}
+}
+
+// checkStalled reports whether the client acknowledged snapshots before but not within
+// stallAfter, logging when it becomes stalled. Clients that never acknowledged are not tracked.
+func (c *Client) checkStalled(now time.Time, stallAfter time.Duration) bool {
+	last := c.lastAckAt.Load()
+	if last == 0 || now.Sub(time.Unix(0, last)) <= stallAfter {
+		return false
+	}
+	if !c.stalled.Swap(true) {
+		c.logger.Warn().
+			Str("user", c.user).
+			Int64("last_ack_seq", c.lastAck.Load()).
+			Dur("since_last_ack", now.Sub(time.Unix(0, last))).
+			Msg("dashboard stopped acknowledging snapshots")
+	}
+	return true
+}
@@ -2,9 +2,13 @@ package websocket
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
 	"github.com/dennisdiepolder/monti/backend/internal/auth"
 	"github.com/dennisdiepolder/monti/backend/internal/config"
+	"github.com/dennisdiepolder/monti/backend/internal/metrics"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
 	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog"
 )
@@ -40,6 +44,26 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Extract user claims from context (set by auth middleware)
 	claims, _ := auth.GetUserFromContext(r.Context())
 
+	if h.hub.drain.isDraining() {
+		apierror.Write(w, r, http.StatusServiceUnavailable, "server shutting down")
+		return
+	}
+
+	// Snapshot schema versions the dashboard understands, negotiated before the history is sent
+	var schemaVersions []int
+	if v := r.URL.Query().Get("schemaVersions"); v != "" {
+		versions, err := types.ParseSchemaVersions(v)
+		if err != nil {
+			apierror.Write(w, r, http.StatusBadRequest, "schemaVersions must be a comma-separated list of integers")
+			return
+		}
+		if _, ok := types.NegotiateSchemaVersion(versions); !ok {
+			apierror.Write(w, r, http.StatusBadRequest, unsupportedSchemaError())
+			return
+		}
+		schemaVersions = versions
+	}
+
 	// Upgrade HTTP connection to WebSocket
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -49,6 +73,24 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// Create new client with claims for RBAC filtering
 	client := NewClient(h.hub, conn, h.config, h.logger, claims)
+	if schemaVersions != nil {
+		client.negotiateSchema(schemaVersions)
+	}
+
+	// Enforce per-user and total connection caps. The upgrade happens first so the
+	// browser sees a close code instead of an opaque handshake failure.
+	if err := h.hub.acquireConnection(client.user); err != nil {
+		metrics.Get().RecordWebSocketRejected()
+		h.logger.Warn().
+			Err(err).
+			Str("user", client.user).
+			Msg("rejecting WebSocket connection")
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(connectionCloseCode(err), err.Error()),
+			time.Now().Add(h.config.WriteWait))
+		conn.Close()
+		return
+	}
 
 	// Log connection with user info
 	if claims != nil {
@@ -57,6 +99,7 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			Str("role", claims.Role).
 			Strs("business_units", claims.BusinessUnits).
 			Int("allowed_locations", len(claims.AllowedLocations)).
+			Strs("teams", claims.Teams).
 			Msg("WebSocket client connected with RBAC context")
 	}
 
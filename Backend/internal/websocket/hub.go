@@ -1,14 +1,20 @@
 package websocket
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/dennisdiepolder/monti/backend/internal/metrics"
 	"github.com/dennisdiepolder/monti/backend/internal/types"
 	"github.com/rs/zerolog"
 )
 
+// maxSnapshotHistory is the default number of snapshots replayed to new clients
 const maxSnapshotHistory = 300
 
 // Hub maintains the set of active clients and broadcasts messages to the clients
@@ -25,11 +31,48 @@ type Hub struct {
 	// Unregister requests from clients
 	unregister chan *Client
 
+	// Liveness probes answered by the Run loop
+	ping chan chan struct{}
+
+	// Shutdown draining of client connections
+	drain drainState
+
 	// Mutex to protect clients map
 	mu sync.RWMutex
 
-	// Ring buffer of recent snapshots (max maxSnapshotHistory)
+	// Ring buffer of recent snapshots (max historySize)
 	snapshotHistory []*types.Snapshot
+	historySize     int
+
+	// Most recent snapshot for REST polling (protected by mu)
+	latest *types.Snapshot
+
+	// Sequence number of the last snapshot broadcast (Run loop only)
+	seq int64
+
+	// How long the last snapshot fan-out took, in nanoseconds (read by load shedding)
+	lastFanOut atomic.Int64
+
+	// Agent detail subscriptions: agentID -> subscribed clients (protected by mu)
+	subscriptions map[string]map[*Client]bool
+
+	// Last seen alerts of subscribed agents, used to detect changes (protected by mu)
+	subscribedAlerts map[string][]types.AgentAlert
+
+	// Source of get_queue_history commands; nil = unavailable (protected by mu)
+	queueHistory QueueHistoryFunc
+
+	// When recent snapshots were queued, by seq % ackWindow, and how long an acknowledging
+	// client may go without an ack (protected by mu)
+	sent          [ackWindow]sentSnapshot
+	ackStallAfter time.Duration
+
+	// Connection slots per user and in total (protected by connMu; limits of 0 = unlimited)
+	connMu          sync.Mutex
+	userConns       map[string]int
+	totalConns      int
+	maxConnsPerUser int
+	maxConns        int
 
 	// Logger
 	logger zerolog.Logger
@@ -38,12 +81,18 @@ type Hub struct {
 // NewHub creates a new Hub
 func NewHub(logger zerolog.Logger) *Hub {
 	return &Hub{
-		broadcast:       make(chan []byte, 256),
-		register:        make(chan *Client),
-		unregister:      make(chan *Client),
-		clients:         make(map[*Client]bool),
-		snapshotHistory: make([]*types.Snapshot, 0, maxSnapshotHistory),
-		logger:          logger,
+		broadcast:        make(chan []byte, 256),
+		register:         make(chan *Client),
+		unregister:       make(chan *Client),
+		ping:             make(chan chan struct{}),
+		clients:          make(map[*Client]bool),
+		snapshotHistory:  make([]*types.Snapshot, 0, maxSnapshotHistory),
+		historySize:      maxSnapshotHistory,
+		subscriptions:    make(map[string]map[*Client]bool),
+		subscribedAlerts: make(map[string][]types.AgentAlert),
+		userConns:        make(map[string]int),
+		ackStallAfter:    defaultAckStallAfter,
+		logger:           logger,
 	}
 }
 
@@ -53,14 +102,24 @@ func (h *Hub) Run() {
 
 	for {
 		select {
+		case reply := <-h.ping:
+			close(reply)
+
 		case client := <-h.register:
+			if h.drain.isDraining() {
+				// Shutdown has begun: the write pump sends the close frame right away
+				close(client.send)
+				continue
+			}
 			h.mu.Lock()
 			h.clients[client] = true
+			total := len(h.clients)
+			h.recordClientRoles()
 			h.mu.Unlock()
 			m.RecordWebSocketConnect()
 			h.logger.Info().
 				Str("client_id", client.id).
-				Int("total_clients", len(h.clients)).
+				Int("total_clients", total).
 				Msg("client connected")
 
 			// Send snapshot history to newly connected client
@@ -68,10 +127,12 @@ func (h *Hub) Run() {
 
 		case client := <-h.unregister:
 			h.mu.Lock()
+			h.removeSubscriptions(client)
 			if _, ok := h.clients[client]; ok {
 				delete(h.clients, client)
 				close(client.send)
 				m.RecordWebSocketDisconnect()
+				h.recordClientRoles()
 				h.logger.Info().
 					Str("client_id", client.id).
 					Int("total_clients", len(h.clients)).
@@ -99,8 +160,19 @@ func (h *Hub) Run() {
 					h.broadcastRaw(message)
 					continue
 				}
+				// Event timings are only for latency measurement and never reach clients
+				timings := snapshot.EventTimes
+				snapshot.EventTimes = nil
+				h.seq++
+				snapshot.Seq = h.seq
 				h.appendSnapshotHistory(&snapshot)
+				h.mu.Lock()
+				h.latest = &snapshot
+				h.recordSent(snapshot.Seq, time.Now())
+				h.mu.Unlock()
 			h.broadcastSnapshot(&snapshot)
+				recordBroadcastLatency(m, timings, time.Now())
+				h.publishAlertChanges(&snapshot)
 
 			default:
 				h.broadcastRaw(message)
@@ -109,11 +181,61 @@ func (h *Hub) Run() {
 	}
 }
 
+// recordBroadcastLatency observes how long each state change in a snapshot took to reach the
+// clients' send queues, measured from the sender's timestamp and from backend ingest
+func recordBroadcastLatency(m *metrics.Metrics, timings []types.EventTiming, sent time.Time) {
+	for _, t := range timings {
+		m.RecordBroadcastLatency(sent.Sub(t.Origin), sent.Sub(t.Ingest))
+	}
+}
+
 // Broadcast sends a message to all connected clients
 func (h *Hub) Broadcast(message []byte) {
 	h.broadcast <- message
 }
 
+// Ping succeeds once the Run loop has picked up the probe, proving it is not stuck
+func (h *Hub) Ping(ctx context.Context) error {
+	return pingLoop(ctx, h.ping)
+}
+
+// SetSnapshotHistorySize sets how many snapshots new clients receive on connect (0 disables);
+// call before Run
+func (h *Hub) SetSnapshotHistorySize(n int) {
+	h.historySize = n
+	h.snapshotHistory = make([]*types.Snapshot, 0, n)
+}
+
+// Shutdown sends every client a server_shutdown close frame and waits until the frames have
+// been written or ctx is done. New connections are refused from then on.
+func (h *Hub) Shutdown(ctx context.Context) error {
+	if h.drain.begin() {
+		m := metrics.Get()
+		h.mu.Lock()
+		n := len(h.clients)
+		for client := range h.clients {
+			h.removeSubscriptions(client)
+			close(client.send)
+			delete(h.clients, client)
+			m.RecordWebSocketDisconnect()
+		}
+		h.recordClientRoles()
+		h.mu.Unlock()
+		h.logger.Info().Int("clients", n).Msg("draining client connections")
+	}
+	return h.drain.wait(ctx)
+}
+
+// BroadcastBacklog returns the length and capacity of the broadcast queue
+func (h *Hub) BroadcastBacklog() (int, int) {
+	return len(h.broadcast), cap(h.broadcast)
+}
+
+// LastBroadcastDuration returns how long fanning the last snapshot out to all clients took
+func (h *Hub) LastBroadcastDuration() time.Duration {
+	return time.Duration(h.lastFanOut.Load())
+}
+
 // ClientCount returns the number of connected clients
 func (h *Hub) ClientCount() int {
 	h.mu.RLock()
@@ -121,35 +243,113 @@ func (h *Hub) ClientCount() int {
 	return len(h.clients)
 }
 
+// Clients lists the connected frontend clients, oldest connection first
+func (h *Hub) Clients() types.WSClientsResponse {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	resp := types.WSClientsResponse{Clients: make([]types.WSClientInfo, 0, len(h.clients))}
+	if h.latest != nil {
+		resp.LatestSeq = h.latest.Seq
+	}
+	for client := range h.clients {
+		info := types.WSClientInfo{
+			ID:              client.id,
+			User:            client.user,
+			ConnectedAt:     client.connectedAt,
+			DroppedMessages: client.dropped.Load(),
+			LastAckSeq:      client.lastAck.Load(),
+			SchemaVersion:   int(client.schemaVersion.Load()),
+			SnapshotsSent:   client.sent.Load(),
+			SnapshotsAcked:  client.acked.Load(),
+			Stalled:         client.stalled.Load(),
+		}
+		if last := client.lastAckAt.Load(); last > 0 {
+			at := time.Unix(0, last)
+			info.LastAckAt = &at
+			if info.SnapshotsSent > 0 {
+				info.DeliveryRatio = float64(info.SnapshotsAcked) / float64(info.SnapshotsSent)
+			}
+		}
+		if n := client.ackLatencyCount.Load(); n > 0 {
+			info.AckLatencyMs = float64(client.ackLatencySum.Load()) / float64(n) / float64(time.Millisecond)
+		}
+		if info.Stalled {
+			resp.StalledClients++
+		}
+		if client.claims != nil {
+			info.Role = client.claims.Role
+			info.AllowedLocations = client.claims.AllowedLocations
+		}
+		if info.LastAckSeq > 0 && resp.LatestSeq > info.LastAckSeq {
+			info.Lag = resp.LatestSeq - info.LastAckSeq
+		}
+		resp.Clients = append(resp.Clients, info)
+	}
+	sort.Slice(resp.Clients, func(i, j int) bool {
+		return resp.Clients[i].ConnectedAt.Before(resp.Clients[j].ConnectedAt)
+	})
+	return resp
+}
+
+// recordClientRoles updates the per-role connection gauge (caller must hold h.mu)
+func (h *Hub) recordClientRoles() {
+	counts := make(map[string]int)
+	for client := range h.clients {
+		counts[client.role()]++
+	}
+	metrics.Get().SetWebSocketClientsByRole(counts)
+}
+
+// LatestSnapshot returns the most recently broadcast snapshot (unfiltered), or nil before the first
+func (h *Hub) LatestSnapshot() *types.Snapshot {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.latest
+}
+
 // broadcastRaw sends a raw message to all clients without filtering
 func (h *Hub) broadcastRaw(message []byte) {
+	start := time.Now()
 	h.mu.RLock()
-	defer h.mu.RUnlock()
+	defer func() {
+		h.mu.RUnlock()
+		metrics.Get().RecordWebSocketBroadcast("raw", time.Since(start))
+	}()
 
+	removed := false
 	for client := range h.clients {
 		select {
 		case client.send <- message:
 		default:
 			// Client's send buffer is full, close and remove it
+			client.noteDropped()
 			close(client.send)
 			delete(h.clients, client)
+			removed = true
 			h.logger.Warn().
 				Str("client_id", client.id).
 				Msg("client send buffer full, closing connection")
 		}
 	}
+	if removed {
+		h.recordClientRoles()
+	}
 }
 
 // appendSnapshotHistory adds a snapshot to the ring buffer, evicting the oldest if full
 func (h *Hub) appendSnapshotHistory(snapshot *types.Snapshot) {
-	if len(h.snapshotHistory) < maxSnapshotHistory {
+	if h.historySize <= 0 {
+		return
+	}
+	if len(h.snapshotHistory) < h.historySize {
 		h.snapshotHistory = append(h.snapshotHistory, snapshot)
 		return
 	}
 	// Shift left and overwrite last slot — avoids re-slicing which leaks
 	// old pointers in the backing array and prevents GC from collecting them
 	copy(h.snapshotHistory, h.snapshotHistory[1:])
-	h.snapshotHistory[maxSnapshotHistory-1] = snapshot
+	h.snapshotHistory[h.historySize-1] = snapshot
 }
 
 // sendSnapshotHistory sends the buffered snapshot history to a newly connected client
@@ -164,10 +364,7 @@ func (h *Hub) sendSnapshotHistory(client *Client) {
 		filtered = append(filtered, client.FilterSnapshot(snap))
 	}
 
-	msg := struct {
-		Type      string            `json:"type"`
-		Snapshots []*types.Snapshot `json:"snapshots"`
-	}{
+	msg := types.SnapshotHistory{
 		Type:      "snapshot_history",
 		Snapshots: filtered,
 	}
@@ -185,6 +382,7 @@ func (h *Hub) sendSnapshotHistory(client *Client) {
 			Int("history_size", len(filtered)).
 			Msg("sent snapshot history to client")
 	default:
+		client.noteDropped()
 		h.logger.Warn().
 			Str("client_id", client.id).
 			Msg("client send buffer full, skipping history")
@@ -193,9 +391,17 @@ func (h *Hub) sendSnapshotHistory(client *Client) {
 
 // broadcastSnapshot sends the snapshot to each client after applying RBAC filtering
 func (h *Hub) broadcastSnapshot(snapshot *types.Snapshot) {
+	start := time.Now()
 	h.mu.RLock()
-	defer h.mu.RUnlock()
-
+	defer func() {
+		h.mu.RUnlock()
+		took := time.Since(start)
+		h.lastFanOut.Store(int64(took))
+		metrics.Get().RecordWebSocketBroadcast("snapshot", took)
+	}()
+
+	removed := false
+	stalled := 0
 	for client := range h.clients {
 		// Apply client-specific RBAC filter
 		filtered := client.FilterSnapshot(snapshot)
@@ -208,12 +414,38 @@ func (h *Hub) broadcastSnapshot(snapshot *types.Snapshot) {
 
 		select {
 		case client.send <- data:
+			client.sent.Add(1)
+			if client.checkStalled(start, h.ackStallAfter) {
+				stalled++
+			}
 		default:
+			client.noteDropped()
 			close(client.send)
 			delete(h.clients, client)
+			removed = true
 			h.logger.Warn().
 				Str("client_id", client.id).
 				Msg("client send buffer full, closing connection")
 		}
 	}
+	if removed {
+		h.recordClientRoles()
+	}
+	metrics.Get().SetWebSocketStalledClients(stalled)
+}
+
+// pingLoop hands a reply channel to a hub loop and waits for it to be closed
+func pingLoop(ctx context.Context, ping chan chan struct{}) error {
+	reply := make(chan struct{})
+	select {
+	case ping <- reply:
+	case <-ctx.Done():
+		return fmt.Errorf("hub loop not responding: %w", ctx.Err())
+	}
+	select {
+	case <-reply:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("hub loop not responding: %w", ctx.Err())
+	}
 }
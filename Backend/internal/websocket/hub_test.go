@@ -2,10 +2,13 @@ package websocket
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
 
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
 	"github.com/dennisdiepolder/monti/backend/internal/types"
 	"github.com/rs/zerolog"
 )
@@ -264,3 +267,316 @@ func TestAppendSnapshotHistory_BackingArrayStaysFixed(t *testing.T) {
 		t.Errorf("backing array grew: cap before=%d, after=%d", capBefore, capAfter)
 	}
 }
+
+func TestAgentDetailSubscription(t *testing.T) {
+	hub := NewHub(zerolog.New(&bytes.Buffer{}))
+	client := &Client{id: "c1", hub: hub, send: make(chan []byte, 10)}
+	hub.clients[client] = true
+
+	snapshot := func(alerts []types.AgentAlert) *types.Snapshot {
+		return &types.Snapshot{
+			Departments: map[types.Department]*types.DepartmentData{
+				types.DeptSales: {Agents: []types.AgentInfo{
+					{AgentID: "a1", Location: types.LocationBerlin, Alerts: alerts},
+				}},
+			},
+		}
+	}
+	hub.latest = snapshot(nil)
+
+	if _, err := hub.SubscribeAgent(client, "missing"); err != errAgentNotFound {
+		t.Fatalf("expected errAgentNotFound, got %v", err)
+	}
+	if _, err := hub.SubscribeAgent(client, "a1"); err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+	if !hub.HasAgentSubscribers("a1") {
+		t.Fatal("expected a1 to have subscribers")
+	}
+
+	hub.PublishAgentDetail(types.AgentDetail{Event: types.AgentDetailHeartbeat, Agent: types.AgentInfo{AgentID: "a1"}})
+	hub.PublishAgentDetail(types.AgentDetail{Event: types.AgentDetailHeartbeat, Agent: types.AgentInfo{AgentID: "other"}})
+	if len(client.send) != 1 {
+		t.Fatalf("expected 1 detail message, got %d", len(client.send))
+	}
+	<-client.send
+
+	// Alert changes are pushed once, unchanged alerts are not
+	alerts := []types.AgentAlert{{Rule: "acw_long", Status: types.AlertFiring}}
+	hub.publishAlertChanges(snapshot(alerts))
+	hub.publishAlertChanges(snapshot(alerts))
+	if len(client.send) != 1 {
+		t.Fatalf("expected 1 alerts message, got %d", len(client.send))
+	}
+
+	hub.UnsubscribeAgent(client, "a1")
+	if hub.HasAgentSubscribers("a1") {
+		t.Error("expected no subscribers after unsubscribe")
+	}
+}
+
+func TestAgentDetailSubscriptionLimit(t *testing.T) {
+	hub := NewHub(zerolog.New(&bytes.Buffer{}))
+	client := &Client{id: "c1", hub: hub, send: make(chan []byte, 10)}
+	hub.clients[client] = true
+
+	agents := make([]types.AgentInfo, 0, maxAgentSubscriptions+1)
+	for i := 0; i <= maxAgentSubscriptions; i++ {
+		agents = append(agents, types.AgentInfo{AgentID: fmt.Sprintf("a%d", i)})
+	}
+	hub.latest = &types.Snapshot{Departments: map[types.Department]*types.DepartmentData{
+		types.DeptSales: {Agents: agents},
+	}}
+
+	for i := 0; i < maxAgentSubscriptions; i++ {
+		if _, err := hub.SubscribeAgent(client, agents[i].AgentID); err != nil {
+			t.Fatalf("subscribe %d failed: %v", i, err)
+		}
+	}
+	if _, err := hub.SubscribeAgent(client, agents[maxAgentSubscriptions].AgentID); err != errTooManySubscriptions {
+		t.Fatalf("expected errTooManySubscriptions, got %v", err)
+	}
+	// Re-subscribing to an existing agent is allowed
+	if _, err := hub.SubscribeAgent(client, agents[0].AgentID); err != nil {
+		t.Fatalf("re-subscribe failed: %v", err)
+	}
+}
+
+func TestClientOnDemandRequests(t *testing.T) {
+	hub := NewHub(zerolog.New(&bytes.Buffer{}))
+	client := &Client{id: "c1", hub: hub, send: make(chan []byte, 10), claims: &auth.Claims{
+		Role: "supervisor", AllowedLocations: []types.Location{types.LocationBerlin}, Departments: []types.Department{types.DeptSales},
+	}}
+	client.schemaVersion.Store(types.SnapshotSchemaV1)
+	hub.clients[client] = true
+
+	request := func(cmd string) types.ClientCommandResult {
+		t.Helper()
+		client.handleCommand([]byte(cmd))
+		select {
+		case data := <-client.send:
+			var result types.ClientCommandResult
+			if err := json.Unmarshal(data, &result); err != nil {
+				t.Fatal(err)
+			}
+			return result
+		default:
+			t.Fatalf("no result for %s", cmd)
+			return types.ClientCommandResult{}
+		}
+	}
+
+	if r := request(`{"type":"get_snapshot","requestId":"r1"}`); r.OK || r.RequestID != "r1" || r.Error != errNoSnapshot.Error() {
+		t.Errorf("expected no snapshot yet, got %+v", r)
+	}
+	hub.latest = &types.Snapshot{Type: "snapshot", Departments: map[types.Department]*types.DepartmentData{
+		types.DeptSales:   {Agents: []types.AgentInfo{{AgentID: "a1", Department: types.DeptSales, Location: types.LocationBerlin}}},
+		types.DeptSupport: {Agents: []types.AgentInfo{{AgentID: "a2", Department: types.DeptSupport, Location: types.LocationBerlin}}},
+	}}
+	r := request(`{"type":"get_snapshot","requestId":"r2"}`)
+	if !r.OK || r.RequestID != "r2" || r.Snapshot == nil || len(r.Snapshot.Departments[types.DeptSales].Agents) != 1 {
+		t.Fatalf("expected the filtered snapshot, got %+v", r)
+	}
+	if d := r.Snapshot.Departments[types.DeptSupport]; d != nil && len(d.Agents) > 0 {
+		t.Errorf("expected support agents filtered out, got %+v", d)
+	}
+
+	if r := request(`{"type":"get_agent","requestId":"r3","agentId":"a1"}`); !r.OK || r.Agent == nil || r.Agent.AgentID != "a1" {
+		t.Errorf("expected agent a1, got %+v", r)
+	}
+	if r := request(`{"type":"get_agent","requestId":"r4","agentId":"a2"}`); r.OK || r.Error != errAgentNotFound.Error() {
+		t.Errorf("expected an agent outside the scope to be not found, got %+v", r)
+	}
+	if hub.HasAgentSubscribers("a1") {
+		t.Error("get_agent must not subscribe")
+	}
+
+	if r := request(`{"type":"get_queue_history","vq":"sales_inbound"}`); r.OK || r.Error != errNoQueueHistory.Error() {
+		t.Errorf("expected no queue history source, got %+v", r)
+	}
+	var gotInterval time.Duration
+	var gotFrom, gotTo time.Time
+	hub.SetQueueHistory(func(tenant string, vq types.VQName, interval time.Duration, from, to time.Time) []types.IntervalStat {
+		gotInterval, gotFrom, gotTo = interval, from, to
+		return []types.IntervalStat{{VQ: vq, Offered: 3}}
+	})
+	r = request(`{"type":"get_queue_history","requestId":"r5","vq":"sales_inbound","minutes":30,"intervalMinutes":5}`)
+	if !r.OK || len(r.Intervals) != 1 || r.Intervals[0].Offered != 3 || gotInterval != 5*time.Minute || gotTo.Sub(gotFrom) != 30*time.Minute {
+		t.Errorf("unexpected queue history: %+v (interval %s, %s to %s)", r, gotInterval, gotFrom, gotTo)
+	}
+	for _, cmd := range []string{
+		`{"type":"get_queue_history","vq":"tech_l1"}`,
+		`{"type":"get_queue_history","vq":"nope"}`,
+	} {
+		if r := request(cmd); r.OK || r.Error != errQueueNotFound.Error() {
+			t.Errorf("%s: expected queue not found, got %+v", cmd, r)
+		}
+	}
+	if r := request(`{"type":"get_queue_history","vq":"sales_inbound","minutes":5000}`); r.OK {
+		t.Errorf("expected a window over a day to be rejected, got %+v", r)
+	}
+}
+
+func TestHubStripsEventTimesFromSnapshot(t *testing.T) {
+	hub := NewHub(zerolog.Nop())
+	go hub.Run()
+
+	client := &Client{id: "client1", hub: hub, send: make(chan []byte, 10)}
+	hub.register <- client
+
+	snapshot := makeSnapshot(1)
+	snapshot.EventTimes = []types.EventTiming{{Origin: time.Now().Add(-time.Second), Ingest: time.Now()}}
+	data, _ := json.Marshal(snapshot)
+	hub.Broadcast(data)
+
+	select {
+	case msg := <-client.send:
+		if bytes.Contains(msg, []byte("eventTimes")) {
+			t.Errorf("expected event timings to be stripped, got %s", msg)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("client did not receive snapshot")
+	}
+	if latest := hub.LatestSnapshot(); latest == nil || latest.EventTimes != nil {
+		t.Error("expected the stored snapshot to have no event timings")
+	}
+}
+
+func TestHubPing(t *testing.T) {
+	logger := zerolog.New(&bytes.Buffer{})
+	hub := NewHub(logger)
+
+	// Without a running loop the probe must time out
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := hub.Ping(ctx); err == nil {
+		t.Fatal("expected ping to fail without a running loop")
+	}
+
+	go hub.Run()
+	if err := hub.Ping(context.Background()); err != nil {
+		t.Fatalf("expected ping to succeed, got %v", err)
+	}
+}
+
+func TestHubClientInventory(t *testing.T) {
+	hub := NewHub(zerolog.Nop())
+	go hub.Run()
+
+	client := &Client{id: "client1", hub: hub, send: make(chan []byte, 10), user: "sup@example.com",
+		claims: &auth.Claims{Role: "supervisor", AllowedLocations: []types.Location{types.LocationBerlin}}}
+	hub.register <- client
+
+	for i := 0; i < 2; i++ {
+		data, _ := json.Marshal(makeSnapshot(1))
+		hub.Broadcast(data)
+		select {
+		case msg := <-client.send:
+			var snapshot types.Snapshot
+			json.Unmarshal(msg, &snapshot)
+			if snapshot.Seq != int64(i+1) {
+				t.Fatalf("expected snapshot seq %d, got %d", i+1, snapshot.Seq)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Fatal("client did not receive snapshot")
+		}
+	}
+	client.handleCommand([]byte(`{"type":"ack","seq":1}`))
+
+	// Fill the send buffer so the next agent detail is dropped
+	for len(client.send) < cap(client.send) {
+		client.send <- nil
+	}
+	hub.sendToClient(client, []byte(`{}`))
+
+	inventory := hub.Clients()
+	if inventory.LatestSeq != 2 || len(inventory.Clients) != 1 {
+		t.Fatalf("unexpected inventory: %+v", inventory)
+	}
+	info := inventory.Clients[0]
+	if info.User != "sup@example.com" || info.Role != "supervisor" || len(info.AllowedLocations) != 1 {
+		t.Errorf("unexpected client identity: %+v", info)
+	}
+	if info.LastAckSeq != 1 || info.Lag != 1 || info.DroppedMessages != 1 {
+		t.Errorf("expected ack 1, lag 1 and 1 dropped message, got %+v", info)
+	}
+}
+
+func TestHubTracksSnapshotDelivery(t *testing.T) {
+	hub := NewHub(zerolog.Nop())
+	hub.SetAckStallAfter(time.Minute)
+	go hub.Run()
+
+	client := &Client{id: "client1", hub: hub, send: make(chan []byte, 10), logger: zerolog.Nop(),
+		claims: &auth.Claims{Role: "supervisor", AllowedLocations: []types.Location{types.LocationBerlin}}}
+	hub.register <- client
+
+	for i := 0; i < 4; i++ {
+		data, _ := json.Marshal(makeSnapshot(1))
+		hub.Broadcast(data)
+		select {
+		case <-client.send:
+		case <-time.After(100 * time.Millisecond):
+			t.Fatal("client did not receive snapshot")
+		}
+	}
+	client.handleCommand([]byte(`{"type":"ack","seq":2}`))
+	client.handleCommand([]byte(`{"type":"ack","seq":3}`))
+	client.handleCommand([]byte(`{"type":"ack","seq":3}`))  // repeated acks are not counted twice
+	client.handleCommand([]byte(`{"type":"ack","seq":99}`)) // nor acks of snapshots never sent
+
+	info := hub.Clients().Clients[0]
+	if info.SnapshotsSent != 4 || info.SnapshotsAcked != 2 || info.DeliveryRatio != 0.5 || info.LastAckSeq != 3 {
+		t.Errorf("expected 2 of 4 snapshots acked, got %+v", info)
+	}
+	if info.LastAckAt == nil || info.AckLatencyMs <= 0 || info.Stalled {
+		t.Errorf("expected an ack time and latency, not stalled, got %+v", info)
+	}
+
+	// No ack for longer than the stall timeout while snapshots keep coming
+	if !client.checkStalled(time.Now().Add(2*time.Minute), time.Minute) {
+		t.Fatal("expected the client to be stalled")
+	}
+	if inventory := hub.Clients(); inventory.StalledClients != 1 || !inventory.Clients[0].Stalled {
+		t.Errorf("expected 1 stalled client, got %+v", inventory)
+	}
+	client.handleCommand([]byte(`{"type":"ack","seq":4}`))
+	if client.stalled.Load() {
+		t.Error("expected an ack to clear the stall")
+	}
+
+	// Clients that never acknowledge are not reported as stalled
+	silent := &Client{id: "client2", hub: hub, send: make(chan []byte, 10), logger: zerolog.Nop()}
+	if silent.checkStalled(time.Now().Add(time.Hour), time.Minute) {
+		t.Error("expected a client without acks not to be stalled")
+	}
+}
+
+func TestHubSendControlTargetsClientsAndRoles(t *testing.T) {
+	hub := NewHub(zerolog.Nop())
+	admin := &Client{id: "c1", hub: hub, send: make(chan []byte, 1), claims: &auth.Claims{Role: "admin"}}
+	supervisor := &Client{id: "c2", hub: hub, send: make(chan []byte, 1), claims: &auth.Claims{Role: "supervisor"}}
+	anonymous := &Client{id: "c3", hub: hub, send: make(chan []byte, 1)}
+	for _, c := range []*Client{admin, supervisor, anonymous} {
+		hub.clients[c] = true
+	}
+
+	msg := types.ControlMessage{Action: types.ControlNotice, Message: "maintenance starting"}
+	if delivered, _ := hub.SendControl(msg, []string{"c3"}, []string{"supervisor"}); delivered != 2 {
+		t.Fatalf("expected 2 targeted clients, got %d", delivered)
+	}
+	if len(admin.send) != 0 || len(supervisor.send) != 1 || len(anonymous.send) != 1 {
+		t.Fatal("expected only the supervisor and c3 to receive the message")
+	}
+	var got types.ControlMessage
+	json.Unmarshal(<-supervisor.send, &got)
+	if got.Type != "control" || got.Message != "maintenance starting" {
+		t.Errorf("unexpected control message: %+v", got)
+	}
+
+	// Without targets every client is messaged; full buffers drop it
+	delivered, dropped := hub.SendControl(types.ControlMessage{Action: types.ControlReload}, nil, nil)
+	if delivered != 2 || dropped != 1 || anonymous.dropped.Load() != 1 {
+		t.Errorf("expected 2 delivered and 1 dropped, got %d and %d", delivered, dropped)
+	}
+}
@@ -0,0 +1,73 @@
+package websocket
+
+import (
+	"errors"
+
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
+	"github.com/gorilla/websocket"
+)
+
+var (
+	errUserConnectionLimit  = errors.New("per-user connection limit reached")
+	errTotalConnectionLimit = errors.New("server connection limit reached")
+)
+
+// SetConnectionLimits caps simultaneous frontend connections per user and in total (0 = unlimited)
+func (h *Hub) SetConnectionLimits(perUser, total int) {
+	h.connMu.Lock()
+	defer h.connMu.Unlock()
+	h.maxConnsPerUser = perUser
+	h.maxConns = total
+}
+
+// acquireConnection reserves a connection slot for user
+func (h *Hub) acquireConnection(user string) error {
+	h.connMu.Lock()
+	defer h.connMu.Unlock()
+
+	if h.maxConns > 0 && h.totalConns >= h.maxConns {
+		return errTotalConnectionLimit
+	}
+	if h.maxConnsPerUser > 0 && h.userConns[user] >= h.maxConnsPerUser {
+		return errUserConnectionLimit
+	}
+	h.userConns[user]++
+	h.totalConns++
+	return nil
+}
+
+// releaseConnection frees a slot reserved by acquireConnection
+func (h *Hub) releaseConnection(user string) {
+	h.connMu.Lock()
+	defer h.connMu.Unlock()
+
+	if h.userConns[user] <= 1 {
+		delete(h.userConns, user)
+	} else {
+		h.userConns[user]--
+	}
+	if h.totalConns > 0 {
+		h.totalConns--
+	}
+}
+
+// connectionCloseCode maps a limit error to the close code sent before dropping the connection:
+// 1008 (policy violation) for the per-user cap, 1013 (try again later) for the server-wide cap
+func connectionCloseCode(err error) int {
+	if errors.Is(err, errUserConnectionLimit) {
+		return websocket.ClosePolicyViolation
+	}
+	return websocket.CloseTryAgainLater
+}
+
+// connectionUser identifies the user a connection counts against
+func connectionUser(claims *auth.Claims) string {
+	switch {
+	case claims == nil:
+		return "anonymous"
+	case claims.Subject != "":
+		return claims.Subject
+	default:
+		return claims.Email
+	}
+}
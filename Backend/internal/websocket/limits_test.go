@@ -0,0 +1,66 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog"
+)
+
+func TestConnectionLimits(t *testing.T) {
+	hub := NewHub(zerolog.Nop())
+	hub.SetConnectionLimits(2, 3)
+
+	for i := 0; i < 2; i++ {
+		if err := hub.acquireConnection("alice"); err != nil {
+			t.Fatalf("connection %d for alice: unexpected error %v", i+1, err)
+		}
+	}
+	err := hub.acquireConnection("alice")
+	if err != errUserConnectionLimit {
+		t.Fatalf("expected per-user limit, got %v", err)
+	}
+	if code := connectionCloseCode(err); code != websocket.ClosePolicyViolation {
+		t.Errorf("expected close code %d, got %d", websocket.ClosePolicyViolation, code)
+	}
+
+	if err := hub.acquireConnection("bob"); err != nil {
+		t.Fatalf("bob: unexpected error %v", err)
+	}
+	err = hub.acquireConnection("carol")
+	if err != errTotalConnectionLimit {
+		t.Fatalf("expected total limit, got %v", err)
+	}
+	if code := connectionCloseCode(err); code != websocket.CloseTryAgainLater {
+		t.Errorf("expected close code %d, got %d", websocket.CloseTryAgainLater, code)
+	}
+
+	hub.releaseConnection("alice")
+	if err := hub.acquireConnection("carol"); err != nil {
+		t.Errorf("expected a freed slot to be reusable, got %v", err)
+	}
+}
+
+func TestConnectionLimitsUnlimited(t *testing.T) {
+	hub := NewHub(zerolog.Nop())
+	for i := 0; i < 50; i++ {
+		if err := hub.acquireConnection("alice"); err != nil {
+			t.Fatalf("unexpected error without limits: %v", err)
+		}
+	}
+}
+
+func TestConnectionUser(t *testing.T) {
+	claims := &auth.Claims{Email: "sup@example.com"}
+	if got := connectionUser(claims); got != "sup@example.com" {
+		t.Errorf("expected email fallback, got %q", got)
+	}
+	claims.Subject = "user-123"
+	if got := connectionUser(claims); got != "user-123" {
+		t.Errorf("expected subject, got %q", got)
+	}
+	if got := connectionUser(nil); got != "anonymous" {
+		t.Errorf("expected anonymous, got %q", got)
+	}
+}
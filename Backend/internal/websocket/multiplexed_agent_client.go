@@ -16,12 +16,19 @@ type MultiplexedAgentClient struct {
 	hub      *AgentHub
 	conn     *websocket.Conn
 	send     chan []byte
-	agentIDs map[string]bool // registered agentIDs on this connection
+	agentIDs map[string]uint64 // registered agentIDs on this connection -> connection generation
 	logger   zerolog.Logger
 	done     chan struct{}
 
-	closeOnce sync.Once
-	mu        sync.Mutex
+	mu sync.Mutex
+
+	// sendMu serializes sends, also by the connection's agents, with the close of send; closed
+	// is set once it is closed
+	sendMu sync.Mutex
+	closed bool
+
+	// Releases the hub's shutdown tracking when the write pump exits
+	untrack func()
 }
 
 // NewMultiplexedAgentClient creates a new multiplexed agent client
@@ -30,7 +37,7 @@ func NewMultiplexedAgentClient(hub *AgentHub, conn *websocket.Conn, logger zerol
 		hub:      hub,
 		conn:     conn,
 		send:     make(chan []byte, 256),
-		agentIDs: make(map[string]bool),
+		agentIDs: make(map[string]uint64),
 		logger:   logger,
 		done:     make(chan struct{}),
 	}
@@ -39,17 +46,22 @@ func NewMultiplexedAgentClient(hub *AgentHub, conn *websocket.Conn, logger zerol
 func (c *MultiplexedAgentClient) readPump() {
 	defer func() {
 		close(c.done)
+		c.hub.removeMux(c)
+		// Refuse sends before the agents are unregistered: routing would otherwise assign
+		// calls that never leave the buffer. This also stops the write pump now rather than at
+		// its next failed ping; removeMux already took the connection out of reach of Shutdown.
+		c.Close()
 		// Unregister all agents on this connection
 		c.mu.Lock()
-		agentIDs := make([]string, 0, len(c.agentIDs))
-		for id := range c.agentIDs {
-			agentIDs = append(agentIDs, id)
+		agentIDs := make(map[string]uint64, len(c.agentIDs))
+		for id, generation := range c.agentIDs {
+			agentIDs[id] = generation
 		}
 		c.mu.Unlock()
 
-		for _, id := range agentIDs {
+		for id, generation := range agentIDs {
 			// Create a temporary AgentClient for unregistration
-			tmpClient := &AgentClient{agentID: id, hub: c.hub}
+			tmpClient := &AgentClient{agentID: id, hub: c.hub, generation: generation, mux: c}
 			c.hub.unregister <- tmpClient
 		}
 		c.conn.Close()
@@ -75,19 +87,16 @@ func (c *MultiplexedAgentClient) readPump() {
 	}
 }
 
-// safeSend attempts to send on the send channel without panicking if it's closed
+// safeSend queues a message unless the connection is closed or its buffer is full
 func (c *MultiplexedAgentClient) safeSend(data []byte) bool {
-	defer func() {
-		if r := recover(); r != nil {
-			// channel was closed
-		}
-	}()
-
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.closed {
+		return false
+	}
 	select {
 	case c.send <- data:
 		return true
-	case <-c.done:
-		return false
 	default:
 		return false
 	}
@@ -117,56 +126,100 @@ func (c *MultiplexedAgentClient) handleMessage(message []byte) {
 			c.logger.Debug().Err(err).Msg("failed to parse mux register message")
 			return
 		}
+		if !types.IsKnownTenant(reg.Tenant) {
+			c.logger.Warn().Str("agent_id", reg.AgentID).Str("tenant", reg.Tenant).Msg("register for unknown tenant rejected")
+			return
+		}
+		if err := c.hub.tracker.CheckTenant(reg.AgentID, reg.Tenant); err != nil {
+			c.logger.Warn().Err(err).Str("agent_id", reg.AgentID).Str("tenant", reg.Tenant).Msg("register rejected")
+			return
+		}
+		generation := c.hub.nextGeneration(reg.AgentID)
 		c.mu.Lock()
-		c.agentIDs[reg.AgentID] = true
+		c.agentIDs[reg.AgentID] = generation
 		c.mu.Unlock()
 
 		// Register with hub - create a virtual AgentClient that shares this connection's send channel
 		virtualClient := &AgentClient{
-			agentID: reg.AgentID,
-			hub:     c.hub,
-			conn:    c.conn,
-			send:    c.send, // share send channel
-			logger:  c.logger.With().Str("agent_id", reg.AgentID).Logger(),
-			done:    c.done,
+			agentID:    reg.AgentID,
+			hub:        c.hub,
+			conn:       c.conn,
+			send:       c.send, // share send channel
+			logger:     c.logger.With().Str("agent_id", reg.AgentID).Logger(),
+			done:       c.done,
+			generation: generation,
+			mux:        c,
 		}
 		c.hub.register <- virtualClient
-		c.hub.agentRegister <- &reg
+		reg.Generation = generation
+		enqueueReliable(c.hub.agentRegister, &reg, queueRegister)
 
 		// Send ack
-		ack := types.ServerAck{Type: "ack", AgentID: reg.AgentID}
+		ack := types.ServerAck{Type: "ack", AgentID: reg.AgentID, Generation: generation}
 		if data, err := json.Marshal(ack); err == nil {
 			c.safeSend(data)
 		}
+		if data := c.hub.throttleMessage(); data != nil {
+			c.safeSend(data)
+		}
 
 	case "heartbeat":
 		var hb types.AgentHeartbeat
 		if err := json.Unmarshal(message, &hb); err != nil {
 			return
 		}
-		c.hub.heartbeat <- &hb
+		hb.Generation = c.generation(hb.AgentID)
+		c.hub.enqueueHeartbeat(&hb)
 
 	case "state_change":
 		var sc types.AgentStateChange
 		if err := json.Unmarshal(message, &sc); err != nil {
 			return
 		}
-		c.hub.stateChange <- &sc
+		sc.Generation = c.generation(sc.AgentID)
+		enqueueReliable(c.hub.stateChange, &sc, queueStateChange)
 
 	case "call_complete":
 		var cc types.CallComplete
 		if err := json.Unmarshal(message, &cc); err != nil {
 			return
 		}
-		c.hub.callComplete <- &cc
+		cc.Generation = c.generation(cc.AgentID)
+		enqueueReliable(c.hub.callComplete, &cc, queueCallComplete)
+
+	case "call_hold_start", "call_hold_end":
+		var ch types.CallHold
+		if err := json.Unmarshal(message, &ch); err != nil {
+			return
+		}
+		ch.Generation = c.generation(ch.AgentID)
+		enqueueReliable(c.hub.callHold, &ch, queueCallHold)
+
+	case "break_request":
+		var req types.BreakRequest
+		if err := json.Unmarshal(message, &req); err != nil {
+			return
+		}
+		if data := c.hub.requestBreak(&req); data != nil {
+			c.safeSend(data)
+		}
 	}
 }
 
+// generation returns the connection generation of an agent registered on this connection
+// (0 = not registered here, untracked)
+func (c *MultiplexedAgentClient) generation(agentID string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.agentIDs[agentID]
+}
+
 func (c *MultiplexedAgentClient) writePump() {
 	ticker := time.NewTicker(agentPingPeriod)
 	defer func() {
 		ticker.Stop()
 		c.conn.Close()
+		c.untrack()
 	}()
 
 	for {
@@ -174,7 +227,7 @@ func (c *MultiplexedAgentClient) writePump() {
 		case message, ok := <-c.send:
 			c.conn.SetWriteDeadline(time.Now().Add(agentWriteWait))
 			if !ok {
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				c.conn.WriteMessage(websocket.CloseMessage, c.hub.drain.closeFrame())
 				return
 			}
 			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
@@ -192,16 +245,17 @@ func (c *MultiplexedAgentClient) writePump() {
 
 // Start starts the multiplexed client's read and write pumps
 func (c *MultiplexedAgentClient) Start() {
+	c.untrack = c.hub.drain.track()
 	go c.writePump()
 	go c.readPump()
 }
 
-// Close safely closes the client's send channel
+// Close closes the client's send channel (idempotent)
 func (c *MultiplexedAgentClient) Close() {
-	c.closeOnce.Do(func() {
-		defer func() {
-			recover() // absorb panic if channel was already closed
-		}()
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if !c.closed {
+		c.closed = true
 		close(c.send)
-	})
+	}
 }
@@ -0,0 +1,104 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/metrics"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// AgentHub inbound queues, as labelled in metrics
+const (
+	queueHeartbeat    = "heartbeat"
+	queueStateChange  = "state_change"
+	queueRegister     = "register"
+	queueCallComplete = "call_complete"
+	queueCallHold     = "call_hold"
+	queueWorkers      = "workers" // all processing workers' queues together
+)
+
+// queueDepthInterval is how often the inbound queue depths are reported
+const queueDepthInterval = time.Second
+
+// shedWarnInterval throttles the overload warning while heartbeats are being shed
+const shedWarnInterval = 10 * time.Second
+
+// shedLog counts shed heartbeats between overload warnings
+type shedLog struct {
+	mu       sync.Mutex
+	count    int
+	lastWarn time.Time
+}
+
+// enqueueHeartbeat queues a heartbeat without ever blocking the connection's reader: when
+// the queue is full, the oldest heartbeat is dropped. Heartbeats only refresh liveness, so
+// a later one of the same agent makes up for it.
+func (h *AgentHub) enqueueHeartbeat(hb *types.AgentHeartbeat) {
+	for {
+		select {
+		case h.heartbeat <- hb:
+			return
+		default:
+		}
+		select {
+		case <-h.heartbeat:
+			h.noteShed()
+		default:
+		}
+	}
+}
+
+// enqueueReliable queues a message that must not be lost (state changes, call completions,
+// registrations). When the queue is full, the connection's reader waits for the Run loop,
+// which slows down that agent only; the wait is counted so a stalled processor shows up.
+func enqueueReliable[T any](ch chan T, msg T, queue string) {
+	select {
+	case ch <- msg:
+		return
+	default:
+	}
+	metrics.Get().RecordAgentHubBlocked(queue)
+	ch <- msg
+}
+
+// noteShed records a shed heartbeat and warns at most every shedWarnInterval
+func (h *AgentHub) noteShed() {
+	metrics.Get().RecordAgentHubShed(queueHeartbeat)
+	h.throttleOnShed()
+
+	h.shed.mu.Lock()
+	defer h.shed.mu.Unlock()
+	h.shed.count++
+	if time.Since(h.shed.lastWarn) < shedWarnInterval {
+		return
+	}
+	h.logger.Warn().
+		Int("shed", h.shed.count).
+		Int("capacity", cap(h.heartbeat)).
+		Msg("agent hub overloaded, shedding oldest heartbeats")
+	h.shed.count = 0
+	h.shed.lastWarn = time.Now()
+}
+
+// reportQueueDepths publishes the depth of every inbound queue every queueDepthInterval and
+// lifts the heartbeat throttle once shedding stopped. It runs apart from the Run loop so the
+// gauges keep moving when processing stalls.
+func (h *AgentHub) reportQueueDepths() {
+	ticker := time.NewTicker(queueDepthInterval)
+	defer ticker.Stop()
+
+	m := metrics.Get()
+	for now := range ticker.C {
+		h.releaseThrottle(now)
+		m.SetAgentHubQueueDepth(queueHeartbeat, len(h.heartbeat))
+		m.SetAgentHubQueueDepth(queueStateChange, len(h.stateChange))
+		m.SetAgentHubQueueDepth(queueRegister, len(h.agentRegister))
+		m.SetAgentHubQueueDepth(queueCallComplete, len(h.callComplete))
+		m.SetAgentHubQueueDepth(queueCallHold, len(h.callHold))
+		if h.workers != nil {
+			depth, _ := h.workers.Backlog()
+			m.SetAgentHubQueueDepth(queueWorkers, depth)
+		}
+	}
+}
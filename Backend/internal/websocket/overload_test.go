@@ -0,0 +1,75 @@
+package websocket
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+func TestEnqueueHeartbeatShedsOldest(t *testing.T) {
+	hub := NewAgentHub(cache.NewAgentStateTracker(), nil, zerolog.Nop())
+	capacity := cap(hub.heartbeat)
+
+	for i := 0; i <= capacity; i++ {
+		hub.enqueueHeartbeat(&types.AgentHeartbeat{AgentID: fmt.Sprintf("agent-%d", i)})
+	}
+
+	if len(hub.heartbeat) != capacity {
+		t.Fatalf("expected a full queue of %d, got %d", capacity, len(hub.heartbeat))
+	}
+	if first := <-hub.heartbeat; first.AgentID != "agent-1" {
+		t.Errorf("expected the oldest heartbeat to be shed, first queued is %s", first.AgentID)
+	}
+	if hub.shed.lastWarn.IsZero() {
+		t.Error("expected shedding to log an overload warning")
+	}
+}
+
+func TestSheddingThrottlesAgentHeartbeats(t *testing.T) {
+	hub := NewAgentHub(cache.NewAgentStateTracker(), nil, zerolog.Nop())
+	hub.SetHeartbeatThrottle(5 * time.Second)
+	single := &AgentClient{agentID: "a1", send: make(chan []byte, 4), done: make(chan struct{})}
+	mux := &MultiplexedAgentClient{send: make(chan []byte, 4), done: make(chan struct{})}
+	hub.agents["a1"] = single
+	hub.agents["a2"] = &AgentClient{agentID: "a2", send: mux.send, done: mux.done, mux: mux}
+	hub.muxClients[mux] = true
+
+	expect := func(ch chan []byte, want string) {
+		t.Helper()
+		select {
+		case data := <-ch:
+			if string(data) != want {
+				t.Errorf("expected %s, got %s", want, data)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected %s", want)
+		}
+		if len(ch) > 0 {
+			t.Errorf("expected one message per connection, %d more queued", len(ch))
+		}
+	}
+
+	for i := 0; i <= cap(hub.heartbeat); i++ {
+		hub.enqueueHeartbeat(&types.AgentHeartbeat{AgentID: "a1"})
+	}
+	expect(single.send, `{"type":"throttle","heartbeatInterval":5}`)
+	expect(mux.send, `{"type":"throttle","heartbeatInterval":5}`)
+	if data := hub.throttleMessage(); string(data) != `{"type":"throttle","heartbeatInterval":5}` {
+		t.Errorf("expected newly registered agents to be throttled, got %s", data)
+	}
+
+	hub.releaseThrottle(time.Now())
+	if len(single.send) > 0 {
+		t.Error("expected the throttle to hold while heartbeats were shed recently")
+	}
+	hub.releaseThrottle(time.Now().Add(throttleReleaseAfter))
+	expect(single.send, `{"type":"throttle","heartbeatInterval":0}`)
+	expect(mux.send, `{"type":"throttle","heartbeatInterval":0}`)
+	if data := hub.throttleMessage(); data != nil {
+		t.Errorf("expected no throttle for new agents once lifted, got %s", data)
+	}
+}
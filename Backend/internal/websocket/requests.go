@@ -0,0 +1,81 @@
+package websocket
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+const (
+	defaultQueueHistoryMinutes = 60
+	maxQueueHistoryMinutes     = 24 * 60
+)
+
+var (
+	errNoSnapshot          = errors.New("no snapshot yet")
+	errQueueNotFound       = errors.New("queue not found")
+	errNoQueueHistory      = errors.New("queue history not available")
+	errInvalidQueueHistory = fmt.Errorf("minutes must be between 0 and %d, intervalMinutes between 0 and minutes", maxQueueHistoryMinutes)
+)
+
+// QueueHistoryFunc returns a tenant's traffic for a VQ in the completed intervals between from
+// and to, oldest first (callqueue.CallQueueManager.IntervalRange)
+type QueueHistoryFunc func(tenant string, vq types.VQName, interval time.Duration, from, to time.Time) []types.IntervalStat
+
+// SetQueueHistory sets the source of get_queue_history; without it the command fails
+func (h *Hub) SetQueueHistory(fn QueueHistoryFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.queueHistory = fn
+}
+
+// Agent returns agentID's state from the latest snapshot without subscribing to it. Agents
+// outside the client's scope are reported as not found.
+func (h *Hub) Agent(client *Client, agentID string) (types.AgentInfo, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.visibleAgent(client, agentID)
+}
+
+// latestSnapshot returns the latest snapshot filtered like a broadcast to the client
+func (c *Client) latestSnapshot() (*types.Snapshot, error) {
+	latest := c.hub.LatestSnapshot()
+	if latest == nil {
+		return nil, errNoSnapshot
+	}
+	return c.FilterSnapshot(latest), nil
+}
+
+// queueHistory answers get_queue_history for a VQ of a department within the client's scope
+func (c *Client) queueHistory(cmd types.ClientCommand) ([]types.IntervalStat, error) {
+	c.hub.mu.RLock()
+	history := c.hub.queueHistory
+	c.hub.mu.RUnlock()
+	if history == nil {
+		return nil, errNoQueueHistory
+	}
+
+	dept, ok := types.VQDepartmentMapping[cmd.VQ]
+	if !ok || (c.claims != nil && !c.claims.IsDepartmentAllowed(dept)) {
+		return nil, errQueueNotFound
+	}
+	minutes, interval := cmd.Minutes, cmd.IntervalMinutes
+	if minutes == 0 {
+		minutes = defaultQueueHistoryMinutes
+	}
+	if interval == 0 {
+		interval = 1
+	}
+	if minutes < 0 || minutes > maxQueueHistoryMinutes || interval < 0 || interval > minutes {
+		return nil, errInvalidQueueHistory
+	}
+
+	tenant := ""
+	if c.claims != nil {
+		tenant = c.claims.Tenant
+	}
+	now := time.Now()
+	return history(tenant, cmd.VQ, time.Duration(interval)*time.Minute, now.Add(-time.Duration(minutes)*time.Minute), now), nil
+}
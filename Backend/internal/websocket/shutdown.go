@@ -0,0 +1,72 @@
+package websocket
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// ShutdownCloseReason is the close frame reason clients receive when the server drains connections
+const ShutdownCloseReason = "server_shutdown"
+
+// drainState lets a hub refuse new connections during shutdown and wait until every
+// write pump has sent its close frame
+type drainState struct {
+	mu       sync.Mutex
+	draining bool
+	pumps    sync.WaitGroup
+}
+
+// track registers a write pump and returns the func it must call on exit.
+// Once draining has begun the pump is not tracked.
+func (d *drainState) track() func() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return func() {}
+	}
+	d.pumps.Add(1)
+	return d.pumps.Done
+}
+
+// begin marks the hub as draining; it reports false if draining had already begun
+func (d *drainState) begin() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return false
+	}
+	d.draining = true
+	return true
+}
+
+// isDraining reports whether shutdown has begun
+func (d *drainState) isDraining() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.draining
+}
+
+// closeFrame is the payload write pumps send when their send channel is closed
+func (d *drainState) closeFrame() []byte {
+	if d.isDraining() {
+		return websocket.FormatCloseMessage(websocket.CloseGoingAway, ShutdownCloseReason)
+	}
+	return []byte{}
+}
+
+// wait blocks until all tracked write pumps have exited or ctx is done
+func (d *drainState) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		d.pumps.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
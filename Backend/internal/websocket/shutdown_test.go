@@ -0,0 +1,61 @@
+package websocket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/config"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog"
+)
+
+func TestHubShutdownSendsCloseFrame(t *testing.T) {
+	logger := zerolog.Nop()
+	hub := NewHub(logger)
+	go hub.Run()
+
+	cfg := &config.Config{PongWait: time.Minute, PingPeriod: time.Minute, WriteWait: time.Second}
+	srv := httptest.NewServer(NewHandler(hub, cfg, logger))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for hub.ClientCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := hub.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err = conn.ReadMessage()
+	closeErr, ok := err.(*websocket.CloseError)
+	if !ok {
+		t.Fatalf("expected close frame, got %v", err)
+	}
+	if closeErr.Code != websocket.CloseGoingAway || closeErr.Text != ShutdownCloseReason {
+		t.Errorf("expected %d %q, got %d %q", websocket.CloseGoingAway, ShutdownCloseReason, closeErr.Code, closeErr.Text)
+	}
+
+	// New connections are refused while draining
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 after shutdown, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,90 @@
+package websocket
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/metrics"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// Every connection an agent registers on gets the agent's next generation number. Messages
+// carry the generation of the connection they arrived on and are only applied while it is
+// the agent's newest, so an old connection that lingers after an agent instance restarted
+// cannot flip the agent back. The old connection is told so with a "superseded" message.
+// Once the newest connection closed, its messages that only report presence (register,
+// heartbeat, state_change) are dropped too: processed after the disconnect, they would mark
+// the agent connected again and have calls routed to it until it goes stale.
+
+// nextGeneration issues the generation of a new connection of agentID
+func (h *AgentHub) nextGeneration(agentID string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.generations[agentID]++
+	return h.generations[agentID]
+}
+
+// isCurrent reports whether generation is the newest connection of agentID (0 = untracked),
+// and with open also whether that connection has not closed yet
+func (h *AgentHub) isCurrent(agentID string, generation uint64, open bool) bool {
+	if generation == 0 {
+		return true
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.generations[agentID] == generation && (!open || h.closed[agentID] != generation)
+}
+
+// dispatchCurrent dispatches a message that arrived on connection generation of agentID.
+// The check runs when the message is processed, so messages still queued when a newer
+// connection registers are dropped as well. Processed messages are timed per queue.
+func (h *AgentHub) dispatchCurrent(agentID string, generation uint64, queue string, process func()) {
+	dispatched := time.Now()
+	// Calls completed or held as a connection closes still count
+	open := queue != queueCallComplete && queue != queueCallHold
+	h.dispatch(agentID, func() {
+		if !h.isCurrent(agentID, generation, open) {
+			metrics.Get().RecordAgentHubStale(queue)
+			return
+		}
+		start := time.Now()
+		process()
+		metrics.Get().RecordAgentHubMessage(queue, start.Sub(dispatched), time.Since(start))
+	})
+}
+
+// takeOver decides which of two connections of the same agent stays registered and tells
+// the other one it was superseded. It returns the connection to keep. Called with mu held.
+func (h *AgentHub) takeOver(existing, client *AgentClient) *AgentClient {
+	if existing.mux != nil && existing.mux == client.mux {
+		// Re-registration on the same multiplexed connection
+		return client
+	}
+	if existing.generation > client.generation {
+		// A newer connection registered first
+		h.supersede(client, existing.generation)
+		return existing
+	}
+	h.supersede(existing, client.generation)
+	return client
+}
+
+// supersede sends a replaced connection the "superseded" notice and closes it. An agent on a
+// multiplexed connection is only notified: the connection carries other agents, and its
+// remaining messages for this agent are dropped by generation.
+func (h *AgentHub) supersede(old *AgentClient, generation uint64) {
+	notice := types.ConnectionSuperseded{Type: "superseded", AgentID: old.agentID, Generation: generation}
+	if data, err := json.Marshal(notice); err == nil {
+		old.safeSend(data)
+	}
+	if old.mux == nil {
+		old.Close()
+	}
+	metrics.Get().RecordAgentTakeover()
+
+	h.logger.Info().
+		Str("agent_id", old.agentID).
+		Uint64("superseded_generation", old.generation).
+		Uint64("generation", generation).
+		Msg("agent connection taken over")
+}
@@ -0,0 +1,81 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/rs/zerolog"
+)
+
+func newTestAgentClient(hub *AgentHub, agentID string) *AgentClient {
+	client := NewAgentClient(hub, nil, zerolog.Nop())
+	client.agentID = agentID
+	client.generation = hub.nextGeneration(agentID)
+	return client
+}
+
+func expectSuperseded(t *testing.T, client *AgentClient, generation uint64) {
+	t.Helper()
+	data, ok := <-client.send
+	if !ok {
+		t.Fatal("expected a superseded notice before the connection closed")
+	}
+	var notice types.ConnectionSuperseded
+	if err := json.Unmarshal(data, &notice); err != nil {
+		t.Fatalf("failed to parse notice: %v", err)
+	}
+	if notice.Type != "superseded" || notice.AgentID != client.agentID || notice.Generation != generation {
+		t.Errorf("unexpected notice %+v", notice)
+	}
+	if _, ok := <-client.send; ok {
+		t.Error("expected the superseded connection to be closed")
+	}
+}
+
+func TestTakeoverSupersedesOlderConnection(t *testing.T) {
+	hub := NewAgentHub(cache.NewAgentStateTracker(), nil, zerolog.Nop())
+	old := newTestAgentClient(hub, "agent-1")
+	newer := newTestAgentClient(hub, "agent-1")
+
+	if kept := hub.takeOver(old, newer); kept != newer {
+		t.Fatal("expected the newer connection to take over")
+	}
+	expectSuperseded(t, old, newer.generation)
+}
+
+func TestTakeoverRejectsLateOlderConnection(t *testing.T) {
+	hub := NewAgentHub(cache.NewAgentStateTracker(), nil, zerolog.Nop())
+	old := newTestAgentClient(hub, "agent-1")
+	newer := newTestAgentClient(hub, "agent-1")
+
+	// The old connection's registration reaches the hub after the newer one's
+	if kept := hub.takeOver(newer, old); kept != newer {
+		t.Fatal("expected the newer connection to stay registered")
+	}
+	expectSuperseded(t, old, newer.generation)
+}
+
+func TestDispatchCurrentDropsSupersededMessages(t *testing.T) {
+	hub := NewAgentHub(cache.NewAgentStateTracker(), nil, zerolog.Nop())
+	old := newTestAgentClient(hub, "agent-1")
+	newer := newTestAgentClient(hub, "agent-1")
+
+	tests := []struct {
+		name       string
+		generation uint64
+		applied    bool
+	}{
+		{"superseded connection", old.generation, false},
+		{"newest connection", newer.generation, true},
+		{"untracked", 0, true},
+	}
+	for _, tt := range tests {
+		applied := false
+		hub.dispatchCurrent("agent-1", tt.generation, queueStateChange, func() { applied = true })
+		if applied != tt.applied {
+			t.Errorf("%s: expected applied=%v, got %v", tt.name, tt.applied, applied)
+		}
+	}
+}
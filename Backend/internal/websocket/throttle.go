@@ -0,0 +1,104 @@
+package websocket
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/metrics"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// throttleReleaseAfter is how long no heartbeat may be shed before the throttle is lifted
+const throttleReleaseAfter = 30 * time.Second
+
+// throttleState is the heartbeat throttle asked of agent connections while heartbeats are shed
+type throttleState struct {
+	mu       sync.Mutex
+	interval time.Duration // heartbeat interval asked for; 0 = never throttle
+	active   bool
+	lastShed time.Time
+}
+
+// SetHeartbeatThrottle sets the heartbeat interval agent connections are asked to slow down
+// to while heartbeats are shed (0 = never throttle; must be called before Run)
+func (h *AgentHub) SetHeartbeatThrottle(interval time.Duration) {
+	h.throttle.mu.Lock()
+	defer h.throttle.mu.Unlock()
+	h.throttle.interval = interval
+}
+
+// throttleOnShed engages the throttle when a heartbeat was shed
+func (h *AgentHub) throttleOnShed() {
+	h.throttle.mu.Lock()
+	h.throttle.lastShed = time.Now()
+	engage := !h.throttle.active && h.throttle.interval > 0
+	if engage {
+		h.throttle.active = true
+	}
+	interval := h.throttle.interval
+	h.throttle.mu.Unlock()
+
+	if engage {
+		metrics.Get().SetAgentHubThrottled(true)
+		h.logger.Warn().Dur("heartbeat_interval", interval).Msg("throttling agent heartbeats")
+		// Off the reader goroutine that shed the heartbeat
+		go h.broadcastThrottle(interval)
+	}
+}
+
+// releaseThrottle lifts the throttle once no heartbeat was shed for throttleReleaseAfter
+func (h *AgentHub) releaseThrottle(now time.Time) {
+	h.throttle.mu.Lock()
+	release := h.throttle.active && now.Sub(h.throttle.lastShed) >= throttleReleaseAfter
+	if release {
+		h.throttle.active = false
+	}
+	h.throttle.mu.Unlock()
+
+	if release {
+		metrics.Get().SetAgentHubThrottled(false)
+		h.logger.Info().Msg("agent heartbeat throttle lifted")
+		h.broadcastThrottle(0)
+	}
+}
+
+// throttleMessage returns the throttle message for a newly registered agent, or nil while
+// heartbeats are not throttled
+func (h *AgentHub) throttleMessage() []byte {
+	h.throttle.mu.Lock()
+	active, interval := h.throttle.active, h.throttle.interval
+	h.throttle.mu.Unlock()
+	if !active {
+		return nil
+	}
+	return h.marshalThrottle(interval)
+}
+
+// broadcastThrottle sends the throttle message once to every agent connection; interval 0
+// returns the senders to their own heartbeat interval
+func (h *AgentHub) broadcastThrottle(interval time.Duration) {
+	data := h.marshalThrottle(interval)
+	if data == nil {
+		return
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, client := range h.agents {
+		if client.mux == nil {
+			client.safeSend(data)
+		}
+	}
+	for client := range h.muxClients {
+		client.safeSend(data)
+	}
+}
+
+func (h *AgentHub) marshalThrottle(interval time.Duration) []byte {
+	data, err := json.Marshal(types.Throttle{Type: "throttle", HeartbeatInterval: int(interval / time.Second)})
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to marshal throttle")
+		return nil
+	}
+	return data
+}
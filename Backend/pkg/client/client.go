@@ -0,0 +1,179 @@
+// Package client is a Go client for the MONTI Backend REST API. The per-route methods in
+// operations.gen.go are generated from the same operation table that serves /api/openapi.json.
+package client
+
+//go:generate go run ../../cmd/clientgen -o operations.gen.go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// Error bodies, re-exported because their package is internal
+type (
+	ErrorResponse = types.ErrorResponse
+	FieldError    = types.FieldError
+)
+
+// Codes of APIError.Code
+const (
+	ErrCodeBadRequest        = types.ErrCodeBadRequest
+	ErrCodeValidation        = types.ErrCodeValidation
+	ErrCodeUnauthorized      = types.ErrCodeUnauthorized
+	ErrCodeForbidden         = types.ErrCodeForbidden
+	ErrCodeNotFound          = types.ErrCodeNotFound
+	ErrCodeMethodNotAllowed  = types.ErrCodeMethodNotAllowed
+	ErrCodeConflict          = types.ErrCodeConflict
+	ErrCodePayloadTooLarge   = types.ErrCodePayloadTooLarge
+	ErrCodeRateLimited       = types.ErrCodeRateLimited
+	ErrCodeInternal          = types.ErrCodeInternal
+	ErrCodeUpstream          = types.ErrCodeUpstream
+	ErrCodeUnavailable       = types.ErrCodeUnavailable
+	ErrCodeAgentNotConnected = types.ErrCodeAgentNotConnected
+	ErrCodeAgentOnCall       = types.ErrCodeAgentOnCall
+	ErrCodeLockedOut         = types.ErrCodeLockedOut
+)
+
+// Client calls the Backend REST API
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+
+	mu           sync.RWMutex // guards the tokens, which may be refreshed while a Subscriber runs
+	token        string
+	serviceToken string
+}
+
+// NewClient creates a client for the Backend at baseURL (e.g. "http://localhost:8080")
+func NewClient(baseURL string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// SetToken sets the OIDC access token sent as Authorization: Bearer
+func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	c.token = token
+	c.mu.Unlock()
+}
+
+// SetServiceToken sets the INTERNAL_AUTH_TOKEN sent as X-Internal-Token
+func (c *Client) SetServiceToken(token string) {
+	c.mu.Lock()
+	c.serviceToken = token
+	c.mu.Unlock()
+}
+
+// tokens returns the current bearer and service tokens
+func (c *Client) tokens() (string, string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.token, c.serviceToken
+}
+
+// SetHTTPClient replaces the underlying HTTP client (timeouts, transport)
+func (c *Client) SetHTTPClient(hc *http.Client) {
+	c.httpClient = hc
+}
+
+// APIError is a non-2xx response. Responses of the backend carry an ErrorResponse body whose
+// message, code and request ID are copied here; other bodies (e.g. from a proxy) leave them empty.
+type APIError struct {
+	StatusCode int
+	Message    string
+	Code       string // one of the ErrCode constants
+	RequestID  string // quote this when reporting a failure, it is in the backend's request log
+	Fields     []FieldError
+	Body       []byte
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("monti: %d %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("monti: unexpected status %d: %s", e.StatusCode, strings.TrimSpace(string(e.Body)))
+}
+
+// do sends an optional JSON body and decodes a JSON response into out (nil to discard)
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	var reader io.Reader
+	contentType := ""
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+		contentType = "application/json"
+	}
+
+	data, err := c.doRaw(ctx, method, path, query, contentType, reader)
+	if err != nil || out == nil {
+		return err
+	}
+	return decode(data, out)
+}
+
+// doRaw sends body as-is and returns the raw response body
+func (c *Client) doRaw(ctx context.Context, method, path string, query url.Values, contentType string, body io.Reader) ([]byte, error) {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	token, serviceToken := c.tokens()
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if serviceToken != "" {
+		req.Header.Set("X-Internal-Token", serviceToken)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &APIError{StatusCode: resp.StatusCode, Body: data}
+		var e ErrorResponse
+		if json.Unmarshal(data, &e) == nil {
+			apiErr.Message, apiErr.Code, apiErr.RequestID, apiErr.Fields = e.Error, e.Code, e.RequestID, e.Fields
+		}
+		return nil, apiErr
+	}
+	return data, nil
+}
+
+// decode unmarshals a JSON response body
+func decode(data []byte, out interface{}) error {
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("monti: failed to decode response: %w", err)
+	}
+	return nil
+}
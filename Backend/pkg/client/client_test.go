@@ -0,0 +1,156 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/api"
+	"github.com/dennisdiepolder/monti/backend/internal/openapi"
+	"github.com/gorilla/websocket"
+)
+
+func TestGeneratedOperationsUpToDate(t *testing.T) {
+	want, err := openapi.GenerateClient("client", api.Operations(), api.StreamTypes...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("operations.gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("operations.gen.go is stale, run go generate ./pkg/client")
+	}
+}
+
+func TestClientSendsAuthAndDecodes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/agents/agent-1/state":
+			var req ForceStateRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			json.NewEncoder(w).Encode(AgentActionResponse{Message: "state forced", AgentID: "agent-1", State: req.State})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"agent not found","code":"not_found","requestId":"host/abc-000001"}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL + "/")
+	c.SetToken("tok")
+
+	resp, err := c.ForceAgentState(context.Background(), "agent-1", ForceStateRequest{State: "break"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.AgentID != "agent-1" || resp.State != "break" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	_, err = c.LogoutAgent(context.Background(), "agent-2")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Message != "agent not found" ||
+		apiErr.Code != ErrCodeNotFound || apiErr.RequestID != "host/abc-000001" {
+		t.Errorf("expected 404 APIError, got %v", err)
+	}
+}
+
+func TestSubscriberReconnectsAndSkipsReplayedSnapshots(t *testing.T) {
+	t0 := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	snapshot := func(sec int) []byte {
+		data, _ := json.Marshal(Snapshot{Type: "snapshot", Timestamp: t0.Add(time.Duration(sec) * time.Second)})
+		return data
+	}
+
+	var mu sync.Mutex
+	var commands []ClientCommand
+	connections := 0
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		mu.Lock()
+		connections++
+		n := connections
+		mu.Unlock()
+
+		// The hub replays history on connect and batches queued messages with newlines
+		if n == 1 {
+			conn.WriteMessage(websocket.TextMessage, bytes.Join([][]byte{snapshot(1), snapshot(2)}, []byte("\n")))
+			var cmd ClientCommand
+			conn.ReadJSON(&cmd)
+			mu.Lock()
+			commands = append(commands, cmd)
+			mu.Unlock()
+			return // drop the connection
+		}
+		var cmd ClientCommand
+		conn.ReadJSON(&cmd)
+		mu.Lock()
+		commands = append(commands, cmd)
+		mu.Unlock()
+		conn.WriteMessage(websocket.TextMessage, bytes.Join([][]byte{snapshot(1), snapshot(2), snapshot(3)}, []byte("\n")))
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"agent_detail","event":"initial","agent":{"agentId":"agent-1"}}`))
+		conn.ReadMessage() // hold until the client goes away
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	c.SetToken("tok")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var got []time.Time
+	var details []string
+	sub := c.NewSubscriber(StreamHandlers{
+		Snapshot: func(s *Snapshot) {
+			if s.Departments == nil {
+				t.Error("expected normalised departments")
+			}
+			got = append(got, s.Timestamp)
+		},
+		AgentDetail: func(d AgentDetail) {
+			details = append(details, d.Agent.AgentID)
+			cancel()
+		},
+		Connected: func() {},
+	})
+	sub.backoff = func(int) time.Duration { return 10 * time.Millisecond }
+	sub.SubscribeAgent("agent-1")
+
+	if err := sub.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	if len(got) != 3 || !got[2].Equal(t0.Add(3*time.Second)) {
+		t.Errorf("expected snapshots 1, 2, 3 once each, got %v", got)
+	}
+	if len(details) != 1 || details[0] != "agent-1" {
+		t.Errorf("expected one agent_detail for agent-1, got %v", details)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(commands) != 2 || commands[1].Type != "subscribe_agent" || commands[1].AgentID != "agent-1" {
+		t.Errorf("expected subscribe_agent on both connections, got %+v", commands)
+	}
+}
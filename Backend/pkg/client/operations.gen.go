@@ -0,0 +1,963 @@
+// Code generated by cmd/clientgen from internal/api.Operations; DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"io"
+	"net/url"
+
+	"github.com/dennisdiepolder/monti/backend/internal/alerts"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+)
+
+// Types of the API bodies, re-exported because their package is internal
+type (
+	AHTPercentiles           = types.AHTPercentiles
+	AgentActionResponse      = types.AgentActionResponse
+	AgentAdherence           = types.AgentAdherence
+	AgentAlert               = types.AgentAlert
+	AgentAlertHistory        = types.AgentAlertHistory
+	AgentConnectionStatus    = types.AgentConnectionStatus
+	AgentDailyStats          = types.AgentDailyStats
+	AgentDetail              = types.AgentDetail
+	AgentDetailEvent         = types.AgentDetailEvent
+	AgentEvent               = types.AgentEvent
+	AgentEventList           = types.AgentEventList
+	AgentFilter              = types.AgentFilter
+	AgentInfo                = types.AgentInfo
+	AgentKPIs                = types.AgentKPIs
+	AgentList                = types.AgentList
+	AgentProductivity        = types.AgentProductivity
+	AgentState               = types.AgentState
+	Alert                    = types.Alert
+	AlertRecord              = types.AlertRecord
+	AlertRuleSummary         = types.AlertRuleSummary
+	AlertScope               = types.AlertScope
+	AlertSeverity            = types.AlertSeverity
+	AlertStatus              = types.AlertStatus
+	Announcement             = types.Announcement
+	AnnouncementRequest      = types.AnnouncementRequest
+	AnnouncementSeverity     = types.AnnouncementSeverity
+	BackfillRequest          = types.BackfillRequest
+	BackfillResponse         = types.BackfillResponse
+	BreakDepartmentStatus    = types.BreakDepartmentStatus
+	BreakReason              = types.BreakReason
+	BreakReasonStatus        = types.BreakReasonStatus
+	BreakStatus              = types.BreakStatus
+	BulkActionRequest        = types.BulkActionRequest
+	BulkActionResponse       = types.BulkActionResponse
+	BulkActionResult         = types.BulkActionResult
+	BusinessHoursResponse    = types.BusinessHoursResponse
+	BusinessHoursStatus      = types.BusinessHoursStatus
+	CalendarEvent            = types.CalendarEvent
+	CalendarImportResponse   = types.CalendarImportResponse
+	CallRecord               = types.CallRecord
+	Capabilities             = types.Capabilities
+	Channel                  = types.Channel
+	ChannelOccupancy         = types.ChannelOccupancy
+	ClientCommand            = types.ClientCommand
+	ClientCommandResult      = types.ClientCommandResult
+	ConfigResponse           = types.ConfigResponse
+	ConfigSetting            = types.ConfigSetting
+	ControlMessage           = types.ControlMessage
+	ControlMessageRequest    = types.ControlMessageRequest
+	ControlMessageResponse   = types.ControlMessageResponse
+	Degradation              = types.Degradation
+	DegradationLevel         = types.DegradationLevel
+	Department               = types.Department
+	DepartmentData           = types.DepartmentData
+	DryRunAgent              = types.DryRunAgent
+	DryRunAssignment         = types.DryRunAssignment
+	DryRunCall               = types.DryRunCall
+	EventLogReplayRequest    = types.EventLogReplayRequest
+	EventLogReplayResponse   = types.EventLogReplayResponse
+	EventLogReplayStats      = types.EventLogReplayStats
+	EventTiming              = types.EventTiming
+	ForceStateRequest        = types.ForceStateRequest
+	GrafanaAnnotation        = types.GrafanaAnnotation
+	GrafanaAnnotationEvent   = types.GrafanaAnnotationEvent
+	GrafanaAnnotationRequest = types.GrafanaAnnotationRequest
+	GrafanaColumn            = types.GrafanaColumn
+	GrafanaQueryRequest      = types.GrafanaQueryRequest
+	GrafanaQueryResult       = types.GrafanaQueryResult
+	GrafanaRange             = types.GrafanaRange
+	GrafanaSearchRequest     = types.GrafanaSearchRequest
+	GrafanaTarget            = types.GrafanaTarget
+	InjectCallsRequest       = types.InjectCallsRequest
+	InjectCallsResponse      = types.InjectCallsResponse
+	IntervalStat             = types.IntervalStat
+	IntervalStats            = types.IntervalStats
+	KPIWindow                = types.KPIWindow
+	Leaderboard              = types.Leaderboard
+	LeaderboardEntry         = types.LeaderboardEntry
+	LeaderboardMetric        = types.LeaderboardMetric
+	LeaderboardSettings      = types.LeaderboardSettings
+	LeaderboardUpdate        = types.LeaderboardUpdate
+	Location                 = types.Location
+	LogLevel                 = types.LogLevel
+	MessageResponse          = types.MessageResponse
+	ObservedKPIs             = types.ObservedKPIs
+	PlannedActivity          = types.PlannedActivity
+	ProductivityReport       = types.ProductivityReport
+	QueueEvent               = types.QueueEvent
+	QueueEventType           = types.QueueEventType
+	QueueList                = types.QueueList
+	QueuePauseResponse       = types.QueuePauseResponse
+	ReserveStatus            = types.ReserveStatus
+	ResetResponse            = types.ResetResponse
+	Rollup                   = types.Rollup
+	RollupDimension          = types.RollupDimension
+	RosterImportResponse     = types.RosterImportResponse
+	RosterRecord             = types.RosterRecord
+	RoutingDryRunRequest     = types.RoutingDryRunRequest
+	RoutingDryRunResponse    = types.RoutingDryRunResponse
+	Rule                     = alerts.Rule
+	RuleType                 = alerts.RuleType
+	SLTargetRequest          = types.SLTargetRequest
+	ScheduleImportResponse   = types.ScheduleImportResponse
+	ScheduleInterval         = types.ScheduleInterval
+	ServiceLevel             = types.ServiceLevel
+	ShadowRoutingReport      = types.ShadowRoutingReport
+	ShadowRoutingRequest     = types.ShadowRoutingRequest
+	SimPreset                = types.SimPreset
+	SimPresetApplyResponse   = types.SimPresetApplyResponse
+	SimScaleRequest          = types.SimScaleRequest
+	SimScheduleEntry         = types.SimScheduleEntry
+	SimScheduleRun           = types.SimScheduleRun
+	SimScheduleStatus        = types.SimScheduleStatus
+	SimStatus                = types.SimStatus
+	SimStep                  = types.SimStep
+	Snapshot                 = types.Snapshot
+	SnapshotHistory          = types.SnapshotHistory
+	SnapshotTrends           = types.SnapshotTrends
+	StaffingForecast         = types.StaffingForecast
+	Team                     = types.Team
+	TeamAdherence            = types.TeamAdherence
+	TeamSummary              = types.TeamSummary
+	Trend                    = types.Trend
+	TrendSet                 = types.TrendSet
+	VQName                   = types.VQName
+	VQSnapshot               = types.VQSnapshot
+	WSClientInfo             = types.WSClientInfo
+	WSClientsResponse        = types.WSClientsResponse
+	Wallboard                = types.Wallboard
+	WallboardAgentAlert      = types.WallboardAgentAlert
+	WallboardDepartment      = types.WallboardDepartment
+	WidgetSummary            = types.WidgetSummary
+	WipeCallsResponse        = types.WipeCallsResponse
+)
+
+// GetOpenAPIDocument calls GET /api/openapi.json.
+// Get this OpenAPI document.
+func (c *Client) GetOpenAPIDocument(ctx context.Context) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "GET", "/api/openapi.json", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetWSSchema calls GET /api/schema/ws.
+// Get the JSON Schema of the WebSocket messages.
+func (c *Client) GetWSSchema(ctx context.Context) ([]byte, error) {
+	return c.doRaw(ctx, "GET", "/api/schema/ws", nil, "", nil)
+}
+
+// ListAgents calls GET /api/agents.
+// List agents visible to the caller.
+func (c *Client) ListAgents(ctx context.Context, query url.Values) (*AgentList, error) {
+	var out AgentList
+	if err := c.do(ctx, "GET", "/api/agents", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListRecentEvents calls GET /api/events.
+// List recent raw agent events.
+func (c *Client) ListRecentEvents(ctx context.Context, query url.Values) (*AgentEventList, error) {
+	var out AgentEventList
+	if err := c.do(ctx, "GET", "/api/events", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListQueues calls GET /api/queues.
+// List virtual queues.
+func (c *Client) ListQueues(ctx context.Context, query url.Values) (*QueueList, error) {
+	var out QueueList
+	if err := c.do(ctx, "GET", "/api/queues", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListQueueEvents calls GET /api/queues/{vq}/events.
+// List a queue's pauses, SL target changes and overflows on a day.
+func (c *Client) ListQueueEvents(ctx context.Context, vq string, query url.Values) ([]QueueEvent, error) {
+	var out []QueueEvent
+	if err := c.do(ctx, "GET", "/api/queues/"+url.PathEscape(vq)+"/events", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListTeams calls GET /api/teams.
+// List teams visible to the caller.
+func (c *Client) ListTeams(ctx context.Context) ([]Team, error) {
+	var out []Team
+	if err := c.do(ctx, "GET", "/api/teams", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetTeamSummary calls GET /api/teams/{team}/summary.
+// Get a team's live states, today's KPIs, queues and top alerts in one call.
+func (c *Client) GetTeamSummary(ctx context.Context, team string) (*TeamSummary, error) {
+	var out TeamSummary
+	if err := c.do(ctx, "GET", "/api/teams/"+url.PathEscape(team)+"/summary", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetBusinessHours calls GET /api/hours.
+// Get which departments are within business hours.
+func (c *Client) GetBusinessHours(ctx context.Context) (*BusinessHoursResponse, error) {
+	var out BusinessHoursResponse
+	if err := c.do(ctx, "GET", "/api/hours", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetWallboard calls GET /api/wallboard.
+// Get the wallboard summary.
+func (c *Client) GetWallboard(ctx context.Context) (*Wallboard, error) {
+	var out Wallboard
+	if err := c.do(ctx, "GET", "/api/wallboard", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAgentHistory calls GET /api/agents/{agentId}/history.
+// Get an agent's daily stats.
+func (c *Client) GetAgentHistory(ctx context.Context, agentID string) ([]AgentDailyStats, error) {
+	var out []AgentDailyStats
+	if err := c.do(ctx, "GET", "/api/agents/"+url.PathEscape(agentID)+"/history", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetAgentCalls calls GET /api/agents/{agentId}/calls.
+// Get an agent's calls for a day.
+func (c *Client) GetAgentCalls(ctx context.Context, agentID string, query url.Values) ([]CallRecord, error) {
+	var out []CallRecord
+	if err := c.do(ctx, "GET", "/api/agents/"+url.PathEscape(agentID)+"/calls", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ForceEndCall calls POST /api/agents/{agentId}/calls/{callId}/end.
+// Force-end an agent's active call.
+func (c *Client) ForceEndCall(ctx context.Context, agentID string, callID string) (*AgentActionResponse, error) {
+	var out AgentActionResponse
+	if err := c.do(ctx, "POST", "/api/agents/"+url.PathEscape(agentID)+"/calls/"+url.PathEscape(callID)+"/end", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// LogoutAgent calls POST /api/agents/{agentId}/logout.
+// Log an agent out.
+func (c *Client) LogoutAgent(ctx context.Context, agentID string) (*AgentActionResponse, error) {
+	var out AgentActionResponse
+	if err := c.do(ctx, "POST", "/api/agents/"+url.PathEscape(agentID)+"/logout", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ForceAgentState calls POST /api/agents/{agentId}/state.
+// Force an agent into a state.
+func (c *Client) ForceAgentState(ctx context.Context, agentID string, body ForceStateRequest) (*AgentActionResponse, error) {
+	var out AgentActionResponse
+	if err := c.do(ctx, "POST", "/api/agents/"+url.PathEscape(agentID)+"/state", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// BulkAgentAction calls POST /api/agents/bulk.
+// Log out or force the state of every agent matching a filter.
+func (c *Client) BulkAgentAction(ctx context.Context, body BulkActionRequest) (*BulkActionResponse, error) {
+	var out BulkActionResponse
+	if err := c.do(ctx, "POST", "/api/agents/bulk", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListAlerts calls GET /api/alerts.
+// List live alerts, or the alert history of a day.
+func (c *Client) ListAlerts(ctx context.Context, query url.Values) ([]AlertRecord, error) {
+	var out []AlertRecord
+	if err := c.do(ctx, "GET", "/api/alerts", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetAgentAlerts calls GET /api/agents/{agentId}/alerts.
+// Get an agent's alerts of a day, with a count and duration per rule.
+func (c *Client) GetAgentAlerts(ctx context.Context, agentID string, query url.Values) (*AgentAlertHistory, error) {
+	var out AgentAlertHistory
+	if err := c.do(ctx, "GET", "/api/agents/"+url.PathEscape(agentID)+"/alerts", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AcknowledgeAlert calls POST /api/alerts/{alertId}/ack.
+// Acknowledge a firing alert.
+func (c *Client) AcknowledgeAlert(ctx context.Context, alertID string) (*AlertRecord, error) {
+	var out AlertRecord
+	if err := c.do(ctx, "POST", "/api/alerts/"+url.PathEscape(alertID)+"/ack", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PutSchedules calls PUT /api/schedules.
+// Replace the schedule intervals of the agent-days in the body.
+func (c *Client) PutSchedules(ctx context.Context, body []ScheduleInterval) (*ScheduleImportResponse, error) {
+	var out ScheduleImportResponse
+	if err := c.do(ctx, "PUT", "/api/schedules", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ImportSchedulesCSV calls POST /api/schedules/import.
+// Import schedule intervals from CSV.
+func (c *Client) ImportSchedulesCSV(ctx context.Context, body io.Reader) (*ScheduleImportResponse, error) {
+	data, err := c.doRaw(ctx, "POST", "/api/schedules/import", nil, "text/csv", body)
+	if err != nil {
+		return nil, err
+	}
+	var out ScheduleImportResponse
+	if err := decode(data, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetSchedule calls GET /api/schedules/{agentId}.
+// Get an agent's schedule for a day.
+func (c *Client) GetSchedule(ctx context.Context, agentID string, query url.Values) ([]ScheduleInterval, error) {
+	var out []ScheduleInterval
+	if err := c.do(ctx, "GET", "/api/schedules/"+url.PathEscape(agentID), query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetAgentAdherence calls GET /api/adherence/agents/{agentId}.
+// Get an agent's schedule adherence.
+func (c *Client) GetAgentAdherence(ctx context.Context, agentID string, query url.Values) (*AgentAdherence, error) {
+	var out AgentAdherence
+	if err := c.do(ctx, "GET", "/api/adherence/agents/"+url.PathEscape(agentID), query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTeamAdherence calls GET /api/adherence/teams/{team}.
+// Get a team's schedule adherence.
+func (c *Client) GetTeamAdherence(ctx context.Context, team string, query url.Values) (*TeamAdherence, error) {
+	var out TeamAdherence
+	if err := c.do(ctx, "GET", "/api/adherence/teams/"+url.PathEscape(team), query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAgentProductivity calls GET /api/reports/agents.
+// Get per-agent, per-interval calls, AHT, occupancy and adherence of a day.
+func (c *Client) GetAgentProductivity(ctx context.Context, query url.Values) (*ProductivityReport, error) {
+	var out ProductivityReport
+	if err := c.do(ctx, "GET", "/api/reports/agents", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// TestGrafanaConnection calls GET /api/grafana/.
+// Connection test of the Grafana JSON datasource.
+func (c *Client) TestGrafanaConnection(ctx context.Context) (map[string]string, error) {
+	var out map[string]string
+	if err := c.do(ctx, "GET", "/api/grafana/", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SearchGrafanaMetrics calls POST /api/grafana/search.
+// List the metrics a Grafana panel can query.
+func (c *Client) SearchGrafanaMetrics(ctx context.Context, body GrafanaSearchRequest) ([]string, error) {
+	var out []string
+	if err := c.do(ctx, "POST", "/api/grafana/search", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// QueryGrafana calls POST /api/grafana/query.
+// Get time series of interval and daily VQ stats, or the alerts table, for a time range.
+func (c *Client) QueryGrafana(ctx context.Context, body GrafanaQueryRequest) ([]GrafanaQueryResult, error) {
+	var out []GrafanaQueryResult
+	if err := c.do(ctx, "POST", "/api/grafana/query", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetGrafanaAnnotations calls POST /api/grafana/annotations.
+// Get the alerts fired in a time range as annotations.
+func (c *Client) GetGrafanaAnnotations(ctx context.Context, body GrafanaAnnotationRequest) ([]GrafanaAnnotationEvent, error) {
+	var out []GrafanaAnnotationEvent
+	if err := c.do(ctx, "POST", "/api/grafana/annotations", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListCalendar calls GET /api/calendar.
+// List a day's team meetings and trainings.
+func (c *Client) ListCalendar(ctx context.Context, query url.Values) ([]CalendarEvent, error) {
+	var out []CalendarEvent
+	if err := c.do(ctx, "GET", "/api/calendar", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PutCalendarEvents calls POST /api/calendar.
+// Add calendar events, replacing events with the same ID.
+func (c *Client) PutCalendarEvents(ctx context.Context, body []CalendarEvent) (*CalendarImportResponse, error) {
+	var out CalendarImportResponse
+	if err := c.do(ctx, "POST", "/api/calendar", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ImportCalendarCSV calls POST /api/calendar/import.
+// Import calendar events from CSV.
+func (c *Client) ImportCalendarCSV(ctx context.Context, body io.Reader) (*CalendarImportResponse, error) {
+	data, err := c.doRaw(ctx, "POST", "/api/calendar/import", nil, "text/csv", body)
+	if err != nil {
+		return nil, err
+	}
+	var out CalendarImportResponse
+	if err := decode(data, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ImportCalendarICS calls POST /api/calendar/import/ics.
+// Import calendar events from iCalendar.
+func (c *Client) ImportCalendarICS(ctx context.Context, query url.Values, body io.Reader) (*CalendarImportResponse, error) {
+	data, err := c.doRaw(ctx, "POST", "/api/calendar/import/ics", query, "text/calendar", body)
+	if err != nil {
+		return nil, err
+	}
+	var out CalendarImportResponse
+	if err := decode(data, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteCalendarEvent calls DELETE /api/calendar/{eventId}.
+// Delete a calendar event.
+func (c *Client) DeleteCalendarEvent(ctx context.Context, eventID string) error {
+	return c.do(ctx, "DELETE", "/api/calendar/"+url.PathEscape(eventID), nil, nil, nil)
+}
+
+// GetStaffingForecast calls GET /api/forecast/staffing.
+// Forecast required staffing per VQ.
+func (c *Client) GetStaffingForecast(ctx context.Context, query url.Values) ([]StaffingForecast, error) {
+	var out []StaffingForecast
+	if err := c.do(ctx, "GET", "/api/forecast/staffing", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetBreakStatus calls GET /api/breaks.
+// Get each department's breaks against the break caps.
+func (c *Client) GetBreakStatus(ctx context.Context, query url.Values) (*BreakStatus, error) {
+	var out BreakStatus
+	if err := c.do(ctx, "GET", "/api/breaks", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetConfig calls GET /api/admin/config.
+// Get the effective configuration with secrets redacted.
+func (c *Client) GetConfig(ctx context.Context) (*ConfigResponse, error) {
+	var out ConfigResponse
+	if err := c.do(ctx, "GET", "/api/admin/config", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCapabilities calls GET /api/admin/capabilities.
+// Get the optional features this deployment has.
+func (c *Client) GetCapabilities(ctx context.Context) (*Capabilities, error) {
+	var out Capabilities
+	if err := c.do(ctx, "GET", "/api/admin/capabilities", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetLogLevel calls GET /api/admin/loglevel.
+// Get the global log level.
+func (c *Client) GetLogLevel(ctx context.Context) (*LogLevel, error) {
+	var out LogLevel
+	if err := c.do(ctx, "GET", "/api/admin/loglevel", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetLogLevel calls PUT /api/admin/loglevel.
+// Change the global log level at runtime.
+func (c *Client) SetLogLevel(ctx context.Context, body LogLevel) (*LogLevel, error) {
+	var out LogLevel
+	if err := c.do(ctx, "PUT", "/api/admin/loglevel", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetSimStatus calls GET /api/admin/sim/status.
+// Get the AgentSim status.
+func (c *Client) GetSimStatus(ctx context.Context) (*SimStatus, error) {
+	var out SimStatus
+	if err := c.do(ctx, "GET", "/api/admin/sim/status", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StartSim calls POST /api/admin/sim/start.
+// Start the simulation.
+func (c *Client) StartSim(ctx context.Context, body SimScaleRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "POST", "/api/admin/sim/start", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StopSim calls POST /api/admin/sim/stop.
+// Stop the simulation.
+func (c *Client) StopSim(ctx context.Context) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "POST", "/api/admin/sim/stop", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ScaleSim calls POST /api/admin/sim/scale.
+// Change the number of simulated agents.
+func (c *Client) ScaleSim(ctx context.Context, body SimScaleRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "POST", "/api/admin/sim/scale", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListSimPresets calls GET /api/admin/sim/presets.
+// List the saved sim presets.
+func (c *Client) ListSimPresets(ctx context.Context) ([]SimPreset, error) {
+	var out []SimPreset
+	if err := c.do(ctx, "GET", "/api/admin/sim/presets", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SaveSimPreset calls POST /api/admin/sim/presets.
+// Create or replace a sim preset by name.
+func (c *Client) SaveSimPreset(ctx context.Context, body SimPreset) (*SimPreset, error) {
+	var out SimPreset
+	if err := c.do(ctx, "POST", "/api/admin/sim/presets", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteSimPreset calls DELETE /api/admin/sim/presets/{name}.
+// Delete a sim preset.
+func (c *Client) DeleteSimPreset(ctx context.Context, name string) error {
+	return c.do(ctx, "DELETE", "/api/admin/sim/presets/"+url.PathEscape(name), nil, nil, nil)
+}
+
+// ApplySimPreset calls POST /api/admin/sim/presets/{name}/apply.
+// Configure AgentSim from a sim preset.
+func (c *Client) ApplySimPreset(ctx context.Context, name string) (*SimPresetApplyResponse, error) {
+	var out SimPresetApplyResponse
+	if err := c.do(ctx, "POST", "/api/admin/sim/presets/"+url.PathEscape(name)+"/apply", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetSimSchedule calls GET /api/admin/sim/schedule.
+// Get the sim schedule with its next and latest run.
+func (c *Client) GetSimSchedule(ctx context.Context) (*SimScheduleStatus, error) {
+	var out SimScheduleStatus
+	if err := c.do(ctx, "GET", "/api/admin/sim/schedule", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCallConfig calls GET /api/admin/calls/config.
+// Get the AgentSim call generator config.
+func (c *Client) GetCallConfig(ctx context.Context) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "GET", "/api/admin/calls/config", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpdateCallConfig calls PUT /api/admin/calls/config.
+// Update the AgentSim call generator config.
+func (c *Client) UpdateCallConfig(ctx context.Context, body map[string]interface{}) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "PUT", "/api/admin/calls/config", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// InjectCalls calls POST /api/admin/calls/inject.
+// Enqueue calls directly.
+func (c *Client) InjectCalls(ctx context.Context, body InjectCallsRequest) (*InjectCallsResponse, error) {
+	var out InjectCallsResponse
+	if err := c.do(ctx, "POST", "/api/admin/calls/inject", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// WipeAllCalls calls DELETE /api/admin/calls/all.
+// Clear every queued and active call.
+func (c *Client) WipeAllCalls(ctx context.Context) (*WipeCallsResponse, error) {
+	var out WipeCallsResponse
+	if err := c.do(ctx, "DELETE", "/api/admin/calls/all", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ResetMemory calls POST /api/admin/reset/memory.
+// Clear in-memory agents and calls.
+func (c *Client) ResetMemory(ctx context.Context) (*ResetResponse, error) {
+	var out ResetResponse
+	if err := c.do(ctx, "POST", "/api/admin/reset/memory", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ReplayEventLog calls POST /api/admin/eventlog/replay.
+// Rebuild agents and calls from the event log.
+func (c *Client) ReplayEventLog(ctx context.Context, body EventLogReplayRequest) (*EventLogReplayResponse, error) {
+	var out EventLogReplayResponse
+	if err := c.do(ctx, "POST", "/api/admin/eventlog/replay", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// BackfillDailyStats calls POST /api/admin/backfill/daily-stats.
+// Recompute the agent and VQ daily stats of a range of days from the call records.
+func (c *Client) BackfillDailyStats(ctx context.Context, body BackfillRequest) (*BackfillResponse, error) {
+	var out BackfillResponse
+	if err := c.do(ctx, "POST", "/api/admin/backfill/daily-stats", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetShadowRouting calls GET /api/admin/routing/shadow.
+// Compare the shadow routing strategy's choices with the active strategy's.
+func (c *Client) GetShadowRouting(ctx context.Context) (*ShadowRoutingReport, error) {
+	var out ShadowRoutingReport
+	if err := c.do(ctx, "GET", "/api/admin/routing/shadow", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetShadowRouting calls PUT /api/admin/routing/shadow.
+// Start evaluating a shadow routing strategy.
+func (c *Client) SetShadowRouting(ctx context.Context, body ShadowRoutingRequest) (*ShadowRoutingReport, error) {
+	var out ShadowRoutingReport
+	if err := c.do(ctx, "PUT", "/api/admin/routing/shadow", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DryRunRouting calls POST /api/admin/routing/dry-run.
+// Show which agents would take hypothetical calls.
+func (c *Client) DryRunRouting(ctx context.Context, body RoutingDryRunRequest) (*RoutingDryRunResponse, error) {
+	var out RoutingDryRunResponse
+	if err := c.do(ctx, "POST", "/api/admin/routing/dry-run", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PauseQueue calls POST /api/admin/queues/{vq}/pause.
+// Stop routing a queue's calls.
+func (c *Client) PauseQueue(ctx context.Context, vq string) (*QueuePauseResponse, error) {
+	var out QueuePauseResponse
+	if err := c.do(ctx, "POST", "/api/admin/queues/"+url.PathEscape(vq)+"/pause", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ResumeQueue calls POST /api/admin/queues/{vq}/resume.
+// Resume routing a paused queue.
+func (c *Client) ResumeQueue(ctx context.Context, vq string) (*QueuePauseResponse, error) {
+	var out QueuePauseResponse
+	if err := c.do(ctx, "POST", "/api/admin/queues/"+url.PathEscape(vq)+"/resume", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetQueueSLTarget calls PUT /api/admin/queues/{vq}/sl.
+// Change a queue's SL target and threshold.
+func (c *Client) SetQueueSLTarget(ctx context.Context, vq string, body SLTargetRequest) (*ServiceLevel, error) {
+	var out ServiceLevel
+	if err := c.do(ctx, "PUT", "/api/admin/queues/"+url.PathEscape(vq)+"/sl", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListWSClients calls GET /api/admin/ws/clients.
+// List connected dashboard clients.
+func (c *Client) ListWSClients(ctx context.Context) (*WSClientsResponse, error) {
+	var out WSClientsResponse
+	if err := c.do(ctx, "GET", "/api/admin/ws/clients", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SendWSControl calls POST /api/admin/ws/control.
+// Push a control message to dashboard clients.
+func (c *Client) SendWSControl(ctx context.Context, body ControlMessageRequest) (*ControlMessageResponse, error) {
+	var out ControlMessageResponse
+	if err := c.do(ctx, "POST", "/api/admin/ws/control", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListAnnouncements calls GET /api/admin/announcements.
+// List active announcements.
+func (c *Client) ListAnnouncements(ctx context.Context) ([]Announcement, error) {
+	var out []Announcement
+	if err := c.do(ctx, "GET", "/api/admin/announcements", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PublishAnnouncement calls POST /api/admin/announcements.
+// Publish an announcement to dashboards.
+func (c *Client) PublishAnnouncement(ctx context.Context, body AnnouncementRequest) (*Announcement, error) {
+	var out Announcement
+	if err := c.do(ctx, "POST", "/api/admin/announcements", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// WithdrawAnnouncement calls DELETE /api/admin/announcements/{announcementId}.
+// Withdraw an announcement.
+func (c *Client) WithdrawAnnouncement(ctx context.Context, announcementID string) error {
+	return c.do(ctx, "DELETE", "/api/admin/announcements/"+url.PathEscape(announcementID), nil, nil, nil)
+}
+
+// WipeStorage calls DELETE /api/admin/reset/dynamo.
+// Delete all persisted history.
+func (c *Client) WipeStorage(ctx context.Context) (*MessageResponse, error) {
+	var out MessageResponse
+	if err := c.do(ctx, "DELETE", "/api/admin/reset/dynamo", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// LogoffAll calls POST /api/admin/agents/logoff-all.
+// Log off every agent.
+func (c *Client) LogoffAll(ctx context.Context) (*ResetResponse, error) {
+	var out ResetResponse
+	if err := c.do(ctx, "POST", "/api/admin/agents/logoff-all", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListRoster calls GET /api/admin/roster.
+// List the roster.
+func (c *Client) ListRoster(ctx context.Context) ([]RosterRecord, error) {
+	var out []RosterRecord
+	if err := c.do(ctx, "GET", "/api/admin/roster", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AddRosterAgent calls POST /api/admin/roster.
+// Add an agent to the roster.
+func (c *Client) AddRosterAgent(ctx context.Context, body RosterRecord) (*RosterRecord, error) {
+	var out RosterRecord
+	if err := c.do(ctx, "POST", "/api/admin/roster", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ImportRosterCSV calls POST /api/admin/roster/import.
+// Import the roster from CSV.
+func (c *Client) ImportRosterCSV(ctx context.Context, body io.Reader) (*RosterImportResponse, error) {
+	data, err := c.doRaw(ctx, "POST", "/api/admin/roster/import", nil, "text/csv", body)
+	if err != nil {
+		return nil, err
+	}
+	var out RosterImportResponse
+	if err := decode(data, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ExportRosterCSV calls GET /api/admin/roster/export.
+// Export the roster as CSV.
+func (c *Client) ExportRosterCSV(ctx context.Context) ([]byte, error) {
+	return c.doRaw(ctx, "GET", "/api/admin/roster/export", nil, "", nil)
+}
+
+// UpdateRosterAgent calls PUT /api/admin/roster/{agentId}.
+// Create or update a roster agent.
+func (c *Client) UpdateRosterAgent(ctx context.Context, agentID string, body RosterRecord) (*RosterRecord, error) {
+	var out RosterRecord
+	if err := c.do(ctx, "PUT", "/api/admin/roster/"+url.PathEscape(agentID), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RetireRosterAgent calls DELETE /api/admin/roster/{agentId}.
+// Retire a roster agent.
+func (c *Client) RetireRosterAgent(ctx context.Context, agentID string) error {
+	return c.do(ctx, "DELETE", "/api/admin/roster/"+url.PathEscape(agentID), nil, nil, nil)
+}
+
+// PutTeam calls PUT /api/admin/teams/{team}.
+// Create or replace a team.
+func (c *Client) PutTeam(ctx context.Context, team string, body Team) (*Team, error) {
+	var out Team
+	if err := c.do(ctx, "PUT", "/api/admin/teams/"+url.PathEscape(team), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteTeam calls DELETE /api/admin/teams/{team}.
+// Delete a team.
+func (c *Client) DeleteTeam(ctx context.Context, team string) error {
+	return c.do(ctx, "DELETE", "/api/admin/teams/"+url.PathEscape(team), nil, nil, nil)
+}
+
+// GetLeaderboardSettings calls GET /api/admin/leaderboards.
+// Get the leaderboard settings.
+func (c *Client) GetLeaderboardSettings(ctx context.Context) (*LeaderboardSettings, error) {
+	var out LeaderboardSettings
+	if err := c.do(ctx, "GET", "/api/admin/leaderboards", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateLeaderboardSettings calls PUT /api/admin/leaderboards.
+// Update the leaderboard settings.
+func (c *Client) UpdateLeaderboardSettings(ctx context.Context, body LeaderboardUpdate) (*LeaderboardSettings, error) {
+	var out LeaderboardSettings
+	if err := c.do(ctx, "PUT", "/api/admin/leaderboards", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListAlertRules calls GET /api/admin/alert-rules.
+// List alert rules.
+func (c *Client) ListAlertRules(ctx context.Context) ([]Rule, error) {
+	var out []Rule
+	if err := c.do(ctx, "GET", "/api/admin/alert-rules", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetAlertRule calls GET /api/admin/alert-rules/{ruleId}.
+// Get an alert rule.
+func (c *Client) GetAlertRule(ctx context.Context, ruleID string) (*Rule, error) {
+	var out Rule
+	if err := c.do(ctx, "GET", "/api/admin/alert-rules/"+url.PathEscape(ruleID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PutAlertRule calls PUT /api/admin/alert-rules/{ruleId}.
+// Create or replace an alert rule.
+func (c *Client) PutAlertRule(ctx context.Context, ruleID string, body Rule) (*Rule, error) {
+	var out Rule
+	if err := c.do(ctx, "PUT", "/api/admin/alert-rules/"+url.PathEscape(ruleID), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteAlertRule calls DELETE /api/admin/alert-rules/{ruleId}.
+// Delete an alert rule.
+func (c *Client) DeleteAlertRule(ctx context.Context, ruleID string) error {
+	return c.do(ctx, "DELETE", "/api/admin/alert-rules/"+url.PathEscape(ruleID), nil, nil, nil)
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Shorthands for the admin simulation routes, named like AgentSim's own client so tools can
+// switch between talking to AgentSim directly and going through the Backend.
+
+// GetStatus retrieves the current simulation status
+func (c *Client) GetStatus(ctx context.Context) (*SimStatus, error) {
+	return c.GetSimStatus(ctx)
+}
+
+// Start starts the simulation with the specified number of active agents
+func (c *Client) Start(ctx context.Context, activeAgents int) error {
+	_, err := c.StartSim(ctx, SimScaleRequest{ActiveAgents: activeAgents})
+	return err
+}
+
+// Stop stops the simulation
+func (c *Client) Stop(ctx context.Context) error {
+	_, err := c.StopSim(ctx)
+	return err
+}
+
+// Scale changes the number of active simulated agents
+func (c *Client) Scale(ctx context.Context, activeAgents int) error {
+	_, err := c.ScaleSim(ctx, SimScaleRequest{ActiveAgents: activeAgents})
+	return err
+}
+
+// Inject enqueues count calls into vq (empty = round-robin over all VQs)
+func (c *Client) Inject(ctx context.Context, count int, vq string) (*InjectCallsResponse, error) {
+	return c.InjectCalls(ctx, InjectCallsRequest{Count: count, VQ: vq})
+}
+
+// Health checks if the Backend is healthy
+func (c *Client) Health(ctx context.Context) error {
+	_, err := c.doRaw(ctx, http.MethodGet, "/health", nil, "", nil)
+	return err
+}
@@ -0,0 +1,295 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	initialBackoff = time.Second
+	maxBackoff     = 30 * time.Second
+
+	// readTimeout closes a connection that delivered neither a message nor a ping for this long
+	readTimeout = 30 * time.Second
+	writeWait   = 10 * time.Second
+)
+
+// StreamHandlers receive /ws messages. Nil handlers are skipped. They run on the subscriber's
+// goroutine, so they should return quickly.
+type StreamHandlers struct {
+	Snapshot      func(*Snapshot)
+	AgentDetail   func(AgentDetail)
+	CommandResult func(ClientCommandResult)
+	Control       func(ControlMessage) // reload requests and notices pushed by admins
+	Connected     func()
+	Disconnected  func(err error) // err is why the connection ended or could not be opened
+}
+
+// Subscriber follows the frontend WebSocket (/ws) as the client's token, reconnecting with
+// exponential backoff until its context is cancelled.
+//
+// Snapshots arrive already filtered to the token's RBAC scope: departments outside it are
+// absent, and departments without visible agents carry no queues. The subscriber normalises
+// them so that Departments, Agents and Queues are never nil. Snapshots are delivered in
+// timestamp order. The history the server replays on connect is only delivered up to the
+// last snapshot seen before a reconnect.
+type Subscriber struct {
+	client   *Client
+	handlers StreamHandlers
+	dialer   *websocket.Dialer
+
+	// backoff is the wait before reconnect attempt n (1-based); replaced in tests
+	backoff func(n int) time.Duration
+
+	mu     sync.Mutex
+	conn   *websocket.Conn
+	agents map[string]bool // drill-down subscriptions, re-sent after a reconnect
+	last   time.Time       // timestamp of the last delivered snapshot
+}
+
+// NewSubscriber creates a subscriber for the client's Backend; call Run to connect
+func (c *Client) NewSubscriber(h StreamHandlers) *Subscriber {
+	return &Subscriber{
+		client:   c,
+		handlers: h,
+		dialer:   &websocket.Dialer{HandshakeTimeout: 10 * time.Second},
+		backoff:  exponentialBackoff,
+		agents:   make(map[string]bool),
+	}
+}
+
+// exponentialBackoff doubles the wait per attempt up to maxBackoff
+func exponentialBackoff(n int) time.Duration {
+	d := initialBackoff << (n - 1)
+	if d > maxBackoff || d <= 0 {
+		return maxBackoff
+	}
+	return d
+}
+
+// Run connects and dispatches messages until ctx is done, then returns ctx.Err()
+func (s *Subscriber) Run(ctx context.Context) error {
+	attempt := 0
+	for {
+		connected, err := s.session(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if s.handlers.Disconnected != nil {
+			s.handlers.Disconnected(err)
+		}
+		if connected {
+			attempt = 0
+		}
+		attempt++
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.backoff(attempt)):
+		}
+	}
+}
+
+// SubscribeAgent streams agent_detail messages for one agent (the server allows 5 per connection).
+// The subscription is kept across reconnects until the server refuses it or UnsubscribeAgent.
+func (s *Subscriber) SubscribeAgent(agentID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.agents[agentID] = true
+	return s.send(ClientCommand{Type: "subscribe_agent", AgentID: agentID})
+}
+
+// UnsubscribeAgent stops agent_detail messages for one agent
+func (s *Subscriber) UnsubscribeAgent(agentID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.agents, agentID)
+	return s.send(ClientCommand{Type: "unsubscribe_agent", AgentID: agentID})
+}
+
+// send writes a command if connected; otherwise it is sent on the next connect. Caller holds mu.
+func (s *Subscriber) send(cmd ClientCommand) error {
+	if s.conn == nil {
+		return nil
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return s.conn.WriteJSON(cmd)
+}
+
+// session runs one connection and reports whether the handshake succeeded
+func (s *Subscriber) session(ctx context.Context) (bool, error) {
+	token, _ := s.client.tokens()
+	header := http.Header{}
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+
+	// Snapshots are decoded into this package's types, which follow the current schema
+	url := websocketURL(s.client.baseURL) + "/ws?schemaVersions=" + strconv.Itoa(types.SnapshotSchemaVersion)
+	conn, resp, err := s.dialer.DialContext(ctx, url, header)
+	if err != nil {
+		if resp != nil {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+			resp.Body.Close()
+			return false, &APIError{StatusCode: resp.StatusCode, Body: body}
+		}
+		return false, err
+	}
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	defer func() {
+		s.mu.Lock()
+		s.conn = nil
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	s.mu.Lock()
+	s.conn = conn
+	for agentID := range s.agents {
+		if err := s.send(ClientCommand{Type: "subscribe_agent", AgentID: agentID}); err != nil {
+			s.mu.Unlock()
+			return true, err
+		}
+	}
+	s.mu.Unlock()
+	if s.handlers.Connected != nil {
+		s.handlers.Connected()
+	}
+
+	conn.SetReadDeadline(time.Now().Add(readTimeout))
+	conn.SetPingHandler(func(data string) error {
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(writeWait))
+	})
+
+	for {
+		_, frame, err := conn.ReadMessage()
+		if err != nil {
+			return true, err
+		}
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+		if err := s.dispatch(frame); err != nil {
+			return true, err
+		}
+	}
+}
+
+// dispatch decodes a frame, which may hold several newline-separated messages
+func (s *Subscriber) dispatch(frame []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(frame))
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("monti: malformed stream message: %w", err)
+		}
+
+		var head struct {
+			Type string `json:"type"`
+		}
+		json.Unmarshal(raw, &head)
+
+		switch head.Type {
+		case "snapshot":
+			var snapshot Snapshot
+			if err := json.Unmarshal(raw, &snapshot); err != nil {
+				return fmt.Errorf("monti: malformed snapshot: %w", err)
+			}
+			if !snapshot.Timestamp.After(s.last) {
+				continue // replayed history we already delivered
+			}
+			s.last = snapshot.Timestamp
+			normalizeSnapshot(&snapshot)
+			if s.handlers.Snapshot != nil {
+				s.handlers.Snapshot(&snapshot)
+			}
+			if snapshot.Seq > 0 {
+				// Acknowledge so the server's client inventory shows how far behind we are
+				s.mu.Lock()
+				err := s.send(ClientCommand{Type: "ack", Seq: snapshot.Seq})
+				s.mu.Unlock()
+				if err != nil {
+					return err
+				}
+			}
+		case "agent_detail":
+			var detail AgentDetail
+			if err := json.Unmarshal(raw, &detail); err != nil {
+				return fmt.Errorf("monti: malformed agent_detail: %w", err)
+			}
+			if s.handlers.AgentDetail != nil {
+				s.handlers.AgentDetail(detail)
+			}
+		case "command_result":
+			var result ClientCommandResult
+			if err := json.Unmarshal(raw, &result); err != nil {
+				return fmt.Errorf("monti: malformed command_result: %w", err)
+			}
+			if result.Command == "subscribe_agent" && !result.OK {
+				s.mu.Lock()
+				delete(s.agents, result.AgentID) // out of scope or over the limit; don't retry
+				s.mu.Unlock()
+			}
+			if s.handlers.CommandResult != nil {
+				s.handlers.CommandResult(result)
+			}
+		case "control":
+			var msg ControlMessage
+			if err := json.Unmarshal(raw, &msg); err != nil {
+				return fmt.Errorf("monti: malformed control message: %w", err)
+			}
+			if s.handlers.Control != nil {
+				s.handlers.Control(msg)
+			}
+		}
+		// unknown types are ignored so newer servers can add messages
+	}
+}
+
+// normalizeSnapshot replaces the nil maps and slices an RBAC-filtered snapshot can carry
+func normalizeSnapshot(snapshot *Snapshot) {
+	if snapshot.Departments == nil {
+		snapshot.Departments = make(map[Department]*DepartmentData)
+	}
+	for dept, data := range snapshot.Departments {
+		if data == nil {
+			delete(snapshot.Departments, dept)
+			continue
+		}
+		if data.Agents == nil {
+			data.Agents = []AgentInfo{}
+		}
+		if data.Queues == nil {
+			data.Queues = []VQSnapshot{}
+		}
+	}
+}
+
+// websocketURL turns an http(s) base URL into ws(s)
+func websocketURL(baseURL string) string {
+	switch {
+	case strings.HasPrefix(baseURL, "https://"):
+		return "wss://" + strings.TrimPrefix(baseURL, "https://")
+	case strings.HasPrefix(baseURL, "http://"):
+		return "ws://" + strings.TrimPrefix(baseURL, "http://")
+	}
+	return baseURL
+}
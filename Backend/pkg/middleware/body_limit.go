@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+)
+
+// MaxBodySize rejects request bodies larger than limit bytes with 413.
+// The body is read up front so handlers never see a truncated payload. limit <= 0 disables the check.
+func MaxBodySize(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limit <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				rejectTooLarge(w, r)
+				return
+			}
+			body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+			r.Body.Close()
+			if err != nil {
+				apierror.Write(w, r, http.StatusBadRequest, "failed to read request body")
+				return
+			}
+			if int64(len(body)) > limit {
+				rejectTooLarge(w, r)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func rejectTooLarge(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Connection", "close")
+	apierror.Write(w, r, http.StatusRequestEntityTooLarge, "request body too large")
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Instrument reports each request's route pattern, status and duration to record.
+// Routes are labelled by their chi pattern (e.g. "GET /api/agents/{agentId}/history") so
+// path parameters don't explode label cardinality; WebSocket upgrades are skipped because
+// their duration is the connection lifetime.
+func Instrument(record func(endpoint string, status int, duration time.Duration)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			ww := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(ww, r)
+
+			record(routePattern(r), ww.statusCode, time.Since(start))
+		})
+	}
+}
+
+// routePattern returns "METHOD /pattern" for the matched chi route, or "unmatched"
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return "unmatched"
+	}
+	pattern := rctx.RoutePattern()
+	if pattern == "" {
+		return "unmatched"
+	}
+	return r.Method + " " + pattern
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestInstrumentRecordsRoutePattern(t *testing.T) {
+	var endpoint string
+	var status int
+	calls := 0
+	record := func(e string, s int, d time.Duration) {
+		endpoint, status = e, s
+		calls++
+	}
+
+	r := chi.NewRouter()
+	r.Use(Instrument(record))
+	r.Get("/api/agents/{agentId}/history", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/agents/agent-42/history", nil))
+	if endpoint != "GET /api/agents/{agentId}/history" || status != http.StatusNotFound {
+		t.Fatalf("expected pattern label and 404, got %q %d", endpoint, status)
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/no/such/route", nil))
+	if endpoint != "unmatched" {
+		t.Errorf("expected unmatched label, got %q", endpoint)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/agents/agent-42/history", nil)
+	req.Header.Set("Upgrade", "websocket")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if calls != 2 {
+		t.Errorf("expected WebSocket upgrades to be skipped, got %d records", calls)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"time"
 
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/rs/zerolog"
 )
 
@@ -28,6 +29,7 @@ func Logger(log zerolog.Logger) func(next http.Handler) http.Handler {
 				Str("remote_addr", r.RemoteAddr).
 				Int("status", ww.statusCode).
 				Dur("duration_ms", time.Since(start)).
+				Str("request_id", chimiddleware.GetReqID(r.Context())). // the requestId of error responses
 				Msg("request completed")
 		})
 	}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+)
+
+// maxRateLimitKeys bounds the bucket table; idle (full) buckets are swept when it fills up
+const maxRateLimitKeys = 10000
+
+// RateLimiter is a set of token buckets keyed by client (user or IP)
+type RateLimiter struct {
+	name    string
+	rate    float64 // tokens per second
+	burst   float64
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	onLimit func(name string)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter allows perMinute requests per key with bursts of up to 10 seconds' worth.
+// Returns nil (no limiting) when perMinute <= 0.
+func NewRateLimiter(name string, perMinute int) *RateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		name:    name,
+		rate:    float64(perMinute) / 60,
+		burst:   math.Max(1, float64(perMinute)/6),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// OnLimit registers a callback invoked with the limiter name for every rejected request
+func (l *RateLimiter) OnLimit(fn func(name string)) {
+	if l == nil {
+		return
+	}
+	l.onLimit = fn
+}
+
+// Allow takes a token for key. If none is left it returns false and the wait until the next token.
+func (l *RateLimiter) Allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxRateLimitKeys {
+			l.sweep(now)
+		}
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// sweep drops buckets that have refilled completely (caller must hold l.mu)
+func (l *RateLimiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RateLimit rejects requests over the limiter's rate with 429 and Retry-After.
+// keyFn selects the bucket; requests with an empty key are not limited. A nil limiter disables the middleware.
+func RateLimit(l *RateLimiter, keyFn func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if l == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := keyFn(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if ok, wait := l.Allow(key, time.Now()); !ok {
+				if l.onLimit != nil {
+					l.onLimit(l.name)
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				apierror.Write(w, r, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientIP is a RateLimit key function using the client address (after chi's RealIP middleware)
+func ClientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	l := NewRateLimiter("test", 60) // 1/s, burst 10
+	now := time.Now()
+
+	for i := 0; i < 10; i++ {
+		if ok, _ := l.Allow("a", now); !ok {
+			t.Fatalf("request %d within burst was rejected", i+1)
+		}
+	}
+	ok, wait := l.Allow("a", now)
+	if ok {
+		t.Fatal("expected request beyond burst to be rejected")
+	}
+	if wait <= 0 || wait > time.Second {
+		t.Errorf("expected wait of up to 1s, got %v", wait)
+	}
+	if ok, _ := l.Allow("b", now); !ok {
+		t.Error("expected other keys to have their own bucket")
+	}
+	if ok, _ := l.Allow("a", now.Add(time.Second)); !ok {
+		t.Error("expected a token to be refilled after 1s")
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	limited := 0
+	l := NewRateLimiter("ip", 6) // burst 1
+	l.OnLimit(func(name string) { limited++ })
+	h := RateLimit(l, ClientIP)(handler)
+
+	request := func(addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/agents", nil)
+		req.RemoteAddr = addr
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := request("10.0.0.1:1234"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	rec := request("10.0.0.1:5678")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header")
+	}
+	if limited != 1 {
+		t.Errorf("expected OnLimit to be called once, got %d", limited)
+	}
+	if rec := request("10.0.0.2:1234"); rec.Code != http.StatusOK {
+		t.Errorf("expected other IP to pass, got %d", rec.Code)
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	l := NewRateLimiter("off", 0)
+	l.OnLimit(func(string) {}) // nil-safe
+	h := RateLimit(l, ClientIP)(handler)
+
+	for i := 0; i < 100; i++ {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected disabled limiter to pass all requests, got %d", rec.Code)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/dennisdiepolder/monti/backend/internal/apierror"
+)
+
+// ServiceTokenHeader carries the shared secret on service-to-service requests
+const ServiceTokenHeader = "X-Internal-Token"
+
+// ServiceAuth rejects requests that do not carry the shared service token.
+// An empty token disables the check (local development).
+func ServiceAuth(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if token == "" {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got := r.Header.Get(ServiceTokenHeader)
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				apierror.Write(w, r, http.StatusUnauthorized, "invalid service token")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
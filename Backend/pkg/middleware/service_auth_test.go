@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServiceAuth(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name           string
+		token          string
+		header         string
+		expectedStatus int
+	}{
+		{
+			name:           "disabled without token",
+			token:          "",
+			header:         "",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "valid token",
+			token:          "s3cret",
+			header:         "s3cret",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing token",
+			token:          "s3cret",
+			header:         "",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "wrong token",
+			token:          "s3cret",
+			header:         "guess",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/internal/event", nil)
+			if tt.header != "" {
+				req.Header.Set(ServiceTokenHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			ServiceAuth(tt.token)(handler).ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,815 @@
+// Package server wires the MONTI backend: state tracking, call queues and routing, the
+// dashboard and agent hubs, aggregation and the HTTP router. cmd/server runs it as a process;
+// the cross-service end-to-end tests in test/e2e boot it in-process.
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/internal/adherence"
+	"github.com/dennisdiepolder/monti/backend/internal/agentsim"
+	"github.com/dennisdiepolder/monti/backend/internal/aggregator"
+	"github.com/dennisdiepolder/monti/backend/internal/alerts"
+	"github.com/dennisdiepolder/monti/backend/internal/announcements"
+	"github.com/dennisdiepolder/monti/backend/internal/api"
+	"github.com/dennisdiepolder/monti/backend/internal/auth"
+	"github.com/dennisdiepolder/monti/backend/internal/backfill"
+	"github.com/dennisdiepolder/monti/backend/internal/breaks"
+	"github.com/dennisdiepolder/monti/backend/internal/cache"
+	"github.com/dennisdiepolder/monti/backend/internal/callqueue"
+	"github.com/dennisdiepolder/monti/backend/internal/config"
+	"github.com/dennisdiepolder/monti/backend/internal/connect"
+	"github.com/dennisdiepolder/monti/backend/internal/event"
+	"github.com/dennisdiepolder/monti/backend/internal/eventlog"
+	"github.com/dennisdiepolder/monti/backend/internal/health"
+	"github.com/dennisdiepolder/monti/backend/internal/hours"
+	"github.com/dennisdiepolder/monti/backend/internal/ingestion"
+	_ "github.com/dennisdiepolder/monti/backend/internal/ingestion/jsonl" // reference adapter
+	"github.com/dennisdiepolder/monti/backend/internal/metrics"
+	"github.com/dennisdiepolder/monti/backend/internal/openapi"
+	"github.com/dennisdiepolder/monti/backend/internal/simpresets"
+	"github.com/dennisdiepolder/monti/backend/internal/storage"
+	"github.com/dennisdiepolder/monti/backend/internal/teams"
+	"github.com/dennisdiepolder/monti/backend/internal/tracing"
+	"github.com/dennisdiepolder/monti/backend/internal/types"
+	"github.com/dennisdiepolder/monti/backend/internal/webhook"
+	"github.com/dennisdiepolder/monti/backend/internal/websocket"
+	"github.com/dennisdiepolder/monti/backend/pkg/middleware"
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog"
+)
+
+// Server is a wired backend. Background loops run from New until Shutdown; HTTP is served
+// once ListenAndServe or Serve is called.
+type Server struct {
+	cfg    *config.Config
+	logger zerolog.Logger
+	srv    *http.Server
+
+	hub             *websocket.Hub
+	agentHub        *websocket.AgentHub
+	adapterRunner   *ingestion.Runner
+	webhooks        *webhook.Dispatcher
+	eventLog        *eventlog.Log
+	store           *storage.AsyncStore
+	cancel          context.CancelFunc
+	routingDone     chan struct{}
+	pushDone        chan struct{}
+	shutdownTracing func(context.Context) error
+}
+
+// New loads the configuration (environment, .env and CONFIG_FILE), applies LOG_LEVEL and
+// starts the backend's background loops
+func New(logger zerolog.Logger) (s *Server, err error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load configuration: %w", err)
+	}
+
+	// Set log level
+	level, err := zerolog.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		logger.Warn().Str("level", cfg.LogLevel).Msg("invalid log level, using info")
+		level = zerolog.InfoLevel
+	}
+	zerolog.SetGlobalLevel(level)
+
+	logger.Info().
+		Str("port", cfg.Port).
+		Strs("allowed_origins", cfg.AllowedOrigins).
+		Str("log_level", cfg.LogLevel).
+		Msg("starting MONTI backend server")
+
+	// Departments, locations and business units must be set before anything reads them
+	if cfg.TaxonomyFile != "" {
+		taxonomy, err := types.LoadTaxonomyFile(cfg.TaxonomyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load taxonomy %s: %w", cfg.TaxonomyFile, err)
+		}
+		types.SetTaxonomy(taxonomy)
+		logger.Info().
+			Int("departments", len(types.AllDepartments)).
+			Int("locations", len(types.AllLocations)).
+			Int("business_units", len(types.BULocationMapping)).
+			Msg("taxonomy loaded")
+	}
+
+	// Days are cut in the reporting timezone from the first date key on
+	if err := types.SetReportingTimezone(cfg.ReportingTimezone); err != nil {
+		return nil, fmt.Errorf("invalid REPORTING_TIMEZONE: %w", err)
+	}
+
+	// Tenants must be set before the call queues are created
+	if err := types.SetTenants(cfg.Tenants); err != nil {
+		return nil, fmt.Errorf("invalid TENANTS: %w", err)
+	}
+	if types.MultiTenant() {
+		logger.Info().Strs("tenants", types.AllTenants()).Msg("multi-tenant mode")
+	}
+
+	// Export OpenTelemetry traces when OTEL_EXPORTER_OTLP_ENDPOINT is set
+	shutdownTracing, err := tracing.Setup(context.Background(), "monti-backend")
+	if err != nil {
+		return nil, fmt.Errorf("initialize tracing: %w", err)
+	}
+
+	// Create context for services; stopped again if wiring fails below
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		if err != nil {
+			cancel()
+		}
+	}()
+
+	// Create WebSocket hub for frontend clients
+	hub := websocket.NewHub(logger)
+	hub.SetConnectionLimits(cfg.WSMaxConnectionsPerUser, cfg.WSMaxConnections)
+	hub.SetSnapshotHistorySize(cfg.SnapshotHistorySize)
+	hub.SetAckStallAfter(cfg.WSAckStallAfter)
+	go hub.Run()
+
+	// Push metrics for deployments without a Prometheus scraping /metrics; the last push
+	// happens once ctx is cancelled on shutdown
+	pusher := metrics.NewPusher(metrics.Get().Samples, cfg.MetricsPushInterval, logger)
+	if cfg.MetricsRemoteWriteURL != "" {
+		hostname, _ := os.Hostname()
+		pusher.AddSink(metrics.NewRemoteWriteSink(cfg.MetricsRemoteWriteURL, map[string]string{"job": "monti-backend", "instance": hostname}))
+	}
+	if cfg.MetricsStatsdAddr != "" {
+		pusher.AddSink(metrics.NewStatsdSink(cfg.MetricsStatsdAddr, cfg.MetricsStatsdFormat))
+	}
+	pushDone := make(chan struct{})
+	if pusher.Enabled() {
+		go func() {
+			pusher.Run(ctx)
+			close(pushDone)
+		}()
+	} else {
+		close(pushDone)
+	}
+
+	// Create agent state tracker
+	stateTracker := cache.NewAgentStateTracker()
+	stateTracker.SetStaleThreshold(cfg.StaleThreshold)
+	stateTracker.SetReconnectGrace(cfg.ReconnectGrace)
+	stateTracker.SetKPIHistorySize(cfg.KPIHistorySize)
+	stateTracker.SetTransitionPolicy(cache.TransitionPolicy(cfg.StateTransitionPolicy))
+	stateTracker.SetTransitionQuarantine(cfg.StateTransitionQuarantine, cfg.StateQuarantinePeriod)
+
+	// Recent raw events, queryable through GET /api/events
+	eventCache := cache.NewEventCache(cfg.EventRetention)
+
+	// Create event processor
+	processor := ingestion.NewDefaultProcessor(stateTracker, logger)
+	processor.SetEventCache(eventCache)
+
+	// Initialize storage
+	baseStore, err := storage.NewStore(ctx, logger)
+	if err != nil {
+		return nil, fmt.Errorf("initialize storage: %w", err)
+	}
+	// Writes happen in the background and are flushed on shutdown
+	store := storage.NewAsyncStore(baseStore, logger)
+
+	// Team directory: team -> supervisor -> department, scoping supervisors to their teams
+	teamDirectory := teams.NewDirectory(store)
+	if n, err := teamDirectory.Load(); err != nil {
+		logger.Error().Err(err).Msg("Failed to load team directory")
+	} else if n > 0 {
+		logger.Info().Int("teams", n).Msg("Team directory loaded")
+	}
+	auth.SetTeamResolver(teamDirectory.SupervisedBy)
+
+	// Record the agent state timeline for schedule adherence
+	adherenceService := adherence.NewService(store, stateTracker, logger)
+	stateTracker.SetStateRecorder(adherenceService)
+
+	// Business hours and holidays; SL is only tracked while a department is open
+	businessHours := hours.AlwaysOpen()
+	if cfg.BusinessHoursFile != "" {
+		businessHours, err = hours.LoadFile(cfg.BusinessHoursFile)
+		if err != nil {
+			return nil, fmt.Errorf("load business hours %s: %w", cfg.BusinessHoursFile, err)
+		}
+	}
+
+	// Create call queue manager
+	callQueueMgr := callqueue.NewCallQueueManager(stateTracker, logger)
+	hub.SetQueueHistory(callQueueMgr.IntervalRange)
+	callQueueMgr.SetStore(store)
+	callQueueMgr.SetBusinessDayStart(cfg.BusinessDayStart)
+	callQueueMgr.SetBusinessHours(businessHours)
+	callQueueMgr.SetChatMaxSessions(cfg.ChatMaxSessions)
+	strategyOpts := callqueue.DefaultStrategyOptions()
+	strategyOpts.ProficiencyWeight = cfg.RoutingProficiencyWeight
+	if len(cfg.RoutingComplexVQs) > 0 {
+		strategyOpts.ComplexVQs = nil
+		for _, vq := range cfg.RoutingComplexVQs {
+			strategyOpts.ComplexVQs = append(strategyOpts.ComplexVQs, types.VQName(vq))
+		}
+	}
+	if err := callQueueMgr.SetStrategyOptions(strategyOpts); err != nil {
+		return nil, fmt.Errorf("invalid routing strategy options: %w", err)
+	}
+	if err := callQueueMgr.SetRoutingStrategy(cfg.RoutingStrategy); err != nil {
+		return nil, fmt.Errorf("invalid ROUTING_STRATEGY: %w", err)
+	}
+	if err := callQueueMgr.SetShadowStrategy(cfg.RoutingShadowStrategy); err != nil {
+		return nil, fmt.Errorf("invalid ROUTING_SHADOW_STRATEGY: %w", err)
+	}
+	if cfg.RoutingReservesFile != "" {
+		reserves, err := callqueue.LoadReservesFile(cfg.RoutingReservesFile)
+		if err != nil {
+			return nil, fmt.Errorf("load routing reserves %s: %w", cfg.RoutingReservesFile, err)
+		}
+		callQueueMgr.SetReserves(reserves)
+	}
+	if cfg.OverflowFile != "" {
+		overflow, err := callqueue.LoadOverflowFile(cfg.OverflowFile)
+		if err != nil {
+			return nil, fmt.Errorf("load overflow rules %s: %w", cfg.OverflowFile, err)
+		}
+		callQueueMgr.SetOverflow(overflow)
+	}
+	processor.SetCallCompleter(callQueueMgr)
+
+	// Outbound webhooks for call completion/abandon and agent logon/logoff
+	var webhooks *webhook.Dispatcher
+	if cfg.WebhooksFile != "" {
+		subs, err := webhook.LoadFile(cfg.WebhooksFile)
+		if err != nil {
+			return nil, fmt.Errorf("load webhooks %s: %w", cfg.WebhooksFile, err)
+		}
+		webhooks = webhook.NewDispatcher(subs, logger)
+		webhooks.Start()
+		callQueueMgr.SetCallObserver(webhooks)
+		stateTracker.SetPresenceObserver(webhooks)
+		logger.Info().Int("subscribers", len(subs)).Msg("Webhooks enabled")
+	}
+
+	// Append-only event log of agent messages and queue mutations, replayable by admins
+	var eventLog *eventlog.Log
+	var ingest ingestion.EventProcessor = processor
+	if cfg.EventLogFile != "" {
+		eventLog, err = eventlog.Open(cfg.EventLogFile, logger)
+		if err != nil {
+			return nil, fmt.Errorf("open event log %s: %w", cfg.EventLogFile, err)
+		}
+		eventLog.Start()
+		ingest = eventlog.NewRecorder(eventLog, processor)
+		callQueueMgr.SetCallLog(eventLog)
+		logger.Info().Str("path", cfg.EventLogFile).Msg("Event log enabled")
+	}
+
+	// Break caps, answered to agents' break_request messages
+	breakPolicy := breaks.DefaultPolicy()
+	if cfg.BreakPolicyFile != "" {
+		breakPolicy, err = breaks.LoadFile(cfg.BreakPolicyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load break policy %s: %w", cfg.BreakPolicyFile, err)
+		}
+	}
+	breakGuard := breaks.NewGuard(breakPolicy, stateTracker, logger)
+
+	// Create agent WebSocket hub
+	agentHub := websocket.NewAgentHub(stateTracker, ingest, logger)
+	agentHub.SetDetailPublisher(hub)
+	agentHub.SetBreakGuard(breakGuard)
+	agentHub.SetWorkers(ingestion.NewWorkers(cfg.IngestWorkers))
+	agentHub.SetHeartbeatThrottle(cfg.AgentThrottleHeartbeatInterval)
+	go agentHub.Run()
+
+	// Amazon Connect agent events arrive through a Firehose HTTP endpoint next to AgentSim
+	var connectAdapter *connect.Adapter
+	if cfg.ConnectAccessKey != "" {
+		connectAdapter = connect.NewAdapter(stateTracker, cfg.ConnectHierarchy, logger)
+		go connectAdapter.Start(ctx, ingest)
+	}
+
+	// Vendor ACD adapters (Genesys, Avaya, ...) registered with the ingestion package
+	var adapters []ingestion.SourceAdapter
+	if cfg.IngestionAdaptersFile != "" {
+		adapters, err = ingestion.LoadAdapterFile(cfg.IngestionAdaptersFile, logger)
+		if err != nil {
+			return nil, fmt.Errorf("load ingestion adapters (types %v): %w", ingestion.AdapterTypes(), err)
+		}
+	}
+	adapterRunner := ingestion.NewRunner(adapters, logger)
+	adapterRunner.Start(ctx, ingest)
+
+	// Start stale agent checker
+	go func() {
+		ticker := time.NewTicker(cfg.StaleCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				stateTracker.CheckStaleAgents()
+				stateTracker.RemoveDisconnected(cfg.DisconnectedTTL)
+			}
+		}
+	}()
+
+	// Ticker disabled - now using widget aggregator for all broadcasts
+	// tickerService := ticker.NewTicker(hub, 1*time.Second, logger)
+	// go tickerService.Start(ctx)
+
+	// Create WebSocket handler for frontend clients
+	wsHandler := websocket.NewHandler(hub, cfg, logger)
+
+	// Create agent WebSocket handler
+	agentWsHandler := websocket.NewAgentHandler(agentHub, logger)
+
+	// Create call handler and routing loop
+	callHandler := callqueue.NewCallHandler(callQueueMgr, logger)
+	routingLoop := callqueue.NewRoutingLoop(callQueueMgr, agentHub, logger)
+	routingLoop.SetInterval(cfg.RoutingInterval)
+	routingLoop.SetOrphanedCalls(cfg.OrphanedCallGrace, cfg.OrphanedCallAction)
+	routingDone := make(chan struct{})
+	go func() {
+		routingLoop.Start(ctx)
+		close(routingDone)
+	}()
+
+	// Create event receiver (uses the already created stateTracker)
+	eventReceiver := event.NewReceiver(eventCache, stateTracker, logger)
+	eventReceiver.SetPermissive(cfg.PermissiveIngestion)
+
+	// Create alert rules engine (built-in defaults unless ALERT_RULES_FILE is set)
+	var alertRules []alerts.Rule
+	if cfg.AlertRulesFile != "" {
+		alertRules, err = alerts.LoadRulesFile(cfg.AlertRulesFile)
+		if err != nil {
+			return nil, fmt.Errorf("load alert rules %s: %w", cfg.AlertRulesFile, err)
+		}
+	}
+	alertEngine := alerts.NewEngine(alertRules)
+	alertTracker := alerts.NewTracker(store, logger)
+
+	// Announcements embedded in every snapshot
+	announcementBoard := announcements.NewBoard()
+
+	// Create aggregator
+	aggregatorService := aggregator.NewAggregator(eventCache, stateTracker, hub, logger)
+	aggregatorService.SetCallQueue(callQueueMgr)
+	aggregatorService.SetAlertEngine(alertEngine)
+	aggregatorService.SetAlertTracker(alertTracker)
+	aggregatorService.SetRollupDimensions(cfg.RollupDimensions)
+	aggregatorService.SetTeamDirectory(teamDirectory)
+	aggregatorService.SetLeaderboardSettings(cfg.Leaderboards)
+	aggregatorService.SetAnnouncements(announcementBoard)
+	aggregatorService.SetCadence(aggregator.Cadence{
+		Interval:     cfg.AggregationInterval,
+		Rollups:      cfg.RollupInterval,
+		Trends:       cfg.TrendInterval,
+		Leaderboards: cfg.LeaderboardInterval,
+	})
+	aggregatorService.SetLoadShedding(aggregator.LoadShedding{
+		AggregationLatency: cfg.LoadShedAggregationLatency,
+		BroadcastLatency:   cfg.LoadShedBroadcastLatency,
+	})
+	go aggregatorService.Start(ctx)
+
+	auth.SetLogger(logger)
+
+	// Load role/group claim mapping (built-in Keycloak/Cognito defaults unless AUTH_MAPPING_FILE is set)
+	if cfg.AuthMappingFile != "" {
+		authMapping, err := auth.LoadMappingFile(cfg.AuthMappingFile)
+		if err != nil {
+			return nil, fmt.Errorf("load auth mapping %s: %w", cfg.AuthMappingFile, err)
+		}
+		auth.SetMapping(authMapping)
+	}
+	auth.SetTokenCacheTTL(cfg.TokenCacheTTL)
+	auth.SetLockoutSettings(auth.LockoutSettings{
+		IPThreshold:   cfg.AuthLockoutIPThreshold,
+		UserThreshold: cfg.AuthLockoutUserThreshold,
+		Window:        cfg.AuthLockoutWindow,
+		Duration:      cfg.AuthLockoutDuration,
+	})
+
+	// Initialize JWKS for production token verification
+	if !cfg.SkipAuth {
+		if cfg.OIDCIssuer != "" {
+			if err := auth.InitJWKS(cfg.OIDCIssuer, 20); err != nil {
+				return nil, fmt.Errorf("initialize JWKS (Keycloak not reachable): %w", err)
+			}
+			auth.StartJWKSRefresh(ctx, cfg.JWKSRefreshInterval)
+		}
+	}
+
+	// Deep health and readiness checks; hub loops and JWKS gate readiness, backlogs only degrade
+	checker := health.NewChecker("monti-backend", cfg.HealthCheckTimeout)
+	checker.Register(health.Check{Name: "hub", Critical: true, Run: hub.Ping})
+	checker.Register(health.Check{Name: "agent_hub", Critical: true, Run: agentHub.Ping})
+	checker.Register(health.BacklogCheck("hub_broadcast_backlog", false, hub.BroadcastBacklog, 0.8))
+	checker.Register(health.BacklogCheck("agent_hub_backlog", false, agentHub.MessageBacklog, 0.8))
+	checker.Register(health.GoroutineCheck(cfg.HealthMaxGoroutines))
+	if pinger, ok := baseStore.(health.Pinger); ok {
+		checker.Register(health.PingCheck("dynamodb", true, pinger))
+	}
+	if !auth.JWKSLastUpdate().IsZero() {
+		// A missed refresh or two is tolerated; older keys risk rejecting rotated tokens
+		checker.Register(health.FreshnessCheck("jwks", true, auth.JWKSLastUpdate, 3*cfg.JWKSRefreshInterval))
+	}
+
+	// Create router
+	r := chi.NewRouter()
+
+	// Add middleware
+	r.Use(chimiddleware.RequestID)
+	r.Use(chimiddleware.RealIP)
+	r.Use(middleware.Logger(logger))
+	r.Use(middleware.Instrument(metrics.Get().RecordHTTPRequest))
+	r.Use(tracing.Middleware)
+	r.Use(chimiddleware.Recoverer)
+	r.Use(middleware.CORS(cfg.AllowedOrigins))
+
+	// Register public routes (no auth required)
+	r.Get("/health", checker.HealthHandler(healthHandler))
+	r.Get("/ready", checker.ReadyHandler)
+	r.Get("/metrics", metrics.Get().Handler())
+
+	// OpenAPI document and WebSocket message schema for integrators (public: they describe
+	// routes and messages, not data)
+	openAPIHandler, err := api.NewOpenAPIHandler(logger)
+	if err != nil {
+		return nil, fmt.Errorf("build OpenAPI document: %w", err)
+	}
+	r.Get("/api/openapi.json", openAPIHandler.ServeHTTP)
+	wsSchemaHandler, err := api.NewWSSchemaHandler()
+	if err != nil {
+		return nil, fmt.Errorf("build WebSocket schema: %w", err)
+	}
+	r.Get("/api/schema/ws", wsSchemaHandler.ServeHTTP)
+
+	// Create roster handler
+	rosterHandler := api.NewRosterHandler(stateTracker, processor, store, teamDirectory, logger)
+	if n, err := rosterHandler.Restore(); err != nil {
+		logger.Error().Err(err).Msg("Failed to restore managed roster")
+	} else if n > 0 {
+		logger.Info().Int("agents", n).Msg("Managed roster restored")
+	}
+
+	// Create schedule/adherence and productivity report handlers
+	adherenceHandler := api.NewAdherenceHandler(adherenceService, logger)
+	reportHandler := api.NewReportHandler(adherenceService, store, logger)
+	grafanaHandler := api.NewGrafanaHandler(callQueueMgr, store, logger)
+
+	// Create business hours handler
+	hoursHandler := api.NewHoursHandler(businessHours, logger)
+
+	// Internal routes and agent WebSockets are for services like AgentSim and require the
+	// shared INTERNAL_AUTH_TOKEN when one is configured
+	serviceAuth := middleware.ServiceAuth(cfg.InternalAuthToken)
+	if cfg.InternalAuthToken == "" {
+		logger.Warn().Msg("INTERNAL_AUTH_TOKEN not set - /internal and /ws/agent are unauthenticated")
+	}
+
+	r.Route("/internal", func(r chi.Router) {
+		r.Use(serviceAuth)
+		eventLimit := middleware.MaxBodySize(int64(cfg.MaxEventBodyBytes))
+		r.With(eventLimit).Post("/event", eventReceiver.HandleEvent)
+		r.Get("/event/stats", eventReceiver.GetStats)
+		r.With(eventLimit).Post("/call/enqueue", callHandler.HandleEnqueue)
+		r.With(eventLimit).Post("/calls/inject", callHandler.HandleEnqueue) // alias for inject
+		r.Get("/calls/stats", callHandler.HandleStats)
+		r.Delete("/calls/all", callHandler.HandleWipeAll)
+		r.With(middleware.MaxBodySize(int64(cfg.MaxRosterBodyBytes))).Post("/agents/roster", rosterHandler.HandleRoster)
+		r.Get("/calendar", adherenceHandler.InternalCalendar)
+		r.Get("/hours", hoursHandler.GetStatus)
+	})
+
+	// Agent WebSocket endpoints (internal AgentSim connections)
+	r.With(serviceAuth).Get("/ws/agent", agentWsHandler.ServeHTTP)
+	r.With(serviceAuth).Get("/ws/agent/multiplexed", agentWsHandler.ServeMultiplexedHTTP)
+
+	// Amazon Connect agent event stream (Firehose authenticates with its own access key)
+	if connectAdapter != nil {
+		r.With(middleware.MaxBodySize(connect.MaxDeliveryBytes)).Post("/ingest/connect", connectAdapter.FirehoseHandler(cfg.ConnectAccessKey))
+	}
+
+	// Create agent history handler
+	agentHistoryHandler := api.NewAgentHistoryHandler(store, logger)
+
+	// Create agent actions handler
+	agentActionsHandler := api.NewAgentActionsHandler(agentHub, callQueueMgr, stateTracker, logger)
+
+	// Create routing handler for strategy evaluation and dry runs
+	routingHandler := api.NewRoutingHandler(callQueueMgr, logger)
+
+	// Create queue event handler for pauses, SL target changes and their history
+	queueEventHandler := api.NewQueueEventHandler(callQueueMgr, store, logger)
+
+	// Create admin handler for simulation control
+	var simClient *agentsim.Client
+	if cfg.AgentSimURL != "" {
+		checker.Register(health.HTTPCheck("agentsim", false, &http.Client{}, cfg.AgentSimURL+"/health"))
+		simClient = agentsim.New(cfg.AgentSimURL)
+	}
+	breaksHandler := api.NewBreaksHandler(breakGuard, stateTracker, logger)
+	adminHandler := api.NewAdminHandler(simClient, stateTracker, callQueueMgr, store, logger)
+	presetLibrary := simpresets.NewLibrary(store)
+	if n, err := presetLibrary.Load(); err != nil {
+		logger.Error().Err(err).Msg("Failed to load sim presets")
+	} else if n > 0 {
+		logger.Info().Int("presets", n).Msg("Sim presets loaded")
+	}
+	var presetRunner *simpresets.Runner
+	if simClient != nil {
+		presetRunner = simpresets.NewRunner(ctx, agentsim.NewControl(simClient, callQueueMgr), logger)
+	}
+	var presetScheduler *simpresets.Scheduler
+	if cfg.SimScheduleFile != "" {
+		if presetRunner == nil {
+			return nil, fmt.Errorf("SIM_SCHEDULE_FILE needs AGENTSIM_URL")
+		}
+		schedule, err := simpresets.LoadSchedule(cfg.SimScheduleFile)
+		if err != nil {
+			return nil, fmt.Errorf("load sim schedule %s: %w", cfg.SimScheduleFile, err)
+		}
+		presetScheduler = simpresets.NewScheduler(schedule, presetLibrary, presetRunner, logger)
+		go presetScheduler.Start(ctx)
+		logger.Info().Int("entries", len(schedule.Entries)).Msg("Sim schedule loaded")
+	}
+	simPresetHandler := api.NewSimPresetHandler(presetLibrary, presetRunner, presetScheduler, logger)
+	eventLogHandler := api.NewEventLogHandler(eventLog, stateTracker, callQueueMgr, processor, logger)
+	backfillHandler := api.NewBackfillHandler(backfill.NewService(store, cfg.BusinessDayStart, logger), logger)
+
+	// Create effective config handler
+	configHandler := api.NewConfigHandler(cfg, logger)
+
+	// Create runtime log level handler
+	logLevelHandler := api.NewLogLevelHandler(logger)
+
+	// Create leaderboard settings handler
+	leaderboardHandler := api.NewLeaderboardHandler(aggregatorService, logger)
+
+	// Create alert rules handler
+	alertRuleHandler := api.NewAlertRuleHandler(alertEngine, logger)
+
+	// Create alert lifecycle handler
+	alertHandler := api.NewAlertHandler(alertTracker, store, logger)
+
+	// Create staffing forecast handler
+	forecastHandler := api.NewForecastHandler(callQueueMgr, adherenceService, logger)
+
+	// Create polling wallboard handler
+	wallboardHandler := api.NewWallboardHandler(hub, logger)
+
+	// Create dashboard client inventory and control message handler
+	wsClientsHandler := api.NewWSClientsHandler(hub, logger)
+
+	// Create announcement handler
+	announcementHandler := api.NewAnnouncementHandler(announcementBoard, logger)
+
+	// Create team directory handler
+	teamHandler := api.NewTeamHandler(teamDirectory, stateTracker, hub, alertTracker, logger)
+
+	// Create live agent/queue state handler
+	liveStateHandler := api.NewLiveStateHandler(stateTracker, callQueueMgr, logger)
+	recentEventsHandler := api.NewRecentEventsHandler(eventCache, stateTracker)
+
+	// Token-bucket rate limits: per IP before auth, per user after auth, stricter for admin routes
+	ipLimiter := middleware.NewRateLimiter("ip", cfg.RateLimitIPPerMinute)
+	userLimiter := middleware.NewRateLimiter("user", cfg.RateLimitUserPerMinute)
+	adminLimiter := middleware.NewRateLimiter("admin", cfg.RateLimitAdminPerMinute)
+	for _, l := range []*middleware.RateLimiter{ipLimiter, userLimiter, adminLimiter} {
+		l.OnLimit(metrics.Get().RecordRateLimited)
+	}
+
+	// Restricts /api/agents/{agentId}/... routes to the caller's location/department/team scope
+	agentScope := api.RequireAgentScope(stateTracker)
+
+	// Add auth middleware for protected routes
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RateLimit(ipLimiter, middleware.ClientIP))
+		r.Use(auth.Middleware)
+		r.Use(middleware.RateLimit(userLimiter, api.UserRateLimitKey))
+
+		// Public authenticated routes (any role)
+		r.Get("/ws", wsHandler.ServeHTTP)
+		r.Get("/ws/agent/desktop", agentWsHandler.ServeDesktopHTTP)
+		r.With(agentScope).Get("/api/agents/{agentId}/history", agentHistoryHandler.GetHistory)
+		r.With(agentScope).Get("/api/agents/{agentId}/calls", agentHistoryHandler.GetCalls)
+		r.Get("/api/wallboard", wallboardHandler.GetWallboard)
+		r.Get("/api/agents", liveStateHandler.ListAgents)
+		r.Get("/api/events", recentEventsHandler.ListEvents)
+		r.Get("/api/queues", liveStateHandler.ListQueues)
+		r.Get("/api/queues/{vq}/events", queueEventHandler.ListEvents)
+		r.Get("/api/teams", teamHandler.ListTeams)
+		r.Get("/api/hours", hoursHandler.GetStatus)
+
+		// Supervisor routes (manager/supervisor + admin only)
+		r.Group(func(r chi.Router) {
+			r.Use(api.RequireManagerOrAdmin)
+			r.With(agentScope).Post("/api/agents/{agentId}/calls/{callId}/end", agentActionsHandler.ForceEndCall)
+			r.With(agentScope).Post("/api/agents/{agentId}/logout", agentActionsHandler.Logout)
+			r.With(agentScope).Post("/api/agents/{agentId}/state", agentActionsHandler.ForceState)
+			r.Post("/api/agents/bulk", agentActionsHandler.BulkAction)
+			r.Get("/api/alerts", alertHandler.ListAlerts)
+			r.With(agentScope).Get("/api/agents/{agentId}/alerts", alertHandler.GetAgentAlerts)
+			r.Get("/api/teams/{team}/summary", teamHandler.GetTeamSummary)
+			r.Post("/api/alerts/{alertId}/ack", alertHandler.Acknowledge)
+			r.Put("/api/schedules", adherenceHandler.PutSchedules)
+			r.Post("/api/schedules/import", adherenceHandler.ImportSchedulesCSV)
+			r.Get("/api/schedules/{agentId}", adherenceHandler.GetSchedule)
+			r.Get("/api/adherence/agents/{agentId}", adherenceHandler.GetAgentAdherence)
+			r.Get("/api/adherence/teams/{team}", adherenceHandler.GetTeamAdherence)
+			r.Get("/api/reports/agents", reportHandler.GetAgentProductivity)
+			r.Get("/api/grafana/", grafanaHandler.TestConnection)
+			r.Post("/api/grafana/search", grafanaHandler.Search)
+			r.Post("/api/grafana/query", grafanaHandler.Query)
+			r.Post("/api/grafana/annotations", grafanaHandler.Annotations)
+			r.Get("/api/calendar", adherenceHandler.ListCalendar)
+			r.Post("/api/calendar", adherenceHandler.PutCalendarEvents)
+			r.Post("/api/calendar/import", adherenceHandler.ImportCalendarCSV)
+			r.Post("/api/calendar/import/ics", adherenceHandler.ImportCalendarICS)
+			r.Delete("/api/calendar/{eventId}", adherenceHandler.DeleteCalendarEvent)
+			r.Get("/api/forecast/staffing", forecastHandler.GetStaffing)
+			r.Get("/api/breaks", breaksHandler.GetStatus)
+		})
+
+		// Admin routes (admin only)
+		r.Route("/api/admin", func(r chi.Router) {
+			r.Use(api.RequireAdmin)
+			r.Use(middleware.RateLimit(adminLimiter, api.UserRateLimitKey))
+			r.Get("/config", configHandler.GetConfig)
+			r.Get("/capabilities", configHandler.GetCapabilities)
+			r.Get("/loglevel", logLevelHandler.GetLevel)
+			r.Put("/loglevel", logLevelHandler.SetLevel)
+			r.Get("/sim/status", adminHandler.GetSimStatus)
+			r.Post("/sim/start", adminHandler.StartSim)
+			r.Post("/sim/stop", adminHandler.StopSim)
+			r.Post("/sim/scale", adminHandler.ScaleSim)
+			r.Get("/sim/presets", simPresetHandler.ListPresets)
+			r.Post("/sim/presets", simPresetHandler.SavePreset)
+			r.Delete("/sim/presets/{name}", simPresetHandler.DeletePreset)
+			r.Post("/sim/presets/{name}/apply", simPresetHandler.ApplyPreset)
+			r.Get("/sim/schedule", simPresetHandler.GetSchedule)
+			r.Get("/calls/config", adminHandler.GetCallConfig)
+			r.Put("/calls/config", adminHandler.UpdateCallConfig)
+			r.Post("/calls/inject", adminHandler.InjectCalls)
+			r.Delete("/calls/all", adminHandler.WipeAllCalls)
+			r.Post("/reset/memory", adminHandler.ResetMemory)
+			r.Post("/eventlog/replay", eventLogHandler.Replay)
+			r.Post("/backfill/daily-stats", backfillHandler.BackfillDailyStats)
+			r.Get("/routing/shadow", routingHandler.GetShadow)
+			r.Put("/routing/shadow", routingHandler.SetShadow)
+			r.Post("/routing/dry-run", routingHandler.DryRun)
+			r.Post("/queues/{vq}/pause", queueEventHandler.Pause)
+			r.Post("/queues/{vq}/resume", queueEventHandler.Resume)
+			r.Put("/queues/{vq}/sl", queueEventHandler.SetSLTarget)
+			r.Get("/ws/clients", wsClientsHandler.ListClients)
+			r.Post("/ws/control", wsClientsHandler.SendControl)
+			r.Get("/announcements", announcementHandler.ListAnnouncements)
+			r.Post("/announcements", announcementHandler.PublishAnnouncement)
+			r.Delete("/announcements/{announcementId}", announcementHandler.WithdrawAnnouncement)
+			r.Delete("/reset/dynamo", adminHandler.WipeDynamo)
+			r.Post("/agents/logoff-all", adminHandler.LogoffAll)
+			r.Get("/roster", rosterHandler.ListRoster)
+			r.Post("/roster", rosterHandler.AddAgent)
+			r.With(middleware.MaxBodySize(int64(cfg.MaxRosterBodyBytes))).Post("/roster/import", rosterHandler.ImportRosterCSV)
+			r.Get("/roster/export", rosterHandler.ExportRosterCSV)
+			r.Put("/teams/{team}", teamHandler.PutTeam)
+			r.Delete("/teams/{team}", teamHandler.DeleteTeam)
+			r.Put("/roster/{agentId}", rosterHandler.UpdateAgent)
+			r.Delete("/roster/{agentId}", rosterHandler.RetireAgent)
+			r.Get("/leaderboards", leaderboardHandler.GetSettings)
+			r.Put("/leaderboards", leaderboardHandler.UpdateSettings)
+			r.Get("/alert-rules", alertRuleHandler.ListRules)
+			r.Get("/alert-rules/{ruleId}", alertRuleHandler.GetRule)
+			r.Put("/alert-rules/{ruleId}", alertRuleHandler.PutRule)
+			r.Delete("/alert-rules/{ruleId}", alertRuleHandler.DeleteRule)
+		})
+	})
+
+	// Every /api route should be described in api.Operations so the spec and client stay complete
+	var routes []openapi.Route
+	chi.Walk(r, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		routes = append(routes, openapi.Route{Method: method, Path: route})
+		return nil
+	})
+	for _, missing := range openapi.Undocumented(api.Operations(), routes, "/api/") {
+		logger.Warn().Str("method", missing.Method).Str("path", missing.Path).Msg("route missing from OpenAPI operations")
+	}
+
+	return &Server{
+		cfg:    cfg,
+		logger: logger,
+		srv: &http.Server{
+			Addr:         ":" + cfg.Port,
+			Handler:      r,
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 15 * time.Second,
+			IdleTimeout:  60 * time.Second,
+		},
+		hub:             hub,
+		agentHub:        agentHub,
+		adapterRunner:   adapterRunner,
+		webhooks:        webhooks,
+		eventLog:        eventLog,
+		store:           store,
+		cancel:          cancel,
+		routingDone:     routingDone,
+		pushDone:        pushDone,
+		shutdownTracing: shutdownTracing,
+	}, nil
+}
+
+// Config returns the loaded configuration
+func (s *Server) Config() *config.Config {
+	return s.cfg
+}
+
+// Handler returns the router
+func (s *Server) Handler() http.Handler {
+	return s.srv.Handler
+}
+
+// ListenAndServe serves HTTP on PORT until Shutdown; it returns http.ErrServerClosed then
+func (s *Server) ListenAndServe() error {
+	return s.srv.ListenAndServe()
+}
+
+// Serve serves HTTP on l until Shutdown; it returns http.ErrServerClosed then
+func (s *Server) Serve(l net.Listener) error {
+	return s.srv.Serve(l)
+}
+
+// Shutdown drains dashboards and agents, stops the background loops and HTTP server, and
+// flushes webhooks, the event log, storage writes, metrics and traces. Steps that run out of
+// time are logged and skipped.
+func (s *Server) Shutdown(ctx context.Context) {
+	// Send server_shutdown close frames to dashboards and agents; new connections are refused
+	if err := s.hub.Shutdown(ctx); err != nil {
+		s.logger.Warn().Err(err).Msg("timed out draining client connections")
+	}
+	if err := s.agentHub.Shutdown(ctx); err != nil {
+		s.logger.Warn().Err(err).Msg("timed out draining agent connections")
+	}
+
+	// Stop ingestion adapters so no events arrive for drained agents
+	if err := s.adapterRunner.Stop(ctx); err != nil {
+		s.logger.Warn().Err(err).Msg("timed out stopping ingestion adapters")
+	}
+
+	// Stop routing and the background tickers, letting an in-progress routing pass finish
+	s.cancel()
+	select {
+	case <-s.routingDone:
+	case <-ctx.Done():
+		s.logger.Warn().Msg("timed out waiting for routing loop to stop")
+	}
+
+	// Stop accepting HTTP requests and wait for in-flight ones
+	if err := s.srv.Shutdown(ctx); err != nil {
+		s.logger.Error().Err(err).Msg("server forced to shutdown")
+	}
+
+	// Deliver queued webhook events
+	if s.webhooks != nil {
+		if err := s.webhooks.Stop(ctx); err != nil {
+			s.logger.Warn().Err(err).Msg("timed out delivering webhooks")
+		}
+	}
+
+	// Write the rest of the event log
+	if s.eventLog != nil {
+		if err := s.eventLog.Stop(ctx); err != nil {
+			s.logger.Warn().Err(err).Msg("timed out writing event log")
+		}
+	}
+
+	// Flush pending call record, alert and state segment writes
+	if err := s.store.Flush(ctx); err != nil {
+		s.logger.Error().Err(err).Msg("timed out flushing storage writes")
+	}
+
+	// Wait for the final metrics push
+	select {
+	case <-s.pushDone:
+	case <-ctx.Done():
+		s.logger.Warn().Msg("timed out pushing metrics")
+	}
+
+	// Flush buffered spans
+	if err := s.shutdownTracing(ctx); err != nil {
+		s.logger.Warn().Err(err).Msg("failed to flush traces")
+	}
+}
+
+// healthHandler handles health check requests
+func healthHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, `{"status":"ok","service":"monti-backend"}`)
+}
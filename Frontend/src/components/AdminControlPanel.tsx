@@ -1,9 +1,12 @@
 import { useState, useEffect, useCallback } from 'react'
 import { useTheme } from '../contexts/ThemeContext'
 import { useAuth } from '../contexts/AuthContext'
-import { SimStatus, CallConfig, VQName } from '../types'
+import { SimStatus, CallConfig, SimPreset, VQName } from '../types'
 import {
+  getCapabilities,
   getSimStatus,
+  getSimPresets,
+  applySimPreset,
   startSim,
   stopSim,
   scaleSim,
@@ -34,6 +37,7 @@ export const AdminControlPanel = ({ onClose }: AdminControlPanelProps) => {
   const { getToken } = useAuth()
 
   // State
+  const [simEnabled, setSimEnabled] = useState(false)
   const [simStatus, setSimStatus] = useState<SimStatus | null>(null)
   const [, setCallConfig] = useState<CallConfig | null>(null)
   const [actionLoading, setActionLoading] = useState<string | null>(null)
@@ -51,6 +55,8 @@ export const AdminControlPanel = ({ onClose }: AdminControlPanelProps) => {
   const [confirmDynamo, setConfirmDynamo] = useState(false)
   const [statusMsg, setStatusMsg] = useState<string | null>(null)
   const [configLoaded, setConfigLoaded] = useState(false)
+  const [presets, setPresets] = useState<SimPreset[]>([])
+  const [selectedPreset, setSelectedPreset] = useState('')
 
   // Sync slider state from a CallConfig response
   const syncSlidersFromConfig = useCallback((config: CallConfig) => {
@@ -78,11 +84,21 @@ export const AdminControlPanel = ({ onClose }: AdminControlPanelProps) => {
     const init = async () => {
       try {
         const token = await getToken()
-        const [status, config] = await Promise.all([
+        // Backends without the capabilities endpoint always have AgentSim
+        const capabilities = await getCapabilities(token).catch(() => ({ simulation: true, eventLog: false }))
+        setSimEnabled(capabilities.simulation)
+        if (!capabilities.simulation) {
+          setConfigLoaded(true)
+          return
+        }
+        const [status, config, savedPresets] = await Promise.all([
           getSimStatus(token),
           getCallConfig(token).catch(() => null),
+          getSimPresets(token).catch(() => []),
         ])
         setSimStatus(status)
+        setPresets(savedPresets)
+        if (savedPresets.length > 0) setSelectedPreset(savedPresets[0].name)
         if (config) {
           setCallConfig(config)
           syncSlidersFromConfig(config)
@@ -97,10 +113,10 @@ export const AdminControlPanel = ({ onClose }: AdminControlPanelProps) => {
 
   // Poll only status every 2.5s (sliders are user-controlled)
   useEffect(() => {
-    if (!configLoaded) return
+    if (!configLoaded || !simEnabled) return
     const interval = setInterval(pollStatus, 2500)
     return () => clearInterval(interval)
-  }, [configLoaded, pollStatus])
+  }, [configLoaded, simEnabled, pollStatus])
 
   // Auto-revert confirm states after 3s
   useEffect(() => {
@@ -138,7 +154,7 @@ export const AdminControlPanel = ({ onClose }: AdminControlPanelProps) => {
     setActionLoading(name)
     try {
       await fn()
-      await pollStatus()
+      if (simEnabled) await pollStatus()
       if (syncConfig) await refreshConfig()
     } catch (err) {
       showStatus(`Error: ${err instanceof Error ? err.message : 'Unknown'}`)
@@ -186,6 +202,15 @@ export const AdminControlPanel = ({ onClose }: AdminControlPanelProps) => {
     }, true)
   }
 
+  const handleApplyPreset = () => {
+    if (!selectedPreset) return
+    withAction('preset', async () => {
+      const token = await getToken()
+      const result = await applySimPreset(selectedPreset, token)
+      showStatus(`Preset ${result.preset} applied${result.steps ? `, ${result.steps} steps running` : ''}`)
+    }, true)
+  }
+
   const handleInject = () => {
     withAction('inject', async () => {
       const token = await getToken()
@@ -345,73 +370,108 @@ export const AdminControlPanel = ({ onClose }: AdminControlPanelProps) => {
 
         <div style={{ display: 'grid', gridTemplateColumns: '1fr 1fr', gap: '16px' }}>
           {/* A. Simulation Control */}
-          <div style={sectionStyle}>
-            <div style={labelStyle}>Simulation Control</div>
-
-            {/* Status Badge */}
-            <div style={{ display: 'flex', alignItems: 'center', gap: '8px', marginBottom: '12px' }}>
-              <div
-                style={{
-                  width: '10px',
-                  height: '10px',
-                  borderRadius: '50%',
-                  backgroundColor: simStatus?.running ? '#22c55e' : '#6b7280',
-                }}
-              />
-              <span style={{ fontSize: '13px', fontWeight: '600', color: colors.text }}>
-                {simStatus?.running ? 'Running' : 'Stopped'}
-              </span>
-              {simStatus && (
-                <span style={{ fontSize: '11px', color: colors.textSecondary, marginLeft: 'auto' }}>
-                  {simStatus.activeAgents} / {simStatus.totalAgents} agents
-                </span>
-              )}
-            </div>
-
-            {/* Agent Slider + Scale */}
-            {sliderRow('Agents', agentCount, 0, 2000, 10, setAgentCount)}
-            <button
-              onClick={handleScale}
-              disabled={actionLoading !== null || !simStatus?.running}
-              style={btnStyle(colors.primary, actionLoading !== null || !simStatus?.running)}
-            >
-              {actionLoading === 'scale' ? 'Scaling...' : 'Scale'}
-            </button>
-
-            {simStatus?.startedAt && (
-              <div style={{ fontSize: '10px', color: colors.textSecondary, marginTop: '8px' }}>
-                Started: {new Date(simStatus.startedAt).toLocaleTimeString()}
-              </div>
-            )}
-          </div>
-
-          {/* B. Call Generation */}
-          <div style={sectionStyle}>
-            <div style={labelStyle}>Call Generation</div>
+          {simEnabled && (
+            <div style={sectionStyle}>
+              <div style={labelStyle}>Simulation Control</div>
 
-            {DEPARTMENTS.map((dept) => (
-              <div key={dept}>
-                {sliderRow(
-                  dept.charAt(0).toUpperCase() + dept.slice(1),
-                  deptRates[dept] || 0,
-                  0,
-                  200,
-                  1,
-                  (v) => setDeptRates((prev) => ({ ...prev, [dept]: v }))
+              {/* Status Badge */}
+              <div style={{ display: 'flex', alignItems: 'center', gap: '8px', marginBottom: '12px' }}>
+                <div
+                  style={{
+                    width: '10px',
+                    height: '10px',
+                    borderRadius: '50%',
+                    backgroundColor: simStatus?.running ? '#22c55e' : '#6b7280',
+                  }}
+                />
+                <span style={{ fontSize: '13px', fontWeight: '600', color: colors.text }}>
+                  {simStatus?.running ? 'Running' : 'Stopped'}
+                </span>
+                {simStatus && (
+                  <span style={{ fontSize: '11px', color: colors.textSecondary, marginLeft: 'auto' }}>
+                    {simStatus.activeAgents} / {simStatus.totalAgents} agents
+                  </span>
                 )}
               </div>
-            ))}
-            {sliderRow('Peak Factor', peakFactor, 0.1, 5.0, 0.1, setPeakFactor)}
 
-            <div style={{ display: 'flex', gap: '8px', marginBottom: '12px' }}>
+              {/* Agent Slider + Scale */}
+              {sliderRow('Agents', agentCount, 0, 2000, 10, setAgentCount)}
               <button
-                onClick={handleApplyCallConfig}
-                disabled={actionLoading !== null}
-                style={btnStyle(colors.primary, actionLoading !== null)}
+                onClick={handleScale}
+                disabled={actionLoading !== null || !simStatus?.running}
+                style={btnStyle(colors.primary, actionLoading !== null || !simStatus?.running)}
               >
-                {actionLoading === 'callConfig' ? 'Applying...' : 'Apply Config'}
+                {actionLoading === 'scale' ? 'Scaling...' : 'Scale'}
               </button>
+
+              {presets.length > 0 && (
+                <div style={{ display: 'flex', gap: '8px', marginTop: '12px' }}>
+                  <select
+                    value={selectedPreset}
+                    onChange={(e) => setSelectedPreset(e.target.value)}
+                    style={{
+                      padding: '4px 8px',
+                      borderRadius: '4px',
+                      border: `1px solid ${colors.border}`,
+                      fontSize: '11px',
+                      backgroundColor: colors.surface,
+                      color: colors.text,
+                      flex: 1,
+                    }}
+                  >
+                    {presets.map((p) => (
+                      <option key={p.name} value={p.name}>{p.name}</option>
+                    ))}
+                  </select>
+                  <button
+                    onClick={handleApplyPreset}
+                    disabled={actionLoading !== null}
+                    style={btnStyle(colors.primary, actionLoading !== null)}
+                  >
+                    {actionLoading === 'preset' ? 'Applying...' : 'Apply Preset'}
+                  </button>
+                </div>
+              )}
+
+              {simStatus?.startedAt && (
+                <div style={{ fontSize: '10px', color: colors.textSecondary, marginTop: '8px' }}>
+                  Started: {new Date(simStatus.startedAt).toLocaleTimeString()}
+                </div>
+              )}
             </div>
+          )}
+
+          {/* B. Call Generation */}
+          <div style={sectionStyle}>
+            <div style={labelStyle}>Call Generation</div>
+
+            {simEnabled && (
+              <>
+                {DEPARTMENTS.map((dept) => (
+                  <div key={dept}>
+                    {sliderRow(
+                      dept.charAt(0).toUpperCase() + dept.slice(1),
+                      deptRates[dept] || 0,
+                      0,
+                      200,
+                      1,
+                      (v) => setDeptRates((prev) => ({ ...prev, [dept]: v }))
+                    )}
+                  </div>
+                ))}
+                {sliderRow('Peak Factor', peakFactor, 0.1, 5.0, 0.1, setPeakFactor)}
+
+                <div style={{ display: 'flex', gap: '8px', marginBottom: '12px' }}>
+                  <button
+                    onClick={handleApplyCallConfig}
+                    disabled={actionLoading !== null}
+                    style={btnStyle(colors.primary, actionLoading !== null)}
+                  >
+                    {actionLoading === 'callConfig' ? 'Applying...' : 'Apply Config'}
+                  </button>
+                </div>
+              </>
+            )}
 
             {/* Inject Calls */}
             <div style={{ borderTop: `1px solid ${colors.border}`, paddingTop: '12px', marginTop: '4px' }}>
@@ -485,7 +545,7 @@ export const AdminControlPanel = ({ onClose }: AdminControlPanelProps) => {
               {actionLoading === 'logoff' ? 'Logging off...' : 'Log Off All Agents'}
             </button>
             <div style={{ fontSize: '10px', color: colors.textSecondary, marginTop: '6px' }}>
-              Disconnects all agents. The simulation keeps running.
+              {simEnabled ? 'Disconnects all agents. The simulation keeps running.' : 'Clears all tracked agents and calls.'}
             </div>
           </div>
 
@@ -499,25 +559,29 @@ export const AdminControlPanel = ({ onClose }: AdminControlPanelProps) => {
             <div style={{ ...labelStyle, color: '#ef4444' }}>Danger Zone</div>
 
             <div style={{ display: 'flex', flexDirection: 'column', gap: '10px' }}>
-              <div style={{ display: 'flex', gap: '8px' }}>
-                <button
-                  onClick={handleStart}
-                  disabled={actionLoading !== null || simStatus?.running === true}
-                  style={btnStyle('#22c55e', actionLoading !== null || simStatus?.running === true)}
-                >
-                  {actionLoading === 'start' ? 'Starting...' : 'Start Simulation'}
-                </button>
-                <button
-                  onClick={handleStop}
-                  disabled={actionLoading !== null || simStatus?.running === false}
-                  style={btnStyle('#ef4444', actionLoading !== null || simStatus?.running === false)}
-                >
-                  {actionLoading === 'stop' ? 'Stopping...' : 'Stop Simulation'}
-                </button>
-              </div>
-              <div style={{ fontSize: '10px', color: colors.textSecondary }}>
-                Start or stop the entire simulation engine.
-              </div>
+              {simEnabled && (
+                <>
+                  <div style={{ display: 'flex', gap: '8px' }}>
+                    <button
+                      onClick={handleStart}
+                      disabled={actionLoading !== null || simStatus?.running === true}
+                      style={btnStyle('#22c55e', actionLoading !== null || simStatus?.running === true)}
+                    >
+                      {actionLoading === 'start' ? 'Starting...' : 'Start Simulation'}
+                    </button>
+                    <button
+                      onClick={handleStop}
+                      disabled={actionLoading !== null || simStatus?.running === false}
+                      style={btnStyle('#ef4444', actionLoading !== null || simStatus?.running === false)}
+                    >
+                      {actionLoading === 'stop' ? 'Stopping...' : 'Stop Simulation'}
+                    </button>
+                  </div>
+                  <div style={{ fontSize: '10px', color: colors.textSecondary }}>
+                    Start or stop the entire simulation engine.
+                  </div>
+                </>
+              )}
 
               <div style={{ borderTop: `1px solid ${colors.border}`, paddingTop: '10px' }}>
                 <button
@@ -22,6 +22,7 @@ const STATE_COLORS: Record<AgentState, string> = {
   on_hold: '#f59e0b',
   transferring: '#8b5cf6',
   conference: '#3b82f6',
+  multi_session: '#0ea5e9',
 }
 
 const STATE_LABELS: Record<AgentState, string> = {
@@ -37,11 +38,13 @@ const STATE_LABELS: Record<AgentState, string> = {
   on_hold: 'Hold',
   transferring: 'Xfer',
   conference: 'Conf',
+  multi_session: 'Chats',
 }
 
 const ALL_STATES: AgentState[] = [
   'available', 'on_call', 'after_call_work', 'break', 'lunch',
   'meeting', 'training', 'offline', 'busy', 'on_hold', 'transferring', 'conference',
+  'multi_session',
 ]
 
 const formatDuration = (stateStart: string): string => {
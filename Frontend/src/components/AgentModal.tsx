@@ -22,6 +22,7 @@ const STATE_COLORS: Record<AgentState, string> = {
   on_hold: '#f59e0b',
   transferring: '#8b5cf6',
   conference: '#3b82f6',
+  multi_session: '#0ea5e9',
 }
 
 const STATE_LABELS: Record<AgentState, string> = {
@@ -37,6 +38,7 @@ const STATE_LABELS: Record<AgentState, string> = {
   on_hold: 'On Hold',
   transferring: 'Transferring',
   conference: 'Conference',
+  multi_session: 'Multi-Session',
 }
 
 // Format seconds to human readable
@@ -278,6 +280,23 @@ export const AgentModal = ({ agent, onClose }: AgentModalProps) => {
           </button>
         </div>
 
+        {/* Current Call (set by backend routing) */}
+        {agent.currentCallId && (
+          <div style={{ marginBottom: '12px', fontSize: '12px', color: colors.textSecondary }}>
+            On call <strong style={{ color: colors.text }}>{agent.currentCallId}</strong>
+            {agent.currentVq && <> in {agent.currentVq}</>}
+            {agent.callStartTime && (
+              <> for {formatTime(Math.max(0, (Date.now() - new Date(agent.callStartTime).getTime()) / 1000))}</>
+            )}
+            {agent.holdStartTime && (
+              <>, on hold for {formatTime(Math.max(0, (Date.now() - new Date(agent.holdStartTime).getTime()) / 1000))}</>
+            )}
+            {!agent.holdStartTime && !!agent.callHoldTime && (
+              <>, held {formatTime(agent.callHoldTime)}</>
+            )}
+          </div>
+        )}
+
         {/* Active Alerts */}
         {agent.alerts && agent.alerts.length > 0 && (
           <div style={{ marginBottom: '12px', display: 'flex', flexDirection: 'column', gap: '6px' }}>
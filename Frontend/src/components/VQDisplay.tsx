@@ -101,6 +101,7 @@ export const VQDisplay = ({ department, queues }: VQDisplayProps) => {
               >
                 <span>W:{q.waitingCount}</span>
                 <span>A:{q.activeCount}</span>
+                {q.onHoldCount > 0 && <span>H:{q.onHoldCount}</span>}
                 <span>
                   {q.longestWaitSecs > 0
                     ? `${Math.round(q.longestWaitSecs)}s`
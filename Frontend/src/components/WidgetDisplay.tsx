@@ -23,6 +23,7 @@ const STATE_COLORS: Record<AgentState, string> = {
   on_hold: '#f59e0b',
   transferring: '#8b5cf6',
   conference: '#3b82f6',
+  multi_session: '#0ea5e9',
 }
 
 const STATE_LABELS: Record<AgentState, string> = {
@@ -38,6 +39,7 @@ const STATE_LABELS: Record<AgentState, string> = {
   on_hold: 'On Hold',
   transferring: 'Transferring',
   conference: 'Conference',
+  multi_session: 'Multi-Session',
 }
 
 export const WidgetDisplay = ({ widget, onAgentClick, selectedState, onStateFilter, showOffline = true }: WidgetDisplayProps) => {
@@ -15,6 +15,8 @@ export interface ThemeColors {
   error: string
   errorBg: string
   errorBorder: string
+  warningBg: string
+  warningBorder: string
   // KPI highlight
   highlightBg: string
   highlightBorder: string
@@ -33,6 +35,8 @@ const themes: Record<Theme, ThemeColors> = {
     error: '#991b1b',
     errorBg: '#fef2f2',
     errorBorder: '#fecaca',
+    warningBg: '#fffbeb',
+    warningBorder: '#fde68a',
     highlightBg: '#f0f9ff',
     highlightBorder: '#bae6fd',
   },
@@ -48,6 +52,8 @@ const themes: Record<Theme, ThemeColors> = {
     error: '#fca5a5',
     errorBg: '#450a0a',
     errorBorder: '#7f1d1d',
+    warningBg: '#451a03',
+    warningBorder: '#92400e',
     highlightBg: '#1e3a5f',
     highlightBorder: '#2563eb',
   },
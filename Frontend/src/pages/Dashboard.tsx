@@ -9,7 +9,7 @@ import { AdminControlPanel } from '../components/AdminControlPanel'
 import { ThemeToggle } from '../components/ThemeToggle'
 import { useAuth } from '../contexts/AuthContext'
 import { useTheme } from '../contexts/ThemeContext'
-import { Snapshot, SnapshotHistory, Location, AgentInfo, AgentState, Department, VQSnapshot } from '../types'
+import { Snapshot, SnapshotHistory, ControlMessage, Announcement, AnnouncementSeverity, Location, AgentInfo, AgentState, Department, VQSnapshot } from '../types'
 import { useState, useEffect, useMemo, useCallback } from 'react'
 
 const WS_URL = import.meta.env.VITE_WS_URL || 'ws://localhost:8080/ws'
@@ -141,6 +141,33 @@ export const Dashboard = () => {
     handleSnapshotHistory()
   }, [handleSnapshotHistory])
 
+  // Handle control messages pushed by admins
+  const [notice, setNotice] = useState<string | null>(null)
+  useEffect(() => {
+    // eslint-disable-next-line @typescript-eslint/no-explicit-any
+    const message = data as any
+    if (message?.type !== 'control') return
+    const control = message as ControlMessage
+    if (control.action === 'reload') {
+      window.location.reload()
+    } else if (control.action === 'notice' && control.message) {
+      setNotice(control.message)
+    }
+  }, [data])
+
+  // Announcements always follow the live stream, also while the history is paused
+  const [announcements, setAnnouncements] = useState<Announcement[]>([])
+  useEffect(() => {
+    if (incomingSnapshot) setAnnouncements(incomingSnapshot.announcements ?? [])
+  }, [incomingSnapshot])
+  // Reduced fidelity under server load, from the live stream like announcements
+  const degradation = incomingSnapshot?.degradation
+  const announcementColors: Record<AnnouncementSeverity, { bg: string; border: string }> = {
+    info: { bg: colors.highlightBg, border: colors.highlightBorder },
+    warning: { bg: colors.warningBg, border: colors.warningBorder },
+    critical: { bg: colors.errorBg, border: colors.errorBorder },
+  }
+
   const [selectedCity, setSelectedCity] = useState<Location | 'all'>('all')
   const [visibleError, setVisibleError] = useState<string | null>(null)
   const [selectedAgent, setSelectedAgent] = useState<AgentInfo | null>(null)
@@ -318,6 +345,85 @@ export const Dashboard = () => {
           </div>
         </div>
 
+        {/* Announcements */}
+        {announcements.map((a) => (
+          <div
+            key={a.id}
+            style={{
+              padding: '12px',
+              backgroundColor: announcementColors[a.severity].bg,
+              border: `1px solid ${announcementColors[a.severity].border}`,
+              borderRadius: '6px',
+              marginBottom: '12px',
+              color: colors.text,
+              fontSize: '12px',
+            }}
+          >
+            <strong>{a.severity === 'info' ? 'Announcement' : a.severity === 'warning' ? 'Warning' : 'Critical'}:</strong> {a.text}
+          </div>
+        ))}
+
+        {/* Load Shedding */}
+        {degradation && (
+          <div
+            style={{
+              padding: '12px',
+              backgroundColor: colors.warningBg,
+              border: `1px solid ${colors.warningBorder}`,
+              borderRadius: '6px',
+              marginBottom: '12px',
+              color: colors.text,
+              fontSize: '12px',
+            }}
+          >
+            <strong>Reduced fidelity:</strong> the server is under heavy load and sends updates every{' '}
+            {degradation.intervalSeconds}s.
+            {degradation.level === 'summary' && (
+              <>
+                {' '}Agent tiles are paused; agents per department:{' '}
+                {allDepartments
+                  .map((d) => `${d} ${incomingSnapshot?.departments[d]?.summary?.totalAgents ?? 0}`)
+                  .join(' · ')}
+              </>
+            )}
+          </div>
+        )}
+
+        {/* Admin Notice */}
+        {notice && (
+          <div
+            style={{
+              padding: '12px',
+              backgroundColor: colors.highlightBg,
+              border: `1px solid ${colors.highlightBorder}`,
+              borderRadius: '6px',
+              marginBottom: '12px',
+              color: colors.text,
+              fontSize: '12px',
+              display: 'flex',
+              justifyContent: 'space-between',
+              alignItems: 'center',
+            }}
+          >
+            <span>
+              <strong>Notice:</strong> {notice}
+            </span>
+            <button
+              onClick={() => setNotice(null)}
+              style={{
+                background: 'none',
+                border: 'none',
+                color: colors.text,
+                cursor: 'pointer',
+                fontSize: '18px',
+                padding: '0 4px',
+              }}
+            >
+              ×
+            </button>
+          </div>
+        )}
+
         {/* Error Display */}
         {visibleError && (
           <div
@@ -1,4 +1,4 @@
-import { AgentDailyStats, CallRecord, SimStatus, CallConfig } from '../types'
+import { AgentDailyStats, CallRecord, SimStatus, CallConfig, Capabilities, SimPreset } from '../types'
 
 // VITE_API_URL already includes /api (e.g. http://localhost:8080/api)
 // Fallback strips it to keep paths consistent
@@ -76,12 +76,36 @@ const adminHeaders = (token: string | null): HeadersInit => ({
   ...(token ? { Authorization: `Bearer ${token}` } : {}),
 })
 
+export const getCapabilities = async (token: string | null): Promise<Capabilities> => {
+  const res = await fetch(`${API_BASE}/api/admin/capabilities`, { headers: adminHeaders(token) })
+  if (!res.ok) throw new Error(`Failed to get capabilities: ${res.statusText}`)
+  return res.json()
+}
+
 export const getSimStatus = async (token: string | null): Promise<SimStatus> => {
   const res = await fetch(`${API_BASE}/api/admin/sim/status`, { headers: adminHeaders(token) })
   if (!res.ok) throw new Error(`Failed to get sim status: ${res.statusText}`)
   return res.json()
 }
 
+export const getSimPresets = async (token: string | null): Promise<SimPreset[]> => {
+  const res = await fetch(`${API_BASE}/api/admin/sim/presets`, { headers: adminHeaders(token) })
+  if (!res.ok) throw new Error(`Failed to get sim presets: ${res.statusText}`)
+  return res.json()
+}
+
+export const applySimPreset = async (
+  name: string,
+  token: string | null
+): Promise<{ preset: string; callConfig: boolean; agents?: string; steps: number }> => {
+  const res = await fetch(`${API_BASE}/api/admin/sim/presets/${encodeURIComponent(name)}/apply`, {
+    method: 'POST',
+    headers: adminHeaders(token),
+  })
+  if (!res.ok) throw new Error(`Failed to apply sim preset: ${res.statusText}`)
+  return res.json()
+}
+
 export const startSim = async (activeAgents: number, token: string | null): Promise<void> => {
   const res = await fetch(`${API_BASE}/api/admin/sim/start`, {
     method: 'POST',
@@ -102,4 +102,29 @@ describe('WebSocketService', () => {
     // eslint-disable-next-line @typescript-eslint/no-explicit-any
     expect((ws as any).messageHandlers.has(handler)).toBe(false)
   })
+
+  it('should correlate request results by requestId', async () => {
+    await new Promise<void>((resolve) => {
+      ws.onStateChange((state) => state === ConnectionState.OPEN && resolve())
+      ws.connect()
+    })
+    // eslint-disable-next-line @typescript-eslint/no-explicit-any
+    const mockWs = (ws as any).ws
+    const sent: string[] = []
+    mockWs.send = (data: string) => sent.push(data)
+
+    const first = ws.request({ type: 'get_agent', agentId: 'a1' })
+    const second = ws.request({ type: 'get_snapshot' })
+    const [req1, req2] = sent.map((data) => JSON.parse(data))
+    expect(req1).toMatchObject({ type: 'get_agent', agentId: 'a1' })
+    expect(req1.requestId).not.toEqual(req2.requestId)
+
+    const answer = (result: object) =>
+      mockWs.onmessage(new MessageEvent('message', { data: JSON.stringify({ type: 'command_result', ...result }) }))
+    answer({ command: 'get_snapshot', requestId: req2.requestId, ok: false, error: 'no snapshot yet' })
+    answer({ command: 'get_agent', requestId: req1.requestId, ok: true, agent: { agentId: 'a1' } })
+
+    await expect(first).resolves.toMatchObject({ agent: { agentId: 'a1' } })
+    await expect(second).rejects.toThrow('no snapshot yet')
+  })
 })
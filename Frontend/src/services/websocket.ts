@@ -1,4 +1,4 @@
-import { ConnectionState, WebSocketError } from '../types'
+import { ConnectionState, WebSocketError, ClientRequest, CommandResult } from '../types'
 
 type MessageHandler = (data: unknown) => void
 type StateChangeHandler = (state: ConnectionState) => void
@@ -7,6 +7,10 @@ type ErrorHandler = (error: WebSocketError) => void
 const INITIAL_RETRY_DELAY = 1000 // 1 second
 const MAX_RETRY_DELAY = 30000 // 30 seconds
 const BACKOFF_MULTIPLIER = 1.5
+const REQUEST_TIMEOUT = 10000 // 10 seconds
+
+// Snapshot schema versions this dashboard understands; the server sends the newest it supports
+const SNAPSHOT_SCHEMA_VERSIONS = [1, 2]
 
 export class WebSocketService {
   private ws: WebSocket | null = null
@@ -19,6 +23,8 @@ export class WebSocketService {
   private reconnectAttempts = 0
   private reconnectTimeout: number | null = null
   private shouldReconnect = true
+  private nextRequestId = 0
+  private pendingRequests: Map<string, { resolve: (result: CommandResult) => void; reject: (error: Error) => void; timeout: number }> = new Map()
 
   constructor(baseUrl: string, getToken: () => Promise<string | null>) {
     this.baseUrl = baseUrl
@@ -42,7 +48,7 @@ export class WebSocketService {
         return
       }
 
-      const wsUrl = `${this.baseUrl}?token=${encodeURIComponent(token)}`
+      const wsUrl = `${this.baseUrl}?token=${encodeURIComponent(token)}&schemaVersions=${SNAPSHOT_SCHEMA_VERSIONS.join(',')}`
       this.ws = new WebSocket(wsUrl)
 
       this.ws.onopen = () => {
@@ -53,7 +59,14 @@ export class WebSocketService {
       this.ws.onmessage = (event) => {
         try {
           const data = JSON.parse(event.data)
+          if (data?.type === 'command_result' && data.requestId && this.settleRequest(data)) {
+            return
+          }
           this.messageHandlers.forEach((handler) => handler(data))
+          // Acknowledge snapshots so operators can spot lagging dashboards
+          if (data?.type === 'snapshot' && typeof data.seq === 'number') {
+            this.ack(data.seq)
+          }
         } catch (error) {
           // Log parse errors to console instead of showing to user
           console.debug('[WebSocket] Failed to parse message:', event.data)
@@ -69,6 +82,7 @@ export class WebSocketService {
 
       this.ws.onclose = (event) => {
         this.updateState(ConnectionState.CLOSED)
+        this.rejectPendingRequests('WebSocket closed')
 
         if (this.shouldReconnect && !event.wasClean) {
           this.scheduleReconnect()
@@ -104,6 +118,50 @@ export class WebSocketService {
     }
   }
 
+  // Sends an on-demand request and resolves with its command_result; rejects when the server
+  // answers ok: false, the connection closes or no answer arrives within REQUEST_TIMEOUT
+  request(req: ClientRequest): Promise<CommandResult> {
+    if (!this.ws || this.ws.readyState !== WebSocket.OPEN) {
+      return Promise.reject(new Error('Cannot send request: WebSocket is not open'))
+    }
+    const requestId = `r${++this.nextRequestId}`
+    return new Promise((resolve, reject) => {
+      const timeout = window.setTimeout(() => {
+        this.pendingRequests.delete(requestId)
+        reject(new Error(`Request ${req.type} timed out`))
+      }, REQUEST_TIMEOUT)
+      this.pendingRequests.set(requestId, { resolve, reject, timeout })
+      this.ws!.send(JSON.stringify({ ...req, requestId }))
+    })
+  }
+
+  private settleRequest(result: CommandResult): boolean {
+    const pending = this.pendingRequests.get(result.requestId!)
+    if (!pending) return false
+    this.pendingRequests.delete(result.requestId!)
+    clearTimeout(pending.timeout)
+    if (result.ok) {
+      pending.resolve(result)
+    } else {
+      pending.reject(new Error(result.error || `Request ${result.command} failed`))
+    }
+    return true
+  }
+
+  private rejectPendingRequests(reason: string): void {
+    this.pendingRequests.forEach((pending) => {
+      clearTimeout(pending.timeout)
+      pending.reject(new Error(reason))
+    })
+    this.pendingRequests.clear()
+  }
+
+  private ack(seq: number): void {
+    if (this.ws && this.ws.readyState === WebSocket.OPEN) {
+      this.ws.send(JSON.stringify({ type: 'ack', seq }))
+    }
+  }
+
   onMessage(handler: MessageHandler): () => void {
     this.messageHandlers.add(handler)
     return () => this.messageHandlers.delete(handler)
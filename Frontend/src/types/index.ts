@@ -33,6 +33,7 @@ export type AgentState =
   | 'on_hold'
   | 'transferring'
   | 'conference'
+  | 'multi_session'
 
 // Department
 export type Department = 'sales' | 'support' | 'technical' | 'retention'
@@ -98,6 +99,8 @@ export interface AgentInfo {
   currentCallId?: string   // active call ID
   currentVq?: VQName       // VQ of active call
   callStartTime?: string   // when current call started
+  callHoldTime?: number    // seconds of the ended holds of the current call
+  holdStartTime?: string   // when the current hold started, while the call is on hold
   acwStartTime?: string    // when ACW started
   breakStartTime?: string  // when break started
   alerts?: AgentAlert[]    // active alerts
@@ -145,6 +148,7 @@ export interface VQSnapshot {
   department: Department
   waitingCount: number
   activeCount: number
+  onHoldCount: number
   completedCount: number
   abandonedCount: number
   longestWaitSecs: number
@@ -164,14 +168,48 @@ export interface VQWidget {
 export interface DepartmentData {
   agents: AgentInfo[]
   queues: VQSnapshot[]
+  summary?: DepartmentSummary // sent instead of agents while the server is degraded to summary-only
+}
+
+// DepartmentSummary - agent counts of a department
+export interface DepartmentSummary {
+  totalAgents: number
+  stateBreakdown: Partial<Record<AgentState, number>>
+  locationBreakdown?: Partial<Record<Location, number>>
+}
+
+// Degradation - set while the server sheds load and snapshots have reduced fidelity
+export interface Degradation {
+  level: 'reduced' | 'summary'
+  since: string
+  reason: 'aggregation' | 'broadcast'
+  intervalSeconds: number // time between snapshots at this level
 }
 
 // Snapshot - single payload from backend every tick
 // Contains all agents and all queues grouped by department
 export interface Snapshot {
   type: 'snapshot'
+  schemaVersion?: number // absent in version 1
   timestamp: string
+  seq?: number // broadcast sequence number, acknowledged back to the server
   departments: Record<Department, DepartmentData>
+  announcements?: Announcement[] // most severe first
+  degradation?: Degradation // absent at full fidelity
+}
+
+// Announcement - shift-wide notice published by an admin
+export type AnnouncementSeverity = 'info' | 'warning' | 'critical'
+
+export interface Announcement {
+  id: string
+  text: string
+  severity: AnnouncementSeverity
+  departments?: Department[]
+  locations?: Location[]
+  createdAt: string
+  createdBy?: string
+  expiresAt?: string
 }
 
 // Call Record - completed call for history
@@ -202,6 +240,22 @@ export interface SimStatus {
   eventsSent?: number
 }
 
+// Optional features of the backend deployment (GET /api/admin/capabilities)
+export interface Capabilities {
+  simulation: boolean // AgentSim configured: sim controls and call generation work
+  eventLog: boolean
+}
+
+// Named sim setup saved on the backend (GET /api/admin/sim/presets)
+export interface SimPreset {
+  name: string
+  activeAgents?: number
+  peakHourFactor?: number
+  callRates?: Record<string, number> // calls per minute per department
+  steps?: { action: string; agents?: number; count?: number; vq?: string; duration?: string }[]
+  updatedAt: string
+}
+
 // Call generation config from AgentSim
 export interface CallConfig {
   peakHourFactor: number
@@ -214,6 +268,45 @@ export interface SnapshotHistory {
   snapshots: Snapshot[]
 }
 
+// On-demand requests over /ws, answered by a CommandResult with the same requestId
+export type ClientRequest =
+  | { type: 'get_snapshot' }
+  | { type: 'get_agent'; agentId: string }
+  | { type: 'get_queue_history'; vq: VQName; minutes?: number; intervalMinutes?: number }
+
+// Traffic of a VQ in one completed interval
+export interface IntervalStat {
+  vq: VQName
+  start: string
+  end: string
+  offered: number
+  handled: number
+  abandoned: number
+  ahtSecs: number
+}
+
+// Answer to a client command or request
+export interface CommandResult {
+  type: 'command_result'
+  command: string
+  requestId?: string
+  agentId?: string
+  ok: boolean
+  error?: string
+  schemaVersion?: number
+  snapshot?: Snapshot       // get_snapshot
+  agent?: AgentInfo         // get_agent
+  intervals?: IntervalStat[] // get_queue_history
+}
+
+// Control message pushed by admins: reload the dashboard or show a notice
+export interface ControlMessage {
+  type: 'control'
+  action: 'reload' | 'notice'
+  message?: string
+  timestamp: string
+}
+
 // Playback mode for snapshot time machine
 export type PlaybackMode = 'live' | 'paused' | 'scrubbing'
 
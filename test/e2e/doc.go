@@ -0,0 +1,9 @@
+// Package e2e runs the backend and AgentSim together in one process and drives them through the
+// backend's public API, so protocol changes on either side that break the other fail in CI
+// instead of in a deployment.
+//
+// The backend runs with DYNAMO_MODE=memory and SKIP_AUTH=true; nothing outside the test process
+// is needed:
+//
+//	cd test/e2e && go test ./...
+package e2e
@@ -0,0 +1,148 @@
+package e2e
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/backend/pkg/client"
+)
+
+// TestSimulationLifecycle drives AgentSim through the backend's admin API and checks what the
+// backend makes of it: connected agents in the snapshots, calls routed to simulated agents,
+// a force-ended call reaching AgentSim and its record persisted, and a clean logoff on stop.
+func TestSimulationLifecycle(t *testing.T) {
+	if testing.Short() {
+		t.Skip("boots the backend and AgentSim")
+	}
+	s := startStack(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	// AgentSim pre-registers its whole roster over /internal/agents/roster
+	var rosterSize int
+	eventually(t, "AgentSim's roster", func() bool {
+		agents, err := s.client.ListAgents(ctx, nil)
+		if err == nil {
+			rosterSize = agents.Count
+		}
+		return rosterSize > 0
+	})
+
+	// Start and scale go through the backend's admin proxy to AgentSim's control API
+	if err := s.client.Start(ctx, 20); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	snap := s.waitSnapshot(t, "20 connected agents", func(snap *client.Snapshot) bool {
+		n, _ := connectedAgents(snap)
+		return n == 20
+	})
+	for dept, data := range snap.Departments {
+		for _, a := range data.Agents {
+			if a.Department != dept {
+				t.Errorf("agent %s of %s listed under %s", a.AgentID, a.Department, dept)
+			}
+		}
+		if len(data.Queues) == 0 {
+			t.Errorf("department %s has no queues in the snapshot", dept)
+		}
+	}
+	status, err := s.client.GetStatus(ctx)
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if !status.Running || status.ActiveAgents != 20 || status.TotalAgents != rosterSize {
+		t.Errorf("sim status = %+v, want running with 20 of %d agents", status, rosterSize)
+	}
+	if local := s.sim.Status(); !local.Running || local.ActiveAgents != 20 {
+		t.Errorf("embedded AgentSim status = %+v, want running with 20 agents", local)
+	}
+
+	if err := s.client.Scale(ctx, 60); err != nil {
+		t.Fatalf("scale: %v", err)
+	}
+	s.waitSnapshot(t, "60 connected agents in every department", func(snap *client.Snapshot) bool {
+		n, byDept := connectedAgents(snap)
+		return n == 60 && len(byDept) == len(snap.Departments)
+	})
+
+	// Injected calls are routed to available simulated agents, which accept them
+	injected, err := s.client.Inject(ctx, 40, "")
+	if err != nil {
+		t.Fatalf("inject: %v", err)
+	}
+	if injected.Injected != 40 {
+		t.Fatalf("injected %d calls, want 40", injected.Injected)
+	}
+	var onCall client.AgentInfo
+	s.waitSnapshot(t, "an agent on an active call", func(snap *client.Snapshot) bool {
+		for _, data := range snap.Departments {
+			active := 0
+			for _, q := range data.Queues {
+				active += q.ActiveCount
+			}
+			for _, a := range data.Agents {
+				if a.State == "on_call" && a.CurrentCallID != "" && active > 0 {
+					onCall = a
+					return true
+				}
+			}
+		}
+		return false
+	})
+	// Agents only count events once they change state, e.g. by taking a call
+	if local := s.sim.Status(); local.EventsSent == 0 {
+		t.Errorf("embedded AgentSim status = %+v, want agents that sent events", local)
+	}
+
+	// A supervisor force-end reaches AgentSim over the agent WebSocket and completes the call
+	ended, err := s.client.ForceEndCall(ctx, onCall.AgentID, onCall.CurrentCallID)
+	if err != nil {
+		t.Fatalf("force-end %s: %v", onCall.CurrentCallID, err)
+	}
+	if ended.CallID != onCall.CurrentCallID {
+		t.Errorf("force-end acknowledged call %q, want %q", ended.CallID, onCall.CurrentCallID)
+	}
+	s.waitSnapshot(t, "the agent to leave the force-ended call", func(snap *client.Snapshot) bool {
+		for _, a := range snap.Departments[onCall.Department].Agents {
+			if a.AgentID == onCall.AgentID {
+				return a.CurrentCallID != onCall.CurrentCallID
+			}
+		}
+		return false
+	})
+
+	// Call records are keyed by enqueue date
+	var record client.CallRecord
+	eventually(t, "the force-ended call's record", func() bool {
+		for _, day := range []time.Time{time.Now(), time.Now().Add(-24 * time.Hour)} {
+			records, err := s.client.GetAgentCalls(ctx, onCall.AgentID, url.Values{"date": {day.Format("2006-01-02")}})
+			if err != nil {
+				t.Fatalf("agent calls: %v", err)
+			}
+			for _, r := range records {
+				if r.CallID == onCall.CurrentCallID {
+					record = r
+					return true
+				}
+			}
+		}
+		return false
+	})
+	if record.VQ != onCall.CurrentVQ || record.Department != string(onCall.Department) {
+		t.Errorf("record = %+v, want VQ %s in %s", record, onCall.CurrentVQ, onCall.Department)
+	}
+	if record.AssignTime == "" || record.CompleteTime == "" || record.Abandoned {
+		t.Errorf("record = %+v, want an answered, completed call", record)
+	}
+
+	// Stopping closes AgentSim's connections; the backend logs every agent off
+	if err := s.client.Stop(ctx); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	s.waitSnapshot(t, "no connected agents", func(snap *client.Snapshot) bool {
+		n, _ := connectedAgents(snap)
+		return n == 0
+	})
+}
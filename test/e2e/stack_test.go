@@ -0,0 +1,165 @@
+package e2e
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dennisdiepolder/monti/agentsim/pkg/sim"
+	"github.com/dennisdiepolder/monti/backend/pkg/client"
+	"github.com/dennisdiepolder/monti/backend/pkg/server"
+	"github.com/rs/zerolog"
+)
+
+const (
+	internalToken = "e2e-internal-token"
+
+	// waitTimeout bounds every wait for the two services to converge
+	waitTimeout = 20 * time.Second
+	pollEvery   = 100 * time.Millisecond
+)
+
+// stack is a backend and an AgentSim wired to each other on loopback ports, plus an API client
+// and a dashboard subscription on the backend
+type stack struct {
+	client *client.Client
+	sim    *sim.App // the embedded AgentSim, for checks without going through the backend
+
+	mu       sync.Mutex
+	snapshot *client.Snapshot // latest snapshot received on /ws
+}
+
+// startStack boots the backend and AgentSim and subscribes to the backend's snapshots. Both
+// are shut down when the test ends, AgentSim first so its agents disconnect from a live backend.
+func startStack(t *testing.T) *stack {
+	t.Helper()
+
+	backendLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	simLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	backendURL := "http://" + backendLn.Addr().String()
+
+	// The backend reads its configuration from the environment only
+	for key, value := range map[string]string{
+		"CONFIG_FILE":                   os.DevNull,
+		"DYNAMO_MODE":                   "memory",
+		"SKIP_AUTH":                     "true",
+		"INTERNAL_AUTH_TOKEN":           internalToken,
+		"AGENTSIM_URL":                  "http://" + simLn.Addr().String(),
+		"LOG_LEVEL":                     "warn",
+		"ROUTING_INTERVAL":              "200ms",
+		"RECONNECT_GRACE":               "0",
+		"LOAD_SHED_AGGREGATION_LATENCY": "0",
+		"LOAD_SHED_BROADCAST_LATENCY":   "0",
+	} {
+		t.Setenv(key, value)
+	}
+	logger := zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).With().Timestamp().Logger()
+
+	backend, err := server.New(logger.With().Str("service", "backend").Logger())
+	if err != nil {
+		t.Fatalf("start backend: %v", err)
+	}
+	go backend.Serve(backendLn)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		backend.Shutdown(ctx)
+	})
+
+	simCtx, simCancel := context.WithCancel(context.Background())
+	app, err := sim.New(simCtx, sim.Options{
+		BackendURL:    backendURL,
+		InternalToken: internalToken,
+		Seed:          1,
+	}, logger.With().Str("service", "agentsim").Logger())
+	if err != nil {
+		simCancel()
+		t.Fatalf("start AgentSim: %v", err)
+	}
+	simServer := &http.Server{Handler: app.Handler()}
+	go simServer.Serve(simLn)
+	t.Cleanup(func() {
+		simCancel()
+		simServer.Close()
+	})
+
+	s := &stack{client: client.NewClient(backendURL), sim: app}
+	subCtx, subCancel := context.WithCancel(context.Background())
+	sub := s.client.NewSubscriber(client.StreamHandlers{
+		Snapshot: func(snap *client.Snapshot) {
+			s.mu.Lock()
+			s.snapshot = snap
+			s.mu.Unlock()
+		},
+	})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sub.Run(subCtx)
+	}()
+	t.Cleanup(func() {
+		subCancel()
+		<-done
+	})
+	return s
+}
+
+// waitSnapshot polls the latest snapshot until cond holds, failing the test after waitTimeout
+func (s *stack) waitSnapshot(t *testing.T, what string, cond func(*client.Snapshot) bool) *client.Snapshot {
+	t.Helper()
+	var last *client.Snapshot
+	deadline := time.Now().Add(waitTimeout)
+	for {
+		s.mu.Lock()
+		last = s.snapshot
+		s.mu.Unlock()
+		if last != nil && cond(last) {
+			return last
+		}
+		if time.Now().After(deadline) {
+			if last == nil {
+				t.Fatalf("timed out after %s waiting for %s: no snapshot received", waitTimeout, what)
+			}
+			total, byDept := connectedAgents(last)
+			t.Fatalf("timed out after %s waiting for %s: last snapshot had %d connected agents %v",
+				waitTimeout, what, total, byDept)
+		}
+		time.Sleep(pollEvery)
+	}
+}
+
+// eventually polls cond until it holds, failing the test after waitTimeout
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(waitTimeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out after %s waiting for %s", waitTimeout, what)
+		}
+		time.Sleep(pollEvery)
+	}
+}
+
+// connectedAgents counts the snapshot's connected agents per department
+func connectedAgents(snap *client.Snapshot) (total int, byDept map[client.Department]int) {
+	byDept = make(map[client.Department]int)
+	for dept, data := range snap.Departments {
+		for _, a := range data.Agents {
+			if a.ConnectionStatus == "connected" {
+				byDept[dept]++
+				total++
+			}
+		}
+	}
+	return total, byDept
+}